@@ -1,38 +1,129 @@
 package foneroplugin
 
-import "encoding/json"
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
 
 // Plugin settings, kinda doesn;t go here but for now it is fine
 const (
-	Version                  = "1"
-	ID                       = "fonero"
-	CmdAuthorizeVote         = "authorizevote"
-	CmdStartVote             = "startvote"
-	CmdVoteDetails           = "votedetails"
-	CmdVoteSummary           = "votesummary"
-	CmdLoadVoteResults       = "loadvoteresults"
-	CmdBallot                = "ballot"
-	CmdBestBlock             = "bestblock"
-	CmdNewComment            = "newcomment"
-	CmdLikeComment           = "likecomment"
-	CmdCensorComment         = "censorcomment"
-	CmdGetComment            = "getcomment"
-	CmdGetComments           = "getcomments"
-	CmdProposalVotes         = "proposalvotes"
-	CmdCommentLikes          = "commentlikes"
-	CmdProposalCommentsLikes = "proposalcommentslikes"
-	CmdInventory             = "inventory"
-	CmdTokenInventory        = "tokeninventory"
-	MDStreamAuthorizeVote    = 13 // Vote authorization by proposal author
-	MDStreamVoteBits         = 14 // Vote bits and mask
-	MDStreamVoteSnapshot     = 15 // Vote tickets and start/end parameters
+	Version                       = "1"
+	ID                            = "fonero"
+	CmdAuthorizeVote              = "authorizevote"
+	CmdStartVote                  = "startvote"
+	CmdVoteDetails                = "votedetails"
+	CmdVoteSummary                = "votesummary"
+	CmdCountVotesByOption         = "countvotesbyoption"
+	CmdVotesByBlockWindow         = "votesbyblockwindow"
+	CmdProposalSupportersCount    = "proposalsupporterscount"
+	CmdGetVoteStatus              = "getvotestatus"
+	CmdLoadVoteResults            = "loadvoteresults"
+	CmdBallot                     = "ballot"
+	CmdBestBlock                  = "bestblock"
+	CmdNewComment                 = "newcomment"
+	CmdLikeComment                = "likecomment"
+	CmdLikeCommentUndo            = "likecommentundo"
+	CmdCensorComment              = "censorcomment"
+	CmdSetCommentVisibility       = "setcommentvisibility"
+	CmdReparentComment            = "reparentcomment"
+	CmdGetComment                 = "getcomment"
+	CmdGetCommentByReceipt        = "getcommentbyreceipt"
+	CmdGetComments                = "getcomments"
+	CmdGetCommentAncestors        = "getcommentancestors"
+	CmdGetCommentsSince           = "getcommentssince"
+	CmdGetCommentVersions         = "getcommentversions"
+	CmdProposalVotes              = "proposalvotes"
+	CmdCommentLikes               = "commentlikes"
+	CmdProposalCommentsLikes      = "proposalcommentslikes"
+	CmdInventory                  = "inventory"
+	CmdTokenInventory             = "tokeninventory"
+	CmdCensoredComments           = "censoredcomments"
+	CmdVoteEligibility            = "voteeligibility"
+	CmdGetRecordTimestampRange    = "getrecordtimestamprange"
+	CmdRecordsByStatus            = "recordsbystatus"
+	CmdRecordHistory              = "recordhistory"
+	CmdProposalCommentsLikeCounts = "proposalcommentslikecounts"
+	CmdVoteExport                 = "voteexport"
+	CmdVoteAuthorizationCheck     = "voteauthorizationcheck"
+	CmdRecomputeVoteResults       = "recomputevoteresults"
+	CmdArchiveProposalVotes       = "archiveproposalvotes"
+	CmdCommentThreadStats         = "commentthreadstats"
+	CmdActivityWindow             = "activitywindow"
+	CmdCommentRate                = "commentrate"
+	CmdVerifyCounts               = "verifycounts"
+	CmdGetProposalMetadata        = "getproposalmetadata"
+	CmdTopComments                = "topcomments"
+	CmdEligibleTickets            = "eligibletickets"
+	CmdGetStartVoteReply          = "getstartvotereply"
+	CmdVoteResultsDigest          = "voteresultsdigest"
+	CmdRecordFilesManifest        = "recordfilesmanifest"
+	CmdGetLatestRecordVersion     = "getlatestrecordversion"
+	CmdCommentsModifiedSince      = "commentsmodifiedsince"
+	CmdProposalVoteReceipts       = "proposalvotereceipts"
+	CmdGetCommentsForProposals    = "getcommentsforproposals"
+	CmdInventoryDigest            = "inventorydigest"
+	CmdCommentedProposals         = "commentedproposals"
+	CmdExpireActiveVotes          = "expireactivevotes"
+	CmdGetVoteOptions             = "getvoteoptions"
+	CmdCommentLikesNetScore       = "commentlikesnetscore"
+	CmdRecordStatusCounts         = "recordstatuscounts"
+	CmdVoteResultsByStatus        = "voteresultsbystatus"
+	CmdArchiveStats               = "archivestats"
+	CmdGetEligibleTicketCount     = "geteligibleticketcount"
+	CmdProposalActivityTimeline   = "proposalactivitytimeline"
+	MDStreamAuthorizeVote         = 13 // Vote authorization by proposal author
+	MDStreamVoteBits              = 14 // Vote bits and mask
+	MDStreamVoteSnapshot          = 15 // Vote tickets and start/end parameters
+
+	// CommentAncestorsMaxDepth is the maximum number of ancestors that
+	// are returned by the getcommentancestors command.
+	CommentAncestorsMaxDepth = 64
 
 	VoteDurationMin = 2016 // Minimum vote duration (in blocks)
 	VoteDurationMax = 4032 // Maximum vote duration (in blocks)
 
+	// Like comment actions.  An undo removes the current up or downvote
+	// of a user on a comment.
+	LikeActionUpvote   = "1"
+	LikeActionDownvote = "-1"
+	LikeActionUndo     = "0"
+
+	// Verify counts tables
+	VerifyCountsRecords   = "records"   // Number of records
+	VerifyCountsComments  = "comments"  // Number of comments
+	VerifyCountsCastVotes = "castvotes" // Number of cast votes
+
 	// Authorize vote actions
 	AuthVoteActionAuthorize = "authorize" // Authorize a proposal vote
 	AuthVoteActionRevoke    = "revoke"    // Revoke a proposal vote authorization
+
+	// Vote types
+	//
+	// VoteTypeStandard is a yes/no vote where the outcome is decided by
+	// the pass percentage of the "yes" option.
+	//
+	// VoteTypeApproval is a multi-option vote where each option is
+	// approved or rejected against its own pass percentage of the total
+	// turnout.
+	VoteTypeStandard = 0
+	VoteTypeApproval = 1
+)
+
+// VoteStatusT represents the lifecycle status of a proposal vote.
+type VoteStatusT int
+
+const (
+	// Vote statuses
+	VoteStatusInvalid       VoteStatusT = 0 // Invalid vote status
+	VoteStatusNotAuthorized VoteStatusT = 1 // Vote has not been authorized by author
+	VoteStatusAuthorized    VoteStatusT = 2 // Vote has been authorized by author
+	VoteStatusStarted       VoteStatusT = 3 // Vote is active
+	VoteStatusFinished      VoteStatusT = 4 // Vote has finished, results not loaded
+	VoteStatusApproved      VoteStatusT = 5 // Vote has finished and was approved
+	VoteStatusRejected      VoteStatusT = 6 // Vote has finished and was rejected
 )
 
 // CastVote is a signed vote.
@@ -111,11 +202,39 @@ func DecodeBallotReply(payload []byte) (*BallotReply, error) {
 	return &br, nil
 }
 
+// BestBlockReply is the reply to the cache best block command.  The backend
+// replies to the best block command with the plain block height.
+type BestBlockReply struct {
+	Height uint64 `json:"height"` // Best block height
+}
+
+// EncodeBestBlockReply encodes a BestBlockReply into a JSON byte slice.
+func EncodeBestBlockReply(bbr BestBlockReply) ([]byte, error) {
+	return json.Marshal(bbr)
+}
+
+// DecodeBestBlockReply decodes a JSON byte slice into a BestBlockReply.
+func DecodeBestBlockReply(payload []byte) (*BestBlockReply, error) {
+	var bbr BestBlockReply
+
+	err := json.Unmarshal(payload, &bbr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &bbr, nil
+}
+
 // VoteOption describes a single vote option.
 type VoteOption struct {
 	Id          string `json:"id"`          // Single unique word identifying vote (e.g. yes)
 	Description string `json:"description"` // Longer description of the vote.
 	Bits        uint64 `json:"bits"`        // Bits used for this option
+
+	// PassPercentage is the percent of total votes this option requires
+	// to be approved in an approval vote.  The vote pass percentage is
+	// used when it is not set.
+	PassPercentage uint32 `json:"passpercentage,omitempty"`
 }
 
 // Vote represents the vote options for vote that is identified by its token.
@@ -126,6 +245,7 @@ type Vote struct {
 	QuorumPercentage uint32       `json:"quorumpercentage"` // Percent of eligible votes required for quorum
 	PassPercentage   uint32       `json:"passpercentage"`   // Percent of total votes required to pass
 	Options          []VoteOption `json:"options"`          // Vote option
+	Type             uint32       `json:"type,omitempty"`   // Vote type (VoteTypeStandard if not set)
 }
 
 // EncodeVote encodes Vote into a JSON byte slice.
@@ -311,13 +431,58 @@ func DecodeVoteDetailsReply(payload []byte) (*VoteDetailsReply, error) {
 	return &vdr, nil
 }
 
-type VoteResults struct {
+// GetStartVoteReply retrieves the StartVoteReply of a proposal without the
+// vote authorization and the start vote.  The reply to this command is the
+// StartVoteReply of the proposal.
+type GetStartVoteReply struct {
 	Token string `json:"token"` // Censorship token
 }
 
+// EncodeGetStartVoteReply encodes GetStartVoteReply into a JSON byte slice.
+func EncodeGetStartVoteReply(g GetStartVoteReply) ([]byte, error) {
+	return json.Marshal(g)
+}
+
+// DecodeGetStartVoteReply decodes a JSON byte slice into a GetStartVoteReply.
+func DecodeGetStartVoteReply(payload []byte) (*GetStartVoteReply, error) {
+	var g GetStartVoteReply
+
+	err := json.Unmarshal(payload, &g)
+	if err != nil {
+		return nil, err
+	}
+
+	return &g, nil
+}
+
+// VoteResults requests the vote results for a proposal.  When TallyOnly is
+// set, the reply contains the number of votes cast for each vote option
+// instead of the full list of cast votes.
+//
+// Offset and Limit page through the cast votes, which are ordered by ticket.
+// All cast votes after the offset are returned when Limit is not set.  The
+// full StartVote is returned for every page.
+//
+// AfterTicket pages through the cast votes by ticket instead of by offset.
+// Only the cast votes with a ticket that sorts after AfterTicket are returned.
+// Unlike an offset, the ticket cursor does not skip or repeat cast votes when
+// votes are cast while the pages are being requested.
+type VoteResults struct {
+	Token       string `json:"token"`                 // Censorship token
+	TallyOnly   bool   `json:"tallyonly,omitempty"`   // Only return vote option tallies
+	Offset      uint32 `json:"offset,omitempty"`      // Number of cast votes to skip
+	Limit       uint32 `json:"limit,omitempty"`       // Maximum number of cast votes
+	AfterTicket string `json:"afterticket,omitempty"` // Ticket cursor
+}
+
+// VoteResultsReply is the reply to the VoteResults command.  Tally is only
+// populated and CastVotes is only omitted when TallyOnly was requested.
+// CastVotes only contains the requested page when a limit was set.
 type VoteResultsReply struct {
-	StartVote StartVote  `json:"startvote"` // Original ballot
-	CastVotes []CastVote `json:"castvotes"` // All votes
+	Started   bool               `json:"started"`         // Vote has been started
+	StartVote StartVote          `json:"startvote"`       // Original ballot
+	CastVotes []CastVote         `json:"castvotes"`       // Cast votes
+	Tally     []VoteOptionResult `json:"tally,omitempty"` // Votes per option
 }
 
 // EncodeVoteResults encodes VoteResults into a JSON byte slice.
@@ -354,6 +519,189 @@ func DecodeVoteResultsReply(payload []byte) (*VoteResultsReply, error) {
 	return &v, nil
 }
 
+// ProposalVoteReceipts retrieves the server receipts of the votes that were
+// cast on a proposal.  The receipts can be filtered by ticket.
+type ProposalVoteReceipts struct {
+	Token  string `json:"token"`            // Censorship token
+	Ticket string `json:"ticket,omitempty"` // Ticket filter (optional)
+}
+
+// EncodeProposalVoteReceipts encodes ProposalVoteReceipts into a JSON byte
+// slice.
+func EncodeProposalVoteReceipts(pvr ProposalVoteReceipts) ([]byte, error) {
+	return json.Marshal(pvr)
+}
+
+// DecodeProposalVoteReceipts decodes a JSON byte slice into a
+// ProposalVoteReceipts.
+func DecodeProposalVoteReceipts(payload []byte) (*ProposalVoteReceipts, error) {
+	var pvr ProposalVoteReceipts
+
+	err := json.Unmarshal(payload, &pvr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pvr, nil
+}
+
+// VoteReceipt contains a cast vote along with the receipt that the server
+// issued for it.  The receipt is empty for votes whose receipt is not known to
+// the cache.
+type VoteReceipt struct {
+	Ticket    string `json:"ticket"`    // Ticket ID
+	VoteBit   string `json:"votebit"`   // Hex encoded vote bit
+	Signature string `json:"signature"` // Client signature of Token+Ticket+VoteBit
+	Receipt   string `json:"receipt"`   // Server signature of the client signature
+}
+
+// ProposalVoteReceiptsReply is the reply to the ProposalVoteReceipts command.
+// The receipts are ordered by ticket.
+type ProposalVoteReceiptsReply struct {
+	Receipts []VoteReceipt `json:"receipts"` // Vote receipts
+}
+
+// EncodeProposalVoteReceiptsReply encodes ProposalVoteReceiptsReply into a
+// JSON byte slice.
+func EncodeProposalVoteReceiptsReply(pvrr ProposalVoteReceiptsReply) ([]byte, error) {
+	return json.Marshal(pvrr)
+}
+
+// DecodeProposalVoteReceiptsReply decodes a JSON byte slice into a
+// ProposalVoteReceiptsReply.
+func DecodeProposalVoteReceiptsReply(payload []byte) (*ProposalVoteReceiptsReply, error) {
+	var pvrr ProposalVoteReceiptsReply
+
+	err := json.Unmarshal(payload, &pvrr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pvrr, nil
+}
+
+// TableDigest summarizes the contents of a fonero plugin cache table.  The
+// digest is the hex encoded SHA256 digest of the sorted, newline delimited
+// primary keys of the table.
+type TableDigest struct {
+	Table  string `json:"table"`  // Table name
+	Count  int    `json:"count"`  // Number of rows
+	Digest string `json:"digest"` // Digest of the sorted primary keys
+}
+
+// InventoryDigestReply is the reply to the InventoryDigest command.  It
+// summarizes the fonero plugin cache contents so that the cache can be
+// compared against the fonero plugin inventory without rebuilding it.  The
+// primary keys of each table are the keys that can be derived from the
+// inventory.  Digest is the hex encoded SHA256 digest of the table digests.
+//
+// The cast votes of the Archived tokens have been removed by the
+// ArchiveProposalVotes command and are not part of the cast_votes table.  The
+// cast_vote_archives table covers them instead.  Its keys are the token and
+// the ArchiveProposalVotes digest of the cast votes, separated by a colon.
+type InventoryDigestReply struct {
+	Digest   string        `json:"digest"`   // Digest of the table digests
+	Tables   []TableDigest `json:"tables"`   // Table digests
+	Archived []string      `json:"archived"` // Tokens with archived cast votes
+}
+
+// EncodeInventoryDigestReply encodes InventoryDigestReply into a JSON byte
+// slice.
+func EncodeInventoryDigestReply(idr InventoryDigestReply) ([]byte, error) {
+	return json.Marshal(idr)
+}
+
+// DecodeInventoryDigestReply decodes a JSON byte slice into a
+// InventoryDigestReply.
+func DecodeInventoryDigestReply(payload []byte) (*InventoryDigestReply, error) {
+	var idr InventoryDigestReply
+
+	err := json.Unmarshal(payload, &idr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &idr, nil
+}
+
+// ComputeKeysDigest returns the hex encoded SHA256 digest of the sorted,
+// newline delimited concatenation of the passed in primary keys.  The digest
+// does not depend on the order of the passed in keys.
+func ComputeKeysDigest(keys []string) string {
+	sorted := make([]string, len(keys))
+	copy(sorted, keys)
+	sort.Strings(sorted)
+
+	h := sha256.New()
+	for _, v := range sorted {
+		h.Write([]byte(v + "\n"))
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// ComputeInventoryDigest returns the digest of the passed in table digests.
+// The digest is the hex encoded SHA256 digest of the newline delimited
+// table:count:digest of every table digest, in the order they are passed in.
+func ComputeInventoryDigest(tables []TableDigest) string {
+	h := sha256.New()
+	for _, v := range tables {
+		h.Write([]byte(fmt.Sprintf("%v:%v:%v\n", v.Table, v.Count,
+			v.Digest)))
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// VoteEligibility is used to check whether a ticket is eligible to vote on a
+// proposal and whether it has already voted.
+type VoteEligibility struct {
+	Token  string `json:"token"`  // Censorship token
+	Ticket string `json:"ticket"` // Ticket hash
+}
+
+// EncodeVoteEligibility encodes VoteEligibility into a JSON byte slice.
+func EncodeVoteEligibility(ve VoteEligibility) ([]byte, error) {
+	return json.Marshal(ve)
+}
+
+// DecodeVoteEligibility decodes a JSON byte slice into a VoteEligibility.
+func DecodeVoteEligibility(payload []byte) (*VoteEligibility, error) {
+	var ve VoteEligibility
+
+	err := json.Unmarshal(payload, &ve)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ve, nil
+}
+
+// VoteEligibilityReply is the reply to the VoteEligibility command.
+type VoteEligibilityReply struct {
+	Eligible     bool `json:"eligible"`     // Ticket is in the eligible ticket pool
+	AlreadyVoted bool `json:"alreadyvoted"` // Ticket has already cast a vote
+}
+
+// EncodeVoteEligibilityReply encodes VoteEligibilityReply into a JSON byte
+// slice.
+func EncodeVoteEligibilityReply(ver VoteEligibilityReply) ([]byte, error) {
+	return json.Marshal(ver)
+}
+
+// DecodeVoteEligibilityReply decodes a JSON byte slice into a
+// VoteEligibilityReply.
+func DecodeVoteEligibilityReply(payload []byte) (*VoteEligibilityReply, error) {
+	var ver VoteEligibilityReply
+
+	err := json.Unmarshal(payload, &ver)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ver, nil
+}
+
 // VoteSummary requests a summary of a proposal vote. This includes certain
 // voting period parameters and a summary of the vote results.
 type VoteSummary struct {
@@ -384,6 +732,7 @@ type VoteOptionResult struct {
 	Description string `json:"description"` // Longer description of the vote.
 	Bits        uint64 `json:"bits"`        // Bits used for this option
 	Votes       uint64 `json:"votes"`       // Number of votes cast for this option
+	Approved    bool   `json:"approved"`    // Option met its pass percentage (approval votes only)
 }
 
 // VoteSummaryReply is the reply to the VoteSummary command and returns certain
@@ -414,6 +763,212 @@ func DecodeVoteSummaryReply(payload []byte) (*VoteSummaryReply, error) {
 	return &v, nil
 }
 
+// CountVotesByOption requests the number of votes that have been cast for each
+// vote option of a proposal.  It is a lightweight alternative to VoteSummary
+// for clients that poll an active vote.
+type CountVotesByOption struct {
+	Token string `json:"token"` // Censorship token
+}
+
+// EncodeCountVotesByOption encodes CountVotesByOption into a JSON byte slice.
+func EncodeCountVotesByOption(c CountVotesByOption) ([]byte, error) {
+	return json.Marshal(c)
+}
+
+// DecodeCountVotesByOption decodes a JSON byte slice into a
+// CountVotesByOption.
+func DecodeCountVotesByOption(payload []byte) (*CountVotesByOption, error) {
+	var c CountVotesByOption
+
+	err := json.Unmarshal(payload, &c)
+	if err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+// CountVotesByOptionReply is the reply to the CountVotesByOption command.
+// Counts contains an entry for every vote option, keyed by the hex encoded
+// vote bit of the option.
+type CountVotesByOptionReply struct {
+	Options []VoteOption      `json:"options"` // Vote options
+	Counts  map[string]uint64 `json:"counts"`  // [voteBit]voteCount
+}
+
+// EncodeCountVotesByOptionReply encodes CountVotesByOptionReply into a JSON
+// byte slice.
+func EncodeCountVotesByOptionReply(c CountVotesByOptionReply) ([]byte, error) {
+	return json.Marshal(c)
+}
+
+// DecodeCountVotesByOptionReply decodes a JSON byte slice into a
+// CountVotesByOptionReply.
+func DecodeCountVotesByOptionReply(payload []byte) (*CountVotesByOptionReply, error) {
+	var c CountVotesByOptionReply
+
+	err := json.Unmarshal(payload, &c)
+	if err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+// GetVoteStatus requests the vote status of a proposal.  The vote status is
+// VoteStatusFinished when the vote has ended but the vote results have not
+// been loaded yet.  The best block is only required when the cache does not
+// have a best block source.
+type GetVoteStatus struct {
+	Token     string `json:"token"`               // Censorship token
+	BestBlock uint64 `json:"bestblock,omitempty"` // Best block height
+}
+
+// EncodeGetVoteStatus encodes GetVoteStatus into a JSON byte slice.
+func EncodeGetVoteStatus(g GetVoteStatus) ([]byte, error) {
+	return json.Marshal(g)
+}
+
+// DecodeGetVoteStatus decodes a JSON byte slice into a GetVoteStatus.
+func DecodeGetVoteStatus(payload []byte) (*GetVoteStatus, error) {
+	var g GetVoteStatus
+
+	err := json.Unmarshal(payload, &g)
+	if err != nil {
+		return nil, err
+	}
+
+	return &g, nil
+}
+
+// GetVoteStatusReply is the reply to the GetVoteStatus command.
+type GetVoteStatusReply struct {
+	Status VoteStatusT `json:"status"` // Vote status
+}
+
+// EncodeGetVoteStatusReply encodes GetVoteStatusReply into a JSON byte slice.
+func EncodeGetVoteStatusReply(g GetVoteStatusReply) ([]byte, error) {
+	return json.Marshal(g)
+}
+
+// DecodeGetVoteStatusReply decodes a JSON byte slice into a
+// GetVoteStatusReply.
+func DecodeGetVoteStatusReply(payload []byte) (*GetVoteStatusReply, error) {
+	var g GetVoteStatusReply
+
+	err := json.Unmarshal(payload, &g)
+	if err != nil {
+		return nil, err
+	}
+
+	return &g, nil
+}
+
+// ProposalSupportersCount requests the number of distinct tickets that have
+// voted on a proposal along with the total number of votes that were cast.
+type ProposalSupportersCount struct {
+	Token string `json:"token"` // Censorship token
+}
+
+// EncodeProposalSupportersCount encodes ProposalSupportersCount into a JSON
+// byte slice.
+func EncodeProposalSupportersCount(p ProposalSupportersCount) ([]byte, error) {
+	return json.Marshal(p)
+}
+
+// DecodeProposalSupportersCount decodes a JSON byte slice into a
+// ProposalSupportersCount.
+func DecodeProposalSupportersCount(payload []byte) (*ProposalSupportersCount, error) {
+	var p ProposalSupportersCount
+
+	err := json.Unmarshal(payload, &p)
+	if err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+// ProposalSupportersCountReply is the reply to the ProposalSupportersCount
+// command.  Supporters is the number of distinct tickets that voted on the
+// proposal and Votes is the total number of votes that were cast, which may
+// be larger when a ticket has cast more than one vote.
+type ProposalSupportersCountReply struct {
+	Supporters uint64 `json:"supporters"` // Number of distinct tickets
+	Votes      uint64 `json:"votes"`      // Total number of votes
+}
+
+// EncodeProposalSupportersCountReply encodes ProposalSupportersCountReply into
+// a JSON byte slice.
+func EncodeProposalSupportersCountReply(p ProposalSupportersCountReply) ([]byte, error) {
+	return json.Marshal(p)
+}
+
+// DecodeProposalSupportersCountReply decodes a JSON byte slice into a
+// ProposalSupportersCountReply.
+func DecodeProposalSupportersCountReply(payload []byte) (*ProposalSupportersCountReply, error) {
+	var p ProposalSupportersCountReply
+
+	err := json.Unmarshal(payload, &p)
+	if err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+// VotesByBlockWindow retrieves the tokens of all proposals whose voting period
+// overlaps the provided block window.  A voting period runs from the start
+// block height through the end height.  Both the start and end heights of the
+// window are inclusive.
+type VotesByBlockWindow struct {
+	StartHeight uint64 `json:"startheight"` // Window start block height
+	EndHeight   uint64 `json:"endheight"`   // Window end block height
+}
+
+// EncodeVotesByBlockWindow encodes VotesByBlockWindow into a JSON byte slice.
+func EncodeVotesByBlockWindow(v VotesByBlockWindow) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// DecodeVotesByBlockWindow decodes a JSON byte slice into a
+// VotesByBlockWindow.
+func DecodeVotesByBlockWindow(payload []byte) (*VotesByBlockWindow, error) {
+	var v VotesByBlockWindow
+
+	err := json.Unmarshal(payload, &v)
+	if err != nil {
+		return nil, err
+	}
+
+	return &v, nil
+}
+
+// VotesByBlockWindowReply is the reply to the VotesByBlockWindow command.  The
+// tokens are sorted by vote start block height in ascending order.
+type VotesByBlockWindowReply struct {
+	Tokens []string `json:"tokens"` // Censorship tokens
+}
+
+// EncodeVotesByBlockWindowReply encodes VotesByBlockWindowReply into a JSON
+// byte slice.
+func EncodeVotesByBlockWindowReply(v VotesByBlockWindowReply) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// DecodeVotesByBlockWindowReply decodes a JSON byte slice into a
+// VotesByBlockWindowReply.
+func DecodeVotesByBlockWindowReply(payload []byte) (*VotesByBlockWindowReply, error) {
+	var v VotesByBlockWindowReply
+
+	err := json.Unmarshal(payload, &v)
+	if err != nil {
+		return nil, err
+	}
+
+	return &v, nil
+}
+
 // Comment is the structure that describes the full server side content.  It
 // includes server side meta-data as well. Note that the receipt is the server
 // side.
@@ -432,6 +987,7 @@ type Comment struct {
 	TotalVotes  uint64 `json:"totalvotes"`  // Total number of up/down votes
 	ResultVotes int64  `json:"resultvotes"` // Vote score
 	Censored    bool   `json:"censored"`    // Has this comment been censored
+	Hidden      bool   `json:"hidden"`      // Has this comment been hidden
 }
 
 // EncodeComment encodes Comment into a JSON byte slice.
@@ -503,11 +1059,12 @@ func DecodeNewCommentReply(payload []byte) (*NewCommentReply, error) {
 	return &ncr, nil
 }
 
-// LikeComment records an up or down vote from a user on a comment.
+// LikeComment records an up or down vote from a user on a comment.  Likes are
+// append only; an undo is recorded as a LikeComment with the undo action.
 type LikeComment struct {
 	Token     string `json:"token"`     // Censorship token
 	CommentID string `json:"commentid"` // Comment ID
-	Action    string `json:"action"`    // Up or downvote (1, -1)
+	Action    string `json:"action"`    // Up or downvote (1, -1) or undo (0)
 	Signature string `json:"signature"` // Client Signature of Token+CommentID+Action
 	PublicKey string `json:"publickey"` // Pubkey used for Signature
 
@@ -558,6 +1115,74 @@ func DecodeLikeCommentReply(payload []byte) (*LikeCommentReply, error) {
 	return &lcr, nil
 }
 
+// LikeCommentAction returns the current like action of a user on a comment
+// after the passed in action has been applied on top of the previous current
+// action.  An empty string means the user does not currently like the
+// comment.  An undo removes the previous action, repeating the previous action
+// removes it as well, and the opposite action replaces it.
+func LikeCommentAction(prev, action string) string {
+	switch {
+	case action == LikeActionUndo:
+		return ""
+	case action == prev:
+		return ""
+	default:
+		return action
+	}
+}
+
+// LikeCommentUndo removes the current up or down vote of a user on a comment.
+// The undo is recorded as a LikeComment with the undo action so that the like
+// history of the comment is retained.
+type LikeCommentUndo struct {
+	Token     string `json:"token"`     // Censorship token
+	CommentID string `json:"commentid"` // Comment ID
+	Signature string `json:"signature"` // Client Signature of Token+CommentID+LikeActionUndo
+	PublicKey string `json:"publickey"` // Pubkey used for Signature
+}
+
+// EncodeLikeCommentUndo encodes LikeCommentUndo into a JSON byte slice.
+func EncodeLikeCommentUndo(lcu LikeCommentUndo) ([]byte, error) {
+	return json.Marshal(lcu)
+}
+
+// DecodeLikeCommentUndo decodes a JSON byte slice into a LikeCommentUndo.
+func DecodeLikeCommentUndo(payload []byte) (*LikeCommentUndo, error) {
+	var lcu LikeCommentUndo
+
+	err := json.Unmarshal(payload, &lcu)
+	if err != nil {
+		return nil, err
+	}
+
+	return &lcu, nil
+}
+
+// LikeCommentUndoReply returns the receipt of a like undo.
+type LikeCommentUndoReply struct {
+	Receipt   string `json:"receipt"`   // Server signature of client signature
+	Timestamp int64  `json:"timestamp"` // Received UNIX timestamp
+}
+
+// EncodeLikeCommentUndoReply encodes LikeCommentUndoReply into a JSON byte
+// slice.
+func EncodeLikeCommentUndoReply(lcur LikeCommentUndoReply) ([]byte, error) {
+	return json.Marshal(lcur)
+}
+
+// DecodeLikeCommentUndoReply decodes a JSON byte slice into a
+// LikeCommentUndoReply.
+func DecodeLikeCommentUndoReply(payload []byte) (*LikeCommentUndoReply, error) {
+	var lcur LikeCommentUndoReply
+
+	err := json.Unmarshal(payload, &lcur)
+	if err != nil {
+		return nil, err
+	}
+
+	return &lcur, nil
+}
+
 // CensorComment is a journal entry for a censored comment.  The signature and
 // public key are from the admin that censored this comment.
 type CensorComment struct {
@@ -608,20 +1233,123 @@ func DecodeCensorCommentReply(payload []byte) (*CensorCommentReply, error) {
 	return &ccr, nil
 }
 
-// GetComment retrieves a single comment.
-type GetComment struct {
-	Token     string `json:"token"`     // Proposal ID
+// SetCommentVisibility is a journal entry that hides or unhides a comment.
+// Unlike a censored comment, a hidden comment retains its body and can be
+// unhidden again.  The signature and public key are from the admin that
+// changed the visibility of the comment.
+type SetCommentVisibility struct {
+	Token     string `json:"token"`     // Proposal censorship token
 	CommentID string `json:"commentid"` // Comment ID
-}
+	Hidden    bool   `json:"hidden"`    // Hide or unhide the comment
+	Signature string `json:"signature"` // Client signature of Token+CommentID+Hidden
+	PublicKey string `json:"publickey"` // Pubkey used for signature
 
-// EncodeGetComment encodes a GetComment into a JSON byte slice.
-func EncodeGetComment(gc GetComment) ([]byte, error) {
-	return json.Marshal(gc)
+	// Generated by foneroplugin
+	Receipt   string `json:"receipt,omitempty"`   // Server signature of client signature
+	Timestamp int64  `json:"timestamp,omitempty"` // Received UNIX timestamp
 }
 
-// DecodeGetComment decodes a JSON byte slice into a GetComment.
-func DecodeGetComment(payload []byte) (*GetComment, error) {
-	var gc GetComment
+// EncodeSetCommentVisibility encodes SetCommentVisibility into a JSON byte
+// slice.
+func EncodeSetCommentVisibility(scv SetCommentVisibility) ([]byte, error) {
+	return json.Marshal(scv)
+}
+
+// DecodeSetCommentVisibility decodes a JSON byte slice into a
+// SetCommentVisibility.
+func DecodeSetCommentVisibility(payload []byte) (*SetCommentVisibility, error) {
+	var scv SetCommentVisibility
+	err := json.Unmarshal(payload, &scv)
+	if err != nil {
+		return nil, err
+	}
+	return &scv, nil
+}
+
+// SetCommentVisibilityReply returns the receipt for the visibility change.
+// The receipt is the server side signature of SetCommentVisibility.Signature.
+type SetCommentVisibilityReply struct {
+	Receipt   string `json:"receipt"`   // Server signature of client signature
+	Timestamp int64  `json:"timestamp"` // Received UNIX timestamp
+}
+
+// EncodeSetCommentVisibilityReply encodes SetCommentVisibilityReply into a
+// JSON byte slice.
+func EncodeSetCommentVisibilityReply(scvr SetCommentVisibilityReply) ([]byte, error) {
+	return json.Marshal(scvr)
+}
+
+// DecodeSetCommentVisibilityReply decodes a JSON byte slice into a
+// SetCommentVisibilityReply.
+func DecodeSetCommentVisibilityReply(payload []byte) (*SetCommentVisibilityReply, error) {
+	var scvr SetCommentVisibilityReply
+	err := json.Unmarshal(payload, &scvr)
+	if err != nil {
+		return nil, err
+	}
+	return &scvr, nil
+}
+
+// ReparentComment moves an existing comment underneath a new parent comment.
+// A ParentID of "0" moves the comment to the top level of the comment tree.
+// A comment cannot be moved underneath itself or one of its descendants.
+type ReparentComment struct {
+	Token     string `json:"token"`     // Proposal censorship token
+	CommentID string `json:"commentid"` // Comment ID
+	ParentID  string `json:"parentid"`  // New parent comment ID
+}
+
+// EncodeReparentComment encodes ReparentComment into a JSON byte slice.
+func EncodeReparentComment(rc ReparentComment) ([]byte, error) {
+	return json.Marshal(rc)
+}
+
+// DecodeReparentComment decodes a JSON byte slice into a ReparentComment.
+func DecodeReparentComment(payload []byte) (*ReparentComment, error) {
+	var rc ReparentComment
+	err := json.Unmarshal(payload, &rc)
+	if err != nil {
+		return nil, err
+	}
+	return &rc, nil
+}
+
+// ReparentCommentReply returns the comment with its updated parent.
+type ReparentCommentReply struct {
+	Comment Comment `json:"comment"` // Reparented comment
+}
+
+// EncodeReparentCommentReply encodes ReparentCommentReply into a JSON byte
+// slice.
+func EncodeReparentCommentReply(rcr ReparentCommentReply) ([]byte, error) {
+	return json.Marshal(rcr)
+}
+
+// DecodeReparentCommentReply decodes a JSON byte slice into a
+// ReparentCommentReply.
+func DecodeReparentCommentReply(payload []byte) (*ReparentCommentReply, error) {
+	var rcr ReparentCommentReply
+	err := json.Unmarshal(payload, &rcr)
+	if err != nil {
+		return nil, err
+	}
+	return &rcr, nil
+}
+
+// GetComment retrieves a single comment.
+type GetComment struct {
+	Token     string `json:"token"`     // Proposal ID
+	CommentID string `json:"commentid"` // Comment ID
+}
+
+// EncodeGetComment encodes a GetComment into a JSON byte slice.
+func EncodeGetComment(gc GetComment) ([]byte, error) {
+	return json.Marshal(gc)
+}
+
+// DecodeGetComment decodes a JSON byte slice into a GetComment.
+func DecodeGetComment(payload []byte) (*GetComment, error) {
+	var gc GetComment
 
 	err := json.Unmarshal(payload, &gc)
 	if err != nil {
@@ -653,296 +1381,2273 @@ func DecodeGetCommentReply(payload []byte) (*GetCommentReply, error) {
 	return &gcr, nil
 }
 
-// GetComments retrieve all comments for a given proposal. This call returns
-// the cooked comments; deleted/censored comments are not returned.
-type GetComments struct {
-	Token string `json:"token"` // Proposal ID
+// GetCommentByReceipt retrieves the comment that has the provided server
+// receipt.  Hidden comments are not returned.
+type GetCommentByReceipt struct {
+	Receipt string `json:"receipt"` // Server signature of the client signature
 }
 
-// EncodeGetComments encodes GetCommentsReply into a JSON byte slice.
-func EncodeGetComments(gc GetComments) ([]byte, error) {
-	return json.Marshal(gc)
+// EncodeGetCommentByReceipt encodes a GetCommentByReceipt into a JSON byte
+// slice.
+func EncodeGetCommentByReceipt(gcbr GetCommentByReceipt) ([]byte, error) {
+	return json.Marshal(gcbr)
 }
 
-// DecodeGetComments decodes a JSON byte slice into a GetComments.
-func DecodeGetComments(payload []byte) (*GetComments, error) {
-	var gc GetComments
+// DecodeGetCommentByReceipt decodes a JSON byte slice into a
+// GetCommentByReceipt.
+func DecodeGetCommentByReceipt(payload []byte) (*GetCommentByReceipt, error) {
+	var gcbr GetCommentByReceipt
 
-	err := json.Unmarshal(payload, &gc)
+	err := json.Unmarshal(payload, &gcbr)
 	if err != nil {
 		return nil, err
 	}
 
-	return &gc, nil
+	return &gcbr, nil
 }
 
-// GetCommentsReply returns the provided number of comments.
-type GetCommentsReply struct {
-	Comments []Comment `json:"comments"` // Comments
+// GetCommentByReceiptReply returns the comment that has the provided receipt.
+type GetCommentByReceiptReply struct {
+	Comment Comment `json:"comment"` // Comment
 }
 
-// EncodeGetCommentsReply encodes GetCommentsReply into a JSON byte slice.
-func EncodeGetCommentsReply(gcr GetCommentsReply) ([]byte, error) {
-	return json.Marshal(gcr)
+// EncodeGetCommentByReceiptReply encodes a GetCommentByReceiptReply into a
+// JSON byte slice.
+func EncodeGetCommentByReceiptReply(gcbrr GetCommentByReceiptReply) ([]byte, error) {
+	return json.Marshal(gcbrr)
 }
 
-// DecodeGetCommentsReply decodes a JSON byte slice into a GetCommentsReply.
-func DecodeGetCommentsReply(payload []byte) (*GetCommentsReply, error) {
-	var gcr GetCommentsReply
+// DecodeGetCommentByReceiptReply decodes a JSON byte slice into a
+// GetCommentByReceiptReply.
+func DecodeGetCommentByReceiptReply(payload []byte) (*GetCommentByReceiptReply, error) {
+	var gcbrr GetCommentByReceiptReply
 
-	err := json.Unmarshal(payload, &gcr)
+	err := json.Unmarshal(payload, &gcbrr)
 	if err != nil {
 		return nil, err
 	}
 
-	return &gcr, nil
+	return &gcbrr, nil
 }
 
-// CommentLikes is used to retrieve all of the comment likes for a single
-// record comment.
-type CommentLikes struct {
-	Token     string `json:"token"`     // Censorship token
-	CommentID string `json:"commentid"` // Comment ID
+// GetCommentAncestors retrieves a single comment along with its ancestors.
+// The ancestors are found by following the ParentID of each comment up to the
+// root comment.  At most MaxDepth ancestors are returned.  A MaxDepth of zero
+// or a MaxDepth that exceeds CommentAncestorsMaxDepth is treated as
+// CommentAncestorsMaxDepth.  Hidden comments are not returned, so the
+// ancestors stop at the first hidden ancestor.
+type GetCommentAncestors struct {
+	Token     string `json:"token"`              // Proposal ID
+	CommentID string `json:"commentid"`          // Comment ID
+	MaxDepth  uint32 `json:"maxdepth,omitempty"` // Maximum number of ancestors
 }
 
-// EncodeCommentLikes encodes CommentLikes into a JSON byte slice.
-func EncodeCommentLikes(gpcv CommentLikes) ([]byte, error) {
-	return json.Marshal(gpcv)
+// EncodeGetCommentAncestors encodes a GetCommentAncestors into a JSON byte
+// slice.
+func EncodeGetCommentAncestors(gca GetCommentAncestors) ([]byte, error) {
+	return json.Marshal(gca)
 }
 
-// DecodeCommentLikes decodes a JSON byte slice into a CommentLikes.
-func DecodeCommentLikes(payload []byte) (*CommentLikes, error) {
-	var cl CommentLikes
+// DecodeGetCommentAncestors decodes a JSON byte slice into a
+// GetCommentAncestors.
+func DecodeGetCommentAncestors(payload []byte) (*GetCommentAncestors, error) {
+	var gca GetCommentAncestors
 
-	err := json.Unmarshal(payload, &cl)
+	err := json.Unmarshal(payload, &gca)
 	if err != nil {
 		return nil, err
 	}
 
-	return &cl, nil
+	return &gca, nil
 }
 
-// CommentLikesReply is the reply to CommentLikes and returns all of the
-// upvote/downvote actions for the specified comment.
-type CommentLikesReply struct {
-	CommentLikes []LikeComment `json:"commentlikes"`
+// GetCommentAncestorsReply returns the provided comment and its ancestors.
+// The ancestors are ordered from the parent of the comment up to the root
+// comment.  The chain stops early if an ancestor could not be found or if the
+// maximum depth was reached.
+type GetCommentAncestorsReply struct {
+	Comment   Comment   `json:"comment"`   // Comment
+	Ancestors []Comment `json:"ancestors"` // Ancestors, parent first
 }
 
-// EncodeCommentLikesReply encodes EncodeCommentLikesReply into a JSON byte
-// slice.
-func EncodeCommentLikesReply(clr CommentLikesReply) ([]byte, error) {
-	return json.Marshal(clr)
+// EncodeGetCommentAncestorsReply encodes a GetCommentAncestorsReply into a
+// JSON byte slice.
+func EncodeGetCommentAncestorsReply(gcar GetCommentAncestorsReply) ([]byte, error) {
+	return json.Marshal(gcar)
 }
 
-// DecodeCommentLikesReply decodes a JSON byte slice into a CommentLikesReply.
-func DecodeCommentLikesReply(payload []byte) (*CommentLikesReply, error) {
-	var clr CommentLikesReply
+// DecodeGetCommentAncestorsReply decodes a JSON byte slice into a
+// GetCommentAncestorsReply.
+func DecodeGetCommentAncestorsReply(payload []byte) (*GetCommentAncestorsReply, error) {
+	var gcar GetCommentAncestorsReply
 
-	err := json.Unmarshal(payload, &clr)
+	err := json.Unmarshal(payload, &gcar)
 	if err != nil {
 		return nil, err
 	}
 
-	return &clr, nil
+	return &gcar, nil
 }
 
-// GetProposalCommentsLikes is a command to fetch all vote actions
-// on the comments of a given proposal
-type GetProposalCommentsLikes struct {
-	Token string `json:"token"` // Censorship token
+// GetCommentVersions retrieves all stored versions of a single comment.
+type GetCommentVersions struct {
+	Token     string `json:"token"`     // Proposal ID
+	CommentID string `json:"commentid"` // Comment ID
 }
 
-// EncodeGetProposalCommentsLikes encodes GetProposalCommentsLikes into a JSON byte slice.
-func EncodeGetProposalCommentsLikes(gpcv GetProposalCommentsLikes) ([]byte, error) {
-	return json.Marshal(gpcv)
+// EncodeGetCommentVersions encodes a GetCommentVersions into a JSON byte
+// slice.
+func EncodeGetCommentVersions(gcv GetCommentVersions) ([]byte, error) {
+	return json.Marshal(gcv)
 }
 
-// DecodeGetProposalCommentsLikes decodes a JSON byte slice into a GetProposalCommentsLikes.
-func DecodeGetProposalCommentsLikes(payload []byte) (*GetProposalCommentsLikes, error) {
-	var gpcl GetProposalCommentsLikes
+// DecodeGetCommentVersions decodes a JSON byte slice into a
+// GetCommentVersions.
+func DecodeGetCommentVersions(payload []byte) (*GetCommentVersions, error) {
+	var gcv GetCommentVersions
 
-	err := json.Unmarshal(payload, &gpcl)
+	err := json.Unmarshal(payload, &gcv)
 	if err != nil {
 		return nil, err
 	}
 
-	return &gpcl, nil
+	return &gcv, nil
 }
 
-// GetProposalCommentsLikesReply is a reply with all vote actions
-// for the comments of a given proposal
-type GetProposalCommentsLikesReply struct {
-	CommentsLikes []LikeComment `json:"commentslikes"`
+// CommentVersion is a single stored version of a comment.  The original
+// comment is version 1.  A censor event is stored as a new version that has
+// an empty comment, has Censored set and contains the reason, signature and
+// public key of the admin that censored the comment.
+type CommentVersion struct {
+	Version   uint32 `json:"version"`          // Version number
+	Comment   string `json:"comment"`          // Comment
+	Signature string `json:"signature"`        // Client signature
+	PublicKey string `json:"publickey"`        // Pubkey used for signature
+	Receipt   string `json:"receipt"`          // Server signature of the client signature
+	Timestamp int64  `json:"timestamp"`        // Received UNIX timestamp
+	Censored  bool   `json:"censored"`         // Is this a censor event
+	Reason    string `json:"reason,omitempty"` // Reason comment was censored
 }
 
-// EncodeGetProposalCommentsLikesReply encodes EncodeGetProposalCommentsLikesReply into a JSON byte slice.
-func EncodeGetProposalCommentsLikesReply(gpclr GetProposalCommentsLikesReply) ([]byte, error) {
-	return json.Marshal(gpclr)
+// GetCommentVersionsReply returns all stored versions of the provided comment
+// ordered from oldest to newest.
+type GetCommentVersionsReply struct {
+	Versions []CommentVersion `json:"versions"` // Comment versions
 }
 
-// DecodeGetProposalCommentsLikesReply decodes a JSON byte slice into a GetProposalCommentsLikesReply.
-func DecodeGetProposalCommentsLikesReply(payload []byte) (*GetProposalCommentsLikesReply, error) {
-	var gpclr GetProposalCommentsLikesReply
+// EncodeGetCommentVersionsReply encodes a GetCommentVersionsReply into a JSON
+// byte slice.
+func EncodeGetCommentVersionsReply(gcvr GetCommentVersionsReply) ([]byte, error) {
+	return json.Marshal(gcvr)
+}
 
-	err := json.Unmarshal(payload, &gpclr)
+// DecodeGetCommentVersionsReply decodes a JSON byte slice into a
+// GetCommentVersionsReply.
+func DecodeGetCommentVersionsReply(payload []byte) (*GetCommentVersionsReply, error) {
+	var gcvr GetCommentVersionsReply
+
+	err := json.Unmarshal(payload, &gcvr)
 	if err != nil {
 		return nil, err
 	}
 
-	return &gpclr, nil
+	return &gcvr, nil
 }
 
-// Inventory is used to retrieve the fonero plugin inventory.
-type Inventory struct{}
+// CommentThreadStats requests the number of replies to each root comment of a
+// proposal.  A root comment is a comment with a ParentID of 0.
+type CommentThreadStats struct {
+	Token string `json:"token"` // Proposal ID
+}
 
-// EncodeInventory encodes Inventory into a JSON byte slice.
-func EncodeInventory(i Inventory) ([]byte, error) {
-	return json.Marshal(i)
+// EncodeCommentThreadStats encodes a CommentThreadStats into a JSON byte
+// slice.
+func EncodeCommentThreadStats(cts CommentThreadStats) ([]byte, error) {
+	return json.Marshal(cts)
 }
 
-// DecodeInventory decodes a JSON byte slice into a Inventory.
-func DecodeInventory(payload []byte) (*Inventory, error) {
-	var i Inventory
+// DecodeCommentThreadStats decodes a JSON byte slice into a
+// CommentThreadStats.
+func DecodeCommentThreadStats(payload []byte) (*CommentThreadStats, error) {
+	var cts CommentThreadStats
 
-	err := json.Unmarshal(payload, &i)
+	err := json.Unmarshal(payload, &cts)
 	if err != nil {
 		return nil, err
 	}
 
-	return &i, nil
+	return &cts, nil
 }
 
-// StartVoteTuple is used to return the StartVote and StartVoteReply for a
-// record. StartVoteReply does not contain any record identifying data so it
-// must be returned with the StartVote in order to know what record it belongs
-// to.
-type StartVoteTuple struct {
-	StartVote      StartVote      `json:"startvote"`      // Start vote
-	StartVoteReply StartVoteReply `json:"startvotereply"` // Start vote reply
+// CommentThread contains the number of descendants of a root comment.
+// Replies only counts descendants that have not been censored.  Censored
+// descendants are counted separately.
+type CommentThread struct {
+	CommentID string `json:"commentid"` // Root comment ID
+	Replies   uint64 `json:"replies"`   // Uncensored descendants
+	Censored  uint64 `json:"censored"`  // Censored descendants
 }
 
-// InventoryReply returns the fonero plugin inventory.
-type InventoryReply struct {
-	Comments             []Comment            `json:"comments"`             // Comments
-	LikeComments         []LikeComment        `json:"likecomments"`         // Like comments
-	AuthorizeVotes       []AuthorizeVote      `json:"authorizevotes"`       // Authorize votes
-	AuthorizeVoteReplies []AuthorizeVoteReply `json:"authorizevotereplies"` // Authorize vote replies
-	StartVoteTuples      []StartVoteTuple     `json:"startvotetuples"`      // Start vote tuples
-	CastVotes            []CastVote           `json:"castvotes"`            // Cast votes
+// CommentThreadStatsReply is the reply to the CommentThreadStats command.
+// Threads contains an entry for every root comment of the proposal.
+type CommentThreadStatsReply struct {
+	Threads []CommentThread `json:"threads"` // Root comment reply counts
 }
 
-// EncodeInventoryReply encodes a InventoryReply into a JSON byte slice.
-func EncodeInventoryReply(ir InventoryReply) ([]byte, error) {
-	return json.Marshal(ir)
+// EncodeCommentThreadStatsReply encodes a CommentThreadStatsReply into a JSON
+// byte slice.
+func EncodeCommentThreadStatsReply(ctsr CommentThreadStatsReply) ([]byte, error) {
+	return json.Marshal(ctsr)
 }
 
-// DecodeInventoryReply decodes a JSON byte slice into a inventory.
-func DecodeInventoryReply(payload []byte) (*InventoryReply, error) {
-	var ir InventoryReply
+// DecodeCommentThreadStatsReply decodes a JSON byte slice into a
+// CommentThreadStatsReply.
+func DecodeCommentThreadStatsReply(payload []byte) (*CommentThreadStatsReply, error) {
+	var ctsr CommentThreadStatsReply
 
-	err := json.Unmarshal(payload, &ir)
+	err := json.Unmarshal(payload, &ctsr)
 	if err != nil {
 		return nil, err
 	}
 
-	return &ir, nil
+	return &ctsr, nil
 }
 
-// TokenInventory requests the tokens of all records in the inventory,
-// categorized by stage of the voting process.
-type TokenInventory struct {
-	BestBlock uint64 `json:"bestblock"` // Best block
+// GetComments retrieve all comments for a given proposal. This call returns
+// the cooked comments; deleted/censored comments are not returned.
+//
+// Offset and Limit page through the comments, which are ordered by timestamp
+// and comment ID.  All comments after the offset are returned when Limit is
+// not set.  Hidden comments are only returned when IncludeHidden is set.
+type GetComments struct {
+	Token         string `json:"token"`                   // Proposal ID
+	Offset        uint32 `json:"offset,omitempty"`        // Number of comments to skip
+	Limit         uint32 `json:"limit,omitempty"`         // Maximum number of comments
+	IncludeHidden bool   `json:"includehidden,omitempty"` // Include hidden comments
 }
 
-// EncodeTokenInventory encodes a TokenInventory into a JSON byte slice.
-func EncodeTokenInventory(i TokenInventory) ([]byte, error) {
-	return json.Marshal(i)
+// EncodeGetComments encodes GetCommentsReply into a JSON byte slice.
+func EncodeGetComments(gc GetComments) ([]byte, error) {
+	return json.Marshal(gc)
 }
 
-// DecodeTokenInventory decodes a JSON byte slice into a TokenInventory.
-func DecodeTokenInventory(payload []byte) (*TokenInventory, error) {
-	var i TokenInventory
+// DecodeGetComments decodes a JSON byte slice into a GetComments.
+func DecodeGetComments(payload []byte) (*GetComments, error) {
+	var gc GetComments
 
-	err := json.Unmarshal(payload, &i)
+	err := json.Unmarshal(payload, &gc)
 	if err != nil {
 		return nil, err
 	}
 
-	return &i, nil
+	return &gc, nil
 }
 
-// TokenInventoryReply is the response to the TokenInventory command and
-// returns the tokens of all records in the inventory.  The tokens are
-// categorized by stage of the voting process.  Pre and abandoned tokens are
-// sorted by timestamp in decending order.  Active, approved, and rejected
-// tokens are sorted by voting period end block height in decending order.
-type TokenInventoryReply struct {
-	Pre       []string `json:"pre"`       // Tokens of records that are pre-vote
-	Active    []string `json:"active"`    // Tokens of records with an active voting period
-	Approved  []string `json:"approved"`  // Tokens of records that have been approved by a vote
-	Rejected  []string `json:"rejected"`  // Tokens of records that have been rejected by a vote
-	Abandoned []string `json:"abandoned"` // Tokens of records that have been abandoned
+// GetCommentsReply returns the provided number of comments.
+type GetCommentsReply struct {
+	Comments []Comment `json:"comments"` // Comments
 }
 
-// EncodeTokenInventoryReply encodes a TokenInventoryReply into a JSON byte
-// slice.
-func EncodeTokenInventoryReply(itr TokenInventoryReply) ([]byte, error) {
-	return json.Marshal(itr)
+// EncodeGetCommentsReply encodes GetCommentsReply into a JSON byte slice.
+func EncodeGetCommentsReply(gcr GetCommentsReply) ([]byte, error) {
+	return json.Marshal(gcr)
 }
 
-// DecodeTokenInventoryReply decodes a JSON byte slice into a inventory.
-func DecodeTokenInventoryReply(payload []byte) (*TokenInventoryReply, error) {
-	var itr TokenInventoryReply
+// DecodeGetCommentsReply decodes a JSON byte slice into a GetCommentsReply.
+func DecodeGetCommentsReply(payload []byte) (*GetCommentsReply, error) {
+	var gcr GetCommentsReply
 
-	err := json.Unmarshal(payload, &itr)
+	err := json.Unmarshal(payload, &gcr)
 	if err != nil {
 		return nil, err
 	}
 
-	return &itr, nil
+	return &gcr, nil
 }
 
-// LoadVoteResults creates a vote results entry in the cache for any proposals
-// that have finsished voting but have not yet been added to the lazy loaded
-// vote results table.
-type LoadVoteResults struct {
-	BestBlock uint64 `json:"bestblock"` // Best block height
+// GetCommentsForProposals retrieves the most recent comments of multiple
+// proposals at once.  Limit is the maximum number of comments that are
+// returned for each proposal.  A limit of zero returns all comments.  Hidden
+// comments are not returned.  Censored comments are only excluded when
+// ExcludeCensored is set.
+type GetCommentsForProposals struct {
+	Tokens          []string `json:"tokens"`                    // Proposal IDs
+	Limit           uint32   `json:"limit,omitempty"`           // Maximum number of comments per proposal
+	ExcludeCensored bool     `json:"excludecensored,omitempty"` // Exclude censored comments
 }
 
-// EncodeLoadVoteResults encodes a LoadVoteResults into a JSON byte slice.
-func EncodeLoadVoteResults(lvr LoadVoteResults) ([]byte, error) {
-	return json.Marshal(lvr)
+// EncodeGetCommentsForProposals encodes GetCommentsForProposals into a JSON
+// byte slice.
+func EncodeGetCommentsForProposals(gcfp GetCommentsForProposals) ([]byte, error) {
+	return json.Marshal(gcfp)
 }
 
-// DecodeLoadVoteResults decodes a JSON byte slice into a LoadVoteResults.
-func DecodeLoadVoteResults(payload []byte) (*LoadVoteResults, error) {
-	var lvr LoadVoteResults
+// DecodeGetCommentsForProposals decodes a JSON byte slice into a
+// GetCommentsForProposals.
+func DecodeGetCommentsForProposals(payload []byte) (*GetCommentsForProposals, error) {
+	var gcfp GetCommentsForProposals
 
-	err := json.Unmarshal(payload, &lvr)
+	err := json.Unmarshal(payload, &gcfp)
 	if err != nil {
 		return nil, err
 	}
 
-	return &lvr, nil
+	return &gcfp, nil
 }
 
-// LoadVoteResultsReply is the reply to the LoadVoteResults command.
-type LoadVoteResultsReply struct{}
+// GetCommentsForProposalsReply is the reply to the GetCommentsForProposals
+// command.  The comments are grouped by proposal token and every requested
+// token is present.  The comments of each proposal are sorted by timestamp in
+// descending order.
+type GetCommentsForProposalsReply struct {
+	Comments map[string][]Comment `json:"comments"` // [token]Comments
+}
 
-// EncodeLoadVoteResultsReply encodes a LoadVoteResultsReply into a JSON
-// byte slice.
-func EncodeLoadVoteResultsReply(reply LoadVoteResultsReply) ([]byte, error) {
-	return json.Marshal(reply)
+// EncodeGetCommentsForProposalsReply encodes GetCommentsForProposalsReply
+// into a JSON byte slice.
+func EncodeGetCommentsForProposalsReply(gcfpr GetCommentsForProposalsReply) ([]byte, error) {
+	return json.Marshal(gcfpr)
 }
 
-// DecodeLoadVoteResultsReply decodes a JSON byte slice into a LoadVoteResults.
-func DecodeLoadVoteResultsReply(payload []byte) (*LoadVoteResultsReply, error) {
-	var reply LoadVoteResultsReply
+// DecodeGetCommentsForProposalsReply decodes a JSON byte slice into a
+// GetCommentsForProposalsReply.
+func DecodeGetCommentsForProposalsReply(payload []byte) (*GetCommentsForProposalsReply, error) {
+	var gcfpr GetCommentsForProposalsReply
 
-	err := json.Unmarshal(payload, &reply)
+	err := json.Unmarshal(payload, &gcfpr)
 	if err != nil {
 		return nil, err
 	}
 
-	return &reply, nil
+	return &gcfpr, nil
+}
+
+// CommentedProposals retrieves the proposals that a public key has commented
+// on.
+type CommentedProposals struct {
+	PublicKey string `json:"publickey"` // Public key of the commenter
+}
+
+// EncodeCommentedProposals encodes CommentedProposals into a JSON byte slice.
+func EncodeCommentedProposals(cp CommentedProposals) ([]byte, error) {
+	return json.Marshal(cp)
+}
+
+// DecodeCommentedProposals decodes a JSON byte slice into a
+// CommentedProposals.
+func DecodeCommentedProposals(payload []byte) (*CommentedProposals, error) {
+	var cp CommentedProposals
+
+	err := json.Unmarshal(payload, &cp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cp, nil
+}
+
+// CommentedProposal contains the number of comments that a public key has
+// made on a proposal.  Censored and hidden comments are included in the count.
+type CommentedProposal struct {
+	Token    string `json:"token"`    // Censorship token
+	Comments uint64 `json:"comments"` // Number of comments
+}
+
+// CommentedProposalsReply is the reply to the CommentedProposals command.  The
+// proposals are sorted by token.
+type CommentedProposalsReply struct {
+	Proposals []CommentedProposal `json:"proposals"` // Commented proposals
+}
+
+// EncodeCommentedProposalsReply encodes CommentedProposalsReply into a JSON
+// byte slice.
+func EncodeCommentedProposalsReply(cpr CommentedProposalsReply) ([]byte, error) {
+	return json.Marshal(cpr)
+}
+
+// DecodeCommentedProposalsReply decodes a JSON byte slice into a
+// CommentedProposalsReply.
+func DecodeCommentedProposalsReply(payload []byte) (*CommentedProposalsReply, error) {
+	var cpr CommentedProposalsReply
+
+	err := json.Unmarshal(payload, &cpr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cpr, nil
+}
+
+// GetCommentsSince retrieves the comments of a proposal that were created
+// after the provided UNIX timestamp.  Comments that were censored after the
+// timestamp are included as well so that callers learn about censored
+// comments.  If CommentID is provided, the timestamp of that comment is used
+// instead of Timestamp and all comments that were created in the same second
+// as the last seen comment are returned, excluding the last seen comment
+// itself, since comments cannot be ordered by timestamp alone.  Hidden
+// comments are not returned.
+type GetCommentsSince struct {
+	Token     string `json:"token"`               // Proposal ID
+	Timestamp int64  `json:"timestamp"`           // UNIX timestamp of the last sync
+	CommentID string `json:"commentid,omitempty"` // Last seen comment ID
+}
+
+// EncodeGetCommentsSince encodes a GetCommentsSince into a JSON byte slice.
+func EncodeGetCommentsSince(gcs GetCommentsSince) ([]byte, error) {
+	return json.Marshal(gcs)
+}
+
+// DecodeGetCommentsSince decodes a JSON byte slice into a GetCommentsSince.
+func DecodeGetCommentsSince(payload []byte) (*GetCommentsSince, error) {
+	var gcs GetCommentsSince
+
+	err := json.Unmarshal(payload, &gcs)
+	if err != nil {
+		return nil, err
+	}
+
+	return &gcs, nil
+}
+
+// GetCommentsSinceReply returns the comments that were created or censored
+// after the provided timestamp, ordered by creation timestamp in ascending
+// order.
+type GetCommentsSinceReply struct {
+	Comments []Comment `json:"comments"` // Comments
+}
+
+// EncodeGetCommentsSinceReply encodes a GetCommentsSinceReply into a JSON
+// byte slice.
+func EncodeGetCommentsSinceReply(gcsr GetCommentsSinceReply) ([]byte, error) {
+	return json.Marshal(gcsr)
+}
+
+// DecodeGetCommentsSinceReply decodes a JSON byte slice into a
+// GetCommentsSinceReply.
+func DecodeGetCommentsSinceReply(payload []byte) (*GetCommentsSinceReply, error) {
+	var gcsr GetCommentsSinceReply
+
+	err := json.Unmarshal(payload, &gcsr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &gcsr, nil
+}
+
+// CommentsModifiedSince retrieves the changes that were made to the comments
+// of a record after the passed in timestamp.  It allows a mirror to apply the
+// changes incrementally.  Hidden comments are not returned.
+type CommentsModifiedSince struct {
+	Token     string `json:"token"`     // Censorship token
+	Timestamp int64  `json:"timestamp"` // UNIX timestamp
+}
+
+// EncodeCommentsModifiedSince encodes a CommentsModifiedSince into a JSON byte
+// slice.
+func EncodeCommentsModifiedSince(cms CommentsModifiedSince) ([]byte, error) {
+	return json.Marshal(cms)
+}
+
+// DecodeCommentsModifiedSince decodes a JSON byte slice into a
+// CommentsModifiedSince.
+func DecodeCommentsModifiedSince(payload []byte) (*CommentsModifiedSince, error) {
+	var cms CommentsModifiedSince
+
+	err := json.Unmarshal(payload, &cms)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cms, nil
+}
+
+// CommentsModifiedSinceReply is the reply to the CommentsModifiedSince
+// command.  The comment IDs are the IDs of the comments that were created,
+// edited or censored after the timestamp, in ascending order.  The likes are
+// the comment likes that were added after the timestamp, in the order that
+// they were added.
+type CommentsModifiedSinceReply struct {
+	CommentIDs []string      `json:"commentids"` // Modified comment IDs
+	Likes      []LikeComment `json:"likes"`      // Added comment likes
+}
+
+// EncodeCommentsModifiedSinceReply encodes a CommentsModifiedSinceReply into
+// a JSON byte slice.
+func EncodeCommentsModifiedSinceReply(cmsr CommentsModifiedSinceReply) ([]byte, error) {
+	return json.Marshal(cmsr)
+}
+
+// DecodeCommentsModifiedSinceReply decodes a JSON byte slice into a
+// CommentsModifiedSinceReply.
+func DecodeCommentsModifiedSinceReply(payload []byte) (*CommentsModifiedSinceReply, error) {
+	var cmsr CommentsModifiedSinceReply
+
+	err := json.Unmarshal(payload, &cmsr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cmsr, nil
+}
+
+// CensoredComments retrieves all censored comments.  If a token is provided,
+// only the censored comments for that record are returned.
+type CensoredComments struct {
+	Token string `json:"token,omitempty"` // Censorship token (optional)
+}
+
+// EncodeCensoredComments encodes CensoredComments into a JSON byte slice.
+func EncodeCensoredComments(cc CensoredComments) ([]byte, error) {
+	return json.Marshal(cc)
+}
+
+// DecodeCensoredComments decodes a JSON byte slice into a CensoredComments.
+func DecodeCensoredComments(payload []byte) (*CensoredComments, error) {
+	var cc CensoredComments
+
+	err := json.Unmarshal(payload, &cc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cc, nil
+}
+
+// CensoredCommentsReply is the reply to the CensoredComments command and
+// returns the censored comments.
+type CensoredCommentsReply struct {
+	Comments []Comment `json:"comments"` // Censored comments
+}
+
+// EncodeCensoredCommentsReply encodes CensoredCommentsReply into a JSON byte
+// slice.
+func EncodeCensoredCommentsReply(ccr CensoredCommentsReply) ([]byte, error) {
+	return json.Marshal(ccr)
+}
+
+// DecodeCensoredCommentsReply decodes a JSON byte slice into a
+// CensoredCommentsReply.
+func DecodeCensoredCommentsReply(payload []byte) (*CensoredCommentsReply, error) {
+	var ccr CensoredCommentsReply
+
+	err := json.Unmarshal(payload, &ccr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ccr, nil
+}
+
+// CommentLikes is used to retrieve all of the comment likes for a single
+// record comment.
+type CommentLikes struct {
+	Token     string `json:"token"`     // Censorship token
+	CommentID string `json:"commentid"` // Comment ID
+}
+
+// EncodeCommentLikes encodes CommentLikes into a JSON byte slice.
+func EncodeCommentLikes(gpcv CommentLikes) ([]byte, error) {
+	return json.Marshal(gpcv)
+}
+
+// DecodeCommentLikes decodes a JSON byte slice into a CommentLikes.
+func DecodeCommentLikes(payload []byte) (*CommentLikes, error) {
+	var cl CommentLikes
+
+	err := json.Unmarshal(payload, &cl)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cl, nil
+}
+
+// CommentLikesReply is the reply to CommentLikes and returns all of the
+// upvote/downvote actions for the specified comment along with the current
+// like of each public key.  Public keys whose like was undone are not included
+// in Current.
+type CommentLikesReply struct {
+	CommentLikes []LikeComment `json:"commentlikes"`
+	Current      []LikeComment `json:"current"`
+}
+
+// EncodeCommentLikesReply encodes EncodeCommentLikesReply into a JSON byte
+// slice.
+func EncodeCommentLikesReply(clr CommentLikesReply) ([]byte, error) {
+	return json.Marshal(clr)
+}
+
+// DecodeCommentLikesReply decodes a JSON byte slice into a CommentLikesReply.
+func DecodeCommentLikesReply(payload []byte) (*CommentLikesReply, error) {
+	var clr CommentLikesReply
+
+	err := json.Unmarshal(payload, &clr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &clr, nil
+}
+
+// GetProposalCommentsLikes is a command to fetch all vote actions
+// on the comments of a given proposal
+type GetProposalCommentsLikes struct {
+	Token string `json:"token"` // Censorship token
+}
+
+// EncodeGetProposalCommentsLikes encodes GetProposalCommentsLikes into a JSON byte slice.
+func EncodeGetProposalCommentsLikes(gpcv GetProposalCommentsLikes) ([]byte, error) {
+	return json.Marshal(gpcv)
+}
+
+// DecodeGetProposalCommentsLikes decodes a JSON byte slice into a GetProposalCommentsLikes.
+func DecodeGetProposalCommentsLikes(payload []byte) (*GetProposalCommentsLikes, error) {
+	var gpcl GetProposalCommentsLikes
+
+	err := json.Unmarshal(payload, &gpcl)
+	if err != nil {
+		return nil, err
+	}
+
+	return &gpcl, nil
+}
+
+// GetProposalCommentsLikesReply is a reply with all vote actions
+// for the comments of a given proposal
+type GetProposalCommentsLikesReply struct {
+	CommentsLikes []LikeComment `json:"commentslikes"`
+}
+
+// EncodeGetProposalCommentsLikesReply encodes EncodeGetProposalCommentsLikesReply into a JSON byte slice.
+func EncodeGetProposalCommentsLikesReply(gpclr GetProposalCommentsLikesReply) ([]byte, error) {
+	return json.Marshal(gpclr)
+}
+
+// DecodeGetProposalCommentsLikesReply decodes a JSON byte slice into a GetProposalCommentsLikesReply.
+func DecodeGetProposalCommentsLikesReply(payload []byte) (*GetProposalCommentsLikesReply, error) {
+	var gpclr GetProposalCommentsLikesReply
+
+	err := json.Unmarshal(payload, &gpclr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &gpclr, nil
+}
+
+// GetProposalCommentsLikeCounts is a command to fetch the aggregated like
+// counts of each comment of a given proposal.
+type GetProposalCommentsLikeCounts struct {
+	Token string `json:"token"` // Censorship token
+}
+
+// EncodeGetProposalCommentsLikeCounts encodes GetProposalCommentsLikeCounts
+// into a JSON byte slice.
+func EncodeGetProposalCommentsLikeCounts(g GetProposalCommentsLikeCounts) ([]byte, error) {
+	return json.Marshal(g)
+}
+
+// DecodeGetProposalCommentsLikeCounts decodes a JSON byte slice into a
+// GetProposalCommentsLikeCounts.
+func DecodeGetProposalCommentsLikeCounts(payload []byte) (*GetProposalCommentsLikeCounts, error) {
+	var g GetProposalCommentsLikeCounts
+
+	err := json.Unmarshal(payload, &g)
+	if err != nil {
+		return nil, err
+	}
+
+	return &g, nil
+}
+
+// CommentLikeCounts contains the number of upvote and downvote actions that
+// have been cast on a comment.  The counts are of the raw like actions and do
+// not account for a user undoing a previous action by repeating it.
+type CommentLikeCounts struct {
+	CommentID string `json:"commentid"` // Comment ID
+	Upvotes   uint64 `json:"upvotes"`   // Number of upvote actions
+	Downvotes uint64 `json:"downvotes"` // Number of downvote actions
+	Score     int64  `json:"score"`     // Upvotes minus downvotes
+}
+
+// GetProposalCommentsLikeCountsReply is the reply to the
+// GetProposalCommentsLikeCounts command.  Comments without any likes are not
+// included.
+type GetProposalCommentsLikeCountsReply struct {
+	LikeCounts []CommentLikeCounts `json:"likecounts"`
+}
+
+// EncodeGetProposalCommentsLikeCountsReply encodes
+// GetProposalCommentsLikeCountsReply into a JSON byte slice.
+func EncodeGetProposalCommentsLikeCountsReply(r GetProposalCommentsLikeCountsReply) ([]byte, error) {
+	return json.Marshal(r)
+}
+
+// DecodeGetProposalCommentsLikeCountsReply decodes a JSON byte slice into a
+// GetProposalCommentsLikeCountsReply.
+func DecodeGetProposalCommentsLikeCountsReply(payload []byte) (*GetProposalCommentsLikeCountsReply, error) {
+	var r GetProposalCommentsLikeCountsReply
+
+	err := json.Unmarshal(payload, &r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+// CommentLikesNetScore retrieves the net like score of each of the passed in
+// comments of a proposal.  The score is calculated from the current like of
+// each user so that undone and replaced likes are not counted.
+type CommentLikesNetScore struct {
+	Token      string   `json:"token"`      // Censorship token
+	CommentIDs []string `json:"commentids"` // Comment IDs
+}
+
+// EncodeCommentLikesNetScore encodes CommentLikesNetScore into a JSON byte
+// slice.
+func EncodeCommentLikesNetScore(c CommentLikesNetScore) ([]byte, error) {
+	return json.Marshal(c)
+}
+
+// DecodeCommentLikesNetScore decodes a JSON byte slice into a
+// CommentLikesNetScore.
+func DecodeCommentLikesNetScore(payload []byte) (*CommentLikesNetScore, error) {
+	var c CommentLikesNetScore
+
+	err := json.Unmarshal(payload, &c)
+	if err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+// CommentLikesNetScoreReply is the reply to the CommentLikesNetScore command.
+// Scores contains an entry for every requested comment.  Comments without any
+// likes, including comments that do not exist, have a score of zero.
+type CommentLikesNetScoreReply struct {
+	Scores map[string]int64 `json:"scores"` // [commentID]netScore
+}
+
+// EncodeCommentLikesNetScoreReply encodes CommentLikesNetScoreReply into a
+// JSON byte slice.
+func EncodeCommentLikesNetScoreReply(c CommentLikesNetScoreReply) ([]byte, error) {
+	return json.Marshal(c)
+}
+
+// DecodeCommentLikesNetScoreReply decodes a JSON byte slice into a
+// CommentLikesNetScoreReply.
+func DecodeCommentLikesNetScoreReply(payload []byte) (*CommentLikesNetScoreReply, error) {
+	var c CommentLikesNetScoreReply
+
+	err := json.Unmarshal(payload, &c)
+	if err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+// TopComments retrieves the uncensored comments of a proposal with the highest
+// net like score.  The score of a comment is the number of current upvotes
+// minus the number of current downvotes.  A limit of zero returns all
+// uncensored comments.  Hidden comments are not returned.
+type TopComments struct {
+	Token string `json:"token"`           // Proposal ID
+	Limit uint32 `json:"limit,omitempty"` // Maximum number of comments
+}
+
+// EncodeTopComments encodes TopComments into a JSON byte slice.
+func EncodeTopComments(t TopComments) ([]byte, error) {
+	return json.Marshal(t)
+}
+
+// DecodeTopComments decodes a JSON byte slice into a TopComments.
+func DecodeTopComments(payload []byte) (*TopComments, error) {
+	var t TopComments
+
+	err := json.Unmarshal(payload, &t)
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+// TopCommentsReply is the reply to the TopComments command.  The comments are
+// ordered by score in descending order with ties broken by the oldest comment
+// first.  The TotalVotes and ResultVotes of each comment are set to the number
+// of current likes and the net score.
+type TopCommentsReply struct {
+	Comments []Comment `json:"comments"`
+}
+
+// EncodeTopCommentsReply encodes TopCommentsReply into a JSON byte slice.
+func EncodeTopCommentsReply(t TopCommentsReply) ([]byte, error) {
+	return json.Marshal(t)
+}
+
+// DecodeTopCommentsReply decodes a JSON byte slice into a TopCommentsReply.
+func DecodeTopCommentsReply(payload []byte) (*TopCommentsReply, error) {
+	var t TopCommentsReply
+
+	err := json.Unmarshal(payload, &t)
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+// RecordsByStatus retrieves the tokens of all records whose latest version has
+// the provided politeiad record status.  Offset and Limit page through the
+// tokens.  All tokens after the offset are returned when Limit is not set.
+type RecordsByStatus struct {
+	Status int    `json:"status"`           // Record status
+	Offset uint32 `json:"offset,omitempty"` // Number of tokens to skip
+	Limit  uint32 `json:"limit,omitempty"`  // Maximum number of tokens
+}
+
+// EncodeRecordsByStatus encodes RecordsByStatus into a JSON byte slice.
+func EncodeRecordsByStatus(r RecordsByStatus) ([]byte, error) {
+	return json.Marshal(r)
+}
+
+// DecodeRecordsByStatus decodes a JSON byte slice into a RecordsByStatus.
+func DecodeRecordsByStatus(payload []byte) (*RecordsByStatus, error) {
+	var r RecordsByStatus
+
+	err := json.Unmarshal(payload, &r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+// RecordsByStatusReply is the reply to the RecordsByStatus command.  The
+// tokens are sorted by timestamp in ascending order.
+type RecordsByStatusReply struct {
+	Tokens []string `json:"tokens"` // Record tokens
+}
+
+// EncodeRecordsByStatusReply encodes RecordsByStatusReply into a JSON byte
+// slice.
+func EncodeRecordsByStatusReply(r RecordsByStatusReply) ([]byte, error) {
+	return json.Marshal(r)
+}
+
+// DecodeRecordsByStatusReply decodes a JSON byte slice into a
+// RecordsByStatusReply.
+func DecodeRecordsByStatusReply(payload []byte) (*RecordsByStatusReply, error) {
+	var r RecordsByStatusReply
+
+	err := json.Unmarshal(payload, &r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+// RecordStatusCounts retrieves the number of records whose latest version has
+// each politeiad record status.
+type RecordStatusCounts struct{}
+
+// EncodeRecordStatusCounts encodes RecordStatusCounts into a JSON byte slice.
+func EncodeRecordStatusCounts(r RecordStatusCounts) ([]byte, error) {
+	return json.Marshal(r)
+}
+
+// DecodeRecordStatusCounts decodes a JSON byte slice into a
+// RecordStatusCounts.
+func DecodeRecordStatusCounts(payload []byte) (*RecordStatusCounts, error) {
+	var r RecordStatusCounts
+
+	err := json.Unmarshal(payload, &r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+// RecordStatusCountsReply is the reply to the RecordStatusCounts command.
+// Counts contains an entry for every status that a record can have, keyed by
+// politeiad record status.
+type RecordStatusCountsReply struct {
+	Counts map[int]uint64 `json:"counts"` // [status]recordCount
+}
+
+// EncodeRecordStatusCountsReply encodes RecordStatusCountsReply into a JSON
+// byte slice.
+func EncodeRecordStatusCountsReply(r RecordStatusCountsReply) ([]byte, error) {
+	return json.Marshal(r)
+}
+
+// DecodeRecordStatusCountsReply decodes a JSON byte slice into a
+// RecordStatusCountsReply.
+func DecodeRecordStatusCountsReply(payload []byte) (*RecordStatusCountsReply, error) {
+	var r RecordStatusCountsReply
+
+	err := json.Unmarshal(payload, &r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+// VoteResultsByStatus retrieves the final vote results of all proposals whose
+// vote was approved or, when Approved is false, of all proposals whose vote
+// was rejected.
+type VoteResultsByStatus struct {
+	Approved bool `json:"approved"` // Approved or rejected votes
+}
+
+// EncodeVoteResultsByStatus encodes VoteResultsByStatus into a JSON byte
+// slice.
+func EncodeVoteResultsByStatus(v VoteResultsByStatus) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// DecodeVoteResultsByStatus decodes a JSON byte slice into a
+// VoteResultsByStatus.
+func DecodeVoteResultsByStatus(payload []byte) (*VoteResultsByStatus, error) {
+	var v VoteResultsByStatus
+
+	err := json.Unmarshal(payload, &v)
+	if err != nil {
+		return nil, err
+	}
+
+	return &v, nil
+}
+
+// TokenVoteResults contains the final vote results of a proposal.
+type TokenVoteResults struct {
+	Token    string             `json:"token"`    // Censorship token
+	Approved bool               `json:"approved"` // Vote was approved
+	Results  []VoteOptionResult `json:"results"`  // Final vote option results
+}
+
+// VoteResultsByStatusReply is the reply to the VoteResultsByStatus command.
+// The vote results are ordered by token.
+type VoteResultsByStatusReply struct {
+	VoteResults []TokenVoteResults `json:"voteresults"`
+}
+
+// EncodeVoteResultsByStatusReply encodes VoteResultsByStatusReply into a JSON
+// byte slice.
+func EncodeVoteResultsByStatusReply(v VoteResultsByStatusReply) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// DecodeVoteResultsByStatusReply decodes a JSON byte slice into a
+// VoteResultsByStatusReply.
+func DecodeVoteResultsByStatusReply(payload []byte) (*VoteResultsByStatusReply, error) {
+	var v VoteResultsByStatusReply
+
+	err := json.Unmarshal(payload, &v)
+	if err != nil {
+		return nil, err
+	}
+
+	return &v, nil
+}
+
+// ArchiveStats retrieves aggregate statistics of the records whose latest
+// version has been archived, i.e. the abandoned proposals.
+type ArchiveStats struct{}
+
+// EncodeArchiveStats encodes ArchiveStats into a JSON byte slice.
+func EncodeArchiveStats(a ArchiveStats) ([]byte, error) {
+	return json.Marshal(a)
+}
+
+// DecodeArchiveStats decodes a JSON byte slice into an ArchiveStats.
+func DecodeArchiveStats(payload []byte) (*ArchiveStats, error) {
+	var a ArchiveStats
+
+	err := json.Unmarshal(payload, &a)
+	if err != nil {
+		return nil, err
+	}
+
+	return &a, nil
+}
+
+// ArchiveStatsReply is the reply to the ArchiveStats command.  The age of an
+// abandoned proposal is the time between the timestamp of its first version
+// and the timestamp at which it was archived.
+type ArchiveStatsReply struct {
+	Abandoned  uint64 `json:"abandoned"`  // Number of abandoned proposals
+	Comments   uint64 `json:"comments"`   // Total comments on abandoned proposals
+	AverageAge int64  `json:"averageage"` // Average age in seconds when abandoned
+}
+
+// EncodeArchiveStatsReply encodes ArchiveStatsReply into a JSON byte slice.
+func EncodeArchiveStatsReply(a ArchiveStatsReply) ([]byte, error) {
+	return json.Marshal(a)
+}
+
+// DecodeArchiveStatsReply decodes a JSON byte slice into an
+// ArchiveStatsReply.
+func DecodeArchiveStatsReply(payload []byte) (*ArchiveStatsReply, error) {
+	var a ArchiveStatsReply
+
+	err := json.Unmarshal(payload, &a)
+	if err != nil {
+		return nil, err
+	}
+
+	return &a, nil
+}
+
+// RecordHistory retrieves the version history of a record.
+type RecordHistory struct {
+	Token string `json:"token"` // Censorship token
+}
+
+// EncodeRecordHistory encodes RecordHistory into a JSON byte slice.
+func EncodeRecordHistory(r RecordHistory) ([]byte, error) {
+	return json.Marshal(r)
+}
+
+// DecodeRecordHistory decodes a JSON byte slice into a RecordHistory.
+func DecodeRecordHistory(payload []byte) (*RecordHistory, error) {
+	var r RecordHistory
+
+	err := json.Unmarshal(payload, &r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+// RecordHistoryVersion describes a single version of a record.
+type RecordHistoryVersion struct {
+	Version   uint64 `json:"version"`   // Record version
+	Status    int    `json:"status"`    // Record status
+	Timestamp int64  `json:"timestamp"` // UNIX timestamp of last update
+}
+
+// RecordHistoryReply is the reply to the RecordHistory command.  The versions
+// are sorted by version in ascending order.
+type RecordHistoryReply struct {
+	Versions []RecordHistoryVersion `json:"versions"` // Record versions
+}
+
+// EncodeRecordHistoryReply encodes RecordHistoryReply into a JSON byte slice.
+func EncodeRecordHistoryReply(r RecordHistoryReply) ([]byte, error) {
+	return json.Marshal(r)
+}
+
+// DecodeRecordHistoryReply decodes a JSON byte slice into a
+// RecordHistoryReply.
+func DecodeRecordHistoryReply(payload []byte) (*RecordHistoryReply, error) {
+	var r RecordHistoryReply
+
+	err := json.Unmarshal(payload, &r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+// MetadataStream is a metadata stream of a record.
+type MetadataStream struct {
+	ID      uint64 `json:"id"`      // Stream identity
+	Payload string `json:"payload"` // String encoded metadata
+}
+
+// GetProposalMetadata retrieves the metadata streams of a proposal without
+// its files.  The most recent version is used when no version is provided.
+type GetProposalMetadata struct {
+	Token   string `json:"token"`             // Censorship token
+	Version string `json:"version,omitempty"` // Record version
+}
+
+// EncodeGetProposalMetadata encodes GetProposalMetadata into a JSON byte
+// slice.
+func EncodeGetProposalMetadata(g GetProposalMetadata) ([]byte, error) {
+	return json.Marshal(g)
+}
+
+// DecodeGetProposalMetadata decodes a JSON byte slice into a
+// GetProposalMetadata.
+func DecodeGetProposalMetadata(payload []byte) (*GetProposalMetadata, error) {
+	var g GetProposalMetadata
+
+	err := json.Unmarshal(payload, &g)
+	if err != nil {
+		return nil, err
+	}
+
+	return &g, nil
+}
+
+// GetProposalMetadataReply is the reply to the GetProposalMetadata command.
+type GetProposalMetadataReply struct {
+	Version  string           `json:"version"`  // Record version
+	Metadata []MetadataStream `json:"metadata"` // Metadata streams
+}
+
+// EncodeGetProposalMetadataReply encodes GetProposalMetadataReply into a
+// JSON byte slice.
+func EncodeGetProposalMetadataReply(g GetProposalMetadataReply) ([]byte, error) {
+	return json.Marshal(g)
+}
+
+// DecodeGetProposalMetadataReply decodes a JSON byte slice into a
+// GetProposalMetadataReply.
+func DecodeGetProposalMetadataReply(payload []byte) (*GetProposalMetadataReply, error) {
+	var g GetProposalMetadataReply
+
+	err := json.Unmarshal(payload, &g)
+	if err != nil {
+		return nil, err
+	}
+
+	return &g, nil
+}
+
+// RecordFilesManifest retrieves the manifest of the files of a proposal
+// without the file payloads.  The most recent version is used when no version
+// is provided.
+type RecordFilesManifest struct {
+	Token   string `json:"token"`             // Censorship token
+	Version string `json:"version,omitempty"` // Record version
+}
+
+// EncodeRecordFilesManifest encodes RecordFilesManifest into a JSON byte
+// slice.
+func EncodeRecordFilesManifest(r RecordFilesManifest) ([]byte, error) {
+	return json.Marshal(r)
+}
+
+// DecodeRecordFilesManifest decodes a JSON byte slice into a
+// RecordFilesManifest.
+func DecodeRecordFilesManifest(payload []byte) (*RecordFilesManifest, error) {
+	var r RecordFilesManifest
+
+	err := json.Unmarshal(payload, &r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+// FileManifest describes a record file without its payload.
+type FileManifest struct {
+	Name   string `json:"name"`   // Basename of the file
+	MIME   string `json:"mime"`   // MIME type
+	Digest string `json:"digest"` // SHA256 of the decoded payload
+	Size   int64  `json:"size"`   // Size of the decoded payload in bytes
+}
+
+// RecordFilesManifestReply is the reply to the RecordFilesManifest command.
+type RecordFilesManifestReply struct {
+	Version string         `json:"version"` // Record version
+	Files   []FileManifest `json:"files"`   // File manifests
+}
+
+// EncodeRecordFilesManifestReply encodes RecordFilesManifestReply into a
+// JSON byte slice.
+func EncodeRecordFilesManifestReply(r RecordFilesManifestReply) ([]byte, error) {
+	return json.Marshal(r)
+}
+
+// DecodeRecordFilesManifestReply decodes a JSON byte slice into a
+// RecordFilesManifestReply.
+func DecodeRecordFilesManifestReply(payload []byte) (*RecordFilesManifestReply, error) {
+	var r RecordFilesManifestReply
+
+	err := json.Unmarshal(payload, &r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+// GetLatestRecordVersion retrieves the version and status of the most recent
+// version of a record.
+type GetLatestRecordVersion struct {
+	Token string `json:"token"` // Censorship token
+}
+
+// EncodeGetLatestRecordVersion encodes GetLatestRecordVersion into a JSON byte
+// slice.
+func EncodeGetLatestRecordVersion(g GetLatestRecordVersion) ([]byte, error) {
+	return json.Marshal(g)
+}
+
+// DecodeGetLatestRecordVersion decodes a JSON byte slice into a
+// GetLatestRecordVersion.
+func DecodeGetLatestRecordVersion(payload []byte) (*GetLatestRecordVersion, error) {
+	var g GetLatestRecordVersion
+
+	err := json.Unmarshal(payload, &g)
+	if err != nil {
+		return nil, err
+	}
+
+	return &g, nil
+}
+
+// GetLatestRecordVersionReply is the reply to the GetLatestRecordVersion
+// command.
+type GetLatestRecordVersionReply struct {
+	Version string `json:"version"` // Latest record version
+	Status  int    `json:"status"`  // Status of the latest record version
+}
+
+// EncodeGetLatestRecordVersionReply encodes GetLatestRecordVersionReply into a
+// JSON byte slice.
+func EncodeGetLatestRecordVersionReply(g GetLatestRecordVersionReply) ([]byte, error) {
+	return json.Marshal(g)
+}
+
+// DecodeGetLatestRecordVersionReply decodes a JSON byte slice into a
+// GetLatestRecordVersionReply.
+func DecodeGetLatestRecordVersionReply(payload []byte) (*GetLatestRecordVersionReply, error) {
+	var g GetLatestRecordVersionReply
+
+	err := json.Unmarshal(payload, &g)
+	if err != nil {
+		return nil, err
+	}
+
+	return &g, nil
+}
+
+// GetRecordTimestampRange retrieves the tokens of all public records whose
+// latest version has a timestamp within the provided range.  Both the start
+// and end timestamps are inclusive.
+type GetRecordTimestampRange struct {
+	Start int64 `json:"start"` // Start UNIX timestamp
+	End   int64 `json:"end"`   // End UNIX timestamp
+}
+
+// EncodeGetRecordTimestampRange encodes GetRecordTimestampRange into a JSON
+// byte slice.
+func EncodeGetRecordTimestampRange(g GetRecordTimestampRange) ([]byte, error) {
+	return json.Marshal(g)
+}
+
+// DecodeGetRecordTimestampRange decodes a JSON byte slice into a
+// GetRecordTimestampRange.
+func DecodeGetRecordTimestampRange(payload []byte) (*GetRecordTimestampRange, error) {
+	var g GetRecordTimestampRange
+
+	err := json.Unmarshal(payload, &g)
+	if err != nil {
+		return nil, err
+	}
+
+	return &g, nil
+}
+
+// GetRecordTimestampRangeReply is the reply to the GetRecordTimestampRange
+// command.  The tokens are sorted by timestamp in ascending order.
+type GetRecordTimestampRangeReply struct {
+	Tokens []string `json:"tokens"` // Record tokens
+}
+
+// EncodeGetRecordTimestampRangeReply encodes GetRecordTimestampRangeReply
+// into a JSON byte slice.
+func EncodeGetRecordTimestampRangeReply(r GetRecordTimestampRangeReply) ([]byte, error) {
+	return json.Marshal(r)
+}
+
+// DecodeGetRecordTimestampRangeReply decodes a JSON byte slice into a
+// GetRecordTimestampRangeReply.
+func DecodeGetRecordTimestampRangeReply(payload []byte) (*GetRecordTimestampRangeReply, error) {
+	var r GetRecordTimestampRangeReply
+
+	err := json.Unmarshal(payload, &r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+// ActivityWindow retrieves the number of comments and cast votes of every
+// proposal that had activity within the provided timestamp range.  Both the
+// start and end timestamps are inclusive.  Comments are bucketed by their
+// received timestamp and cast votes by the time they were added to the cache.
+// Cast votes that were added before cast vote timestamps were recorded are
+// not counted.
+type ActivityWindow struct {
+	Start int64 `json:"start"` // Start UNIX timestamp
+	End   int64 `json:"end"`   // End UNIX timestamp
+}
+
+// EncodeActivityWindow encodes an ActivityWindow into a JSON byte slice.
+func EncodeActivityWindow(aw ActivityWindow) ([]byte, error) {
+	return json.Marshal(aw)
+}
+
+// DecodeActivityWindow decodes a JSON byte slice into an ActivityWindow.
+func DecodeActivityWindow(payload []byte) (*ActivityWindow, error) {
+	var aw ActivityWindow
+
+	err := json.Unmarshal(payload, &aw)
+	if err != nil {
+		return nil, err
+	}
+
+	return &aw, nil
+}
+
+// ProposalActivity contains the number of comments and cast votes of a
+// proposal within an activity window.
+type ProposalActivity struct {
+	Token     string `json:"token"`     // Proposal ID
+	Comments  uint64 `json:"comments"`  // Number of comments
+	CastVotes uint64 `json:"castvotes"` // Number of cast votes
+}
+
+// ActivityWindowReply is the reply to the ActivityWindow command.  Only
+// proposals that had activity within the window are included and they are
+// sorted by token.
+type ActivityWindowReply struct {
+	Activity []ProposalActivity `json:"activity"` // Proposal activity
+}
+
+// EncodeActivityWindowReply encodes an ActivityWindowReply into a JSON byte
+// slice.
+func EncodeActivityWindowReply(r ActivityWindowReply) ([]byte, error) {
+	return json.Marshal(r)
+}
+
+// DecodeActivityWindowReply decodes a JSON byte slice into an
+// ActivityWindowReply.
+func DecodeActivityWindowReply(payload []byte) (*ActivityWindowReply, error) {
+	var r ActivityWindowReply
+
+	err := json.Unmarshal(payload, &r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+// CommentRate retrieves the number of comments of a proposal that were
+// received within the provided window along with the total number of comments
+// of the proposal.  The window ends at the time the command is executed and is
+// inclusive of its start.  Comments are bucketed by their received timestamp
+// and censored comments are counted as well.
+type CommentRate struct {
+	Token  string `json:"token"`  // Proposal ID
+	Window int64  `json:"window"` // Window duration in seconds
+}
+
+// EncodeCommentRate encodes a CommentRate into a JSON byte slice.
+func EncodeCommentRate(cr CommentRate) ([]byte, error) {
+	return json.Marshal(cr)
+}
+
+// DecodeCommentRate decodes a JSON byte slice into a CommentRate.
+func DecodeCommentRate(payload []byte) (*CommentRate, error) {
+	var cr CommentRate
+
+	err := json.Unmarshal(payload, &cr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cr, nil
+}
+
+// CommentRateReply is the reply to the CommentRate command.
+type CommentRateReply struct {
+	Start    int64  `json:"start"`    // Start UNIX timestamp of the window
+	Comments uint64 `json:"comments"` // Number of comments within the window
+	Total    uint64 `json:"total"`    // Total number of comments
+}
+
+// EncodeCommentRateReply encodes a CommentRateReply into a JSON byte slice.
+func EncodeCommentRateReply(crr CommentRateReply) ([]byte, error) {
+	return json.Marshal(crr)
+}
+
+// DecodeCommentRateReply decodes a JSON byte slice into a CommentRateReply.
+func DecodeCommentRateReply(payload []byte) (*CommentRateReply, error) {
+	var crr CommentRateReply
+
+	err := json.Unmarshal(payload, &crr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &crr, nil
+}
+
+// VerifyCounts compares the number of records, comments and cast votes in the
+// cache against the counts of the source of truth that are supplied by the
+// caller.  Records are counted by token.  Cast votes that were archived are
+// included in the cache cast vote count.  A table diverges when the absolute
+// difference between the cache count and the source count exceeds Threshold.
+type VerifyCounts struct {
+	Records   uint64 `json:"records"`   // Number of records in the source
+	Comments  uint64 `json:"comments"`  // Number of comments in the source
+	CastVotes uint64 `json:"castvotes"` // Number of cast votes in the source
+	Threshold uint64 `json:"threshold"` // Maximum allowed absolute difference
+}
+
+// EncodeVerifyCounts encodes a VerifyCounts into a JSON byte slice.
+func EncodeVerifyCounts(vc VerifyCounts) ([]byte, error) {
+	return json.Marshal(vc)
+}
+
+// DecodeVerifyCounts decodes a JSON byte slice into a VerifyCounts.
+func DecodeVerifyCounts(payload []byte) (*VerifyCounts, error) {
+	var vc VerifyCounts
+
+	err := json.Unmarshal(payload, &vc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &vc, nil
+}
+
+// CountDelta contains the difference between the cache count and the source
+// count of a single table.
+type CountDelta struct {
+	Table    string `json:"table"`    // Verify counts table
+	Cache    uint64 `json:"cache"`    // Cache count
+	Source   uint64 `json:"source"`   // Source count
+	Delta    int64  `json:"delta"`    // Cache count minus source count
+	Diverged bool   `json:"diverged"` // Delta exceeds the threshold
+}
+
+// VerifyCountsReply is the reply to the VerifyCounts command.  The deltas are
+// returned in the order records, comments, cast votes.
+type VerifyCountsReply struct {
+	Deltas   []CountDelta `json:"deltas"`   // Per table deltas
+	Diverged bool         `json:"diverged"` // Any table diverged
+}
+
+// EncodeVerifyCountsReply encodes a VerifyCountsReply into a JSON byte
+// slice.
+func EncodeVerifyCountsReply(vcr VerifyCountsReply) ([]byte, error) {
+	return json.Marshal(vcr)
+}
+
+// DecodeVerifyCountsReply decodes a JSON byte slice into a
+// VerifyCountsReply.
+func DecodeVerifyCountsReply(payload []byte) (*VerifyCountsReply, error) {
+	var vcr VerifyCountsReply
+
+	err := json.Unmarshal(payload, &vcr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &vcr, nil
+}
+
+// Inventory is used to retrieve the fonero plugin inventory.
+type Inventory struct{}
+
+// EncodeInventory encodes Inventory into a JSON byte slice.
+func EncodeInventory(i Inventory) ([]byte, error) {
+	return json.Marshal(i)
+}
+
+// DecodeInventory decodes a JSON byte slice into a Inventory.
+func DecodeInventory(payload []byte) (*Inventory, error) {
+	var i Inventory
+
+	err := json.Unmarshal(payload, &i)
+	if err != nil {
+		return nil, err
+	}
+
+	return &i, nil
+}
+
+// StartVoteTuple is used to return the StartVote and StartVoteReply for a
+// record. StartVoteReply does not contain any record identifying data so it
+// must be returned with the StartVote in order to know what record it belongs
+// to.
+type StartVoteTuple struct {
+	StartVote      StartVote      `json:"startvote"`      // Start vote
+	StartVoteReply StartVoteReply `json:"startvotereply"` // Start vote reply
+}
+
+// InventoryReply returns the fonero plugin inventory.
+type InventoryReply struct {
+	Comments             []Comment            `json:"comments"`             // Comments
+	LikeComments         []LikeComment        `json:"likecomments"`         // Like comments
+	AuthorizeVotes       []AuthorizeVote      `json:"authorizevotes"`       // Authorize votes
+	AuthorizeVoteReplies []AuthorizeVoteReply `json:"authorizevotereplies"` // Authorize vote replies
+	StartVoteTuples      []StartVoteTuple     `json:"startvotetuples"`      // Start vote tuples
+	CastVotes            []CastVote           `json:"castvotes"`            // Cast votes
+}
+
+// EncodeInventoryReply encodes a InventoryReply into a JSON byte slice.
+func EncodeInventoryReply(ir InventoryReply) ([]byte, error) {
+	return json.Marshal(ir)
+}
+
+// DecodeInventoryReply decodes a JSON byte slice into a inventory.
+func DecodeInventoryReply(payload []byte) (*InventoryReply, error) {
+	var ir InventoryReply
+
+	err := json.Unmarshal(payload, &ir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ir, nil
+}
+
+// TokenInventory requests the tokens of all records in the inventory,
+// categorized by stage of the voting process.
+//
+// When CountsOnly is set, only the number of records in each stage of the
+// voting process is returned and the token lists are omitted.
+//
+// The token lists can be paginated by setting Limit to the maximum number of
+// tokens that should be returned for each category.  The next page of a
+// category is requested by setting its cursor to the cursor that was returned
+// in TokenInventoryReply.Next.  All tokens are returned when neither a limit
+// nor a cursor is provided.
+type TokenInventory struct {
+	BestBlock  uint64                `json:"bestblock"`            // Best block
+	CountsOnly bool                  `json:"countsonly,omitempty"` // Only return counts
+	Limit      uint                  `json:"limit,omitempty"`      // Max tokens per category
+	Cursors    TokenInventoryCursors `json:"cursors"`              // Page cursors
+}
+
+// TokenInventoryCursors contains a pagination cursor for each category of the
+// token inventory.  An empty cursor refers to the first page of a category
+// in a request and indicates that there are no more pages in a reply.
+type TokenInventoryCursors struct {
+	Pre       string `json:"pre,omitempty"`       // Pre-vote cursor
+	Active    string `json:"active,omitempty"`    // Active voting period cursor
+	Approved  string `json:"approved,omitempty"`  // Approved cursor
+	Rejected  string `json:"rejected,omitempty"`  // Rejected cursor
+	Abandoned string `json:"abandoned,omitempty"` // Abandoned cursor
+}
+
+// EncodeTokenInventory encodes a TokenInventory into a JSON byte slice.
+func EncodeTokenInventory(i TokenInventory) ([]byte, error) {
+	return json.Marshal(i)
+}
+
+// DecodeTokenInventory decodes a JSON byte slice into a TokenInventory.
+func DecodeTokenInventory(payload []byte) (*TokenInventory, error) {
+	var i TokenInventory
+
+	err := json.Unmarshal(payload, &i)
+	if err != nil {
+		return nil, err
+	}
+
+	return &i, nil
+}
+
+// TokenInventoryReply is the response to the TokenInventory command and
+// returns the tokens of all records in the inventory.  The tokens are
+// categorized by stage of the voting process.  Pre and abandoned tokens are
+// sorted by timestamp in decending order.  Active, approved, and rejected
+// tokens are sorted by voting period end block height in decending order.
+type TokenInventoryReply struct {
+	Pre       []string `json:"pre"`       // Tokens of records that are pre-vote
+	Active    []string `json:"active"`    // Tokens of records with an active voting period
+	Approved  []string `json:"approved"`  // Tokens of records that have been approved by a vote
+	Rejected  []string `json:"rejected"`  // Tokens of records that have been rejected by a vote
+	Abandoned []string `json:"abandoned"` // Tokens of records that have been abandoned
+
+	Counts TokenInventoryCounts  `json:"counts"` // Number of records in each stage
+	Next   TokenInventoryCursors `json:"next"`   // Cursors of the next pages
+}
+
+// TokenInventoryCounts contains the number of records in each stage of the
+// voting process.
+type TokenInventoryCounts struct {
+	Pre       int `json:"pre"`       // Number of pre-vote records
+	Active    int `json:"active"`    // Number of records with an active voting period
+	Approved  int `json:"approved"`  // Number of records approved by a vote
+	Rejected  int `json:"rejected"`  // Number of records rejected by a vote
+	Abandoned int `json:"abandoned"` // Number of abandoned records
+}
+
+// EncodeTokenInventoryReply encodes a TokenInventoryReply into a JSON byte
+// slice.
+func EncodeTokenInventoryReply(itr TokenInventoryReply) ([]byte, error) {
+	return json.Marshal(itr)
+}
+
+// DecodeTokenInventoryReply decodes a JSON byte slice into a inventory.
+func DecodeTokenInventoryReply(payload []byte) (*TokenInventoryReply, error) {
+	var itr TokenInventoryReply
+
+	err := json.Unmarshal(payload, &itr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &itr, nil
+}
+
+// LoadVoteResults creates a vote results entry in the cache for any proposals
+// that have finsished voting but have not yet been added to the lazy loaded
+// vote results table.  When DryRun is set the pending proposal tokens are
+// returned without loading their vote results.  When Tokens is set only the
+// specified pending proposals are loaded.
+type LoadVoteResults struct {
+	BestBlock uint64   `json:"bestblock"`        // Best block height
+	DryRun    bool     `json:"dryrun,omitempty"` // Only return pending tokens
+	Tokens    []string `json:"tokens,omitempty"` // Subset of tokens to load
+}
+
+// EncodeLoadVoteResults encodes a LoadVoteResults into a JSON byte slice.
+func EncodeLoadVoteResults(lvr LoadVoteResults) ([]byte, error) {
+	return json.Marshal(lvr)
+}
+
+// DecodeLoadVoteResults decodes a JSON byte slice into a LoadVoteResults.
+func DecodeLoadVoteResults(payload []byte) (*LoadVoteResults, error) {
+	var lvr LoadVoteResults
+
+	err := json.Unmarshal(payload, &lvr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &lvr, nil
+}
+
+// LoadVoteResultsReply is the reply to the LoadVoteResults command. Tokens
+// contains the pending proposal tokens on a dry run and the tokens of the
+// proposals that were loaded otherwise.
+type LoadVoteResultsReply struct {
+	Tokens []string `json:"tokens"` // Proposal tokens
+}
+
+// EncodeLoadVoteResultsReply encodes a LoadVoteResultsReply into a JSON
+// byte slice.
+func EncodeLoadVoteResultsReply(reply LoadVoteResultsReply) ([]byte, error) {
+	return json.Marshal(reply)
+}
+
+// DecodeLoadVoteResultsReply decodes a JSON byte slice into a LoadVoteResults.
+func DecodeLoadVoteResultsReply(payload []byte) (*LoadVoteResultsReply, error) {
+	var reply LoadVoteResultsReply
+
+	err := json.Unmarshal(payload, &reply)
+	if err != nil {
+		return nil, err
+	}
+
+	return &reply, nil
+}
+
+// ExpireActiveVotes finds the proposal votes that finished after FromHeight
+// and on or before the best block and loads their vote results if they have
+// not been loaded yet.  It is meant to be run periodically using the best
+// block of the previous run as FromHeight so that every finished vote is
+// reported exactly once.  A dry run only returns the tokens of the finished
+// votes.
+type ExpireActiveVotes struct {
+	BestBlock  uint64 `json:"bestblock"`        // Best block height
+	FromHeight uint64 `json:"fromheight"`       // Best block of the previous run
+	DryRun     bool   `json:"dryrun,omitempty"` // Do not load vote results
+}
+
+// EncodeExpireActiveVotes encodes ExpireActiveVotes into a JSON byte slice.
+func EncodeExpireActiveVotes(eav ExpireActiveVotes) ([]byte, error) {
+	return json.Marshal(eav)
+}
+
+// DecodeExpireActiveVotes decodes a JSON byte slice into a
+// ExpireActiveVotes.
+func DecodeExpireActiveVotes(payload []byte) (*ExpireActiveVotes, error) {
+	var eav ExpireActiveVotes
+
+	err := json.Unmarshal(payload, &eav)
+	if err != nil {
+		return nil, err
+	}
+
+	return &eav, nil
+}
+
+// ExpireActiveVotesReply is the reply to the ExpireActiveVotes command.
+// Tokens contains the tokens of the votes that finished since FromHeight,
+// sorted by token.  BestBlock is the best block that was used and should be
+// passed as FromHeight on the next run.
+type ExpireActiveVotesReply struct {
+	BestBlock uint64   `json:"bestblock"` // Best block height that was used
+	Tokens    []string `json:"tokens"`    // Tokens of the finished votes
+}
+
+// EncodeExpireActiveVotesReply encodes ExpireActiveVotesReply into a JSON
+// byte slice.
+func EncodeExpireActiveVotesReply(eavr ExpireActiveVotesReply) ([]byte, error) {
+	return json.Marshal(eavr)
+}
+
+// DecodeExpireActiveVotesReply decodes a JSON byte slice into a
+// ExpireActiveVotesReply.
+func DecodeExpireActiveVotesReply(payload []byte) (*ExpireActiveVotesReply, error) {
+	var eavr ExpireActiveVotesReply
+
+	err := json.Unmarshal(payload, &eavr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &eavr, nil
+}
+
+// RecomputeVoteResults deletes the cached vote results of a single proposal
+// and tallies them again from the cast votes.  The proposal vote must have
+// finished.
+type RecomputeVoteResults struct {
+	Token     string `json:"token"`     // Censorship token
+	BestBlock uint64 `json:"bestblock"` // Best block height
+}
+
+// EncodeRecomputeVoteResults encodes a RecomputeVoteResults into a JSON byte
+// slice.
+func EncodeRecomputeVoteResults(rvr RecomputeVoteResults) ([]byte, error) {
+	return json.Marshal(rvr)
+}
+
+// DecodeRecomputeVoteResults decodes a JSON byte slice into a
+// RecomputeVoteResults.
+func DecodeRecomputeVoteResults(payload []byte) (*RecomputeVoteResults, error) {
+	var rvr RecomputeVoteResults
+
+	err := json.Unmarshal(payload, &rvr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rvr, nil
+}
+
+// RecomputeVoteResultsReply is the reply to the RecomputeVoteResults command
+// and contains the recomputed vote results.
+type RecomputeVoteResultsReply struct {
+	Approved bool               `json:"approved"` // Vote was approved
+	Results  []VoteOptionResult `json:"results"`  // Vote option results
+}
+
+// EncodeRecomputeVoteResultsReply encodes a RecomputeVoteResultsReply into a
+// JSON byte slice.
+func EncodeRecomputeVoteResultsReply(reply RecomputeVoteResultsReply) ([]byte, error) {
+	return json.Marshal(reply)
+}
+
+// DecodeRecomputeVoteResultsReply decodes a JSON byte slice into a
+// RecomputeVoteResultsReply.
+func DecodeRecomputeVoteResultsReply(payload []byte) (*RecomputeVoteResultsReply, error) {
+	var reply RecomputeVoteResultsReply
+
+	err := json.Unmarshal(payload, &reply)
+	if err != nil {
+		return nil, err
+	}
+
+	return &reply, nil
+}
+
+// VoteResultsDigest retrieves the digest of the loaded vote results of a
+// proposal.  The digest is computed when the vote results are loaded so that
+// clients can verify the vote results without fetching every cast vote.  See
+// ComputeVoteResultsDigest for the format of the digest.
+type VoteResultsDigest struct {
+	Token string `json:"token"` // Censorship token
+}
+
+// EncodeVoteResultsDigest encodes a VoteResultsDigest into a JSON byte slice.
+func EncodeVoteResultsDigest(v VoteResultsDigest) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// DecodeVoteResultsDigest decodes a JSON byte slice into a VoteResultsDigest.
+func DecodeVoteResultsDigest(payload []byte) (*VoteResultsDigest, error) {
+	var v VoteResultsDigest
+
+	err := json.Unmarshal(payload, &v)
+	if err != nil {
+		return nil, err
+	}
+
+	return &v, nil
+}
+
+// VoteResultsDigestReply is the reply to the VoteResultsDigest command.
+type VoteResultsDigestReply struct {
+	Digest string `json:"digest"` // Vote results digest
+}
+
+// EncodeVoteResultsDigestReply encodes a VoteResultsDigestReply into a JSON
+// byte slice.
+func EncodeVoteResultsDigestReply(v VoteResultsDigestReply) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// DecodeVoteResultsDigestReply decodes a JSON byte slice into a
+// VoteResultsDigestReply.
+func DecodeVoteResultsDigestReply(payload []byte) (*VoteResultsDigestReply, error) {
+	var v VoteResultsDigestReply
+
+	err := json.Unmarshal(payload, &v)
+	if err != nil {
+		return nil, err
+	}
+
+	return &v, nil
+}
+
+// ComputeVoteResultsDigest returns the digest of the passed in cast votes and
+// vote option results.  The digest is the hex encoded SHA256 digest of the
+// sorted, newline delimited token+ticket+votebit+signature of every cast vote
+// followed by the newline delimited id:bits:votes of every vote option
+// result, ordered by bits.  The digest does not depend on the order of the
+// passed in cast votes and vote option results.
+func ComputeVoteResultsDigest(votes []CastVote, results []VoteOptionResult) string {
+	keys := make([]string, 0, len(votes))
+	for _, v := range votes {
+		keys = append(keys, v.Token+v.Ticket+v.VoteBit+v.Signature)
+	}
+	sort.Strings(keys)
+
+	r := make([]VoteOptionResult, len(results))
+	copy(r, results)
+	sort.Slice(r, func(i, j int) bool {
+		return r[i].Bits < r[j].Bits
+	})
+
+	h := sha256.New()
+	for _, v := range keys {
+		h.Write([]byte(v + "\n"))
+	}
+	for _, v := range r {
+		h.Write([]byte(fmt.Sprintf("%v:%v:%v\n", v.ID, v.Bits, v.Votes)))
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// ArchiveProposalVotes compacts the cached cast votes of a finished proposal
+// vote whose results have been loaded.  The cast votes are deleted from the
+// cache and only a digest of them is kept along with the vote results.  The
+// digest is the hex encoded SHA256 digest of the sorted, newline delimited
+// token+ticket+votebit+signature of every cast vote.  Archived cast votes can
+// only be restored by rebuilding the cache.
+type ArchiveProposalVotes struct {
+	Token     string `json:"token"`     // Censorship token
+	BestBlock uint64 `json:"bestblock"` // Best block height
+}
+
+// EncodeArchiveProposalVotes encodes an ArchiveProposalVotes into a JSON byte
+// slice.
+func EncodeArchiveProposalVotes(apv ArchiveProposalVotes) ([]byte, error) {
+	return json.Marshal(apv)
+}
+
+// DecodeArchiveProposalVotes decodes a JSON byte slice into an
+// ArchiveProposalVotes.
+func DecodeArchiveProposalVotes(payload []byte) (*ArchiveProposalVotes, error) {
+	var apv ArchiveProposalVotes
+
+	err := json.Unmarshal(payload, &apv)
+	if err != nil {
+		return nil, err
+	}
+
+	return &apv, nil
+}
+
+// ArchiveProposalVotesReply is the reply to the ArchiveProposalVotes command.
+// It contains the digest and the number of the archived cast votes.  The same
+// reply is returned when the cast votes have already been archived.
+type ArchiveProposalVotesReply struct {
+	Digest string `json:"digest"` // Digest of the archived cast votes
+	Votes  uint64 `json:"votes"`  // Number of archived cast votes
+}
+
+// EncodeArchiveProposalVotesReply encodes an ArchiveProposalVotesReply into a
+// JSON byte slice.
+func EncodeArchiveProposalVotesReply(reply ArchiveProposalVotesReply) ([]byte, error) {
+	return json.Marshal(reply)
+}
+
+// DecodeArchiveProposalVotesReply decodes a JSON byte slice into an
+// ArchiveProposalVotesReply.
+func DecodeArchiveProposalVotesReply(payload []byte) (*ArchiveProposalVotesReply, error) {
+	var reply ArchiveProposalVotesReply
+
+	err := json.Unmarshal(payload, &reply)
+	if err != nil {
+		return nil, err
+	}
+
+	return &reply, nil
+}
+
+// EligibleTickets requests the tickets that are eligible to vote on a
+// proposal.  Offset and Limit page through the tickets in the order that they
+// were recorded in the start vote.  All tickets after the offset are returned
+// when Limit is not set.
+type EligibleTickets struct {
+	Token  string `json:"token"`            // Censorship token
+	Offset uint32 `json:"offset,omitempty"` // Number of tickets to skip
+	Limit  uint32 `json:"limit,omitempty"`  // Maximum number of tickets
+}
+
+// EncodeEligibleTickets encodes an EligibleTickets into a JSON byte slice.
+func EncodeEligibleTickets(et EligibleTickets) ([]byte, error) {
+	return json.Marshal(et)
+}
+
+// DecodeEligibleTickets decodes a JSON byte slice into an EligibleTickets.
+func DecodeEligibleTickets(payload []byte) (*EligibleTickets, error) {
+	var et EligibleTickets
+
+	err := json.Unmarshal(payload, &et)
+	if err != nil {
+		return nil, err
+	}
+
+	return &et, nil
+}
+
+// EligibleTicketsReply is the reply to the EligibleTickets command.  Total is
+// the number of eligible tickets of the proposal regardless of paging.  A
+// proposal whose vote has not been started has no eligible tickets.
+type EligibleTicketsReply struct {
+	Tickets []string `json:"tickets"` // Eligible tickets
+	Total   uint32   `json:"total"`   // Total number of eligible tickets
+}
+
+// EncodeEligibleTicketsReply encodes an EligibleTicketsReply into a JSON byte
+// slice.
+func EncodeEligibleTicketsReply(r EligibleTicketsReply) ([]byte, error) {
+	return json.Marshal(r)
+}
+
+// DecodeEligibleTicketsReply decodes a JSON byte slice into an
+// EligibleTicketsReply.
+func DecodeEligibleTicketsReply(payload []byte) (*EligibleTicketsReply, error) {
+	var r EligibleTicketsReply
+
+	err := json.Unmarshal(payload, &r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+// VoteExport returns the full vote lifecycle of a proposal.
+type VoteExport struct {
+	Token string `json:"token"` // Censorship token
+}
+
+// EncodeVoteExport encodes a VoteExport into a JSON byte slice.
+func EncodeVoteExport(ve VoteExport) ([]byte, error) {
+	return json.Marshal(ve)
+}
+
+// DecodeVoteExport decodes a JSON byte slice into a VoteExport.
+func DecodeVoteExport(payload []byte) (*VoteExport, error) {
+	var ve VoteExport
+
+	err := json.Unmarshal(payload, &ve)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ve, nil
+}
+
+// VoteExportReply is the reply to the VoteExport command.  It combines the
+// vote authorization, the vote parameters and eligible tickets, all cast votes
+// and the vote results of a proposal.  Results contains the final vote results
+// when Final is set and the live vote tally otherwise.  Approved is only set
+// once the results are final.
+type VoteExportReply struct {
+	AuthorizeVote  AuthorizeVote      `json:"authorizevote"`  // Vote authorization
+	StartVote      StartVote          `json:"startvote"`      // Vote ballot
+	StartVoteReply StartVoteReply     `json:"startvotereply"` // Start vote snapshot
+	CastVotes      []CastVote         `json:"castvotes"`      // All votes
+	Results        []VoteOptionResult `json:"results"`        // Votes per option
+	Final          bool               `json:"final"`          // Results are final
+	Approved       bool               `json:"approved"`       // Vote was approved
+}
+
+// EncodeVoteExportReply encodes a VoteExportReply into a JSON byte slice.
+func EncodeVoteExportReply(r VoteExportReply) ([]byte, error) {
+	return json.Marshal(r)
+}
+
+// DecodeVoteExportReply decodes a JSON byte slice into a VoteExportReply.
+func DecodeVoteExportReply(payload []byte) (*VoteExportReply, error) {
+	var r VoteExportReply
+
+	err := json.Unmarshal(payload, &r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+// VoteAuthorizationCheck is used to verify that every proposal vote that has
+// been started was authorized by the proposal author for the version of the
+// proposal that is being voted on.
+type VoteAuthorizationCheck struct{}
+
+// EncodeVoteAuthorizationCheck encodes a VoteAuthorizationCheck into a JSON
+// byte slice.
+func EncodeVoteAuthorizationCheck(vac VoteAuthorizationCheck) ([]byte, error) {
+	return json.Marshal(vac)
+}
+
+// DecodeVoteAuthorizationCheck decodes a JSON byte slice into a
+// VoteAuthorizationCheck.
+func DecodeVoteAuthorizationCheck(payload []byte) (*VoteAuthorizationCheck, error) {
+	var vac VoteAuthorizationCheck
+
+	err := json.Unmarshal(payload, &vac)
+	if err != nil {
+		return nil, err
+	}
+
+	return &vac, nil
+}
+
+// UnauthorizedVote describes a started proposal vote that does not have a
+// matching vote authorization.
+type UnauthorizedVote struct {
+	Token   string `json:"token"`   // Censorship token
+	Version string `json:"version"` // Version of the proposal being voted on
+	Reason  string `json:"reason"`  // Why the vote is considered unauthorized
+}
+
+// VoteAuthorizationCheckReply is the reply to the VoteAuthorizationCheck
+// command.  An empty UnauthorizedVotes means that the data is consistent.
+type VoteAuthorizationCheckReply struct {
+	UnauthorizedVotes []UnauthorizedVote `json:"unauthorizedvotes"`
+}
+
+// EncodeVoteAuthorizationCheckReply encodes a VoteAuthorizationCheckReply
+// into a JSON byte slice.
+func EncodeVoteAuthorizationCheckReply(r VoteAuthorizationCheckReply) ([]byte, error) {
+	return json.Marshal(r)
+}
+
+// DecodeVoteAuthorizationCheckReply decodes a JSON byte slice into a
+// VoteAuthorizationCheckReply.
+func DecodeVoteAuthorizationCheckReply(payload []byte) (*VoteAuthorizationCheckReply, error) {
+	var r VoteAuthorizationCheckReply
+
+	err := json.Unmarshal(payload, &r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+// GetVoteOptions retrieves the vote options and the vote mask of a proposal
+// vote without the remaining vote details.  It is meant for clients that only
+// need to render a ballot.
+type GetVoteOptions struct {
+	Token string `json:"token"` // Censorship token
+}
+
+// EncodeGetVoteOptions encodes GetVoteOptions into a JSON byte slice.
+func EncodeGetVoteOptions(g GetVoteOptions) ([]byte, error) {
+	return json.Marshal(g)
+}
+
+// DecodeGetVoteOptions decodes a JSON byte slice into a GetVoteOptions.
+func DecodeGetVoteOptions(payload []byte) (*GetVoteOptions, error) {
+	var g GetVoteOptions
+
+	err := json.Unmarshal(payload, &g)
+	if err != nil {
+		return nil, err
+	}
+
+	return &g, nil
+}
+
+// GetVoteOptionsReply is the reply to the GetVoteOptions command.
+type GetVoteOptionsReply struct {
+	Mask    uint64       `json:"mask"`    // Valid votebits
+	Options []VoteOption `json:"options"` // Vote options
+}
+
+// EncodeGetVoteOptionsReply encodes GetVoteOptionsReply into a JSON byte
+// slice.
+func EncodeGetVoteOptionsReply(g GetVoteOptionsReply) ([]byte, error) {
+	return json.Marshal(g)
+}
+
+// DecodeGetVoteOptionsReply decodes a JSON byte slice into a
+// GetVoteOptionsReply.
+func DecodeGetVoteOptionsReply(payload []byte) (*GetVoteOptionsReply, error) {
+	var g GetVoteOptionsReply
+
+	err := json.Unmarshal(payload, &g)
+	if err != nil {
+		return nil, err
+	}
+
+	return &g, nil
+}
+
+// GetEligibleTicketCount retrieves the number of tickets that are eligible to
+// vote on a proposal without the remaining vote details.
+type GetEligibleTicketCount struct {
+	Token string `json:"token"` // Censorship token
+}
+
+// EncodeGetEligibleTicketCount encodes GetEligibleTicketCount into a JSON byte
+// slice.
+func EncodeGetEligibleTicketCount(g GetEligibleTicketCount) ([]byte, error) {
+	return json.Marshal(g)
+}
+
+// DecodeGetEligibleTicketCount decodes a JSON byte slice into a
+// GetEligibleTicketCount.
+func DecodeGetEligibleTicketCount(payload []byte) (*GetEligibleTicketCount, error) {
+	var g GetEligibleTicketCount
+
+	err := json.Unmarshal(payload, &g)
+	if err != nil {
+		return nil, err
+	}
+
+	return &g, nil
+}
+
+// GetEligibleTicketCountReply is the reply to the GetEligibleTicketCount
+// command.
+type GetEligibleTicketCountReply struct {
+	EligibleTicketCount int `json:"eligibleticketcount"` // Number of eligible tickets
+}
+
+// EncodeGetEligibleTicketCountReply encodes GetEligibleTicketCountReply into a
+// JSON byte slice.
+func EncodeGetEligibleTicketCountReply(g GetEligibleTicketCountReply) ([]byte, error) {
+	return json.Marshal(g)
+}
+
+// DecodeGetEligibleTicketCountReply decodes a JSON byte slice into a
+// GetEligibleTicketCountReply.
+func DecodeGetEligibleTicketCountReply(payload []byte) (*GetEligibleTicketCountReply, error) {
+	var g GetEligibleTicketCountReply
+
+	err := json.Unmarshal(payload, &g)
+	if err != nil {
+		return nil, err
+	}
+
+	return &g, nil
+}
+
+// Proposal activity timeline event types
+const (
+	TimelineEventComment       = "comment"       // Comment was made
+	TimelineEventLikeComment   = "likecomment"   // Comment was liked or the like was undone
+	TimelineEventAuthorizeVote = "authorizevote" // Vote was authorized or revoked
+	TimelineEventStartVote     = "startvote"     // Vote was started
+	TimelineEventCastVote      = "castvote"      // Vote was cast
+)
+
+// ProposalActivityTimeline retrieves the comments, comment likes, authorize
+// votes, start vote, and cast votes of a proposal as a single chronological
+// stream of events.  Hidden comments are not included.
+type ProposalActivityTimeline struct {
+	Token string `json:"token"` // Censorship token
+}
+
+// EncodeProposalActivityTimeline encodes ProposalActivityTimeline into a JSON
+// byte slice.
+func EncodeProposalActivityTimeline(p ProposalActivityTimeline) ([]byte, error) {
+	return json.Marshal(p)
+}
+
+// DecodeProposalActivityTimeline decodes a JSON byte slice into a
+// ProposalActivityTimeline.
+func DecodeProposalActivityTimeline(payload []byte) (*ProposalActivityTimeline, error) {
+	var p ProposalActivityTimeline
+
+	err := json.Unmarshal(payload, &p)
+	if err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+// TimelineEvent is a single event of a proposal activity timeline.  The
+// fields that are set depend on the type of the event.  The timestamp is zero
+// for events whose timestamp is not known, e.g. votes that were cast before
+// the cache was built.
+type TimelineEvent struct {
+	Type      string `json:"type"`                // Timeline event type
+	Timestamp int64  `json:"timestamp"`           // UNIX timestamp of the event
+	PublicKey string `json:"publickey,omitempty"` // Public key of the event author
+	CommentID string `json:"commentid,omitempty"` // Comment and like events
+	Action    string `json:"action,omitempty"`    // Like and authorize vote events
+	Ticket    string `json:"ticket,omitempty"`    // Cast vote events
+	VoteBit   string `json:"votebit,omitempty"`   // Cast vote events
+}
+
+// ProposalActivityTimelineReply is the reply to the ProposalActivityTimeline
+// command.  The events are ordered by timestamp.  Events with the same
+// timestamp are ordered by type in the order in which the event types are
+// declared.
+type ProposalActivityTimelineReply struct {
+	Events []TimelineEvent `json:"events"`
+}
+
+// EncodeProposalActivityTimelineReply encodes ProposalActivityTimelineReply
+// into a JSON byte slice.
+func EncodeProposalActivityTimelineReply(p ProposalActivityTimelineReply) ([]byte, error) {
+	return json.Marshal(p)
+}
+
+// DecodeProposalActivityTimelineReply decodes a JSON byte slice into a
+// ProposalActivityTimelineReply.
+func DecodeProposalActivityTimelineReply(payload []byte) (*ProposalActivityTimelineReply, error) {
+	var p ProposalActivityTimelineReply
+
+	err := json.Unmarshal(payload, &p)
+	if err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+// SortTimelineEvents sorts the passed in timeline events by timestamp.  The
+// events must be ordered by type before they are sorted so that events with
+// the same timestamp remain ordered by type.
+func SortTimelineEvents(events []TimelineEvent) {
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Timestamp < events[j].Timestamp
+	})
 }
@@ -13,6 +13,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"math"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -38,6 +39,21 @@ const (
 	foneroPluginJournals  = "journals"
 	foneroPluginInventory = "inventory"
 
+	// foneroPluginMaxCommentLength is the maximum length in bytes of
+	// a new comment. A missing setting or a value of zero does not
+	// limit the comment length.
+	foneroPluginMaxCommentLength = "maxcommentlength"
+
+	// foneroPluginMaxVoteOptions is the maximum number of vote options
+	// that a start vote can have. The value must be a positive
+	// integer. Start votes with more options are rejected so that
+	// the tally of a vote stays bounded.
+	foneroPluginMaxVoteOptions = "maxvoteoptions"
+
+	// defaultMaxVoteOptions is the maximum number of vote options
+	// that is used when the setting is not provided.
+	defaultMaxVoteOptions = 256
+
 	defaultCommentIDFilename = "commentid.txt"
 	defaultCommentFilename   = "comments.journal"
 	defaultCommentsFlushed   = "comments.flushed"
@@ -45,10 +61,11 @@ const (
 	defaultBallotFilename = "ballot.journal"
 	defaultBallotFlushed  = "ballot.flushed"
 
-	journalVersion       = "1"       // Version 1 of the comment journal
-	journalActionAdd     = "add"     // Add entry
-	journalActionDel     = "del"     // Delete entry
-	journalActionAddLike = "addlike" // Add comment like
+	journalVersion          = "1"          // Version 1 of the comment journal
+	journalActionAdd        = "add"        // Add entry
+	journalActionDel        = "del"        // Delete entry
+	journalActionAddLike    = "addlike"    // Add comment like
+	journalActionVisibility = "visibility" // Hide or unhide comment
 
 	flushRecordVersion = "1" // Version 1 of the flush journal
 
@@ -74,6 +91,7 @@ type FlushRecord struct {
 // journalActionAdd -> Add entry
 // journalActionDel -> Delete entry
 // journalActionAddLike -> Add comment like structure (comments only)
+// journalActionVisibility -> Set comment visibility structure (comments only)
 type JournalAction struct {
 	Version string `json:"version"` // Version
 	Action  string `json:"action"`  // Add/Del
@@ -108,15 +126,21 @@ var (
 	foneroPluginSettings map[string]string             // [key]setting
 	foneroPluginHooks    map[string]func(string) error // [key]func(token) error
 
+	// foneroPluginLimits are the limits that are configured by the
+	// plugin settings. They are parsed once by
+	// parseFoneroPluginLimits when the backend is created.
+	foneroPluginLimits foneroPluginLimitSettings
+
 	// cached values, requires lock
 	// XXX why is this a pointer? Convert if possible after investigating
 	foneroPluginVoteCache         = make(map[string]*foneroplugin.StartVote)     // [token]startvote
 	foneroPluginVoteSnapshotCache = make(map[string]foneroplugin.StartVoteReply) // [token]StartVoteReply
 
 	// Pregenerated journal actions
-	journalAdd     []byte
-	journalDel     []byte
-	journalAddLike []byte
+	journalAdd        []byte
+	journalDel        []byte
+	journalAddLike    []byte
+	journalVisibility []byte
 
 	// Plugin specific data that CANNOT be treated as metadata
 	pluginDataDir = filepath.Join("plugins", "fonero")
@@ -156,6 +180,13 @@ func init() {
 	if err != nil {
 		panic(err.Error())
 	}
+	journalVisibility, err = json.Marshal(JournalAction{
+		Version: journalVersion,
+		Action:  journalActionVisibility,
+	})
+	if err != nil {
+		panic(err.Error())
+	}
 }
 
 func getFoneroPlugin(testnet bool) backend.Plugin {
@@ -256,6 +287,72 @@ func setFoneroPluginHook(name string, f func(string) error) {
 	foneroPluginHooks[name] = f
 }
 
+// foneroPluginLimitSettings contains the limits that are configured by the
+// fonero plugin settings.
+type foneroPluginLimitSettings struct {
+	maxCommentLength int // Max comment length in bytes, 0 is unlimited
+	maxVoteOptions   int // Max vote options of a start vote
+}
+
+// parseFoneroPluginLimits parses and validates the plugin settings that
+// configure the fonero plugin limits.  It is called once when the backend is
+// created so that an invalid setting is reported at startup instead of failing
+// every command that uses it.
+func parseFoneroPluginLimits() (foneroPluginLimitSettings, error) {
+	maxLength, err := maxCommentLength()
+	if err != nil {
+		return foneroPluginLimitSettings{}, err
+	}
+	maxOptions, err := maxVoteOptions()
+	if err != nil {
+		return foneroPluginLimitSettings{}, err
+	}
+	return foneroPluginLimitSettings{
+		maxCommentLength: maxLength,
+		maxVoteOptions:   maxOptions,
+	}, nil
+}
+
+// maxVoteOptions returns the maximum number of vote options of a start vote.
+func maxVoteOptions() (int, error) {
+	v, ok := foneroPluginSettings[foneroPluginMaxVoteOptions]
+	if !ok {
+		return defaultMaxVoteOptions, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid %v setting '%v'",
+			foneroPluginMaxVoteOptions, v)
+	}
+	return n, nil
+}
+
+// maxCommentLength returns the maximum length in bytes of a new comment.  Zero
+// is returned when the comment length is not limited.
+func maxCommentLength() (int, error) {
+	v, ok := foneroPluginSettings[foneroPluginMaxCommentLength]
+	if !ok {
+		return 0, nil
+	}
+	length, err := strconv.Atoi(v)
+	if err != nil || length < 0 {
+		return 0, fmt.Errorf("invalid %v setting '%v'",
+			foneroPluginMaxCommentLength, v)
+	}
+	return length, nil
+}
+
+// parentCommentExists returns whether the passed in parent comment ID refers
+// to an uncensored comment of the passed in proposal.  The top level parent ID
+// always exists.  This function must be called with the lock held.
+func parentCommentExists(token, parentID string) bool {
+	if parentID == "0" {
+		return true
+	}
+	c, ok := foneroPluginCommentsCache[token][parentID]
+	return ok && !c.Censored
+}
+
 func (g *gitBackEnd) propExists(repo, token string) bool {
 	_, err := os.Stat(pijoin(repo, token))
 	return err == nil
@@ -563,6 +660,17 @@ func (g *gitBackEnd) pluginBestBlock() (string, error) {
 	return strconv.FormatUint(uint64(bb.Height), 10), nil
 }
 
+// BestBlock returns the best block height that is reported by fnodata.
+//
+// BestBlock satisfies the cache BestBlockSource interface.
+func (g *gitBackEnd) BestBlock() (uint64, error) {
+	bb, err := bestBlock()
+	if err != nil {
+		return 0, err
+	}
+	return uint64(bb.Height), nil
+}
+
 // foneroPluginPostEdit called after and edit is complete but before commit.
 func (g *gitBackEnd) foneroPluginPostEdit(token string) error {
 	log.Tracef("foneroPluginPostEdit: %v", token)
@@ -1025,6 +1133,13 @@ func (g *gitBackEnd) pluginNewComment(payload string) (string, error) {
 		return "", fmt.Errorf("unknown proposal: %v", comment.Token)
 	}
 
+	// Reject comments that exceed the maximum comment length
+	maxLength := foneroPluginLimits.maxCommentLength
+	if maxLength > 0 && len(comment.Comment) > maxLength {
+		return "", fmt.Errorf("comment length %v exceeds the maximum "+
+			"comment length of %v", len(comment.Comment), maxLength)
+	}
+
 	// Do some cheap things before expensive calls
 	cfilename := pijoin(g.journals, comment.Token,
 		defaultCommentFilename)
@@ -1033,6 +1148,13 @@ func (g *gitBackEnd) pluginNewComment(payload string) (string, error) {
 		comment.ParentID = "0"
 	}
 
+	// Replies must refer to a comment that exists and that has not
+	// been censored.  The comments cache is only complete once the
+	// journals have been replayed.
+	if comment.ParentID != "0" && !journalsReplayed {
+		return "", backend.ErrJournalsNotReplayed
+	}
+
 	// Sign signature
 	r := fi.SignMessage([]byte(comment.Signature))
 	receipt := hex.EncodeToString(r[:])
@@ -1060,20 +1182,21 @@ func (g *gitBackEnd) pluginNewComment(payload string) (string, error) {
 		return "", fmt.Errorf("EncodeComment: %v", err)
 	}
 
-	// Add comment to journal
-	err = g.journal.Journal(cfilename, string(journalAdd)+
-		string(blob))
-	if err != nil {
-		return "", fmt.Errorf("could not journal %v: %v", c.Token, err)
-	}
-
 	// Comment journal filename
 	flushFilename := pijoin(g.journals, comment.Token,
 		defaultCommentsFlushed)
 
-	// Cache comment
+	// Cache comment. The parent comment is verified under the same
+	// lock so that it cannot be censored between the check and the
+	// insert.
 	g.Lock()
 
+	if !parentCommentExists(c.Token, c.ParentID) {
+		g.Unlock()
+		return "", fmt.Errorf("parent comment not found %v:%v",
+			c.Token, c.ParentID)
+	}
+
 	// Mark comment journal dirty
 	_ = os.Remove(flushFilename)
 
@@ -1090,6 +1213,17 @@ func (g *gitBackEnd) pluginNewComment(payload string) (string, error) {
 	foneroPluginCommentsCache[c.Token][c.CommentID] = c
 	g.Unlock()
 
+	// Add comment to journal
+	err = g.journal.Journal(cfilename, string(journalAdd)+
+		string(blob))
+	if err != nil {
+		// Unwind the cached comment
+		g.Lock()
+		delete(foneroPluginCommentsCache[c.Token], c.CommentID)
+		g.Unlock()
+		return "", fmt.Errorf("could not journal %v: %v", c.Token, err)
+	}
+
 	// Encode reply
 	ncr := foneroplugin.NewCommentReply{
 		CommentID: c.CommentID,
@@ -1220,6 +1354,128 @@ func (g *gitBackEnd) pluginLikeComment(payload string) (string, error) {
 	return string(lcrb), nil
 }
 
+// pluginLikeCommentUndo removes the current up or down vote of a user on a
+// comment.  The undo is journaled as a like with the undo action so that the
+// like history of the comment is retained.
+func (g *gitBackEnd) pluginLikeCommentUndo(payload string) (string, error) {
+	log.Tracef("pluginLikeCommentUndo")
+
+	// Check if journals were replayed
+	if !journalsReplayed {
+		return "", backend.ErrJournalsNotReplayed
+	}
+
+	// XXX this should become part of some sort of context
+	fiJSON, ok := foneroPluginSettings[foneroPluginIdentity]
+	if !ok {
+		return "", fmt.Errorf("full identity not set")
+	}
+	fi, err := identity.UnmarshalFullIdentity([]byte(fiJSON))
+	if err != nil {
+		return "", err
+	}
+
+	// Decode undo
+	undo, err := foneroplugin.DecodeLikeCommentUndo([]byte(payload))
+	if err != nil {
+		return "", fmt.Errorf("DecodeLikeCommentUndo: %v", err)
+	}
+
+	// Verify proposal exists, we can run this lockless
+	if !g.propExists(g.vetted, undo.Token) {
+		return "", fmt.Errorf("unknown proposal: %v", undo.Token)
+	}
+
+	// Sign signature
+	r := fi.SignMessage([]byte(undo.Signature))
+	receipt := hex.EncodeToString(r[:])
+
+	// Comment journal filename
+	flushFilename := pijoin(g.journals, undo.Token,
+		defaultCommentsFlushed)
+
+	g.Lock()
+
+	// Verify cache
+	_, ok = foneroPluginCommentsCache[undo.Token][undo.CommentID]
+	if !ok {
+		g.Unlock()
+		return "", fmt.Errorf("comment not found %v:%v",
+			undo.Token, undo.CommentID)
+	}
+
+	// Ensure the user currently likes the comment
+	cc := foneroPluginCommentsLikesCache[undo.Token]
+	var current string
+	for _, v := range cc {
+		if v.CommentID == undo.CommentID &&
+			v.PublicKey == undo.PublicKey {
+			current = foneroplugin.LikeCommentAction(current,
+				v.Action)
+		}
+	}
+	if current == "" {
+		g.Unlock()
+		return "", fmt.Errorf("no like to undo %v:%v",
+			undo.Token, undo.CommentID)
+	}
+
+	// Mark comment journal dirty
+	_ = os.Remove(flushFilename)
+
+	// Update cache
+	lc := foneroplugin.LikeComment{
+		Token:     undo.Token,
+		CommentID: undo.CommentID,
+		Action:    foneroplugin.LikeActionUndo,
+		Signature: undo.Signature,
+		PublicKey: undo.PublicKey,
+		Receipt:   receipt,
+		Timestamp: time.Now().Unix(),
+	}
+	foneroPluginCommentsLikesCache[undo.Token] = append(cc, lc)
+	g.Unlock()
+
+	// We create an unwind function that MUST be called from all error
+	// paths. If everything works ok it is a no-op.
+	unwind := func() {
+		g.Lock()
+		foneroPluginCommentsLikesCache[undo.Token] = cc
+		g.Unlock()
+	}
+
+	// Create Journal entry
+	blob, err := foneroplugin.EncodeLikeComment(lc)
+	if err != nil {
+		unwind()
+		return "", fmt.Errorf("EncodeLikeComment: %v", err)
+	}
+
+	// Add like undo to journal
+	cfilename := pijoin(g.journals, undo.Token,
+		defaultCommentFilename)
+	err = g.journal.Journal(cfilename, string(journalAddLike)+
+		string(blob))
+	if err != nil {
+		unwind()
+		return "", fmt.Errorf("could not journal %v: %v", lc.Token, err)
+	}
+
+	// Encode reply
+	lcurb, err := foneroplugin.EncodeLikeCommentUndoReply(
+		foneroplugin.LikeCommentUndoReply{
+			Receipt:   receipt,
+			Timestamp: lc.Timestamp,
+		})
+	if err != nil {
+		unwind()
+		return "", fmt.Errorf("EncodeLikeCommentUndoReply: %v", err)
+	}
+
+	// return success and encoded answer
+	return string(lcurb), nil
+}
+
 func (g *gitBackEnd) pluginCensorComment(payload string) (string, error) {
 	log.Tracef("pluginCensorComment")
 
@@ -1338,6 +1594,133 @@ func (g *gitBackEnd) pluginCensorComment(payload string) (string, error) {
 	return string(ccrb), nil
 }
 
+// pluginSetCommentVisibility hides or unhides a comment.  A hidden comment
+// retains its body so, unlike a censored comment, it can be unhidden again.
+func (g *gitBackEnd) pluginSetCommentVisibility(payload string) (string, error) {
+	log.Tracef("pluginSetCommentVisibility")
+
+	// Check if journals were replayed
+	if !journalsReplayed {
+		return "", backend.ErrJournalsNotReplayed
+	}
+
+	// XXX this should become part of some sort of context
+	fiJSON, ok := foneroPluginSettings[foneroPluginIdentity]
+	if !ok {
+		return "", fmt.Errorf("full identity not set")
+	}
+	fi, err := identity.UnmarshalFullIdentity([]byte(fiJSON))
+	if err != nil {
+		return "", fmt.Errorf("UnmarshalFullIdentity: %v", err)
+	}
+
+	// Decode set comment visibility
+	scv, err := foneroplugin.DecodeSetCommentVisibility([]byte(payload))
+	if err != nil {
+		return "", fmt.Errorf("DecodeSetCommentVisibility: %v", err)
+	}
+
+	// Verify proposal exists, we can run this lockless
+	if !g.propExists(g.vetted, scv.Token) {
+		return "", fmt.Errorf("unknown proposal: %v", scv.Token)
+	}
+
+	// Sign signature
+	r := fi.SignMessage([]byte(scv.Signature))
+	receipt := hex.EncodeToString(r[:])
+
+	// Comment journal filename
+	flushFilename := pijoin(g.journals, scv.Token,
+		defaultCommentsFlushed)
+
+	// Ensure proposal exists in comments cache
+	g.Lock()
+
+	// Mark comment journal dirty
+	_ = os.Remove(flushFilename)
+
+	// Verify cache
+	_, ok = foneroPluginCommentsCache[scv.Token]
+	if !ok {
+		g.Unlock()
+		return "", fmt.Errorf("proposal not found %v", scv.Token)
+	}
+
+	// Ensure comment exists in comments cache, has not been
+	// censored, and does not already have the requested
+	// visibility
+	c, ok := foneroPluginCommentsCache[scv.Token][scv.CommentID]
+	if !ok {
+		g.Unlock()
+		return "", fmt.Errorf("comment not found %v:%v",
+			scv.Token, scv.CommentID)
+	}
+	if c.Censored {
+		g.Unlock()
+		return "", fmt.Errorf("comment censored %v: %v",
+			scv.Token, scv.CommentID)
+	}
+	if c.Hidden == scv.Hidden {
+		g.Unlock()
+		return "", fmt.Errorf("comment visibility unchanged %v: %v",
+			scv.Token, scv.CommentID)
+	}
+
+	// Update comments cache
+	oc := c
+	c.Hidden = scv.Hidden
+	foneroPluginCommentsCache[scv.Token][scv.CommentID] = c
+
+	g.Unlock()
+
+	// We create an unwind function that MUST be called from all error
+	// paths. If everything works ok it is a no-op.
+	unwind := func() {
+		g.Lock()
+		foneroPluginCommentsCache[scv.Token][scv.CommentID] = oc
+		g.Unlock()
+	}
+
+	// Create Journal entry
+	je := foneroplugin.SetCommentVisibility{
+		Token:     scv.Token,
+		CommentID: scv.CommentID,
+		Hidden:    scv.Hidden,
+		Signature: scv.Signature,
+		PublicKey: scv.PublicKey,
+		Receipt:   receipt,
+		Timestamp: time.Now().Unix(),
+	}
+	blob, err := foneroplugin.EncodeSetCommentVisibility(je)
+	if err != nil {
+		unwind()
+		return "", fmt.Errorf("EncodeSetCommentVisibility: %v", err)
+	}
+
+	// Add set comment visibility to journal
+	cfilename := pijoin(g.journals, scv.Token,
+		defaultCommentFilename)
+	err = g.journal.Journal(cfilename, string(journalVisibility)+
+		string(blob))
+	if err != nil {
+		unwind()
+		return "", fmt.Errorf("could not journal %v: %v", je.Token, err)
+	}
+
+	// Encode reply
+	scvr := foneroplugin.SetCommentVisibilityReply{
+		Receipt:   je.Receipt,
+		Timestamp: je.Timestamp,
+	}
+	scvrb, err := foneroplugin.EncodeSetCommentVisibilityReply(scvr)
+	if err != nil {
+		unwind()
+		return "", fmt.Errorf("EncodeSetCommentVisibilityReply: %v", err)
+	}
+
+	return string(scvrb), nil
+}
+
 // encodeGetCommentsReply converts a comment map into a JSON string that can be
 // returned as a foneroplugin reply. If the comment map is nil it returns a
 // valid empty reply structure.
@@ -1456,6 +1839,28 @@ func (g *gitBackEnd) replayComments(token string) (map[string]foneroplugin.Comme
 
 				commentsLikes = append(commentsLikes, lc)
 
+			case journalActionVisibility:
+				var scv foneroplugin.SetCommentVisibility
+				err = d.Decode(&scv)
+				if err != nil {
+					return fmt.Errorf("journal visibility: %v",
+						err)
+				}
+
+				// Ensure comment has been added
+				c, ok := comments[scv.CommentID]
+				if !ok {
+					// Complain but we can't do anything
+					// about it. Can't return error or we'd
+					// abort journal loop.
+					log.Errorf("comment not found: %v",
+						scv.CommentID)
+					return nil
+				}
+
+				c.Hidden = scv.Hidden
+				comments[scv.CommentID] = c
+
 			default:
 				return fmt.Errorf("invalid action: %v",
 					action.Action)
@@ -1623,6 +2028,12 @@ func (g *gitBackEnd) pluginStartVote(payload string) (string, error) {
 		return "", fmt.Errorf("DecodeStartVote %v", err)
 	}
 
+	// Reject votes with more options than can be tallied
+	if len(vote.Vote.Options) > foneroPluginLimits.maxVoteOptions {
+		return "", fmt.Errorf("vote has %v options, maximum is %v",
+			len(vote.Vote.Options), foneroPluginLimits.maxVoteOptions)
+	}
+
 	// Verify vote bits are somewhat sane
 	for _, v := range vote.Vote.Options {
 		err = _validateVoteBit(vote.Vote, v.Bits)
@@ -1676,15 +2087,19 @@ func (g *gitBackEnd) pluginStartVote(payload string) (string, error) {
 			foneroplugin.VoteDurationMax)
 	}
 
+	// On EndHeight: we start in the past, add maturity to correct
+	endHeight, err := calcVoteEndHeight(snapshotBlock.Height,
+		vote.Vote.Duration, uint32(g.activeNetParams.TicketMaturity))
+	if err != nil {
+		return "", err
+	}
+
 	svr := foneroplugin.StartVoteReply{
 		Version: foneroplugin.VersionStartVoteReply,
 		StartBlockHeight: strconv.FormatUint(uint64(snapshotBlock.Height),
 			10),
-		StartBlockHash: snapshotBlock.Hash,
-		// On EndHeight: we start in the past, add maturity to correct
-		EndHeight: strconv.FormatUint(uint64(snapshotBlock.Height+
-			vote.Vote.Duration+
-			uint32(g.activeNetParams.TicketMaturity)), 10),
+		StartBlockHash:  snapshotBlock.Hash,
+		EndHeight:       strconv.FormatUint(uint64(endHeight), 10),
 		EligibleTickets: snapshot,
 	}
 	svrb, err := foneroplugin.EncodeStartVoteReply(svr)
@@ -1840,7 +2255,9 @@ func _validateVoteBit(vote foneroplugin.Vote, bit uint64) error {
 func (g *gitBackEnd) validateVoteBit(token, bit string) error {
 	b, err := strconv.ParseUint(bit, 16, 64)
 	if err != nil {
-		return err
+		return invalidVoteBitError{
+			err: fmt.Errorf("invalid vote bit '%v'", bit),
+		}
 	}
 
 	g.Lock()
@@ -1965,6 +2382,18 @@ func (g *gitBackEnd) replayBallot(token string) error {
 	return nil
 }
 
+// calcVoteEndHeight returns the end height of a vote that starts at the passed
+// in snapshot height.  An error is returned when the end height does not come
+// after the start height or does not fit in a block height.
+func calcVoteEndHeight(startHeight, duration, maturity uint32) (uint32, error) {
+	endHeight := uint64(startHeight) + uint64(duration) + uint64(maturity)
+	if endHeight <= uint64(startHeight) || endHeight > math.MaxUint32 {
+		return 0, fmt.Errorf("invalid vote end height: start %v "+
+			"duration %v maturity %v", startHeight, duration, maturity)
+	}
+	return uint32(endHeight), nil
+}
+
 // voteEndHeight returns the end height for the voting period of the passed in
 // proposal.  This function is expensive due to it's filesystem touches and
 // therefore is lazily cached.
@@ -2330,6 +2759,7 @@ func (g *gitBackEnd) pluginProposalVotes(payload string) (string, error) {
 		}
 		return "", err
 	}
+	vrr.Started = true
 
 nodata:
 	reply, err := foneroplugin.EncodeVoteResultsReply(vrr)
@@ -2548,3 +2978,26 @@ func (g *gitBackEnd) pluginLoadVoteResults() (string, error) {
 	}
 	return string(reply), nil
 }
+
+// pluginRecomputeVoteResults is a pass through function.
+// CmdRecomputeVoteResults does not require any work to be performed in
+// gitBackEnd.
+func (g *gitBackEnd) pluginRecomputeVoteResults() (string, error) {
+	r := foneroplugin.RecomputeVoteResultsReply{}
+	reply, err := foneroplugin.EncodeRecomputeVoteResultsReply(r)
+	if err != nil {
+		return "", err
+	}
+	return string(reply), nil
+}
+
+// pluginExpireActiveVotes is a pass through function. CmdExpireActiveVotes
+// does not require any work to be performed in gitBackEnd.
+func (g *gitBackEnd) pluginExpireActiveVotes() (string, error) {
+	r := foneroplugin.ExpireActiveVotesReply{}
+	reply, err := foneroplugin.EncodeExpireActiveVotesReply(r)
+	if err != nil {
+		return "", err
+	}
+	return string(reply), nil
+}
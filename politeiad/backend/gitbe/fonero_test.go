@@ -0,0 +1,311 @@
+// Copyright (c) 2017-2019 The Fonero developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package gitbe
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/fonero-project/fnod/chaincfg"
+	fnodataapi "github.com/fonero-project/fnodata/api/types"
+	"github.com/fonero-project/politeia/foneroplugin"
+	"github.com/fonero-project/politeia/politeiad/api/v1/identity"
+)
+
+func TestMaxCommentLength(t *testing.T) {
+	settings := foneroPluginSettings
+	defer func() {
+		foneroPluginSettings = settings
+	}()
+
+	var tests = []struct {
+		name    string
+		setting string // Empty string means not set
+		want    int
+		wantErr bool
+	}{
+		{"not set", "", 0, false},
+		{"valid", "8000", 8000, false},
+		{"zero", "0", 0, false},
+		{"negative", "-1", 0, true},
+		{"invalid", "long", 0, true},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			foneroPluginSettings = make(map[string]string)
+			if v.setting != "" {
+				foneroPluginSettings[foneroPluginMaxCommentLength] =
+					v.setting
+			}
+
+			got, err := maxCommentLength()
+			if (err != nil) != v.wantErr {
+				t.Fatalf("got error %v, want error %v", err, v.wantErr)
+			}
+			if got != v.want {
+				t.Fatalf("got %v, want %v", got, v.want)
+			}
+		})
+	}
+}
+
+func TestParseFoneroPluginLimits(t *testing.T) {
+	settings := foneroPluginSettings
+	defer func() {
+		foneroPluginSettings = settings
+	}()
+
+	var tests = []struct {
+		name     string
+		settings map[string]string
+		want     foneroPluginLimitSettings
+		wantErr  bool
+	}{
+		{"not set", map[string]string{}, foneroPluginLimitSettings{
+			maxVoteOptions: defaultMaxVoteOptions,
+		}, false},
+		{"comment length", map[string]string{
+			foneroPluginMaxCommentLength: "8000",
+		}, foneroPluginLimitSettings{
+			maxCommentLength: 8000,
+			maxVoteOptions:   defaultMaxVoteOptions,
+		}, false},
+		{"invalid comment length", map[string]string{
+			foneroPluginMaxCommentLength: "long",
+		}, foneroPluginLimitSettings{}, true},
+		{"vote options", map[string]string{
+			foneroPluginMaxVoteOptions: "16",
+		}, foneroPluginLimitSettings{maxVoteOptions: 16}, false},
+		{"zero vote options", map[string]string{
+			foneroPluginMaxVoteOptions: "0",
+		}, foneroPluginLimitSettings{}, true},
+		{"invalid vote options", map[string]string{
+			foneroPluginMaxVoteOptions: "many",
+		}, foneroPluginLimitSettings{}, true},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			foneroPluginSettings = v.settings
+
+			got, err := parseFoneroPluginLimits()
+			if (err != nil) != v.wantErr {
+				t.Fatalf("got error %v, want error %v", err, v.wantErr)
+			}
+			if got != v.want {
+				t.Fatalf("got %+v, want %+v", got, v.want)
+			}
+		})
+	}
+}
+
+func TestParentCommentExists(t *testing.T) {
+	comments := foneroPluginCommentsCache
+	defer func() {
+		foneroPluginCommentsCache = comments
+	}()
+
+	// Comment 1 of proposal a exists and comment 2 of proposal a
+	// has been censored.
+	foneroPluginCommentsCache = map[string]map[string]foneroplugin.Comment{
+		"a": {
+			"1": {Token: "a", CommentID: "1"},
+			"2": {Token: "a", CommentID: "2", Censored: true},
+		},
+	}
+
+	var tests = []struct {
+		name     string
+		token    string
+		parentID string
+		want     bool
+	}{
+		{"top level", "a", "0", true},
+		{"top level without comments", "b", "0", true},
+		{"valid reply", "a", "1", true},
+		{"nonexistent parent", "a", "3", false},
+		{"censored parent", "a", "2", false},
+		{"parent of other proposal", "b", "1", false},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			got := parentCommentExists(v.token, v.parentID)
+			if got != v.want {
+				t.Fatalf("got %v, want %v", got, v.want)
+			}
+		})
+	}
+}
+
+func TestValidateVoteBitNotHex(t *testing.T) {
+	g := &gitBackEnd{}
+	for _, v := range []string{"", "zz", "0x1", "-1"} {
+		err := g.validateVoteBit("a", v)
+		if _, ok := err.(invalidVoteBitError); !ok {
+			t.Fatalf("vote bit %q: got error %v, want invalidVoteBitError",
+				v, err)
+		}
+	}
+}
+
+func TestCalcVoteEndHeight(t *testing.T) {
+	var tests = []struct {
+		name     string
+		start    uint32
+		duration uint32
+		maturity uint32
+		want     uint32
+		wantErr  bool
+	}{
+		{"valid", 100, 2016, 256, 2372, false},
+		{"no duration", 100, 0, 0, 0, true},
+		{"overflow", math.MaxUint32 - 10, 2016, 256, 0, true},
+		{"wrap past start", 1, math.MaxUint32, 1, 0, true},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			got, err := calcVoteEndHeight(v.start, v.duration,
+				v.maturity)
+			if (err != nil) != v.wantErr {
+				t.Fatalf("got error %v, want error %v", err,
+					v.wantErr)
+			}
+			if got != v.want {
+				t.Fatalf("got %v, want %v", got, v.want)
+			}
+		})
+	}
+}
+
+func TestPluginBallotFinishedVote(t *testing.T) {
+	dir, err := ioutil.TempDir("", "politeia.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// fnodata reports a best block that lies after the end of the
+	// finished vote and before the end of the active vote.
+	fnodata := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/api/block/best":
+				json.NewEncoder(w).Encode(fnodataapi.BlockDataBasic{
+					Height: 500,
+				})
+			case "/api/txs/trimmed":
+				json.NewEncoder(w).Encode([]fnodataapi.TrimmedTx{})
+			default:
+				http.NotFound(w, r)
+			}
+		}))
+	defer fnodata.Close()
+
+	fi, err := identity.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fiJSON, err := fi.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	settings := foneroPluginSettings
+	replayed := journalsReplayed
+	defer func() {
+		foneroPluginSettings = settings
+		journalsReplayed = replayed
+	}()
+	foneroPluginSettings = map[string]string{
+		foneroPluginIdentity: string(fiJSON),
+		"fnodata":            fnodata.URL + "/",
+	}
+	journalsReplayed = true
+
+	g := &gitBackEnd{
+		activeNetParams: &chaincfg.TestNetParams,
+		journal:         NewJournal(),
+		vetted:          pijoin(dir, "vetted"),
+		journals:        pijoin(dir, "journals"),
+	}
+
+	// Prime the vote caches so that the start votes are not read
+	// from the repository.
+	endHeights := map[string]string{
+		"active":   "1000",
+		"finished": "10",
+	}
+	for token, endHeight := range endHeights {
+		err := os.MkdirAll(pijoin(g.vetted, token), 0774)
+		if err != nil {
+			t.Fatal(err)
+		}
+		foneroPluginVoteCache[token] = &foneroplugin.StartVote{
+			Vote: foneroplugin.Vote{
+				Token: token,
+				Mask:  0x03,
+				Options: []foneroplugin.VoteOption{
+					{Id: "no", Bits: 0x01},
+					{Id: "yes", Bits: 0x02},
+				},
+			},
+		}
+		foneroPluginVoteSnapshotCache[token] = foneroplugin.StartVoteReply{
+			EndHeight: endHeight,
+		}
+	}
+	defer func() {
+		for token := range endHeights {
+			delete(foneroPluginVoteCache, token)
+			delete(foneroPluginVoteSnapshotCache, token)
+		}
+	}()
+
+	ballot, err := foneroplugin.EncodeBallot(foneroplugin.Ballot{
+		Votes: []foneroplugin.CastVote{
+			{Token: "active", Ticket: "t1", VoteBit: "1"},
+			{Token: "finished", Ticket: "t2", VoteBit: "1"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reply, err := g.pluginBallot(string(ballot))
+	if err != nil {
+		t.Fatalf("pluginBallot: %v", err)
+	}
+	br, err := foneroplugin.DecodeBallotReply([]byte(reply))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(br.Receipts) != 2 {
+		t.Fatalf("got %v receipts, want 2", len(br.Receipts))
+	}
+
+	// Only the vote on the finished vote is rejected because it
+	// has ended.  The vote on the active vote passes the end
+	// height check and fails later on its missing signature.
+	if strings.HasPrefix(br.Receipts[0].Error, "vote has ended") {
+		t.Fatalf("active vote rejected: %v", br.Receipts[0].Error)
+	}
+	want := "vote has ended: finished"
+	if br.Receipts[1].Error != want {
+		t.Fatalf("got finished vote error %q, want %q",
+			br.Receipts[1].Error, want)
+	}
+	if _, ok := foneroPluginVotesCache["finished"]["t2"]; ok {
+		t.Fatalf("finished vote cached")
+	}
+}
@@ -2370,9 +2370,15 @@ func (g *gitBackEnd) Plugin(command, payload string) (string, string, error) {
 	case foneroplugin.CmdLikeComment:
 		payload, err := g.pluginLikeComment(payload)
 		return foneroplugin.CmdLikeComment, payload, err
+	case foneroplugin.CmdLikeCommentUndo:
+		payload, err := g.pluginLikeCommentUndo(payload)
+		return foneroplugin.CmdLikeCommentUndo, payload, err
 	case foneroplugin.CmdCensorComment:
 		payload, err := g.pluginCensorComment(payload)
 		return foneroplugin.CmdCensorComment, payload, err
+	case foneroplugin.CmdSetCommentVisibility:
+		payload, err := g.pluginSetCommentVisibility(payload)
+		return foneroplugin.CmdSetCommentVisibility, payload, err
 	case foneroplugin.CmdGetComments:
 		payload, err := g.pluginGetComments(payload)
 		return foneroplugin.CmdGetComments, payload, err
@@ -2385,6 +2391,12 @@ func (g *gitBackEnd) Plugin(command, payload string) (string, string, error) {
 	case foneroplugin.CmdLoadVoteResults:
 		payload, err := g.pluginLoadVoteResults()
 		return foneroplugin.CmdLoadVoteResults, payload, err
+	case foneroplugin.CmdRecomputeVoteResults:
+		payload, err := g.pluginRecomputeVoteResults()
+		return foneroplugin.CmdRecomputeVoteResults, payload, err
+	case foneroplugin.CmdExpireActiveVotes:
+		payload, err := g.pluginExpireActiveVotes()
+		return foneroplugin.CmdExpireActiveVotes, payload, err
 	}
 	return "", "", fmt.Errorf("invalid payload command") // XXX this needs to become a type error
 }
@@ -2557,6 +2569,11 @@ func New(anp *chaincfg.Params, root string, fnotimeHost string, gitPath string,
 	setFoneroPluginSetting(foneroPluginJournals, g.journals)
 	setFoneroPluginHook(PluginPostHookEdit, g.foneroPluginPostEdit)
 
+	foneroPluginLimits, err = parseFoneroPluginLimits()
+	if err != nil {
+		return nil, err
+	}
+
 	// Create jounals path
 	// XXX this needs to move into plugin init
 	log.Infof("Journals directory: %v", g.journals)
@@ -5,6 +5,7 @@
 package cache
 
 import (
+	"context"
 	"errors"
 )
 
@@ -35,6 +36,10 @@ var (
 	// ErrInvalidPluginCmd is emitted when an invalid plugin command
 	// is used.
 	ErrInvalidPluginCmd = errors.New("invalid plugin command")
+
+	// ErrBuildInProgress is emitted when a cache build is requested
+	// while a build of the same cache is already running.
+	ErrBuildInProgress = errors.New("cache build already in progress")
 )
 
 const (
@@ -126,6 +131,26 @@ type Plugin struct {
 	Settings []PluginSetting // Settings
 }
 
+// NewCommentEvent describes a comment that has been added to the cache.
+type NewCommentEvent struct {
+	Token     string // Censorship token
+	CommentID string // Comment ID
+	ParentID  string // Parent comment ID
+	PublicKey string // Public key of the comment author
+}
+
+// NewCommentHandler is called with every comment that is added to the cache.
+// It is called asynchronously and must not assume that the comment is still
+// the latest comment of the record.
+type NewCommentHandler func(NewCommentEvent)
+
+// BestBlockSource describes a source that cache plugins can consult directly
+// for the current best block height.
+type BestBlockSource interface {
+	// Get the current best block height
+	BestBlock() (uint64, error)
+}
+
 // PluginDriver describes the common set of methods that the cache uses to
 // build and maintain the cache for a plugin.
 //
@@ -137,13 +162,21 @@ type PluginDriver interface {
 	// Setup the plugin tables
 	Setup() error
 
-	// Build the plugin tables from scratch
-	Build(string) error
+	// Build the plugin tables from scratch.  The build is aborted
+	// when the context is cancelled.
+	Build(context.Context, string) error
 
 	// Execute a plugin command
 	Exec(string, string, string) (string, error)
 }
 
+// PluginSelfTester is implemented by caches that are able to verify that the
+// commands of a registered plugin round-trip through the cache.
+type PluginSelfTester interface {
+	// Verify the command round-trips of a plugin
+	PluginSelfTest(string) error
+}
+
 // Cache describes the interface used for interacting with an external
 // politeiad cache.  The politeiad backend implementation serves as the source
 // of truth for politeiad data and an external cache can be used if more
@@ -158,6 +191,11 @@ type Cache interface {
 	// Get a specific version of a record
 	RecordVersion(string, string) (*Record, error)
 
+	// Get a batch of records.  The latest version of each record is
+	// returned unless a specific version is requested for its token.
+	// Unknown tokens are not included in the reply.
+	Records([]string, map[string]string) (map[string]Record, error)
+
 	// Update a record
 	UpdateRecord(Record) error
 
@@ -177,8 +215,9 @@ type Cache interface {
 	// Setup the record cache tables
 	Setup() error
 
-	// Build the records cache from scratch
-	Build([]Record) error
+	// Build the records cache from scratch.  The build is aborted
+	// when the context is cancelled.
+	Build(context.Context, []Record) error
 
 	// Register a plugin with the cache
 	RegisterPlugin(Plugin) error
@@ -186,8 +225,9 @@ type Cache interface {
 	// Setup the database tables for a plugin
 	PluginSetup(string) error
 
-	// Build the cache for a plugin
-	PluginBuild(string, string) error
+	// Build the cache for a plugin.  The build is aborted when the
+	// context is cancelled.
+	PluginBuild(context.Context, string, string) error
 
 	// Execute a plugin command
 	PluginExec(PluginCommand) (*PluginCommandReply, error)
@@ -4,7 +4,11 @@
 
 package cachestub
 
-import "github.com/fonero-project/politeia/politeiad/cache"
+import (
+	"context"
+
+	"github.com/fonero-project/politeia/politeiad/cache"
+)
 
 // cachestub implements the cache interface.
 type cachestub struct{}
@@ -24,6 +28,11 @@ func (c *cachestub) RecordVersion(token, version string) (*cache.Record, error)
 	return &cache.Record{}, nil
 }
 
+// Records is a stub to satisfy the cache interface.
+func (c *cachestub) Records(tokens []string, versions map[string]string) (map[string]cache.Record, error) {
+	return map[string]cache.Record{}, nil
+}
+
 // UpdateRecord is a stub to satisfy the cache interface.
 func (c *cachestub) UpdateRecord(r cache.Record) error {
 	return nil
@@ -54,7 +63,7 @@ func (c *cachestub) Setup() error {
 }
 
 // Build is a stub to satisfy the cache interface.
-func (c *cachestub) Build(records []cache.Record) error {
+func (c *cachestub) Build(ctx context.Context, records []cache.Record) error {
 	return nil
 }
 
@@ -68,7 +77,7 @@ func (c *cachestub) PluginSetup(id string) error {
 }
 
 // PluginBuild is a stub to satisfy the cache interface.
-func (c *cachestub) PluginBuild(id, payload string) error {
+func (c *cachestub) PluginBuild(ctx context.Context, id, payload string) error {
 	return nil
 }
 
@@ -5,10 +5,12 @@
 package cockroachdb
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -28,17 +30,32 @@ const (
 	tableMetadataStreams = "metadata_streams"
 	tableFiles           = "files"
 
+	// Build table suffixes. A build populates build tables that are
+	// then swapped with the live tables. The live tables are renamed
+	// to old tables during the swap and dropped once it completes.
+	buildTableSuffix = "_build"
+	oldTableSuffix   = "_old"
+
 	// Database users
 	UserPoliteiad   = "politeiad"   // politeiad user (read/write access)
 	UserPoliteiawww = "politeiawww" // politeiawww user (read access)
 )
 
+// recordTables contains the names of the records cache tables that are
+// rebuilt during a build.
+var recordTables = []string{tableRecords, tableMetadataStreams, tableFiles}
+
 // cockroachdb implements the cache interface.
 type cockroachdb struct {
 	sync.RWMutex
-	shutdown  bool                          // Backend is shutdown
-	recordsdb *gorm.DB                      // Database context
-	plugins   map[string]cache.PluginDriver // [pluginID]PluginDriver
+	buildMtx        sync.RWMutex                  // Held for writing during a build
+	building        bool                          // Build in progress
+	shutdown        bool                          // Backend is shutdown
+	recordsdb       *gorm.DB                      // Database context
+	plugins         map[string]cache.PluginDriver // [pluginID]PluginDriver
+	bestBlockSource cache.BestBlockSource         // Best block source (optional)
+	newComment      cache.NewCommentHandler       // New comment handler (optional)
+	buildSigs       buildSigVerification          // Build signature verification
 }
 
 // NewRecord creates a new entry in the database for the passed in record.
@@ -53,6 +70,11 @@ func (c *cockroachdb) NewRecord(cr cache.Record) error {
 		return cache.ErrShutdown
 	}
 
+	// Wait for any build that is in progress to finish so that
+	// the write is not lost when the build tables are swapped in.
+	c.buildMtx.RLock()
+	defer c.buildMtx.RUnlock()
+
 	v, err := strconv.ParseUint(cr.Version, 10, 64)
 	if err != nil {
 		return fmt.Errorf("parse version '%v' failed: %v",
@@ -149,6 +171,76 @@ func (c *cockroachdb) Record(token string) (*cache.Record, error) {
 	return &cr, nil
 }
 
+// recordsQuery returns the where clause and arguments that select a batch of
+// records.  The latest version of each token is selected unless a specific
+// version is requested for the token in the versions map.
+func recordsQuery(tokens []string, versions map[string]string) (string, []interface{}) {
+	latest := make([]string, 0, len(tokens))
+	keys := make([]string, 0, len(versions))
+	for _, v := range tokens {
+		version, ok := versions[v]
+		if ok && version != "" {
+			keys = append(keys, v+version)
+			continue
+		}
+		latest = append(latest, v)
+	}
+
+	where := make([]string, 0, 2)
+	args := make([]interface{}, 0, 2)
+	if len(latest) > 0 {
+		where = append(where, `(records.token IN (?) AND `+
+			`records.version = (SELECT MAX(r.version) FROM records r `+
+			`WHERE r.token = records.token))`)
+		args = append(args, latest)
+	}
+	if len(keys) > 0 {
+		where = append(where, "records.key IN (?)")
+		args = append(args, keys)
+	}
+
+	return strings.Join(where, " OR "), args
+}
+
+// Records gets a batch of records from the database using a single query.
+// The latest version of each record is returned unless a specific version is
+// requested for its token.  The returned map is keyed by token and does not
+// contain entries for tokens that were not found.
+func (c *cockroachdb) Records(tokens []string, versions map[string]string) (map[string]cache.Record, error) {
+	log.Tracef("Records: %v", tokens)
+
+	c.RLock()
+	shutdown := c.shutdown
+	c.RUnlock()
+
+	if shutdown {
+		return nil, cache.ErrShutdown
+	}
+
+	if len(tokens) == 0 {
+		return map[string]cache.Record{}, nil
+	}
+
+	where, args := recordsQuery(tokens, versions)
+	records := make([]Record, 0, len(tokens))
+	err := c.recordsdb.
+		Where(where, args...).
+		Preload("Metadata").
+		Preload("Files").
+		Find(&records).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	cr := make(map[string]cache.Record, len(records)) // [token]Record
+	for _, r := range records {
+		cr[r.Token] = convertRecordToCache(r)
+	}
+
+	return cr, nil
+}
+
 // updateMetadataStreams updates a records metadata streams by deleting the
 // existing metadata streams then adding the passed in metadata streams to the
 // database.
@@ -254,6 +346,11 @@ func (c *cockroachdb) UpdateRecord(r cache.Record) error {
 		return cache.ErrShutdown
 	}
 
+	// Wait for any build that is in progress to finish so that
+	// the write is not lost when the build tables are swapped in.
+	c.buildMtx.RLock()
+	defer c.buildMtx.RUnlock()
+
 	v, err := strconv.ParseUint(r.Version, 10, 64)
 	if err != nil {
 		return fmt.Errorf("parse version '%v' failed: %v",
@@ -315,6 +412,11 @@ func (c *cockroachdb) UpdateRecordStatus(token, version string, status cache.Rec
 		return cache.ErrShutdown
 	}
 
+	// Wait for any build that is in progress to finish so that
+	// the write is not lost when the build tables are swapped in.
+	c.buildMtx.RLock()
+	defer c.buildMtx.RUnlock()
+
 	mdStreams := make([]MetadataStream, 0, len(metadata))
 	for _, ms := range metadata {
 		mdStreams = append(mdStreams, convertMDStreamFromCache(ms))
@@ -361,6 +463,11 @@ func (c *cockroachdb) UpdateRecordMetadata(token string, ms []cache.MetadataStre
 		return cache.ErrShutdown
 	}
 
+	// Wait for any build that is in progress to finish so that
+	// the write is not lost when the build tables are swapped in.
+	c.buildMtx.RLock()
+	defer c.buildMtx.RUnlock()
+
 	m := convertMDStreamsFromCache(ms)
 
 	// Run update in a transaction
@@ -406,6 +513,12 @@ func (c *cockroachdb) Inventory() ([]cache.Record, error) {
 		}
 		records = append(records, r)
 	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Release the connection before making the next query
+	rows.Close()
 
 	// XXX this could be done in a more efficient way
 	keys := make([]string, 0, len(records))
@@ -568,7 +681,12 @@ func (c *cockroachdb) RegisterPlugin(p cache.Plugin) error {
 	var pd cache.PluginDriver
 	switch p.ID {
 	case foneroplugin.ID:
-		pd = newFoneroPlugin(c.recordsdb, p)
+		d := newFoneroPlugin(c.recordsdb, p, c.bestBlockSource)
+		d.buildSigs = c.buildSigs
+		if c.newComment != nil {
+			d.setNewCommentHandler(c.newComment)
+		}
+		pd = d
 		c.plugins[foneroplugin.ID] = pd
 	default:
 		return cache.ErrInvalidPlugin
@@ -578,8 +696,90 @@ func (c *cockroachdb) RegisterPlugin(p cache.Plugin) error {
 	return pd.CheckVersion()
 }
 
-// PluginBuilds builds the cache for the passed in plugin.
-func (c *cockroachdb) PluginBuild(id, payload string) error {
+// SetBestBlockSource sets the source that cache plugins consult for the
+// current best block height.  Plugins that have already been registered are
+// updated as well.  This function must be called before the cache is used.
+func (c *cockroachdb) SetBestBlockSource(bbs cache.BestBlockSource) {
+	log.Tracef("SetBestBlockSource")
+
+	c.Lock()
+	defer c.Unlock()
+
+	c.bestBlockSource = bbs
+
+	if d, ok := c.plugins[foneroplugin.ID].(*fonero); ok {
+		d.setBestBlockSource(bbs)
+	}
+}
+
+// SetNewCommentHandler sets the handler that is called with every comment that
+// is added to the cache.  The handler is called from a separate goroutine so
+// that it cannot block the comment insert.  Plugins that have already been
+// registered are updated as well.
+func (c *cockroachdb) SetNewCommentHandler(h cache.NewCommentHandler) {
+	log.Tracef("SetNewCommentHandler")
+
+	c.Lock()
+	defer c.Unlock()
+
+	c.newComment = h
+
+	if d, ok := c.plugins[foneroplugin.ID].(*fonero); ok {
+		d.setNewCommentHandler(h)
+	}
+}
+
+// SetBuildSignatureVerification enables or disables the verification of the
+// comment and cast vote signatures of a plugin inventory when a plugin cache
+// is built.  Invalid entries are logged and are not added to the cache.  The
+// build fails when the number of invalid entries exceeds maxInvalid.  Plugins
+// that have already been registered are updated as well.
+func (c *cockroachdb) SetBuildSignatureVerification(enabled bool, maxInvalid int) {
+	log.Tracef("SetBuildSignatureVerification: %v %v", enabled, maxInvalid)
+
+	c.Lock()
+	defer c.Unlock()
+
+	c.buildSigs = buildSigVerification{
+		enabled:    enabled,
+		maxInvalid: maxInvalid,
+	}
+
+	if d, ok := c.plugins[foneroplugin.ID].(*fonero); ok {
+		d.buildSigs = c.buildSigs
+	}
+}
+
+// PluginSelfTest verifies that the commands of the passed in plugin round-trip
+// through the cache.  The command payloads must decode back into the payloads
+// that were encoded and the commands that do not write to the cache must be
+// dispatched and return a reply that can be decoded.
+func (c *cockroachdb) PluginSelfTest(id string) error {
+	log.Tracef("PluginSelfTest: %v", id)
+
+	c.RLock()
+	shutdown := c.shutdown
+	c.RUnlock()
+
+	if shutdown {
+		return cache.ErrShutdown
+	}
+
+	pd, err := c.getPlugin(id)
+	if err != nil {
+		return err
+	}
+	d, ok := pd.(*fonero)
+	if !ok {
+		return cache.ErrInvalidPlugin
+	}
+
+	return d.selfTest(foneroSelfTestCases)
+}
+
+// PluginBuilds builds the cache for the passed in plugin.  The build is
+// aborted when the passed in context is cancelled.
+func (c *cockroachdb) PluginBuild(ctx context.Context, id, payload string) error {
 	log.Tracef("PluginBuild: %v", id)
 
 	c.RLock()
@@ -597,7 +797,7 @@ func (c *cockroachdb) PluginBuild(id, payload string) error {
 
 	log.Infof("Building plugin cache: %v", id)
 
-	return plugin.Build(payload)
+	return plugin.Build(ctx, payload)
 }
 
 // createTables creates the database tables if they do not already exist.  A
@@ -663,6 +863,90 @@ func (c *cockroachdb) dropTables(tx *gorm.DB) error {
 	}).Error
 }
 
+// liveTableName returns the name of the live table of the passed in table.
+func liveTableName(table string) string {
+	return table
+}
+
+// buildTableName returns the name of the build table of the passed in table.
+func buildTableName(table string) string {
+	return table + buildTableSuffix
+}
+
+// dropBuildTables drops the build tables and the old tables of the passed in
+// tables.  These are left behind when a build or a table swap does not
+// complete.
+func dropBuildTables(db *gorm.DB, tables []string) error {
+	names := make([]interface{}, 0, len(tables)*2)
+	for _, v := range tables {
+		names = append(names, v+buildTableSuffix, v+oldTableSuffix)
+	}
+	return db.DropTableIfExists(names...).Error
+}
+
+// swapTables replaces the passed in live tables with their build tables and
+// replaces the version record with the passed in version record.  The tables
+// are renamed within a single transaction so that readers either see the old
+// tables or the new tables but never a partially built cache.  The old tables
+// are dropped once the transaction has been committed.  The transaction is
+// rolled back if the context is cancelled before it has been committed.
+func swapTables(ctx context.Context, db *gorm.DB, tables []string, v Version) error {
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
+
+	tx := db.Begin()
+	for _, t := range tables {
+		if tx.HasTable(t) {
+			q := fmt.Sprintf("ALTER TABLE %v RENAME TO %v",
+				t, t+oldTableSuffix)
+			err := tx.Exec(q).Error
+			if err != nil {
+				tx.Rollback()
+				return fmt.Errorf("rename %v: %v", t, err)
+			}
+		}
+		q := fmt.Sprintf("ALTER TABLE %v RENAME TO %v",
+			buildTableName(t), t)
+		err := tx.Exec(q).Error
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("rename %v: %v", buildTableName(t), err)
+		}
+	}
+
+	err = tx.Delete(&Version{ID: v.ID}).Error
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("delete version: %v", err)
+	}
+	err = tx.Create(&v).Error
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("create version: %v", err)
+	}
+
+	err = ctx.Err()
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	err = tx.Commit().Error
+	if err != nil {
+		return fmt.Errorf("commit transaction: %v", err)
+	}
+
+	// The new tables are already live at this point so
+	// failing to drop the old tables is not fatal.
+	err = dropBuildTables(db, tables)
+	if err != nil {
+		log.Errorf("swapTables: drop old tables: %v", err)
+	}
+
+	return nil
+}
+
 // Setup creates the database tables for the records cache if they do not
 // already exist. A version record is inserted into the database during table
 // creation.
@@ -686,16 +970,104 @@ func (c *cockroachdb) Setup() error {
 	return tx.Commit().Error
 }
 
-// build the records cache using the passed in records.
-//
-// This function cannot be called using a transaction because it could
-// potentially exceed cockroachdb's transaction size limit.
-func (c *cockroachdb) build(records []Record) error {
-	log.Tracef("build")
+// createBuildTables creates the records cache build tables.  Any build tables
+// that were left behind by a previous build are dropped first.  The tables are
+// not created if the context is cancelled.
+func (c *cockroachdb) createBuildTables(ctx context.Context) error {
+	err := dropBuildTables(c.recordsdb, recordTables)
+	if err != nil {
+		return fmt.Errorf("drop build tables: %v", err)
+	}
+
+	tx := c.recordsdb.Begin()
+	err = tx.Table(buildTableName(tableRecords)).
+		CreateTable(&Record{}).
+		Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	err = tx.Table(buildTableName(tableMetadataStreams)).
+		CreateTable(&MetadataStream{}).
+		Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	err = tx.Table(buildTableName(tableFiles)).
+		CreateTable(&File{}).
+		Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = ctx.Err()
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// populateTables inserts the passed in records into the records cache tables.
+// The table function returns the name of the table that is written to so that
+// the records can be inserted into either the live tables or the build
+// tables.  The context is checked before each record is inserted so that a
+// cancelled build stops promptly.
+func (c *cockroachdb) populateTables(ctx context.Context, records []Record, table func(string) string) error {
+	for _, r := range records {
+		err := ctx.Err()
+		if err != nil {
+			return err
+		}
+
+		// The metadata streams and files are inserted manually
+		// since gorm does not apply the table name to the
+		// associations.
+		err = c.recordsdb.
+			Table(table(tableRecords)).
+			Set("gorm:save_associations", false).
+			Create(&r).
+			Error
+		if err != nil {
+			log.Debugf("create record failed on '%v'", r)
+			return fmt.Errorf("create record: %v", err)
+		}
+		for _, v := range r.Metadata {
+			v.RecordKey = r.Key
+			err := c.recordsdb.Table(table(tableMetadataStreams)).
+				Create(&v).
+				Error
+			if err != nil {
+				return fmt.Errorf("create metadata stream: %v", err)
+			}
+		}
+		for _, v := range r.Files {
+			v.RecordKey = r.Key
+			err := c.recordsdb.Table(table(tableFiles)).
+				Create(&v).
+				Error
+			if err != nil {
+				return fmt.Errorf("create file: %v", err)
+			}
+		}
+	}
+
+	return nil
+}
 
+// buildInPlace drops the records cache tables then recreates and populates
+// them.  The records cache is unavailable until the build completes.
+func (c *cockroachdb) buildInPlace(ctx context.Context, records []Record) error {
 	// Drop record tables
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
 	tx := c.recordsdb.Begin()
-	err := c.dropTables(tx)
+	err = c.dropTables(tx)
 	if err != nil {
 		tx.Rollback()
 		return fmt.Errorf("drop tables: %v", err)
@@ -706,6 +1078,10 @@ func (c *cockroachdb) build(records []Record) error {
 	}
 
 	// Create record tables
+	err = ctx.Err()
+	if err != nil {
+		return err
+	}
 	tx = c.recordsdb.Begin()
 	err = c.createTables(tx)
 	if err != nil {
@@ -718,28 +1094,82 @@ func (c *cockroachdb) build(records []Record) error {
 	}
 
 	// Populate record tables
-	for _, r := range records {
-		err := c.recordsdb.Create(&r).Error
-		if err != nil {
-			log.Debugf("create record failed on '%v'", r)
-			return fmt.Errorf("create record: %v", err)
-		}
+	return c.populateTables(ctx, records, liveTableName)
+}
+
+// build the records cache using the passed in records.  The records are
+// inserted into build tables that are swapped with the live tables once they
+// have been populated so that the existing cache keeps serving reads during
+// the build.  The live tables are rebuilt in place if the tables cannot be
+// swapped.  The build stops with the context error if the context is
+// cancelled.
+//
+// This function cannot be called using a transaction because it could
+// potentially exceed cockroachdb's transaction size limit.
+func (c *cockroachdb) build(ctx context.Context, records []Record) error {
+	log.Tracef("build")
+
+	err := c.createBuildTables(ctx)
+	if err != nil {
+		return fmt.Errorf("create build tables: %v", err)
+	}
+	err = c.populateTables(ctx, records, buildTableName)
+	if err != nil {
+		// The build tables are dropped by the next build
+		return err
 	}
 
-	return nil
+	err = swapTables(ctx, c.recordsdb, recordTables, Version{
+		ID:        cacheID,
+		Version:   cacheVersion,
+		Timestamp: time.Now().Unix(),
+	})
+	if err == nil {
+		return nil
+	}
+	if ctx.Err() != nil {
+		return err
+	}
+
+	log.Warnf("Unable to swap records cache tables, rebuilding "+
+		"the tables in place: %v", err)
+	err = dropBuildTables(c.recordsdb, recordTables)
+	if err != nil {
+		return fmt.Errorf("drop build tables: %v", err)
+	}
+
+	return c.buildInPlace(ctx, records)
 }
 
-// Build drops all existing tables from the records cache, recreates them, then
-// builds the records cache using the passed in records.
-func (c *cockroachdb) Build(records []cache.Record) error {
+// Build rebuilds the records cache using the passed in records.  The existing
+// records cache continues to serve reads until the rebuilt tables replace it.
+// Record writes are blocked until the build has finished.
+// The build is aborted when the context is cancelled and the version record is
+// removed so that the cache is rebuilt on the next start up.  Only one build
+// may run at a time; cache.ErrBuildInProgress is returned if a build is
+// already running.
+func (c *cockroachdb) Build(ctx context.Context, records []cache.Record) error {
 	log.Tracef("Build")
 
+	// The lock is not held for the duration of the build so
+	// that reads are not blocked while the cache is built.
 	c.Lock()
-	defer c.Unlock()
-
 	if c.shutdown {
+		c.Unlock()
 		return cache.ErrShutdown
 	}
+	if c.building {
+		c.Unlock()
+		return cache.ErrBuildInProgress
+	}
+	c.building = true
+	c.Unlock()
+
+	defer func() {
+		c.Lock()
+		c.building = false
+		c.Unlock()
+	}()
 
 	log.Infof("Building records cache")
 
@@ -755,8 +1185,11 @@ func (c *cockroachdb) Build(records []cache.Record) error {
 
 	// Build the records cache. This is not run using a
 	// transaction because it could potentially exceed
-	// cockroachdb's transaction size limit.
-	err := c.build(r)
+	// cockroachdb's transaction size limit. Writes are
+	// blocked until the rebuilt tables are live.
+	c.buildMtx.Lock()
+	err := c.build(ctx, r)
+	c.buildMtx.Unlock()
 	if err != nil {
 		// Remove the version record. This will
 		// force a rebuild on the next start up.
@@ -0,0 +1,144 @@
+// Copyright (c) 2017-2019 The Fonero developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package cockroachdb
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fonero-project/politeia/politeiad/cache"
+	"github.com/jinzhu/gorm"
+)
+
+// testDBEnv is the environment variable that contains the connection URL of
+// the CockroachDB instance that is used by the tests that require a database,
+// e.g. postgresql://root@localhost:26257?sslmode=disable.  These tests are
+// skipped when it is not set.
+const testDBEnv = "POLITEIAD_CACHE_TESTDB"
+
+// newTestDB creates an empty database on the test CockroachDB instance and
+// returns a connection to it that is configured the same way as the
+// connection of the records cache.  The records cache tables are created.  The
+// returned function closes the connection and drops the database.
+func newTestDB(t *testing.T) (*gorm.DB, func()) {
+	t.Helper()
+
+	addr := os.Getenv(testDBEnv)
+	if addr == "" {
+		t.Skipf("%v is not set", testDBEnv)
+	}
+	u, err := url.Parse(addr)
+	if err != nil {
+		t.Fatalf("parse %v: %v", testDBEnv, err)
+	}
+
+	admin, err := gorm.Open("postgres", u.String())
+	if err != nil {
+		t.Fatalf("connect to test database: %v", err)
+	}
+	name := fmt.Sprintf("%v_test_%v", cacheID, time.Now().UnixNano())
+	err = admin.Exec("CREATE DATABASE " + name).Error
+	if err != nil {
+		admin.Close()
+		t.Fatalf("create database %v: %v", name, err)
+	}
+	drop := func() {
+		err := admin.Exec("DROP DATABASE " + name + " CASCADE").Error
+		if err != nil {
+			t.Errorf("drop database %v: %v", name, err)
+		}
+		admin.Close()
+	}
+
+	u.Path = "/" + name
+	db, err := gorm.Open("postgres", u.String())
+	if err != nil {
+		drop()
+		t.Fatalf("connect to database %v: %v", name, err)
+	}
+	db.LogMode(false)
+	db.SingularTable(true)
+
+	tx := db.Begin()
+	err = (&cockroachdb{}).createTables(tx)
+	if err != nil {
+		tx.Rollback()
+		db.Close()
+		drop()
+		t.Fatalf("create tables: %v", err)
+	}
+	err = tx.Commit().Error
+	if err != nil {
+		db.Close()
+		drop()
+		t.Fatalf("commit transaction: %v", err)
+	}
+
+	return db, func() {
+		db.Close()
+		drop()
+	}
+}
+
+func TestRecordsQuery(t *testing.T) {
+	const (
+		latest = `(records.token IN (?) AND records.version = ` +
+			`(SELECT MAX(r.version) FROM records r ` +
+			`WHERE r.token = records.token))`
+		keys = "records.key IN (?)"
+	)
+
+	var tests = []struct {
+		name      string
+		tokens    []string
+		versions  map[string]string
+		wantWhere string
+		wantArgs  []interface{}
+	}{
+		{"latest only", []string{"a", "b"}, nil, latest,
+			[]interface{}{[]string{"a", "b"}}},
+		{"versions only", []string{"a", "b"},
+			map[string]string{"a": "1", "b": "2"}, keys,
+			[]interface{}{[]string{"a1", "b2"}}},
+		{"latest and versions", []string{"a", "b", "c"},
+			map[string]string{"b": "3", "c": ""},
+			latest + " OR " + keys,
+			[]interface{}{[]string{"a", "c"}, []string{"b3"}}},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			where, args := recordsQuery(v.tokens, v.versions)
+			if where != v.wantWhere {
+				t.Fatalf("got where %q, want %q", where, v.wantWhere)
+			}
+			if !reflect.DeepEqual(args, v.wantArgs) {
+				t.Fatalf("got args %v, want %v", args, v.wantArgs)
+			}
+		})
+	}
+}
+
+func TestBuildInProgress(t *testing.T) {
+	c := &cockroachdb{
+		building: true,
+	}
+
+	err := c.Build(context.Background(), nil)
+	if err != cache.ErrBuildInProgress {
+		t.Fatalf("got error %v, want %v", err, cache.ErrBuildInProgress)
+	}
+
+	// The rejected build must not clear the flag of the running
+	// build.
+	if !c.building {
+		t.Fatalf("build no longer marked as in progress")
+	}
+}
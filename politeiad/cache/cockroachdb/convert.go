@@ -5,10 +5,11 @@
 package cockroachdb
 
 import (
+	"encoding/hex"
 	"fmt"
 	"strconv"
-	"strings"
 
+	"github.com/fonero-project/fnod/chaincfg/chainhash"
 	"github.com/fonero-project/politeia/foneroplugin"
 	"github.com/fonero-project/politeia/politeiad/cache"
 )
@@ -28,6 +29,17 @@ func convertMDStreamsFromCache(ms []cache.MetadataStream) []MetadataStream {
 	return m
 }
 
+func convertMDStreamsToFonero(ms []MetadataStream) []foneroplugin.MetadataStream {
+	m := make([]foneroplugin.MetadataStream, 0, len(ms))
+	for _, v := range ms {
+		m = append(m, foneroplugin.MetadataStream{
+			ID:      v.ID,
+			Payload: v.Payload,
+		})
+	}
+	return m
+}
+
 func convertRecordFromCache(r cache.Record, version uint64) Record {
 	files := make([]File, 0, len(r.Files))
 	for _, f := range r.Files {
@@ -117,6 +129,7 @@ func convertCommentFromFonero(c foneroplugin.Comment) Comment {
 		Receipt:   c.Receipt,
 		Timestamp: c.Timestamp,
 		Censored:  false,
+		Hidden:    c.Hidden,
 	}
 }
 
@@ -133,6 +146,46 @@ func convertCommentToFonero(c Comment) foneroplugin.Comment {
 		TotalVotes:  0,
 		ResultVotes: 0,
 		Censored:    c.Censored,
+		Hidden:      c.Hidden,
+	}
+}
+
+func convertCommentVersionFromComment(c Comment) CommentVersion {
+	return CommentVersion{
+		Token:     c.Token,
+		CommentID: c.CommentID,
+		Comment:   c.Comment,
+		Signature: c.Signature,
+		PublicKey: c.PublicKey,
+		Receipt:   c.Receipt,
+		Timestamp: c.Timestamp,
+		Censored:  c.Censored,
+	}
+}
+
+func convertCommentVersionFromCensorComment(cc foneroplugin.CensorComment, timestamp int64) CommentVersion {
+	return CommentVersion{
+		Token:     cc.Token,
+		CommentID: cc.CommentID,
+		Signature: cc.Signature,
+		PublicKey: cc.PublicKey,
+		Receipt:   cc.Receipt,
+		Timestamp: timestamp,
+		Censored:  true,
+		Reason:    cc.Reason,
+	}
+}
+
+func convertCommentVersionToFonero(cv CommentVersion) foneroplugin.CommentVersion {
+	return foneroplugin.CommentVersion{
+		Version:   cv.Version,
+		Comment:   cv.Comment,
+		Signature: cv.Signature,
+		PublicKey: cv.PublicKey,
+		Receipt:   cv.Receipt,
+		Timestamp: cv.Timestamp,
+		Censored:  cv.Censored,
+		Reason:    cv.Reason,
 	}
 }
 
@@ -143,6 +196,8 @@ func convertLikeCommentFromFonero(lc foneroplugin.LikeComment) LikeComment {
 		Action:    lc.Action,
 		Signature: lc.Signature,
 		PublicKey: lc.PublicKey,
+		Receipt:   lc.Receipt,
+		Timestamp: lc.Timestamp,
 	}
 }
 
@@ -153,6 +208,17 @@ func convertLikeCommentToFonero(lc LikeComment) foneroplugin.LikeComment {
 		Action:    lc.Action,
 		Signature: lc.Signature,
 		PublicKey: lc.PublicKey,
+		Receipt:   lc.Receipt,
+		Timestamp: lc.Timestamp,
+	}
+}
+
+func convertLikeCommentStateToFonero(s LikeCommentState) foneroplugin.LikeComment {
+	return foneroplugin.LikeComment{
+		Token:     s.Token,
+		CommentID: s.CommentID,
+		Action:    s.Action,
+		PublicKey: s.PublicKey,
 	}
 }
 
@@ -180,14 +246,47 @@ func convertAuthorizeVoteToFonero(av AuthorizeVote) foneroplugin.AuthorizeVote {
 	}
 }
 
+// normalizeEligibleTickets returns the passed in eligible tickets with the
+// duplicate and malformed tickets removed.  A valid ticket is a hex encoded
+// ticket hash.  The order of the first occurrence of each ticket is preserved.
+func normalizeEligibleTickets(tickets []string) []string {
+	seen := make(map[string]struct{}, len(tickets))
+	normalized := make([]string, 0, len(tickets))
+	for _, v := range tickets {
+		if len(v) != chainhash.MaxHashStringSize {
+			continue
+		}
+		if _, err := hex.DecodeString(v); err != nil {
+			continue
+		}
+		if _, ok := seen[v]; ok {
+			continue
+		}
+		seen[v] = struct{}{}
+		normalized = append(normalized, v)
+	}
+	return normalized
+}
+
 func convertStartVoteFromFonero(sv foneroplugin.StartVote, svr foneroplugin.StartVoteReply, endHeight uint64) StartVote {
 	opts := make([]VoteOption, 0, len(sv.Vote.Options))
 	for _, v := range sv.Vote.Options {
 		opts = append(opts, VoteOption{
-			Token:       sv.Vote.Token,
-			ID:          v.Id,
-			Description: v.Description,
-			Bits:        v.Bits,
+			Token:          sv.Vote.Token,
+			ID:             v.Id,
+			Description:    v.Description,
+			Bits:           v.Bits,
+			PassPercentage: v.PassPercentage,
+		})
+	}
+	normalized := normalizeEligibleTickets(svr.EligibleTickets)
+	tickets := make([]EligibleTicket, 0, len(normalized))
+	for i, v := range normalized {
+		tickets = append(tickets, EligibleTicket{
+			Key:      sv.Vote.Token + v,
+			Token:    sv.Vote.Token,
+			Ticket:   v,
+			Position: i,
 		})
 	}
 	return StartVote{
@@ -196,14 +295,15 @@ func convertStartVoteFromFonero(sv foneroplugin.StartVote, svr foneroplugin.Star
 		Duration:            sv.Vote.Duration,
 		QuorumPercentage:    sv.Vote.QuorumPercentage,
 		PassPercentage:      sv.Vote.PassPercentage,
+		Type:                sv.Vote.Type,
 		Options:             opts,
 		PublicKey:           sv.PublicKey,
 		Signature:           sv.Signature,
 		StartBlockHeight:    svr.StartBlockHeight,
 		StartBlockHash:      svr.StartBlockHash,
 		EndHeight:           endHeight,
-		EligibleTickets:     strings.Join(svr.EligibleTickets, ","),
-		EligibleTicketCount: len(svr.EligibleTickets),
+		EligibleTickets:     tickets,
+		EligibleTicketCount: len(tickets),
 	}
 }
 
@@ -211,9 +311,10 @@ func convertStartVoteToFonero(sv StartVote) (foneroplugin.StartVote, foneroplugi
 	opts := make([]foneroplugin.VoteOption, 0, len(sv.Options))
 	for _, v := range sv.Options {
 		opts = append(opts, foneroplugin.VoteOption{
-			Id:          v.ID,
-			Description: v.Description,
-			Bits:        v.Bits,
+			Id:             v.ID,
+			Description:    v.Description,
+			Bits:           v.Bits,
+			PassPercentage: v.PassPercentage,
 		})
 	}
 
@@ -227,12 +328,16 @@ func convertStartVoteToFonero(sv StartVote) (foneroplugin.StartVote, foneroplugi
 			QuorumPercentage: sv.QuorumPercentage,
 			PassPercentage:   sv.PassPercentage,
 			Options:          opts,
+			Type:             sv.Type,
 		},
 	}
 
 	var tix []string
-	if sv.EligibleTickets != "" {
-		tix = strings.Split(sv.EligibleTickets, ",")
+	if len(sv.EligibleTickets) > 0 {
+		tix = make([]string, 0, len(sv.EligibleTickets))
+		for _, v := range sv.EligibleTickets {
+			tix = append(tix, v.Ticket)
+		}
 	}
 	dsvr := foneroplugin.StartVoteReply{
 		StartBlockHeight: sv.StartBlockHeight,
@@ -250,7 +355,7 @@ func convertCastVoteFromFonero(cv foneroplugin.CastVote) CastVote {
 		Ticket:       cv.Ticket,
 		VoteBit:      cv.VoteBit,
 		Signature:    cv.Signature,
-		TokenVoteBit: cv.Token + cv.VoteBit,
+		TokenVoteBit: cv.Token + tallyVoteBit(cv.VoteBit),
 	}
 }
 
@@ -263,15 +368,23 @@ func convertCastVoteToFonero(cv CastVote) foneroplugin.CastVote {
 	}
 }
 
-func convertVoteOptionResultToFonero(r VoteOptionResult) foneroplugin.VoteOptionResult {
+// convertVoteOptionToFonero is used to build every fonero plugin vote option
+// result so that the option metadata does not depend on whether the votes were
+// looked up in the vote results table or were counted manually.
+func convertVoteOptionToFonero(o VoteOption, votes uint64, approved bool) foneroplugin.VoteOptionResult {
 	return foneroplugin.VoteOptionResult{
-		ID:          r.Option.ID,
-		Description: r.Option.Description,
-		Bits:        r.Option.Bits,
-		Votes:       r.Votes,
+		ID:          o.ID,
+		Description: o.Description,
+		Bits:        o.Bits,
+		Votes:       votes,
+		Approved:    approved,
 	}
 }
 
+func convertVoteOptionResultToFonero(r VoteOptionResult) foneroplugin.VoteOptionResult {
+	return convertVoteOptionToFonero(r.Option, r.Votes, r.Approved)
+}
+
 func convertVoteOptionResultsToFonero(r []VoteOptionResult) []foneroplugin.VoteOptionResult {
 	results := make([]foneroplugin.VoteOptionResult, 0, len(r))
 	for _, v := range r {
@@ -5,13 +5,25 @@
 package cockroachdb
 
 import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"reflect"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/fonero-project/fnod/chaincfg/chainhash"
+	"github.com/fonero-project/fnod/fnoec/secp256k1"
+	"github.com/fonero-project/fnod/wire"
 	"github.com/fonero-project/politeia/foneroplugin"
 	pd "github.com/fonero-project/politeia/politeiad/api/v1"
+	"github.com/fonero-project/politeia/politeiad/api/v1/identity"
 	"github.com/fonero-project/politeia/politeiad/cache"
 	"github.com/jinzhu/gorm"
 )
@@ -19,39 +31,646 @@ import (
 const (
 	// foneroVersion is the version of the cache implementation of
 	// fonero plugin. This may differ from the foneroplugin package
-	// version.
-	foneroVersion = "1.1"
+	// version.  Changing the version causes the fonero plugin cache
+	// to be rebuilt, which migrates the tables to the current models.
+	foneroVersion = "1.16"
 
 	// Fonero plugin table names
 	tableComments          = "comments"
 	tableCommentLikes      = "comment_likes"
+	tableCommentLikeStates = "comment_like_states"
+	tableCommentVersions   = "comment_versions"
 	tableCastVotes         = "cast_votes"
+	tableCastVoteCounts    = "cast_vote_counts"
 	tableAuthorizeVotes    = "authorize_votes"
 	tableVoteOptions       = "vote_options"
+	tableEligibleTickets   = "eligible_tickets"
 	tableStartVotes        = "start_votes"
 	tableVoteOptionResults = "vote_option_results"
 	tableVoteResults       = "vote_results"
+	tableCastVoteArchives  = "cast_vote_archives"
+	tableCensoredComments  = "censored_comments"
 
 	// Vote option IDs
 	voteOptionIDApproved = "yes"
 )
 
+// foneroTables contains the names of the fonero plugin tables that are
+// rebuilt during a build.
+var foneroTables = []string{tableComments, tableCommentLikes,
+	tableCommentLikeStates, tableCommentVersions, tableCastVotes,
+	tableCastVoteCounts, tableAuthorizeVotes, tableVoteOptions,
+	tableEligibleTickets, tableStartVotes, tableVoteOptionResults,
+	tableVoteResults, tableCastVoteArchives}
+
+var (
+	// errBestBlockRequired is emitted when a command requires a best
+	// block, none was provided, and no best block source is set.
+	errBestBlockRequired = errors.New("best block required")
+
+	// errCommentCycle is emitted when a comment is reparented underneath
+	// itself or one of its descendants.
+	errCommentCycle = errors.New("comment cannot be moved underneath " +
+		"itself or one of its descendants")
+
+	// errCommandLimit is emitted when a command is rejected because the
+	// maximum number of concurrent executions of the command has been
+	// reached and the command limits are configured to fail fast.
+	errCommandLimit = errors.New("command concurrency limit reached")
+)
+
+// settingSlowQueryThreshold is the plugin setting that configures the
+// duration after which a raw query is logged as slow.  The value must be
+// parsable by time.ParseDuration.  A value of zero disables the logging.
+const settingSlowQueryThreshold = "slowquerythreshold"
+
+// defaultSlowQueryThreshold is the slow query threshold that is used when the
+// plugin settings do not specify one.
+const defaultSlowQueryThreshold = 2 * time.Second
+
+// settingComputeVoteResults is the plugin setting that configures whether the
+// token inventory command computes the vote results of proposals that have
+// finished voting but that have not been added to the vote results table yet.
+// The value must be parsable by strconv.ParseBool.  By default the token
+// inventory command fails when vote results are missing and the vote results
+// must first be loaded using the loadvoteresults command.
+const settingComputeVoteResults = "computevoteresults"
+
+// settingMaxAuthorizeVoteSkips is the plugin setting that configures the
+// maximum number of authorize votes without a matching authorize vote reply
+// that are skipped when the cache is built.  The value must be a non-negative
+// integer.  The build fails once more authorize votes than the maximum have
+// been skipped.
+const settingMaxAuthorizeVoteSkips = "maxauthorizevoteskips"
+
+// settingCensoredCommentBody is the plugin setting that configures what
+// happens to the body of a comment when it is censored.  The body is always
+// removed from the comments table.  In purge mode, which is the default, the
+// body is discarded.  In audit mode the body is moved to the censored comments
+// table, which is kept when the cache is rebuilt.  Retaining encrypted
+// comment bodies is not supported.
+const settingCensoredCommentBody = "censoredcommentbody"
+
+// Censored comment body modes that are supported by the censored comment body
+// plugin setting.
+const (
+	censorModePurge = "purge" // Discard censored comment bodies
+	censorModeAudit = "audit" // Move censored comment bodies to an audit table
+)
+
+// defaultMaxAuthorizeVoteSkips is the maximum number of skipped authorize
+// votes that is used when the plugin settings do not specify one.
+const defaultMaxAuthorizeVoteSkips = 10
+
+// Plugin settings that configure the connection pool of the cache database.
+// The open and idle connection settings must be non-negative integers and the
+// lifetime setting must be a non-negative duration that is parsable by
+// time.ParseDuration.  The database defaults are used for settings that are
+// not provided.
+const (
+	settingMaxOpenConns    = "maxopenconns"    // Max open connections (0 is unlimited)
+	settingMaxIdleConns    = "maxidleconns"    // Max idle connections
+	settingConnMaxLifetime = "connmaxlifetime" // Max connection lifetime (0 is unlimited)
+)
+
+// poolSettings contains the connection pool configuration that is applied to
+// the cache database during setup.
+type poolSettings struct {
+	maxOpen     int           // Max open connections, 0 is unlimited
+	maxIdle     int           // Max idle connections, -1 if not set
+	maxLifetime time.Duration // Max connection lifetime, 0 is unlimited
+}
+
+// parsePoolSettings returns the connection pool settings that are configured
+// by the passed in plugin settings.  Invalid values are logged and ignored.
+func parsePoolSettings(settings []cache.PluginSetting) poolSettings {
+	ps := poolSettings{
+		maxIdle: -1,
+	}
+	for _, v := range settings {
+		switch v.Key {
+		case settingMaxOpenConns, settingMaxIdleConns:
+			n, err := strconv.Atoi(v.Value)
+			if err != nil || n < 0 {
+				log.Errorf("parsePoolSettings: invalid %v '%v', ignoring",
+					v.Key, v.Value)
+				continue
+			}
+			if v.Key == settingMaxOpenConns {
+				ps.maxOpen = n
+			} else {
+				ps.maxIdle = n
+			}
+		case settingConnMaxLifetime:
+			lifetime, err := time.ParseDuration(v.Value)
+			if err != nil || lifetime < 0 {
+				log.Errorf("parsePoolSettings: invalid %v '%v', ignoring",
+					v.Key, v.Value)
+				continue
+			}
+			ps.maxLifetime = lifetime
+		}
+	}
+
+	// The idle connections can not exceed the open connections
+	if ps.maxOpen > 0 && ps.maxIdle > ps.maxOpen {
+		log.Errorf("parsePoolSettings: %v %v exceeds %v %v, using %v",
+			settingMaxIdleConns, ps.maxIdle, settingMaxOpenConns,
+			ps.maxOpen, ps.maxOpen)
+		ps.maxIdle = ps.maxOpen
+	}
+
+	return ps
+}
+
+// Plugin settings that limit the number of heavy commands that are executed
+// concurrently.  The limit settings must be non-negative integers and a value
+// of zero, which is the default, does not limit the command.  Commands that
+// are over their limit wait for a running command to finish unless the fail
+// fast setting, which must be parsable by strconv.ParseBool, is enabled.  All
+// other commands are never limited.
+const (
+	settingMaxTokenInventory    = "maxtokeninventory"    // Max concurrent tokeninventory commands
+	settingMaxLoadVoteResults   = "maxloadvoteresults"   // Max concurrent loadvoteresults commands
+	settingCommandLimitFailFast = "commandlimitfailfast" // Fail commands that are over the limit
+)
+
+// commandLimitSettings maps the command limit plugin settings to the command
+// that they limit.
+var commandLimitSettings = map[string]string{
+	settingMaxTokenInventory:  foneroplugin.CmdTokenInventory,
+	settingMaxLoadVoteResults: foneroplugin.CmdLoadVoteResults,
+}
+
+// commandLimiter limits the number of concurrent executions of a command.
+type commandLimiter struct {
+	sem      chan struct{} // Semaphore, buffered to the limit
+	failFast bool          // Fail instead of wait when the limit is reached
+}
+
+// acquire reserves an execution slot.  It waits until a slot is available
+// unless the limiter fails fast, in which case errCommandLimit is returned
+// when no slot is available.
+func (l *commandLimiter) acquire() error {
+	if !l.failFast {
+		l.sem <- struct{}{}
+		return nil
+	}
+
+	select {
+	case l.sem <- struct{}{}:
+		return nil
+	default:
+		return errCommandLimit
+	}
+}
+
+// release frees an execution slot that was reserved using acquire.
+func (l *commandLimiter) release() {
+	<-l.sem
+}
+
+// startVoteCache is a concurrency safe in-memory cache of start votes, keyed
+// by token.  A start vote is immutable once it has been recorded so a cached
+// start vote only needs to be invalidated when a new start vote is recorded
+// for the token or when the cache is rebuilt.  The cached start votes include
+// their vote options but not their eligible tickets.
+type startVoteCache struct {
+	sync.Mutex
+	startVotes map[string]StartVote // [token]StartVote
+
+	// gen is incremented every time a start vote is invalidated so
+	// that a start vote that was looked up before the invalidation
+	// is not added to the cache.
+	gen uint64
+}
+
+// generation returns the current generation of the start vote cache.
+func (c *startVoteCache) generation() uint64 {
+	c.Lock()
+	defer c.Unlock()
+
+	return c.gen
+}
+
+// get returns a copy of the cached start vote of the passed in token.
+func (c *startVoteCache) get(token string) (*StartVote, bool) {
+	c.Lock()
+	defer c.Unlock()
+
+	sv, ok := c.startVotes[token]
+	if !ok {
+		return nil, false
+	}
+	sv.Options = append([]VoteOption(nil), sv.Options...)
+	return &sv, true
+}
+
+// put adds the passed in start vote to the cache unless the cache has been
+// invalidated since the passed in generation was read.
+func (c *startVoteCache) put(sv StartVote, gen uint64) {
+	c.Lock()
+	defer c.Unlock()
+
+	if gen != c.gen {
+		return
+	}
+	if c.startVotes == nil {
+		c.startVotes = make(map[string]StartVote)
+	}
+	sv.Options = append([]VoteOption(nil), sv.Options...)
+	sv.EligibleTickets = nil
+	c.startVotes[sv.Token] = sv
+}
+
+// invalidate removes the start vote of the passed in token from the cache.
+func (c *startVoteCache) invalidate(token string) {
+	c.Lock()
+	defer c.Unlock()
+
+	delete(c.startVotes, token)
+	c.gen++
+}
+
+// reset removes all of the start votes from the cache.
+func (c *startVoteCache) reset() {
+	c.Lock()
+	defer c.Unlock()
+
+	c.startVotes = nil
+	c.gen++
+}
+
+// parseCommandLimits returns the command limiters, keyed by command, that are
+// configured by the passed in plugin settings.  Invalid values are logged and
+// ignored.
+func parseCommandLimits(settings []cache.PluginSetting) map[string]*commandLimiter {
+	var failFast bool
+	limits := make(map[string]int, len(commandLimitSettings)) // [cmd]limit
+	for _, v := range settings {
+		if v.Key == settingCommandLimitFailFast {
+			ff, err := strconv.ParseBool(v.Value)
+			if err != nil {
+				log.Errorf("parseCommandLimits: invalid %v '%v', ignoring",
+					v.Key, v.Value)
+				continue
+			}
+			failFast = ff
+			continue
+		}
+
+		cmd, ok := commandLimitSettings[v.Key]
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(v.Value)
+		if err != nil || n < 0 {
+			log.Errorf("parseCommandLimits: invalid %v '%v', ignoring",
+				v.Key, v.Value)
+			continue
+		}
+		limits[cmd] = n
+	}
+
+	limiters := make(map[string]*commandLimiter, len(limits))
+	for cmd, n := range limits {
+		if n == 0 {
+			continue
+		}
+		limiters[cmd] = &commandLimiter{
+			sem:      make(chan struct{}, n),
+			failFast: failFast,
+		}
+	}
+
+	return limiters
+}
+
+// buildSigVerification configures the signature verification that is
+// performed on the plugin inventory before the cache is built.  Verification
+// is disabled by default to preserve rebuild speed.
+type buildSigVerification struct {
+	enabled    bool // Verify inventory signatures
+	maxInvalid int  // Maximum number of invalid entries allowed
+}
+
 // fonero implements the PluginDriver interface.
 type fonero struct {
-	recordsdb *gorm.DB              // Database context
-	version   string                // Version of fonero cache plugin
-	settings  []cache.PluginSetting // Plugin settings
+	sync.Mutex
+	buildMtx        sync.RWMutex          // Held for writing during a build
+	building        bool                  // Build in progress (protected by mutex)
+	recordsdb       *gorm.DB              // Database context
+	version         string                // Version of fonero cache plugin
+	settings        []cache.PluginSetting // Plugin settings
+	bestBlockSource cache.BestBlockSource // Best block source (protected by mutex)
+	buildSigs       buildSigVerification  // Build signature verification
+	slowQuery       time.Duration         // Slow query warning threshold
+	now             func() time.Time      // Clock used to time queries
+	pool            poolSettings          // Connection pool settings
+
+	// limiters limit the number of concurrent executions of heavy
+	// commands, keyed by command. Commands without a limiter are
+	// not limited.
+	limiters map[string]*commandLimiter
+
+	// computeVoteResults indicates that missing vote results are
+	// computed by the token inventory command instead of failing.
+	computeVoteResults bool
+
+	// maxAuthVoteSkips is the maximum number of authorize votes
+	// without a reply that are skipped during a build.
+	maxAuthVoteSkips int
+
+	// censorMode is the censored comment body mode. It is either
+	// censorModePurge or censorModeAudit.
+	censorMode string
+
+	// startVotes caches the start votes that are looked up by the
+	// vote commands.
+	startVotes startVoteCache
+
+	// lastBestBlock is the highest best block that the cache has
+	// been told about by politeiad or by a command payload. It is
+	// protected by the mutex.
+	lastBestBlock uint64
+
+	// newCommentHandler is called with every comment that is added
+	// by the new comment command. newComments queues the events
+	// for the handler so that the handler cannot block the insert.
+	// Both are protected by the mutex and newComments is nil until
+	// a handler has been set.
+	newCommentHandler cache.NewCommentHandler
+	newComments       chan cache.NewCommentEvent
+}
+
+// newCommentEventsBuffer is the number of new comment events that are queued
+// for the new comment handler.  Events are dropped when the queue is full.
+const newCommentEventsBuffer = 256
+
+// setNewCommentHandler sets the handler that is called with every comment that
+// is added by the new comment command.  The events are delivered to the
+// handler by a separate goroutine, which is started the first time a handler
+// is set.
+func (d *fonero) setNewCommentHandler(h cache.NewCommentHandler) {
+	d.Lock()
+	defer d.Unlock()
+
+	d.newCommentHandler = h
+	if d.newComments != nil {
+		return
+	}
+
+	d.newComments = make(chan cache.NewCommentEvent, newCommentEventsBuffer)
+	go func() {
+		for e := range d.newComments {
+			d.Lock()
+			h := d.newCommentHandler
+			d.Unlock()
+			if h != nil {
+				h(e)
+			}
+		}
+	}()
+}
+
+// notifyNewComment queues a new comment event for the new comment handler.
+// It never blocks; the event is dropped when the queue is full.
+func (d *fonero) notifyNewComment(c Comment) {
+	d.Lock()
+	ch := d.newComments
+	d.Unlock()
+	if ch == nil {
+		return
+	}
+
+	e := cache.NewCommentEvent{
+		Token:     c.Token,
+		CommentID: c.CommentID,
+		ParentID:  c.ParentID,
+		PublicKey: c.PublicKey,
+	}
+	select {
+	case ch <- e:
+	default:
+		log.Warnf("notifyNewComment: queue full, dropping event %v %v",
+			c.Token, c.CommentID)
+	}
+}
+
+// timeQuery starts timing the raw query identified by label and returns a
+// function that stops the timer.  A warning that contains the query label and
+// duration is logged when the query took longer than the slow query threshold.
+//
+// Usage: defer d.timeQuery("label")()
+func (d *fonero) timeQuery(label string) func() {
+	start := d.now()
+	return func() {
+		elapsed := d.now().Sub(start)
+		if d.slowQuery > 0 && elapsed > d.slowQuery {
+			log.Warnf("Slow query: label=%v duration=%v threshold=%v",
+				label, elapsed, d.slowQuery)
+		}
+	}
+}
+
+// bestBlock returns the best block height that should be used by a command.
+// When a best block source has been set it is always consulted, so that the
+// result does not depend on a possibly stale caller provided value.  When no
+// best block source has been set, the caller provided best block is required.
+func (d *fonero) bestBlock(requested uint64) (uint64, error) {
+	d.Lock()
+	bbs := d.bestBlockSource
+	d.Unlock()
+
+	if bbs != nil {
+		bb, err := bbs.BestBlock()
+		if err != nil {
+			return 0, fmt.Errorf("best block source: %v", err)
+		}
+		d.setLastBestBlock(bb)
+		return bb, nil
+	}
+
+	if requested == 0 {
+		return 0, errBestBlockRequired
+	}
+
+	d.setLastBestBlock(requested)
+	return requested, nil
+}
+
+// setBestBlockSource sets the best block source that is consulted by the
+// commands.
+func (d *fonero) setBestBlockSource(bbs cache.BestBlockSource) {
+	d.Lock()
+	defer d.Unlock()
+
+	d.bestBlockSource = bbs
+}
+
+// setLastBestBlock records the passed in best block if it is higher than the
+// last best block the cache was told about.
+func (d *fonero) setLastBestBlock(bestBlock uint64) {
+	d.Lock()
+	defer d.Unlock()
+
+	if bestBlock > d.lastBestBlock {
+		d.lastBestBlock = bestBlock
+	}
+}
+
+// cmdBestBlock returns the best block that the cache is operating against.
+// The best block source is used when one has been set.  Otherwise the highest
+// best block that the cache has been told about is returned.  politeiad
+// passes the best block that was returned by the backend as the reply
+// payload, which is recorded before the reply is prepared.
+func (d *fonero) cmdBestBlock(replyPayload string) (string, error) {
+	log.Tracef("fonero cmdBestBlock")
+
+	if replyPayload != "" {
+		bb, err := strconv.ParseUint(replyPayload, 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("parse best block '%v': %v",
+				replyPayload, err)
+		}
+		d.setLastBestBlock(bb)
+	}
+
+	bb, err := d.bestBlock(0)
+	if err == errBestBlockRequired {
+		d.Lock()
+		bb = d.lastBestBlock
+		d.Unlock()
+		if bb == 0 {
+			return "", err
+		}
+	} else if err != nil {
+		return "", err
+	}
+
+	reply, err := foneroplugin.EncodeBestBlockReply(
+		foneroplugin.BestBlockReply{
+			Height: bb,
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(reply), nil
+}
+
+// queryStrings runs the passed in raw query, which must select a single
+// string column, and returns the results.  The label identifies the query in
+// the slow query log.  The rows are closed before this
+// function returns so that the database connection is released back to the
+// connection pool before any subsequent queries are made.
+func (d *fonero) queryStrings(label, q string, args ...interface{}) ([]string, error) {
+	defer d.timeQuery(label)()
+
+	rows, err := d.recordsdb.Raw(q, args...).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var s string
+	strs := make([]string, 0, 1024) // PNOOMA
+	for rows.Next() {
+		err := rows.Scan(&s)
+		if err != nil {
+			return nil, err
+		}
+		strs = append(strs, s)
+	}
+
+	return strs, rows.Err()
+}
+
+// queryCount returns the number of rows that are selected by the passed in
+// raw query.  The label identifies the query in the slow query log.
+func (d *fonero) queryCount(label, q string, args ...interface{}) (int, error) {
+	defer d.timeQuery(label)()
+
+	var count int
+	err := d.recordsdb.
+		Raw("SELECT COUNT(*) FROM ("+q+") AS q", args...).
+		Row().
+		Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
 }
 
 // newComment inserts a Comment record into the database.  This function has a
 // database parameter so that it can be called inside of a transaction when
 // required.
+//
+// Inserting a comment is idempotent so that replayed cache writes do not fail
+// on a duplicate primary key.  An identical existing comment is treated as a
+// success and an existing comment with different content is updated.  A new
+// comment version is stored whenever the comment is inserted or updated.
 func (d *fonero) newComment(db *gorm.DB, c Comment) error {
-	return db.Create(&c).Error
+	var existing Comment
+	err := db.
+		Where("key = ?", c.Key).
+		Find(&existing).
+		Error
+	switch {
+	case err == gorm.ErrRecordNotFound:
+		err = db.Create(&c).Error
+		if err != nil {
+			return err
+		}
+	case err != nil:
+		return fmt.Errorf("comment lookup failed: %v", err)
+	case !commentNeedsUpdate(existing, c):
+		// The comment already exists. This can happen when a
+		// cache write is replayed.
+		log.Debugf("newComment: comment %v already exists", c.Key)
+		return nil
+	default:
+		err = db.Save(&c).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return d.newCommentVersion(db, convertCommentVersionFromComment(c))
+}
+
+// newCommentVersion inserts a CommentVersion record into the database.  The
+// version number is set to the next version of the comment.  This function has
+// a database parameter so that it can be called inside of a transaction when
+// required.
+func (d *fonero) newCommentVersion(db *gorm.DB, cv CommentVersion) error {
+	var count uint32
+	err := db.
+		Model(&CommentVersion{}).
+		Where("token = ? AND comment_id = ?", cv.Token, cv.CommentID).
+		Count(&count).
+		Error
+	if err != nil {
+		return fmt.Errorf("comment version count failed: %v", err)
+	}
+
+	cv.Version = count + 1
+	return db.Create(&cv).Error
+}
+
+// commentNeedsUpdate returns whether an existing comment must be overwritten
+// with the content of a replayed comment.  A censored comment is never
+// overwritten since that would restore the censored comment message.
+func commentNeedsUpdate(existing, c Comment) bool {
+	if existing.Censored {
+		return false
+	}
+	return existing != c
 }
 
 // cmdNewComment creates a Comment record using the passed in payloads and
-// inserts it into the database.
+// inserts it into the database.  The new comment handler is notified once the
+// comment has been committed.
 func (d *fonero) cmdNewComment(cmdPayload, replyPayload string) (string, error) {
 	log.Tracef("fonero cmdNewComment")
 
@@ -64,10 +683,24 @@ func (d *fonero) cmdNewComment(cmdPayload, replyPayload string) (string, error)
 		return "", err
 	}
 
+	// The comment and its comment version are inserted in
+	// the same transaction.
 	c := convertNewCommentFromFonero(*nc, *ncr)
-	err = d.newComment(d.recordsdb, c)
+	tx := d.recordsdb.Begin()
+	err = d.newComment(tx, c)
+	if err != nil {
+		tx.Rollback()
+		return "", err
+	}
 
-	return replyPayload, err
+	err = tx.Commit().Error
+	if err != nil {
+		return "", fmt.Errorf("commit transaction: %v", err)
+	}
+
+	d.notifyNewComment(c)
+
+	return replyPayload, nil
 }
 
 // newLikeComment inserts a LikeComment record into the database.  This
@@ -77,8 +710,122 @@ func (d *fonero) newLikeComment(db *gorm.DB, lc LikeComment) error {
 	return db.Create(&lc).Error
 }
 
+// likeCommentStateKey returns the primary key of the LikeCommentState of the
+// passed in public key on a comment.
+func likeCommentStateKey(token, commentID, publicKey string) string {
+	return token + commentID + publicKey
+}
+
+// likeCommentStates returns the current like state of every public key on
+// every comment that results from applying the passed in like history in
+// order.  Likes that were undone are not included.  The states are sorted by
+// key.
+func likeCommentStates(likes []foneroplugin.LikeComment) []LikeCommentState {
+	states := make(map[string]LikeCommentState, len(likes)) // [key]LikeCommentState
+	for _, v := range likes {
+		key := likeCommentStateKey(v.Token, v.CommentID, v.PublicKey)
+		action := foneroplugin.LikeCommentAction(states[key].Action,
+			v.Action)
+		if action == "" {
+			delete(states, key)
+			continue
+		}
+		states[key] = LikeCommentState{
+			Key:       key,
+			Token:     v.Token,
+			CommentID: v.CommentID,
+			PublicKey: v.PublicKey,
+			Action:    action,
+		}
+	}
+
+	s := make([]LikeCommentState, 0, len(states))
+	for _, v := range states {
+		s = append(s, v)
+	}
+	sort.Slice(s, func(i, j int) bool {
+		return s[i].Key < s[j].Key
+	})
+
+	return s
+}
+
+// updateLikeCommentState applies the passed in like to the current like state
+// of its public key on the comment.  The state is removed when the like is
+// undone and replaced when the like is flipped.
+//
+// This function must be called within a transaction.
+func updateLikeCommentState(tx *gorm.DB, lc LikeComment) error {
+	s := LikeCommentState{
+		Key: likeCommentStateKey(lc.Token, lc.CommentID, lc.PublicKey),
+	}
+	var prev string
+	err := tx.Find(&s).Error
+	switch err {
+	case nil:
+		prev = s.Action
+	case gorm.ErrRecordNotFound:
+	default:
+		return fmt.Errorf("lookup like state: %v", err)
+	}
+
+	action := foneroplugin.LikeCommentAction(prev, lc.Action)
+	switch {
+	case action == prev:
+		// Nothing to do
+	case action == "":
+		err = tx.Delete(&s).Error
+		if err != nil {
+			return fmt.Errorf("delete like state: %v", err)
+		}
+	case prev == "":
+		err = tx.Create(&LikeCommentState{
+			Key:       s.Key,
+			Token:     lc.Token,
+			CommentID: lc.CommentID,
+			PublicKey: lc.PublicKey,
+			Action:    action,
+		}).Error
+		if err != nil {
+			return fmt.Errorf("create like state: %v", err)
+		}
+	default:
+		err = tx.Model(&s).Update("action", action).Error
+		if err != nil {
+			return fmt.Errorf("update like state: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// likeComment inserts the passed in like into the like history and applies it
+// to the current like state of its public key on the comment within a single
+// transaction.
+func (d *fonero) likeComment(lc LikeComment) error {
+	tx := d.recordsdb.Begin()
+	err := d.newLikeComment(tx, lc)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("new like comment: %v", err)
+	}
+	err = updateLikeCommentState(tx, lc)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = tx.Commit().Error
+	if err != nil {
+		return fmt.Errorf("commit transaction: %v", err)
+	}
+
+	return nil
+}
+
 // cmdLikeComment creates a LikeComment record using the passed in payloads
-// and inserts it into the database.
+// and inserts it into the database.  The current like state of the user on the
+// comment is updated accordingly.
 func (d *fonero) cmdLikeComment(cmdPayload, replyPayload string) (string, error) {
 	log.Tracef("fonero cmdLikeComment")
 
@@ -87,14 +834,92 @@ func (d *fonero) cmdLikeComment(cmdPayload, replyPayload string) (string, error)
 		return "", err
 	}
 
+	lcr, err := foneroplugin.DecodeLikeCommentReply([]byte(replyPayload))
+	if err != nil {
+		return "", err
+	}
+
+	// Likes that are received by the cache do not carry the
+	// timestamp that politeiad records on disk.
 	lc := convertLikeCommentFromFonero(*dlc)
-	err = d.newLikeComment(d.recordsdb, lc)
+	lc.Receipt = lcr.Receipt
+	if lc.Timestamp == 0 {
+		lc.Timestamp = time.Now().Unix()
+	}
+	err = d.likeComment(lc)
+
+	return replyPayload, err
+}
+
+// cmdLikeCommentUndo records the undo of the current like of a user on a
+// comment in the like history and removes the current like state.  The
+// receipt and timestamp of the undo are taken from the politeiad reply so that
+// they match the undo that politeiad recorded on disk.
+func (d *fonero) cmdLikeCommentUndo(cmdPayload, replyPayload string) (string, error) {
+	log.Tracef("fonero cmdLikeCommentUndo")
+
+	lcu, err := foneroplugin.DecodeLikeCommentUndo([]byte(cmdPayload))
+	if err != nil {
+		return "", err
+	}
+	lcur, err := foneroplugin.DecodeLikeCommentUndoReply([]byte(replyPayload))
+	if err != nil {
+		return "", err
+	}
+
+	ts := lcur.Timestamp
+	if ts == 0 {
+		ts = time.Now().Unix()
+	}
+	err = d.likeComment(LikeComment{
+		Token:     lcu.Token,
+		CommentID: lcu.CommentID,
+		Action:    foneroplugin.LikeActionUndo,
+		Signature: lcu.Signature,
+		PublicKey: lcu.PublicKey,
+		Receipt:   lcur.Receipt,
+		Timestamp: ts,
+	})
 
 	return replyPayload, err
 }
 
+// auditCensoredComment copies the body of the comment that is being censored
+// to the censored comments table.  Comments that have already been censored
+// no longer have a body and are not audited again.
+//
+// This function must be called within a transaction.
+func auditCensoredComment(tx *gorm.DB, cc foneroplugin.CensorComment, ts int64) error {
+	var c Comment
+	err := tx.Where("key = ?", cc.Token+cc.CommentID).
+		Find(&c).
+		Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = cache.ErrRecordNotFound
+		}
+		return err
+	}
+	if c.Censored {
+		return nil
+	}
+
+	return tx.Create(&CensoredComment{
+		Key:       c.Key,
+		Token:     cc.Token,
+		CommentID: cc.CommentID,
+		Comment:   c.Comment,
+		PublicKey: cc.PublicKey,
+		Reason:    cc.Reason,
+		Timestamp: ts,
+	}).Error
+}
+
 // cmdCensorComment censors an existing comment.  A censored comment has its
-// comment message removed and is marked as censored.
+// comment message removed and is marked as censored.  The comment message is
+// moved to the censored comments table when the plugin is configured to audit
+// censored comment bodies.  The censor event is stored as a new version of
+// the comment.
 func (d *fonero) cmdCensorComment(cmdPayload, replyPayload string) (string, error) {
 	log.Tracef("fonero cmdCensorComment")
 
@@ -103,267 +928,3443 @@ func (d *fonero) cmdCensorComment(cmdPayload, replyPayload string) (string, erro
 		return "", err
 	}
 
-	c := Comment{
-		Key: cc.Token + cc.CommentID,
+	// Record when the comment was censored so that incremental
+	// comment syncs pick up the censorship.
+	ts := cc.Timestamp
+	if ts == 0 {
+		ts = time.Now().Unix()
+	}
+
+	tx := d.recordsdb.Begin()
+	if d.censorMode == censorModeAudit {
+		err = auditCensoredComment(tx, *cc, ts)
+		if err != nil {
+			tx.Rollback()
+			return "", err
+		}
+	}
+
+	c := Comment{
+		Key: cc.Token + cc.CommentID,
+	}
+	err = tx.Model(&c).
+		Updates(map[string]interface{}{
+			"comment":            "",
+			"censored":           true,
+			"censored_timestamp": ts,
+		}).Error
+	if err != nil {
+		tx.Rollback()
+		return "", err
+	}
+
+	err = d.newCommentVersion(tx,
+		convertCommentVersionFromCensorComment(*cc, ts))
+	if err != nil {
+		tx.Rollback()
+		return "", err
+	}
+
+	err = tx.Commit().Error
+	if err != nil {
+		return "", fmt.Errorf("commit transaction: %v", err)
+	}
+
+	return replyPayload, nil
+}
+
+// cmdSetCommentVisibility hides or unhides a comment.  The comment body is
+// left untouched so that the comment can be unhidden again.
+func (d *fonero) cmdSetCommentVisibility(cmdPayload, replyPayload string) (string, error) {
+	log.Tracef("fonero cmdSetCommentVisibility")
+
+	scv, err := foneroplugin.DecodeSetCommentVisibility([]byte(cmdPayload))
+	if err != nil {
+		return "", err
+	}
+
+	c := Comment{
+		Key: scv.Token + scv.CommentID,
+	}
+	err = d.recordsdb.Model(&c).
+		Update("hidden", scv.Hidden).
+		Error
+	if err != nil {
+		return "", err
+	}
+
+	return replyPayload, nil
+}
+
+// validateReparent ensures that the comment with the passed in comment ID can
+// be moved underneath the passed in parent comment.  The comments map must
+// contain all of the comments of the record, keyed by comment ID.  The new
+// parent must exist and must not be the comment itself or one of its
+// descendants since that would create a cycle in the comment tree.
+func validateReparent(comments map[string]Comment, commentID, parentID string) error {
+	if _, ok := comments[commentID]; !ok {
+		return cache.ErrRecordNotFound
+	}
+
+	// Moving a comment to the top level is always allowed
+	if parentID == "0" {
+		return nil
+	}
+
+	if _, ok := comments[parentID]; !ok {
+		return fmt.Errorf("parent comment %v not found", parentID)
+	}
+
+	// Walk up the comment tree from the new parent. If the
+	// comment being moved is found, the new parent is one
+	// of its descendants.
+	seen := make(map[string]bool)
+	for id := parentID; id != "0"; {
+		if id == commentID {
+			return errCommentCycle
+		}
+		if seen[id] {
+			return fmt.Errorf("comment tree contains a cycle at %v", id)
+		}
+		seen[id] = true
+
+		c, ok := comments[id]
+		if !ok {
+			return fmt.Errorf("comment %v not found", id)
+		}
+		id = c.ParentID
+	}
+
+	return nil
+}
+
+// cmdReparentComment moves an existing comment underneath a new parent
+// comment.
+func (d *fonero) cmdReparentComment(cmdPayload string) (string, error) {
+	log.Tracef("fonero cmdReparentComment")
+
+	rc, err := foneroplugin.DecodeReparentComment([]byte(cmdPayload))
+	if err != nil {
+		return "", err
+	}
+
+	tx := d.recordsdb.Begin()
+
+	// Lookup all comments of the record so that the comment
+	// tree can be checked for cycles.
+	cs := make([]Comment, 0, 1024) // PNOOMA
+	err = tx.
+		Where("token = ?", rc.Token).
+		Find(&cs).
+		Error
+	if err != nil {
+		tx.Rollback()
+		return "", err
+	}
+
+	comments := make(map[string]Comment, len(cs))
+	for _, v := range cs {
+		comments[v.CommentID] = v
+	}
+
+	err = validateReparent(comments, rc.CommentID, rc.ParentID)
+	if err != nil {
+		tx.Rollback()
+		return "", err
+	}
+
+	// Update the parent of the comment
+	c := comments[rc.CommentID]
+	c.ParentID = rc.ParentID
+	err = tx.Model(&c).
+		Update("parent_id", rc.ParentID).
+		Error
+	if err != nil {
+		tx.Rollback()
+		return "", err
+	}
+
+	err = tx.Commit().Error
+	if err != nil {
+		return "", fmt.Errorf("commit transaction: %v", err)
+	}
+
+	reply, err := foneroplugin.EncodeReparentCommentReply(
+		foneroplugin.ReparentCommentReply{
+			Comment: convertCommentToFonero(c),
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(reply), nil
+}
+
+// cmdGetComment retreives the passed in comment from the database.
+func (d *fonero) cmdGetComment(payload string) (string, error) {
+	log.Tracef("fonero cmdGetComment")
+
+	gc, err := foneroplugin.DecodeGetComment([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	c := Comment{
+		Key: gc.Token + gc.CommentID,
+	}
+	err = d.recordsdb.Find(&c).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = cache.ErrRecordNotFound
+		}
+		return "", err
+	}
+
+	gcr := foneroplugin.GetCommentReply{
+		Comment: convertCommentToFonero(c),
+	}
+	gcrb, err := foneroplugin.EncodeGetCommentReply(gcr)
+	if err != nil {
+		return "", err
+	}
+
+	return string(gcrb), nil
+}
+
+// cmdGetCommentByReceipt returns the comment that has the passed in server
+// receipt.  The receipt column is indexed so the lookup does not require a
+// scan of the comments table.  Hidden comments are not returned.
+func (d *fonero) cmdGetCommentByReceipt(payload string) (string, error) {
+	log.Tracef("fonero cmdGetCommentByReceipt")
+
+	gcbr, err := foneroplugin.DecodeGetCommentByReceipt([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	var c Comment
+	err = d.recordsdb.
+		Where("receipt = ? AND hidden = ?", gcbr.Receipt, false).
+		First(&c).
+		Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = cache.ErrRecordNotFound
+		}
+		return "", err
+	}
+
+	gcbrr := foneroplugin.GetCommentByReceiptReply{
+		Comment: convertCommentToFonero(c),
+	}
+	gcbrrb, err := foneroplugin.EncodeGetCommentByReceiptReply(gcbrr)
+	if err != nil {
+		return "", err
+	}
+
+	return string(gcbrrb), nil
+}
+
+// commentAncestors returns the ancestors of the passed in comment ordered from
+// its parent up to the root comment.  The lookup function is used to retrieve
+// each ancestor by comment ID.  The chain stops when the root comment is
+// reached, when maxDepth ancestors have been found, when an ancestor cannot be
+// found, or when the comment tree contains a cycle.
+func commentAncestors(c Comment, maxDepth int, lookup func(commentID string) (*Comment, error)) ([]Comment, error) {
+	ancestors := make([]Comment, 0, 16)
+	seen := map[string]bool{
+		c.CommentID: true,
+	}
+	for id := c.ParentID; id != "0" && len(ancestors) < maxDepth; {
+		if seen[id] {
+			log.Debugf("commentAncestors: comment tree contains a "+
+				"cycle at %v %v", c.Token, id)
+			break
+		}
+		seen[id] = true
+
+		a, err := lookup(id)
+		if err == cache.ErrRecordNotFound {
+			log.Debugf("commentAncestors: ancestor not found %v %v",
+				c.Token, id)
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		ancestors = append(ancestors, *a)
+		id = a.ParentID
+	}
+
+	return ancestors, nil
+}
+
+// cmdGetCommentAncestors retrieves the passed in comment and its ancestors
+// from the database.  Hidden comments are not returned, so the ancestor chain
+// stops at the first hidden ancestor.
+func (d *fonero) cmdGetCommentAncestors(payload string) (string, error) {
+	log.Tracef("fonero cmdGetCommentAncestors")
+
+	gca, err := foneroplugin.DecodeGetCommentAncestors([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	maxDepth := int(gca.MaxDepth)
+	if maxDepth == 0 || maxDepth > foneroplugin.CommentAncestorsMaxDepth {
+		maxDepth = foneroplugin.CommentAncestorsMaxDepth
+	}
+
+	// lookup retrieves a visible comment of the record from the
+	// database.
+	lookup := func(commentID string) (*Comment, error) {
+		c := Comment{
+			Key: gca.Token + commentID,
+		}
+		err := d.recordsdb.
+			Where("hidden = ?", false).
+			Find(&c).
+			Error
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				err = cache.ErrRecordNotFound
+			}
+			return nil, err
+		}
+		return &c, nil
+	}
+
+	c, err := lookup(gca.CommentID)
+	if err != nil {
+		return "", err
+	}
+	ancestors, err := commentAncestors(*c, maxDepth, lookup)
+	if err != nil {
+		return "", err
+	}
+
+	fa := make([]foneroplugin.Comment, 0, len(ancestors))
+	for _, v := range ancestors {
+		fa = append(fa, convertCommentToFonero(v))
+	}
+
+	gcar := foneroplugin.GetCommentAncestorsReply{
+		Comment:   convertCommentToFonero(*c),
+		Ancestors: fa,
+	}
+	gcarb, err := foneroplugin.EncodeGetCommentAncestorsReply(gcar)
+	if err != nil {
+		return "", err
+	}
+
+	return string(gcarb), nil
+}
+
+// commentThreadStats returns the number of uncensored and censored
+// descendants of each root comment in the passed in comments.  The threads
+// are returned in the order of the root comments.  Comments whose ancestor
+// chain is broken or contains a cycle are not counted.
+func commentThreadStats(comments []Comment) []foneroplugin.CommentThread {
+	parents := make(map[string]string, len(comments)) // [commentID]parentID
+	for _, v := range comments {
+		parents[v.CommentID] = v.ParentID
+	}
+
+	// root returns the root comment ID of the passed in comment
+	// or an empty string if the root could not be found.
+	roots := make(map[string]string, len(comments)) // [commentID]rootID
+	root := func(commentID string) string {
+		seen := make(map[string]bool)
+		id := commentID
+		for {
+			if r, ok := roots[id]; ok {
+				return r
+			}
+			parentID, ok := parents[id]
+			if !ok || seen[id] {
+				return ""
+			}
+			if parentID == "0" {
+				return id
+			}
+			seen[id] = true
+			id = parentID
+		}
+	}
+
+	threads := make([]foneroplugin.CommentThread, 0, len(comments))
+	index := make(map[string]int, len(comments)) // [rootID]threads index
+	for _, v := range comments {
+		if v.ParentID == "0" {
+			index[v.CommentID] = len(threads)
+			threads = append(threads, foneroplugin.CommentThread{
+				CommentID: v.CommentID,
+			})
+		}
+	}
+	for _, v := range comments {
+		if v.ParentID == "0" {
+			continue
+		}
+		r := root(v.CommentID)
+		roots[v.CommentID] = r
+		i, ok := index[r]
+		if !ok {
+			log.Debugf("commentThreadStats: root not found %v %v",
+				v.Token, v.CommentID)
+			continue
+		}
+		if v.Censored {
+			threads[i].Censored++
+		} else {
+			threads[i].Replies++
+		}
+	}
+
+	return threads
+}
+
+// cmdGetCommentVersions retrieves all stored versions of the passed in comment
+// from the database, ordered from oldest to newest.
+func (d *fonero) cmdGetCommentVersions(payload string) (string, error) {
+	log.Tracef("fonero cmdGetCommentVersions")
+
+	gcv, err := foneroplugin.DecodeGetCommentVersions([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	cvs := make([]CommentVersion, 0, 16) // PNOOMA
+	err = d.recordsdb.
+		Where("token = ? AND comment_id = ?", gcv.Token, gcv.CommentID).
+		Order("version asc").
+		Find(&cvs).
+		Error
+	if err != nil {
+		return "", err
+	}
+	if len(cvs) == 0 {
+		return "", cache.ErrRecordNotFound
+	}
+
+	versions := make([]foneroplugin.CommentVersion, 0, len(cvs))
+	for _, v := range cvs {
+		versions = append(versions, convertCommentVersionToFonero(v))
+	}
+
+	reply, err := foneroplugin.EncodeGetCommentVersionsReply(
+		foneroplugin.GetCommentVersionsReply{
+			Versions: versions,
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(reply), nil
+}
+
+// cmdCommentThreadStats returns the number of replies to each root comment of
+// a proposal.
+func (d *fonero) cmdCommentThreadStats(payload string) (string, error) {
+	log.Tracef("fonero cmdCommentThreadStats")
+
+	cts, err := foneroplugin.DecodeCommentThreadStats([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	comments := make([]Comment, 0, 1024) // PNOOMA
+	err = d.recordsdb.
+		Select("token, comment_id, parent_id, censored").
+		Where("token = ?", cts.Token).
+		Order("timestamp asc").
+		Find(&comments).
+		Error
+	if err != nil {
+		return "", fmt.Errorf("lookup comments: %v", err)
+	}
+
+	ctsr := foneroplugin.CommentThreadStatsReply{
+		Threads: commentThreadStats(comments),
+	}
+	ctsrb, err := foneroplugin.EncodeCommentThreadStatsReply(ctsr)
+	if err != nil {
+		return "", err
+	}
+
+	return string(ctsrb), nil
+}
+
+// cmdGetComments returns all of the comments for the passed in record token.
+func (d *fonero) cmdGetComments(payload string) (string, error) {
+	log.Tracef("fonero cmdGetComments")
+
+	gc, err := foneroplugin.DecodeGetComments([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	// Lookup the requested page of comments. The comments are
+	// ordered so that pages are deterministic.
+	comments := make([]Comment, 0, 1024) // PNOOMA
+	q := d.recordsdb.
+		Where("token = ?", gc.Token).
+		Order("timestamp asc").
+		Order("comment_id asc")
+	if !gc.IncludeHidden {
+		q = q.Where("hidden = ?", false)
+	}
+	if gc.Offset > 0 {
+		q = q.Offset(gc.Offset)
+	}
+	if gc.Limit > 0 {
+		q = q.Limit(gc.Limit)
+	}
+	err = q.Find(&comments).Error
+	if err != nil {
+		return "", err
+	}
+
+	dpc := make([]foneroplugin.Comment, 0, len(comments))
+	for _, c := range comments {
+		dpc = append(dpc, convertCommentToFonero(c))
+	}
+
+	gcr := foneroplugin.GetCommentsReply{
+		Comments: dpc,
+	}
+	gcrb, err := foneroplugin.EncodeGetCommentsReply(gcr)
+	if err != nil {
+		return "", err
+	}
+
+	return string(gcrb), nil
+}
+
+// cmdGetCommentsForProposals returns the most recent comments of each of the
+// passed in record tokens, grouped by token.  The comments of all of the
+// records are looked up using a single query and the per record limit is
+// applied as the comments are grouped.
+func (d *fonero) cmdGetCommentsForProposals(payload string) (string, error) {
+	log.Tracef("fonero cmdGetCommentsForProposals")
+
+	gcfp, err := foneroplugin.DecodeGetCommentsForProposals([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	// Every requested token is included in the reply
+	grouped := make(map[string][]foneroplugin.Comment, len(gcfp.Tokens))
+	for _, v := range gcfp.Tokens {
+		grouped[v] = []foneroplugin.Comment{}
+	}
+
+	if len(gcfp.Tokens) > 0 {
+		comments := make([]Comment, 0, 1024) // PNOOMA
+		q := d.recordsdb.
+			Where("token IN (?)", gcfp.Tokens).
+			Where("hidden = ?", false)
+		if gcfp.ExcludeCensored {
+			q = q.Where("censored = ?", false)
+		}
+		err = q.Order("token asc").
+			Order("timestamp desc").
+			Order("comment_id desc").
+			Find(&comments).
+			Error
+		if err != nil {
+			return "", err
+		}
+
+		for _, v := range comments {
+			if gcfp.Limit > 0 && len(grouped[v.Token]) >= int(gcfp.Limit) {
+				continue
+			}
+			grouped[v.Token] = append(grouped[v.Token],
+				convertCommentToFonero(v))
+		}
+	}
+
+	reply, err := foneroplugin.EncodeGetCommentsForProposalsReply(
+		foneroplugin.GetCommentsForProposalsReply{
+			Comments: grouped,
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(reply), nil
+}
+
+// cmdCommentedProposals returns the tokens of the records that the passed in
+// public key has commented on along with the number of comments that the key
+// made on each record.  The comments are counted using a single aggregate
+// query.
+func (d *fonero) cmdCommentedProposals(payload string) (string, error) {
+	log.Tracef("fonero cmdCommentedProposals")
+
+	cp, err := foneroplugin.DecodeCommentedProposals([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	q := `SELECT token, COUNT(*)
+        FROM comments
+        WHERE public_key = ?
+        GROUP BY token
+        ORDER BY token ASC`
+	defer d.timeQuery("commented proposals")()
+	rows, err := d.recordsdb.Raw(q, cp.PublicKey).Rows()
+	if err != nil {
+		return "", fmt.Errorf("commented proposals: %v", err)
+	}
+	defer rows.Close()
+
+	proposals := make([]foneroplugin.CommentedProposal, 0, 16)
+	for rows.Next() {
+		var p foneroplugin.CommentedProposal
+		err := rows.Scan(&p.Token, &p.Comments)
+		if err != nil {
+			return "", err
+		}
+		proposals = append(proposals, p)
+	}
+	if err = rows.Err(); err != nil {
+		return "", err
+	}
+
+	reply, err := foneroplugin.EncodeCommentedProposalsReply(
+		foneroplugin.CommentedProposalsReply{
+			Proposals: proposals,
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(reply), nil
+}
+
+// commentsSinceQuery returns the where clause and arguments that select the
+// visible comments of a record that were created or censored after the passed
+// in timestamp.  When lastSeenKey is provided the timestamp is the creation
+// timestamp of the last seen comment, so comments that were created in the
+// same second are included, excluding the last seen comment itself.
+func commentsSinceQuery(token string, since int64, lastSeenKey string) (string, []interface{}) {
+	if lastSeenKey == "" {
+		return `token = ? AND hidden = false AND ` +
+				`(timestamp > ? OR censored_timestamp > ?)`,
+			[]interface{}{token, since, since}
+	}
+	return `token = ? AND hidden = false AND ` +
+			`((timestamp >= ? AND key != ?) OR censored_timestamp >= ?)`,
+		[]interface{}{token, since, lastSeenKey, since}
+}
+
+// cmdGetCommentsSince returns the comments of a record that were created or
+// censored after the passed in timestamp or last seen comment, ordered by
+// creation timestamp in ascending order.  Hidden comments are not returned.
+func (d *fonero) cmdGetCommentsSince(payload string) (string, error) {
+	log.Tracef("fonero cmdGetCommentsSince")
+
+	gcs, err := foneroplugin.DecodeGetCommentsSince([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	// Lookup the timestamp of the last seen comment
+	since := gcs.Timestamp
+	var lastSeenKey string
+	if gcs.CommentID != "" {
+		c := Comment{
+			Key: gcs.Token + gcs.CommentID,
+		}
+		err = d.recordsdb.Find(&c).Error
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				err = cache.ErrRecordNotFound
+			}
+			return "", err
+		}
+		since = c.Timestamp
+		lastSeenKey = c.Key
+	}
+
+	where, args := commentsSinceQuery(gcs.Token, since, lastSeenKey)
+	comments := make([]Comment, 0, 1024) // PNOOMA
+	err = d.recordsdb.
+		Where(where, args...).
+		Order("timestamp asc, comment_id asc").
+		Find(&comments).
+		Error
+	if err != nil {
+		return "", err
+	}
+
+	fc := make([]foneroplugin.Comment, 0, len(comments))
+	for _, c := range comments {
+		fc = append(fc, convertCommentToFonero(c))
+	}
+
+	gcsr := foneroplugin.GetCommentsSinceReply{
+		Comments: fc,
+	}
+	gcsrb, err := foneroplugin.EncodeGetCommentsSinceReply(gcsr)
+	if err != nil {
+		return "", err
+	}
+
+	return string(gcsrb), nil
+}
+
+// cmdCommentsModifiedSince returns the IDs of the comments of a record that
+// were created, edited or censored after the passed in timestamp and the
+// comment likes of the record that were added after the timestamp.  Every
+// comment change is stored as a comment version, so the modified comments are
+// looked up using the comment versions.  The comments table is consulted as
+// well so that comments without versions are not missed.  Hidden comments are
+// not returned.
+func (d *fonero) cmdCommentsModifiedSince(payload string) (string, error) {
+	log.Tracef("fonero cmdCommentsModifiedSince")
+
+	cms, err := foneroplugin.DecodeCommentsModifiedSince([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	q := `SELECT comment_id FROM (
+          SELECT comment_id FROM comment_versions
+          WHERE token = ? AND timestamp > ?
+          UNION
+          SELECT comment_id FROM comments
+          WHERE token = ? AND (timestamp > ? OR censored_timestamp > ?)
+        ) AS modified
+        WHERE comment_id NOT IN (
+          SELECT comment_id FROM comments
+          WHERE token = ? AND hidden = true
+        )
+        ORDER BY CAST(comment_id AS INT) ASC`
+	ids, err := d.queryStrings("comments modified since", q, cms.Token,
+		cms.Timestamp, cms.Token, cms.Timestamp, cms.Timestamp, cms.Token)
+	if err != nil {
+		return "", fmt.Errorf("modified comments: %v", err)
+	}
+
+	likes := make([]LikeComment, 0, 1024) // PNOOMA
+	err = d.recordsdb.
+		Where("token = ? AND timestamp > ?", cms.Token, cms.Timestamp).
+		Order("key asc").
+		Find(&likes).
+		Error
+	if err != nil {
+		return "", err
+	}
+
+	lc := make([]foneroplugin.LikeComment, 0, len(likes))
+	for _, v := range likes {
+		lc = append(lc, convertLikeCommentToFonero(v))
+	}
+
+	reply, err := foneroplugin.EncodeCommentsModifiedSinceReply(
+		foneroplugin.CommentsModifiedSinceReply{
+			CommentIDs: ids,
+			Likes:      lc,
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(reply), nil
+}
+
+// cmdCensoredComments returns all of the censored comments in the cache. If a
+// token is provided, only the censored comments for that record are returned.
+func (d *fonero) cmdCensoredComments(payload string) (string, error) {
+	log.Tracef("fonero cmdCensoredComments")
+
+	cc, err := foneroplugin.DecodeCensoredComments([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	q := d.recordsdb.Where("censored = ?", true)
+	if cc.Token != "" {
+		q = q.Where("token = ?", cc.Token)
+	}
+
+	comments := make([]Comment, 0, 1024) // PNOOMA
+	err = q.Order("timestamp asc").
+		Find(&comments).
+		Error
+	if err != nil {
+		return "", err
+	}
+
+	dc := make([]foneroplugin.Comment, 0, len(comments))
+	for _, c := range comments {
+		dc = append(dc, convertCommentToFonero(c))
+	}
+
+	ccr := foneroplugin.CensoredCommentsReply{
+		Comments: dc,
+	}
+	ccrb, err := foneroplugin.EncodeCensoredCommentsReply(ccr)
+	if err != nil {
+		return "", err
+	}
+
+	return string(ccrb), nil
+}
+
+// cmdCommentLikes returns all of the comment likes for the passed in comment.
+func (d *fonero) cmdCommentLikes(payload string) (string, error) {
+	log.Tracef("fonero cmdCommentLikes")
+
+	cl, err := foneroplugin.DecodeCommentLikes([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	likes := make([]LikeComment, 1024) // PNOOMA
+	err = d.recordsdb.
+		Where("token = ? AND comment_id = ?", cl.Token, cl.CommentID).
+		Find(&likes).
+		Error
+	if err != nil {
+		return "", err
+	}
+
+	lc := make([]foneroplugin.LikeComment, 0, len(likes))
+	for _, v := range likes {
+		lc = append(lc, convertLikeCommentToFonero(v))
+	}
+
+	// Lookup the current like of each public key
+	states := make([]LikeCommentState, 0, len(likes))
+	err = d.recordsdb.
+		Where("token = ? AND comment_id = ?", cl.Token, cl.CommentID).
+		Order("key").
+		Find(&states).
+		Error
+	if err != nil {
+		return "", err
+	}
+
+	current := make([]foneroplugin.LikeComment, 0, len(states))
+	for _, v := range states {
+		current = append(current, convertLikeCommentStateToFonero(v))
+	}
+
+	clr := foneroplugin.CommentLikesReply{
+		CommentLikes: lc,
+		Current:      current,
+	}
+	clrb, err := foneroplugin.EncodeCommentLikesReply(clr)
+	if err != nil {
+		return "", err
+	}
+
+	return string(clrb), nil
+}
+
+// cmdProposalLikes returns all of the comment likes for all comments of the
+// passed in record token.
+func (d *fonero) cmdProposalCommentsLikes(payload string) (string, error) {
+	log.Tracef("fonero cmdProposalCommentsLikes")
+
+	cl, err := foneroplugin.DecodeGetProposalCommentsLikes([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	likes := make([]LikeComment, 0, 1024) // PNOOMA
+	err = d.recordsdb.
+		Where("token = ?", cl.Token).
+		Find(&likes).
+		Error
+	if err != nil {
+		return "", err
+	}
+
+	lc := make([]foneroplugin.LikeComment, 0, len(likes))
+	for _, v := range likes {
+		lc = append(lc, convertLikeCommentToFonero(v))
+	}
+
+	clr := foneroplugin.GetProposalCommentsLikesReply{
+		CommentsLikes: lc,
+	}
+	clrb, err := foneroplugin.EncodeGetProposalCommentsLikesReply(clr)
+	if err != nil {
+		return "", err
+	}
+
+	return string(clrb), nil
+}
+
+// cmdProposalCommentsLikeCounts returns the number of users that currently
+// upvote and downvote each comment of the passed in record token.  The counts
+// are calculated from the current like of each user so that undone and
+// replaced likes are not counted.
+func (d *fonero) cmdProposalCommentsLikeCounts(payload string) (string, error) {
+	log.Tracef("fonero cmdProposalCommentsLikeCounts")
+
+	g, err := foneroplugin.DecodeGetProposalCommentsLikeCounts([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	q := `SELECT comment_id,
+          SUM(CASE WHEN action = '1' THEN 1 ELSE 0 END),
+          SUM(CASE WHEN action = '-1' THEN 1 ELSE 0 END)
+        FROM comment_like_states
+        WHERE token = ?
+        GROUP BY comment_id
+        ORDER BY comment_id`
+	defer d.timeQuery("comment like counts")()
+	rows, err := d.recordsdb.Raw(q, g.Token).Rows()
+	if err != nil {
+		return "", fmt.Errorf("comment like counts: %v", err)
+	}
+	defer rows.Close()
+
+	var (
+		commentID string
+		up, down  uint64
+	)
+	counts := make([]foneroplugin.CommentLikeCounts, 0, 1024) // PNOOMA
+	for rows.Next() {
+		err := rows.Scan(&commentID, &up, &down)
+		if err != nil {
+			return "", err
+		}
+		counts = append(counts, foneroplugin.CommentLikeCounts{
+			CommentID: commentID,
+			Upvotes:   up,
+			Downvotes: down,
+			Score:     int64(up) - int64(down),
+		})
+	}
+	if err = rows.Err(); err != nil {
+		return "", err
+	}
+
+	reply, err := foneroplugin.EncodeGetProposalCommentsLikeCountsReply(
+		foneroplugin.GetProposalCommentsLikeCountsReply{
+			LikeCounts: counts,
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(reply), nil
+}
+
+// cmdCommentLikesNetScore returns the net like score of each of the requested
+// comments of the passed in record token.  The scores are calculated from the
+// current like of each user using a single grouped aggregate query.  Comments
+// without any likes have a score of zero.
+func (d *fonero) cmdCommentLikesNetScore(payload string) (string, error) {
+	log.Tracef("fonero cmdCommentLikesNetScore")
+
+	c, err := foneroplugin.DecodeCommentLikesNetScore([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	// Every requested comment is included in the reply
+	scores := make(map[string]int64, len(c.CommentIDs))
+	for _, v := range c.CommentIDs {
+		scores[v] = 0
+	}
+
+	if len(c.CommentIDs) > 0 {
+		q := `SELECT comment_id,
+            SUM(CASE WHEN action = '1' THEN 1
+                     WHEN action = '-1' THEN -1
+                     ELSE 0 END)
+          FROM comment_like_states
+          WHERE token = ? AND comment_id IN (?)
+          GROUP BY comment_id`
+		defer d.timeQuery("comment likes net score")()
+		rows, err := d.recordsdb.Raw(q, c.Token, c.CommentIDs).Rows()
+		if err != nil {
+			return "", fmt.Errorf("comment likes net score: %v", err)
+		}
+		defer rows.Close()
+
+		var (
+			commentID string
+			score     int64
+		)
+		for rows.Next() {
+			err := rows.Scan(&commentID, &score)
+			if err != nil {
+				return "", err
+			}
+			scores[commentID] = score
+		}
+		if err = rows.Err(); err != nil {
+			return "", err
+		}
+	}
+
+	reply, err := foneroplugin.EncodeCommentLikesNetScoreReply(
+		foneroplugin.CommentLikesNetScoreReply{
+			Scores: scores,
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(reply), nil
+}
+
+// cmdTopComments returns the uncensored and visible comments of a proposal
+// ranked by their net like score.  The score is calculated from the current
+// like of each user so that undone and replaced likes are not counted.
+func (d *fonero) cmdTopComments(payload string) (string, error) {
+	log.Tracef("fonero cmdTopComments")
+
+	tc, err := foneroplugin.DecodeTopComments([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	// This query ranks the uncensored and visible comments of the
+	// proposal by their net score. The timestamp and comment ID are used as
+	// tie breakers so that the ranking is deterministic.
+	q := `SELECT c.key,
+          COUNT(s.key),
+          COALESCE(SUM(CASE WHEN s.action = '1' THEN 1
+            WHEN s.action = '-1' THEN -1 ELSE 0 END), 0) AS score
+        FROM comments c
+        LEFT OUTER JOIN comment_like_states s
+          ON s.token = c.token
+          AND s.comment_id = c.comment_id
+        WHERE c.token = ?
+          AND c.censored = false
+          AND c.hidden = false
+        GROUP BY c.key, c.timestamp, c.comment_id
+        ORDER BY score DESC, c.timestamp ASC, c.comment_id ASC`
+	args := []interface{}{tc.Token}
+	if tc.Limit > 0 {
+		q += " LIMIT ?"
+		args = append(args, tc.Limit)
+	}
+	defer d.timeQuery("top comments")()
+	rows, err := d.recordsdb.Raw(q, args...).Rows()
+	if err != nil {
+		return "", fmt.Errorf("top comments: %v", err)
+	}
+	defer rows.Close()
+
+	var (
+		key   string
+		total uint64
+		score int64
+	)
+	keys := make([]string, 0, 64)
+	totals := make(map[string]uint64) // [key]Total likes
+	scores := make(map[string]int64)  // [key]Score
+	for rows.Next() {
+		err := rows.Scan(&key, &total, &score)
+		if err != nil {
+			return "", err
+		}
+		keys = append(keys, key)
+		totals[key] = total
+		scores[key] = score
+	}
+	if err = rows.Err(); err != nil {
+		return "", err
+	}
+
+	// Lookup the ranked comments
+	comments := make([]Comment, 0, len(keys))
+	if len(keys) > 0 {
+		err = d.recordsdb.
+			Where("key IN (?)", keys).
+			Find(&comments).
+			Error
+		if err != nil {
+			return "", err
+		}
+	}
+	byKey := make(map[string]Comment, len(comments))
+	for _, c := range comments {
+		byKey[c.Key] = c
+	}
+
+	top := make([]foneroplugin.Comment, 0, len(keys))
+	for _, k := range keys {
+		c, ok := byKey[k]
+		if !ok {
+			return "", fmt.Errorf("comment not found %v", k)
+		}
+		fc := convertCommentToFonero(c)
+		fc.TotalVotes = totals[k]
+		fc.ResultVotes = scores[k]
+		top = append(top, fc)
+	}
+
+	reply, err := foneroplugin.EncodeTopCommentsReply(
+		foneroplugin.TopCommentsReply{
+			Comments: top,
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(reply), nil
+}
+
+// newAuthorizeVote creates an AuthorizeVote record and inserts it into the
+// database.  If a previous AuthorizeVote record exists for the passed in
+// proposal and version, it will be deleted before the new AuthorizeVote record
+// is inserted.
+//
+// This function must be called within a transaction.
+func (d *fonero) newAuthorizeVote(tx *gorm.DB, av AuthorizeVote) error {
+	// Delete authorize vote if one exists for this version
+	err := tx.Where("key = ?", av.Key).
+		Delete(AuthorizeVote{}).
+		Error
+	if err != nil {
+		return fmt.Errorf("delete authorize vote: %v", err)
+	}
+
+	// Add new authorize vote
+	err = tx.Create(&av).Error
+	if err != nil {
+		return fmt.Errorf("create authorize vote: %v", err)
+	}
+
+	return nil
+}
+
+// cmdAuthorizeVote creates a AuthorizeVote record using the passed in payloads
+// and inserts it into the database.
+func (d *fonero) cmdAuthorizeVote(cmdPayload, replyPayload string) (string, error) {
+	log.Tracef("fonero cmdAuthorizeVote")
+
+	av, err := foneroplugin.DecodeAuthorizeVote([]byte(cmdPayload))
+	if err != nil {
+		return "", err
+	}
+	avr, err := foneroplugin.DecodeAuthorizeVoteReply([]byte(replyPayload))
+	if err != nil {
+		return "", err
+	}
+
+	v, err := strconv.ParseUint(avr.RecordVersion, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("parse version '%v' failed: %v",
+			avr.RecordVersion, err)
+	}
+
+	// Run update in a transaction
+	a := convertAuthorizeVoteFromFonero(*av, *avr, v)
+	tx := d.recordsdb.Begin()
+	err = d.newAuthorizeVote(tx, a)
+	if err != nil {
+		tx.Rollback()
+		return "", fmt.Errorf("newAuthorizeVote: %v", err)
+	}
+
+	// Commit transaction
+	err = tx.Commit().Error
+	if err != nil {
+		return "", fmt.Errorf("commit transaction: %v", err)
+	}
+
+	return replyPayload, nil
+}
+
+// newStartVote inserts a StartVote record and its vote options into the
+// database.  The vote options are only tied to the start vote by token so any
+// existing start vote and vote options for the token are deleted first to
+// prevent stale vote options from being left behind.  This function has a
+// database parameter so that it can be called inside of a transaction when
+// required.
+func (d *fonero) newStartVote(db *gorm.DB, sv StartVote) error {
+	err := db.Where("token = ?", sv.Token).
+		Delete(VoteOption{}).
+		Error
+	if err != nil {
+		return fmt.Errorf("delete vote options: %v", err)
+	}
+	err = db.Where("token = ?", sv.Token).
+		Delete(EligibleTicket{}).
+		Error
+	if err != nil {
+		return fmt.Errorf("delete eligible tickets: %v", err)
+	}
+	err = db.Where("token = ?", sv.Token).
+		Delete(StartVote{}).
+		Error
+	if err != nil {
+		return fmt.Errorf("delete start vote: %v", err)
+	}
+
+	// The eligible tickets are inserted separately since gorm
+	// inserts associations one row at a time.
+	err = db.Omit("EligibleTickets").Create(&sv).Error
+	if err != nil {
+		return err
+	}
+	err = insertEligibleTickets(db, tableEligibleTickets, sv.EligibleTickets)
+	if err != nil {
+		return fmt.Errorf("insert eligible tickets: %v", err)
+	}
+
+	return nil
+}
+
+// eligibleTicketsBatchSize is the maximum number of eligible tickets that are
+// inserted by a single statement.
+const eligibleTicketsBatchSize = 1000
+
+// insertEligibleTickets inserts the passed in eligible tickets into the passed
+// in table using multi-row inserts of at most eligibleTicketsBatchSize rows.
+// This function has a database parameter so that it can be called inside of
+// a transaction when required.
+func insertEligibleTickets(db *gorm.DB, table string, tickets []EligibleTicket) error {
+	for len(tickets) > 0 {
+		n := len(tickets)
+		if n > eligibleTicketsBatchSize {
+			n = eligibleTicketsBatchSize
+		}
+
+		rows := make([]string, 0, n)
+		args := make([]interface{}, 0, n*4)
+		for _, v := range tickets[:n] {
+			rows = append(rows, "(?, ?, ?, ?)")
+			args = append(args, v.Key, v.Token, v.Ticket, v.Position)
+		}
+		q := `INSERT INTO ` + table + ` (key, token, ticket, position)
+        VALUES ` + strings.Join(rows, ", ")
+		err := db.Exec(q, args...).Error
+		if err != nil {
+			return err
+		}
+
+		tickets = tickets[n:]
+	}
+
+	return nil
+}
+
+// validateVoteOptions ensures that the bits of every vote option are non-zero,
+// fit within the vote mask, and are not shared with any other vote option.
+// Votes cast on a vote whose options fail these checks cannot be tallied.
+func validateVoteOptions(mask uint64, options []foneroplugin.VoteOption) error {
+	var used uint64
+	for _, v := range options {
+		if v.Bits == 0 {
+			return fmt.Errorf("vote option '%v' has no bits set", v.Id)
+		}
+		if v.Bits&mask != v.Bits {
+			return fmt.Errorf("vote option '%v' bits %x do not fit "+
+				"within mask %x", v.Id, v.Bits, mask)
+		}
+		if v.Bits&used != 0 {
+			return fmt.Errorf("vote option '%v' bits %x overlap with "+
+				"another vote option", v.Id, v.Bits)
+		}
+		used |= v.Bits
+	}
+	return nil
+}
+
+// cmdStartVote creates a StartVote record using the passed in payloads and
+// inserts it into the database.
+func (d *fonero) cmdStartVote(cmdPayload, replyPayload string) (string, error) {
+	log.Tracef("fonero cmdStartVote")
+
+	sv, err := foneroplugin.DecodeStartVote([]byte(cmdPayload))
+	if err != nil {
+		return "", err
+	}
+	svr, err := foneroplugin.DecodeStartVoteReply([]byte(replyPayload))
+	if err != nil {
+		return "", err
+	}
+
+	endHeight, err := strconv.ParseUint(svr.EndHeight, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("parse end height '%v': %v",
+			svr.EndHeight, err)
+	}
+
+	err = validateVoteOptions(sv.Vote.Mask, sv.Vote.Options)
+	if err != nil {
+		return "", err
+	}
+
+	// The start vote and its vote options are inserted in the
+	// same transaction so that a failure does not leave behind
+	// a partially inserted start vote.
+	s := convertStartVoteFromFonero(*sv, *svr, endHeight)
+	s.Timestamp = d.now().Unix()
+	tx := d.recordsdb.Begin()
+	err = d.newStartVote(tx, s)
+	if err != nil {
+		tx.Rollback()
+		return "", err
+	}
+
+	err = tx.Commit().Error
+	if err != nil {
+		return "", fmt.Errorf("commit transaction: %v", err)
+	}
+	d.startVotes.invalidate(s.Token)
+
+	return replyPayload, nil
+}
+
+// latestRecordVersion returns the most recent version of the record with the
+// passed in token without its metadata streams and files.  This function has
+// a database parameter so that it can be called inside of a transaction when
+// required.
+func latestRecordVersion(db *gorm.DB, token string) (*Record, error) {
+	var r Record
+	err := db.
+		Where("records.token = ?", token).
+		Order("records.version desc").
+		Limit(1).
+		Find(&r).
+		Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = cache.ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+// startVote returns the start vote of the passed in token along with its vote
+// options.  The eligible tickets are not returned.  The start vote is served
+// from the start vote cache when possible.  cache.ErrRecordNotFound is
+// returned if a start vote does not exist for the token.
+func (d *fonero) startVote(token string) (*StartVote, error) {
+	sv, ok := d.startVotes.get(token)
+	if ok {
+		return sv, nil
+	}
+
+	gen := d.startVotes.generation()
+	var s StartVote
+	err := d.recordsdb.
+		Where("token = ?", token).
+		Preload("Options").
+		Find(&s).
+		Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = cache.ErrRecordNotFound
+		}
+		return nil, err
+	}
+	d.startVotes.put(s, gen)
+
+	return &s, nil
+}
+
+// cmdVoteDetails returns the AuthorizeVote and StartVote records for the
+// passed in record token.
+func (d *fonero) cmdVoteDetails(payload string) (string, error) {
+	log.Tracef("fonero cmdVoteDetails")
+
+	vd, err := foneroplugin.DecodeVoteDetails([]byte(payload))
+	if err != nil {
+		return "", nil
+	}
+
+	// Lookup the most recent version of the record
+	r, err := latestRecordVersion(d.recordsdb, vd.Token)
+	if err != nil {
+		return "", err
+	}
+
+	// Lookup authorize vote
+	var av AuthorizeVote
+	key := vd.Token + strconv.FormatUint(r.Version, 10)
+	err = d.recordsdb.
+		Where("key = ?", key).
+		Find(&av).
+		Error
+	if err == gorm.ErrRecordNotFound {
+		// An authorize vote may note exist. This is ok.
+	} else if err != nil {
+		return "", fmt.Errorf("authorize vote lookup failed: %v", err)
+	}
+
+	// Lookup start vote. The eligible tickets are not cached
+	// with the start vote so they are looked up separately.
+	var sv StartVote
+	s, err := d.startVote(vd.Token)
+	if err == cache.ErrRecordNotFound {
+		// A start vote may note exist. This is ok.
+	} else if err != nil {
+		return "", fmt.Errorf("start vote lookup failed: %v", err)
+	} else {
+		sv = *s
+		err = d.recordsdb.
+			Where("token = ?", vd.Token).
+			Order("position asc").
+			Find(&sv.EligibleTickets).
+			Error
+		if err != nil {
+			return "", fmt.Errorf("eligible tickets lookup failed: %v",
+				err)
+		}
+	}
+
+	// Prepare reply
+	dav := convertAuthorizeVoteToFonero(av)
+	dsv, dsvr := convertStartVoteToFonero(sv)
+	vdr := foneroplugin.VoteDetailsReply{
+		AuthorizeVote:  dav,
+		StartVote:      dsv,
+		StartVoteReply: dsvr,
+	}
+	vdrb, err := foneroplugin.EncodeVoteDetailsReply(vdr)
+	if err != nil {
+		return "", err
+	}
+
+	return string(vdrb), nil
+}
+
+// cmdGetStartVoteReply returns the StartVoteReply of the passed in record
+// token.  A cache.ErrRecordNotFound is returned if the vote of the record has
+// not been started.
+func (d *fonero) cmdGetStartVoteReply(payload string) (string, error) {
+	log.Tracef("fonero cmdGetStartVoteReply")
+
+	g, err := foneroplugin.DecodeGetStartVoteReply([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	var sv StartVote
+	err = d.recordsdb.
+		Where("token = ?", g.Token).
+		Preload("EligibleTickets", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position asc")
+		}).
+		Find(&sv).
+		Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = cache.ErrRecordNotFound
+		}
+		return "", err
+	}
+
+	_, svr := convertStartVoteToFonero(sv)
+	reply, err := foneroplugin.EncodeStartVoteReply(svr)
+	if err != nil {
+		return "", err
+	}
+
+	return string(reply), nil
+}
+
+// cmdGetVoteOptions returns the vote options and the vote mask of the passed
+// in record token.  cache.ErrRecordNotFound is returned if the vote of the
+// record has not been started.
+func (d *fonero) cmdGetVoteOptions(payload string) (string, error) {
+	log.Tracef("fonero cmdGetVoteOptions")
+
+	g, err := foneroplugin.DecodeGetVoteOptions([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	sv, err := d.startVote(g.Token)
+	if err != nil {
+		return "", err
+	}
+
+	fsv, _ := convertStartVoteToFonero(*sv)
+	reply, err := foneroplugin.EncodeGetVoteOptionsReply(
+		foneroplugin.GetVoteOptionsReply{
+			Mask:    fsv.Vote.Mask,
+			Options: fsv.Vote.Options,
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(reply), nil
+}
+
+// cmdProposalActivityTimeline returns the comments, comment likes, authorize
+// votes, start vote, and cast votes of the passed in record token as a single
+// stream of events that is ordered by timestamp.  Hidden comments are not
+// included.
+func (d *fonero) cmdProposalActivityTimeline(payload string) (string, error) {
+	log.Tracef("fonero cmdProposalActivityTimeline")
+
+	pat, err := foneroplugin.DecodeProposalActivityTimeline([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	// The events of every type are ordered by timestamp so that
+	// events with the same timestamp keep their order once all
+	// events have been sorted.
+	var comments []Comment
+	err = d.recordsdb.
+		Where("token = ? AND hidden = ?", pat.Token, false).
+		Order("timestamp, comment_id").
+		Find(&comments).
+		Error
+	if err != nil {
+		return "", fmt.Errorf("lookup comments: %v", err)
+	}
+
+	var likes []LikeComment
+	err = d.recordsdb.
+		Where("token = ?", pat.Token).
+		Order("timestamp, key").
+		Find(&likes).
+		Error
+	if err != nil {
+		return "", fmt.Errorf("lookup comment likes: %v", err)
+	}
+
+	var avs []AuthorizeVote
+	err = d.recordsdb.
+		Where("token = ?", pat.Token).
+		Order("timestamp, version").
+		Find(&avs).
+		Error
+	if err != nil {
+		return "", fmt.Errorf("lookup authorize votes: %v", err)
+	}
+
+	sv, err := d.startVote(pat.Token)
+	if err != nil && err != cache.ErrRecordNotFound {
+		return "", err
+	}
+
+	var cvs []CastVote
+	err = d.recordsdb.
+		Where("token = ?", pat.Token).
+		Order("timestamp, key").
+		Find(&cvs).
+		Error
+	if err != nil {
+		return "", fmt.Errorf("lookup cast votes: %v", err)
+	}
+
+	events := make([]foneroplugin.TimelineEvent, 0,
+		len(comments)+len(likes)+len(avs)+len(cvs)+1)
+	for _, v := range comments {
+		events = append(events, foneroplugin.TimelineEvent{
+			Type:      foneroplugin.TimelineEventComment,
+			Timestamp: v.Timestamp,
+			PublicKey: v.PublicKey,
+			CommentID: v.CommentID,
+		})
+	}
+	for _, v := range likes {
+		events = append(events, foneroplugin.TimelineEvent{
+			Type:      foneroplugin.TimelineEventLikeComment,
+			Timestamp: v.Timestamp,
+			PublicKey: v.PublicKey,
+			CommentID: v.CommentID,
+			Action:    v.Action,
+		})
+	}
+	for _, v := range avs {
+		events = append(events, foneroplugin.TimelineEvent{
+			Type:      foneroplugin.TimelineEventAuthorizeVote,
+			Timestamp: v.Timestamp,
+			PublicKey: v.PublicKey,
+			Action:    v.Action,
+		})
+	}
+	if sv != nil {
+		events = append(events, foneroplugin.TimelineEvent{
+			Type:      foneroplugin.TimelineEventStartVote,
+			Timestamp: sv.Timestamp,
+			PublicKey: sv.PublicKey,
+		})
+	}
+	for _, v := range cvs {
+		events = append(events, foneroplugin.TimelineEvent{
+			Type:      foneroplugin.TimelineEventCastVote,
+			Timestamp: v.Timestamp,
+			Ticket:    v.Ticket,
+			VoteBit:   v.VoteBit,
+		})
+	}
+	foneroplugin.SortTimelineEvents(events)
+
+	reply, err := foneroplugin.EncodeProposalActivityTimelineReply(
+		foneroplugin.ProposalActivityTimelineReply{
+			Events: events,
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(reply), nil
+}
+
+// cmdGetEligibleTicketCount returns the number of tickets that are eligible to
+// vote on the passed in record token.  cache.ErrRecordNotFound is returned if
+// the vote of the record has not been started.
+func (d *fonero) cmdGetEligibleTicketCount(payload string) (string, error) {
+	log.Tracef("fonero cmdGetEligibleTicketCount")
+
+	g, err := foneroplugin.DecodeGetEligibleTicketCount([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	sv, err := d.startVote(g.Token)
+	if err != nil {
+		return "", err
+	}
+
+	reply, err := foneroplugin.EncodeGetEligibleTicketCountReply(
+		foneroplugin.GetEligibleTicketCountReply{
+			EligibleTicketCount: sv.EligibleTicketCount,
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(reply), nil
+}
+
+// newCastVote inserts a CastVote record into the database.  This function has
+// a database parameter so that it can be called inside of a transaction when
+// required.
+func (d *fonero) newCastVote(db *gorm.DB, cv CastVote) error {
+	return db.Create(&cv).Error
+}
+
+// castVoteCounts returns the number of votes in the passed in cast votes for
+// each token+votebit, sorted by key.
+func castVoteCounts(votes []CastVote) []CastVoteCount {
+	counts := make(map[string]*CastVoteCount, 16) // [tokenVoteBit]CastVoteCount
+	for _, v := range votes {
+		c, ok := counts[v.TokenVoteBit]
+		if !ok {
+			c = &CastVoteCount{
+				Key:     v.TokenVoteBit,
+				Token:   v.Token,
+				VoteBit: tallyVoteBit(v.VoteBit),
+			}
+			counts[v.TokenVoteBit] = c
+		}
+		c.Votes++
+	}
+
+	cvc := make([]CastVoteCount, 0, len(counts))
+	for _, v := range counts {
+		cvc = append(cvc, *v)
+	}
+	sort.Slice(cvc, func(i, j int) bool {
+		return cvc[i].Key < cvc[j].Key
+	})
+
+	return cvc
+}
+
+// incrementCastVoteCount adds the votes of the passed in CastVoteCount to the
+// running total of its token+votebit.  A counter is created if one does not
+// already exist.  This function has a database parameter so that it can be
+// called inside of a transaction when required.
+func (d *fonero) incrementCastVoteCount(db *gorm.DB, c CastVoteCount) error {
+	q := `INSERT INTO cast_vote_counts (key, token, vote_bit, votes)
+        VALUES (?, ?, ?, ?)
+        ON CONFLICT (key)
+        DO UPDATE SET votes = cast_vote_counts.votes + excluded.votes`
+	return db.Exec(q, c.Key, c.Token, c.VoteBit, c.Votes).Error
+}
+
+// loadCastVoteCounts sets the cast vote counters using a full count of the
+// cast votes table.  This function has a database parameter so that it can be
+// called inside of a transaction when required.
+func (d *fonero) loadCastVoteCounts(db *gorm.DB) error {
+	err := db.Delete(CastVoteCount{}).Error
+	if err != nil {
+		return fmt.Errorf("delete cast vote counts: %v", err)
+	}
+
+	q := `INSERT INTO cast_vote_counts (key, token, vote_bit, votes)
+        SELECT token_vote_bit, token,
+          SUBSTR(token_vote_bit, LENGTH(token) + 1), COUNT(*)
+        FROM cast_votes
+        GROUP BY token_vote_bit, token`
+	defer d.timeQuery("load cast vote counts")()
+	err = db.Exec(q).Error
+	if err != nil {
+		return fmt.Errorf("count cast votes: %v", err)
+	}
+
+	return nil
+}
+
+// cmdNewBallot creates CastVote records using the passed in payloads and
+// inserts them into the database.  Votes that were rejected by the backend,
+// which is indicated by an error in their backend receipt, were not journaled
+// and are skipped.  Every vote is inserted in its own transaction along with
+// its cast vote counter update.  Votes that could not be inserted are reported
+// in the error field of their receipt in the returned ballot reply and do not
+// prevent the remaining votes from being inserted.
+func (d *fonero) cmdNewBallot(cmdPayload, replyPayload string) (string, error) {
+	log.Tracef("fonero cmdNewBallot")
+
+	b, err := foneroplugin.DecodeBallot([]byte(cmdPayload))
+	if err != nil {
+		return "", err
+	}
+	br, err := foneroplugin.DecodeBallotReply([]byte(replyPayload))
+	if err != nil {
+		return "", err
+	}
+
+	// Ensure there is a receipt for every vote so that cache
+	// failures can be reported.
+	for len(br.Receipts) < len(b.Votes) {
+		br.Receipts = append(br.Receipts, foneroplugin.CastVoteReply{})
+	}
+
+	// Add votes to database. Each vote is added in its own
+	// transaction along with its cast vote counter update so
+	// that a single invalid vote does not prevent the rest of
+	// the ballot from being cached.
+	ts := d.now().Unix()
+	for i, v := range b.Votes {
+		// Skip votes that were rejected by the backend
+		if br.Receipts[i].Error != "" {
+			log.Debugf("cmdNewBallot: vote %v %v rejected by backend: %v",
+				v.Token, v.Ticket, br.Receipts[i].Error)
+			continue
+		}
+
+		c := convertCastVoteFromFonero(v)
+		c.Timestamp = ts
+		c.Receipt = br.Receipts[i].Signature
+		err := d.newBallotVote(c)
+		if err != nil {
+			log.Errorf("cmdNewBallot: vote %v %v not cached: %v",
+				c.Token, c.Ticket, err)
+			br.Receipts[i].Error = fmt.Sprintf("vote not cached: %v", err)
+		}
+	}
+
+	reply, err := foneroplugin.EncodeBallotReply(*br)
+	if err != nil {
+		return "", err
+	}
+
+	return string(reply), nil
+}
+
+// normalizeVoteBit returns the canonical representation of the passed in hex
+// encoded vote bit, which is lowercase hex without a 0x prefix or leading
+// zeros.  This matches the way the vote option bits are formatted when the
+// votes are tallied, so cast votes must be matched against the vote options
+// using the canonical representation.  An error is returned if the vote bit
+// is not valid hex.
+func normalizeVoteBit(voteBit string) (string, error) {
+	s := voteBit
+	if len(s) > 2 && s[0] == '0' && (s[1] == 'x' || s[1] == 'X') {
+		s = s[2:]
+	}
+	bits, err := strconv.ParseUint(s, 16, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid vote bit '%v'", voteBit)
+	}
+	return strconv.FormatUint(bits, 16), nil
+}
+
+// tallyVoteBit returns the canonical representation of the passed in vote bit
+// that is used to tally the cast votes.  Vote bits that are not valid hex are
+// returned unchanged so that they do not match any vote option.
+func tallyVoteBit(voteBit string) string {
+	vb, err := normalizeVoteBit(voteBit)
+	if err != nil {
+		return voteBit
+	}
+	return vb
+}
+
+// tallyCastVotes returns the number of the passed in cast votes for each vote
+// bit, keyed by canonical vote bit.
+func tallyCastVotes(votes []CastVote) map[string]uint64 {
+	tally := make(map[string]uint64, 16) // [voteBit]voteCount
+	for _, v := range votes {
+		tally[tallyVoteBit(v.VoteBit)]++
+	}
+	return tally
+}
+
+// newBallotVote inserts a single cast vote and increments its cast vote
+// counter in a transaction.
+func (d *fonero) newBallotVote(c CastVote) error {
+	tx := d.recordsdb.Begin()
+	err := d.newCastVote(tx, c)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	for _, v := range castVoteCounts([]CastVote{c}) {
+		err = d.incrementCastVoteCount(tx, v)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("increment cast vote count: %v", err)
+		}
+	}
+
+	err = tx.Commit().Error
+	if err != nil {
+		return fmt.Errorf("commit transaction failed: %v", err)
+	}
+
+	return nil
+}
+
+// voteTally returns the number of votes that have been cast for each of the
+// passed in vote options.  The votes are counted using an aggregate query so
+// that the cast votes do not need to be loaded into memory.
+func (d *fonero) voteTally(token string, options []VoteOption) ([]foneroplugin.VoteOptionResult, error) {
+	q := `SELECT vote_bit, COUNT(*)
+        FROM cast_votes
+        WHERE token = ?
+        GROUP BY vote_bit`
+	return d.queryVoteOptionResults(q, token, options)
+}
+
+// voteCounts returns the number of votes that have been cast for each of the
+// passed in vote options using the cast vote counters.
+func (d *fonero) voteCounts(token string, options []VoteOption) ([]foneroplugin.VoteOptionResult, error) {
+	q := `SELECT vote_bit, votes
+        FROM cast_vote_counts
+        WHERE token = ?`
+	return d.queryVoteOptionResults(q, token, options)
+}
+
+// queryVoteOptionResults executes the passed in query, which must select a
+// vote bit and its vote count, and returns the results for each of the passed
+// in vote options.
+func (d *fonero) queryVoteOptionResults(q, token string, options []VoteOption) ([]foneroplugin.VoteOptionResult, error) {
+	defer d.timeQuery("vote option results")()
+
+	rows, err := d.recordsdb.Raw(q, token).Rows()
+	if err != nil {
+		return nil, fmt.Errorf("tally cast votes: %v", err)
+	}
+	defer rows.Close()
+
+	var (
+		voteBit string
+		count   uint64
+	)
+	tally := make(map[string]uint64, len(options)) // [voteBit]voteCount
+	for rows.Next() {
+		err := rows.Scan(&voteBit, &count)
+		if err != nil {
+			return nil, err
+		}
+		tally[tallyVoteBit(voteBit)] += count
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tallyVoteOptionResults(options, tally), nil
+}
+
+// tallyVoteOptionResults returns the results of the passed in vote options
+// using the passed in tally of votes, which is keyed by hex encoded vote bit.
+func tallyVoteOptionResults(options []VoteOption, tally map[string]uint64) []foneroplugin.VoteOptionResult {
+	results := make([]foneroplugin.VoteOptionResult, 0, len(options))
+	for _, v := range options {
+		votes := tally[strconv.FormatUint(v.Bits, 16)]
+		results = append(results, convertVoteOptionToFonero(v, votes, false))
+	}
+	return results
+}
+
+// voteOptionCounts returns the number of votes of the passed in vote option
+// results, keyed by hex encoded vote bit.
+func voteOptionCounts(results []foneroplugin.VoteOptionResult) map[string]uint64 {
+	counts := make(map[string]uint64, len(results)) // [voteBit]voteCount
+	for _, v := range results {
+		counts[strconv.FormatUint(v.Bits, 16)] = v.Votes
+	}
+	return counts
+}
+
+// cmdCountVotesByOption returns the vote options of the passed in record
+// token along with the number of votes that have been cast for each option.
+// The votes are counted using a single aggregate query over the cast votes.
+func (d *fonero) cmdCountVotesByOption(payload string) (string, error) {
+	log.Tracef("fonero cmdCountVotesByOption")
+
+	c, err := foneroplugin.DecodeCountVotesByOption([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	// Lookup start vote
+	sv, err := d.startVote(c.Token)
+	if err != nil {
+		return "", err
+	}
+
+	results, err := d.voteTally(c.Token, sv.Options)
+	if err != nil {
+		return "", err
+	}
+	fsv, _ := convertStartVoteToFonero(*sv)
+
+	reply, err := foneroplugin.EncodeCountVotesByOptionReply(
+		foneroplugin.CountVotesByOptionReply{
+			Options: fsv.Vote.Options,
+			Counts:  voteOptionCounts(results),
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(reply), nil
+}
+
+// voteStatus returns the vote status of a proposal given its authorize vote,
+// start vote, and vote results.  A nil pointer indicates that the record does
+// not exist.  The best block is only used when the vote has been started.
+func voteStatus(av *AuthorizeVote, sv *StartVote, vr *VoteResults, bestBlock uint64) foneroplugin.VoteStatusT {
+	switch {
+	case sv == nil && av != nil &&
+		av.Action == foneroplugin.AuthVoteActionAuthorize:
+		return foneroplugin.VoteStatusAuthorized
+	case sv == nil:
+		return foneroplugin.VoteStatusNotAuthorized
+	case sv.EndHeight > bestBlock:
+		return foneroplugin.VoteStatusStarted
+	case vr == nil:
+		return foneroplugin.VoteStatusFinished
+	case vr.Approved:
+		return foneroplugin.VoteStatusApproved
+	default:
+		return foneroplugin.VoteStatusRejected
+	}
+}
+
+// cmdGetVoteStatus returns the vote status of the passed in record token.  The
+// vote status is derived from the authorize vote of the most recent record
+// version, the start vote, the best block, and the vote results.
+func (d *fonero) cmdGetVoteStatus(payload string) (string, error) {
+	log.Tracef("fonero cmdGetVoteStatus")
+
+	gvs, err := foneroplugin.DecodeGetVoteStatus([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	// Lookup the most recent record version
+	r, err := latestRecordVersion(d.recordsdb, gvs.Token)
+	if err != nil {
+		return "", err
+	}
+
+	// Lookup authorize vote
+	var av *AuthorizeVote
+	var a AuthorizeVote
+	key := gvs.Token + strconv.FormatUint(r.Version, 10)
+	err = d.recordsdb.
+		Where("key = ?", key).
+		Find(&a).
+		Error
+	switch {
+	case err == nil:
+		av = &a
+	case err != gorm.ErrRecordNotFound:
+		return "", fmt.Errorf("lookup authorize vote: %v", err)
+	}
+
+	// Lookup start vote
+	sv, err := d.startVote(gvs.Token)
+	switch {
+	case err == cache.ErrRecordNotFound:
+		sv = nil
+	case err != nil:
+		return "", fmt.Errorf("lookup start vote: %v", err)
+	}
+
+	// The best block and the vote results are only
+	// needed once the vote has been started.
+	var (
+		bestBlock uint64
+		vr        *VoteResults
+	)
+	if sv != nil {
+		bestBlock, err = d.bestBlock(gvs.BestBlock)
+		if err != nil {
+			return "", err
+		}
+
+		var v VoteResults
+		err = d.recordsdb.
+			Where("token = ?", gvs.Token).
+			Find(&v).
+			Error
+		switch {
+		case err == nil:
+			vr = &v
+		case err != gorm.ErrRecordNotFound:
+			return "", fmt.Errorf("lookup vote results: %v", err)
+		}
+	}
+
+	reply, err := foneroplugin.EncodeGetVoteStatusReply(
+		foneroplugin.GetVoteStatusReply{
+			Status: voteStatus(av, sv, vr, bestBlock),
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(reply), nil
+}
+
+// cmdProposalSupportersCount returns the number of distinct tickets that have
+// voted on the passed in record token along with the total number of votes
+// that were cast.
+func (d *fonero) cmdProposalSupportersCount(payload string) (string, error) {
+	log.Tracef("fonero cmdProposalSupportersCount")
+
+	p, err := foneroplugin.DecodeProposalSupportersCount([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	q := `SELECT COUNT(DISTINCT ticket), COUNT(*)
+        FROM cast_votes
+        WHERE token = ?`
+	defer d.timeQuery("proposal supporters count")()
+	var supporters, votes uint64
+	err = d.recordsdb.
+		Raw(q, p.Token).
+		Row().
+		Scan(&supporters, &votes)
+	if err != nil {
+		return "", fmt.Errorf("proposal supporters count: %v", err)
+	}
+
+	reply, err := foneroplugin.EncodeProposalSupportersCountReply(
+		foneroplugin.ProposalSupportersCountReply{
+			Supporters: supporters,
+			Votes:      votes,
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(reply), nil
+}
+
+// validateBlockWindow returns an error if the passed in block window is not
+// valid.
+func validateBlockWindow(start, end uint64) error {
+	if start > end {
+		return fmt.Errorf("invalid block window: start height %v is "+
+			"greater than end height %v", start, end)
+	}
+	return nil
+}
+
+// cmdVotesByBlockWindow returns the tokens of all proposals whose voting
+// period overlaps the passed in block window, ordered by vote start block
+// height.
+func (d *fonero) cmdVotesByBlockWindow(payload string) (string, error) {
+	log.Tracef("fonero cmdVotesByBlockWindow")
+
+	v, err := foneroplugin.DecodeVotesByBlockWindow([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	err = validateBlockWindow(v.StartHeight, v.EndHeight)
+	if err != nil {
+		return "", err
+	}
+
+	// This query returns the tokens of all votes that started
+	// on or before the end of the window and ended on or after
+	// the start of the window. The start block height is stored
+	// as a string so it must be cast to compare it numerically.
+	// The token is used as a tie breaker so that the results
+	// are deterministic.
+	q := `SELECT token
+        FROM start_votes
+        WHERE CAST(start_block_height AS INT) <= ?
+          AND end_height >= ?
+        ORDER BY CAST(start_block_height AS INT) ASC, token ASC`
+	tokens, err := d.queryStrings("votes by block window", q,
+		v.EndHeight, v.StartHeight)
+	if err != nil {
+		return "", fmt.Errorf("votes by block window: %v", err)
+	}
+
+	reply, err := foneroplugin.EncodeVotesByBlockWindowReply(
+		foneroplugin.VotesByBlockWindowReply{
+			Tokens: tokens,
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(reply), nil
+}
+
+// cmdProposalVotes returns the StartVote record and all CastVote records for
+// the passed in record token.  If a tally was requested, the number of votes
+// cast for each vote option is returned instead of the CastVote records.  The
+// started field of the reply distinguishes a vote that has not been started
+// from a started vote that has no cast votes.
+func (d *fonero) cmdProposalVotes(payload string) (string, error) {
+	log.Tracef("fonero cmdProposalVotes")
+
+	vr, err := foneroplugin.DecodeVoteResults([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	// Lookup start vote
+	var sv StartVote
+	s, err := d.startVote(vr.Token)
+	if err == cache.ErrRecordNotFound {
+		// A start vote may note exist if the voting period has not
+		// been started yet. This is ok.
+	} else if err != nil {
+		return "", fmt.Errorf("start vote lookup failed: %v", err)
+	} else {
+		sv = *s
+	}
+	started := (err == nil)
+	dsv, _ := convertStartVoteToFonero(sv)
+
+	// Only return the vote option tallies if requested
+	if vr.TallyOnly {
+		tally, err := d.voteTally(vr.Token, sv.Options)
+		if err != nil {
+			return "", err
+		}
+
+		vrrb, err := foneroplugin.EncodeVoteResultsReply(
+			foneroplugin.VoteResultsReply{
+				Started:   started,
+				StartVote: dsv,
+				Tally:     tally,
+			})
+		if err != nil {
+			return "", err
+		}
+
+		return string(vrrb), nil
+	}
+
+	// Lookup the requested page of cast votes. The cast votes
+	// are ordered by ticket so that pages are deterministic.
+	var cv []CastVote
+	q := d.recordsdb.
+		Where("token = ?", vr.Token).
+		Order("ticket asc")
+	if vr.AfterTicket != "" {
+		q = q.Where("ticket > ?", vr.AfterTicket)
+	}
+	if vr.Offset > 0 {
+		q = q.Offset(vr.Offset)
+	}
+	if vr.Limit > 0 {
+		q = q.Limit(vr.Limit)
+	}
+	err = q.Find(&cv).Error
+	if err == gorm.ErrRecordNotFound {
+		// No cast votes may exist yet. This is ok.
+	} else if err != nil {
+		return "", fmt.Errorf("cast votes lookup failed: %v", err)
+	}
+
+	// Prepare reply
+	dcv := make([]foneroplugin.CastVote, 0, len(cv))
+	for _, v := range cv {
+		dcv = append(dcv, convertCastVoteToFonero(v))
+	}
+
+	vrr := foneroplugin.VoteResultsReply{
+		Started:   started,
+		StartVote: dsv,
+		CastVotes: dcv,
+	}
+
+	vrrb, err := foneroplugin.EncodeVoteResultsReply(vrr)
+	if err != nil {
+		return "", err
+	}
+
+	return string(vrrb), nil
+}
+
+// cmdProposalVoteReceipts returns the cast votes of a record along with the
+// receipts that were returned to the voters.  The votes can optionally be
+// filtered by ticket.
+func (d *fonero) cmdProposalVoteReceipts(payload string) (string, error) {
+	log.Tracef("fonero cmdProposalVoteReceipts")
+
+	pvr, err := foneroplugin.DecodeProposalVoteReceipts([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	q := d.recordsdb.Where("token = ?", pvr.Token)
+	if pvr.Ticket != "" {
+		q = q.Where("ticket = ?", pvr.Ticket)
+	}
+
+	cv := make([]CastVote, 0, 1024) // PNOOMA
+	err = q.Order("ticket asc").
+		Find(&cv).
+		Error
+	if err != nil {
+		return "", err
+	}
+
+	receipts := make([]foneroplugin.VoteReceipt, 0, len(cv))
+	for _, v := range cv {
+		receipts = append(receipts, foneroplugin.VoteReceipt{
+			Ticket:    v.Ticket,
+			VoteBit:   v.VoteBit,
+			Signature: v.Signature,
+			Receipt:   v.Receipt,
+		})
+	}
+
+	reply, err := foneroplugin.EncodeProposalVoteReceiptsReply(
+		foneroplugin.ProposalVoteReceiptsReply{
+			Receipts: receipts,
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(reply), nil
+}
+
+// cmdVoteEligibility returns whether the passed in ticket is eligible to vote
+// on the passed in record token and whether the ticket has already voted.
+func (d *fonero) cmdVoteEligibility(payload string) (string, error) {
+	log.Tracef("fonero cmdVoteEligibility")
+
+	ve, err := foneroplugin.DecodeVoteEligibility([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	// Lookup start vote
+	var (
+		sv       StartVote
+		ver      foneroplugin.VoteEligibilityReply
+		eligible int
+	)
+	err = d.recordsdb.
+		Where("token = ?", ve.Token).
+		Find(&sv).
+		Error
+	if err == gorm.ErrRecordNotFound {
+		// A start vote may not exist if the voting period has not
+		// been started yet. No tickets are eligible in that case.
+		goto sendReply
+	} else if err != nil {
+		return "", fmt.Errorf("start vote lookup failed: %v", err)
+	}
+
+	// Check if the ticket is part of the eligible ticket pool
+	err = d.recordsdb.
+		Model(&EligibleTicket{}).
+		Where("key = ?", ve.Token+ve.Ticket).
+		Count(&eligible).
+		Error
+	if err != nil {
+		return "", fmt.Errorf("count eligible tickets: %v", err)
+	}
+	ver.Eligible = (eligible > 0)
+
+	// Check if the ticket has already voted
+	if ver.Eligible {
+		var count int
+		err = d.recordsdb.
+			Model(&CastVote{}).
+			Where("token = ? AND ticket = ?", ve.Token, ve.Ticket).
+			Count(&count).
+			Error
+		if err != nil {
+			return "", fmt.Errorf("count cast votes: %v", err)
+		}
+		ver.AlreadyVoted = (count > 0)
+	}
+
+sendReply:
+	reply, err := foneroplugin.EncodeVoteEligibilityReply(ver)
+	if err != nil {
+		return "", err
+	}
+
+	return string(reply), nil
+}
+
+// eligibleTicketsPage returns the start and end positions of the page of
+// eligible tickets that is described by offset and limit.  The start position
+// is inclusive and the end position is exclusive.  A limit of zero returns all
+// tickets after the offset.
+func eligibleTicketsPage(total, offset, limit uint32) (uint32, uint32) {
+	start := offset
+	if start > total {
+		start = total
+	}
+	end := total
+	if limit > 0 && limit < end-start {
+		end = start + limit
+	}
+
+	return start, end
+}
+
+// cmdEligibleTickets returns the requested page of the tickets that are
+// eligible to vote on a proposal.
+func (d *fonero) cmdEligibleTickets(payload string) (string, error) {
+	log.Tracef("fonero cmdEligibleTickets")
+
+	et, err := foneroplugin.DecodeEligibleTickets([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	// Lookup start vote
+	var sv StartVote
+	err = d.recordsdb.
+		Select("eligible_ticket_count").
+		Where("token = ?", et.Token).
+		Find(&sv).
+		Error
+	if err == gorm.ErrRecordNotFound {
+		// A start vote may not exist if the voting period has not
+		// been started yet. No tickets are eligible in that case.
+	} else if err != nil {
+		return "", fmt.Errorf("start vote lookup failed: %v", err)
+	}
+
+	// Lookup the requested page of eligible tickets
+	total := uint32(sv.EligibleTicketCount)
+	start, end := eligibleTicketsPage(total, et.Offset, et.Limit)
+	tickets := make([]string, 0, end-start)
+	if start < end {
+		var eligible []EligibleTicket
+		err = d.recordsdb.
+			Where("token = ? AND position >= ? AND position < ?",
+				et.Token, start, end).
+			Order("position asc").
+			Find(&eligible).
+			Error
+		if err != nil {
+			return "", fmt.Errorf("eligible tickets lookup failed: %v",
+				err)
+		}
+		for _, v := range eligible {
+			tickets = append(tickets, v.Ticket)
+		}
+	}
+
+	reply, err := foneroplugin.EncodeEligibleTicketsReply(
+		foneroplugin.EligibleTicketsReply{
+			Tickets: tickets,
+			Total:   total,
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(reply), nil
+}
+
+// validateRecordStatus returns an error if the passed in status is not the
+// status of an existing record.
+func validateRecordStatus(status pd.RecordStatusT) error {
+	switch status {
+	case pd.RecordStatusNotReviewed, pd.RecordStatusCensored,
+		pd.RecordStatusPublic, pd.RecordStatusUnreviewedChanges,
+		pd.RecordStatusArchived:
+		return nil
+	}
+	return fmt.Errorf("invalid record status %v", status)
+}
+
+// cmdRecordsByStatus returns the tokens of all records whose latest version
+// has the requested status, ordered by timestamp in ascending order.
+func (d *fonero) cmdRecordsByStatus(payload string) (string, error) {
+	log.Tracef("fonero cmdRecordsByStatus")
+
+	rs, err := foneroplugin.DecodeRecordsByStatus([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	status := pd.RecordStatusT(rs.Status)
+	err = validateRecordStatus(status)
+	if err != nil {
+		return "", err
+	}
+
+	// This query returns the tokens of the most recent version
+	// of all records with the provided status, ordered by
+	// timestamp in ascending order. The token is used as a tie
+	// breaker so that pages are deterministic.
+	q := `SELECT a.token
+        FROM records a
+        LEFT OUTER JOIN records b
+          ON a.token = b.token
+          AND a.version < b.version
+        WHERE b.token IS NULL
+          AND a.status = ?
+        ORDER BY a.timestamp ASC, a.token ASC`
+	args := []interface{}{status}
+	if rs.Limit > 0 {
+		q += " LIMIT ?"
+		args = append(args, rs.Limit)
+	}
+	if rs.Offset > 0 {
+		q += " OFFSET ?"
+		args = append(args, rs.Offset)
+	}
+	tokens, err := d.queryStrings("records by status", q, args...)
+	if err != nil {
+		return "", fmt.Errorf("records by status: %v", err)
+	}
+
+	reply, err := foneroplugin.EncodeRecordsByStatusReply(
+		foneroplugin.RecordsByStatusReply{
+			Tokens: tokens,
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(reply), nil
+}
+
+// cmdRecordStatusCounts returns the number of records whose latest version has
+// each record status.  Every status that a record can have is included in the
+// reply, even when no records have that status.
+func (d *fonero) cmdRecordStatusCounts(payload string) (string, error) {
+	log.Tracef("fonero cmdRecordStatusCounts")
+
+	_, err := foneroplugin.DecodeRecordStatusCounts([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	counts := map[int]uint64{
+		int(pd.RecordStatusNotReviewed):       0,
+		int(pd.RecordStatusCensored):          0,
+		int(pd.RecordStatusPublic):            0,
+		int(pd.RecordStatusUnreviewedChanges): 0,
+		int(pd.RecordStatusArchived):          0,
+	}
+
+	// This query counts the most recent version of all records,
+	// grouped by status.
+	q := `SELECT a.status, COUNT(*)
+        FROM records a
+        LEFT OUTER JOIN records b
+          ON a.token = b.token
+          AND a.version < b.version
+        WHERE b.token IS NULL
+        GROUP BY a.status`
+	defer d.timeQuery("record status counts")()
+	rows, err := d.recordsdb.Raw(q).Rows()
+	if err != nil {
+		return "", fmt.Errorf("record status counts: %v", err)
+	}
+	defer rows.Close()
+
+	var (
+		status int
+		count  uint64
+	)
+	for rows.Next() {
+		err := rows.Scan(&status, &count)
+		if err != nil {
+			return "", err
+		}
+		counts[status] = count
+	}
+	if err = rows.Err(); err != nil {
+		return "", err
+	}
+
+	reply, err := foneroplugin.EncodeRecordStatusCountsReply(
+		foneroplugin.RecordStatusCountsReply{
+			Counts: counts,
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(reply), nil
+}
+
+// cmdArchiveStats returns the number of abandoned proposals, the total number
+// of comments on them, and their average age when they were abandoned.  A
+// proposal is abandoned when its latest version has been archived.  The
+// timestamp of the archived version is the time at which the proposal was
+// abandoned and the earliest timestamp of all of its versions is the time at
+// which it was created.
+func (d *fonero) cmdArchiveStats(payload string) (string, error) {
+	log.Tracef("fonero cmdArchiveStats")
+
+	_, err := foneroplugin.DecodeArchiveStats([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	// This query returns the archived timestamp, the created
+	// timestamp, and the comment count of the most recent version
+	// of all archived records.
+	q := `SELECT a.timestamp,
+          (SELECT MIN(c.timestamp) FROM records c
+            WHERE c.token = a.token),
+          (SELECT COUNT(*) FROM comments
+            WHERE comments.token = a.token)
+        FROM records a
+        LEFT OUTER JOIN records b
+          ON a.token = b.token
+          AND a.version < b.version
+        WHERE b.token IS NULL
+          AND a.status = ?`
+	defer d.timeQuery("archive stats")()
+	rows, err := d.recordsdb.Raw(q, pd.RecordStatusArchived).Rows()
+	if err != nil {
+		return "", fmt.Errorf("archive stats: %v", err)
+	}
+	defer rows.Close()
+
+	var (
+		r                      foneroplugin.ArchiveStatsReply
+		archived, created, age int64
+		comments               uint64
+	)
+	for rows.Next() {
+		err := rows.Scan(&archived, &created, &comments)
+		if err != nil {
+			return "", err
+		}
+		r.Abandoned++
+		r.Comments += comments
+		age += archived - created
+	}
+	if err = rows.Err(); err != nil {
+		return "", err
+	}
+	if r.Abandoned > 0 {
+		r.AverageAge = age / int64(r.Abandoned)
+	}
+
+	reply, err := foneroplugin.EncodeArchiveStatsReply(r)
+	if err != nil {
+		return "", err
+	}
+
+	return string(reply), nil
+}
+
+// cmdRecordHistory returns the version, status, and timestamp of every version
+// of the passed in record token, ordered by version.
+func (d *fonero) cmdRecordHistory(payload string) (string, error) {
+	log.Tracef("fonero cmdRecordHistory")
+
+	rh, err := foneroplugin.DecodeRecordHistory([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	var records []Record
+	err = d.recordsdb.
+		Select("version, status, timestamp").
+		Where("token = ?", rh.Token).
+		Order("version asc").
+		Find(&records).
+		Error
+	if err != nil {
+		return "", err
+	}
+	if len(records) == 0 {
+		return "", cache.ErrRecordNotFound
+	}
+
+	versions := make([]foneroplugin.RecordHistoryVersion, 0, len(records))
+	for _, v := range records {
+		versions = append(versions, foneroplugin.RecordHistoryVersion{
+			Version:   v.Version,
+			Status:    v.Status,
+			Timestamp: v.Timestamp,
+		})
+	}
+
+	reply, err := foneroplugin.EncodeRecordHistoryReply(
+		foneroplugin.RecordHistoryReply{
+			Versions: versions,
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(reply), nil
+}
+
+// cmdGetProposalMetadata returns the metadata streams of the requested
+// version of a proposal, or of its most recent version when no version is
+// provided.  The record files are not loaded.
+func (d *fonero) cmdGetProposalMetadata(payload string) (string, error) {
+	log.Tracef("fonero cmdGetProposalMetadata")
+
+	g, err := foneroplugin.DecodeGetProposalMetadata([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	q := d.recordsdb.Where("records.token = ?", g.Token)
+	if g.Version != "" {
+		q = q.Where("records.key = ?", g.Token+g.Version)
+	}
+	var r Record
+	err = q.Order("records.version desc").
+		Limit(1).
+		Preload("Metadata").
+		Find(&r).
+		Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = cache.ErrRecordNotFound
+		}
+		return "", err
+	}
+
+	reply, err := foneroplugin.EncodeGetProposalMetadataReply(
+		foneroplugin.GetProposalMetadataReply{
+			Version:  strconv.FormatUint(r.Version, 10),
+			Metadata: convertMDStreamsToFonero(r.Metadata),
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(reply), nil
+}
+
+// base64DecodedLen returns the length of the data that is encoded by a padded
+// base64 string of the passed in length whose last characters are tail.  It
+// allows the size of a file to be determined without reading its payload.
+func base64DecodedLen(n int64, tail string) int64 {
+	size := n / 4 * 3
+	if size > 0 {
+		size -= int64(strings.Count(tail, "="))
+	}
+	return size
+}
+
+// cmdRecordFilesManifest returns the name, MIME type, digest and size of the
+// files of the requested record version.  The most recent version is used
+// when no version is requested.  The file payloads are not read from the
+// database.
+func (d *fonero) cmdRecordFilesManifest(payload string) (string, error) {
+	log.Tracef("fonero cmdRecordFilesManifest")
+
+	rfm, err := foneroplugin.DecodeRecordFilesManifest([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	q := d.recordsdb.Where("records.token = ?", rfm.Token)
+	if rfm.Version != "" {
+		q = q.Where("records.key = ?", rfm.Token+rfm.Version)
+	}
+	var r Record
+	err = q.Order("records.version desc").
+		Limit(1).
+		Find(&r).
+		Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = cache.ErrRecordNotFound
+		}
+		return "", err
+	}
+
+	// Only the length and the padding of the payloads are
+	// selected so that the file sizes can be computed without
+	// transferring the payloads.
+	fq := `SELECT name, mime, digest, LENGTH(payload), RIGHT(payload, 2)
+        FROM files
+        WHERE record_key = ?
+        ORDER BY key`
+	defer d.timeQuery("record files manifest")()
+	rows, err := d.recordsdb.Raw(fq, r.Key).Rows()
+	if err != nil {
+		return "", fmt.Errorf("record files manifest: %v", err)
+	}
+	defer rows.Close()
+
+	var (
+		fm     foneroplugin.FileManifest
+		length int64
+		tail   string
+	)
+	files := make([]foneroplugin.FileManifest, 0, 16)
+	for rows.Next() {
+		err := rows.Scan(&fm.Name, &fm.MIME, &fm.Digest, &length, &tail)
+		if err != nil {
+			return "", err
+		}
+		fm.Size = base64DecodedLen(length, tail)
+		files = append(files, fm)
+	}
+	if err = rows.Err(); err != nil {
+		return "", err
+	}
+
+	reply, err := foneroplugin.EncodeRecordFilesManifestReply(
+		foneroplugin.RecordFilesManifestReply{
+			Version: strconv.FormatUint(r.Version, 10),
+			Files:   files,
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(reply), nil
+}
+
+// cmdGetLatestRecordVersion returns the version and status of the most recent
+// version of a record.  It allows clients to cheaply check whether their copy
+// of a record is up to date.
+func (d *fonero) cmdGetLatestRecordVersion(payload string) (string, error) {
+	log.Tracef("fonero cmdGetLatestRecordVersion")
+
+	g, err := foneroplugin.DecodeGetLatestRecordVersion([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	r, err := latestRecordVersion(d.recordsdb, g.Token)
+	if err != nil {
+		return "", err
+	}
+
+	reply, err := foneroplugin.EncodeGetLatestRecordVersionReply(
+		foneroplugin.GetLatestRecordVersionReply{
+			Version: strconv.FormatUint(r.Version, 10),
+			Status:  r.Status,
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(reply), nil
+}
+
+// cmdGetRecordTimestampRange returns the tokens of all public records whose
+// most recent version has a timestamp within the provided range. The tokens
+// are ordered by timestamp in ascending order.
+func (d *fonero) cmdGetRecordTimestampRange(payload string) (string, error) {
+	log.Tracef("fonero cmdGetRecordTimestampRange")
+
+	g, err := foneroplugin.DecodeGetRecordTimestampRange([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	if g.Start > g.End {
+		return "", fmt.Errorf("invalid timestamp range: start %v > end %v",
+			g.Start, g.End)
+	}
+
+	// This query returns the tokens of the most recent version
+	// of all public records whose timestamp falls within the
+	// provided range, ordered by timestamp in ascending order.
+	q := `SELECT a.token
+        FROM records a
+        LEFT OUTER JOIN records b
+          ON a.token = b.token
+          AND a.version < b.version
+        WHERE b.token IS NULL
+          AND a.status = ?
+          AND a.timestamp BETWEEN ? AND ?
+        ORDER BY a.timestamp ASC`
+	tokens, err := d.queryStrings("record timestamp range", q,
+		pd.RecordStatusPublic, g.Start, g.End)
+	if err != nil {
+		return "", fmt.Errorf("timestamp range: %v", err)
+	}
+
+	reply, err := foneroplugin.EncodeGetRecordTimestampRangeReply(
+		foneroplugin.GetRecordTimestampRangeReply{
+			Tokens: tokens,
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(reply), nil
+}
+
+// cmdInventory returns the fonero plugin inventory.
+func (d *fonero) cmdInventory() (string, error) {
+	log.Tracef("fonero cmdInventory")
+
+	// XXX the only part of the fonero plugin inventory that we return
+	// at the moment is comments. This is because comments are the only
+	// thing politeiawww currently needs on startup.
+
+	// Get all comments. The comments are ordered so that a build
+	// that replays the inventory inserts them in the same order
+	// every time.
+	var c []Comment
+	err := d.recordsdb.
+		Order("token, timestamp, comment_id").
+		Find(&c).
+		Error
+	if err != nil {
+		return "", err
+	}
+
+	dc := make([]foneroplugin.Comment, 0, len(c))
+	for _, v := range c {
+		dc = append(dc, convertCommentToFonero(v))
+	}
+
+	// Prepare inventory reply
+	ir := foneroplugin.InventoryReply{
+		Comments: dc,
+	}
+	irb, err := foneroplugin.EncodeInventoryReply(ir)
+	if err != nil {
+		return "", err
+	}
+
+	return string(irb), err
+}
+
+// voteIsApproved returns whether the passed in vote option results meet the
+// quorum and pass requirements of the passed in start vote.  A vote with no
+// eligible tickets is never approved.
+func voteIsApproved(sv StartVote, results []VoteOptionResult) bool {
+	eligible := sv.EligibleTicketCount
+	if eligible == 0 {
+		return false
+	}
+
+	var total uint64
+	for _, v := range results {
+		total += v.Votes
+	}
+
+	quorum := uint64(float64(sv.QuorumPercentage) / 100 * float64(eligible))
+	pass := uint64(float64(sv.PassPercentage) / 100 * float64(total))
+
+	// XXX: this only supports proposals with yes/no
+	// voting options. Multiple voting option support
+	// will need to be added in the future.
+	var approvedVotes uint64
+	for _, v := range results {
+		if v.Option.ID == voteOptionIDApproved {
+			approvedVotes = v.Votes
+		}
+	}
+
+	var approved bool
+	switch {
+	case total == 0:
+		// No votes were cast
+	case total < quorum:
+		// Quorum not met
+	case approvedVotes < pass:
+		// Pass percentage not met
+	default:
+		// Vote was approved
+		approved = true
+	}
+
+	return approved
+}
+
+// approveVoteOptions sets the approved field of each of the passed in vote
+// option results for an approval vote.  An option is approved when the vote
+// met quorum and the option received at least its own pass percentage of the
+// total turnout.  Options without a pass percentage use the pass percentage
+// of the start vote.  It returns whether any of the options were approved.
+func approveVoteOptions(sv StartVote, results []VoteOptionResult) bool {
+	for i := range results {
+		results[i].Approved = false
+	}
+	eligible := sv.EligibleTicketCount
+	if eligible == 0 {
+		return false
+	}
+
+	var total uint64
+	for _, v := range results {
+		total += v.Votes
+	}
+
+	quorum := uint64(float64(sv.QuorumPercentage) / 100 * float64(eligible))
+	if total == 0 || total < quorum {
+		return false
+	}
+
+	var approved bool
+	for i, v := range results {
+		passPercentage := v.Option.PassPercentage
+		if passPercentage == 0 {
+			passPercentage = sv.PassPercentage
+		}
+		pass := uint64(float64(passPercentage) / 100 * float64(total))
+		if v.Votes >= pass {
+			results[i].Approved = true
+			approved = true
+		}
+	}
+
+	return approved
+}
+
+// newVoteResults creates a VoteResults record for a proposal and inserts it
+// into the cache. A VoteResults record should only be created for proposals
+// once the voting period has ended.  This function has a database parameter
+// so that it can be called inside of a transaction when required.
+func (d *fonero) newVoteResults(db *gorm.DB, token string) error {
+	log.Tracef("newVoteResults %v", token)
+
+	// Lookup start vote
+	var sv StartVote
+	err := db.
+		Where("token = ?", token).
+		Preload("Options").
+		Find(&sv).
+		Error
+	if err != nil {
+		return fmt.Errorf("lookup start vote: %v", err)
+	}
+
+	// Lookup cast votes
+	var cv []CastVote
+	err = db.
+		Where("token = ?", token).
+		Find(&cv).
+		Error
+	if err == gorm.ErrRecordNotFound {
+		// No cast votes exists. In theory, this could
+		// happen if no one were to vote on a proposal.
+		// In practice, this shouldn't happen.
+	} else if err != nil {
+		return fmt.Errorf("lookup cast votes: %v", err)
+	}
+
+	// Tally cast votes
+	tally := tallyCastVotes(cv)
+
+	// Create vote option results
+	results := newVoteOptionResults(token, sv.Options, tally)
+
+	// Determine the vote outcome
+	var approved bool
+	switch sv.Type {
+	case foneroplugin.VoteTypeApproval:
+		approved = approveVoteOptions(sv, results)
+	default:
+		approved = voteIsApproved(sv, results)
+	}
+
+	// Compute the vote results digest
+	dcv := make([]foneroplugin.CastVote, 0, len(cv))
+	for _, v := range cv {
+		dcv = append(dcv, convertCastVoteToFonero(v))
+	}
+	digest := foneroplugin.ComputeVoteResultsDigest(dcv,
+		convertVoteOptionResultsToFonero(results))
+
+	// Create a vote results entry
+	err = db.Create(&VoteResults{
+		Token:    token,
+		Approved: approved,
+		Results:  results,
+		Digest:   digest,
+	}).Error
+	if err != nil {
+		return fmt.Errorf("new vote results: %v", err)
+	}
+
+	return nil
+}
+
+// filterTokens returns the tokens that are present in both the pending and
+// the requested token lists.  The order of the pending list is preserved.
+func filterTokens(pending, requested []string) []string {
+	r := make(map[string]struct{}, len(requested))
+	for _, v := range requested {
+		r[v] = struct{}{}
 	}
-	err = d.recordsdb.Model(&c).
-		Updates(map[string]interface{}{
-			"comment":  "",
-			"censored": true,
-		}).Error
+	tokens := make([]string, 0, len(pending))
+	for _, v := range pending {
+		if _, ok := r[v]; ok {
+			tokens = append(tokens, v)
+		}
+	}
+	return tokens
+}
 
-	return replyPayload, err
+// newVoteOptionResults returns the VoteOptionResult records of the passed in
+// vote options using the passed in tally of votes, which is keyed by hex
+// encoded vote bit.
+func newVoteOptionResults(token string, options []VoteOption, tally map[string]uint64) []VoteOptionResult {
+	results := make([]VoteOptionResult, 0, len(options))
+	for _, v := range options {
+		voteBit := strconv.FormatUint(v.Bits, 16)
+		results = append(results, VoteOptionResult{
+			Key:    token + voteBit,
+			Votes:  tally[voteBit],
+			Option: v,
+		})
+	}
+	return results
 }
 
-// cmdGetComment retreives the passed in comment from the database.
-func (d *fonero) cmdGetComment(payload string) (string, error) {
-	log.Tracef("fonero cmdGetComment")
+// cmdLoadVoteResults creates vote results entries for any proposals that have
+// a finished voting period but have not yet been added to the vote results
+// table. The vote results table is lazy loaded. A dry run returns the tokens
+// of the pending proposals without creating any entries.
+func (d *fonero) cmdLoadVoteResults(payload string) (string, error) {
+	log.Tracef("cmdLoadVoteResults")
 
-	gc, err := foneroplugin.DecodeGetComment([]byte(payload))
+	lvs, err := foneroplugin.DecodeLoadVoteResults([]byte(payload))
 	if err != nil {
 		return "", err
 	}
 
-	c := Comment{
-		Key: gc.Token + gc.CommentID,
+	bestBlock, err := d.bestBlock(lvs.BestBlock)
+	if err != nil {
+		return "", err
 	}
-	err = d.recordsdb.Find(&c).Error
+
+	// Find proposals that have a finished voting period but
+	// have not yet been added to the vote results table.
+	q := `SELECT start_votes.token
+        FROM start_votes
+        LEFT OUTER JOIN vote_results
+          ON start_votes.token = vote_results.token
+          WHERE start_votes.end_height <= ?
+          AND vote_results.token IS NULL`
+	tokens, err := d.queryStrings("load vote results", q, bestBlock)
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			err = cache.ErrRecordNotFound
+		return "", fmt.Errorf("no vote results: %v", err)
+	}
+
+	if !lvs.DryRun {
+		// Only load the requested tokens if a subset was specified
+		if len(lvs.Tokens) > 0 {
+			tokens = filterTokens(tokens, lvs.Tokens)
+		}
+
+		// Create vote result entries
+		for _, v := range tokens {
+			err := d.newVoteResults(d.recordsdb, v)
+			if err != nil {
+				return "", fmt.Errorf("newVoteResults %v: %v", v, err)
+			}
 		}
-		return "", err
 	}
 
-	gcr := foneroplugin.GetCommentReply{
-		Comment: convertCommentToFonero(c),
+	// Prepare reply
+	r := foneroplugin.LoadVoteResultsReply{
+		Tokens: tokens,
 	}
-	gcrb, err := foneroplugin.EncodeGetCommentReply(gcr)
+	reply, err := foneroplugin.EncodeLoadVoteResultsReply(r)
 	if err != nil {
 		return "", err
 	}
 
-	return string(gcrb), nil
+	return string(reply), nil
 }
 
-// cmdGetComments returns all of the comments for the passed in record token.
-func (d *fonero) cmdGetComments(payload string) (string, error) {
-	log.Tracef("fonero cmdGetComments")
+// cmdExpireActiveVotes returns the tokens of the proposal votes that finished
+// after the provided from height and on or before the best block.  The vote
+// results of any of these proposals that have not yet been added to the vote
+// results table are created unless this is a dry run.
+func (d *fonero) cmdExpireActiveVotes(payload string) (string, error) {
+	log.Tracef("fonero cmdExpireActiveVotes")
 
-	gc, err := foneroplugin.DecodeGetComments([]byte(payload))
+	eav, err := foneroplugin.DecodeExpireActiveVotes([]byte(payload))
 	if err != nil {
 		return "", err
 	}
 
-	comments := make([]Comment, 0, 1024) // PNOOMA
-	err = d.recordsdb.
-		Where("token = ?", gc.Token).
-		Find(&comments).
-		Error
+	bestBlock, err := d.bestBlock(eav.BestBlock)
 	if err != nil {
 		return "", err
 	}
 
-	dpc := make([]foneroplugin.Comment, 0, len(comments))
-	for _, c := range comments {
-		dpc = append(dpc, convertCommentToFonero(c))
+	// Find the proposal votes that finished since the from height
+	q := `SELECT token
+        FROM start_votes
+        WHERE end_height > ?
+          AND end_height <= ?`
+	tokens, err := d.queryStrings("expire active votes", q,
+		eav.FromHeight, bestBlock)
+	if err != nil {
+		return "", fmt.Errorf("finished votes: %v", err)
 	}
+	sort.Strings(tokens)
+
+	if !eav.DryRun && len(tokens) > 0 {
+		// Find the finished votes that have not yet been
+		// added to the vote results table.
+		q := `SELECT start_votes.token
+          FROM start_votes
+          LEFT OUTER JOIN vote_results
+            ON start_votes.token = vote_results.token
+            WHERE start_votes.end_height > ?
+            AND start_votes.end_height <= ?
+            AND vote_results.token IS NULL`
+		pending, err := d.queryStrings("expire active votes pending", q,
+			eav.FromHeight, bestBlock)
+		if err != nil {
+			return "", fmt.Errorf("no vote results: %v", err)
+		}
 
-	gcr := foneroplugin.GetCommentsReply{
-		Comments: dpc,
+		// Create vote result entries
+		for _, v := range filterTokens(pending, tokens) {
+			err := d.newVoteResults(d.recordsdb, v)
+			if err != nil {
+				return "", fmt.Errorf("newVoteResults %v: %v", v, err)
+			}
+		}
 	}
-	gcrb, err := foneroplugin.EncodeGetCommentsReply(gcr)
+
+	// Prepare reply
+	r := foneroplugin.ExpireActiveVotesReply{
+		BestBlock: bestBlock,
+		Tokens:    tokens,
+	}
+	reply, err := foneroplugin.EncodeExpireActiveVotesReply(r)
 	if err != nil {
 		return "", err
 	}
 
-	return string(gcrb), nil
+	return string(reply), nil
 }
 
-// cmdCommentLikes returns all of the comment likes for the passed in comment.
-func (d *fonero) cmdCommentLikes(payload string) (string, error) {
-	log.Tracef("fonero cmdCommentLikes")
+// deleteVoteResults deletes the VoteResults record of a proposal along with
+// its VoteOptionResult records.  The vote option results are not removed by
+// the database when a vote results record is deleted so they must always be
+// deleted explicitly to prevent orphaned rows.  This function has a database
+// parameter so that it can be called inside of a transaction when required.
+func (d *fonero) deleteVoteResults(db *gorm.DB, token string) error {
+	err := db.Where("token = ?", token).
+		Delete(VoteOptionResult{}).
+		Error
+	if err != nil {
+		return fmt.Errorf("delete vote option results: %v", err)
+	}
+	err = db.Where("token = ?", token).
+		Delete(VoteResults{}).
+		Error
+	if err != nil {
+		return fmt.Errorf("delete vote results: %v", err)
+	}
+	return nil
+}
 
-	cl, err := foneroplugin.DecodeCommentLikes([]byte(payload))
+// orphanedVoteOptionResults returns the keys of the VoteOptionResult records
+// that do not have a parent VoteResults record.
+func (d *fonero) orphanedVoteOptionResults() ([]string, error) {
+	q := `SELECT vote_option_results.key
+        FROM vote_option_results
+        LEFT OUTER JOIN vote_results
+          ON vote_option_results.token = vote_results.token
+          WHERE vote_results.token IS NULL`
+	return d.queryStrings("orphaned vote option results", q)
+}
+
+// removeOrphanedVoteOptionResults deletes the VoteOptionResult records that
+// do not have a parent VoteResults record and returns the keys of the records
+// that were deleted.  Orphaned vote option results would otherwise be counted
+// in vote option aggregates.
+func (d *fonero) removeOrphanedVoteOptionResults() ([]string, error) {
+	log.Tracef("fonero removeOrphanedVoteOptionResults")
+
+	keys, err := d.orphanedVoteOptionResults()
 	if err != nil {
-		return "", err
+		return nil, fmt.Errorf("orphaned vote option results: %v", err)
+	}
+	if len(keys) == 0 {
+		return keys, nil
 	}
 
-	likes := make([]LikeComment, 1024) // PNOOMA
 	err = d.recordsdb.
-		Where("token = ? AND comment_id = ?", cl.Token, cl.CommentID).
-		Find(&likes).
+		Where("key IN (?)", keys).
+		Delete(VoteOptionResult{}).
 		Error
 	if err != nil {
-		return "", err
+		return nil, fmt.Errorf("delete vote option results: %v", err)
 	}
 
-	lc := make([]foneroplugin.LikeComment, 0, len(likes))
-	for _, v := range likes {
-		lc = append(lc, convertLikeCommentToFonero(v))
-	}
+	return keys, nil
+}
 
-	clr := foneroplugin.CommentLikesReply{
-		CommentLikes: lc,
+// replaceVoteResults deletes any existing vote results of a proposal and
+// creates them again from the cast votes in a single transaction.  This makes
+// it safe to create the vote results of a proposal that another caller may be
+// creating at the same time.
+func (d *fonero) replaceVoteResults(token string) error {
+	tx := d.recordsdb.Begin()
+	err := d.deleteVoteResults(tx, token)
+	if err != nil {
+		tx.Rollback()
+		return err
 	}
-	clrb, err := foneroplugin.EncodeCommentLikesReply(clr)
+	err = d.newVoteResults(tx, token)
 	if err != nil {
-		return "", err
+		tx.Rollback()
+		return fmt.Errorf("newVoteResults: %v", err)
 	}
 
-	return string(clrb), nil
+	// Commit transaction
+	err = tx.Commit().Error
+	if err != nil {
+		return fmt.Errorf("commit transaction: %v", err)
+	}
+
+	return nil
 }
 
-// cmdProposalLikes returns all of the comment likes for all comments of the
-// passed in record token.
-func (d *fonero) cmdProposalCommentsLikes(payload string) (string, error) {
-	log.Tracef("fonero cmdProposalCommentsLikes")
+// cmdRecomputeVoteResults deletes the vote results of a single proposal and
+// creates them again from the cast votes.  This allows a proposal tally to be
+// corrected without rebuilding the cache.  The proposal vote must have
+// finished.
+func (d *fonero) cmdRecomputeVoteResults(payload string) (string, error) {
+	log.Tracef("fonero cmdRecomputeVoteResults")
 
-	cl, err := foneroplugin.DecodeGetProposalCommentsLikes([]byte(payload))
+	rvr, err := foneroplugin.DecodeRecomputeVoteResults([]byte(payload))
 	if err != nil {
 		return "", err
 	}
 
-	likes := make([]LikeComment, 0, 1024) // PNOOMA
+	bestBlock, err := d.bestBlock(rvr.BestBlock)
+	if err != nil {
+		return "", err
+	}
+
+	// Ensure the proposal vote has finished
+	var sv StartVote
 	err = d.recordsdb.
-		Where("token = ?", cl.Token).
-		Find(&likes).
+		Where("token = ?", rvr.Token).
+		Find(&sv).
 		Error
 	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = cache.ErrRecordNotFound
+		}
 		return "", err
 	}
-
-	lc := make([]foneroplugin.LikeComment, 0, len(likes))
-	for _, v := range likes {
-		lc = append(lc, convertLikeCommentToFonero(v))
+	if sv.EndHeight > bestBlock {
+		return "", fmt.Errorf("vote has not finished: token %v end height "+
+			"%v best block %v", rvr.Token, sv.EndHeight, bestBlock)
 	}
 
-	clr := foneroplugin.GetProposalCommentsLikesReply{
-		CommentsLikes: lc,
-	}
-	clrb, err := foneroplugin.EncodeGetProposalCommentsLikesReply(clr)
+	// The vote results cannot be recomputed once the cast votes
+	// have been archived.
+	archived, err := d.castVotesArchived(rvr.Token)
 	if err != nil {
 		return "", err
 	}
+	if archived {
+		return "", fmt.Errorf("cast votes have been archived: token %v",
+			rvr.Token)
+	}
 
-	return string(clrb), nil
-}
+	// Recreate the vote results
+	err = d.replaceVoteResults(rvr.Token)
+	if err != nil {
+		return "", err
+	}
 
-// newAuthorizeVote creates an AuthorizeVote record and inserts it into the
-// database.  If a previous AuthorizeVote record exists for the passed in
-// proposal and version, it will be deleted before the new AuthorizeVote record
-// is inserted.
-//
-// This function must be called within a transaction.
-func (d *fonero) newAuthorizeVote(tx *gorm.DB, av AuthorizeVote) error {
-	// Delete authorize vote if one exists for this version
-	err := tx.Where("key = ?", av.Key).
-		Delete(AuthorizeVote{}).
+	// Lookup the recomputed vote results
+	var vr VoteResults
+	err = d.recordsdb.
+		Where("token = ?", rvr.Token).
+		Preload("Results").
+		Preload("Results.Option").
+		Find(&vr).
 		Error
 	if err != nil {
-		return fmt.Errorf("delete authorize vote: %v", err)
+		return "", fmt.Errorf("lookup vote results: %v", err)
 	}
 
-	// Add new authorize vote
-	err = tx.Create(&av).Error
+	// Prepare reply
+	r := foneroplugin.RecomputeVoteResultsReply{
+		Approved: vr.Approved,
+		Results:  convertVoteOptionResultsToFonero(vr.Results),
+	}
+	reply, err := foneroplugin.EncodeRecomputeVoteResultsReply(r)
 	if err != nil {
-		return fmt.Errorf("create authorize vote: %v", err)
+		return "", err
 	}
 
-	return nil
+	return string(reply), nil
 }
 
-// cmdAuthorizeVote creates a AuthorizeVote record using the passed in payloads
-// and inserts it into the database.
-func (d *fonero) cmdAuthorizeVote(cmdPayload, replyPayload string) (string, error) {
-	log.Tracef("fonero cmdAuthorizeVote")
+// cmdVoteResultsByStatus returns the final vote results of all proposals whose
+// vote was approved, or of all proposals whose vote was rejected, ordered by
+// token.  Only proposals whose vote results have been loaded are included.
+func (d *fonero) cmdVoteResultsByStatus(payload string) (string, error) {
+	log.Tracef("fonero cmdVoteResultsByStatus")
 
-	av, err := foneroplugin.DecodeAuthorizeVote([]byte(cmdPayload))
-	if err != nil {
-		return "", err
-	}
-	avr, err := foneroplugin.DecodeAuthorizeVoteReply([]byte(replyPayload))
+	vrbs, err := foneroplugin.DecodeVoteResultsByStatus([]byte(payload))
 	if err != nil {
 		return "", err
 	}
 
-	v, err := strconv.ParseUint(avr.RecordVersion, 10, 64)
+	var vrs []VoteResults
+	err = d.recordsdb.
+		Where("approved = ?", vrbs.Approved).
+		Order("token").
+		Preload("Results").
+		Preload("Results.Option").
+		Find(&vrs).
+		Error
 	if err != nil {
-		return "", fmt.Errorf("parse version '%v' failed: %v",
-			avr.RecordVersion, err)
+		return "", fmt.Errorf("lookup vote results: %v", err)
 	}
 
-	// Run update in a transaction
-	a := convertAuthorizeVoteFromFonero(*av, *avr, v)
-	tx := d.recordsdb.Begin()
-	err = d.newAuthorizeVote(tx, a)
-	if err != nil {
-		tx.Rollback()
-		return "", fmt.Errorf("newAuthorizeVote: %v", err)
+	results := make([]foneroplugin.TokenVoteResults, 0, len(vrs))
+	for _, v := range vrs {
+		results = append(results, foneroplugin.TokenVoteResults{
+			Token:    v.Token,
+			Approved: v.Approved,
+			Results:  convertVoteOptionResultsToFonero(v.Results),
+		})
 	}
 
-	// Commit transaction
-	err = tx.Commit().Error
+	reply, err := foneroplugin.EncodeVoteResultsByStatusReply(
+		foneroplugin.VoteResultsByStatusReply{
+			VoteResults: results,
+		})
 	if err != nil {
-		return "", fmt.Errorf("commit transaction: %v", err)
+		return "", err
 	}
 
-	return replyPayload, nil
-}
-
-// newStartVote inserts a StartVote record into the database.  This function
-// has a database parameter so that it can be called inside of a transaction
-// when required.
-func (d *fonero) newStartVote(db *gorm.DB, sv StartVote) error {
-	return db.Create(&sv).Error
+	return string(reply), nil
 }
 
-// cmdStartVote creates a StartVote record using the passed in payloads and
-// inserts it into the database.
-func (d *fonero) cmdStartVote(cmdPayload, replyPayload string) (string, error) {
-	log.Tracef("fonero cmdStartVote")
+// cmdVoteResultsDigest returns the vote results digest of the passed in record
+// token.  The digest is computed when the vote results are created, so it is
+// still available once the cast votes have been archived.  A
+// cache.ErrRecordNotFound is returned if the vote results of the record have
+// not been loaded.
+func (d *fonero) cmdVoteResultsDigest(payload string) (string, error) {
+	log.Tracef("fonero cmdVoteResultsDigest")
 
-	sv, err := foneroplugin.DecodeStartVote([]byte(cmdPayload))
+	vrd, err := foneroplugin.DecodeVoteResultsDigest([]byte(payload))
 	if err != nil {
 		return "", err
 	}
-	svr, err := foneroplugin.DecodeStartVoteReply([]byte(replyPayload))
+
+	var vr VoteResults
+	err = d.recordsdb.
+		Where("token = ?", vrd.Token).
+		Find(&vr).
+		Error
 	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			err = cache.ErrRecordNotFound
+		}
 		return "", err
 	}
 
-	endHeight, err := strconv.ParseUint(svr.EndHeight, 10, 64)
+	reply, err := foneroplugin.EncodeVoteResultsDigestReply(
+		foneroplugin.VoteResultsDigestReply{
+			Digest: vr.Digest,
+		})
 	if err != nil {
-		return "", fmt.Errorf("parse end height '%v': %v",
-			svr.EndHeight, err)
+		return "", err
 	}
 
-	s := convertStartVoteFromFonero(*sv, *svr, endHeight)
-	err = d.newStartVote(d.recordsdb, s)
-	if err != nil {
-		return "", err
+	return string(reply), nil
+}
+
+// castVotesArchived returns whether the cast votes of the passed in token have
+// been archived.
+func (d *fonero) castVotesArchived(token string) (bool, error) {
+	var a CastVoteArchive
+	err := d.recordsdb.
+		Where("token = ?", token).
+		Find(&a).
+		Error
+	switch {
+	case err == gorm.ErrRecordNotFound:
+		return false, nil
+	case err != nil:
+		return false, fmt.Errorf("lookup cast vote archive: %v", err)
 	}
+	return true, nil
+}
 
-	return replyPayload, nil
+// castVotesDigest returns the digest of the passed in cast votes.  See the
+// foneroplugin ArchiveProposalVotes command for the format of the digest.
+func castVotesDigest(votes []CastVote) string {
+	keys := make([]string, 0, len(votes))
+	for _, v := range votes {
+		keys = append(keys, v.Token+v.Ticket+v.VoteBit+v.Signature)
+	}
+	return foneroplugin.ComputeKeysDigest(keys)
 }
 
-// cmdVoteDetails returns the AuthorizeVote and StartVote records for the
-// passed in record token.
-func (d *fonero) cmdVoteDetails(payload string) (string, error) {
-	log.Tracef("fonero cmdVoteDetails")
+// cmdArchiveProposalVotes replaces the cast votes of a finished proposal vote
+// with a digest of the cast votes.  The vote results must have been loaded
+// so that the tally is not lost.  The cast vote counters are kept.  Archiving
+// the cast votes of a proposal whose cast votes have already been archived
+// returns the existing archive.
+func (d *fonero) cmdArchiveProposalVotes(payload string) (string, error) {
+	log.Tracef("fonero cmdArchiveProposalVotes")
 
-	vd, err := foneroplugin.DecodeVoteDetails([]byte(payload))
+	apv, err := foneroplugin.DecodeArchiveProposalVotes([]byte(payload))
 	if err != nil {
-		return "", nil
+		return "", err
 	}
 
-	// Lookup the most recent version of the record
-	var r Record
+	bestBlock, err := d.bestBlock(apv.BestBlock)
+	if err != nil {
+		return "", err
+	}
+
+	// Ensure the proposal vote has finished
+	var sv StartVote
 	err = d.recordsdb.
-		Where("records.token = ?", vd.Token).
-		Order("records.version desc").
-		Limit(1).
-		Find(&r).
+		Where("token = ?", apv.Token).
+		Find(&sv).
 		Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
@@ -371,323 +4372,426 @@ func (d *fonero) cmdVoteDetails(payload string) (string, error) {
 		}
 		return "", err
 	}
+	if sv.EndHeight > bestBlock {
+		return "", fmt.Errorf("vote has not finished: token %v end height "+
+			"%v best block %v", apv.Token, sv.EndHeight, bestBlock)
+	}
 
-	// Lookup authorize vote
-	var av AuthorizeVote
-	key := vd.Token + strconv.FormatUint(r.Version, 10)
+	// Ensure the vote results have been loaded
+	var vr VoteResults
 	err = d.recordsdb.
-		Where("key = ?", key).
-		Find(&av).
+		Where("token = ?", apv.Token).
+		Find(&vr).
 		Error
 	if err == gorm.ErrRecordNotFound {
-		// An authorize vote may note exist. This is ok.
+		return "", fmt.Errorf("vote results not loaded: token %v",
+			apv.Token)
 	} else if err != nil {
-		return "", fmt.Errorf("authorize vote lookup failed: %v", err)
+		return "", fmt.Errorf("lookup vote results: %v", err)
 	}
 
-	// Lookup start vote
-	var sv StartVote
+	// Return the existing archive if the cast votes have already
+	// been archived.
+	var a CastVoteArchive
 	err = d.recordsdb.
-		Where("token = ?", vd.Token).
-		Preload("Options").
-		Find(&sv).
+		Where("token = ?", apv.Token).
+		Find(&a).
 		Error
 	if err == gorm.ErrRecordNotFound {
-		// A start vote may note exist. This is ok.
+		// Lookup the cast votes that will be archived
+		var cv []CastVote
+		err = d.recordsdb.
+			Where("token = ?", apv.Token).
+			Find(&cv).
+			Error
+		if err != nil {
+			return "", fmt.Errorf("lookup cast votes: %v", err)
+		}
+
+		a = CastVoteArchive{
+			Token:     apv.Token,
+			Digest:    castVotesDigest(cv),
+			Votes:     uint64(len(cv)),
+			Timestamp: d.now().Unix(),
+		}
+
+		// Store the archive and delete the cast votes in a
+		// transaction.
+		tx := d.recordsdb.Begin()
+		err = tx.Create(&a).Error
+		if err != nil {
+			tx.Rollback()
+			return "", fmt.Errorf("create cast vote archive: %v", err)
+		}
+		err = tx.Where("token = ?", apv.Token).
+			Delete(CastVote{}).
+			Error
+		if err != nil {
+			tx.Rollback()
+			return "", fmt.Errorf("delete cast votes: %v", err)
+		}
+		err = tx.Commit().Error
+		if err != nil {
+			return "", fmt.Errorf("commit transaction: %v", err)
+		}
+
+		log.Infof("Archived %v cast votes of %v", a.Votes, apv.Token)
 	} else if err != nil {
-		return "", fmt.Errorf("start vote lookup failed: %v", err)
+		return "", fmt.Errorf("lookup cast vote archive: %v", err)
 	}
 
-	// Prepare reply
-	dav := convertAuthorizeVoteToFonero(av)
-	dsv, dsvr := convertStartVoteToFonero(sv)
-	vdr := foneroplugin.VoteDetailsReply{
-		AuthorizeVote:  dav,
-		StartVote:      dsv,
-		StartVoteReply: dsvr,
-	}
-	vdrb, err := foneroplugin.EncodeVoteDetailsReply(vdr)
+	reply, err := foneroplugin.EncodeArchiveProposalVotesReply(
+		foneroplugin.ArchiveProposalVotesReply{
+			Digest: a.Digest,
+			Votes:  a.Votes,
+		})
 	if err != nil {
 		return "", err
 	}
 
-	return string(vdrb), nil
-}
-
-// newCastVote inserts a CastVote record into the database.  This function has
-// a database parameter so that it can be called inside of a transaction when
-// required.
-func (d *fonero) newCastVote(db *gorm.DB, cv CastVote) error {
-	return db.Create(&cv).Error
+	return string(reply), nil
 }
 
-// cmdNewBallot creates CastVote records using the passed in payloads and
-// inserts them into the database.
-func (d *fonero) cmdNewBallot(cmdPayload, replyPayload string) (string, error) {
-	log.Tracef("fonero cmdNewBallot")
+// queryTokenCounts returns the counts that are selected by the passed in raw
+// query, mapped by token.  The query must select the token column followed by
+// the count.  The label identifies the query in the slow query log.
+func (d *fonero) queryTokenCounts(label, q string, args ...interface{}) (map[string]uint64, error) {
+	defer d.timeQuery(label)()
 
-	b, err := foneroplugin.DecodeBallot([]byte(cmdPayload))
+	rows, err := d.recordsdb.Raw(q, args...).Rows()
 	if err != nil {
-		return "", err
+		return nil, err
 	}
+	defer rows.Close()
 
-	// Add votes to database
-	tx := d.recordsdb.Begin()
-	for _, v := range b.Votes {
-		cv := convertCastVoteFromFonero(v)
-		err = d.newCastVote(tx, cv)
+	counts := make(map[string]uint64, 1024) // PNOOMA
+	for rows.Next() {
+		var (
+			token string
+			count uint64
+		)
+		err := rows.Scan(&token, &count)
 		if err != nil {
-			tx.Rollback()
-			return "", err
+			return nil, err
 		}
+		counts[token] = count
 	}
 
-	err = tx.Commit().Error
-	if err != nil {
-		return "", fmt.Errorf("commit transaction failed: %v", err)
+	return counts, rows.Err()
+}
+
+// proposalActivity merges the passed in comment and cast vote counts into a
+// list of proposal activity that is sorted by token.
+func proposalActivity(comments, votes map[string]uint64) []foneroplugin.ProposalActivity {
+	tokens := make(map[string]struct{}, len(comments)+len(votes))
+	for k := range comments {
+		tokens[k] = struct{}{}
+	}
+	for k := range votes {
+		tokens[k] = struct{}{}
 	}
 
-	return replyPayload, nil
+	pa := make([]foneroplugin.ProposalActivity, 0, len(tokens))
+	for k := range tokens {
+		pa = append(pa, foneroplugin.ProposalActivity{
+			Token:     k,
+			Comments:  comments[k],
+			CastVotes: votes[k],
+		})
+	}
+	sort.Slice(pa, func(i, j int) bool {
+		return pa[i].Token < pa[j].Token
+	})
+
+	return pa
 }
 
-// cmdProposalVotes returns the StartVote record and all CastVote records for
-// the passed in record token.
-func (d *fonero) cmdProposalVotes(payload string) (string, error) {
-	log.Tracef("fonero cmdProposalVotes")
+// cmdActivityWindow returns the number of comments and cast votes of every
+// proposal that had activity within the requested timestamp range.
+func (d *fonero) cmdActivityWindow(payload string) (string, error) {
+	log.Tracef("fonero cmdActivityWindow")
 
-	vr, err := foneroplugin.DecodeVoteResults([]byte(payload))
+	aw, err := foneroplugin.DecodeActivityWindow([]byte(payload))
 	if err != nil {
 		return "", err
 	}
 
-	// Lookup start vote
-	var sv StartVote
-	err = d.recordsdb.
-		Where("token = ?", vr.Token).
-		Preload("Options").
-		Find(&sv).
-		Error
-	if err == gorm.ErrRecordNotFound {
-		// A start vote may note exist if the voting period has not
-		// been started yet. This is ok.
-	} else if err != nil {
-		return "", fmt.Errorf("start vote lookup failed: %v", err)
-	}
-
-	// Lookup all cast votes
-	var cv []CastVote
-	err = d.recordsdb.
-		Where("token = ?", vr.Token).
-		Find(&cv).
-		Error
-	if err == gorm.ErrRecordNotFound {
-		// No cast votes may exist yet. This is ok.
-	} else if err != nil {
-		return "", fmt.Errorf("cast votes lookup failed: %v", err)
+	if aw.Start > aw.End {
+		return "", fmt.Errorf("invalid timestamp range: start %v > end %v",
+			aw.Start, aw.End)
 	}
 
-	// Prepare reply
-	dsv, _ := convertStartVoteToFonero(sv)
-	dcv := make([]foneroplugin.CastVote, 0, len(cv))
-	for _, v := range cv {
-		dcv = append(dcv, convertCastVoteToFonero(v))
+	q := `SELECT token, COUNT(*)
+        FROM comments
+        WHERE timestamp BETWEEN ? AND ?
+        GROUP BY token`
+	comments, err := d.queryTokenCounts("activity window comments", q,
+		aw.Start, aw.End)
+	if err != nil {
+		return "", fmt.Errorf("comment counts: %v", err)
 	}
 
-	vrr := foneroplugin.VoteResultsReply{
-		StartVote: dsv,
-		CastVotes: dcv,
+	q = `SELECT token, COUNT(*)
+        FROM cast_votes
+        WHERE timestamp BETWEEN ? AND ?
+        GROUP BY token`
+	votes, err := d.queryTokenCounts("activity window cast votes", q,
+		aw.Start, aw.End)
+	if err != nil {
+		return "", fmt.Errorf("cast vote counts: %v", err)
 	}
 
-	vrrb, err := foneroplugin.EncodeVoteResultsReply(vrr)
+	reply, err := foneroplugin.EncodeActivityWindowReply(
+		foneroplugin.ActivityWindowReply{
+			Activity: proposalActivity(comments, votes),
+		})
 	if err != nil {
 		return "", err
 	}
 
-	return string(vrrb), nil
+	return string(reply), nil
 }
 
-// cmdInventory returns the fonero plugin inventory.
-func (d *fonero) cmdInventory() (string, error) {
-	log.Tracef("fonero cmdInventory")
-
-	// XXX the only part of the fonero plugin inventory that we return
-	// at the moment is comments. This is because comments are the only
-	// thing politeiawww currently needs on startup.
+// cmdCommentRate returns the number of comments of a record that were received
+// within the requested window, which ends now, along with the total number of
+// comments of the record.
+func (d *fonero) cmdCommentRate(payload string) (string, error) {
+	log.Tracef("fonero cmdCommentRate")
 
-	// Get all comments
-	var c []Comment
-	err := d.recordsdb.Find(&c).Error
+	cr, err := foneroplugin.DecodeCommentRate([]byte(payload))
 	if err != nil {
 		return "", err
 	}
 
-	dc := make([]foneroplugin.Comment, 0, len(c))
-	for _, v := range c {
-		dc = append(dc, convertCommentToFonero(v))
+	if cr.Window <= 0 {
+		return "", fmt.Errorf("invalid window: %v", cr.Window)
 	}
 
-	// Prepare inventory reply
-	ir := foneroplugin.InventoryReply{
-		Comments: dc,
+	start := time.Now().Unix() - cr.Window
+	q := `SELECT COUNT(CASE WHEN timestamp >= ? THEN 1 END), COUNT(*)
+        FROM comments
+        WHERE token = ?`
+	defer d.timeQuery("comment rate")()
+	var comments, total uint64
+	err = d.recordsdb.
+		Raw(q, start, cr.Token).
+		Row().
+		Scan(&comments, &total)
+	if err != nil {
+		return "", fmt.Errorf("comment rate: %v", err)
 	}
-	irb, err := foneroplugin.EncodeInventoryReply(ir)
+
+	reply, err := foneroplugin.EncodeCommentRateReply(
+		foneroplugin.CommentRateReply{
+			Start:    start,
+			Comments: comments,
+			Total:    total,
+		})
 	if err != nil {
 		return "", err
 	}
 
-	return string(irb), err
+	return string(reply), nil
 }
 
-// newVoteResults creates a VoteResults record for a proposal and inserts it
-// into the cache. A VoteResults record should only be created for proposals
-// once the voting period has ended.
-func (d *fonero) newVoteResults(token string) error {
-	log.Tracef("newVoteResults %v", token)
+// countDelta returns the difference between the passed in cache and source
+// counts of a table.  The table diverges when the absolute difference exceeds
+// the threshold.
+func countDelta(table string, cacheCount, sourceCount, threshold uint64) foneroplugin.CountDelta {
+	delta := int64(cacheCount) - int64(sourceCount)
+	abs := delta
+	if abs < 0 {
+		abs = -abs
+	}
+	return foneroplugin.CountDelta{
+		Table:    table,
+		Cache:    cacheCount,
+		Source:   sourceCount,
+		Delta:    delta,
+		Diverged: uint64(abs) > threshold,
+	}
+}
 
-	// Lookup start vote
-	var sv StartVote
-	err := d.recordsdb.
-		Where("token = ?", token).
-		Preload("Options").
-		Find(&sv).
-		Error
+// cmdVerifyCounts compares the number of records, comments and cast votes in
+// the cache against the passed in source counts and returns the difference of
+// each.
+func (d *fonero) cmdVerifyCounts(payload string) (string, error) {
+	log.Tracef("fonero cmdVerifyCounts")
+
+	vc, err := foneroplugin.DecodeVerifyCounts([]byte(payload))
 	if err != nil {
-		return fmt.Errorf("lookup start vote: %v", err)
+		return "", err
 	}
 
-	// Lookup cast votes
-	var cv []CastVote
+	// Archived cast votes have been deleted from the cast votes
+	// table but are still part of the source.
+	q := `SELECT
+          (SELECT COUNT(DISTINCT token) FROM records),
+          (SELECT COUNT(*) FROM comments),
+          (SELECT COUNT(*) FROM cast_votes) +
+            (SELECT COALESCE(SUM(votes), 0) FROM cast_vote_archives)`
+	defer d.timeQuery("verify counts")()
+	var records, comments, castVotes uint64
 	err = d.recordsdb.
-		Where("token = ?", token).
-		Find(&cv).
-		Error
-	if err == gorm.ErrRecordNotFound {
-		// No cast votes exists. In theory, this could
-		// happen if no one were to vote on a proposal.
-		// In practice, this shouldn't happen.
-	} else if err != nil {
-		return fmt.Errorf("lookup cast votes: %v", err)
+		Raw(q).
+		Row().
+		Scan(&records, &comments, &castVotes)
+	if err != nil {
+		return "", fmt.Errorf("verify counts: %v", err)
 	}
 
-	// Tally cast votes
-	tally := make(map[string]uint64) // [voteBit]voteCount
-	for _, v := range cv {
-		tally[v.VoteBit]++
+	deltas := []foneroplugin.CountDelta{
+		countDelta(foneroplugin.VerifyCountsRecords, records,
+			vc.Records, vc.Threshold),
+		countDelta(foneroplugin.VerifyCountsComments, comments,
+			vc.Comments, vc.Threshold),
+		countDelta(foneroplugin.VerifyCountsCastVotes, castVotes,
+			vc.CastVotes, vc.Threshold),
+	}
+	var diverged bool
+	for _, v := range deltas {
+		if v.Diverged {
+			log.Warnf("Cache %v count diverged from the source: "+
+				"cache %v source %v", v.Table, v.Cache, v.Source)
+			diverged = true
+		}
 	}
 
-	// Create vote option results
-	results := make([]VoteOptionResult, 0, len(sv.Options))
-	for _, v := range sv.Options {
-		voteBit := strconv.FormatUint(v.Bits, 16)
-		voteCount := tally[voteBit]
-
-		results = append(results, VoteOptionResult{
-			Key:    token + voteBit,
-			Votes:  voteCount,
-			Option: v,
+	reply, err := foneroplugin.EncodeVerifyCountsReply(
+		foneroplugin.VerifyCountsReply{
+			Deltas:   deltas,
+			Diverged: diverged,
 		})
+	if err != nil {
+		return "", err
 	}
 
-	// Check whether vote was approved
-	var total uint64
-	for _, v := range results {
-		total += v.Votes
-	}
+	return string(reply), nil
+}
 
-	eligible := len(strings.Split(sv.EligibleTickets, ","))
-	quorum := uint64(float64(sv.QuorumPercentage) / 100 * float64(eligible))
-	pass := uint64(float64(sv.PassPercentage) / 100 * float64(total))
+// tokenInventoryQuery describes the query that selects the tokens of a single
+// token inventory category.  The query must select the token column followed
+// by the sort key column and must contain a WHERE clause.  Tokens are sorted
+// by the sort key in descending order.
+type tokenInventoryQuery struct {
+	query string        // Query without ORDER BY and LIMIT clauses
+	token string        // Token column
+	key   string        // Sort key column
+	args  []interface{} // Query arguments
+}
 
-	// XXX: this only supports proposals with yes/no
-	// voting options. Multiple voting option support
-	// will need to be added in the future.
-	var approvedVotes uint64
-	for _, v := range results {
-		if v.Option.ID == voteOptionIDApproved {
-			approvedVotes = v.Votes
-		}
+// tokenInventoryEntry is a single row that is returned by a token inventory
+// query.
+type tokenInventoryEntry struct {
+	token string
+	key   int64
+}
+
+// tokenInventoryCursor returns the pagination cursor that refers to the
+// entries that come after the passed in entry.
+func tokenInventoryCursor(e tokenInventoryEntry) string {
+	return strconv.FormatInt(e.key, 10) + ":" + e.token
+}
+
+// parseTokenInventoryCursor parses a pagination cursor into the sort key and
+// token of the last entry of the previous page.
+func parseTokenInventoryCursor(cursor string) (int64, string, error) {
+	s := strings.SplitN(cursor, ":", 2)
+	if len(s) != 2 || s[1] == "" {
+		return 0, "", fmt.Errorf("invalid cursor '%v'", cursor)
+	}
+	key, err := strconv.ParseInt(s[0], 10, 64)
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid cursor '%v': %v", cursor, err)
 	}
+	return key, s[1], nil
+}
 
-	var approved bool
-	switch {
-	case total < quorum:
-		// Quorum not met
-	case approvedVotes < pass:
-		// Pass percentage not met
-	default:
-		// Vote was approved
-		approved = true
+// page returns the query and query arguments that select the page of tokens
+// that comes after the passed in cursor.  One more entry than the limit is
+// selected so that it can be determined whether there is a next page.  A
+// limit of zero selects all remaining tokens.
+func (q tokenInventoryQuery) page(cursor string, limit uint) (string, []interface{}, error) {
+	query := q.query
+	args := make([]interface{}, 0, len(q.args)+4)
+	args = append(args, q.args...)
+
+	if cursor != "" {
+		key, token, err := parseTokenInventoryCursor(cursor)
+		if err != nil {
+			return "", nil, err
+		}
+		query += fmt.Sprintf(" AND (%v < ? OR (%v = ? AND %v < ?))",
+			q.key, q.key, q.token)
+		args = append(args, key, key, token)
 	}
 
-	// Create a vote results entry
-	err = d.recordsdb.Create(&VoteResults{
-		Token:    token,
-		Approved: approved,
-		Results:  results,
-	}).Error
-	if err != nil {
-		return fmt.Errorf("new vote results: %v", err)
+	query += fmt.Sprintf(" ORDER BY %v DESC, %v DESC", q.key, q.token)
+
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit+1)
 	}
 
-	return nil
+	return query, args, nil
 }
 
-// cmdLoadVoteResults creates vote results entries for any proposals that have
-// a finished voting period but have not yet been added to the vote results
-// table. The vote results table is lazy loaded.
-func (d *fonero) cmdLoadVoteResults(payload string) (string, error) {
-	log.Tracef("cmdLoadVoteResults")
+// pageTokenInventory returns the tokens of the passed in entries, truncated to
+// the limit, and the cursor of the next page.  The cursor is empty when there
+// is no next page.
+func pageTokenInventory(entries []tokenInventoryEntry, limit uint) ([]string, string) {
+	var next string
+	if limit > 0 && uint(len(entries)) > limit {
+		entries = entries[:limit]
+		next = tokenInventoryCursor(entries[limit-1])
+	}
 
-	lvs, err := foneroplugin.DecodeLoadVoteResults([]byte(payload))
-	if err != nil {
-		return "", err
+	tokens := make([]string, 0, len(entries))
+	for _, v := range entries {
+		tokens = append(tokens, v.token)
 	}
 
-	// Find proposals that have a finished voting period but
-	// have not yet been added to the vote results table.
-	q := `SELECT start_votes.token
-        FROM start_votes
-        LEFT OUTER JOIN vote_results
-          ON start_votes.token = vote_results.token
-          WHERE start_votes.end_height <= ?
-          AND vote_results.token IS NULL`
-	rows, err := d.recordsdb.Raw(q, lvs.BestBlock).Rows()
+	return tokens, next
+}
+
+// queryTokenInventory runs the passed in token inventory query and returns
+// the selected entries.
+func (d *fonero) queryTokenInventory(q string, args ...interface{}) ([]tokenInventoryEntry, error) {
+	defer d.timeQuery("token inventory")()
+
+	rows, err := d.recordsdb.Raw(q, args...).Rows()
 	if err != nil {
-		return "", fmt.Errorf("no vote results: %v", err)
+		return nil, err
 	}
 	defer rows.Close()
 
-	var token string
-	tokens := make([]string, 0, 1024)
+	entries := make([]tokenInventoryEntry, 0, 1024) // PNOOMA
 	for rows.Next() {
-		rows.Scan(&token)
-		tokens = append(tokens, token)
-	}
-
-	// Create vote result entries
-	for _, v := range tokens {
-		err := d.newVoteResults(v)
+		var e tokenInventoryEntry
+		err := rows.Scan(&e.token, &e.key)
 		if err != nil {
-			return "", fmt.Errorf("newVoteResults %v: %v", v, err)
+			return nil, err
 		}
+		entries = append(entries, e)
 	}
 
-	// Prepare reply
-	r := foneroplugin.LoadVoteResultsReply{}
-	reply, err := foneroplugin.EncodeLoadVoteResultsReply(r)
-	if err != nil {
-		return "", err
-	}
-
-	return string(reply), nil
+	return entries, rows.Err()
 }
 
 // cmdTokenInventory returns the tokens of all records in the cache,
-// categorized by stage of the voting process.
+// categorized by stage of the voting process.  Proposals that have finished
+// voting but that do not have vote results yet cause the command to fail
+// unless the computevoteresults plugin setting is enabled, in which case their
+// vote results are computed first.
 func (d *fonero) cmdTokenInventory(payload string) (string, error) {
 	log.Tracef("fonero cmdTokenInventory")
 
-	ti, err := foneroplugin.DecodeTokenInventory([]byte(payload))
+	ti, err := foneroplugin.DecodeTokenInventory([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	bestBlock, err := d.bestBlock(ti.BestBlock)
 	if err != nil {
 		return "", err
 	}
@@ -695,37 +4799,52 @@ func (d *fonero) cmdTokenInventory(payload string) (string, error) {
 	// The token inventory call cannot be completed if there
 	// are any proposals that have finished voting but that
 	// don't have an entry in the vote results table yet.
-	// Fail here if any are found.
+	// Compute the missing vote results if the plugin is
+	// configured to do so. Fail otherwise.
 	q := `SELECT start_votes.token
         FROM start_votes
         LEFT OUTER JOIN vote_results
           ON start_votes.token = vote_results.token
           WHERE start_votes.end_height <= ?
           AND vote_results.token IS NULL`
-	rows, err := d.recordsdb.Raw(q, ti.BestBlock).Rows()
+	missing, err := d.queryStrings("token inventory missing vote results",
+		q, bestBlock)
 	if err != nil {
 		return "", fmt.Errorf("no vote results: %v", err)
 	}
-	defer rows.Close()
-
-	var token string
-	missing := make([]string, 0, 1024)
-	for rows.Next() {
-		rows.Scan(&token)
-		missing = append(missing, token)
-	}
 
-	if len(missing) > 0 {
+	if len(missing) > 0 && !d.computeVoteResults {
 		// Return a ErrRecordNotFound to indicate one
 		// or more vote result records were not found.
 		return "", cache.ErrRecordNotFound
 	}
+	if len(missing) > 0 {
+		// Computing the vote results writes to the fonero plugin
+		// tables, so it waits for any build that is in progress
+		// to finish the same way that the write commands do.
+		d.buildMtx.RLock()
+		for _, v := range missing {
+			// The vote results are replaced in a transaction
+			// since concurrent inventory requests may compute
+			// the vote results of the same proposal.
+			log.Debugf("cmdTokenInventory: computing vote "+
+				"results %v", v)
+			err := d.replaceVoteResults(v)
+			if err != nil {
+				d.buildMtx.RUnlock()
+				return "", fmt.Errorf("replaceVoteResults %v: %v",
+					v, err)
+			}
+		}
+		d.buildMtx.RUnlock()
+	}
 
 	// Pre voting period tokens. This query returns the
 	// tokens of the most recent version of all records that
 	// are public and do not have an associated StartVote
-	// record, ordered by timestamp in descending order.
-	q = `SELECT a.token
+	// record, sorted by timestamp.
+	pre := tokenInventoryQuery{
+		query: `SELECT a.token, a.timestamp
         FROM records a
         LEFT OUTER JOIN start_votes
           ON a.token = start_votes.token
@@ -734,100 +4853,132 @@ func (d *fonero) cmdTokenInventory(payload string) (string, error) {
           AND a.version < b.version
         WHERE b.token IS NULL
           AND start_votes.token IS NULL
-          AND a.status = ?
-        ORDER BY a.timestamp DESC`
-	rows, err = d.recordsdb.Raw(q, pd.RecordStatusPublic).Rows()
-	if err != nil {
-		return "", fmt.Errorf("pre: %v", err)
-	}
-	defer rows.Close()
-
-	pre := make([]string, 0, 1024)
-	for rows.Next() {
-		rows.Scan(&token)
-		pre = append(pre, token)
+          AND a.status = ?`,
+		token: "a.token",
+		key:   "a.timestamp",
+		args:  []interface{}{pd.RecordStatusPublic},
 	}
 
 	// Active voting period tokens
-	q = `SELECT token
+	active := tokenInventoryQuery{
+		query: `SELECT token, end_height
        FROM start_votes
-       WHERE end_height > ?
-       ORDER BY end_height DESC`
-	rows, err = d.recordsdb.Raw(q, ti.BestBlock).Rows()
-	if err != nil {
-		return "", fmt.Errorf("active: %v", err)
-	}
-	defer rows.Close()
-
-	active := make([]string, 0, 1024)
-	for rows.Next() {
-		rows.Scan(&token)
-		active = append(active, token)
+       WHERE end_height > ?`,
+		token: "token",
+		key:   "end_height",
+		args:  []interface{}{bestBlock},
 	}
 
 	// Approved vote tokens
-	q = `SELECT vote_results.token
+	approved := tokenInventoryQuery{
+		query: `SELECT vote_results.token, start_votes.end_height
        FROM vote_results
        INNER JOIN start_votes
          ON vote_results.token = start_votes.token
-         WHERE vote_results.approved = true
-       ORDER BY start_votes.end_height DESC`
-	rows, err = d.recordsdb.Raw(q).Rows()
-	if err != nil {
-		return "", fmt.Errorf("approved: %v", err)
-	}
-	defer rows.Close()
-
-	approved := make([]string, 0, 1024)
-	for rows.Next() {
-		rows.Scan(&token)
-		approved = append(approved, token)
+         WHERE vote_results.approved = true`,
+		token: "vote_results.token",
+		key:   "start_votes.end_height",
 	}
 
 	// Rejected vote tokens
-	q = `SELECT vote_results.token
+	rejected := tokenInventoryQuery{
+		query: `SELECT vote_results.token, start_votes.end_height
        FROM vote_results
        INNER JOIN start_votes
          ON vote_results.token = start_votes.token
-         WHERE vote_results.approved = false
-       ORDER BY start_votes.end_height DESC`
-	rows, err = d.recordsdb.Raw(q).Rows()
-	if err != nil {
-		return "", fmt.Errorf("rejected: %v", err)
-	}
-	defer rows.Close()
-
-	rejected := make([]string, 0, 1024)
-	for rows.Next() {
-		rows.Scan(&token)
-		rejected = append(rejected, token)
+         WHERE vote_results.approved = false`,
+		token: "vote_results.token",
+		key:   "start_votes.end_height",
 	}
 
 	// Abandoned tokens
-	abandoned := make([]string, 0, 1024)
-	q = `SELECT token
+	abandoned := tokenInventoryQuery{
+		query: `SELECT token, timestamp
        FROM records
-       WHERE status = ?
-       ORDER BY timestamp DESC`
-	rows, err = d.recordsdb.Raw(q, pd.RecordStatusArchived).Rows()
-	if err != nil {
-		return "", fmt.Errorf("abandoned: %v", err)
+       WHERE status = ?`,
+		token: "token",
+		key:   "timestamp",
+		args:  []interface{}{pd.RecordStatusArchived},
 	}
-	defer rows.Close()
 
-	for rows.Next() {
-		rows.Scan(&token)
-		abandoned = append(abandoned, token)
+	// tokens returns a page of the tokens that are selected by
+	// the passed in query and sets count to the total number of
+	// tokens and next to the cursor of the next page. When only
+	// the counts were requested, the tokens are counted using a
+	// COUNT(*) query and are not returned.
+	var (
+		counts foneroplugin.TokenInventoryCounts
+		next   foneroplugin.TokenInventoryCursors
+	)
+	tokens := func(tiq tokenInventoryQuery, cursor string, count *int, nextCursor *string) ([]string, error) {
+		if ti.CountsOnly {
+			c, err := d.queryCount("token inventory count",
+				tiq.query, tiq.args...)
+			*count = c
+			return nil, err
+		}
+
+		q, args, err := tiq.page(cursor, ti.Limit)
+		if err != nil {
+			return nil, err
+		}
+		entries, err := d.queryTokenInventory(q, args...)
+		if err != nil {
+			return nil, err
+		}
+		t, n := pageTokenInventory(entries, ti.Limit)
+		*nextCursor = n
+
+		// The total count must be queried separately when the
+		// tokens are paginated.
+		if ti.Limit == 0 && cursor == "" {
+			*count = len(t)
+		} else {
+			*count, err = d.queryCount("token inventory count",
+				tiq.query, tiq.args...)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return t, nil
+	}
+
+	preTokens, err := tokens(pre, ti.Cursors.Pre, &counts.Pre, &next.Pre)
+	if err != nil {
+		return "", fmt.Errorf("pre: %v", err)
+	}
+	activeTokens, err := tokens(active, ti.Cursors.Active, &counts.Active,
+		&next.Active)
+	if err != nil {
+		return "", fmt.Errorf("active: %v", err)
+	}
+	approvedTokens, err := tokens(approved, ti.Cursors.Approved,
+		&counts.Approved, &next.Approved)
+	if err != nil {
+		return "", fmt.Errorf("approved: %v", err)
+	}
+	rejectedTokens, err := tokens(rejected, ti.Cursors.Rejected,
+		&counts.Rejected, &next.Rejected)
+	if err != nil {
+		return "", fmt.Errorf("rejected: %v", err)
+	}
+	abandonedTokens, err := tokens(abandoned, ti.Cursors.Abandoned,
+		&counts.Abandoned, &next.Abandoned)
+	if err != nil {
+		return "", fmt.Errorf("abandoned: %v", err)
 	}
 
 	// Prepare reply
 	reply, err := foneroplugin.EncodeTokenInventoryReply(
 		foneroplugin.TokenInventoryReply{
-			Pre:       pre,
-			Active:    active,
-			Approved:  approved,
-			Rejected:  rejected,
-			Abandoned: abandoned,
+			Pre:       preTokens,
+			Active:    activeTokens,
+			Approved:  approvedTokens,
+			Rejected:  rejectedTokens,
+			Abandoned: abandonedTokens,
+			Counts:    counts,
+			Next:      next,
 		})
 	if err != nil {
 		return "", err
@@ -845,17 +4996,8 @@ func (d *fonero) cmdVoteSummary(payload string) (string, error) {
 	}
 
 	// Lookup the most recent record version
-	var r Record
-	err = d.recordsdb.
-		Where("records.token = ?", vs.Token).
-		Order("records.version desc").
-		Limit(1).
-		Find(&r).
-		Error
+	r, err := latestRecordVersion(d.recordsdb, vs.Token)
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			err = cache.ErrRecordNotFound
-		}
 		return "", err
 	}
 
@@ -864,6 +5006,7 @@ func (d *fonero) cmdVoteSummary(payload string) (string, error) {
 	var (
 		av AuthorizeVote
 		sv StartVote
+		s  *StartVote
 		vr VoteResults
 	)
 
@@ -882,18 +5025,15 @@ func (d *fonero) cmdVoteSummary(payload string) (string, error) {
 	}
 
 	// Lookup start vote
-	err = d.recordsdb.
-		Where("token = ?", vs.Token).
-		Preload("Options").
-		Find(&sv).
-		Error
-	if err == gorm.ErrRecordNotFound {
+	s, err = d.startVote(vs.Token)
+	if err == cache.ErrRecordNotFound {
 		// If an start vote doesn't exist then
 		// there is no need to continue.
 		goto sendReply
 	} else if err != nil {
 		return "", fmt.Errorf("lookup start vote: %v", err)
 	}
+	sv = *s
 
 	// Lookup vote results
 	err = d.recordsdb.
@@ -917,26 +5057,11 @@ func (d *fonero) cmdVoteSummary(payload string) (string, error) {
 		goto sendReply
 	}
 
-	// Lookup vote results manually
-	for _, v := range sv.Options {
-		var votes uint64
-		tokenVoteBit := v.Token + strconv.FormatUint(v.Bits, 16)
-		err := d.recordsdb.
-			Model(&CastVote{}).
-			Where("token_vote_bit = ?", tokenVoteBit).
-			Count(&votes).
-			Error
-		if err != nil {
-			return "", fmt.Errorf("count cast votes: %v", err)
-		}
-
-		results = append(results,
-			foneroplugin.VoteOptionResult{
-				ID:          v.ID,
-				Description: v.Description,
-				Bits:        v.Bits,
-				Votes:       votes,
-			})
+	// Lookup vote results using the cast vote counters. The
+	// counters table is created along with the cast votes table.
+	results, err = d.voteCounts(vs.Token, sv.Options)
+	if err != nil {
+		return "", fmt.Errorf("vote counts: %v", err)
 	}
 
 sendReply:
@@ -962,13 +5087,123 @@ sendReply:
 	return string(reply), nil
 }
 
+// cmdVoteExport returns the full vote lifecycle of a proposal.  The vote
+// details, cast votes and vote summary lookups are reused to assemble the
+// reply.  The vote results are final if the vote results table contains an
+// entry for the proposal.
+func (d *fonero) cmdVoteExport(payload string) (string, error) {
+	log.Tracef("fonero cmdVoteExport")
+
+	ve, err := foneroplugin.DecodeVoteExport([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	// Lookup vote authorization and start vote
+	vd, err := foneroplugin.EncodeVoteDetails(
+		foneroplugin.VoteDetails{
+			Token: ve.Token,
+		})
+	if err != nil {
+		return "", err
+	}
+	reply, err := d.cmdVoteDetails(string(vd))
+	if err != nil {
+		return "", err
+	}
+	vdr, err := foneroplugin.DecodeVoteDetailsReply([]byte(reply))
+	if err != nil {
+		return "", err
+	}
+
+	// Lookup cast votes
+	vr, err := foneroplugin.EncodeVoteResults(
+		foneroplugin.VoteResults{
+			Token: ve.Token,
+		})
+	if err != nil {
+		return "", err
+	}
+	reply, err = d.cmdProposalVotes(string(vr))
+	if err != nil {
+		return "", err
+	}
+	vrr, err := foneroplugin.DecodeVoteResultsReply([]byte(reply))
+	if err != nil {
+		return "", err
+	}
+
+	// Lookup vote results or live tally
+	vs, err := foneroplugin.EncodeVoteSummary(
+		foneroplugin.VoteSummary{
+			Token: ve.Token,
+		})
+	if err != nil {
+		return "", err
+	}
+	reply, err = d.cmdVoteSummary(string(vs))
+	if err != nil {
+		return "", err
+	}
+	vsr, err := foneroplugin.DecodeVoteSummaryReply([]byte(reply))
+	if err != nil {
+		return "", err
+	}
+
+	// Lookup whether the vote results are final
+	var results VoteResults
+	var final bool
+	err = d.recordsdb.
+		Where("token = ?", ve.Token).
+		Find(&results).
+		Error
+	if err == nil {
+		final = true
+	} else if err != gorm.ErrRecordNotFound {
+		return "", fmt.Errorf("lookup vote results: %v", err)
+	}
+
+	ver := foneroplugin.VoteExportReply{
+		AuthorizeVote:  vdr.AuthorizeVote,
+		StartVote:      vdr.StartVote,
+		StartVoteReply: vdr.StartVoteReply,
+		CastVotes:      vrr.CastVotes,
+		Results:        vsr.Results,
+		Final:          final,
+		Approved:       results.Approved,
+	}
+	verb, err := foneroplugin.EncodeVoteExportReply(ver)
+	if err != nil {
+		return "", err
+	}
+
+	return string(verb), nil
+}
+
 // Exec executes a fonero plugin command.  Plugin commands that write data to
 // the cache require both the command payload and the reply payload.  Plugin
 // commands that fetch data from the cache require only the command payload.
-// All commands return the appropriate reply payload.
+// All commands return the appropriate reply payload.  Heavy commands are
+// subject to the configured command concurrency limits.
 func (d *fonero) Exec(cmd, cmdPayload, replyPayload string) (string, error) {
 	log.Tracef("fonero Exec: %v", cmd)
 
+	// Commands that write to the fonero plugin tables wait for
+	// any build that is in progress to finish.
+	if _, ok := writeCommands[cmd]; ok {
+		d.buildMtx.RLock()
+		defer d.buildMtx.RUnlock()
+	}
+
+	if l, ok := d.limiters[cmd]; ok {
+		err := l.acquire()
+		if err != nil {
+			log.Warnf("fonero Exec: %v: %v", cmd, err)
+			return "", err
+		}
+		defer l.release()
+	}
+
 	switch cmd {
 	case foneroplugin.CmdAuthorizeVote:
 		return d.cmdAuthorizeVote(cmdPayload, replyPayload)
@@ -979,23 +5214,91 @@ func (d *fonero) Exec(cmd, cmdPayload, replyPayload string) (string, error) {
 	case foneroplugin.CmdBallot:
 		return d.cmdNewBallot(cmdPayload, replyPayload)
 	case foneroplugin.CmdBestBlock:
-		return "", nil
+		return d.cmdBestBlock(replyPayload)
+	case foneroplugin.CmdInventoryDigest:
+		return d.cmdInventoryDigest()
+	case foneroplugin.CmdArchiveProposalVotes:
+		return d.cmdArchiveProposalVotes(cmdPayload)
 	case foneroplugin.CmdNewComment:
 		return d.cmdNewComment(cmdPayload, replyPayload)
 	case foneroplugin.CmdLikeComment:
 		return d.cmdLikeComment(cmdPayload, replyPayload)
+	case foneroplugin.CmdLikeCommentUndo:
+		return d.cmdLikeCommentUndo(cmdPayload, replyPayload)
 	case foneroplugin.CmdCensorComment:
 		return d.cmdCensorComment(cmdPayload, replyPayload)
+	case foneroplugin.CmdSetCommentVisibility:
+		return d.cmdSetCommentVisibility(cmdPayload, replyPayload)
+	case foneroplugin.CmdReparentComment:
+		return d.cmdReparentComment(cmdPayload)
 	case foneroplugin.CmdGetComment:
 		return d.cmdGetComment(cmdPayload)
+	case foneroplugin.CmdGetCommentByReceipt:
+		return d.cmdGetCommentByReceipt(cmdPayload)
+	case foneroplugin.CmdTopComments:
+		return d.cmdTopComments(cmdPayload)
 	case foneroplugin.CmdGetComments:
 		return d.cmdGetComments(cmdPayload)
+	case foneroplugin.CmdGetCommentsForProposals:
+		return d.cmdGetCommentsForProposals(cmdPayload)
+	case foneroplugin.CmdCommentedProposals:
+		return d.cmdCommentedProposals(cmdPayload)
+	case foneroplugin.CmdGetCommentAncestors:
+		return d.cmdGetCommentAncestors(cmdPayload)
+	case foneroplugin.CmdGetCommentVersions:
+		return d.cmdGetCommentVersions(cmdPayload)
+	case foneroplugin.CmdCommentThreadStats:
+		return d.cmdCommentThreadStats(cmdPayload)
+	case foneroplugin.CmdGetCommentsSince:
+		return d.cmdGetCommentsSince(cmdPayload)
+	case foneroplugin.CmdCommentsModifiedSince:
+		return d.cmdCommentsModifiedSince(cmdPayload)
+	case foneroplugin.CmdCensoredComments:
+		return d.cmdCensoredComments(cmdPayload)
 	case foneroplugin.CmdProposalVotes:
 		return d.cmdProposalVotes(cmdPayload)
+	case foneroplugin.CmdProposalVoteReceipts:
+		return d.cmdProposalVoteReceipts(cmdPayload)
+	case foneroplugin.CmdVoteEligibility:
+		return d.cmdVoteEligibility(cmdPayload)
+	case foneroplugin.CmdEligibleTickets:
+		return d.cmdEligibleTickets(cmdPayload)
+	case foneroplugin.CmdGetStartVoteReply:
+		return d.cmdGetStartVoteReply(cmdPayload)
+	case foneroplugin.CmdVoteResultsDigest:
+		return d.cmdVoteResultsDigest(cmdPayload)
+	case foneroplugin.CmdVoteResultsByStatus:
+		return d.cmdVoteResultsByStatus(cmdPayload)
+	case foneroplugin.CmdRecordFilesManifest:
+		return d.cmdRecordFilesManifest(cmdPayload)
+	case foneroplugin.CmdGetLatestRecordVersion:
+		return d.cmdGetLatestRecordVersion(cmdPayload)
+	case foneroplugin.CmdRecordsByStatus:
+		return d.cmdRecordsByStatus(cmdPayload)
+	case foneroplugin.CmdRecordStatusCounts:
+		return d.cmdRecordStatusCounts(cmdPayload)
+	case foneroplugin.CmdArchiveStats:
+		return d.cmdArchiveStats(cmdPayload)
+	case foneroplugin.CmdRecordHistory:
+		return d.cmdRecordHistory(cmdPayload)
+	case foneroplugin.CmdGetProposalMetadata:
+		return d.cmdGetProposalMetadata(cmdPayload)
+	case foneroplugin.CmdGetRecordTimestampRange:
+		return d.cmdGetRecordTimestampRange(cmdPayload)
+	case foneroplugin.CmdActivityWindow:
+		return d.cmdActivityWindow(cmdPayload)
+	case foneroplugin.CmdCommentRate:
+		return d.cmdCommentRate(cmdPayload)
+	case foneroplugin.CmdVerifyCounts:
+		return d.cmdVerifyCounts(cmdPayload)
 	case foneroplugin.CmdCommentLikes:
 		return d.cmdCommentLikes(cmdPayload)
 	case foneroplugin.CmdProposalCommentsLikes:
 		return d.cmdProposalCommentsLikes(cmdPayload)
+	case foneroplugin.CmdProposalCommentsLikeCounts:
+		return d.cmdProposalCommentsLikeCounts(cmdPayload)
+	case foneroplugin.CmdCommentLikesNetScore:
+		return d.cmdCommentLikesNetScore(cmdPayload)
 	case foneroplugin.CmdInventory:
 		return d.cmdInventory()
 	case foneroplugin.CmdLoadVoteResults:
@@ -1004,11 +5307,758 @@ func (d *fonero) Exec(cmd, cmdPayload, replyPayload string) (string, error) {
 		return d.cmdTokenInventory(cmdPayload)
 	case foneroplugin.CmdVoteSummary:
 		return d.cmdVoteSummary(cmdPayload)
+	case foneroplugin.CmdCountVotesByOption:
+		return d.cmdCountVotesByOption(cmdPayload)
+	case foneroplugin.CmdVotesByBlockWindow:
+		return d.cmdVotesByBlockWindow(cmdPayload)
+	case foneroplugin.CmdProposalSupportersCount:
+		return d.cmdProposalSupportersCount(cmdPayload)
+	case foneroplugin.CmdGetVoteStatus:
+		return d.cmdGetVoteStatus(cmdPayload)
+	case foneroplugin.CmdVoteExport:
+		return d.cmdVoteExport(cmdPayload)
+	case foneroplugin.CmdVoteAuthorizationCheck:
+		return d.cmdVoteAuthorizationCheck(cmdPayload)
+	case foneroplugin.CmdRecomputeVoteResults:
+		return d.cmdRecomputeVoteResults(cmdPayload)
+	case foneroplugin.CmdExpireActiveVotes:
+		return d.cmdExpireActiveVotes(cmdPayload)
+	case foneroplugin.CmdGetVoteOptions:
+		return d.cmdGetVoteOptions(cmdPayload)
+	case foneroplugin.CmdGetEligibleTicketCount:
+		return d.cmdGetEligibleTicketCount(cmdPayload)
+	case foneroplugin.CmdProposalActivityTimeline:
+		return d.cmdProposalActivityTimeline(cmdPayload)
 	}
 
 	return "", cache.ErrInvalidPluginCmd
 }
 
+// writeCommands contains the fonero plugin commands that write to the fonero
+// plugin tables.  These commands are blocked while the tables are rebuilt
+// since their writes would be lost when the build tables replace the live
+// tables.  The token inventory command only writes when it computes missing
+// vote results, so it takes the build lock itself.
+var writeCommands = map[string]struct{}{
+	foneroplugin.CmdAuthorizeVote:        {},
+	foneroplugin.CmdStartVote:            {},
+	foneroplugin.CmdBallot:               {},
+	foneroplugin.CmdArchiveProposalVotes: {},
+	foneroplugin.CmdNewComment:           {},
+	foneroplugin.CmdLikeComment:          {},
+	foneroplugin.CmdLikeCommentUndo:      {},
+	foneroplugin.CmdCensorComment:        {},
+	foneroplugin.CmdSetCommentVisibility: {},
+	foneroplugin.CmdReparentComment:      {},
+	foneroplugin.CmdLoadVoteResults:      {},
+	foneroplugin.CmdRecomputeVoteResults: {},
+	foneroplugin.CmdExpireActiveVotes:    {},
+}
+
+// selfTestToken is the censorship token that is used by the self-test
+// payloads.  It does not belong to any record so that the commands that are
+// executed by the self-test do not return any data.
+const selfTestToken = "0000000000000000000000000000000000000000000000000000000000000000"
+
+// selfTestCase is a plugin command that is verified by the fonero plugin
+// self-test.  The sample payload is JSON encoded, the way the plugin encodes
+// its payloads, and must decode back into the sample payload.  Commands that
+// have a reply decoder are also executed with the sample payload.  Only
+// commands that do not write to the cache may have a reply decoder.
+type selfTestCase struct {
+	command string                            // Plugin command
+	payload interface{}                       // Sample command payload
+	decode  func([]byte) (interface{}, error) // Command payload decoder
+	reply   func([]byte) (interface{}, error) // Reply decoder (optional)
+}
+
+// foneroSelfTestCases contains the self-test cases of all of the fonero plugin
+// commands that take a command payload.
+var foneroSelfTestCases = []selfTestCase{
+	{
+		command: foneroplugin.CmdAuthorizeVote,
+		payload: foneroplugin.AuthorizeVote{
+			Action:    foneroplugin.AuthVoteActionAuthorize,
+			Token:     selfTestToken,
+			Signature: "signature",
+			PublicKey: "publickey",
+		},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeAuthorizeVote(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdStartVote,
+		payload: foneroplugin.StartVote{
+			Version:   foneroplugin.VersionStartVote,
+			PublicKey: "publickey",
+			Vote: foneroplugin.Vote{
+				Token:            selfTestToken,
+				Mask:             0x03,
+				Duration:         2016,
+				QuorumPercentage: 20,
+				PassPercentage:   60,
+				Options: []foneroplugin.VoteOption{{
+					Id:          "yes",
+					Description: "approve",
+					Bits:        0x02,
+				}},
+			},
+			Signature: "signature",
+		},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeStartVote(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdBallot,
+		payload: foneroplugin.Ballot{
+			Votes: []foneroplugin.CastVote{{
+				Token:     selfTestToken,
+				Ticket:    "ticket",
+				VoteBit:   "2",
+				Signature: "signature",
+			}},
+		},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeBallot(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdNewComment,
+		payload: foneroplugin.NewComment{
+			Token:     selfTestToken,
+			ParentID:  "1",
+			Comment:   "comment",
+			Signature: "signature",
+			PublicKey: "publickey",
+		},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeNewComment(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdLikeComment,
+		payload: foneroplugin.LikeComment{
+			Token:     selfTestToken,
+			CommentID: "1",
+			Action:    "1",
+			Signature: "signature",
+			PublicKey: "publickey",
+		},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeLikeComment(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdLikeCommentUndo,
+		payload: foneroplugin.LikeCommentUndo{
+			Token:     selfTestToken,
+			CommentID: "1",
+			Signature: "signature",
+			PublicKey: "publickey",
+		},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeLikeCommentUndo(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdCensorComment,
+		payload: foneroplugin.CensorComment{
+			Token:     selfTestToken,
+			CommentID: "1",
+			Reason:    "reason",
+			Signature: "signature",
+			PublicKey: "publickey",
+		},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeCensorComment(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdSetCommentVisibility,
+		payload: foneroplugin.SetCommentVisibility{
+			Token:     selfTestToken,
+			CommentID: "1",
+			Hidden:    true,
+			Signature: "signature",
+			PublicKey: "publickey",
+		},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeSetCommentVisibility(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdReparentComment,
+		payload: foneroplugin.ReparentComment{
+			Token:     selfTestToken,
+			CommentID: "2",
+			ParentID:  "1",
+		},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeReparentComment(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdGetComment,
+		payload: foneroplugin.GetComment{
+			Token:     selfTestToken,
+			CommentID: "1",
+		},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeGetComment(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdGetCommentByReceipt,
+		payload: foneroplugin.GetCommentByReceipt{
+			Receipt: "receipt",
+		},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeGetCommentByReceipt(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdTopComments,
+		payload: foneroplugin.TopComments{
+			Token: selfTestToken,
+			Limit: 10,
+		},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeTopComments(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdGetComments,
+		payload: foneroplugin.GetComments{
+			Token:         selfTestToken,
+			Offset:        1,
+			Limit:         10,
+			IncludeHidden: true,
+		},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeGetComments(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdGetCommentsForProposals,
+		payload: foneroplugin.GetCommentsForProposals{
+			Tokens:          []string{selfTestToken},
+			Limit:           10,
+			ExcludeCensored: true,
+		},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeGetCommentsForProposals(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdCommentedProposals,
+		payload: foneroplugin.CommentedProposals{
+			PublicKey: selfTestToken,
+		},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeCommentedProposals(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdGetCommentAncestors,
+		payload: foneroplugin.GetCommentAncestors{
+			Token:     selfTestToken,
+			CommentID: "2",
+			MaxDepth:  5,
+		},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeGetCommentAncestors(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdGetCommentVersions,
+		payload: foneroplugin.GetCommentVersions{
+			Token:     selfTestToken,
+			CommentID: "1",
+		},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeGetCommentVersions(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdCommentThreadStats,
+		payload: foneroplugin.CommentThreadStats{
+			Token: selfTestToken,
+		},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeCommentThreadStats(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdGetCommentsSince,
+		payload: foneroplugin.GetCommentsSince{
+			Token:     selfTestToken,
+			Timestamp: 1,
+			CommentID: "1",
+		},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeGetCommentsSince(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdCommentsModifiedSince,
+		payload: foneroplugin.CommentsModifiedSince{
+			Token:     selfTestToken,
+			Timestamp: 1,
+		},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeCommentsModifiedSince(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdCensoredComments,
+		payload: foneroplugin.CensoredComments{
+			Token: selfTestToken,
+		},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeCensoredComments(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdCommentLikes,
+		payload: foneroplugin.CommentLikes{
+			Token:     selfTestToken,
+			CommentID: "1",
+		},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeCommentLikes(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdProposalCommentsLikes,
+		payload: foneroplugin.GetProposalCommentsLikes{
+			Token: selfTestToken,
+		},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeGetProposalCommentsLikes(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdProposalCommentsLikeCounts,
+		payload: foneroplugin.GetProposalCommentsLikeCounts{
+			Token: selfTestToken,
+		},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeGetProposalCommentsLikeCounts(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdCommentLikesNetScore,
+		payload: foneroplugin.CommentLikesNetScore{
+			Token:      selfTestToken,
+			CommentIDs: []string{"1", "2"},
+		},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeCommentLikesNetScore(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdCommentRate,
+		payload: foneroplugin.CommentRate{
+			Token:  selfTestToken,
+			Window: 3600,
+		},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeCommentRate(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdVoteDetails,
+		payload: foneroplugin.VoteDetails{
+			Token: selfTestToken,
+		},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeVoteDetails(b)
+		},
+		reply: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeVoteDetailsReply(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdVoteSummary,
+		payload: foneroplugin.VoteSummary{
+			Token: selfTestToken,
+		},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeVoteSummary(b)
+		},
+		reply: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeVoteSummaryReply(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdGetVoteStatus,
+		payload: foneroplugin.GetVoteStatus{
+			Token:     selfTestToken,
+			BestBlock: 1,
+		},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeGetVoteStatus(b)
+		},
+		reply: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeGetVoteStatusReply(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdCountVotesByOption,
+		payload: foneroplugin.CountVotesByOption{
+			Token: selfTestToken,
+		},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeCountVotesByOption(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdVotesByBlockWindow,
+		payload: foneroplugin.VotesByBlockWindow{
+			StartHeight: 1,
+			EndHeight:   2,
+		},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeVotesByBlockWindow(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdProposalSupportersCount,
+		payload: foneroplugin.ProposalSupportersCount{
+			Token: selfTestToken,
+		},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeProposalSupportersCount(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdProposalVotes,
+		payload: foneroplugin.VoteResults{
+			Token:     selfTestToken,
+			TallyOnly: true,
+			Offset:    1,
+			Limit:     10,
+		},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeVoteResults(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdProposalVoteReceipts,
+		payload: foneroplugin.ProposalVoteReceipts{
+			Token:  selfTestToken,
+			Ticket: selfTestToken,
+		},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeProposalVoteReceipts(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdVoteEligibility,
+		payload: foneroplugin.VoteEligibility{
+			Token:  selfTestToken,
+			Ticket: "ticket",
+		},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeVoteEligibility(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdEligibleTickets,
+		payload: foneroplugin.EligibleTickets{
+			Token:  selfTestToken,
+			Offset: 1,
+			Limit:  10,
+		},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeEligibleTickets(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdGetStartVoteReply,
+		payload: foneroplugin.GetStartVoteReply{
+			Token: selfTestToken,
+		},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeGetStartVoteReply(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdGetVoteOptions,
+		payload: foneroplugin.GetVoteOptions{
+			Token: selfTestToken,
+		},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeGetVoteOptions(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdGetEligibleTicketCount,
+		payload: foneroplugin.GetEligibleTicketCount{
+			Token: selfTestToken,
+		},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeGetEligibleTicketCount(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdProposalActivityTimeline,
+		payload: foneroplugin.ProposalActivityTimeline{
+			Token: selfTestToken,
+		},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeProposalActivityTimeline(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdVoteResultsDigest,
+		payload: foneroplugin.VoteResultsDigest{
+			Token: selfTestToken,
+		},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeVoteResultsDigest(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdVoteResultsByStatus,
+		payload: foneroplugin.VoteResultsByStatus{
+			Approved: true,
+		},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeVoteResultsByStatus(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdArchiveProposalVotes,
+		payload: foneroplugin.ArchiveProposalVotes{
+			Token:     selfTestToken,
+			BestBlock: 1,
+		},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeArchiveProposalVotes(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdLoadVoteResults,
+		payload: foneroplugin.LoadVoteResults{
+			BestBlock: 1,
+			DryRun:    true,
+			Tokens:    []string{selfTestToken},
+		},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeLoadVoteResults(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdRecomputeVoteResults,
+		payload: foneroplugin.RecomputeVoteResults{
+			Token:     selfTestToken,
+			BestBlock: 1,
+		},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeRecomputeVoteResults(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdExpireActiveVotes,
+		payload: foneroplugin.ExpireActiveVotes{
+			BestBlock:  2,
+			FromHeight: 1,
+			DryRun:     true,
+		},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeExpireActiveVotes(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdVoteExport,
+		payload: foneroplugin.VoteExport{
+			Token: selfTestToken,
+		},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeVoteExport(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdVoteAuthorizationCheck,
+		payload: foneroplugin.VoteAuthorizationCheck{},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeVoteAuthorizationCheck(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdTokenInventory,
+		payload: foneroplugin.TokenInventory{
+			BestBlock:  1,
+			CountsOnly: true,
+			Limit:      10,
+			Cursors: foneroplugin.TokenInventoryCursors{
+				Pre: selfTestToken,
+			},
+		},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeTokenInventory(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdRecordsByStatus,
+		payload: foneroplugin.RecordsByStatus{
+			Status: 4,
+			Offset: 1,
+			Limit:  10,
+		},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeRecordsByStatus(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdRecordStatusCounts,
+		payload: foneroplugin.RecordStatusCounts{},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeRecordStatusCounts(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdArchiveStats,
+		payload: foneroplugin.ArchiveStats{},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeArchiveStats(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdRecordHistory,
+		payload: foneroplugin.RecordHistory{
+			Token: selfTestToken,
+		},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeRecordHistory(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdGetProposalMetadata,
+		payload: foneroplugin.GetProposalMetadata{
+			Token:   selfTestToken,
+			Version: "1",
+		},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeGetProposalMetadata(b)
+		},
+		reply: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeGetProposalMetadataReply(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdRecordFilesManifest,
+		payload: foneroplugin.RecordFilesManifest{
+			Token:   selfTestToken,
+			Version: "1",
+		},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeRecordFilesManifest(b)
+		},
+		reply: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeRecordFilesManifestReply(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdGetLatestRecordVersion,
+		payload: foneroplugin.GetLatestRecordVersion{
+			Token: selfTestToken,
+		},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeGetLatestRecordVersion(b)
+		},
+		reply: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeGetLatestRecordVersionReply(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdGetRecordTimestampRange,
+		payload: foneroplugin.GetRecordTimestampRange{
+			Start: 1,
+			End:   2,
+		},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeGetRecordTimestampRange(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdActivityWindow,
+		payload: foneroplugin.ActivityWindow{
+			Start: 1,
+			End:   2,
+		},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeActivityWindow(b)
+		},
+	},
+	{
+		command: foneroplugin.CmdVerifyCounts,
+		payload: foneroplugin.VerifyCounts{
+			Records:   1,
+			Comments:  2,
+			CastVotes: 3,
+			Threshold: 4,
+		},
+		decode: func(b []byte) (interface{}, error) {
+			return foneroplugin.DecodeVerifyCounts(b)
+		},
+	},
+}
+
+// selfTest verifies that the passed in self-test cases round-trip.  The sample
+// payload of each case must decode back into the sample payload and the
+// commands that have a reply decoder must be dispatched by Exec and return
+// either a reply that can be decoded or a record not found error.  An error is
+// returned for the first case that fails.
+func (d *fonero) selfTest(cases []selfTestCase) error {
+	log.Tracef("fonero selfTest")
+
+	for _, v := range cases {
+		payload, err := json.Marshal(v.payload)
+		if err != nil {
+			return fmt.Errorf("%v: encode payload: %v", v.command, err)
+		}
+		decoded, err := v.decode(payload)
+		if err != nil {
+			return fmt.Errorf("%v: decode payload: %v", v.command, err)
+		}
+		dv := reflect.ValueOf(decoded)
+		if dv.Kind() == reflect.Ptr {
+			if dv.IsNil() {
+				return fmt.Errorf("%v: decode payload: nil payload",
+					v.command)
+			}
+			dv = dv.Elem()
+		}
+		if !reflect.DeepEqual(dv.Interface(), v.payload) {
+			return fmt.Errorf("%v: payload did not round-trip: got %v, "+
+				"want %v", v.command, dv.Interface(), v.payload)
+		}
+
+		if v.reply == nil {
+			continue
+		}
+		reply, err := d.Exec(v.command, string(payload), "")
+		switch {
+		case err == cache.ErrRecordNotFound:
+			continue
+		case err == cache.ErrInvalidPluginCmd:
+			return fmt.Errorf("%v: command not dispatched", v.command)
+		case err != nil:
+			return fmt.Errorf("%v: exec: %v", v.command, err)
+		}
+		_, err = v.reply([]byte(reply))
+		if err != nil {
+			return fmt.Errorf("%v: decode reply: %v", v.command, err)
+		}
+	}
+
+	return nil
+}
+
 // createTables creates the cache tables needed by the fonero plugin if they do
 // not already exist. A fonero plugin version record is inserted into the
 // database during table creation.
@@ -1030,12 +6080,44 @@ func (d *fonero) createTables(tx *gorm.DB) error {
 			return err
 		}
 	}
+	if !tx.HasTable(tableCommentLikeStates) {
+		err := tx.CreateTable(&LikeCommentState{}).Error
+		if err != nil {
+			return err
+		}
+	}
+	if !tx.HasTable(tableCommentVersions) {
+		err := tx.CreateTable(&CommentVersion{}).Error
+		if err != nil {
+			return err
+		}
+	}
+	if !tx.HasTable(tableCensoredComments) {
+		err := tx.CreateTable(&CensoredComment{}).Error
+		if err != nil {
+			return err
+		}
+	}
 	if !tx.HasTable(tableCastVotes) {
 		err := tx.CreateTable(&CastVote{}).Error
 		if err != nil {
 			return err
 		}
 	}
+	if !tx.HasTable(tableCastVoteCounts) {
+		err := tx.CreateTable(&CastVoteCount{}).Error
+		if err != nil {
+			return err
+		}
+
+		// The cast vote counters may be created after votes
+		// have already been cast. Load them using the existing
+		// cast votes.
+		err = d.loadCastVoteCounts(tx)
+		if err != nil {
+			return err
+		}
+	}
 	if !tx.HasTable(tableAuthorizeVotes) {
 		err := tx.CreateTable(&AuthorizeVote{}).Error
 		if err != nil {
@@ -1048,6 +6130,12 @@ func (d *fonero) createTables(tx *gorm.DB) error {
 			return err
 		}
 	}
+	if !tx.HasTable(tableEligibleTickets) {
+		err := tx.CreateTable(&EligibleTicket{}).Error
+		if err != nil {
+			return err
+		}
+	}
 	if !tx.HasTable(tableStartVotes) {
 		err := tx.CreateTable(&StartVote{}).Error
 		if err != nil {
@@ -1066,6 +6154,12 @@ func (d *fonero) createTables(tx *gorm.DB) error {
 			return err
 		}
 	}
+	if !tx.HasTable(tableCastVoteArchives) {
+		err := tx.CreateTable(&CastVoteArchive{}).Error
+		if err != nil {
+			return err
+		}
+	}
 
 	// Check if a fonero version record exists. Insert one
 	// if no version record is found.
@@ -1095,8 +6189,10 @@ func (d *fonero) createTables(tx *gorm.DB) error {
 func (d *fonero) dropTables(tx *gorm.DB) error {
 	// Drop fonero plugin tables
 	err := tx.DropTableIfExists(tableComments, tableCommentLikes,
-		tableCastVotes, tableAuthorizeVotes, tableVoteOptions,
-		tableStartVotes, tableVoteOptionResults, tableVoteResults).
+		tableCommentLikeStates, tableCommentVersions, tableCastVotes, tableCastVoteCounts,
+		tableAuthorizeVotes, tableVoteOptions, tableEligibleTickets,
+		tableStartVotes, tableVoteOptionResults, tableVoteResults,
+		tableCastVoteArchives).
 		Error
 	if err != nil {
 		return err
@@ -1108,58 +6204,233 @@ func (d *fonero) dropTables(tx *gorm.DB) error {
 	}).Error
 }
 
-// build the fonero plugin cache using the passed in inventory.
-//
-// This function cannot be called using a transaction because it could
-// potentially exceed cockroachdb's transaction size limit.
-func (d *fonero) build(ir *foneroplugin.InventoryReply) error {
-	log.Tracef("fonero build")
-
-	// Drop all fonero plugin tables
-	tx := d.recordsdb.Begin()
-	err := d.dropTables(tx)
+// verifyCommentSignature verifies that the comment signature is a valid
+// signature of token+parentID+comment made using the comment public key.
+func verifyCommentSignature(c foneroplugin.Comment) error {
+	pk, err := hex.DecodeString(c.PublicKey)
 	if err != nil {
-		tx.Rollback()
-		return fmt.Errorf("drop tables: %v", err)
+		return fmt.Errorf("invalid public key: %v", err)
 	}
-	err = tx.Commit().Error
+	id, err := identity.PublicIdentityFromBytes(pk)
 	if err != nil {
 		return err
 	}
+	sig, err := identity.SignatureFromString(c.Signature)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %v", err)
+	}
+	if !id.VerifyMessage([]byte(c.Token+c.ParentID+c.Comment), *sig) {
+		return fmt.Errorf("signature does not match")
+	}
+	return nil
+}
 
-	// Create fonero plugin tables
-	tx = d.recordsdb.Begin()
-	err = d.createTables(tx)
+// castVoteMessageHash returns the hash of the message that is signed by the
+// ticket commitment address when casting a vote.
+func castVoteMessageHash(cv foneroplugin.CastVote) []byte {
+	var buf bytes.Buffer
+	wire.WriteVarString(&buf, 0, "Fonero Signed Message:\n")
+	wire.WriteVarString(&buf, 0, cv.Token+cv.Ticket+cv.VoteBit)
+	return chainhash.HashB(buf.Bytes())
+}
+
+// verifyCastVote verifies that the cast vote ticket is eligible to vote on the
+// proposal, that the vote bit is one of the vote options, and that the
+// signature is a valid compact signature of token+ticket+votebit.  The ticket
+// commitment addresses are not part of the inventory so the public key that
+// is recovered from the signature cannot be matched against the ticket.
+func verifyCastVote(cv foneroplugin.CastVote, svt *foneroplugin.StartVoteTuple) error {
+	if svt == nil {
+		return fmt.Errorf("start vote not found")
+	}
+
+	var eligible bool
+	for _, v := range svt.StartVoteReply.EligibleTickets {
+		if v == cv.Ticket {
+			eligible = true
+			break
+		}
+	}
+	if !eligible {
+		return fmt.Errorf("ticket not eligible")
+	}
+
+	var validBit bool
+	for _, v := range svt.StartVote.Vote.Options {
+		if strconv.FormatUint(v.Bits, 16) == tallyVoteBit(cv.VoteBit) {
+			validBit = true
+			break
+		}
+	}
+	if !validBit {
+		return fmt.Errorf("invalid vote bit")
+	}
+
+	sig, err := hex.DecodeString(cv.Signature)
 	if err != nil {
-		tx.Rollback()
-		return fmt.Errorf("create tables: %v", err)
+		return fmt.Errorf("invalid signature: %v", err)
 	}
-	err = tx.Commit().Error
+	_, _, err = secp256k1.RecoverCompact(sig, castVoteMessageHash(cv))
 	if err != nil {
-		return err
+		return fmt.Errorf("invalid signature: %v", err)
+	}
+
+	return nil
+}
+
+// verifyInventorySignatures verifies the signatures of the comments and cast
+// votes of the passed in inventory.  Censored comments are skipped since
+// their content has been removed.  A copy of the inventory that does not
+// contain the invalid entries is returned along with the number of invalid
+// entries.  Each invalid entry is logged along with its token.  An error is
+// returned if the number of invalid entries exceeds maxInvalid.
+func verifyInventorySignatures(ir *foneroplugin.InventoryReply, maxInvalid int) (*foneroplugin.InventoryReply, int, error) {
+	var invalid int
+	valid := *ir
+
+	valid.Comments = make([]foneroplugin.Comment, 0, len(ir.Comments))
+	for _, v := range ir.Comments {
+		if !v.Censored {
+			err := verifyCommentSignature(v)
+			if err != nil {
+				invalid++
+				log.Errorf("Rejected invalid comment %v %v: %v",
+					v.Token, v.CommentID, err)
+				continue
+			}
+		}
+		valid.Comments = append(valid.Comments, v)
+	}
+
+	startVotes := make(map[string]*foneroplugin.StartVoteTuple,
+		len(ir.StartVoteTuples)) // [token]StartVoteTuple
+	for i, v := range ir.StartVoteTuples {
+		startVotes[v.StartVote.Vote.Token] = &ir.StartVoteTuples[i]
+	}
+	valid.CastVotes = make([]foneroplugin.CastVote, 0, len(ir.CastVotes))
+	for _, v := range ir.CastVotes {
+		err := verifyCastVote(v, startVotes[v.Token])
+		if err != nil {
+			invalid++
+			log.Errorf("Rejected invalid cast vote %v %v: %v",
+				v.Token, v.Ticket, err)
+			continue
+		}
+		valid.CastVotes = append(valid.CastVotes, v)
+	}
+
+	if invalid > maxInvalid {
+		return nil, invalid, fmt.Errorf("%v invalid inventory entries "+
+			"exceeds the maximum of %v", invalid, maxInvalid)
+	}
+
+	return &valid, invalid, nil
+}
+
+// createBuildTables creates the fonero plugin build tables.  Any build tables
+// that were left behind by a previous build are dropped first.  The
+// transaction is rolled back if the context is cancelled while the tables are
+// being created.
+func (d *fonero) createBuildTables(ctx context.Context) error {
+	err := dropBuildTables(d.recordsdb, foneroTables)
+	if err != nil {
+		return fmt.Errorf("drop build tables: %v", err)
+	}
+
+	models := map[string]interface{}{
+		tableComments:          &Comment{},
+		tableCommentLikes:      &LikeComment{},
+		tableCommentLikeStates: &LikeCommentState{},
+		tableCommentVersions:   &CommentVersion{},
+		tableCastVotes:         &CastVote{},
+		tableCastVoteCounts:    &CastVoteCount{},
+		tableAuthorizeVotes:    &AuthorizeVote{},
+		tableVoteOptions:       &VoteOption{},
+		tableEligibleTickets:   &EligibleTicket{},
+		tableStartVotes:        &StartVote{},
+		tableVoteOptionResults: &VoteOptionResult{},
+		tableVoteResults:       &VoteResults{},
+		tableCastVoteArchives:  &CastVoteArchive{},
+	}
+
+	tx := d.recordsdb.Begin()
+	for _, v := range foneroTables {
+		err := ctx.Err()
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		err = tx.Table(buildTableName(v)).
+			CreateTable(models[v]).
+			Error
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("create %v: %v", buildTableName(v), err)
+		}
 	}
 
+	return tx.Commit().Error
+}
+
+// populateTables inserts the passed in inventory into the fonero plugin
+// tables.  The table function returns the name of the table that is written
+// to so that the inventory can be inserted into either the live tables or the
+// build tables.  The vote results tables are lazy loaded and are left empty.
+// The context is checked between the sections of the build so that a
+// cancelled build stops before the next section is started.
+func (d *fonero) populateTables(ctx context.Context, ir *foneroplugin.InventoryReply, table func(string) string) error {
 	// Build comments cache
 	log.Tracef("fonero: building comments cache")
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
 	for _, v := range ir.Comments {
 		c := convertCommentFromFonero(v)
-		err := d.newComment(d.recordsdb, c)
+		err := d.recordsdb.Table(table(tableComments)).
+			Create(&c).
+			Error
 		if err != nil {
 			log.Debugf("newComment failed on '%v'", c)
 			return fmt.Errorf("newComment: %v", err)
 		}
+
+		cv := convertCommentVersionFromComment(c)
+		cv.Version = 1
+		err = d.recordsdb.Table(table(tableCommentVersions)).
+			Create(&cv).
+			Error
+		if err != nil {
+			log.Debugf("newCommentVersion failed on '%v'", cv)
+			return fmt.Errorf("newCommentVersion: %v", err)
+		}
 	}
 
 	// Build like comments cache
 	log.Tracef("fonero: building like comments cache")
+	err = ctx.Err()
+	if err != nil {
+		return err
+	}
 	for _, v := range ir.LikeComments {
 		lc := convertLikeCommentFromFonero(v)
-		err := d.newLikeComment(d.recordsdb, lc)
+		err := d.recordsdb.Table(table(tableCommentLikes)).
+			Create(&lc).
+			Error
 		if err != nil {
 			log.Debugf("newLikeComment failed on '%v'", lc)
 			return fmt.Errorf("newLikeComment: %v", err)
 		}
 	}
+	for _, s := range likeCommentStates(ir.LikeComments) {
+		err := d.recordsdb.Table(table(tableCommentLikeStates)).
+			Create(&s).
+			Error
+		if err != nil {
+			log.Debugf("newLikeCommentState failed on '%v'", s)
+			return fmt.Errorf("newLikeCommentState: %v", err)
+		}
+	}
 
 	// Put authorize vote replies in a map for quick lookups
 	avr := make(map[string]foneroplugin.AuthorizeVoteReply,
@@ -1170,11 +6441,25 @@ func (d *fonero) build(ir *foneroplugin.InventoryReply) error {
 
 	// Build authorize vote cache
 	log.Tracef("fonero: building authorize vote cache")
+	err = ctx.Err()
+	if err != nil {
+		return err
+	}
+	var skipped int
 	for _, v := range ir.AuthorizeVotes {
 		r, ok := avr[v.Receipt]
 		if !ok {
-			return fmt.Errorf("AuthorizeVoteReply not found %v",
-				v.Token)
+			// A single inconsistent inventory entry should not
+			// abort the entire build.
+			skipped++
+			log.Errorf("AuthorizeVoteReply not found %v %v, skipping",
+				v.Token, v.Receipt)
+			if skipped > d.maxAuthVoteSkips {
+				return fmt.Errorf("%v authorize votes without a "+
+					"reply exceeds the maximum of %v", skipped,
+					d.maxAuthVoteSkips)
+			}
+			continue
 		}
 
 		rv, err := strconv.ParseUint(r.RecordVersion, 10, 64)
@@ -1185,15 +6470,24 @@ func (d *fonero) build(ir *foneroplugin.InventoryReply) error {
 		}
 
 		av := convertAuthorizeVoteFromFonero(v, r, rv)
-		err = d.newAuthorizeVote(d.recordsdb, av)
+		db := d.recordsdb.Table(table(tableAuthorizeVotes))
+		err = d.newAuthorizeVote(db, av)
 		if err != nil {
 			log.Debugf("newAuthorizeVote failed on '%v'", av)
 			return fmt.Errorf("newAuthorizeVote: %v", err)
 		}
 	}
 
+	if skipped > 0 {
+		log.Warnf("Skipped %v authorize votes without a reply", skipped)
+	}
+
 	// Build start vote cache
 	log.Tracef("fonero: building start vote cache")
+	err = ctx.Err()
+	if err != nil {
+		return err
+	}
 	for _, v := range ir.StartVoteTuples {
 		endHeight, err := strconv.ParseUint(v.StartVoteReply.EndHeight, 10, 64)
 		if err != nil {
@@ -1202,35 +6496,538 @@ func (d *fonero) build(ir *foneroplugin.InventoryReply) error {
 				v.StartVoteReply.EndHeight, err)
 		}
 
+		// The vote options and eligible tickets are inserted
+		// manually since gorm does not apply the table name to
+		// the associations.
 		sv := convertStartVoteFromFonero(v.StartVote,
 			v.StartVoteReply, endHeight)
-		err = d.newStartVote(d.recordsdb, sv)
+		err = d.recordsdb.Table(table(tableStartVotes)).
+			Set("gorm:save_associations", false).
+			Create(&sv).
+			Error
 		if err != nil {
 			log.Debugf("newStartVote failed on '%v'", sv)
 			return fmt.Errorf("newStartVote: %v", err)
 		}
+		for _, o := range sv.Options {
+			o.Token = sv.Token
+			err := d.recordsdb.Table(table(tableVoteOptions)).
+				Create(&o).
+				Error
+			if err != nil {
+				log.Debugf("newStartVote failed on '%v'", o)
+				return fmt.Errorf("newStartVote: %v", err)
+			}
+		}
+		err = insertEligibleTickets(d.recordsdb,
+			table(tableEligibleTickets), sv.EligibleTickets)
+		if err != nil {
+			log.Debugf("newStartVote failed on '%v'", sv.Token)
+			return fmt.Errorf("newStartVote: insert eligible "+
+				"tickets: %v", err)
+		}
 	}
 
 	// Build cast vote cache
 	log.Tracef("fonero: building cast vote cache")
+	err = ctx.Err()
+	if err != nil {
+		return err
+	}
 	for _, v := range ir.CastVotes {
 		cv := convertCastVoteFromFonero(v)
-		err := d.newCastVote(d.recordsdb, cv)
+		err := d.recordsdb.Table(table(tableCastVotes)).
+			Create(&cv).
+			Error
 		if err != nil {
 			log.Debugf("newCastVote failed on '%v'", cv)
 			return fmt.Errorf("newCastVote: %v", err)
 		}
 	}
 
+	// Build cast vote counters
+	log.Tracef("fonero: building cast vote counters")
+	err = ctx.Err()
+	if err != nil {
+		return err
+	}
+	q := fmt.Sprintf(`INSERT INTO %v (key, token, vote_bit, votes)
+        SELECT token_vote_bit, token,
+          SUBSTR(token_vote_bit, LENGTH(token) + 1), COUNT(*)
+        FROM %v
+        GROUP BY token_vote_bit, token`,
+		table(tableCastVoteCounts), table(tableCastVotes))
+	err = d.recordsdb.Exec(q).Error
+	if err != nil {
+		return fmt.Errorf("count cast votes: %v", err)
+	}
+
+	return nil
+}
+
+// buildInPlace drops the fonero plugin tables then recreates and populates
+// them.  The fonero plugin cache is unavailable until the build completes.
+func (d *fonero) buildInPlace(ctx context.Context, ir *foneroplugin.InventoryReply) error {
+	// Drop all fonero plugin tables
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
+	tx := d.recordsdb.Begin()
+	err = d.dropTables(tx)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("drop tables: %v", err)
+	}
+	err = tx.Commit().Error
+	if err != nil {
+		return err
+	}
+
+	// Create fonero plugin tables
+	err = ctx.Err()
+	if err != nil {
+		return err
+	}
+	tx = d.recordsdb.Begin()
+	err = d.createTables(tx)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("create tables: %v", err)
+	}
+	err = tx.Commit().Error
+	if err != nil {
+		return err
+	}
+
+	err = d.populateTables(ctx, ir, liveTableName)
+	if err != nil {
+		return err
+	}
+
+	return d.checkIntegrity(ir, liveTableName)
+}
+
+// checkIntegrity returns an error that describes the discrepancies between
+// the passed in inventory and the tables that were built from it.  The table
+// function returns the name of the table that is checked.
+func (d *fonero) checkIntegrity(ir *foneroplugin.InventoryReply, table func(string) string) error {
+	discrepancies, err := d.verifyIntegrity(ir, table)
+	if err != nil {
+		return err
+	}
+	if len(discrepancies) > 0 {
+		return fmt.Errorf("integrity check failed: %v",
+			strings.Join(discrepancies, "; "))
+	}
 	return nil
 }
 
-// Build drops all existing fonero plugin tables from the database, recreates
-// them, then uses the passed in inventory payload to build the fonero plugin
-// cache.
-func (d *fonero) Build(payload string) error {
+// build the fonero plugin cache using the passed in inventory.  The inventory
+// is inserted into build tables that are swapped with the live tables once
+// they have been populated and have passed the integrity check so that the
+// existing cache keeps serving reads during the build.  The live tables are
+// rebuilt in place if the tables cannot be swapped.  The build stops with the
+// context error if the context is cancelled.
+//
+// This function cannot be called using a transaction because it could
+// potentially exceed cockroachdb's transaction size limit.
+func (d *fonero) build(ctx context.Context, ir *foneroplugin.InventoryReply) error {
+	log.Tracef("fonero build")
+
+	// The cached start votes are cleared once the build has
+	// finished, whether or not it succeeded, since the start
+	// vote tables may have been replaced.
+	defer d.startVotes.reset()
+
+	// Verify the inventory signatures before any of the tables
+	// are built so that a failed verification does not leave
+	// behind a partially built cache.
+	if d.buildSigs.enabled {
+		log.Infof("Verifying fonero plugin inventory signatures")
+		valid, invalid, err := verifyInventorySignatures(ir,
+			d.buildSigs.maxInvalid)
+		if err != nil {
+			return fmt.Errorf("verify signatures: %v", err)
+		}
+		if invalid > 0 {
+			log.Warnf("Building fonero plugin cache without %v invalid "+
+				"inventory entries", invalid)
+		}
+		ir = valid
+	}
+
+	err := d.createBuildTables(ctx)
+	if err != nil {
+		return fmt.Errorf("create build tables: %v", err)
+	}
+	err = d.populateTables(ctx, ir, buildTableName)
+	if err != nil {
+		// The build tables are dropped by the next build
+		return err
+	}
+
+	// Verify that the build tables match the inventory they
+	// were built from before they replace the live tables.
+	err = d.checkIntegrity(ir, buildTableName)
+	if err != nil {
+		return err
+	}
+
+	err = swapTables(ctx, d.recordsdb, foneroTables, Version{
+		ID:        foneroplugin.ID,
+		Version:   foneroVersion,
+		Timestamp: time.Now().Unix(),
+	})
+	if err == nil {
+		return nil
+	}
+	if ctx.Err() != nil {
+		return err
+	}
+
+	log.Warnf("Unable to swap fonero plugin tables, rebuilding the "+
+		"tables in place: %v", err)
+	err = dropBuildTables(d.recordsdb, foneroTables)
+	if err != nil {
+		return fmt.Errorf("drop build tables: %v", err)
+	}
+
+	return d.buildInPlace(ctx, ir)
+}
+
+// unauthorizedVotes returns the start votes that do not have a matching
+// authorize vote.  Authorize votes are keyed by token+version so a start vote
+// is only considered authorized if the proposal author authorized the same
+// version of the proposal that is being voted on and did not revoke the
+// authorization afterwards.  The returned slice is sorted by token.
+func unauthorizedVotes(svs []StartVote, avs []AuthorizeVote) []foneroplugin.UnauthorizedVote {
+	actions := make(map[string]string, len(avs)) // [key]action
+	for _, v := range avs {
+		actions[v.Key] = v.Action
+	}
+
+	uv := make([]foneroplugin.UnauthorizedVote, 0)
+	for _, v := range svs {
+		version := strconv.FormatUint(v.Version, 10)
+		action, ok := actions[v.Token+version]
+		var reason string
+		switch {
+		case !ok:
+			reason = "authorize vote not found"
+		case action != foneroplugin.AuthVoteActionAuthorize:
+			reason = fmt.Sprintf("authorize vote action is %v", action)
+		default:
+			continue
+		}
+		uv = append(uv, foneroplugin.UnauthorizedVote{
+			Token:   v.Token,
+			Version: version,
+			Reason:  reason,
+		})
+	}
+
+	sort.Slice(uv, func(i, j int) bool {
+		return uv[i].Token < uv[j].Token
+	})
+
+	return uv
+}
+
+// checkVoteAuthorizations looks up all of the start votes and authorize votes
+// in the cache and returns the start votes that do not have a matching
+// authorize vote.
+func (d *fonero) checkVoteAuthorizations() ([]foneroplugin.UnauthorizedVote, error) {
+	log.Tracef("fonero checkVoteAuthorizations")
+
+	svs := make([]StartVote, 0, 1024) // PNOOMA
+	err := d.recordsdb.
+		Select("token, version").
+		Find(&svs).
+		Error
+	if err != nil {
+		return nil, fmt.Errorf("lookup start votes: %v", err)
+	}
+
+	avs := make([]AuthorizeVote, 0, 1024) // PNOOMA
+	err = d.recordsdb.
+		Select("key, action").
+		Find(&avs).
+		Error
+	if err != nil {
+		return nil, fmt.Errorf("lookup authorize votes: %v", err)
+	}
+
+	return unauthorizedVotes(svs, avs), nil
+}
+
+// cmdVoteAuthorizationCheck returns the start votes in the cache that do not
+// have a matching authorize vote.
+func (d *fonero) cmdVoteAuthorizationCheck(payload string) (string, error) {
+	log.Tracef("fonero cmdVoteAuthorizationCheck")
+
+	_, err := foneroplugin.DecodeVoteAuthorizationCheck([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	uv, err := d.checkVoteAuthorizations()
+	if err != nil {
+		return "", err
+	}
+
+	vacr := foneroplugin.VoteAuthorizationCheckReply{
+		UnauthorizedVotes: uv,
+	}
+	vacrb, err := foneroplugin.EncodeVoteAuthorizationCheckReply(vacr)
+	if err != nil {
+		return "", err
+	}
+
+	return string(vacrb), nil
+}
+
+// checkTableIntegrity compares the expected primary keys of a table against
+// the primary keys that were read back from the database.  An empty string is
+// returned if the row counts and digests match, otherwise a description of the
+// discrepancy is returned.
+func checkTableIntegrity(table string, expected, actual []string) string {
+	if len(expected) != len(actual) {
+		return fmt.Sprintf("%v: got %v rows, want %v", table,
+			len(actual), len(expected))
+	}
+
+	e := foneroplugin.ComputeKeysDigest(expected)
+	a := foneroplugin.ComputeKeysDigest(actual)
+	if e != a {
+		return fmt.Sprintf("%v: got digest %v, want %v", table, a, e)
+	}
+
+	return ""
+}
+
+// integrityTables are the fonero plugin tables that are covered by the
+// integrity check and the inventory digest along with the query that selects
+// the primary keys of each table.  The keys are selected in the form in which
+// they can be derived from the fonero plugin inventory.  The queries take the
+// name of the table that is read so that both the live tables and the build
+// tables can be checked.
+var integrityTables = []struct {
+	name  string
+	query string
+}{
+	{tableComments, `SELECT key FROM %v`},
+	{tableCommentLikes, `SELECT token || comment_id || signature FROM %v`},
+	{tableCommentLikeStates, `SELECT key FROM %v`},
+	{tableAuthorizeVotes, `SELECT key FROM %v`},
+	{tableStartVotes, `SELECT token FROM %v`},
+	{tableCastVotes, `SELECT token || ticket FROM %v`},
+	{tableCastVoteArchives, `SELECT token || ':' || digest FROM %v`},
+}
+
+// castVoteArchiveKey returns the integrity key of a cast vote archive.
+func castVoteArchiveKey(token, digest string) string {
+	return token + ":" + digest
+}
+
+// inventoryKeys returns the primary keys, keyed by table, that the integrity
+// tables are expected to contain once the cache has been built from the
+// passed in inventory and the cast votes of the passed in tokens have been
+// archived.  Archived cast votes are not part of the cast votes table.  They
+// are covered by the digest of their cast vote archive instead.
+func inventoryKeys(ir *foneroplugin.InventoryReply, archived []string) map[string][]string {
+	comments := make([]string, 0, len(ir.Comments))
+	for _, v := range ir.Comments {
+		comments = append(comments, v.Token+v.CommentID)
+	}
+
+	likes := make([]string, 0, len(ir.LikeComments))
+	for _, v := range ir.LikeComments {
+		likes = append(likes, v.Token+v.CommentID+v.Signature)
+	}
+
+	states := likeCommentStates(ir.LikeComments)
+	likeStates := make([]string, 0, len(states))
+	for _, v := range states {
+		likeStates = append(likeStates, v.Key)
+	}
+
+	// Authorize votes are keyed by token+version and replace any
+	// previous authorize vote with the same key.  Authorize votes
+	// without a reply are skipped by the build.
+	avr := make(map[string]string, len(ir.AuthorizeVoteReplies)) // [receipt]version
+	for _, v := range ir.AuthorizeVoteReplies {
+		avr[v.Receipt] = v.RecordVersion
+	}
+	avKeys := make(map[string]struct{}, len(ir.AuthorizeVotes))
+	for _, v := range ir.AuthorizeVotes {
+		version, ok := avr[v.Receipt]
+		if !ok {
+			continue
+		}
+		avKeys[v.Token+version] = struct{}{}
+	}
+	authVotes := make([]string, 0, len(avKeys))
+	for k := range avKeys {
+		authVotes = append(authVotes, k)
+	}
+
+	startVotes := make([]string, 0, len(ir.StartVoteTuples))
+	for _, v := range ir.StartVoteTuples {
+		startVotes = append(startVotes, v.StartVote.Vote.Token)
+	}
+
+	isArchived := make(map[string]bool, len(archived))
+	for _, v := range archived {
+		isArchived[v] = true
+	}
+	castVotes := make([]string, 0, len(ir.CastVotes))
+	archivedVotes := make(map[string][]CastVote, len(archived)) // [token]CastVotes
+	for _, v := range ir.CastVotes {
+		if isArchived[v.Token] {
+			archivedVotes[v.Token] = append(archivedVotes[v.Token],
+				convertCastVoteFromFonero(v))
+			continue
+		}
+		castVotes = append(castVotes, v.Token+v.Ticket)
+	}
+
+	archives := make([]string, 0, len(archived))
+	for _, v := range archived {
+		archives = append(archives, castVoteArchiveKey(v,
+			castVotesDigest(archivedVotes[v])))
+	}
+
+	return map[string][]string{
+		tableComments:          comments,
+		tableCommentLikes:      likes,
+		tableCommentLikeStates: likeStates,
+		tableAuthorizeVotes:    authVotes,
+		tableStartVotes:        startVotes,
+		tableCastVotes:         castVotes,
+		tableCastVoteArchives:  archives,
+	}
+}
+
+// tableKeys reads back the primary keys of each of the integrity tables,
+// keyed by table.  The table function returns the name of the table that is
+// read so that either the live tables or the build tables can be read.
+func (d *fonero) tableKeys(table func(string) string) (map[string][]string, error) {
+	keys := make(map[string][]string, len(integrityTables))
+	for _, v := range integrityTables {
+		q := fmt.Sprintf(v.query, table(v.name))
+		k, err := d.queryStrings("integrity "+v.name, q)
+		if err != nil {
+			return nil, fmt.Errorf("%v keys: %v", v.name, err)
+		}
+		keys[v.name] = k
+	}
+
+	return keys, nil
+}
+
+// inventoryDigest returns the digest of the passed in primary keys of the
+// integrity tables.  The tables are digested in the order of the integrity
+// tables.  The archived tokens are taken from the cast vote archive keys.
+func inventoryDigest(keys map[string][]string) foneroplugin.InventoryDigestReply {
+	tables := make([]foneroplugin.TableDigest, 0, len(integrityTables))
+	for _, v := range integrityTables {
+		tables = append(tables, foneroplugin.TableDigest{
+			Table:  v.name,
+			Count:  len(keys[v.name]),
+			Digest: foneroplugin.ComputeKeysDigest(keys[v.name]),
+		})
+	}
+
+	archived := make([]string, 0, len(keys[tableCastVoteArchives]))
+	for _, v := range keys[tableCastVoteArchives] {
+		archived = append(archived, strings.SplitN(v, ":", 2)[0])
+	}
+	sort.Strings(archived)
+
+	return foneroplugin.InventoryDigestReply{
+		Digest:   foneroplugin.ComputeInventoryDigest(tables),
+		Tables:   tables,
+		Archived: archived,
+	}
+}
+
+// cmdInventoryDigest returns a digest of the contents of the fonero plugin
+// cache.  The digest is computed over the same primary keys that are verified
+// by the integrity check, so a caller can compare it against the digest of the
+// fonero plugin inventory and skip a rebuild when they match.  The reply lists
+// the tokens whose cast votes have been archived so that the caller can leave
+// their cast votes out of the inventory digest.
+func (d *fonero) cmdInventoryDigest() (string, error) {
+	log.Tracef("fonero cmdInventoryDigest")
+
+	keys, err := d.tableKeys(liveTableName)
+	if err != nil {
+		return "", err
+	}
+
+	reply, err := foneroplugin.EncodeInventoryDigestReply(
+		inventoryDigest(keys))
+	if err != nil {
+		return "", err
+	}
+
+	return string(reply), nil
+}
+
+// verifyIntegrity re-reads the row count and a digest of the primary keys of
+// each fonero plugin table and compares them against the inventory that the
+// cache was built from.  The table function returns the name of the table that
+// is verified.  A description of each discrepancy that was found is returned.
+// An empty slice means the tables match the inventory.
+func (d *fonero) verifyIntegrity(ir *foneroplugin.InventoryReply, table func(string) string) ([]string, error) {
+	log.Tracef("fonero verifyIntegrity")
+
+	// The cast votes of the tables that are verified have not been
+	// archived.
+	expected := inventoryKeys(ir, nil)
+	actual, err := d.tableKeys(table)
+	if err != nil {
+		return nil, err
+	}
+
+	discrepancies := make([]string, 0, len(integrityTables))
+	for _, v := range integrityTables {
+		s := checkTableIntegrity(v.name, expected[v.name], actual[v.name])
+		if s != "" {
+			discrepancies = append(discrepancies, s)
+		}
+	}
+
+	return discrepancies, nil
+}
+
+// Build uses the passed in inventory payload to rebuild the fonero plugin
+// cache.  The existing fonero plugin cache continues to serve reads until the
+// rebuilt tables replace it.  Commands that write to the fonero plugin tables
+// are blocked until the build has finished.  The build is aborted when the
+// context is cancelled and the version record is removed so that the cache is
+// rebuilt on the next start up.  Only one build may run at a time;
+// cache.ErrBuildInProgress is returned if a build is already running.
+func (d *fonero) Build(ctx context.Context, payload string) error {
 	log.Tracef("fonero Build")
 
+	// A concurrent build would drop and recreate the build tables
+	// that are being populated by the running build.
+	d.Lock()
+	if d.building {
+		d.Unlock()
+		return cache.ErrBuildInProgress
+	}
+	d.building = true
+	d.Unlock()
+
+	defer func() {
+		d.Lock()
+		d.building = false
+		d.Unlock()
+	}()
+
 	// Decode the payload
 	ir, err := foneroplugin.DecodeInventoryReply([]byte(payload))
 	if err != nil {
@@ -1239,8 +7036,25 @@ func (d *fonero) Build(payload string) error {
 
 	// Build the fonero plugin cache. This is not run using
 	// a transaction because it could potentially exceed
-	// cockroachdb's transaction size limit.
-	err = d.build(ir)
+	// cockroachdb's transaction size limit. Writes are blocked
+	// until the rebuilt tables are live so that they are not
+	// lost when the rebuilt tables replace the live tables.
+	d.buildMtx.Lock()
+	err = d.build(ctx, ir)
+	d.buildMtx.Unlock()
+	if err == nil {
+		// Report any votes that were started without being
+		// authorized.  This indicates inconsistent data in the
+		// backend but it is not fatal to the build.
+		uv, err1 := d.checkVoteAuthorizations()
+		if err1 != nil {
+			log.Errorf("checkVoteAuthorizations: %v", err1)
+		}
+		for _, v := range uv {
+			log.Warnf("Unauthorized vote %v version %v: %v",
+				v.Token, v.Version, v.Reason)
+		}
+	}
 	if err != nil {
 		// Remove the version record. This will
 		// force a rebuild on the next start up.
@@ -1258,18 +7072,43 @@ func (d *fonero) Build(payload string) error {
 
 // Setup creates the fonero plugin tables if they do not already exist.  A
 // fonero plugin version record is inserted into the database during table
-// creation.
+// creation.  The connection pool settings are applied to the database before
+// the tables are created and orphaned vote option results are removed once the
+// tables exist.
 func (d *fonero) Setup() error {
 	log.Tracef("fonero: Setup")
 
+	// Apply the connection pool settings
+	db := d.recordsdb.DB()
+	db.SetMaxOpenConns(d.pool.maxOpen)
+	if d.pool.maxIdle >= 0 {
+		db.SetMaxIdleConns(d.pool.maxIdle)
+	}
+	db.SetConnMaxLifetime(d.pool.maxLifetime)
+
 	tx := d.recordsdb.Begin()
 	err := d.createTables(tx)
 	if err != nil {
 		tx.Rollback()
 		return err
 	}
+	err = tx.Commit().Error
+	if err != nil {
+		return err
+	}
 
-	return tx.Commit().Error
+	// Remove any vote option results that were left behind
+	// by a vote results record being deleted.
+	keys, err := d.removeOrphanedVoteOptionResults()
+	if err != nil {
+		return err
+	}
+	if len(keys) > 0 {
+		log.Warnf("Removed %v orphaned vote option results: %v",
+			len(keys), strings.Join(keys, ", "))
+	}
+
+	return nil
 }
 
 // CheckVersion retrieves the fonero plugin version record from the database,
@@ -1304,12 +7143,65 @@ func (d *fonero) CheckVersion() error {
 	return err
 }
 
-// newFoneroPlugin returns a cache fonero plugin context.
-func newFoneroPlugin(db *gorm.DB, p cache.Plugin) *fonero {
+// newFoneroPlugin returns a cache fonero plugin context.  The best block
+// source is optional and may be nil.
+func newFoneroPlugin(db *gorm.DB, p cache.Plugin, bbs cache.BestBlockSource) *fonero {
 	log.Tracef("newFoneroPlugin")
+
+	slowQuery := defaultSlowQueryThreshold
+	var computeVoteResults bool
+	maxAuthVoteSkips := defaultMaxAuthorizeVoteSkips
+	censorMode := censorModePurge
+	for _, v := range p.Settings {
+		switch v.Key {
+		case settingSlowQueryThreshold:
+			threshold, err := time.ParseDuration(v.Value)
+			if err != nil || threshold < 0 {
+				log.Errorf("newFoneroPlugin: invalid %v '%v', using %v",
+					settingSlowQueryThreshold, v.Value, slowQuery)
+				continue
+			}
+			slowQuery = threshold
+		case settingComputeVoteResults:
+			compute, err := strconv.ParseBool(v.Value)
+			if err != nil {
+				log.Errorf("newFoneroPlugin: invalid %v '%v', using %v",
+					settingComputeVoteResults, v.Value,
+					computeVoteResults)
+				continue
+			}
+			computeVoteResults = compute
+		case settingMaxAuthorizeVoteSkips:
+			skips, err := strconv.Atoi(v.Value)
+			if err != nil || skips < 0 {
+				log.Errorf("newFoneroPlugin: invalid %v '%v', using %v",
+					settingMaxAuthorizeVoteSkips, v.Value,
+					maxAuthVoteSkips)
+				continue
+			}
+			maxAuthVoteSkips = skips
+		case settingCensoredCommentBody:
+			switch v.Value {
+			case censorModePurge, censorModeAudit:
+				censorMode = v.Value
+			default:
+				log.Errorf("newFoneroPlugin: invalid %v '%v', using %v",
+					settingCensoredCommentBody, v.Value, censorMode)
+			}
+		}
+	}
+
 	return &fonero{
-		recordsdb: db,
-		version:   foneroVersion,
-		settings:  p.Settings,
+		recordsdb:          db,
+		version:            foneroVersion,
+		settings:           p.Settings,
+		bestBlockSource:    bbs,
+		slowQuery:          slowQuery,
+		now:                time.Now,
+		pool:               parsePoolSettings(p.Settings),
+		limiters:           parseCommandLimits(p.Settings),
+		computeVoteResults: computeVoteResults,
+		maxAuthVoteSkips:   maxAuthVoteSkips,
+		censorMode:         censorMode,
 	}
 }
@@ -0,0 +1,4365 @@
+// Copyright (c) 2017-2019 The Fonero developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package cockroachdb
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/decred/slog"
+	"github.com/fonero-project/fnod/fnoec/secp256k1"
+	"github.com/fonero-project/politeia/foneroplugin"
+	pd "github.com/fonero-project/politeia/politeiad/api/v1"
+	"github.com/fonero-project/politeia/politeiad/api/v1/identity"
+	"github.com/fonero-project/politeia/politeiad/cache"
+	"github.com/jinzhu/gorm"
+)
+
+// testBestBlockSource implements the cache BestBlockSource interface.
+type testBestBlockSource struct {
+	height uint64
+	err    error
+}
+
+// BestBlock returns the best block height of the test best block source.
+func (s *testBestBlockSource) BestBlock() (uint64, error) {
+	return s.height, s.err
+}
+
+func TestBestBlock(t *testing.T) {
+	errSource := errors.New("source unavailable")
+
+	var tests = []struct {
+		name      string
+		source    *testBestBlockSource
+		requested uint64
+		want      uint64
+		wantErr   bool
+	}{
+		{"no source no request", nil, 0, 0, true},
+		{"no source with request", nil, 100, 100, false},
+		{"source overrides request", &testBestBlockSource{height: 200},
+			100, 200, false},
+		{"source without request", &testBestBlockSource{height: 200},
+			0, 200, false},
+		{"source error", &testBestBlockSource{err: errSource},
+			100, 0, true},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			d := newFoneroPlugin(nil, cache.Plugin{}, nil)
+			if v.source != nil {
+				d.bestBlockSource = v.source
+			}
+
+			bb, err := d.bestBlock(v.requested)
+			if (err != nil) != v.wantErr {
+				t.Fatalf("got error %v, want error %v",
+					err, v.wantErr)
+			}
+			if bb != v.want {
+				t.Fatalf("got best block %v, want %v", bb, v.want)
+			}
+		})
+	}
+}
+
+func TestCmdBestBlock(t *testing.T) {
+	errSource := errors.New("source unavailable")
+
+	var tests = []struct {
+		name    string
+		source  *testBestBlockSource
+		last    uint64 // Best block the cache was told about
+		reply   string // Reply payload from politeiad
+		want    uint64
+		wantErr bool
+	}{
+		{"source", &testBestBlockSource{height: 700}, 0, "", 700, false},
+		{"source overrides reply", &testBestBlockSource{height: 700},
+			0, "500", 700, false},
+		{"source error", &testBestBlockSource{err: errSource}, 0, "",
+			0, true},
+		{"reply", nil, 0, "500", 500, false},
+		{"last best block", nil, 500, "", 500, false},
+		{"stale reply", nil, 500, "400", 500, false},
+		{"invalid reply", nil, 500, "block", 0, true},
+		{"no best block", nil, 0, "", 0, true},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			d := newFoneroPlugin(nil, cache.Plugin{}, nil)
+			if v.source != nil {
+				d.bestBlockSource = v.source
+			}
+			d.lastBestBlock = v.last
+
+			reply, err := d.cmdBestBlock(v.reply)
+			if (err != nil) != v.wantErr {
+				t.Fatalf("got error %v, want error %v",
+					err, v.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			bbr, err := foneroplugin.DecodeBestBlockReply([]byte(reply))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if bbr.Height != v.want {
+				t.Fatalf("got best block %v, want %v", bbr.Height,
+					v.want)
+			}
+		})
+	}
+}
+
+func TestCheckTableIntegrity(t *testing.T) {
+	expected := []string{"a1", "a2", "b1"}
+
+	var tests = []struct {
+		name    string
+		actual  []string
+		wantErr bool
+	}{
+		{"match", []string{"a1", "a2", "b1"}, false},
+		{"match different order", []string{"b1", "a1", "a2"}, false},
+		{"missing row", []string{"a1", "a2"}, true},
+		{"extra row", []string{"a1", "a2", "b1", "b2"}, true},
+		{"corrupt row", []string{"a1", "a2", "b2"}, true},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			s := checkTableIntegrity(tableComments, expected, v.actual)
+			if (s != "") != v.wantErr {
+				t.Fatalf("got discrepancy '%v', want discrepancy %v",
+					s, v.wantErr)
+			}
+		})
+	}
+}
+
+func TestInventoryDigest(t *testing.T) {
+	ir := &foneroplugin.InventoryReply{
+		Comments: []foneroplugin.Comment{
+			{Token: "a", CommentID: "1"},
+			{Token: "a", CommentID: "2"},
+		},
+		CastVotes: []foneroplugin.CastVote{
+			{Token: "a", Ticket: "t1"},
+		},
+	}
+
+	// The digest does not depend on the order of the inventory
+	want := inventoryDigest(inventoryKeys(ir, nil))
+	ir.Comments[0], ir.Comments[1] = ir.Comments[1], ir.Comments[0]
+	got := inventoryDigest(inventoryKeys(ir, nil))
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got digest %v, want %v", got, want)
+	}
+
+	// Adding a row changes the digest of its table only
+	ir.Comments = append(ir.Comments,
+		foneroplugin.Comment{Token: "b", CommentID: "1"})
+	got = inventoryDigest(inventoryKeys(ir, nil))
+	if got.Digest == want.Digest {
+		t.Fatalf("digest did not change")
+	}
+	for i, v := range got.Tables {
+		changed := v != want.Tables[i]
+		if changed != (v.Table == tableComments) {
+			t.Fatalf("table %v: got changed %v", v.Table, changed)
+		}
+	}
+	if got.Tables[0].Count != 3 {
+		t.Fatalf("got %v comments, want 3", got.Tables[0].Count)
+	}
+
+	// Archived cast votes are covered by the cast vote archives
+	// table instead of the cast votes table.
+	want = got
+	got = inventoryDigest(inventoryKeys(ir, []string{"a"}))
+	if !reflect.DeepEqual(got.Archived, []string{"a"}) {
+		t.Fatalf("got archived %v, want [a]", got.Archived)
+	}
+	for i, v := range got.Tables {
+		changed := v != want.Tables[i]
+		wantChanged := v.Table == tableCastVotes ||
+			v.Table == tableCastVoteArchives
+		if changed != wantChanged {
+			t.Fatalf("table %v: got changed %v", v.Table, changed)
+		}
+		if wantChanged && v.Count != 1-want.Tables[i].Count {
+			t.Fatalf("table %v: got count %v", v.Table, v.Count)
+		}
+	}
+}
+
+func TestCmdInventoryDigest(t *testing.T) {
+	d, cleanup := newTestFonero(t)
+	defer cleanup()
+
+	ir := &foneroplugin.InventoryReply{
+		Comments: []foneroplugin.Comment{
+			{Token: "a", CommentID: "1"},
+			{Token: "a", CommentID: "2"},
+		},
+		CastVotes: []foneroplugin.CastVote{
+			{Token: "a", Ticket: "t1", VoteBit: "1"},
+		},
+	}
+	for _, v := range ir.Comments {
+		c := convertCommentFromFonero(v)
+		testInsert(t, d.recordsdb, &c)
+	}
+	for _, v := range ir.CastVotes {
+		cv := convertCastVoteFromFonero(v)
+		testInsert(t, d.recordsdb, &cv)
+	}
+
+	// digest returns the digest of the cache
+	digest := func() foneroplugin.InventoryDigestReply {
+		t.Helper()
+		reply, err := d.cmdInventoryDigest()
+		if err != nil {
+			t.Fatalf("cmdInventoryDigest: %v", err)
+		}
+		idr, err := foneroplugin.DecodeInventoryDigestReply([]byte(reply))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return *idr
+	}
+
+	// The cache digest is computed over the keys that are read
+	// back from the database, so it matches the digest of the
+	// inventory that the cache contains.
+	want := inventoryDigest(inventoryKeys(ir, nil))
+	for i := 0; i < 2; i++ {
+		got := digest()
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("got cache digest %v, want %v", got, want)
+		}
+	}
+
+	// Adding a row to the cache changes the digest
+	c := convertCommentFromFonero(foneroplugin.Comment{
+		Token:     "b",
+		CommentID: "1",
+	})
+	testInsert(t, d.recordsdb, &c)
+	got := digest()
+	if got.Digest == want.Digest {
+		t.Fatalf("cache digest did not change")
+	}
+	ir.Comments = append(ir.Comments, convertCommentToFonero(c))
+	want = inventoryDigest(inventoryKeys(ir, nil))
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got cache digest %v, want %v", got, want)
+	}
+
+	// Archiving the cast votes of a proposal removes them from the
+	// cast votes table.  The digest still matches the inventory
+	// once the archived proposal is accounted for.
+	d.bestBlockSource = &testBestBlockSource{height: 500}
+	testInsert(t, d.recordsdb, &StartVote{
+		Token:     "a",
+		EndHeight: 100,
+	}, &VoteResults{
+		Token: "a",
+	})
+	payload, err := foneroplugin.EncodeArchiveProposalVotes(
+		foneroplugin.ArchiveProposalVotes{
+			Token: "a",
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = d.cmdArchiveProposalVotes(string(payload))
+	if err != nil {
+		t.Fatalf("cmdArchiveProposalVotes: %v", err)
+	}
+	ir.StartVoteTuples = []foneroplugin.StartVoteTuple{{
+		StartVote: foneroplugin.StartVote{
+			Vote: foneroplugin.Vote{
+				Token: "a",
+			},
+		},
+	}}
+	got = digest()
+	want = inventoryDigest(inventoryKeys(ir, got.Archived))
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got cache digest %v, want %v", got, want)
+	}
+	if !reflect.DeepEqual(got.Archived, []string{"a"}) {
+		t.Fatalf("got archived %v, want [a]", got.Archived)
+	}
+}
+
+func TestVerifyCountsArchivedVotes(t *testing.T) {
+	d, cleanup := newTestFonero(t)
+	defer cleanup()
+
+	// Two cast votes are cached and three have been archived
+	testInsert(t, d.recordsdb, &CastVote{
+		Token:  "a",
+		Ticket: "t1",
+	}, &CastVote{
+		Token:  "a",
+		Ticket: "t2",
+	}, &CastVoteArchive{
+		Token:  "b",
+		Digest: "digest",
+		Votes:  3,
+	})
+
+	payload, err := foneroplugin.EncodeVerifyCounts(
+		foneroplugin.VerifyCounts{
+			CastVotes: 5,
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reply, err := d.cmdVerifyCounts(string(payload))
+	if err != nil {
+		t.Fatalf("cmdVerifyCounts: %v", err)
+	}
+	vcr, err := foneroplugin.DecodeVerifyCountsReply([]byte(reply))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vcr.Diverged {
+		t.Fatalf("got diverged counts %v", vcr.Deltas)
+	}
+	for _, v := range vcr.Deltas {
+		if v.Table == foneroplugin.VerifyCountsCastVotes && v.Cache != 5 {
+			t.Fatalf("got %v cast votes, want 5", v.Cache)
+		}
+	}
+}
+
+func TestVoteIsApproved(t *testing.T) {
+	yes := VoteOption{ID: voteOptionIDApproved, Bits: 2}
+	no := VoteOption{ID: "no", Bits: 1}
+
+	// results returns the vote option results for the passed in
+	// number of yes and no votes.
+	results := func(yesVotes, noVotes uint64) []VoteOptionResult {
+		return []VoteOptionResult{
+			{Votes: yesVotes, Option: yes},
+			{Votes: noVotes, Option: no},
+		}
+	}
+
+	sv := StartVote{
+		QuorumPercentage:    20,
+		PassPercentage:      60,
+		EligibleTicketCount: 10,
+	}
+	svNoTickets := sv
+	svNoTickets.EligibleTicketCount = 0
+
+	var tests = []struct {
+		name    string
+		sv      StartVote
+		results []VoteOptionResult
+		want    bool
+	}{
+		{"no eligible tickets", svNoTickets, results(0, 0), false},
+		{"no eligible tickets with votes", svNoTickets, results(5, 0), false},
+		{"no votes", sv, results(0, 0), false},
+		{"quorum not met", sv, results(1, 0), false},
+		{"pass not met", sv, results(1, 4), false},
+		{"approved", sv, results(3, 1), true},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			got := voteIsApproved(v.sv, v.results)
+			if got != v.want {
+				t.Fatalf("got approved %v, want %v", got, v.want)
+			}
+		})
+	}
+}
+
+func TestVoteOptionResultPaths(t *testing.T) {
+	options := []VoteOption{
+		{
+			Key:         1,
+			Token:       "a",
+			ID:          "no",
+			Description: "Don't approve proposal",
+			Bits:        0x01,
+		},
+		{
+			Key:         2,
+			Token:       "a",
+			ID:          "yes",
+			Description: "Approve proposal",
+			Bits:        0x02,
+		},
+		{
+			Key:         3,
+			Token:       "a",
+			ID:          "abstain",
+			Description: "Abstain from voting",
+			Bits:        0x1f,
+		},
+	}
+	tally := map[string]uint64{
+		"1":  3,
+		"2":  7,
+		"1f": 0,
+	}
+
+	// The results of a finished vote are stored in the vote
+	// results table while the results of an active vote are
+	// counted manually. Both paths must describe the options
+	// identically.
+	stored := convertVoteOptionResultsToFonero(
+		newVoteOptionResults("a", options, tally))
+	counted := tallyVoteOptionResults(options, tally)
+	if !reflect.DeepEqual(stored, counted) {
+		t.Fatalf("got stored results %v, counted results %v",
+			stored, counted)
+	}
+
+	for i, v := range counted {
+		o := options[i]
+		if v.ID != o.ID || v.Description != o.Description ||
+			v.Bits != o.Bits {
+			t.Fatalf("result %v: got option %v %q %v, want %v %q %v",
+				i, v.ID, v.Description, v.Bits, o.ID, o.Description,
+				o.Bits)
+		}
+		want := tally[strconv.FormatUint(o.Bits, 16)]
+		if v.Votes != want {
+			t.Fatalf("result %v: got %v votes, want %v",
+				i, v.Votes, want)
+		}
+	}
+}
+
+func TestVoteOptionCounts(t *testing.T) {
+	var tests = []struct {
+		name    string
+		results []foneroplugin.VoteOptionResult
+		want    map[string]uint64
+	}{
+		{"no options", nil, map[string]uint64{}},
+		{"votes cast", []foneroplugin.VoteOptionResult{
+			{ID: "no", Bits: 0x01, Votes: 4},
+			{ID: "yes", Bits: 0x02, Votes: 9},
+			{ID: "abstain", Bits: 0x1f, Votes: 1},
+		}, map[string]uint64{"1": 4, "2": 9, "1f": 1}},
+		{"no votes", []foneroplugin.VoteOptionResult{
+			{ID: "no", Bits: 0x01},
+			{ID: "yes", Bits: 0x02},
+		}, map[string]uint64{"1": 0, "2": 0}},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			got := voteOptionCounts(v.results)
+			if !reflect.DeepEqual(got, v.want) {
+				t.Fatalf("got %v, want %v", got, v.want)
+			}
+		})
+	}
+}
+
+func TestApproveVoteOptions(t *testing.T) {
+	// Option c has no pass percentage of its own and
+	// falls back to the start vote pass percentage.
+	a := VoteOption{ID: "a", Bits: 1, PassPercentage: 30}
+	b := VoteOption{ID: "b", Bits: 2, PassPercentage: 20}
+	c := VoteOption{ID: "c", Bits: 4}
+
+	// results returns the vote option results for the passed
+	// in number of votes for options a, b and c.
+	results := func(aVotes, bVotes, cVotes uint64) []VoteOptionResult {
+		return []VoteOptionResult{
+			{Votes: aVotes, Option: a},
+			{Votes: bVotes, Option: b},
+			{Votes: cVotes, Option: c},
+		}
+	}
+
+	sv := StartVote{
+		Type:                foneroplugin.VoteTypeApproval,
+		QuorumPercentage:    20,
+		PassPercentage:      40,
+		EligibleTicketCount: 10,
+	}
+	svNoTickets := sv
+	svNoTickets.EligibleTicketCount = 0
+
+	var tests = []struct {
+		name         string
+		sv           StartVote
+		results      []VoteOptionResult
+		want         bool
+		wantApproved []bool
+	}{
+		{"no eligible tickets", svNoTickets, results(5, 5, 0), false,
+			[]bool{false, false, false}},
+		{"no votes", sv, results(0, 0, 0), false,
+			[]bool{false, false, false}},
+		{"quorum not met", sv, results(1, 0, 0), false,
+			[]bool{false, false, false}},
+		{"two pass one fails", sv, results(4, 3, 3), true,
+			[]bool{true, true, false}},
+		{"fallback pass percentage", sv, results(1, 1, 8), true,
+			[]bool{false, false, true}},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			got := approveVoteOptions(v.sv, v.results)
+			if got != v.want {
+				t.Fatalf("got approved %v, want %v", got, v.want)
+			}
+			for i, r := range v.results {
+				if r.Approved != v.wantApproved[i] {
+					t.Fatalf("option %v: got approved %v, want %v",
+						r.Option.ID, r.Approved, v.wantApproved[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCommentNeedsUpdate(t *testing.T) {
+	c := Comment{
+		Key:       "token1",
+		Token:     "token",
+		ParentID:  "0",
+		Comment:   "comment",
+		Signature: "signature",
+		PublicKey: "publickey",
+		CommentID: "1",
+		Receipt:   "receipt",
+		Timestamp: 1,
+	}
+
+	changed := c
+	changed.Comment = "edited comment"
+
+	censored := c
+	censored.Comment = ""
+	censored.Censored = true
+
+	var tests = []struct {
+		name     string
+		existing Comment
+		replayed Comment
+		want     bool
+	}{
+		{"identical replay", c, c, false},
+		{"different content", c, changed, true},
+		{"censored comment", censored, c, false},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			got := commentNeedsUpdate(v.existing, v.replayed)
+			if got != v.want {
+				t.Fatalf("got needs update %v, want %v", got, v.want)
+			}
+		})
+	}
+}
+
+func TestValidateVoteOptions(t *testing.T) {
+	yes := foneroplugin.VoteOption{Id: "yes", Bits: 0x02}
+	no := foneroplugin.VoteOption{Id: "no", Bits: 0x01}
+	abstain := foneroplugin.VoteOption{Id: "abstain", Bits: 0x04}
+	overlap := foneroplugin.VoteOption{Id: "overlap", Bits: 0x03}
+	empty := foneroplugin.VoteOption{Id: "empty", Bits: 0}
+
+	var tests = []struct {
+		name    string
+		mask    uint64
+		options []foneroplugin.VoteOption
+		wantErr bool
+	}{
+		{"valid", 0x03, []foneroplugin.VoteOption{no, yes}, false},
+		{"overlapping bits", 0x03,
+			[]foneroplugin.VoteOption{no, yes, overlap}, true},
+		{"out of mask", 0x03,
+			[]foneroplugin.VoteOption{no, yes, abstain}, true},
+		{"no bits", 0x03, []foneroplugin.VoteOption{no, empty}, true},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			err := validateVoteOptions(v.mask, v.options)
+			if (err != nil) != v.wantErr {
+				t.Fatalf("got error %v, want error %v",
+					err, v.wantErr)
+			}
+		})
+	}
+}
+
+// newTestFonero returns a fonero plugin that uses a new test database.  The
+// plugin is configured using the passed in plugin settings and the fonero
+// plugin tables are created.  The returned function closes and drops the test
+// database.
+func newTestFonero(t *testing.T, settings ...cache.PluginSetting) (*fonero, func()) {
+	t.Helper()
+
+	db, cleanup := newTestDB(t)
+	d := newFoneroPlugin(db, cache.Plugin{
+		Settings: settings,
+	}, nil)
+
+	tx := db.Begin()
+	err := d.createTables(tx)
+	if err != nil {
+		tx.Rollback()
+		cleanup()
+		t.Fatalf("create tables: %v", err)
+	}
+	err = tx.Commit().Error
+	if err != nil {
+		cleanup()
+		t.Fatalf("commit transaction: %v", err)
+	}
+
+	return d, cleanup
+}
+
+// testInsert inserts the passed in records into the test database.  The
+// records must be pointers to models.
+func testInsert(t *testing.T, db *gorm.DB, records ...interface{}) {
+	t.Helper()
+
+	for _, v := range records {
+		err := db.Create(v).Error
+		if err != nil {
+			t.Fatalf("insert %T: %v", v, err)
+		}
+	}
+}
+
+// testQuery returns the strings that are selected by the passed in query.
+func testQuery(t *testing.T, d *fonero, q string, args ...interface{}) []string {
+	t.Helper()
+
+	s, err := d.queryStrings("test", q, args...)
+	if err != nil {
+		t.Fatalf("query %q: %v", q, err)
+	}
+	return s
+}
+
+// testFailValue is the value that causes a write to fail once a column has
+// been set up to reject it using testFailColumn.
+const testFailValue = "fail"
+
+// testFailColumn adds a constraint to the passed in table that rejects any
+// row whose column is set to the test fail value.  It is used to make a
+// specific write fail.
+func testFailColumn(t *testing.T, db *gorm.DB, table, column string) {
+	t.Helper()
+
+	q := fmt.Sprintf("ALTER TABLE %v ADD CONSTRAINT %v_%v_fail "+
+		"CHECK (%v != '%v')", table, table, column, column, testFailValue)
+	err := db.Exec(q).Error
+	if err != nil {
+		t.Fatalf("add constraint: %v", err)
+	}
+}
+
+func TestQueryStrings(t *testing.T) {
+	d, cleanup := newTestFonero(t)
+	defer cleanup()
+
+	for i, v := range []string{"a", "b", "c"} {
+		testInsert(t, d.recordsdb, &StartVote{
+			Token:     v,
+			EndHeight: uint64(i + 1),
+		})
+	}
+
+	// Run the query repeatedly and ensure that the connection
+	// is released back to the pool after every query.
+	want := []string{"b", "c"}
+	for i := 0; i < 100; i++ {
+		strs, err := d.queryStrings("test", "SELECT token FROM "+
+			"start_votes WHERE end_height > ? ORDER BY token", 1)
+		if err != nil {
+			t.Fatalf("queryStrings: %v", err)
+		}
+		if !reflect.DeepEqual(strs, want) {
+			t.Fatalf("got %v, want %v", strs, want)
+		}
+
+		stats := d.recordsdb.DB().Stats()
+		if stats.InUse != 0 {
+			t.Fatalf("got %v connections in use, want 0", stats.InUse)
+		}
+	}
+}
+
+func TestValidateReparent(t *testing.T) {
+	// Comment tree: 1 is the parent of 2, 2 is the parent of 3,
+	// and 4 is a top level comment.
+	comments := map[string]Comment{
+		"1": {CommentID: "1", ParentID: "0"},
+		"2": {CommentID: "2", ParentID: "1"},
+		"3": {CommentID: "3", ParentID: "2"},
+		"4": {CommentID: "4", ParentID: "0"},
+	}
+
+	var tests = []struct {
+		name      string
+		commentID string
+		parentID  string
+		wantErr   bool
+	}{
+		{"valid reparent", "4", "3", false},
+		{"move subtree", "2", "4", false},
+		{"move to top level", "3", "0", false},
+		{"comment not found", "5", "1", true},
+		{"parent not found", "4", "5", true},
+		{"parent is itself", "2", "2", true},
+		{"parent is child", "1", "2", true},
+		{"parent is grandchild", "1", "3", true},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			err := validateReparent(comments, v.commentID, v.parentID)
+			if (err != nil) != v.wantErr {
+				t.Fatalf("got error %v, want error %v",
+					err, v.wantErr)
+			}
+		})
+	}
+}
+
+func TestBase64DecodedLen(t *testing.T) {
+	for n := 0; n < 10; n++ {
+		b64 := base64.StdEncoding.EncodeToString(make([]byte, n))
+		tail := b64
+		if len(tail) > 2 {
+			tail = tail[len(tail)-2:]
+		}
+		got := base64DecodedLen(int64(len(b64)), tail)
+		if got != int64(n) {
+			t.Fatalf("%q: got size %v, want %v", b64, got, n)
+		}
+	}
+}
+
+func TestTokenInventoryCountsOnly(t *testing.T) {
+	d, cleanup := newTestFonero(t)
+	defer cleanup()
+
+	// There is a single proposal in every stage of the voting
+	// process at a best block of 100.
+	testInsert(t, d.recordsdb,
+		&Record{
+			Key:       "pre1",
+			Token:     "pre",
+			Version:   1,
+			Status:    int(pd.RecordStatusPublic),
+			Timestamp: 1,
+		},
+		&Record{
+			Key:       "abandoned1",
+			Token:     "abandoned",
+			Version:   1,
+			Status:    int(pd.RecordStatusArchived),
+			Timestamp: 2,
+		},
+		&StartVote{Token: "active", EndHeight: 200},
+		&StartVote{Token: "approved", EndHeight: 50},
+		&StartVote{Token: "rejected", EndHeight: 60},
+		&VoteResults{Token: "approved", Approved: true},
+		&VoteResults{Token: "rejected", Approved: false})
+
+	// tokenInventory executes the token inventory command.
+	tokenInventory := func(countsOnly bool) *foneroplugin.TokenInventoryReply {
+		payload, err := foneroplugin.EncodeTokenInventory(
+			foneroplugin.TokenInventory{
+				BestBlock:  100,
+				CountsOnly: countsOnly,
+			})
+		if err != nil {
+			t.Fatalf("encode token inventory: %v", err)
+		}
+		reply, err := d.cmdTokenInventory(string(payload))
+		if err != nil {
+			t.Fatalf("cmdTokenInventory: %v", err)
+		}
+		tir, err := foneroplugin.DecodeTokenInventoryReply([]byte(reply))
+		if err != nil {
+			t.Fatalf("decode token inventory reply: %v", err)
+		}
+		return tir
+	}
+
+	full := tokenInventory(false)
+	counts := tokenInventory(true)
+
+	got := [][]string{full.Pre, full.Active, full.Approved, full.Rejected,
+		full.Abandoned}
+	want := [][]string{{"pre"}, {"active"}, {"approved"}, {"rejected"},
+		{"abandoned"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got tokens %v, want %v", got, want)
+	}
+
+	wantCounts := foneroplugin.TokenInventoryCounts{
+		Pre:       1,
+		Active:    1,
+		Approved:  1,
+		Rejected:  1,
+		Abandoned: 1,
+	}
+	if full.Counts != wantCounts {
+		t.Fatalf("got full counts %+v, want %+v", full.Counts, wantCounts)
+	}
+	if counts.Counts != wantCounts {
+		t.Fatalf("got counts only counts %+v, want %+v",
+			counts.Counts, wantCounts)
+	}
+
+	// Token lists must not be returned when only the
+	// counts are requested.
+	if counts.Pre != nil || counts.Active != nil || counts.Approved != nil ||
+		counts.Rejected != nil || counts.Abandoned != nil {
+		t.Fatalf("got tokens in counts only reply: %+v", counts)
+	}
+}
+
+func TestTokenInventoryPagination(t *testing.T) {
+	d, cleanup := newTestFonero(t)
+	defer cleanup()
+
+	// The approved tokens are sorted by vote end height in
+	// descending order.
+	tokens := []string{"a", "b", "c", "d", "e"}
+	for i, v := range tokens {
+		testInsert(t, d.recordsdb,
+			&StartVote{Token: v, EndHeight: uint64(i + 1)},
+			&VoteResults{Token: v, Approved: true})
+	}
+
+	// approved executes the token inventory command and returns
+	// the approved tokens and the cursor of the next page.
+	approved := func(limit uint, cursor string) ([]string, string) {
+		payload, err := foneroplugin.EncodeTokenInventory(
+			foneroplugin.TokenInventory{
+				BestBlock: 10,
+				Limit:     limit,
+				Cursors: foneroplugin.TokenInventoryCursors{
+					Approved: cursor,
+				},
+			})
+		if err != nil {
+			t.Fatalf("encode token inventory: %v", err)
+		}
+		reply, err := d.cmdTokenInventory(string(payload))
+		if err != nil {
+			t.Fatalf("cmdTokenInventory: %v", err)
+		}
+		tir, err := foneroplugin.DecodeTokenInventoryReply([]byte(reply))
+		if err != nil {
+			t.Fatalf("decode token inventory reply: %v", err)
+		}
+		if tir.Counts.Approved != len(tokens) {
+			t.Fatalf("got approved count %v, want %v",
+				tir.Counts.Approved, len(tokens))
+		}
+		return tir.Approved, tir.Next.Approved
+	}
+
+	// Page through the approved tokens
+	var (
+		pages  [][]string
+		cursor string
+	)
+	for {
+		page, next := approved(2, cursor)
+		pages = append(pages, page)
+		if next == "" {
+			break
+		}
+		if len(pages) > len(tokens) {
+			t.Fatalf("pagination did not terminate")
+		}
+		cursor = next
+	}
+
+	want := [][]string{{"e", "d"}, {"c", "b"}, {"a"}}
+	if !reflect.DeepEqual(pages, want) {
+		t.Fatalf("got pages %v, want %v", pages, want)
+	}
+
+	// All tokens are returned when no limit is provided
+	all, next := approved(0, "")
+	if !reflect.DeepEqual(all, []string{"e", "d", "c", "b", "a"}) {
+		t.Fatalf("got tokens %v, want all tokens", all)
+	}
+	if next != "" {
+		t.Fatalf("got next cursor %v, want none", next)
+	}
+
+	// An invalid cursor is rejected
+	payload, err := foneroplugin.EncodeTokenInventory(
+		foneroplugin.TokenInventory{
+			BestBlock: 10,
+			Limit:     2,
+			Cursors: foneroplugin.TokenInventoryCursors{
+				Approved: "invalid",
+			},
+		})
+	if err != nil {
+		t.Fatalf("encode token inventory: %v", err)
+	}
+	_, err = d.cmdTokenInventory(string(payload))
+	if err == nil {
+		t.Fatalf("got nil error for invalid cursor")
+	}
+}
+
+func TestTokenInventoryPage(t *testing.T) {
+	q := tokenInventoryQuery{
+		query: "SELECT token, end_height FROM start_votes WHERE end_height > ?",
+		token: "token",
+		key:   "end_height",
+		args:  []interface{}{1},
+	}
+	const order = " ORDER BY end_height DESC, token DESC"
+
+	var tests = []struct {
+		name      string
+		cursor    string
+		limit     uint
+		wantQuery string
+		wantArgs  []interface{}
+		wantErr   bool
+	}{
+		{"all", "", 0, q.query + order, []interface{}{1}, false},
+		{"first page", "", 2, q.query + order + " LIMIT ?",
+			[]interface{}{1, uint(3)}, false},
+		{"next page", "5:e", 2, q.query + " AND (end_height < ? OR " +
+			"(end_height = ? AND token < ?))" + order + " LIMIT ?",
+			[]interface{}{1, int64(5), int64(5), "e", uint(3)}, false},
+		{"missing token", "5:", 2, "", nil, true},
+		{"invalid key", "e:e", 2, "", nil, true},
+		{"no separator", "invalid", 2, "", nil, true},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			query, args, err := q.page(v.cursor, v.limit)
+			if (err != nil) != v.wantErr {
+				t.Fatalf("got error %v, want error %v",
+					err, v.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if query != v.wantQuery {
+				t.Fatalf("got query %q, want %q", query, v.wantQuery)
+			}
+			if !reflect.DeepEqual(args, v.wantArgs) {
+				t.Fatalf("got args %v, want %v", args, v.wantArgs)
+			}
+		})
+	}
+}
+
+func TestPageTokenInventory(t *testing.T) {
+	entries := []tokenInventoryEntry{
+		{token: "e", key: 5},
+		{token: "d", key: 4},
+		{token: "c", key: 3},
+	}
+
+	var tests = []struct {
+		name       string
+		entries    []tokenInventoryEntry
+		limit      uint
+		wantTokens []string
+		wantNext   string
+	}{
+		{"no limit", entries, 0, []string{"e", "d", "c"}, ""},
+		{"next page", entries, 2, []string{"e", "d"}, "4:d"},
+		{"last page", entries, 3, []string{"e", "d", "c"}, ""},
+		{"no entries", nil, 2, []string{}, ""},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			tokens, next := pageTokenInventory(v.entries, v.limit)
+			if !reflect.DeepEqual(tokens, v.wantTokens) {
+				t.Fatalf("got tokens %v, want %v", tokens, v.wantTokens)
+			}
+			if next != v.wantNext {
+				t.Fatalf("got next %q, want %q", next, v.wantNext)
+			}
+		})
+	}
+}
+
+func TestTokenInventoryMissingVoteResults(t *testing.T) {
+	var tests = []struct {
+		name     string
+		settings []cache.PluginSetting
+		wantErr  error
+	}{
+		{"fail fast by default", nil, cache.ErrRecordNotFound},
+		{"fail fast when disabled", []cache.PluginSetting{
+			{Key: settingComputeVoteResults, Value: "false"},
+		}, cache.ErrRecordNotFound},
+		{"compute when enabled", []cache.PluginSetting{
+			{Key: settingComputeVoteResults, Value: "true"},
+		}, nil},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			d, cleanup := newTestFonero(t, v.settings...)
+			defer cleanup()
+
+			// The vote of proposal f has finished but its vote
+			// results have not been loaded.  A vote option result
+			// was left behind by vote results that were deleted.
+			testInsert(t, d.recordsdb, &StartVote{
+				Token:     "f",
+				Mask:      0x03,
+				EndHeight: 1,
+				Options: []VoteOption{
+					{ID: "no", Bits: 0x01},
+					{ID: "yes", Bits: 0x02},
+				},
+			})
+			err := d.recordsdb.Exec(`INSERT INTO vote_option_results
+          (key, token, votes, option_key) VALUES ('f1', 'f', 7, 0)`).
+				Error
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			payload, err := foneroplugin.EncodeTokenInventory(
+				foneroplugin.TokenInventory{
+					BestBlock: 10,
+				})
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = d.cmdTokenInventory(string(payload))
+			if err != v.wantErr {
+				t.Fatalf("got error %v, want %v", err, v.wantErr)
+			}
+
+			// The vote results of the unloaded proposal must
+			// only be created when they are computed, and must
+			// replace any existing vote option results.
+			results := testQuery(t, d, `SELECT token FROM vote_results`)
+			options := testQuery(t, d, `SELECT key || ':' ||
+        CAST(votes AS STRING) FROM vote_option_results ORDER BY key`)
+			wantResults := []string{}
+			wantOptions := []string{"f1:7"}
+			if v.wantErr == nil {
+				wantResults = []string{"f"}
+				wantOptions = []string{"f1:0", "f2:0"}
+			}
+			if !reflect.DeepEqual(results, wantResults) {
+				t.Fatalf("got vote results %v, want %v", results,
+					wantResults)
+			}
+			if !reflect.DeepEqual(options, wantOptions) {
+				t.Fatalf("got vote option results %v, want %v",
+					options, wantOptions)
+			}
+		})
+	}
+}
+
+func TestValidateRecordStatus(t *testing.T) {
+	var tests = []struct {
+		status  pd.RecordStatusT
+		wantErr bool
+	}{
+		{pd.RecordStatusInvalid, true},
+		{pd.RecordStatusNotFound, true},
+		{pd.RecordStatusNotReviewed, false},
+		{pd.RecordStatusCensored, false},
+		{pd.RecordStatusPublic, false},
+		{pd.RecordStatusUnreviewedChanges, false},
+		{pd.RecordStatusArchived, false},
+		{pd.RecordStatusT(99), true},
+	}
+
+	for _, v := range tests {
+		t.Run(pd.RecordStatus[v.status], func(t *testing.T) {
+			err := validateRecordStatus(v.status)
+			if (err != nil) != v.wantErr {
+				t.Fatalf("got error %v, want error %v",
+					err, v.wantErr)
+			}
+		})
+	}
+}
+
+func TestProposalSupportersCount(t *testing.T) {
+	d, cleanup := newTestFonero(t)
+	defer cleanup()
+
+	// Ticket t1 voted three times on proposal a
+	for i, v := range []string{"t1", "t2", "t1", "t3", "t1"} {
+		cv := convertCastVoteFromFonero(foneroplugin.CastVote{
+			Token:     "a",
+			Ticket:    v,
+			VoteBit:   "1",
+			Signature: "sig" + strconv.Itoa(i),
+		})
+		testInsert(t, d.recordsdb, &cv)
+	}
+	cv := convertCastVoteFromFonero(foneroplugin.CastVote{
+		Token:   "b",
+		Ticket:  "t4",
+		VoteBit: "1",
+	})
+	testInsert(t, d.recordsdb, &cv)
+
+	payload, err := foneroplugin.EncodeProposalSupportersCount(
+		foneroplugin.ProposalSupportersCount{
+			Token: "a",
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := d.cmdProposalSupportersCount(string(payload))
+	if err != nil {
+		t.Fatalf("cmdProposalSupportersCount: %v", err)
+	}
+	reply, err := foneroplugin.DecodeProposalSupportersCountReply([]byte(r))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if reply.Supporters != 3 {
+		t.Fatalf("got supporters %v, want 3", reply.Supporters)
+	}
+	if reply.Votes != 5 {
+		t.Fatalf("got votes %v, want 5", reply.Votes)
+	}
+}
+
+func TestVoteStatus(t *testing.T) {
+	authorize := &AuthorizeVote{
+		Action: foneroplugin.AuthVoteActionAuthorize,
+	}
+	revoke := &AuthorizeVote{
+		Action: foneroplugin.AuthVoteActionRevoke,
+	}
+	sv := &StartVote{
+		EndHeight: 100,
+	}
+	approved := &VoteResults{
+		Approved: true,
+	}
+	rejected := &VoteResults{
+		Approved: false,
+	}
+
+	var tests = []struct {
+		name      string
+		av        *AuthorizeVote
+		sv        *StartVote
+		vr        *VoteResults
+		bestBlock uint64
+		want      foneroplugin.VoteStatusT
+	}{
+		{"not authorized", nil, nil, nil, 0,
+			foneroplugin.VoteStatusNotAuthorized},
+		{"authorized", authorize, nil, nil, 0,
+			foneroplugin.VoteStatusAuthorized},
+		{"authorization revoked", revoke, nil, nil, 0,
+			foneroplugin.VoteStatusNotAuthorized},
+		{"started", authorize, sv, nil, 99,
+			foneroplugin.VoteStatusStarted},
+		{"finished", authorize, sv, nil, 100,
+			foneroplugin.VoteStatusFinished},
+		{"approved", authorize, sv, approved, 100,
+			foneroplugin.VoteStatusApproved},
+		{"rejected", authorize, sv, rejected, 150,
+			foneroplugin.VoteStatusRejected},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			got := voteStatus(v.av, v.sv, v.vr, v.bestBlock)
+			if got != v.want {
+				t.Fatalf("got %v, want %v", got, v.want)
+			}
+		})
+	}
+}
+
+func TestValidateBlockWindow(t *testing.T) {
+	var tests = []struct {
+		name    string
+		start   uint64
+		end     uint64
+		wantErr bool
+	}{
+		{"single block", 10, 10, false},
+		{"range", 10, 20, false},
+		{"start after end", 20, 10, true},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			err := validateBlockWindow(v.start, v.end)
+			if (err != nil) != v.wantErr {
+				t.Fatalf("got error %v, want error %v",
+					err, v.wantErr)
+			}
+		})
+	}
+}
+
+func TestConvertStartVoteEligibleTickets(t *testing.T) {
+	ticketA := strings.Repeat("a", 64)
+	ticketB := strings.Repeat("b", 64)
+	ticketC := strings.Repeat("c", 64)
+
+	var tests = []struct {
+		name      string
+		tickets   []string
+		want      []string
+		wantCount int
+	}{
+		{"no tickets", nil, []string{}, 0},
+		{"valid tickets", []string{ticketA, ticketB},
+			[]string{ticketA, ticketB}, 2},
+		{"duplicate tickets", []string{ticketB, ticketA, ticketB,
+			ticketC, ticketA}, []string{ticketB, ticketA, ticketC}, 3},
+		{"malformed tickets", []string{ticketA, "", "t1",
+			strings.Repeat("z", 64), ticketA + "a", ticketC},
+			[]string{ticketA, ticketC}, 2},
+		{"only malformed tickets", []string{"t1", "t2"}, []string{}, 0},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			sv := convertStartVoteFromFonero(foneroplugin.StartVote{
+				Vote: foneroplugin.Vote{
+					Token: "a",
+				},
+			}, foneroplugin.StartVoteReply{
+				EligibleTickets: v.tickets,
+			}, 0)
+			got := make([]string, 0, len(sv.EligibleTickets))
+			for i, et := range sv.EligibleTickets {
+				if et.Key != "a"+et.Ticket || et.Token != "a" ||
+					et.Position != i {
+					t.Fatalf("got ticket %v key %v token %v "+
+						"position %v", et.Ticket, et.Key, et.Token,
+						et.Position)
+				}
+				got = append(got, et.Ticket)
+			}
+			if !reflect.DeepEqual(got, v.want) {
+				t.Fatalf("got tickets %v, want %v", got, v.want)
+			}
+			if sv.EligibleTicketCount != v.wantCount {
+				t.Fatalf("got count %v, want %v",
+					sv.EligibleTicketCount, v.wantCount)
+			}
+		})
+	}
+}
+
+func TestFilterTokens(t *testing.T) {
+	pending := []string{"a", "b", "c", "d"}
+
+	var tests = []struct {
+		name      string
+		requested []string
+		want      []string
+	}{
+		{"all requested", []string{"d", "c", "b", "a"},
+			[]string{"a", "b", "c", "d"}},
+		{"subset", []string{"c", "a"}, []string{"a", "c"}},
+		{"not pending", []string{"e", "b"}, []string{"b"}},
+		{"none pending", []string{"e"}, []string{}},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			got := filterTokens(pending, v.requested)
+			if !reflect.DeepEqual(got, v.want) {
+				t.Fatalf("got %v, want %v", got, v.want)
+			}
+		})
+	}
+}
+
+func TestCastVoteCounts(t *testing.T) {
+	// castVote returns a CastVote for the passed in token and vote bit.
+	castVote := func(token, ticket, voteBit string) CastVote {
+		return CastVote{
+			Token:        token,
+			Ticket:       ticket,
+			VoteBit:      voteBit,
+			TokenVoteBit: token + voteBit,
+		}
+	}
+
+	ballots := [][]CastVote{
+		{
+			castVote("a", "t1", "1"),
+			castVote("a", "t2", "2"),
+			castVote("a", "t3", "1"),
+		},
+		{
+			castVote("b", "t1", "2"),
+			castVote("a", "t4", "2"),
+		},
+		{},
+		{
+			castVote("a", "t5", "1"),
+			castVote("b", "t2", "2"),
+			castVote("b", "t3", "1"),
+		},
+	}
+
+	// Increment the counters one ballot at a time the same
+	// way that cmdNewBallot does.
+	counters := make(map[string]CastVoteCount)
+	all := make([]CastVote, 0, 16)
+	for _, b := range ballots {
+		for _, c := range castVoteCounts(b) {
+			cvc, ok := counters[c.Key]
+			if !ok {
+				cvc = CastVoteCount{
+					Key:     c.Key,
+					Token:   c.Token,
+					VoteBit: c.VoteBit,
+				}
+			}
+			cvc.Votes += c.Votes
+			counters[c.Key] = cvc
+		}
+		all = append(all, b...)
+	}
+
+	// A cache rebuild counts all of the cast votes at once
+	rebuilt := castVoteCounts(all)
+
+	want := []CastVoteCount{
+		{Key: "a1", Token: "a", VoteBit: "1", Votes: 3},
+		{Key: "a2", Token: "a", VoteBit: "2", Votes: 2},
+		{Key: "b1", Token: "b", VoteBit: "1", Votes: 1},
+		{Key: "b2", Token: "b", VoteBit: "2", Votes: 2},
+	}
+	if !reflect.DeepEqual(rebuilt, want) {
+		t.Fatalf("got rebuilt counts %v, want %v", rebuilt, want)
+	}
+	if len(counters) != len(want) {
+		t.Fatalf("got %v counters, want %v", len(counters), len(want))
+	}
+	for _, v := range want {
+		if counters[v.Key] != v {
+			t.Fatalf("got counter %v, want %v", counters[v.Key], v)
+		}
+	}
+}
+
+func TestNormalizeVoteBit(t *testing.T) {
+	var tests = []struct {
+		name    string
+		voteBit string
+		want    string
+		wantErr bool
+	}{
+		{"canonical", "1", "1", false},
+		{"leading zero", "01", "1", false},
+		{"prefix", "0x1", "1", false},
+		{"uppercase prefix", "0X01", "1", false},
+		{"uppercase hex", "1F", "1f", false},
+		{"zero", "00", "0", false},
+		{"prefix only", "0x", "", true},
+		{"empty", "", "", true},
+		{"invalid hex", "zz", "", true},
+		{"negative", "-1", "", true},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			got, err := normalizeVoteBit(v.voteBit)
+			if (err != nil) != v.wantErr {
+				t.Fatalf("got error %v, want error %v", err, v.wantErr)
+			}
+			if got != v.want {
+				t.Fatalf("got %q, want %q", got, v.want)
+			}
+		})
+	}
+}
+
+func TestVoteBitVariantsTally(t *testing.T) {
+	options := []VoteOption{
+		{Token: "a", ID: "no", Bits: 0x01},
+		{Token: "a", ID: "yes", Bits: 0x02},
+	}
+
+	// Cast the same vote bit using different representations.
+	// The votes are converted the same way that cmdNewBallot
+	// converts them.
+	variants := []string{"0x1", "01", "1", "0X01", "0x01"}
+	votes := make([]CastVote, 0, len(variants)+1)
+	for i, v := range variants {
+		votes = append(votes, convertCastVoteFromFonero(
+			foneroplugin.CastVote{
+				Token:   "a",
+				Ticket:  strconv.Itoa(i),
+				VoteBit: v,
+			}))
+	}
+	votes = append(votes, convertCastVoteFromFonero(
+		foneroplugin.CastVote{
+			Token:   "a",
+			Ticket:  "yes",
+			VoteBit: "2",
+		}))
+
+	// The signed vote bit is stored unchanged
+	for i, v := range variants {
+		if votes[i].VoteBit != v {
+			t.Fatalf("got vote bit %v, want %v", votes[i].VoteBit, v)
+		}
+		if votes[i].TokenVoteBit != "a1" {
+			t.Fatalf("got token vote bit %v, want a1",
+				votes[i].TokenVoteBit)
+		}
+	}
+
+	// The cast vote counters
+	wantCounts := []CastVoteCount{
+		{Key: "a1", Token: "a", VoteBit: "1", Votes: 5},
+		{Key: "a2", Token: "a", VoteBit: "2", Votes: 1},
+	}
+	counts := castVoteCounts(votes)
+	if !reflect.DeepEqual(counts, wantCounts) {
+		t.Fatalf("got counts %v, want %v", counts, wantCounts)
+	}
+
+	// The vote option results
+	wantVotes := map[string]uint64{"no": 5, "yes": 1}
+	results := tallyVoteOptionResults(options, tallyCastVotes(votes))
+	for _, v := range results {
+		if v.Votes != wantVotes[v.ID] {
+			t.Fatalf("got %v votes for %v, want %v", v.Votes, v.ID,
+				wantVotes[v.ID])
+		}
+	}
+}
+
+func TestVerifyInventorySignatures(t *testing.T) {
+	id, err := identity.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := secp256k1.GeneratePrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// newComment returns a comment that is signed by the test identity.
+	newComment := func(token, commentID string, censored bool) foneroplugin.Comment {
+		c := foneroplugin.Comment{
+			Token:     token,
+			ParentID:  "0",
+			Comment:   "comment " + commentID,
+			PublicKey: hex.EncodeToString(id.Public.Key[:]),
+			CommentID: commentID,
+			Censored:  censored,
+		}
+		sig := id.SignMessage([]byte(c.Token + c.ParentID + c.Comment))
+		c.Signature = hex.EncodeToString(sig[:])
+		return c
+	}
+
+	// newCastVote returns a cast vote that is signed by the test key.
+	newCastVote := func(token, ticket, voteBit string) foneroplugin.CastVote {
+		cv := foneroplugin.CastVote{
+			Token:   token,
+			Ticket:  ticket,
+			VoteBit: voteBit,
+		}
+		sig, err := secp256k1.SignCompact(key, castVoteMessageHash(cv), true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cv.Signature = hex.EncodeToString(sig)
+		return cv
+	}
+
+	// tamper replaces the first byte of the cast vote signature.
+	tamper := func(cv foneroplugin.CastVote) foneroplugin.CastVote {
+		cv.Signature = "00" + cv.Signature[2:]
+		return cv
+	}
+
+	svt := foneroplugin.StartVoteTuple{
+		StartVote: foneroplugin.StartVote{
+			Vote: foneroplugin.Vote{
+				Token: "a",
+				Options: []foneroplugin.VoteOption{
+					{Id: "no", Bits: 1},
+					{Id: "yes", Bits: 2},
+				},
+			},
+		},
+		StartVoteReply: foneroplugin.StartVoteReply{
+			EligibleTickets: []string{"t1", "t2", "t3"},
+		},
+	}
+
+	// inventory returns an inventory with the passed in cast votes.
+	inventory := func(cvs ...foneroplugin.CastVote) *foneroplugin.InventoryReply {
+		censored := newComment("a", "2", true)
+		censored.Comment = ""
+		return &foneroplugin.InventoryReply{
+			Comments: []foneroplugin.Comment{
+				newComment("a", "1", false),
+				censored,
+			},
+			StartVoteTuples: []foneroplugin.StartVoteTuple{svt},
+			CastVotes:       cvs,
+		}
+	}
+
+	var tests = []struct {
+		name        string
+		ir          *foneroplugin.InventoryReply
+		maxInvalid  int
+		wantInvalid int
+		wantErr     bool
+	}{
+		{"valid", inventory(newCastVote("a", "t1", "1"),
+			newCastVote("a", "t2", "2")), 0, 0, false},
+		{"tampered cast vote", inventory(newCastVote("a", "t1", "1"),
+			tamper(newCastVote("a", "t2", "2"))), 0, 1, true},
+		{"tampered cast vote within maximum",
+			inventory(newCastVote("a", "t1", "1"),
+				tamper(newCastVote("a", "t2", "2"))), 1, 1, false},
+		{"ineligible ticket", inventory(newCastVote("a", "t4", "1")),
+			0, 1, true},
+		{"invalid vote bit", inventory(newCastVote("a", "t1", "4")),
+			0, 1, true},
+		{"missing start vote", inventory(newCastVote("b", "t1", "1")),
+			0, 1, true},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			valid, invalid, err := verifyInventorySignatures(v.ir,
+				v.maxInvalid)
+			if (err != nil) != v.wantErr {
+				t.Fatalf("got error %v, want error %v",
+					err, v.wantErr)
+			}
+			if invalid != v.wantInvalid {
+				t.Fatalf("got %v invalid, want %v",
+					invalid, v.wantInvalid)
+			}
+			if err != nil {
+				return
+			}
+
+			// The invalid entries must not be built
+			wantVotes := len(v.ir.CastVotes) - invalid
+			if len(valid.CastVotes) != wantVotes {
+				t.Fatalf("got %v cast votes, want %v",
+					len(valid.CastVotes), wantVotes)
+			}
+			for _, cv := range valid.CastVotes {
+				if verifyCastVote(cv, &svt) != nil {
+					t.Fatalf("invalid cast vote %v kept",
+						cv.Ticket)
+				}
+			}
+			if len(valid.Comments) != len(v.ir.Comments) {
+				t.Fatalf("got %v comments, want %v",
+					len(valid.Comments), len(v.ir.Comments))
+			}
+		})
+	}
+}
+
+func TestCommentThreadStats(t *testing.T) {
+	// Comment tree: 1 <- 2 <- 3 <- 4 with 3 censored, 5 has no
+	// replies, 6 <- 7 and 6 <- 8 with 8 censored.  The parent of
+	// 10 is missing and 11 and 12 form a cycle.
+	comments := []Comment{
+		{CommentID: "1", ParentID: "0"},
+		{CommentID: "2", ParentID: "1"},
+		{CommentID: "3", ParentID: "2", Censored: true},
+		{CommentID: "4", ParentID: "3"},
+		{CommentID: "5", ParentID: "0"},
+		{CommentID: "6", ParentID: "0"},
+		{CommentID: "7", ParentID: "6"},
+		{CommentID: "8", ParentID: "6", Censored: true},
+		{CommentID: "10", ParentID: "9"},
+		{CommentID: "11", ParentID: "12"},
+		{CommentID: "12", ParentID: "11"},
+	}
+
+	var tests = []struct {
+		name     string
+		comments []Comment
+		want     []foneroplugin.CommentThread
+	}{
+		{"nested threads", comments, []foneroplugin.CommentThread{
+			{CommentID: "1", Replies: 2, Censored: 1},
+			{CommentID: "5"},
+			{CommentID: "6", Replies: 1, Censored: 1},
+		}},
+		{"no comments", nil, []foneroplugin.CommentThread{}},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			got := commentThreadStats(v.comments)
+			if !reflect.DeepEqual(got, v.want) {
+				t.Fatalf("got threads %v, want %v", got, v.want)
+			}
+		})
+	}
+}
+
+func TestCommentAncestors(t *testing.T) {
+	// Comment tree: 1 is the root of the 4 deep chain 1 <- 2 <- 3 <- 4.
+	// The parent of 6 is missing and 7 and 8 form a cycle.
+	comments := map[string]Comment{
+		"1": {CommentID: "1", ParentID: "0"},
+		"2": {CommentID: "2", ParentID: "1"},
+		"3": {CommentID: "3", ParentID: "2"},
+		"4": {CommentID: "4", ParentID: "3"},
+		"5": {CommentID: "5", ParentID: "6"},
+		"7": {CommentID: "7", ParentID: "8"},
+		"8": {CommentID: "8", ParentID: "7"},
+	}
+	lookup := func(commentID string) (*Comment, error) {
+		c, ok := comments[commentID]
+		if !ok {
+			return nil, cache.ErrRecordNotFound
+		}
+		return &c, nil
+	}
+
+	var tests = []struct {
+		name      string
+		commentID string
+		maxDepth  int
+		want      []string // Ancestor comment IDs
+	}{
+		{"4 deep", "4", 64, []string{"3", "2", "1"}},
+		{"root comment", "1", 64, []string{}},
+		{"max depth", "4", 2, []string{"3", "2"}},
+		{"missing ancestor", "5", 64, []string{}},
+		{"cycle", "7", 64, []string{"8"}},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			ancestors, err := commentAncestors(comments[v.commentID],
+				v.maxDepth, lookup)
+			if err != nil {
+				t.Fatalf("commentAncestors: %v", err)
+			}
+			got := make([]string, 0, len(ancestors))
+			for _, a := range ancestors {
+				got = append(got, a.CommentID)
+			}
+			if !reflect.DeepEqual(got, v.want) {
+				t.Fatalf("got ancestors %v, want %v", got, v.want)
+			}
+		})
+	}
+
+	// Lookup errors other than not found are returned
+	_, err := commentAncestors(comments["4"], 64,
+		func(string) (*Comment, error) {
+			return nil, errors.New("lookup failed")
+		})
+	if err == nil {
+		t.Fatalf("got nil error, want lookup error")
+	}
+}
+
+func TestUnauthorizedVotes(t *testing.T) {
+	authorize := foneroplugin.AuthVoteActionAuthorize
+	revoke := foneroplugin.AuthVoteActionRevoke
+
+	var tests = []struct {
+		name string
+		svs  []StartVote
+		avs  []AuthorizeVote
+		want []foneroplugin.UnauthorizedVote
+	}{
+		{"authorized",
+			[]StartVote{{Token: "a", Version: 1}},
+			[]AuthorizeVote{{Key: "a1", Action: authorize}},
+			[]foneroplugin.UnauthorizedVote{}},
+		{"missing authorize vote",
+			[]StartVote{{Token: "a", Version: 1}, {Token: "b", Version: 2}},
+			[]AuthorizeVote{{Key: "a1", Action: authorize}},
+			[]foneroplugin.UnauthorizedVote{
+				{Token: "b", Version: "2",
+					Reason: "authorize vote not found"},
+			}},
+		{"authorized different version",
+			[]StartVote{{Token: "a", Version: 2}},
+			[]AuthorizeVote{{Key: "a1", Action: authorize}},
+			[]foneroplugin.UnauthorizedVote{
+				{Token: "a", Version: "2",
+					Reason: "authorize vote not found"},
+			}},
+		{"revoked",
+			[]StartVote{{Token: "a", Version: 1}},
+			[]AuthorizeVote{{Key: "a1", Action: revoke}},
+			[]foneroplugin.UnauthorizedVote{
+				{Token: "a", Version: "1",
+					Reason: "authorize vote action is revoke"},
+			}},
+		{"sorted by token",
+			[]StartVote{{Token: "c", Version: 1}, {Token: "a", Version: 1}},
+			nil,
+			[]foneroplugin.UnauthorizedVote{
+				{Token: "a", Version: "1",
+					Reason: "authorize vote not found"},
+				{Token: "c", Version: "1",
+					Reason: "authorize vote not found"},
+			}},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			uv := unauthorizedVotes(v.svs, v.avs)
+			if !reflect.DeepEqual(uv, v.want) {
+				t.Fatalf("got unauthorized votes %v, want %v",
+					uv, v.want)
+			}
+		})
+	}
+}
+
+func TestSlowQueryLogging(t *testing.T) {
+	d, cleanup := newTestFonero(t)
+	defer cleanup()
+
+	var tests = []struct {
+		name      string
+		threshold time.Duration
+		elapsed   time.Duration
+		wantWarn  bool
+	}{
+		{"above threshold", time.Second, 2 * time.Second, true},
+		{"below threshold", time.Second, 500 * time.Millisecond, false},
+		{"at threshold", time.Second, time.Second, false},
+		{"disabled", 0, time.Hour, false},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			// Capture log output
+			var buf bytes.Buffer
+			logger := slog.NewBackend(&buf).Logger("CACH")
+			logger.SetLevel(slog.LevelWarn)
+			oldLog := log
+			log = logger
+			defer func() {
+				log = oldLog
+			}()
+
+			// Fake clock that advances by the elapsed duration
+			// every time that it is read.
+			clock := time.Unix(0, 0)
+			d.now = func() time.Time {
+				now := clock
+				clock = clock.Add(v.elapsed)
+				return now
+			}
+			d.slowQuery = v.threshold
+
+			_, err := d.queryStrings("slow test", "SELECT token "+
+				"FROM start_votes WHERE end_height > ?", 1)
+			if err != nil {
+				t.Fatalf("queryStrings: %v", err)
+			}
+
+			warned := strings.Contains(buf.String(),
+				"label=slow test duration="+v.elapsed.String())
+			if warned != v.wantWarn {
+				t.Fatalf("got warning %v, want %v: %q",
+					warned, v.wantWarn, buf.String())
+			}
+		})
+	}
+}
+
+func TestSlowQueryThresholdSetting(t *testing.T) {
+	var tests = []struct {
+		name     string
+		settings []cache.PluginSetting
+		want     time.Duration
+	}{
+		{"default", nil, defaultSlowQueryThreshold},
+		{"configured", []cache.PluginSetting{
+			{Key: settingSlowQueryThreshold, Value: "500ms"},
+		}, 500 * time.Millisecond},
+		{"disabled", []cache.PluginSetting{
+			{Key: settingSlowQueryThreshold, Value: "0"},
+		}, 0},
+		{"invalid", []cache.PluginSetting{
+			{Key: settingSlowQueryThreshold, Value: "fast"},
+		}, defaultSlowQueryThreshold},
+		{"negative", []cache.PluginSetting{
+			{Key: settingSlowQueryThreshold, Value: "-1s"},
+		}, defaultSlowQueryThreshold},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			d := newFoneroPlugin(nil, cache.Plugin{
+				Settings: v.settings,
+			}, nil)
+			if d.slowQuery != v.want {
+				t.Fatalf("got threshold %v, want %v",
+					d.slowQuery, v.want)
+			}
+		})
+	}
+}
+
+func TestComputeVoteResultsSetting(t *testing.T) {
+	var tests = []struct {
+		name     string
+		settings []cache.PluginSetting
+		want     bool
+	}{
+		{"default", nil, false},
+		{"enabled", []cache.PluginSetting{
+			{Key: settingComputeVoteResults, Value: "true"},
+		}, true},
+		{"disabled", []cache.PluginSetting{
+			{Key: settingComputeVoteResults, Value: "false"},
+		}, false},
+		{"invalid", []cache.PluginSetting{
+			{Key: settingComputeVoteResults, Value: "sometimes"},
+		}, false},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			d := newFoneroPlugin(nil, cache.Plugin{
+				Settings: v.settings,
+			}, nil)
+			if d.computeVoteResults != v.want {
+				t.Fatalf("got %v, want %v",
+					d.computeVoteResults, v.want)
+			}
+		})
+	}
+}
+
+func TestCensorCommentBodyMode(t *testing.T) {
+	const body = "offending comment"
+	var tests = []struct {
+		name      string
+		mode      string
+		commentID string
+		wantAudit bool
+		wantErr   error
+	}{
+		{"purge", censorModePurge, "1", false, nil},
+		{"audit", censorModeAudit, "1", true, nil},
+		{"audit censored comment", censorModeAudit, "2", false, nil},
+		{"audit missing comment", censorModeAudit, "3", false,
+			cache.ErrRecordNotFound},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			d, cleanup := newTestFonero(t)
+			defer cleanup()
+			d.censorMode = v.mode
+
+			// Comment 1 of proposal a has a body and comment 2
+			// of proposal a has already been censored.
+			testInsert(t, d.recordsdb,
+				&Comment{
+					Key:       "a1",
+					Token:     "a",
+					CommentID: "1",
+					Comment:   body,
+				},
+				&Comment{
+					Key:       "a2",
+					Token:     "a",
+					CommentID: "2",
+					Censored:  true,
+				})
+
+			cc, err := foneroplugin.EncodeCensorComment(
+				foneroplugin.CensorComment{
+					Token:     "a",
+					CommentID: v.commentID,
+					Reason:    "spam",
+					PublicKey: "adminpk",
+					Timestamp: 100,
+				})
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = d.cmdCensorComment(string(cc), "")
+			if err != v.wantErr {
+				t.Fatalf("got error %v, want %v", err, v.wantErr)
+			}
+
+			// The body is removed from the comments table in
+			// all modes and is only stored in the audit table
+			// in audit mode.
+			if v.wantErr == nil {
+				c := Comment{
+					Key: "a" + v.commentID,
+				}
+				err = d.recordsdb.Find(&c).Error
+				if err != nil {
+					t.Fatalf("lookup comment: %v", err)
+				}
+				if c.Comment != "" || !c.Censored {
+					t.Fatalf("got comment %q censored %v, want "+
+						"purged", c.Comment, c.Censored)
+				}
+			}
+
+			var audits []CensoredComment
+			err = d.recordsdb.Find(&audits).Error
+			if err != nil {
+				t.Fatalf("lookup censored comments: %v", err)
+			}
+			if (len(audits) > 0) != v.wantAudit {
+				t.Fatalf("got %v audits, want audit %v", len(audits),
+					v.wantAudit)
+			}
+			if !v.wantAudit {
+				return
+			}
+			want := []CensoredComment{{
+				Key:       "a1",
+				Token:     "a",
+				CommentID: "1",
+				Comment:   body,
+				PublicKey: "adminpk",
+				Reason:    "spam",
+				Timestamp: 100,
+			}}
+			if !reflect.DeepEqual(audits, want) {
+				t.Fatalf("got audits %v, want %v", audits, want)
+			}
+		})
+	}
+}
+
+func TestCmdGetLatestRecordVersion(t *testing.T) {
+	d, cleanup := newTestFonero(t)
+	defer cleanup()
+
+	testInsert(t, d.recordsdb,
+		&Record{Key: "a1", Token: "a", Version: 1, Status: 2},
+		&Record{Key: "a10", Token: "a", Version: 10, Status: 4},
+		&Record{Key: "a2", Token: "a", Version: 2, Status: 4},
+		&Record{Key: "b1", Token: "b", Version: 1, Status: 2})
+
+	var tests = []struct {
+		name    string
+		token   string
+		want    foneroplugin.GetLatestRecordVersionReply
+		wantErr error
+	}{
+		{"multiple versions", "a",
+			foneroplugin.GetLatestRecordVersionReply{
+				Version: "10",
+				Status:  4,
+			}, nil},
+		{"single version", "b",
+			foneroplugin.GetLatestRecordVersionReply{
+				Version: "1",
+				Status:  2,
+			}, nil},
+		{"record not found", "c",
+			foneroplugin.GetLatestRecordVersionReply{},
+			cache.ErrRecordNotFound},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			payload, err := foneroplugin.EncodeGetLatestRecordVersion(
+				foneroplugin.GetLatestRecordVersion{
+					Token: v.token,
+				})
+			if err != nil {
+				t.Fatal(err)
+			}
+			reply, err := d.cmdGetLatestRecordVersion(string(payload))
+			if err != v.wantErr {
+				t.Fatalf("got error %v, want %v", err, v.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			got, err := foneroplugin.DecodeGetLatestRecordVersionReply(
+				[]byte(reply))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *got != v.want {
+				t.Fatalf("got %v, want %v", *got, v.want)
+			}
+		})
+	}
+}
+
+func TestStartVoteCache(t *testing.T) {
+	d, cleanup := newTestFonero(t)
+	defer cleanup()
+
+	testInsert(t, d.recordsdb, &StartVote{
+		Token:     "a",
+		EndHeight: 1000,
+	})
+
+	// setEndHeight changes the end height of the start vote of
+	// proposal a in the database without going through the
+	// cache.
+	setEndHeight := func(endHeight uint64) {
+		t.Helper()
+		err := d.recordsdb.Model(&StartVote{Token: "a"}).
+			Update("end_height", endHeight).
+			Error
+		if err != nil {
+			t.Fatalf("update end height: %v", err)
+		}
+	}
+
+	// lookup looks up the start vote of proposal a and verifies
+	// its end height.
+	lookup := func(want uint64) {
+		t.Helper()
+		sv, err := d.startVote("a")
+		if err != nil {
+			t.Fatalf("startVote: %v", err)
+		}
+		if sv.EndHeight != want {
+			t.Fatalf("got end height %v, want %v", sv.EndHeight, want)
+		}
+	}
+
+	// The first lookup hits the database and the second
+	// lookup is served from the cache.
+	lookup(1000)
+	setEndHeight(2000)
+	lookup(1000)
+
+	// A missing start vote is not cached
+	_, err := d.startVote("b")
+	if err != cache.ErrRecordNotFound {
+		t.Fatalf("got error %v, want %v", err, cache.ErrRecordNotFound)
+	}
+	testInsert(t, d.recordsdb, &StartVote{
+		Token:     "b",
+		EndHeight: 10,
+	})
+	sv, err := d.startVote("b")
+	if err != nil {
+		t.Fatalf("startVote: %v", err)
+	}
+	if sv.EndHeight != 10 {
+		t.Fatalf("got end height %v, want 10", sv.EndHeight)
+	}
+
+	// Recording a new start vote invalidates the cached start vote
+	d.startVotes.invalidate("a")
+	lookup(2000)
+	setEndHeight(3000)
+	lookup(2000)
+
+	// A rebuild clears the cache. The build is cancelled before it
+	// starts since only the start vote cache is under test.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = d.build(ctx, &foneroplugin.InventoryReply{})
+	if err == nil {
+		t.Fatal("cancelled build succeeded")
+	}
+	lookup(3000)
+
+	// A start vote that was looked up before the cache was
+	// invalidated is not cached.
+	setEndHeight(4000)
+	gen := d.startVotes.generation()
+	d.startVotes.invalidate("a")
+	d.startVotes.put(StartVote{Token: "a", EndHeight: 1}, gen)
+	lookup(4000)
+}
+
+func TestSelfTest(t *testing.T) {
+	d, cleanup := newTestFonero(t)
+	defer cleanup()
+
+	// brokenDecoder drops the comment ID of the get comment
+	// payload.
+	brokenDecoder := func(b []byte) (interface{}, error) {
+		gc, err := foneroplugin.DecodeGetComment(b)
+		if err != nil {
+			return nil, err
+		}
+		gc.CommentID = ""
+		return gc, nil
+	}
+
+	// replace returns a copy of the self-test cases with the case
+	// of the passed in command replaced.
+	replace := func(tc selfTestCase) []selfTestCase {
+		cases := make([]selfTestCase, 0, len(foneroSelfTestCases))
+		for _, v := range foneroSelfTestCases {
+			if v.command == tc.command {
+				v = tc
+			}
+			cases = append(cases, v)
+		}
+		return cases
+	}
+
+	var tests = []struct {
+		name    string
+		cases   []selfTestCase
+		wantErr bool
+	}{
+		{"healthy plugin", foneroSelfTestCases, false},
+		{"broken decoder", replace(selfTestCase{
+			command: foneroplugin.CmdGetComment,
+			payload: foneroplugin.GetComment{
+				Token:     selfTestToken,
+				CommentID: "1",
+			},
+			decode: brokenDecoder,
+		}), true},
+		{"command not dispatched", append([]selfTestCase{{
+			command: "invalidcommand",
+			payload: foneroplugin.VoteDetails{
+				Token: selfTestToken,
+			},
+			decode: func(b []byte) (interface{}, error) {
+				return foneroplugin.DecodeVoteDetails(b)
+			},
+			reply: func(b []byte) (interface{}, error) {
+				return foneroplugin.DecodeVoteDetailsReply(b)
+			},
+		}}, foneroSelfTestCases...), true},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			err := d.selfTest(v.cases)
+			if (err != nil) != v.wantErr {
+				t.Fatalf("got error %v, want error %v", err,
+					v.wantErr)
+			}
+		})
+	}
+
+	// Every self-test case must cover a different command
+	commands := make(map[string]struct{}, len(foneroSelfTestCases))
+	for _, v := range foneroSelfTestCases {
+		if _, ok := commands[v.command]; ok {
+			t.Fatalf("duplicate self-test case: %v", v.command)
+		}
+		commands[v.command] = struct{}{}
+	}
+}
+
+func TestCensoredCommentBodySetting(t *testing.T) {
+	var tests = []struct {
+		name     string
+		settings []cache.PluginSetting
+		want     string
+	}{
+		{"default", nil, censorModePurge},
+		{"purge", []cache.PluginSetting{
+			{Key: settingCensoredCommentBody, Value: "purge"},
+		}, censorModePurge},
+		{"audit", []cache.PluginSetting{
+			{Key: settingCensoredCommentBody, Value: "audit"},
+		}, censorModeAudit},
+		{"invalid", []cache.PluginSetting{
+			{Key: settingCensoredCommentBody, Value: "encrypt"},
+		}, censorModePurge},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			d := newFoneroPlugin(nil, cache.Plugin{
+				Settings: v.settings,
+			}, nil)
+			if d.censorMode != v.want {
+				t.Fatalf("got mode %v, want %v",
+					d.censorMode, v.want)
+			}
+		})
+	}
+}
+
+func TestParsePoolSettings(t *testing.T) {
+	var tests = []struct {
+		name     string
+		settings []cache.PluginSetting
+		want     poolSettings
+	}{
+		{"default", nil, poolSettings{maxIdle: -1}},
+		{"configured", []cache.PluginSetting{
+			{Key: settingMaxOpenConns, Value: "10"},
+			{Key: settingMaxIdleConns, Value: "5"},
+			{Key: settingConnMaxLifetime, Value: "5m"},
+		}, poolSettings{10, 5, 5 * time.Minute}},
+		{"no idle connections", []cache.PluginSetting{
+			{Key: settingMaxIdleConns, Value: "0"},
+		}, poolSettings{maxIdle: 0}},
+		{"invalid", []cache.PluginSetting{
+			{Key: settingMaxOpenConns, Value: "many"},
+			{Key: settingMaxIdleConns, Value: "-1"},
+			{Key: settingConnMaxLifetime, Value: "-1s"},
+		}, poolSettings{maxIdle: -1}},
+		{"idle exceeds open", []cache.PluginSetting{
+			{Key: settingMaxOpenConns, Value: "2"},
+			{Key: settingMaxIdleConns, Value: "5"},
+		}, poolSettings{maxOpen: 2, maxIdle: 2}},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			got := parsePoolSettings(v.settings)
+			if got != v.want {
+				t.Fatalf("got %+v, want %+v", got, v.want)
+			}
+		})
+	}
+}
+
+func TestParseCommandLimits(t *testing.T) {
+	var tests = []struct {
+		name     string
+		settings []cache.PluginSetting
+		want     map[string]int // [cmd]limit
+		failFast bool
+	}{
+		{"default", nil, map[string]int{}, false},
+		{"configured", []cache.PluginSetting{
+			{Key: settingMaxTokenInventory, Value: "2"},
+			{Key: settingMaxLoadVoteResults, Value: "1"},
+		}, map[string]int{
+			foneroplugin.CmdTokenInventory:  2,
+			foneroplugin.CmdLoadVoteResults: 1,
+		}, false},
+		{"fail fast", []cache.PluginSetting{
+			{Key: settingMaxTokenInventory, Value: "3"},
+			{Key: settingCommandLimitFailFast, Value: "true"},
+		}, map[string]int{
+			foneroplugin.CmdTokenInventory: 3,
+		}, true},
+		{"zero is unlimited", []cache.PluginSetting{
+			{Key: settingMaxTokenInventory, Value: "0"},
+		}, map[string]int{}, false},
+		{"invalid", []cache.PluginSetting{
+			{Key: settingMaxTokenInventory, Value: "many"},
+			{Key: settingMaxLoadVoteResults, Value: "-1"},
+			{Key: settingCommandLimitFailFast, Value: "maybe"},
+		}, map[string]int{}, false},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			limiters := parseCommandLimits(v.settings)
+			got := make(map[string]int, len(limiters))
+			for cmd, l := range limiters {
+				got[cmd] = cap(l.sem)
+				if l.failFast != v.failFast {
+					t.Fatalf("%v: got fail fast %v, want %v",
+						cmd, l.failFast, v.failFast)
+				}
+			}
+			if !reflect.DeepEqual(got, v.want) {
+				t.Fatalf("got %v, want %v", got, v.want)
+			}
+		})
+	}
+}
+
+func TestExecCommandLimit(t *testing.T) {
+	d, cleanup := newTestFonero(t)
+	defer cleanup()
+
+	const limit = 2
+	var tests = []struct {
+		name     string
+		failFast bool
+	}{
+		{"wait", false},
+		{"fail fast", true},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			d.limiters = parseCommandLimits([]cache.PluginSetting{
+				{Key: settingMaxLoadVoteResults,
+					Value: strconv.Itoa(limit)},
+				{Key: settingCommandLimitFailFast,
+					Value: strconv.FormatBool(v.failFast)},
+			})
+			defer func() {
+				d.limiters = nil
+			}()
+			l := d.limiters[foneroplugin.CmdLoadVoteResults]
+
+			// Occupy every slot as if the limit of heavy
+			// commands were running.
+			for i := 0; i < limit; i++ {
+				err := l.acquire()
+				if err != nil {
+					t.Fatalf("acquire: %v", err)
+				}
+			}
+
+			done := make(chan error, 1)
+			go func() {
+				_, err := d.Exec(foneroplugin.CmdLoadVoteResults,
+					"{}", "")
+				done <- err
+			}()
+
+			if v.failFast {
+				// The command over the limit must fail
+				// right away.
+				select {
+				case err := <-done:
+					if err != errCommandLimit {
+						t.Fatalf("got error %v, want %v",
+							err, errCommandLimit)
+					}
+				case <-time.After(5 * time.Second):
+					t.Fatalf("command over the limit did not fail")
+				}
+			} else {
+				// The command over the limit must wait
+				// until a slot is released.
+				select {
+				case err := <-done:
+					t.Fatalf("command over the limit was not "+
+						"blocked: %v", err)
+				case <-time.After(100 * time.Millisecond):
+				}
+				l.release()
+				select {
+				case err := <-done:
+					if err == errCommandLimit {
+						t.Fatalf("got error %v", err)
+					}
+				case <-time.After(5 * time.Second):
+					t.Fatalf("command was not unblocked")
+				}
+				if len(l.sem) != limit-1 {
+					t.Fatalf("got %v slots in use, want %v",
+						len(l.sem), limit-1)
+				}
+			}
+
+			for len(l.sem) > 0 {
+				l.release()
+			}
+		})
+	}
+}
+
+func TestSetupPoolSettings(t *testing.T) {
+	d, cleanup := newTestFonero(t,
+		cache.PluginSetting{Key: settingMaxOpenConns, Value: "3"},
+		cache.PluginSetting{Key: settingMaxIdleConns, Value: "1"},
+		cache.PluginSetting{Key: settingConnMaxLifetime, Value: "1h"})
+	defer cleanup()
+
+	err := d.Setup()
+	if err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+
+	sqlDB := d.recordsdb.DB()
+	stats := sqlDB.Stats()
+	if stats.MaxOpenConnections != 3 {
+		t.Fatalf("got max open connections %v, want 3",
+			stats.MaxOpenConnections)
+	}
+
+	// Open the maximum number of connections and release them.
+	// Only the max idle connections are kept in the pool.
+	ctx := context.Background()
+	conns := make([]*sql.Conn, 0, 3)
+	for i := 0; i < 3; i++ {
+		c, err := sqlDB.Conn(ctx)
+		if err != nil {
+			t.Fatalf("conn: %v", err)
+		}
+		conns = append(conns, c)
+	}
+	for _, c := range conns {
+		c.Close()
+	}
+	stats = sqlDB.Stats()
+	if stats.Idle != 1 {
+		t.Fatalf("got %v idle connections, want 1", stats.Idle)
+	}
+}
+
+func TestProposalActivity(t *testing.T) {
+	var tests = []struct {
+		name     string
+		comments map[string]uint64
+		votes    map[string]uint64
+		want     []foneroplugin.ProposalActivity
+	}{
+		{"no activity", nil, nil, []foneroplugin.ProposalActivity{}},
+		{"merged", map[string]uint64{"c": 1, "a": 2},
+			map[string]uint64{"b": 3, "a": 4},
+			[]foneroplugin.ProposalActivity{
+				{Token: "a", Comments: 2, CastVotes: 4},
+				{Token: "b", CastVotes: 3},
+				{Token: "c", Comments: 1},
+			}},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			got := proposalActivity(v.comments, v.votes)
+			if !reflect.DeepEqual(got, v.want) {
+				t.Fatalf("got %v, want %v", got, v.want)
+			}
+		})
+	}
+}
+
+func TestEligibleTicketsPage(t *testing.T) {
+	var tests = []struct {
+		name          string
+		total         uint32
+		offset, limit uint32
+		wantStart     uint32
+		wantEnd       uint32
+	}{
+		{"no tickets", 0, 0, 0, 0, 0},
+		{"all tickets", 5, 0, 0, 0, 5},
+		{"first page", 5, 0, 2, 0, 2},
+		{"middle page", 5, 2, 2, 2, 4},
+		{"last page", 5, 4, 2, 4, 5},
+		{"offset at end", 5, 5, 2, 5, 5},
+		{"offset past end", 5, 10, 0, 5, 5},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			start, end := eligibleTicketsPage(v.total, v.offset,
+				v.limit)
+			if start != v.wantStart || end != v.wantEnd {
+				t.Fatalf("got page %v-%v, want %v-%v", start, end,
+					v.wantStart, v.wantEnd)
+			}
+		})
+	}
+}
+
+func TestCommentsSinceQuery(t *testing.T) {
+	var tests = []struct {
+		name        string
+		lastSeenKey string
+		wantWhere   string
+		wantArgs    []interface{}
+	}{
+		{"timestamp",
+			"",
+			`token = ? AND hidden = false AND ` +
+				`(timestamp > ? OR censored_timestamp > ?)`,
+			[]interface{}{"a", int64(100), int64(100)}},
+		{"last seen comment",
+			"a3",
+			`token = ? AND hidden = false AND ` +
+				`((timestamp >= ? AND key != ?) OR censored_timestamp >= ?)`,
+			[]interface{}{"a", int64(100), "a3", int64(100)}},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			where, args := commentsSinceQuery("a", 100, v.lastSeenKey)
+			if where != v.wantWhere {
+				t.Fatalf("got where %q, want %q", where, v.wantWhere)
+			}
+			if !reflect.DeepEqual(args, v.wantArgs) {
+				t.Fatalf("got args %v, want %v", args, v.wantArgs)
+			}
+		})
+	}
+}
+
+// testInsertVoteOptionResults inserts vote option results with the passed in
+// keys into the test database.  The token of each vote option result is the
+// first character of its key.
+func testInsertVoteOptionResults(t *testing.T, db *gorm.DB, keys ...string) {
+	t.Helper()
+
+	for _, v := range keys {
+		err := db.Exec(`INSERT INTO vote_option_results
+        (key, token, votes, option_key) VALUES (?, ?, 0, 0)`,
+			v, v[:1]).Error
+		if err != nil {
+			t.Fatalf("insert vote option result: %v", err)
+		}
+	}
+}
+
+func TestDeleteVoteResults(t *testing.T) {
+	d, cleanup := newTestFonero(t)
+	defer cleanup()
+
+	testInsert(t, d.recordsdb,
+		&VoteResults{Token: "a"},
+		&VoteResults{Token: "b"})
+	testInsertVoteOptionResults(t, d.recordsdb, "a1", "a2", "b1")
+
+	err := d.deleteVoteResults(d.recordsdb, "a")
+	if err != nil {
+		t.Fatalf("deleteVoteResults: %v", err)
+	}
+
+	// The vote option results must be deleted along with
+	// the vote results record.
+	results := testQuery(t, d, "SELECT token FROM vote_results")
+	if !reflect.DeepEqual(results, []string{"b"}) {
+		t.Fatalf("got vote results %v, want [b]", results)
+	}
+	options := testQuery(t, d, "SELECT key FROM vote_option_results")
+	if !reflect.DeepEqual(options, []string{"b1"}) {
+		t.Fatalf("got vote option results %v, want [b1]", options)
+	}
+}
+
+func TestRemoveOrphanedVoteOptionResults(t *testing.T) {
+	d, cleanup := newTestFonero(t)
+	defer cleanup()
+
+	// Only the vote option results of proposal c have a parent
+	// vote results record.
+	testInsert(t, d.recordsdb, &VoteResults{Token: "c"})
+	testInsertVoteOptionResults(t, d.recordsdb, "a1", "b2", "c1")
+
+	keys, err := d.removeOrphanedVoteOptionResults()
+	if err != nil {
+		t.Fatalf("removeOrphanedVoteOptionResults: %v", err)
+	}
+	sort.Strings(keys)
+	want := []string{"a1", "b2"}
+	if !reflect.DeepEqual(keys, want) {
+		t.Fatalf("got keys %v, want %v", keys, want)
+	}
+
+	// Only the orphaned vote option results are deleted
+	options := testQuery(t, d, "SELECT key FROM vote_option_results")
+	if !reflect.DeepEqual(options, []string{"c1"}) {
+		t.Fatalf("got vote option results %v, want [c1]", options)
+	}
+}
+
+func TestNewBallotPartialFailure(t *testing.T) {
+	d, cleanup := newTestFonero(t)
+	defer cleanup()
+
+	// The second vote fails to insert
+	testFailColumn(t, d.recordsdb, tableCastVotes, "ticket")
+	tickets := []string{"t1", testFailValue, "t3"}
+	votes := make([]foneroplugin.CastVote, 0, len(tickets))
+	receipts := make([]foneroplugin.CastVoteReply, 0, len(tickets))
+	for _, v := range tickets {
+		votes = append(votes, foneroplugin.CastVote{
+			Token:   "a",
+			Ticket:  v,
+			VoteBit: "1",
+		})
+		receipts = append(receipts, foneroplugin.CastVoteReply{
+			ClientSignature: "sig" + v,
+		})
+	}
+	b, err := foneroplugin.EncodeBallot(foneroplugin.Ballot{
+		Votes: votes,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	br, err := foneroplugin.EncodeBallotReply(foneroplugin.BallotReply{
+		Receipts: receipts,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reply, err := d.cmdNewBallot(string(b), string(br))
+	if err != nil {
+		t.Fatalf("cmdNewBallot: %v", err)
+	}
+
+	// Only the failed vote is reported
+	r, err := foneroplugin.DecodeBallotReply([]byte(reply))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Receipts) != len(tickets) {
+		t.Fatalf("got %v receipts, want %v", len(r.Receipts), len(tickets))
+	}
+	for i, v := range r.Receipts {
+		if v.ClientSignature != receipts[i].ClientSignature {
+			t.Fatalf("receipt %v: got client signature %v, want %v",
+				i, v.ClientSignature, receipts[i].ClientSignature)
+		}
+		failed := tickets[i] == testFailValue
+		if (v.Error != "") != failed {
+			t.Fatalf("receipt %v: got error %q, want failed %v",
+				i, v.Error, failed)
+		}
+	}
+
+	// The valid votes are stored and counted and the failed
+	// vote is not counted.
+	inserted := testQuery(t, d, "SELECT ticket FROM cast_votes "+
+		"ORDER BY ticket")
+	want := []string{"t1", "t3"}
+	if !reflect.DeepEqual(inserted, want) {
+		t.Fatalf("got inserted tickets %v, want %v", inserted, want)
+	}
+	counts := testQuery(t, d, "SELECT key || ':' || "+
+		"CAST(votes AS STRING) FROM cast_vote_counts")
+	if !reflect.DeepEqual(counts, []string{"a1:2"}) {
+		t.Fatalf("got cast vote counts %v, want [a1:2]", counts)
+	}
+}
+
+func TestNewBallotBackendRejected(t *testing.T) {
+	d, cleanup := newTestFonero(t)
+	defer cleanup()
+
+	// The backend rejected the second vote as a duplicate and the
+	// third vote because the vote of its proposal has finished.
+	tickets := []string{"t1", "t2", "t3"}
+	tokens := []string{"a", "a", "finished"}
+	votes := make([]foneroplugin.CastVote, 0, len(tickets))
+	for i, v := range tickets {
+		votes = append(votes, foneroplugin.CastVote{
+			Token:   tokens[i],
+			Ticket:  v,
+			VoteBit: "1",
+		})
+	}
+	receipts := []foneroplugin.CastVoteReply{
+		{ClientSignature: "sigt1", Signature: "receiptt1"},
+		{Error: "duplicate vote: a"},
+		{Error: "vote has ended: finished"},
+	}
+	b, err := foneroplugin.EncodeBallot(foneroplugin.Ballot{
+		Votes: votes,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	br, err := foneroplugin.EncodeBallotReply(foneroplugin.BallotReply{
+		Receipts: receipts,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reply, err := d.cmdNewBallot(string(b), string(br))
+	if err != nil {
+		t.Fatalf("cmdNewBallot: %v", err)
+	}
+
+	// The backend receipts are returned unchanged
+	r, err := foneroplugin.DecodeBallotReply([]byte(reply))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(r.Receipts, receipts) {
+		t.Fatalf("got receipts %v, want %v", r.Receipts, receipts)
+	}
+
+	// Only the vote accepted by the backend is inserted and
+	// counted.
+	inserted := testQuery(t, d, "SELECT token || ticket || ':' || "+
+		"receipt FROM cast_votes")
+	want := []string{"at1:receiptt1"}
+	if !reflect.DeepEqual(inserted, want) {
+		t.Fatalf("got inserted votes %v, want %v", inserted, want)
+	}
+	counts := testQuery(t, d, "SELECT key || ':' || "+
+		"CAST(votes AS STRING) FROM cast_vote_counts")
+	if !reflect.DeepEqual(counts, []string{"a1:1"}) {
+		t.Fatalf("got cast vote counts %v, want [a1:1]", counts)
+	}
+}
+
+func TestStartVoteReinsert(t *testing.T) {
+	d, cleanup := newTestFonero(t)
+	defer cleanup()
+	testFailColumn(t, d.recordsdb, tableVoteOptions, "description")
+
+	// startVote returns the start vote payloads of proposal a using
+	// the passed in vote option description.
+	startVote := func(description string) (string, string) {
+		sv, err := foneroplugin.EncodeStartVote(foneroplugin.StartVote{
+			Vote: foneroplugin.Vote{
+				Token: "a",
+				Mask:  0x03,
+				Options: []foneroplugin.VoteOption{
+					{Id: "no", Description: description, Bits: 0x01},
+					{Id: "yes", Description: description, Bits: 0x02},
+				},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		svr, err := foneroplugin.EncodeStartVoteReply(
+			foneroplugin.StartVoteReply{
+				StartBlockHeight: "100",
+				EndHeight:        "200",
+			})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(sv), string(svr)
+	}
+
+	var tests = []struct {
+		name        string
+		description string
+		want        string // Description of the stored vote options
+		wantErr     bool
+	}{
+		{"insert", "first", "first", false},
+		{"reinsert", "second", "second", false},
+		{"option insert fails", testFailValue, "second", true},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			sv, svr := startVote(v.description)
+			_, err := d.cmdStartVote(sv, svr)
+			if (err != nil) != v.wantErr {
+				t.Fatalf("got error %v, want error %v",
+					err, v.wantErr)
+			}
+
+			// The existing vote options must be replaced by the
+			// new vote options, and a failed insert must leave
+			// the existing start vote untouched.
+			options := testQuery(t, d, "SELECT id || ':' || "+
+				"description FROM vote_options WHERE token = ? "+
+				"ORDER BY id", "a")
+			want := []string{"no:" + v.want, "yes:" + v.want}
+			if !reflect.DeepEqual(options, want) {
+				t.Fatalf("got vote options %v, want %v", options, want)
+			}
+			votes := testQuery(t, d, "SELECT token FROM start_votes")
+			if !reflect.DeepEqual(votes, []string{"a"}) {
+				t.Fatalf("got start votes %v, want [a]", votes)
+			}
+		})
+	}
+}
+
+// testCommentVersions returns the versions of comment 1 of proposal a, sorted
+// by version.  The last version is a censor event.
+func testCommentVersions() []CommentVersion {
+	return []CommentVersion{
+		{
+			Key:       1,
+			Token:     "a",
+			CommentID: "1",
+			Version:   1,
+			Comment:   "original",
+			Signature: "sig1",
+			PublicKey: "pk",
+			Receipt:   "receipt1",
+			Timestamp: 100,
+		},
+		{
+			Key:       2,
+			Token:     "a",
+			CommentID: "1",
+			Version:   2,
+			Comment:   "updated",
+			Signature: "sig2",
+			PublicKey: "pk",
+			Receipt:   "receipt2",
+			Timestamp: 200,
+		},
+		{
+			Key:       3,
+			Token:     "a",
+			CommentID: "1",
+			Version:   3,
+			Signature: "adminsig",
+			PublicKey: "adminpk",
+			Receipt:   "receipt3",
+			Timestamp: 300,
+			Censored:  true,
+			Reason:    "spam",
+		},
+	}
+}
+
+func TestGetCommentVersions(t *testing.T) {
+	d, cleanup := newTestFonero(t)
+	defer cleanup()
+
+	cvs := testCommentVersions()
+	want := make([]foneroplugin.CommentVersion, 0, len(cvs))
+	for _, v := range cvs {
+		v := v
+		testInsert(t, d.recordsdb, &v)
+		want = append(want, convertCommentVersionToFonero(v))
+	}
+
+	// The version of a different comment must not be returned
+	testInsert(t, d.recordsdb, &CommentVersion{
+		Token:     "b",
+		CommentID: "1",
+		Version:   1,
+	})
+
+	var tests = []struct {
+		name      string
+		commentID string
+		want      []foneroplugin.CommentVersion
+		wantErr   error
+	}{
+		{"all versions", "1", want, nil},
+		{"comment not found", "2", nil, cache.ErrRecordNotFound},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			gcv, err := foneroplugin.EncodeGetCommentVersions(
+				foneroplugin.GetCommentVersions{
+					Token:     "a",
+					CommentID: v.commentID,
+				})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			reply, err := d.cmdGetCommentVersions(string(gcv))
+			if err != v.wantErr {
+				t.Fatalf("got error %v, want %v", err, v.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			gcvr, err := foneroplugin.DecodeGetCommentVersionsReply(
+				[]byte(reply))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(gcvr.Versions, v.want) {
+				t.Fatalf("got %v, want %v", gcvr.Versions, v.want)
+			}
+		})
+	}
+}
+
+func TestCensorCommentVersion(t *testing.T) {
+	d, cleanup := newTestFonero(t)
+	defer cleanup()
+
+	testInsert(t, d.recordsdb, &Comment{
+		Key:       "a1",
+		Token:     "a",
+		CommentID: "1",
+		Comment:   "updated",
+	})
+	cvs := testCommentVersions()
+	for i := range cvs {
+		testInsert(t, d.recordsdb, &cvs[i])
+	}
+
+	cc, err := foneroplugin.EncodeCensorComment(foneroplugin.CensorComment{
+		Token:     "a",
+		CommentID: "1",
+		Reason:    "offtopic",
+		Signature: "adminsig",
+		PublicKey: "adminpk",
+		Receipt:   "receipt",
+		Timestamp: 400,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = d.cmdCensorComment(string(cc), "")
+	if err != nil {
+		t.Fatalf("cmdCensorComment: %v", err)
+	}
+
+	// The censor event is stored as the next comment version
+	var got []CommentVersion
+	err = d.recordsdb.
+		Where("token = ? AND comment_id = ?", "a", "1").
+		Order("version asc").
+		Find(&got).
+		Error
+	if err != nil {
+		t.Fatalf("lookup comment versions: %v", err)
+	}
+	if len(got) != len(cvs)+1 {
+		t.Fatalf("got %v comment versions, want %v", len(got),
+			len(cvs)+1)
+	}
+	cv := got[len(got)-1]
+	if cv.Version != uint32(len(cvs)+1) || !cv.Censored ||
+		cv.Reason != "offtopic" || cv.PublicKey != "adminpk" ||
+		cv.Timestamp != 400 {
+		t.Fatalf("got censor event %+v", cv)
+	}
+}
+
+func TestBuildSwapTables(t *testing.T) {
+	ir := &foneroplugin.InventoryReply{
+		Comments: []foneroplugin.Comment{
+			{
+				Token:     "a",
+				CommentID: "1",
+				Comment:   "comment",
+			},
+		},
+		LikeComments: []foneroplugin.LikeComment{
+			{
+				Token:     "a",
+				CommentID: "1",
+				Action:    "1",
+			},
+		},
+	}
+
+	// The live tables are rebuilt in place when the build tables
+	// cannot be swapped with the live tables.
+	var tests = []struct {
+		name  string
+		build func(*fonero) error
+	}{
+		{"swap", func(d *fonero) error {
+			return d.build(context.Background(), ir)
+		}},
+		{"in place", func(d *fonero) error {
+			return d.buildInPlace(context.Background(), ir)
+		}},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			d, cleanup := newTestFonero(t)
+			defer cleanup()
+
+			// The stale comment must be replaced by the build
+			testInsert(t, d.recordsdb, &Comment{
+				Key:       "b1",
+				Token:     "b",
+				CommentID: "1",
+			})
+
+			err := v.build(d)
+			if err != nil {
+				t.Fatalf("build: %v", err)
+			}
+
+			discrepancies, err := d.verifyIntegrity(ir, liveTableName)
+			if err != nil {
+				t.Fatalf("verifyIntegrity: %v", err)
+			}
+			if len(discrepancies) > 0 {
+				t.Fatalf("got discrepancies %v", discrepancies)
+			}
+			for _, table := range foneroTables {
+				for _, name := range []string{buildTableName(table),
+					table + oldTableSuffix} {
+					if d.recordsdb.HasTable(name) {
+						t.Fatalf("table %v left behind", name)
+					}
+				}
+			}
+			err = d.CheckVersion()
+			if err != nil {
+				t.Fatalf("CheckVersion: %v", err)
+			}
+		})
+	}
+}
+
+func TestCheckIntegrity(t *testing.T) {
+	d, cleanup := newTestFonero(t)
+	defer cleanup()
+
+	// The live tables contain a comment that is not part of the
+	// inventory.
+	testInsert(t, d.recordsdb, &Comment{
+		Key:       "b1",
+		Token:     "b",
+		CommentID: "1",
+	})
+
+	ir := &foneroplugin.InventoryReply{
+		Comments: []foneroplugin.Comment{
+			{
+				Token:     "a",
+				CommentID: "1",
+				Comment:   "comment",
+			},
+		},
+	}
+	ctx := context.Background()
+	err := d.createBuildTables(ctx)
+	if err != nil {
+		t.Fatalf("createBuildTables: %v", err)
+	}
+	err = d.populateTables(ctx, ir, buildTableName)
+	if err != nil {
+		t.Fatalf("populateTables: %v", err)
+	}
+
+	// The build tables match the inventory that they were built
+	// from while the live tables do not.
+	err = d.checkIntegrity(ir, buildTableName)
+	if err != nil {
+		t.Fatalf("build tables: %v", err)
+	}
+	err = d.checkIntegrity(ir, liveTableName)
+	if err == nil || !strings.Contains(err.Error(), "integrity check") {
+		t.Fatalf("live tables: got error %v, want integrity check "+
+			"failure", err)
+	}
+
+	// A mismatch is reported for the mismatched table only
+	mismatched := &foneroplugin.InventoryReply{
+		Comments: append(ir.Comments, foneroplugin.Comment{
+			Token:     "a",
+			CommentID: "2",
+		}),
+	}
+	discrepancies, err := d.verifyIntegrity(mismatched, buildTableName)
+	if err != nil {
+		t.Fatalf("verifyIntegrity: %v", err)
+	}
+	if len(discrepancies) != 1 ||
+		!strings.HasPrefix(discrepancies[0], tableComments+":") {
+		t.Fatalf("got discrepancies %v, want comments discrepancy",
+			discrepancies)
+	}
+}
+
+func TestBuildStartVoteHeights(t *testing.T) {
+	d, cleanup := newTestFonero(t)
+	defer cleanup()
+
+	// The start vote ends before it starts.  It was accepted by
+	// the backend and must be mirrored without failing the build.
+	ir := &foneroplugin.InventoryReply{
+		StartVoteTuples: []foneroplugin.StartVoteTuple{
+			{
+				StartVote: foneroplugin.StartVote{
+					Vote: foneroplugin.Vote{
+						Token: "a",
+						Mask:  0x03,
+						Options: []foneroplugin.VoteOption{
+							{Id: "no", Bits: 0x01},
+							{Id: "yes", Bits: 0x02},
+						},
+					},
+				},
+				StartVoteReply: foneroplugin.StartVoteReply{
+					StartBlockHeight: "200",
+					EndHeight:        "100",
+				},
+			},
+		},
+	}
+
+	err := d.build(context.Background(), ir)
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+
+	got := testQuery(t, d, "SELECT token || ':' || start_block_height || "+
+		"':' || CAST(end_height AS STRING) FROM start_votes")
+	want := []string{"a:200:100"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got start votes %v, want %v", got, want)
+	}
+}
+
+func TestExecWaitsForBuild(t *testing.T) {
+	d, cleanup := newTestFonero(t)
+	defer cleanup()
+
+	// Hold the build lock the way a build does
+	d.buildMtx.Lock()
+
+	// Commands that do not write are not blocked
+	_, err := d.Exec(foneroplugin.CmdInventoryDigest, "", "")
+	if err != nil {
+		t.Fatalf("Exec %v: %v", foneroplugin.CmdInventoryDigest, err)
+	}
+
+	// The token inventory is not blocked when there are no vote
+	// results to compute.
+	ti, err := foneroplugin.EncodeTokenInventory(
+		foneroplugin.TokenInventory{
+			BestBlock: 10,
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = d.Exec(foneroplugin.CmdTokenInventory, string(ti), "")
+	if err != nil {
+		t.Fatalf("Exec %v: %v", foneroplugin.CmdTokenInventory, err)
+	}
+
+	// Commands that write wait for the build to finish. The
+	// invalid payload fails the command once it runs.
+	done := make(chan struct{})
+	go func() {
+		d.Exec(foneroplugin.CmdReparentComment, "", "")
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatalf("write command ran during build")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	d.buildMtx.Unlock()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("write command did not run after build")
+	}
+
+	// The token inventory waits for the build to finish when it
+	// has to compute the vote results of a finished vote.
+	d.computeVoteResults = true
+	testInsert(t, d.recordsdb, &StartVote{
+		Token:     "f",
+		Mask:      0x03,
+		EndHeight: 1,
+		Options: []VoteOption{
+			{ID: "no", Bits: 0x01},
+			{ID: "yes", Bits: 0x02},
+		},
+	})
+	d.buildMtx.Lock()
+	done = make(chan struct{})
+	go func() {
+		d.Exec(foneroplugin.CmdTokenInventory, string(ti), "")
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatalf("vote results computed during build")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	d.buildMtx.Unlock()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("token inventory did not run after build")
+	}
+	results := testQuery(t, d, `SELECT token FROM vote_results`)
+	if !reflect.DeepEqual(results, []string{"f"}) {
+		t.Fatalf("got vote results %v, want [f]", results)
+	}
+}
+
+func TestConcurrentBuild(t *testing.T) {
+	d, cleanup := newTestFonero(t)
+	defer cleanup()
+
+	payload, err := foneroplugin.EncodeInventoryReply(
+		foneroplugin.InventoryReply{})
+	if err != nil {
+		t.Fatalf("EncodeInventoryReply: %v", err)
+	}
+
+	// A build is rejected while another build is running
+	d.Lock()
+	d.building = true
+	d.Unlock()
+	err = d.Build(context.Background(), string(payload))
+	if err != cache.ErrBuildInProgress {
+		t.Fatalf("got error %v, want %v", err, cache.ErrBuildInProgress)
+	}
+
+	// The rejected build must not clear the flag of the running
+	// build.
+	d.Lock()
+	building := d.building
+	d.building = false
+	d.Unlock()
+	if !building {
+		t.Fatalf("build no longer marked as in progress")
+	}
+
+	// A build may run again once the running build has finished
+	err = d.Build(context.Background(), string(payload))
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	d.Lock()
+	building = d.building
+	d.Unlock()
+	if building {
+		t.Fatalf("build still marked as in progress")
+	}
+}
+
+// testCancelContext is a context that reports that it has been cancelled once
+// its error has been checked the passed in number of times.  It is used to
+// cancel a build at a specific point.
+type testCancelContext struct {
+	context.Context
+	checks int
+}
+
+// Err returns context.Canceled once the context has run out of checks.
+func (c *testCancelContext) Err() error {
+	if c.checks <= 0 {
+		return context.Canceled
+	}
+	c.checks--
+	return nil
+}
+
+func TestBuildCancel(t *testing.T) {
+	ir := &foneroplugin.InventoryReply{
+		Comments: []foneroplugin.Comment{
+			{
+				Token:     "a",
+				CommentID: "1",
+				Comment:   "comment",
+			},
+		},
+		LikeComments: []foneroplugin.LikeComment{
+			{
+				Token:     "a",
+				CommentID: "1",
+				Action:    "1",
+			},
+		},
+	}
+	payload, err := foneroplugin.EncodeInventoryReply(*ir)
+	if err != nil {
+		t.Fatalf("EncodeInventoryReply: %v", err)
+	}
+
+	// The context is checked before each build table is created
+	// and before each section of the build tables is populated.
+	var tests = []struct {
+		name        string
+		checks      int  // Checks before the build is cancelled
+		wantTables  bool // Build tables were created
+		wantComment bool // Build comments table was populated
+	}{
+		{"create build tables", 1, false, false},
+		{"populate build tables", len(foneroTables) + 1, true, true},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			d, cleanup := newTestFonero(t)
+			defer cleanup()
+			testInsert(t, d.recordsdb, &Comment{
+				Key:       "b1",
+				Token:     "b",
+				CommentID: "1",
+			})
+
+			ctx := &testCancelContext{
+				Context: context.Background(),
+				checks:  v.checks,
+			}
+			err := d.Build(ctx, string(payload))
+			if err == nil || !strings.Contains(err.Error(),
+				context.Canceled.Error()) {
+				t.Fatalf("got error %v, want %v", err, context.Canceled)
+			}
+
+			// The build must stop within the section that was
+			// running when it was cancelled.  The creation of the
+			// build tables is rolled back and the live tables are
+			// left untouched.
+			tables := d.recordsdb.HasTable(buildTableName(tableComments))
+			if tables != v.wantTables {
+				t.Fatalf("got build tables %v, want %v", tables,
+					v.wantTables)
+			}
+			if v.wantTables {
+				got := testQuery(t, d, "SELECT key FROM "+
+					buildTableName(tableComments))
+				if (len(got) > 0) != v.wantComment {
+					t.Fatalf("got build comments %v", got)
+				}
+				got = testQuery(t, d, "SELECT key FROM "+
+					buildTableName(tableCommentLikes))
+				if len(got) > 0 {
+					t.Fatalf("like comments built after cancel")
+				}
+			}
+			comments := testQuery(t, d, "SELECT key FROM comments")
+			if !reflect.DeepEqual(comments, []string{"b1"}) {
+				t.Fatalf("got live comments %v, want [b1]", comments)
+			}
+
+			// The version record is removed so that the cache is
+			// rebuilt on the next start up.
+			err = d.CheckVersion()
+			if err != cache.ErrNoVersionRecord {
+				t.Fatalf("got error %v, want %v", err,
+					cache.ErrNoVersionRecord)
+			}
+		})
+	}
+}
+
+func TestBuildAuthorizeVoteSkips(t *testing.T) {
+	// The authorize vote of proposal b is missing its reply
+	ir := &foneroplugin.InventoryReply{
+		AuthorizeVotes: []foneroplugin.AuthorizeVote{
+			{
+				Token:   "a",
+				Action:  foneroplugin.AuthVoteActionAuthorize,
+				Receipt: "receipta",
+			},
+			{
+				Token:   "b",
+				Action:  foneroplugin.AuthVoteActionAuthorize,
+				Receipt: "receiptb",
+			},
+		},
+		AuthorizeVoteReplies: []foneroplugin.AuthorizeVoteReply{
+			{
+				Action:        foneroplugin.AuthVoteActionAuthorize,
+				RecordVersion: "1",
+				Receipt:       "receipta",
+			},
+		},
+	}
+
+	var tests = []struct {
+		name     string
+		maxSkips int
+		wantErr  bool
+	}{
+		{"skip allowed", 1, false},
+		{"skips exceed maximum", 0, true},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			d, cleanup := newTestFonero(t)
+			defer cleanup()
+			d.maxAuthVoteSkips = v.maxSkips
+
+			// Capture log output
+			var buf bytes.Buffer
+			logger := slog.NewBackend(&buf).Logger("CACH")
+			logger.SetLevel(slog.LevelWarn)
+			oldLog := log
+			log = logger
+			defer func() {
+				log = oldLog
+			}()
+
+			err := d.build(context.Background(), ir)
+			if (err != nil) != v.wantErr {
+				t.Fatalf("got error %v, want error %v", err, v.wantErr)
+			}
+			if !strings.Contains(buf.String(),
+				"AuthorizeVoteReply not found b receiptb, skipping") {
+				t.Fatalf("skip not logged: %q", buf.String())
+			}
+			if v.wantErr {
+				return
+			}
+
+			// Only the authorize vote with a reply is inserted
+			inserted := testQuery(t, d, "SELECT key FROM authorize_votes")
+			want := []string{"a1"}
+			if !reflect.DeepEqual(inserted, want) {
+				t.Fatalf("got inserted keys %v, want %v",
+					inserted, want)
+			}
+			if !strings.Contains(buf.String(),
+				"Skipped 1 authorize votes without a reply") {
+				t.Fatalf("skip count not logged: %q", buf.String())
+			}
+		})
+	}
+}
+
+func TestSetCommentVisibility(t *testing.T) {
+	d, cleanup := newTestFonero(t)
+	defer cleanup()
+
+	testInsert(t, d.recordsdb, &Comment{
+		Key:       "a1",
+		Token:     "a",
+		CommentID: "1",
+		Comment:   "comment",
+	})
+
+	var tests = []struct {
+		name   string
+		hidden bool
+	}{
+		{"hide", true},
+		{"unhide", false},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			b, err := foneroplugin.EncodeSetCommentVisibility(
+				foneroplugin.SetCommentVisibility{
+					Token:     "a",
+					CommentID: "1",
+					Hidden:    v.hidden,
+				})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = d.cmdSetCommentVisibility(string(b), "")
+			if err != nil {
+				t.Fatalf("cmdSetCommentVisibility: %v", err)
+			}
+
+			// The comment body must be retained so that the
+			// comment can be unhidden again.
+			c := Comment{
+				Key: "a1",
+			}
+			err = d.recordsdb.Find(&c).Error
+			if err != nil {
+				t.Fatalf("lookup comment: %v", err)
+			}
+			if c.Hidden != v.hidden {
+				t.Fatalf("got hidden %v, want %v", c.Hidden, v.hidden)
+			}
+			if c.Comment != "comment" {
+				t.Fatalf("got comment %q, want comment", c.Comment)
+			}
+		})
+	}
+}
+
+func TestHiddenComments(t *testing.T) {
+	d, cleanup := newTestFonero(t)
+	defer cleanup()
+
+	// Comment 2 is hidden.  Comment 3 is a reply to comment 2.
+	testInsert(t, d.recordsdb, &Comment{
+		Key:       "a1",
+		Token:     "a",
+		ParentID:  "0",
+		CommentID: "1",
+		Receipt:   "receipt1",
+		Timestamp: 100,
+	}, &Comment{
+		Key:       "a2",
+		Token:     "a",
+		ParentID:  "1",
+		CommentID: "2",
+		Receipt:   "receipt2",
+		Timestamp: 200,
+		Hidden:    true,
+	}, &Comment{
+		Key:       "a3",
+		Token:     "a",
+		ParentID:  "2",
+		CommentID: "3",
+		Receipt:   "receipt3",
+		Timestamp: 300,
+	})
+
+	// commentIDs returns the IDs of the passed in comments.
+	commentIDs := func(comments []foneroplugin.Comment) []string {
+		ids := make([]string, 0, len(comments))
+		for _, v := range comments {
+			ids = append(ids, v.CommentID)
+		}
+		return ids
+	}
+
+	var tests = []struct {
+		name string
+		cmd  func() ([]string, error) // Returns the comment IDs
+		want []string
+	}{
+		{"top comments", func() ([]string, error) {
+			b, err := foneroplugin.EncodeTopComments(
+				foneroplugin.TopComments{
+					Token: "a",
+				})
+			if err != nil {
+				return nil, err
+			}
+			reply, err := d.cmdTopComments(string(b))
+			if err != nil {
+				return nil, err
+			}
+			tcr, err := foneroplugin.DecodeTopCommentsReply([]byte(reply))
+			if err != nil {
+				return nil, err
+			}
+			return commentIDs(tcr.Comments), nil
+		}, []string{"1", "3"}},
+		{"ancestors", func() ([]string, error) {
+			b, err := foneroplugin.EncodeGetCommentAncestors(
+				foneroplugin.GetCommentAncestors{
+					Token:     "a",
+					CommentID: "3",
+				})
+			if err != nil {
+				return nil, err
+			}
+			reply, err := d.cmdGetCommentAncestors(string(b))
+			if err != nil {
+				return nil, err
+			}
+			gcar, err := foneroplugin.DecodeGetCommentAncestorsReply(
+				[]byte(reply))
+			if err != nil {
+				return nil, err
+			}
+			return append([]string{gcar.Comment.CommentID},
+				commentIDs(gcar.Ancestors)...), nil
+		}, []string{"3"}},
+		{"since", func() ([]string, error) {
+			b, err := foneroplugin.EncodeGetCommentsSince(
+				foneroplugin.GetCommentsSince{
+					Token: "a",
+				})
+			if err != nil {
+				return nil, err
+			}
+			reply, err := d.cmdGetCommentsSince(string(b))
+			if err != nil {
+				return nil, err
+			}
+			gcsr, err := foneroplugin.DecodeGetCommentsSinceReply(
+				[]byte(reply))
+			if err != nil {
+				return nil, err
+			}
+			return commentIDs(gcsr.Comments), nil
+		}, []string{"1", "3"}},
+		{"modified since", func() ([]string, error) {
+			b, err := foneroplugin.EncodeCommentsModifiedSince(
+				foneroplugin.CommentsModifiedSince{
+					Token: "a",
+				})
+			if err != nil {
+				return nil, err
+			}
+			reply, err := d.cmdCommentsModifiedSince(string(b))
+			if err != nil {
+				return nil, err
+			}
+			cmsr, err := foneroplugin.DecodeCommentsModifiedSinceReply(
+				[]byte(reply))
+			if err != nil {
+				return nil, err
+			}
+			return cmsr.CommentIDs, nil
+		}, []string{"1", "3"}},
+		{"by receipt", func() ([]string, error) {
+			ids := make([]string, 0, 3)
+			for _, v := range []string{"receipt1", "receipt2", "receipt3"} {
+				b, err := foneroplugin.EncodeGetCommentByReceipt(
+					foneroplugin.GetCommentByReceipt{
+						Receipt: v,
+					})
+				if err != nil {
+					return nil, err
+				}
+				reply, err := d.cmdGetCommentByReceipt(string(b))
+				if err == cache.ErrRecordNotFound {
+					continue
+				} else if err != nil {
+					return nil, err
+				}
+				gcbrr, err := foneroplugin.DecodeGetCommentByReceiptReply(
+					[]byte(reply))
+				if err != nil {
+					return nil, err
+				}
+				ids = append(ids, gcbrr.Comment.CommentID)
+			}
+			return ids, nil
+		}, []string{"1", "3"}},
+		{"timeline", func() ([]string, error) {
+			b, err := foneroplugin.EncodeProposalActivityTimeline(
+				foneroplugin.ProposalActivityTimeline{
+					Token: "a",
+				})
+			if err != nil {
+				return nil, err
+			}
+			reply, err := d.cmdProposalActivityTimeline(string(b))
+			if err != nil {
+				return nil, err
+			}
+			patr, err := foneroplugin.DecodeProposalActivityTimelineReply(
+				[]byte(reply))
+			if err != nil {
+				return nil, err
+			}
+			ids := make([]string, 0, len(patr.Events))
+			for _, v := range patr.Events {
+				ids = append(ids, v.CommentID)
+			}
+			return ids, nil
+		}, []string{"1", "3"}},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			got, err := v.cmd()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, v.want) {
+				t.Fatalf("got comments %v, want %v", got, v.want)
+			}
+		})
+	}
+
+	// The hidden comment itself cannot be looked up through its
+	// ancestors.
+	b, err := foneroplugin.EncodeGetCommentAncestors(
+		foneroplugin.GetCommentAncestors{
+			Token:     "a",
+			CommentID: "2",
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = d.cmdGetCommentAncestors(string(b))
+	if err != cache.ErrRecordNotFound {
+		t.Fatalf("got error %v, want %v", err, cache.ErrRecordNotFound)
+	}
+}
+
+func TestLikeCommentStates(t *testing.T) {
+	like := func(publicKey, action string) foneroplugin.LikeComment {
+		return foneroplugin.LikeComment{
+			Token:     "a",
+			CommentID: "1",
+			PublicKey: publicKey,
+			Action:    action,
+		}
+	}
+	state := func(publicKey, action string) LikeCommentState {
+		return LikeCommentState{
+			Key:       likeCommentStateKey("a", "1", publicKey),
+			Token:     "a",
+			CommentID: "1",
+			PublicKey: publicKey,
+			Action:    action,
+		}
+	}
+
+	const (
+		up   = foneroplugin.LikeActionUpvote
+		down = foneroplugin.LikeActionDownvote
+		undo = foneroplugin.LikeActionUndo
+	)
+
+	var tests = []struct {
+		name  string
+		likes []foneroplugin.LikeComment
+		want  []LikeCommentState
+	}{
+		{"up", []foneroplugin.LikeComment{like("pk1", up)},
+			[]LikeCommentState{state("pk1", up)}},
+		{"up undo", []foneroplugin.LikeComment{like("pk1", up),
+			like("pk1", undo)}, []LikeCommentState{}},
+		{"up undo down", []foneroplugin.LikeComment{like("pk1", up),
+			like("pk1", undo), like("pk1", down)},
+			[]LikeCommentState{state("pk1", down)}},
+		{"up down", []foneroplugin.LikeComment{like("pk1", up),
+			like("pk1", down)}, []LikeCommentState{state("pk1", down)}},
+		{"up up", []foneroplugin.LikeComment{like("pk1", up),
+			like("pk1", up)}, []LikeCommentState{}},
+		{"undo without like", []foneroplugin.LikeComment{
+			like("pk1", undo)}, []LikeCommentState{}},
+		{"multiple users", []foneroplugin.LikeComment{like("pk2", up),
+			like("pk1", up), like("pk2", undo), like("pk1", down),
+			like("pk2", down)}, []LikeCommentState{state("pk1", down),
+			state("pk2", down)}},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			got := likeCommentStates(v.likes)
+			if !reflect.DeepEqual(got, v.want) {
+				t.Fatalf("got states %v, want %v", got, v.want)
+			}
+		})
+	}
+}
+
+func TestLikeCommentUndo(t *testing.T) {
+	// The receipts and the undo timestamp are taken from the
+	// politeiad replies.
+	const (
+		receipt   = "receipt"
+		timestamp = int64(1234)
+	)
+	lcr, err := foneroplugin.EncodeLikeCommentReply(
+		foneroplugin.LikeCommentReply{
+			Receipt: receipt,
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lcur, err := foneroplugin.EncodeLikeCommentUndoReply(
+		foneroplugin.LikeCommentUndoReply{
+			Receipt:   receipt,
+			Timestamp: timestamp,
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const (
+		up   = foneroplugin.LikeActionUpvote
+		down = foneroplugin.LikeActionDownvote
+		undo = foneroplugin.LikeActionUndo
+	)
+	var tests = []struct {
+		name      string
+		current   string // Current like action, empty if none
+		action    string // Like action
+		wantState string // Like action of the state, empty if none
+	}{
+		{"new like", "", up, up},
+		{"flip like", up, down, down},
+		{"repeat like", up, up, ""},
+		{"undo like", up, undo, ""},
+		{"undo without like", "", undo, ""},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			d, cleanup := newTestFonero(t)
+			defer cleanup()
+			if v.current != "" {
+				testInsert(t, d.recordsdb, &LikeCommentState{
+					Key:       likeCommentStateKey("a", "1", "pk"),
+					Token:     "a",
+					CommentID: "1",
+					PublicKey: "pk",
+					Action:    v.current,
+				})
+			}
+
+			var err error
+			if v.action == undo {
+				var b []byte
+				b, err = foneroplugin.EncodeLikeCommentUndo(
+					foneroplugin.LikeCommentUndo{
+						Token:     "a",
+						CommentID: "1",
+						PublicKey: "pk",
+					})
+				if err != nil {
+					t.Fatal(err)
+				}
+				_, err = d.cmdLikeCommentUndo(string(b), string(lcur))
+			} else {
+				var b []byte
+				b, err = foneroplugin.EncodeLikeComment(
+					foneroplugin.LikeComment{
+						Token:     "a",
+						CommentID: "1",
+						PublicKey: "pk",
+						Action:    v.action,
+					})
+				if err != nil {
+					t.Fatal(err)
+				}
+				_, err = d.cmdLikeComment(string(b), string(lcr))
+			}
+			if err != nil {
+				t.Fatalf("like comment: %v", err)
+			}
+
+			// The like must be added to the history and the like
+			// state must be updated.
+			var likes []LikeComment
+			err = d.recordsdb.Find(&likes).Error
+			if err != nil {
+				t.Fatalf("lookup likes: %v", err)
+			}
+			if len(likes) != 1 {
+				t.Fatalf("got %v likes, want 1", len(likes))
+			}
+			lc := likes[0]
+			if lc.Action != v.action || lc.Receipt != receipt {
+				t.Fatalf("got like %+v, want action %v and receipt %v",
+					lc, v.action, receipt)
+			}
+			if v.action == undo && lc.Timestamp != timestamp {
+				t.Fatalf("got timestamp %v, want %v", lc.Timestamp,
+					timestamp)
+			}
+
+			var states []LikeCommentState
+			err = d.recordsdb.Find(&states).Error
+			if err != nil {
+				t.Fatalf("lookup like states: %v", err)
+			}
+			var gotState string
+			if len(states) > 0 {
+				gotState = states[0].Action
+			}
+			if len(states) > 1 || gotState != v.wantState {
+				t.Fatalf("got like states %v, want action %q",
+					states, v.wantState)
+			}
+		})
+	}
+}
+
+func TestCountDelta(t *testing.T) {
+	var tests = []struct {
+		name         string
+		cache        uint64
+		source       uint64
+		threshold    uint64
+		wantDelta    int64
+		wantDiverged bool
+	}{
+		{"match", 10, 10, 0, 0, false},
+		{"cache behind", 8, 10, 0, -2, true},
+		{"cache ahead", 12, 10, 0, 2, true},
+		{"behind within threshold", 8, 10, 2, -2, false},
+		{"ahead within threshold", 12, 10, 2, 2, false},
+		{"behind beyond threshold", 7, 10, 2, -3, true},
+		{"ahead beyond threshold", 13, 10, 2, 3, true},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			got := countDelta("table", v.cache, v.source, v.threshold)
+			want := foneroplugin.CountDelta{
+				Table:    "table",
+				Cache:    v.cache,
+				Source:   v.source,
+				Delta:    v.wantDelta,
+				Diverged: v.wantDiverged,
+			}
+			if got != want {
+				t.Fatalf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+// testEligibleTickets returns the passed in number of unique hex encoded
+// tickets.
+func testEligibleTickets(n int) []string {
+	tickets := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		tickets = append(tickets, fmt.Sprintf("%064x", i))
+	}
+	return tickets
+}
+
+func TestConvertStartVoteEligibleTicketsRoundTrip(t *testing.T) {
+	tickets := testEligibleTickets(40960)
+
+	sv := convertStartVoteFromFonero(foneroplugin.StartVote{
+		Vote: foneroplugin.Vote{
+			Token: "a",
+		},
+	}, foneroplugin.StartVoteReply{
+		EligibleTickets: tickets,
+	}, 0)
+	if sv.EligibleTicketCount != len(tickets) {
+		t.Fatalf("got count %v, want %v", sv.EligibleTicketCount,
+			len(tickets))
+	}
+
+	_, svr := convertStartVoteToFonero(sv)
+	if !reflect.DeepEqual(svr.EligibleTickets, tickets) {
+		t.Fatalf("eligible tickets did not round trip")
+	}
+}
+
+func TestInsertEligibleTickets(t *testing.T) {
+	d, cleanup := newTestFonero(t)
+	defer cleanup()
+
+	tickets := testEligibleTickets(2*eligibleTicketsBatchSize + 1)
+	sv := convertStartVoteFromFonero(foneroplugin.StartVote{
+		Vote: foneroplugin.Vote{
+			Token: "a",
+		},
+	}, foneroplugin.StartVoteReply{
+		EligibleTickets: tickets,
+	}, 0)
+
+	err := insertEligibleTickets(d.recordsdb, tableEligibleTickets,
+		sv.EligibleTickets)
+	if err != nil {
+		t.Fatalf("insertEligibleTickets: %v", err)
+	}
+
+	// The tickets are inserted in order
+	var et []EligibleTicket
+	err = d.recordsdb.Order("position").Find(&et).Error
+	if err != nil {
+		t.Fatalf("lookup eligible tickets: %v", err)
+	}
+	if len(et) != len(tickets) {
+		t.Fatalf("got %v tickets, want %v", len(et), len(tickets))
+	}
+	for i, v := range et {
+		want := EligibleTicket{
+			Key:      "a" + tickets[i],
+			Token:    "a",
+			Ticket:   tickets[i],
+			Position: i,
+		}
+		if v != want {
+			t.Fatalf("got ticket %v, want %v", v, want)
+		}
+	}
+}
+
+func TestArchiveProposalVotes(t *testing.T) {
+	payload, err := foneroplugin.EncodeArchiveProposalVotes(
+		foneroplugin.ArchiveProposalVotes{
+			Token: "a",
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tickets := []string{"t1", "t2", "t1", "t3", "t1"}
+	votes := make([]CastVote, 0, len(tickets))
+	for i, v := range tickets {
+		votes = append(votes, CastVote{
+			Token:        "a",
+			Ticket:       v,
+			VoteBit:      "1",
+			Signature:    "sig" + strconv.Itoa(i),
+			TokenVoteBit: "a1",
+		})
+	}
+
+	var tests = []struct {
+		name         string
+		archive      *CastVoteArchive
+		wantDigest   string
+		wantArchived bool // Archive is created and cast votes deleted
+	}{
+		{"archive", nil, castVotesDigest(votes), true},
+		{"already archived", &CastVoteArchive{
+			Token:  "a",
+			Digest: "digest",
+			Votes:  uint64(len(votes)),
+		}, "digest", false},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			d, cleanup := newTestFonero(t)
+			defer cleanup()
+			d.bestBlockSource = &testBestBlockSource{height: 500}
+
+			testInsert(t, d.recordsdb, &StartVote{
+				Token:     "a",
+				EndHeight: 100,
+			}, &VoteResults{
+				Token: "a",
+			}, &CastVoteCount{
+				Key:     "a1",
+				Token:   "a",
+				VoteBit: "1",
+				Votes:   uint64(len(votes)),
+			})
+			for _, cv := range votes {
+				cv := cv
+				testInsert(t, d.recordsdb, &cv)
+			}
+			if v.archive != nil {
+				testInsert(t, d.recordsdb, v.archive)
+			}
+
+			reply, err := d.cmdArchiveProposalVotes(string(payload))
+			if err != nil {
+				t.Fatalf("cmdArchiveProposalVotes: %v", err)
+			}
+			r, err := foneroplugin.DecodeArchiveProposalVotesReply(
+				[]byte(reply))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.Digest != v.wantDigest {
+				t.Fatalf("got digest %v, want %v", r.Digest, v.wantDigest)
+			}
+			if r.Votes != uint64(len(votes)) {
+				t.Fatalf("got %v votes, want %v", r.Votes, len(votes))
+			}
+
+			// The digest is stored and the cast votes are deleted.
+			// The vote results and the cast vote counters are not
+			// touched.
+			digests := testQuery(t, d, `SELECT digest
+        FROM cast_vote_archives WHERE token = 'a'`)
+			if !reflect.DeepEqual(digests, []string{v.wantDigest}) {
+				t.Fatalf("got archive digests %v, want %v", digests,
+					v.wantDigest)
+			}
+			cast := testQuery(t, d, `SELECT ticket FROM cast_votes`)
+			wantCast := len(votes)
+			if v.wantArchived {
+				wantCast = 0
+			}
+			if len(cast) != wantCast {
+				t.Fatalf("got %v cast votes, want %v", len(cast),
+					wantCast)
+			}
+			results := testQuery(t, d, `SELECT token FROM vote_results`)
+			if !reflect.DeepEqual(results, []string{"a"}) {
+				t.Fatalf("got vote results %v, want [a]", results)
+			}
+			counts := testQuery(t, d, `SELECT key || ':' ||
+        CAST(votes AS STRING) FROM cast_vote_counts`)
+			wantCounts := []string{fmt.Sprintf("a1:%v", len(votes))}
+			if !reflect.DeepEqual(counts, wantCounts) {
+				t.Fatalf("got cast vote counts %v, want %v", counts,
+					wantCounts)
+			}
+		})
+	}
+}
+
+func TestRecomputeVoteResultsArchived(t *testing.T) {
+	d, cleanup := newTestFonero(t)
+	defer cleanup()
+	d.bestBlockSource = &testBestBlockSource{height: 500}
+
+	testInsert(t, d.recordsdb, &StartVote{
+		Token:     "a",
+		EndHeight: 100,
+	}, &VoteResults{
+		Token: "a",
+	}, &CastVoteArchive{
+		Token:  "a",
+		Digest: "digest",
+	})
+
+	payload, err := foneroplugin.EncodeRecomputeVoteResults(
+		foneroplugin.RecomputeVoteResults{
+			Token: "a",
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = d.cmdRecomputeVoteResults(string(payload))
+	if err == nil || !strings.Contains(err.Error(), "archived") {
+		t.Fatalf("got error %v, want archived error", err)
+	}
+
+	// The vote results must not be deleted
+	results := testQuery(t, d, `SELECT token FROM vote_results`)
+	if !reflect.DeepEqual(results, []string{"a"}) {
+		t.Fatalf("got vote results %v, want [a]", results)
+	}
+}
+
+func TestExpireActiveVotes(t *testing.T) {
+	var tests = []struct {
+		name        string
+		fromHeight  uint64
+		dryRun      bool
+		wantTokens  []string
+		wantCreated bool
+	}{
+		{"just expired", 9, false, []string{"finished"}, true},
+		{"dry run", 9, true, []string{"finished"}, false},
+		{"already reported", 10, false, []string{}, false},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			d, cleanup := newTestFonero(t)
+			defer cleanup()
+			d.bestBlockSource = &testBestBlockSource{height: 20}
+
+			testInsert(t, d.recordsdb, &StartVote{
+				Token:     "finished",
+				Mask:      0x03,
+				EndHeight: 10,
+				Options: []VoteOption{
+					{ID: "no", Bits: 0x01},
+					{ID: "yes", Bits: 0x02},
+				},
+			}, &StartVote{
+				Token:     "active",
+				EndHeight: 30,
+			})
+
+			payload, err := foneroplugin.EncodeExpireActiveVotes(
+				foneroplugin.ExpireActiveVotes{
+					FromHeight: v.fromHeight,
+					DryRun:     v.dryRun,
+				})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			reply, err := d.cmdExpireActiveVotes(string(payload))
+			if err != nil {
+				t.Fatalf("cmdExpireActiveVotes: %v", err)
+			}
+			eavr, err := foneroplugin.DecodeExpireActiveVotesReply(
+				[]byte(reply))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if eavr.BestBlock != 20 {
+				t.Fatalf("got best block %v, want 20", eavr.BestBlock)
+			}
+			if !reflect.DeepEqual(eavr.Tokens, v.wantTokens) {
+				t.Fatalf("got tokens %v, want %v", eavr.Tokens,
+					v.wantTokens)
+			}
+
+			// The vote results of the expired vote must only be
+			// created when this is not a dry run.
+			results := testQuery(t, d, `SELECT token FROM vote_results`)
+			created := reflect.DeepEqual(results, []string{"finished"})
+			if created != v.wantCreated {
+				t.Fatalf("got vote results %v, want created %v",
+					results, v.wantCreated)
+			}
+		})
+	}
+}
+
+// newTestComment runs the new comment command for the passed in comment.
+func newTestComment(d *fonero, token, commentID, parentID, publicKey string) error {
+	nc, err := foneroplugin.EncodeNewComment(foneroplugin.NewComment{
+		Token:     token,
+		ParentID:  parentID,
+		Comment:   "comment",
+		Signature: "signature",
+		PublicKey: publicKey,
+	})
+	if err != nil {
+		return err
+	}
+	ncr, err := foneroplugin.EncodeNewCommentReply(
+		foneroplugin.NewCommentReply{
+			CommentID: commentID,
+			Receipt:   "receipt",
+			Timestamp: 100,
+		})
+	if err != nil {
+		return err
+	}
+	_, err = d.cmdNewComment(string(nc), string(ncr))
+	return err
+}
+
+func TestNewCommentHandler(t *testing.T) {
+	d, cleanup := newTestFonero(t)
+	defer cleanup()
+
+	// Comments must be inserted without a handler.
+	err := newTestComment(d, "a", "1", "0", "pk1")
+	if err != nil {
+		t.Fatalf("newTestComment: %v", err)
+	}
+
+	events := make(chan cache.NewCommentEvent, 1)
+	d.setNewCommentHandler(func(e cache.NewCommentEvent) {
+		events <- e
+	})
+
+	err = newTestComment(d, "a", "2", "1", "pk2")
+	if err != nil {
+		t.Fatalf("newTestComment: %v", err)
+	}
+
+	want := cache.NewCommentEvent{
+		Token:     "a",
+		CommentID: "2",
+		ParentID:  "1",
+		PublicKey: "pk2",
+	}
+	select {
+	case e := <-events:
+		if e != want {
+			t.Fatalf("got event %v, want %v", e, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("handler not called")
+	}
+
+	// A failed insert must not notify the handler.
+	_, err = d.cmdNewComment("", "")
+	if err == nil {
+		t.Fatalf("cmdNewComment: expected error")
+	}
+	select {
+	case e := <-events:
+		t.Fatalf("unexpected event %v", e)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestNewCommentHandlerBlocked(t *testing.T) {
+	d, cleanup := newTestFonero(t)
+	defer cleanup()
+
+	// A handler that never returns must not block the inserts, even
+	// once the event queue is full.
+	block := make(chan struct{})
+	defer close(block)
+	d.setNewCommentHandler(func(e cache.NewCommentEvent) {
+		<-block
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		for i := 0; i < 2*newCommentEventsBuffer; i++ {
+			err := newTestComment(d, "a", strconv.Itoa(i+1), "0", "pk")
+			if err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- nil
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("newTestComment: %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("inserts blocked by the new comment handler")
+	}
+}
+
+func TestInventoryCommentOrder(t *testing.T) {
+	d, cleanup := newTestFonero(t)
+	defer cleanup()
+
+	// newComment returns a comment of the passed in proposal.
+	newComment := func(token, commentID string, timestamp int64) *Comment {
+		return &Comment{
+			Key:       token + commentID,
+			Token:     token,
+			ParentID:  "0",
+			Comment:   "comment " + commentID,
+			CommentID: commentID,
+			Timestamp: timestamp,
+		}
+	}
+
+	testInsert(t, d.recordsdb,
+		newComment("b", "1", 100),
+		newComment("a", "3", 300),
+		newComment("a", "1", 100),
+		newComment("b", "2", 200),
+		newComment("a", "2", 200),
+		newComment("a", "4", 300),
+		newComment("c", "1", 50),
+	)
+
+	// inventory returns the keys of the inventory comments in the
+	// order that they are returned.
+	inventory := func() []string {
+		reply, err := d.cmdInventory()
+		if err != nil {
+			t.Fatalf("cmdInventory: %v", err)
+		}
+		ir, err := foneroplugin.DecodeInventoryReply([]byte(reply))
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys := make([]string, 0, len(ir.Comments))
+		for _, v := range ir.Comments {
+			keys = append(keys, v.Token+v.CommentID)
+		}
+		return keys
+	}
+
+	want := []string{"a1", "a2", "a3", "a4", "b1", "b2", "c1"}
+	for i := 0; i < 10; i++ {
+		got := inventory()
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("inventory %v: got comments %v, want %v",
+				i, got, want)
+		}
+	}
+}
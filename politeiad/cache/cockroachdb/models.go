@@ -69,16 +69,26 @@ func (Record) TableName() string {
 //
 // This is a fonero plugin model.
 type Comment struct {
-	Key       string `gorm:"primary_key"`       // Primary key (token+commentID)
-	Token     string `gorm:"not null;size:64"`  // Censorship token
-	ParentID  string `gorm:"not null"`          // Parent comment ID
-	Comment   string `gorm:"not null"`          // Comment
-	Signature string `gorm:"not null;size:128"` // Client Signature of Token+ParentID+Comment
-	PublicKey string `gorm:"not null;size:64"`  // Pubkey used for Signature
-	CommentID string `gorm:"not null"`          // Comment ID
-	Receipt   string `gorm:"not null"`          // Server signature of the client Signature
-	Timestamp int64  `gorm:"not null"`          // Received UNIX timestamp
-	Censored  bool   `gorm:"not null"`          // Has this comment been censored
+	Key       string `gorm:"primary_key"`            // Primary key (token+commentID)
+	Token     string `gorm:"not null;size:64"`       // Censorship token
+	ParentID  string `gorm:"not null"`               // Parent comment ID
+	Comment   string `gorm:"not null"`               // Comment
+	Signature string `gorm:"not null;size:128"`      // Client Signature of Token+ParentID+Comment
+	PublicKey string `gorm:"not null;size:64;index"` // Pubkey used for Signature
+	CommentID string `gorm:"not null"`               // Comment ID
+	Receipt   string `gorm:"not null;index"`         // Server signature of the client Signature
+	Timestamp int64  `gorm:"not null"`               // Received UNIX timestamp
+	Censored  bool   `gorm:"not null"`               // Has this comment been censored
+
+	// CensoredTimestamp is the UNIX timestamp of when the comment was
+	// censored.  It is zero for comments that have not been censored
+	// and for comments that were censored before the cache was built.
+	CensoredTimestamp int64 `gorm:"not null;default:0"`
+
+	// Hidden indicates that the comment has been hidden by an admin.
+	// Unlike a censored comment, a hidden comment retains its body
+	// and can be unhidden again.
+	Hidden bool `gorm:"not null;default:false"`
 }
 
 // TableName returns the name of the Comment database table.
@@ -86,6 +96,52 @@ func (Comment) TableName() string {
 	return tableComments
 }
 
+// CommentVersion is a stored version of a comment.  A new version is stored
+// when a comment is inserted, when an existing comment is overwritten and when
+// a comment is censored.  Censor events contain the signature and public key
+// of the admin that censored the comment.
+//
+// This is a fonero plugin model.
+type CommentVersion struct {
+	Key       uint   `gorm:"primary_key"`       // Primary key
+	Token     string `gorm:"not null;size:64"`  // Censorship token
+	CommentID string `gorm:"not null"`          // Comment ID
+	Version   uint32 `gorm:"not null"`          // Version number
+	Comment   string `gorm:"not null"`          // Comment
+	Signature string `gorm:"not null;size:128"` // Client signature
+	PublicKey string `gorm:"not null;size:64"`  // Pubkey used for signature
+	Receipt   string `gorm:"not null"`          // Server signature of the client signature
+	Timestamp int64  `gorm:"not null"`          // Received UNIX timestamp
+	Censored  bool   `gorm:"not null"`          // Is this a censor event
+	Reason    string `gorm:"not null"`          // Reason comment was censored
+}
+
+// TableName returns the name of the CommentVersion database table.
+func (CommentVersion) TableName() string {
+	return tableCommentVersions
+}
+
+// CensoredComment is the audit record of the body of a comment that was
+// censored while the fonero plugin was configured to retain censored comment
+// bodies.  Censored comments are not part of the plugin inventory, so the
+// table is not rebuilt when the cache is rebuilt.
+//
+// This is a fonero plugin model.
+type CensoredComment struct {
+	Key       string `gorm:"primary_key"`      // Primary key (token+commentID)
+	Token     string `gorm:"not null;size:64"` // Censorship token
+	CommentID string `gorm:"not null"`         // Comment ID
+	Comment   string `gorm:"not null"`         // Censored comment body
+	PublicKey string `gorm:"not null;size:64"` // Pubkey of the censoring admin
+	Reason    string `gorm:"not null"`         // Reason comment was censored
+	Timestamp int64  `gorm:"not null"`         // Censored UNIX timestamp
+}
+
+// TableName returns the name of the CensoredComment database table.
+func (CensoredComment) TableName() string {
+	return tableCensoredComments
+}
+
 // LikeComment describes a comment upvote/downvote.  The server side metadata
 // is not included.
 //
@@ -94,9 +150,19 @@ type LikeComment struct {
 	Key       uint   `gorm:"primary_key"`       // Primary key
 	Token     string `gorm:"not null;size:64"`  // Censorship token
 	CommentID string `gorm:"not null"`          // Comment ID
-	Action    string `gorm:"not null;size:2"`   // Up or downvote (1, -1)
+	Action    string `gorm:"not null;size:2"`   // Up or downvote (1, -1) or undo (0)
 	Signature string `gorm:"not null;size:128"` // Client Signature of Token+CommentID+Action
 	PublicKey string `gorm:"not null;size:64"`  // Public key used for Signature
+
+	// Timestamp is the UNIX timestamp of when the like was received.
+	// It is zero for likes whose timestamp is not part of the plugin
+	// inventory.
+	Timestamp int64 `gorm:"not null;default:0"`
+
+	// Receipt is the server signature of the like signature.  It is
+	// empty for likes whose receipt is not part of the plugin
+	// inventory.
+	Receipt string `gorm:"not null;default:''"`
 }
 
 // TableName returns the name of the LikeComment database table.
@@ -104,6 +170,25 @@ func (LikeComment) TableName() string {
 	return tableCommentLikes
 }
 
+// LikeCommentState describes the current like of a public key on a comment.
+// It is derived from the LikeComment history, which is retained, and there is
+// at most one LikeCommentState per public key and comment.  The state is
+// removed when the like is undone.
+//
+// This is a fonero plugin model.
+type LikeCommentState struct {
+	Key       string `gorm:"primary_key"`            // Primary key (token+commentID+publicKey)
+	Token     string `gorm:"not null;size:64;index"` // Censorship token
+	CommentID string `gorm:"not null"`               // Comment ID
+	PublicKey string `gorm:"not null;size:64"`       // Public key of the like
+	Action    string `gorm:"not null;size:2"`        // Up or downvote (1, -1)
+}
+
+// TableName returns the name of the LikeCommentState database table.
+func (LikeCommentState) TableName() string {
+	return tableCommentLikeStates
+}
+
 // AuthorizeVote is used to indicate that a record has been finalized and is
 // ready to be voted on.
 //
@@ -128,11 +213,12 @@ func (AuthorizeVote) TableName() string {
 //
 // This is a fonero plugin model.
 type VoteOption struct {
-	Key         uint   `gorm:"primary_key"`      // Primary key
-	Token       string `gorm:"not null;size:64"` // StartVote foreign key
-	ID          string `gorm:"not null"`         // Single unique word identifying vote (e.g. yes)
-	Description string `gorm:"not null"`         // Longer description of the vote
-	Bits        uint64 `gorm:"not null"`         // Bits used for this option
+	Key            uint   `gorm:"primary_key"`        // Primary key
+	Token          string `gorm:"not null;size:64"`   // StartVote foreign key
+	ID             string `gorm:"not null"`           // Single unique word identifying vote (e.g. yes)
+	Description    string `gorm:"not null"`           // Longer description of the vote
+	Bits           uint64 `gorm:"not null"`           // Bits used for this option
+	PassPercentage uint32 `gorm:"not null;default:0"` // Option pass percentage (approval votes only)
 }
 
 // TableName returns the name of the VoteOption database table.
@@ -140,6 +226,24 @@ func (VoteOption) TableName() string {
 	return tableVoteOptions
 }
 
+// EligibleTicket is a ticket that is eligible to vote on a proposal.  The
+// primary key is the token+ticket so that eligibility checks use the primary
+// key index.  Position is the index of the ticket in the eligible ticket list
+// of the StartVote and is used to preserve the ticket order.
+//
+// This is a fonero plugin model.
+type EligibleTicket struct {
+	Key      string `gorm:"primary_key"`            // Primary key (token+ticket)
+	Token    string `gorm:"not null;size:64;index"` // StartVote foreign key
+	Ticket   string `gorm:"not null;size:64"`       // Ticket hash
+	Position int    `gorm:"not null"`               // Position in the eligible tickets
+}
+
+// TableName returns the name of the EligibleTicket database table.
+func (EligibleTicket) TableName() string {
+	return tableEligibleTickets
+}
+
 // StartVote records the details of a proposal vote.
 //
 // This is a fonero plugin model.
@@ -150,14 +254,23 @@ type StartVote struct {
 	Duration            uint32       `gorm:"not null"`            // Duration in blocks
 	QuorumPercentage    uint32       `gorm:"not null"`            // Percent of eligible votes required for quorum
 	PassPercentage      uint32       `gorm:"not null"`            // Percent of total votes required to pass
+	Type                uint32       `gorm:"not null;default:0"`  // Vote type
 	Options             []VoteOption `gorm:"foreignkey:Token"`    // Vote option
 	PublicKey           string       `gorm:"not null;size:64"`    // Key used for signature
 	Signature           string       `gorm:"not null;size:128"`   // Signature of Votehash
 	StartBlockHeight    string       `gorm:"not null"`            // Block height
 	StartBlockHash      string       `gorm:"not null"`            // Block hash
 	EndHeight           uint64       `gorm:"not null"`            // Height of vote end
-	EligibleTickets     string       `gorm:"not null"`            // Valid voting tickets
 	EligibleTicketCount int          `gorm:"not null"`            // Number of eligible tickets
+
+	// Timestamp is the UNIX timestamp of when the vote was started.
+	// It is zero for votes that were started before the cache was
+	// built.
+	Timestamp int64 `gorm:"not null;default:0"`
+
+	// EligibleTickets are the valid voting tickets.  They are not
+	// loaded unless explicitly preloaded ordered by position.
+	EligibleTickets []EligibleTicket `gorm:"foreignkey:Token"`
 }
 
 // TableName returns the name of the StartVote database table.
@@ -177,7 +290,20 @@ type CastVote struct {
 
 	// TokenVoteBit is the Token+VoteBit. Indexing TokenVoteBit allows
 	// for quick lookups of the number of votes cast for each vote bit.
+	// The vote bit is in its canonical form so that it matches the
+	// vote option bits. VoteBit is stored as it was signed.
 	TokenVoteBit string `gorm:"no null;index"`
+
+	// Timestamp is the UNIX timestamp of when the vote was added to the
+	// cache.  It is zero for votes that were cast before the cache was
+	// built.
+	Timestamp int64 `gorm:"not null;default:0"`
+
+	// Receipt is the server signature of the vote signature that was
+	// returned to the voter.  The receipt is not part of the vote that
+	// is stored in the inventory, so it is empty for votes that were
+	// loaded when the cache was built.
+	Receipt string `gorm:"not null;default:''"`
 }
 
 // TableName returns the name of the CastVote database table.
@@ -185,16 +311,35 @@ func (CastVote) TableName() string {
 	return tableCastVotes
 }
 
+// CastVoteCount records the running total of the votes that have been cast
+// for a vote option.  The counts are incremented as ballots are inserted so
+// that the results of an active vote can be read without counting the
+// CastVote records.
+//
+// This is a fonero plugin model.
+type CastVoteCount struct {
+	Key     string `gorm:"primary_key"`      // Primary key (token+votebit)
+	Token   string `gorm:"not null;size:64"` // Censorship token
+	VoteBit string `gorm:"not null"`         // Canonical hex encoded vote bit
+	Votes   uint64 `gorm:"not null"`         // Number of votes cast for this vote bit
+}
+
+// TableName returns the name of the CastVoteCount database table.
+func (CastVoteCount) TableName() string {
+	return tableCastVoteCounts
+}
+
 // VoteOptionResults records the vote result for a vote option. A
 // VoteOptionResult should only be created once the proposal vote has finished.
 //
 // This is a fonero plugin model.
 type VoteOptionResult struct {
-	Key       string     `gorm:"primary_key"`      // Primary key (token+votebit)
-	Token     string     `gorm:"not null;size:64"` // Censorship token (VoteResults foreign key)
-	Votes     uint64     `gorm:"not null"`         // Number of votes cast for this option
-	Option    VoteOption `gorm:"not null"`         // Vote option
-	OptionKey uint       `gorm:"not null"`         // VoteOption foreign key
+	Key       string     `gorm:"primary_key"`            // Primary key (token+votebit)
+	Token     string     `gorm:"not null;size:64"`       // Censorship token (VoteResults foreign key)
+	Votes     uint64     `gorm:"not null"`               // Number of votes cast for this option
+	Option    VoteOption `gorm:"not null"`               // Vote option
+	OptionKey uint       `gorm:"not null"`               // VoteOption foreign key
+	Approved  bool       `gorm:"not null;default:false"` // Option was approved (approval votes only)
 }
 
 // TableName returns the name of the VoteOptionResult database table.
@@ -209,11 +354,34 @@ func (VoteOptionResult) TableName() string {
 // This is a fonero plugin model.
 type VoteResults struct {
 	Token    string             `gorm:"primary_key;size:64"` // Censorship token
-	Approved bool               `gorm:"not null"`            // Vote was approved
+	Approved bool               `gorm:"not null"`            // Vote was approved (any option approved for approval votes)
 	Results  []VoteOptionResult `gorm:"foreignkey:Token"`    // Results for the vote options
+
+	// Digest is the digest of the cast votes and the vote option
+	// results at the time that the vote results were created.  See
+	// foneroplugin.ComputeVoteResultsDigest for the digest format.
+	Digest string `gorm:"not null;size:64"`
 }
 
 // TableName returns the name of the VoteResults database table.
 func (VoteResults) TableName() string {
 	return tableVoteResults
 }
+
+// CastVoteArchive records that the cast votes of a finished proposal vote
+// were deleted from the cache.  The digest allows the archived cast votes to
+// be verified against the backend.  Archived cast votes are only restored by
+// rebuilding the cache.
+//
+// This is a fonero plugin model.
+type CastVoteArchive struct {
+	Token     string `gorm:"primary_key;size:64"` // Censorship token
+	Digest    string `gorm:"not null;size:64"`    // SHA256 digest of the cast votes
+	Votes     uint64 `gorm:"not null"`            // Number of archived cast votes
+	Timestamp int64  `gorm:"not null"`            // UNIX timestamp of the archive
+}
+
+// TableName returns the name of the CastVoteArchive database table.
+func (CastVoteArchive) TableName() string {
+	return tableCastVoteArchives
+}
@@ -5,6 +5,12 @@
 package testcache
 
 import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
 	fonero "github.com/fonero-project/politeia/foneroplugin"
 	"github.com/fonero-project/politeia/politeiad/cache"
 )
@@ -18,9 +24,33 @@ func (c *testcache) getComments(payload string) (string, error) {
 	c.RLock()
 	defer c.RUnlock()
 
+	// Page through the comments ordered by timestamp and
+	// comment ID
+	comments := make([]fonero.Comment, 0, len(c.comments[gc.Token]))
+	for _, v := range c.comments[gc.Token] {
+		if v.Hidden && !gc.IncludeHidden {
+			continue
+		}
+		comments = append(comments, v)
+	}
+	sort.SliceStable(comments, func(i, j int) bool {
+		if comments[i].Timestamp != comments[j].Timestamp {
+			return comments[i].Timestamp < comments[j].Timestamp
+		}
+		return comments[i].CommentID < comments[j].CommentID
+	})
+	start := int(gc.Offset)
+	if start > len(comments) {
+		start = len(comments)
+	}
+	end := len(comments)
+	if gc.Limit > 0 && start+int(gc.Limit) < end {
+		end = start + int(gc.Limit)
+	}
+
 	gcrb, err := fonero.EncodeGetCommentsReply(
 		fonero.GetCommentsReply{
-			Comments: c.comments[gc.Token],
+			Comments: comments[start:end],
 		})
 	if err != nil {
 		return "", err
@@ -29,40 +59,308 @@ func (c *testcache) getComments(payload string) (string, error) {
 	return string(gcrb), nil
 }
 
-func (c *testcache) authorizeVote(cmdPayload, replyPayload string) (string, error) {
-	av, err := fonero.DecodeAuthorizeVote([]byte(cmdPayload))
+func (c *testcache) getCommentsForProposals(payload string) (string, error) {
+	gcfp, err := fonero.DecodeGetCommentsForProposals([]byte(payload))
 	if err != nil {
 		return "", err
 	}
 
-	avr, err := fonero.DecodeAuthorizeVoteReply([]byte(replyPayload))
+	c.RLock()
+	defer c.RUnlock()
+
+	// Group the most recent comments by token
+	grouped := make(map[string][]fonero.Comment, len(gcfp.Tokens))
+	for _, token := range gcfp.Tokens {
+		comments := make([]fonero.Comment, 0, len(c.comments[token]))
+		for _, v := range c.comments[token] {
+			if v.Hidden || (v.Censored && gcfp.ExcludeCensored) {
+				continue
+			}
+			comments = append(comments, v)
+		}
+		sort.SliceStable(comments, func(i, j int) bool {
+			if comments[i].Timestamp != comments[j].Timestamp {
+				return comments[i].Timestamp > comments[j].Timestamp
+			}
+			return comments[i].CommentID > comments[j].CommentID
+		})
+		if gcfp.Limit > 0 && len(comments) > int(gcfp.Limit) {
+			comments = comments[:gcfp.Limit]
+		}
+		grouped[token] = comments
+	}
+
+	reply, err := fonero.EncodeGetCommentsForProposalsReply(
+		fonero.GetCommentsForProposalsReply{
+			Comments: grouped,
+		})
 	if err != nil {
 		return "", err
 	}
 
-	av.Receipt = avr.Receipt
-	av.Timestamp = avr.Timestamp
+	return string(reply), nil
+}
+
+func (c *testcache) commentedProposals(payload string) (string, error) {
+	cp, err := fonero.DecodeCommentedProposals([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	c.RLock()
+	defer c.RUnlock()
+
+	proposals := make([]fonero.CommentedProposal, 0, 16)
+	for token, comments := range c.comments {
+		var count uint64
+		for _, v := range comments {
+			if v.PublicKey == cp.PublicKey {
+				count++
+			}
+		}
+		if count > 0 {
+			proposals = append(proposals, fonero.CommentedProposal{
+				Token:    token,
+				Comments: count,
+			})
+		}
+	}
+	sort.Slice(proposals, func(i, j int) bool {
+		return proposals[i].Token < proposals[j].Token
+	})
+
+	reply, err := fonero.EncodeCommentedProposalsReply(
+		fonero.CommentedProposalsReply{
+			Proposals: proposals,
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(reply), nil
+}
+
+// inventoryDigest returns a digest of the fonero plugin contents of the cache
+// using the same tables and primary keys as the cockroachdb cache.
+func (c *testcache) inventoryDigest() (string, error) {
+	c.RLock()
+	defer c.RUnlock()
+
+	var comments, likes, likeStates, authVotes, startVotes, castVotes []string
+	for token, v := range c.comments {
+		for _, comment := range v {
+			comments = append(comments, token+comment.CommentID)
+		}
+	}
+	for token, v := range c.commentLikes {
+		states := make(map[string]string) // [key]action
+		for _, like := range v {
+			likes = append(likes, token+like.CommentID+like.Signature)
+			key := token + like.CommentID + like.PublicKey
+			states[key] = fonero.LikeCommentAction(states[key], like.Action)
+		}
+		for k, action := range states {
+			if action != "" {
+				likeStates = append(likeStates, k)
+			}
+		}
+	}
+	for token, v := range c.authorizeVotes {
+		for version := range v {
+			authVotes = append(authVotes, token+version)
+		}
+	}
+	for token := range c.startVotes {
+		startVotes = append(startVotes, token)
+	}
+	for token, v := range c.castVotes {
+		for _, cv := range v {
+			castVotes = append(castVotes, token+cv.Ticket)
+		}
+	}
+
+	keys := []struct {
+		table string
+		keys  []string
+	}{
+		{"comments", comments},
+		{"comment_likes", likes},
+		{"comment_like_states", likeStates},
+		{"authorize_votes", authVotes},
+		{"start_votes", startVotes},
+		{"cast_votes", castVotes},
+		{"cast_vote_archives", []string{}},
+	}
+	tables := make([]fonero.TableDigest, 0, len(keys))
+	for _, v := range keys {
+		tables = append(tables, fonero.TableDigest{
+			Table:  v.table,
+			Count:  len(v.keys),
+			Digest: fonero.ComputeKeysDigest(v.keys),
+		})
+	}
+
+	reply, err := fonero.EncodeInventoryDigestReply(
+		fonero.InventoryDigestReply{
+			Digest:   fonero.ComputeInventoryDigest(tables),
+			Tables:   tables,
+			Archived: []string{},
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(reply), nil
+}
+
+func (c *testcache) commentThreadStats(payload string) (string, error) {
+	cts, err := fonero.DecodeCommentThreadStats([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	c.RLock()
+	defer c.RUnlock()
+
+	comments := c.comments[cts.Token]
+	parents := make(map[string]string, len(comments)) // [commentID]parentID
+	threads := make([]fonero.CommentThread, 0, len(comments))
+	index := make(map[string]int, len(comments)) // [rootID]threads index
+	for _, v := range comments {
+		parents[v.CommentID] = v.ParentID
+		if v.ParentID == "0" {
+			index[v.CommentID] = len(threads)
+			threads = append(threads, fonero.CommentThread{
+				CommentID: v.CommentID,
+			})
+		}
+	}
+
+	// Walk up to the root comment of every reply
+	for _, v := range comments {
+		id := v.ParentID
+		for depth := 0; id != "0" && depth < len(comments); depth++ {
+			parentID, ok := parents[id]
+			if !ok || parentID == "0" {
+				break
+			}
+			id = parentID
+		}
+		i, ok := index[id]
+		if v.ParentID == "0" || !ok {
+			continue
+		}
+		if v.Censored {
+			threads[i].Censored++
+		} else {
+			threads[i].Replies++
+		}
+	}
+
+	ctsrb, err := fonero.EncodeCommentThreadStatsReply(
+		fonero.CommentThreadStatsReply{
+			Threads: threads,
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(ctsrb), nil
+}
+
+func (c *testcache) newComment(cmdPayload, replyPayload string) (string, error) {
+	nc, err := fonero.DecodeNewComment([]byte(cmdPayload))
+	if err != nil {
+		return "", err
+	}
+	ncr, err := fonero.DecodeNewCommentReply([]byte(replyPayload))
+	if err != nil {
+		return "", err
+	}
 
 	c.Lock()
 	defer c.Unlock()
 
-	_, ok := c.authorizeVotes[av.Token]
-	if !ok {
-		c.authorizeVotes[av.Token] = make(map[string]fonero.AuthorizeVote)
+	comment := fonero.Comment{
+		Token:     nc.Token,
+		ParentID:  nc.ParentID,
+		Comment:   nc.Comment,
+		Signature: nc.Signature,
+		PublicKey: nc.PublicKey,
+		CommentID: ncr.CommentID,
+		Receipt:   ncr.Receipt,
+		Timestamp: ncr.Timestamp,
 	}
 
-	c.authorizeVotes[av.Token][avr.RecordVersion] = *av
+	// An existing comment is overwritten unless it has been
+	// censored or is identical to the new comment.
+	var found bool
+	for i, v := range c.comments[nc.Token] {
+		if v.CommentID != comment.CommentID {
+			continue
+		}
+		if v.Censored || v == comment {
+			return replyPayload, nil
+		}
+		c.comments[nc.Token][i] = comment
+		found = true
+		break
+	}
+	if !found {
+		c.comments[nc.Token] = append(c.comments[nc.Token], comment)
+	}
+
+	c.addCommentVersion(nc.Token, comment.CommentID, fonero.CommentVersion{
+		Comment:   comment.Comment,
+		Signature: comment.Signature,
+		PublicKey: comment.PublicKey,
+		Receipt:   comment.Receipt,
+		Timestamp: comment.Timestamp,
+	})
 
 	return replyPayload, nil
 }
 
-func (c *testcache) startVote(cmdPayload, replyPayload string) (string, error) {
-	sv, err := fonero.DecodeStartVote([]byte(cmdPayload))
+// addCommentVersion stores the passed in comment version as the next version
+// of the comment.
+//
+// This function must be called with the lock held.
+func (c *testcache) addCommentVersion(token, commentID string, cv fonero.CommentVersion) {
+	if _, ok := c.commentVersions[token]; !ok {
+		c.commentVersions[token] = make(map[string][]fonero.CommentVersion)
+	}
+	versions := c.commentVersions[token][commentID]
+	cv.Version = uint32(len(versions) + 1)
+	c.commentVersions[token][commentID] = append(versions, cv)
+}
+
+func (c *testcache) getCommentVersions(payload string) (string, error) {
+	gcv, err := fonero.DecodeGetCommentVersions([]byte(payload))
 	if err != nil {
 		return "", err
 	}
 
-	svr, err := fonero.DecodeStartVoteReply([]byte(replyPayload))
+	c.RLock()
+	defer c.RUnlock()
+
+	versions, ok := c.commentVersions[gcv.Token][gcv.CommentID]
+	if !ok {
+		return "", cache.ErrRecordNotFound
+	}
+
+	gcvr, err := fonero.EncodeGetCommentVersionsReply(
+		fonero.GetCommentVersionsReply{
+			Versions: versions,
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(gcvr), nil
+}
+
+func (c *testcache) censorComment(cmdPayload, replyPayload string) (string, error) {
+	cc, err := fonero.DecodeCensorComment([]byte(cmdPayload))
 	if err != nil {
 		return "", err
 	}
@@ -70,15 +368,76 @@ func (c *testcache) startVote(cmdPayload, replyPayload string) (string, error) {
 	c.Lock()
 	defer c.Unlock()
 
-	// Store start vote data
-	c.startVotes[sv.Vote.Token] = *sv
-	c.startVoteReplies[sv.Vote.Token] = *svr
+	for i, v := range c.comments[cc.Token] {
+		if v.CommentID != cc.CommentID {
+			continue
+		}
+		v.Comment = ""
+		v.Censored = true
+		c.comments[cc.Token][i] = v
 
-	return replyPayload, nil
+		if _, ok := c.censoredAt[cc.Token]; !ok {
+			c.censoredAt[cc.Token] = make(map[string]int64)
+		}
+		c.censoredAt[cc.Token][cc.CommentID] = cc.Timestamp
+
+		c.addCommentVersion(cc.Token, cc.CommentID, fonero.CommentVersion{
+			Signature: cc.Signature,
+			PublicKey: cc.PublicKey,
+			Receipt:   cc.Receipt,
+			Timestamp: cc.Timestamp,
+			Censored:  true,
+			Reason:    cc.Reason,
+		})
+		return replyPayload, nil
+	}
+
+	return "", cache.ErrRecordNotFound
 }
 
-func (c *testcache) voteDetails(payload string) (string, error) {
-	vd, err := fonero.DecodeVoteDetails([]byte(payload))
+func (c *testcache) censoredComments(payload string) (string, error) {
+	cc, err := fonero.DecodeCensoredComments([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	c.RLock()
+	defer c.RUnlock()
+
+	comments := make([]fonero.Comment, 0, 16)
+	for token, v := range c.comments {
+		if cc.Token != "" && token != cc.Token {
+			continue
+		}
+		for _, comment := range v {
+			if comment.Censored {
+				comments = append(comments, comment)
+			}
+		}
+	}
+	sort.SliceStable(comments, func(i, j int) bool {
+		if comments[i].Timestamp != comments[j].Timestamp {
+			return comments[i].Timestamp < comments[j].Timestamp
+		}
+		if comments[i].Token != comments[j].Token {
+			return comments[i].Token < comments[j].Token
+		}
+		return comments[i].CommentID < comments[j].CommentID
+	})
+
+	ccrb, err := fonero.EncodeCensoredCommentsReply(
+		fonero.CensoredCommentsReply{
+			Comments: comments,
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(ccrb), nil
+}
+
+func (c *testcache) setCommentVisibility(cmdPayload, replyPayload string) (string, error) {
+	scv, err := fonero.DecodeSetCommentVisibility([]byte(cmdPayload))
 	if err != nil {
 		return "", err
 	}
@@ -86,41 +445,1948 @@ func (c *testcache) voteDetails(payload string) (string, error) {
 	c.Lock()
 	defer c.Unlock()
 
-	// Lookup the latest record version
-	r, err := c.record(vd.Token)
+	for i, v := range c.comments[scv.Token] {
+		if v.CommentID != scv.CommentID {
+			continue
+		}
+		v.Hidden = scv.Hidden
+		c.comments[scv.Token][i] = v
+		return replyPayload, nil
+	}
+
+	return "", cache.ErrRecordNotFound
+}
+
+func (c *testcache) getCommentsSince(payload string) (string, error) {
+	gcs, err := fonero.DecodeGetCommentsSince([]byte(payload))
 	if err != nil {
 		return "", err
 	}
 
-	// Prepare reply
-	_, ok := c.authorizeVotes[vd.Token]
+	c.RLock()
+	defer c.RUnlock()
+
+	// Lookup the timestamp of the last seen comment
+	since := gcs.Timestamp
+	if gcs.CommentID != "" {
+		found := false
+		for _, v := range c.comments[gcs.Token] {
+			if v.CommentID == gcs.CommentID {
+				since = v.Timestamp
+				found = true
+				break
+			}
+		}
+		if !found {
+			return "", cache.ErrRecordNotFound
+		}
+	}
+
+	comments := make([]fonero.Comment, 0, len(c.comments[gcs.Token]))
+	for _, v := range c.comments[gcs.Token] {
+		if v.Hidden {
+			continue
+		}
+		censoredAt, censored := c.censoredAt[gcs.Token][v.CommentID]
+		var include bool
+		if gcs.CommentID == "" {
+			include = v.Timestamp > since ||
+				(censored && censoredAt > since)
+		} else {
+			include = (v.Timestamp >= since &&
+				v.CommentID != gcs.CommentID) ||
+				(censored && censoredAt >= since)
+		}
+		if include {
+			comments = append(comments, v)
+		}
+	}
+	sort.SliceStable(comments, func(i, j int) bool {
+		return comments[i].Timestamp < comments[j].Timestamp
+	})
+
+	gcsrb, err := fonero.EncodeGetCommentsSinceReply(
+		fonero.GetCommentsSinceReply{
+			Comments: comments,
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(gcsrb), nil
+}
+
+func (c *testcache) getCommentByReceipt(payload string) (string, error) {
+	gcbr, err := fonero.DecodeGetCommentByReceipt([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	c.RLock()
+	defer c.RUnlock()
+
+	for _, comments := range c.comments {
+		for _, v := range comments {
+			if v.Receipt != gcbr.Receipt || v.Hidden {
+				continue
+			}
+			gcbrrb, err := fonero.EncodeGetCommentByReceiptReply(
+				fonero.GetCommentByReceiptReply{
+					Comment: v,
+				})
+			if err != nil {
+				return "", err
+			}
+			return string(gcbrrb), nil
+		}
+	}
+
+	return "", cache.ErrRecordNotFound
+}
+
+func (c *testcache) getCommentAncestors(payload string) (string, error) {
+	gca, err := fonero.DecodeGetCommentAncestors([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	maxDepth := int(gca.MaxDepth)
+	if maxDepth == 0 || maxDepth > fonero.CommentAncestorsMaxDepth {
+		maxDepth = fonero.CommentAncestorsMaxDepth
+	}
+
+	c.RLock()
+	defer c.RUnlock()
+
+	comments := make(map[string]fonero.Comment) // [commentID]Comment
+	for _, v := range c.comments[gca.Token] {
+		if v.Hidden {
+			continue
+		}
+		comments[v.CommentID] = v
+	}
+
+	comment, ok := comments[gca.CommentID]
 	if !ok {
-		c.authorizeVotes[vd.Token] = make(map[string]fonero.AuthorizeVote)
+		return "", cache.ErrRecordNotFound
 	}
 
-	vdb, err := fonero.EncodeVoteDetailsReply(
-		fonero.VoteDetailsReply{
-			AuthorizeVote:  c.authorizeVotes[vd.Token][r.Version],
-			StartVote:      c.startVotes[vd.Token],
-			StartVoteReply: c.startVoteReplies[vd.Token],
+	ancestors := make([]fonero.Comment, 0, maxDepth)
+	for id := comment.ParentID; id != "0" && len(ancestors) < maxDepth; {
+		a, ok := comments[id]
+		if !ok {
+			break
+		}
+		ancestors = append(ancestors, a)
+		id = a.ParentID
+	}
+
+	gcarb, err := fonero.EncodeGetCommentAncestorsReply(
+		fonero.GetCommentAncestorsReply{
+			Comment:   comment,
+			Ancestors: ancestors,
 		})
 	if err != nil {
 		return "", err
 	}
 
-	return string(vdb), nil
+	return string(gcarb), nil
+}
+
+func (c *testcache) commentsModifiedSince(payload string) (string, error) {
+	cms, err := fonero.DecodeCommentsModifiedSince([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	c.RLock()
+	defer c.RUnlock()
+
+	modified := make(map[string]struct{})
+	for commentID, versions := range c.commentVersions[cms.Token] {
+		for _, v := range versions {
+			if v.Timestamp > cms.Timestamp {
+				modified[commentID] = struct{}{}
+			}
+		}
+	}
+	for _, v := range c.comments[cms.Token] {
+		censoredAt := c.censoredAt[cms.Token][v.CommentID]
+		if v.Timestamp > cms.Timestamp || censoredAt > cms.Timestamp {
+			modified[v.CommentID] = struct{}{}
+		}
+	}
+	for _, v := range c.comments[cms.Token] {
+		if v.Hidden {
+			delete(modified, v.CommentID)
+		}
+	}
+	ids := make([]string, 0, len(modified))
+	for k := range modified {
+		ids = append(ids, k)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, _ := strconv.Atoi(ids[i])
+		b, _ := strconv.Atoi(ids[j])
+		return a < b
+	})
+
+	likes := make([]fonero.LikeComment, 0, len(c.commentLikes[cms.Token]))
+	for _, v := range c.commentLikes[cms.Token] {
+		if v.Timestamp > cms.Timestamp {
+			likes = append(likes, v)
+		}
+	}
+
+	reply, err := fonero.EncodeCommentsModifiedSinceReply(
+		fonero.CommentsModifiedSinceReply{
+			CommentIDs: ids,
+			Likes:      likes,
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(reply), nil
+}
+
+func (c *testcache) likeComment(cmdPayload, replyPayload string) (string, error) {
+	lc, err := fonero.DecodeLikeComment([]byte(cmdPayload))
+	if err != nil {
+		return "", err
+	}
+
+	if lc.Timestamp == 0 {
+		lc.Timestamp = time.Now().Unix()
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	c.commentLikes[lc.Token] = append(c.commentLikes[lc.Token], *lc)
+
+	return replyPayload, nil
+}
+
+func (c *testcache) likeCommentUndo(cmdPayload, replyPayload string) (string, error) {
+	lcu, err := fonero.DecodeLikeCommentUndo([]byte(cmdPayload))
+	if err != nil {
+		return "", err
+	}
+	lcur, err := fonero.DecodeLikeCommentUndoReply([]byte(replyPayload))
+	if err != nil {
+		return "", err
+	}
+
+	ts := lcur.Timestamp
+	if ts == 0 {
+		ts = time.Now().Unix()
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	c.commentLikes[lcu.Token] = append(c.commentLikes[lcu.Token],
+		fonero.LikeComment{
+			Token:     lcu.Token,
+			CommentID: lcu.CommentID,
+			Action:    fonero.LikeActionUndo,
+			Signature: lcu.Signature,
+			PublicKey: lcu.PublicKey,
+			Receipt:   lcur.Receipt,
+			Timestamp: ts,
+		})
+
+	return replyPayload, nil
+}
+
+func (c *testcache) getCommentLikes(payload string) (string, error) {
+	cl, err := fonero.DecodeCommentLikes([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	c.RLock()
+	defer c.RUnlock()
+
+	// Replay the like history to find the current like of each
+	// public key
+	likes := make([]fonero.LikeComment, 0, len(c.commentLikes[cl.Token]))
+	actions := make(map[string]string) // [publicKey]action
+	for _, v := range c.commentLikes[cl.Token] {
+		if v.CommentID != cl.CommentID {
+			continue
+		}
+		likes = append(likes, v)
+		actions[v.PublicKey] = fonero.LikeCommentAction(
+			actions[v.PublicKey], v.Action)
+	}
+
+	current := make([]fonero.LikeComment, 0, len(actions))
+	for k, v := range actions {
+		if v == "" {
+			continue
+		}
+		current = append(current, fonero.LikeComment{
+			Token:     cl.Token,
+			CommentID: cl.CommentID,
+			Action:    v,
+			PublicKey: k,
+		})
+	}
+	sort.Slice(current, func(i, j int) bool {
+		return current[i].PublicKey < current[j].PublicKey
+	})
+
+	clrb, err := fonero.EncodeCommentLikesReply(
+		fonero.CommentLikesReply{
+			CommentLikes: likes,
+			Current:      current,
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(clrb), nil
+}
+
+func (c *testcache) topComments(payload string) (string, error) {
+	tc, err := fonero.DecodeTopComments([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	c.RLock()
+	defer c.RUnlock()
+
+	// Replay the like history to find the current like of each
+	// public key on each comment
+	actions := make(map[string]map[string]string) // [commentID][publicKey]action
+	for _, v := range c.commentLikes[tc.Token] {
+		if _, ok := actions[v.CommentID]; !ok {
+			actions[v.CommentID] = make(map[string]string)
+		}
+		actions[v.CommentID][v.PublicKey] = fonero.LikeCommentAction(
+			actions[v.CommentID][v.PublicKey], v.Action)
+	}
+
+	top := make([]fonero.Comment, 0, len(c.comments[tc.Token]))
+	for _, v := range c.comments[tc.Token] {
+		if v.Censored || v.Hidden {
+			continue
+		}
+		v.TotalVotes = 0
+		v.ResultVotes = 0
+		for _, action := range actions[v.CommentID] {
+			switch action {
+			case fonero.LikeActionUpvote:
+				v.TotalVotes++
+				v.ResultVotes++
+			case fonero.LikeActionDownvote:
+				v.TotalVotes++
+				v.ResultVotes--
+			}
+		}
+		top = append(top, v)
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].ResultVotes != top[j].ResultVotes {
+			return top[i].ResultVotes > top[j].ResultVotes
+		}
+		if top[i].Timestamp != top[j].Timestamp {
+			return top[i].Timestamp < top[j].Timestamp
+		}
+		return top[i].CommentID < top[j].CommentID
+	})
+	if tc.Limit > 0 && int(tc.Limit) < len(top) {
+		top = top[:tc.Limit]
+	}
+
+	reply, err := fonero.EncodeTopCommentsReply(
+		fonero.TopCommentsReply{
+			Comments: top,
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(reply), nil
+}
+
+func (c *testcache) proposalCommentsLikeCounts(payload string) (string, error) {
+	g, err := fonero.DecodeGetProposalCommentsLikeCounts([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	c.RLock()
+	defer c.RUnlock()
+
+	// Determine the current like action of each public key on
+	// each comment
+	actions := make(map[string]map[string]string) // [commentID][publicKey]action
+	for _, v := range c.commentLikes[g.Token] {
+		if _, ok := actions[v.CommentID]; !ok {
+			actions[v.CommentID] = make(map[string]string)
+		}
+		actions[v.CommentID][v.PublicKey] = fonero.LikeCommentAction(
+			actions[v.CommentID][v.PublicKey], v.Action)
+	}
+
+	// Tally the current like actions of each comment
+	counts := make(map[string]*fonero.CommentLikeCounts)
+	for commentID, v := range actions {
+		for _, action := range v {
+			if action == "" {
+				continue
+			}
+			lc, ok := counts[commentID]
+			if !ok {
+				lc = &fonero.CommentLikeCounts{
+					CommentID: commentID,
+				}
+				counts[commentID] = lc
+			}
+			switch action {
+			case fonero.LikeActionUpvote:
+				lc.Upvotes++
+				lc.Score++
+			case fonero.LikeActionDownvote:
+				lc.Downvotes++
+				lc.Score--
+			}
+		}
+	}
+
+	likeCounts := make([]fonero.CommentLikeCounts, 0, len(counts))
+	for _, v := range counts {
+		likeCounts = append(likeCounts, *v)
+	}
+	sort.Slice(likeCounts, func(i, j int) bool {
+		return likeCounts[i].CommentID < likeCounts[j].CommentID
+	})
+
+	lcrb, err := fonero.EncodeGetProposalCommentsLikeCountsReply(
+		fonero.GetProposalCommentsLikeCountsReply{
+			LikeCounts: likeCounts,
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(lcrb), nil
+}
+
+func (c *testcache) commentLikesNetScore(payload string) (string, error) {
+	cl, err := fonero.DecodeCommentLikesNetScore([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	c.RLock()
+	defer c.RUnlock()
+
+	// Determine the current like action of each public key on
+	// each comment
+	actions := make(map[string]map[string]string) // [commentID][publicKey]action
+	for _, v := range c.commentLikes[cl.Token] {
+		if _, ok := actions[v.CommentID]; !ok {
+			actions[v.CommentID] = make(map[string]string)
+		}
+		actions[v.CommentID][v.PublicKey] = fonero.LikeCommentAction(
+			actions[v.CommentID][v.PublicKey], v.Action)
+	}
+
+	scores := make(map[string]int64, len(cl.CommentIDs))
+	for _, id := range cl.CommentIDs {
+		var score int64
+		for _, action := range actions[id] {
+			switch action {
+			case fonero.LikeActionUpvote:
+				score++
+			case fonero.LikeActionDownvote:
+				score--
+			}
+		}
+		scores[id] = score
+	}
+
+	reply, err := fonero.EncodeCommentLikesNetScoreReply(
+		fonero.CommentLikesNetScoreReply{
+			Scores: scores,
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(reply), nil
+}
+
+func (c *testcache) authorizeVote(cmdPayload, replyPayload string) (string, error) {
+	av, err := fonero.DecodeAuthorizeVote([]byte(cmdPayload))
+	if err != nil {
+		return "", err
+	}
+
+	avr, err := fonero.DecodeAuthorizeVoteReply([]byte(replyPayload))
+	if err != nil {
+		return "", err
+	}
+
+	av.Receipt = avr.Receipt
+	av.Timestamp = avr.Timestamp
+
+	c.Lock()
+	defer c.Unlock()
+
+	_, ok := c.authorizeVotes[av.Token]
+	if !ok {
+		c.authorizeVotes[av.Token] = make(map[string]fonero.AuthorizeVote)
+	}
+
+	c.authorizeVotes[av.Token][avr.RecordVersion] = *av
+
+	return replyPayload, nil
+}
+
+func (c *testcache) startVote(cmdPayload, replyPayload string) (string, error) {
+	sv, err := fonero.DecodeStartVote([]byte(cmdPayload))
+	if err != nil {
+		return "", err
+	}
+
+	svr, err := fonero.DecodeStartVoteReply([]byte(replyPayload))
+	if err != nil {
+		return "", err
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	// Store start vote data
+	c.startVotes[sv.Vote.Token] = *sv
+	c.startVoteReplies[sv.Vote.Token] = *svr
+	c.startVoteTimes[sv.Vote.Token] = time.Now().Unix()
+
+	return replyPayload, nil
+}
+
+func (c *testcache) voteDetails(payload string) (string, error) {
+	vd, err := fonero.DecodeVoteDetails([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	// Lookup the latest record version
+	r, err := c.record(vd.Token)
+	if err != nil {
+		return "", err
+	}
+
+	// Prepare reply
+	_, ok := c.authorizeVotes[vd.Token]
+	if !ok {
+		c.authorizeVotes[vd.Token] = make(map[string]fonero.AuthorizeVote)
+	}
+
+	vdb, err := fonero.EncodeVoteDetailsReply(
+		fonero.VoteDetailsReply{
+			AuthorizeVote:  c.authorizeVotes[vd.Token][r.Version],
+			StartVote:      c.startVotes[vd.Token],
+			StartVoteReply: c.startVoteReplies[vd.Token],
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(vdb), nil
+}
+
+func (c *testcache) getStartVoteReply(payload string) (string, error) {
+	g, err := fonero.DecodeGetStartVoteReply([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	c.RLock()
+	defer c.RUnlock()
+
+	svr, ok := c.startVoteReplies[g.Token]
+	if !ok {
+		return "", cache.ErrRecordNotFound
+	}
+
+	reply, err := fonero.EncodeStartVoteReply(svr)
+	if err != nil {
+		return "", err
+	}
+
+	return string(reply), nil
+}
+
+func (c *testcache) getVoteOptions(payload string) (string, error) {
+	g, err := fonero.DecodeGetVoteOptions([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	c.RLock()
+	defer c.RUnlock()
+
+	sv, ok := c.startVotes[g.Token]
+	if !ok {
+		return "", cache.ErrRecordNotFound
+	}
+
+	reply, err := fonero.EncodeGetVoteOptionsReply(
+		fonero.GetVoteOptionsReply{
+			Mask:    sv.Vote.Mask,
+			Options: sv.Vote.Options,
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(reply), nil
+}
+
+func (c *testcache) getEligibleTicketCount(payload string) (string, error) {
+	g, err := fonero.DecodeGetEligibleTicketCount([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	c.RLock()
+	defer c.RUnlock()
+
+	svr, ok := c.startVoteReplies[g.Token]
+	if !ok {
+		return "", cache.ErrRecordNotFound
+	}
+
+	reply, err := fonero.EncodeGetEligibleTicketCountReply(
+		fonero.GetEligibleTicketCountReply{
+			EligibleTicketCount: len(svr.EligibleTickets),
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(reply), nil
+}
+
+func (c *testcache) proposalActivityTimeline(payload string) (string, error) {
+	pat, err := fonero.DecodeProposalActivityTimeline([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	c.RLock()
+	defer c.RUnlock()
+
+	// The events of every type are ordered by timestamp before
+	// all events are sorted.
+	var comments, likes, avs, cvs []fonero.TimelineEvent
+	for _, v := range c.comments[pat.Token] {
+		if v.Hidden {
+			continue
+		}
+		comments = append(comments, fonero.TimelineEvent{
+			Type:      fonero.TimelineEventComment,
+			Timestamp: v.Timestamp,
+			PublicKey: v.PublicKey,
+			CommentID: v.CommentID,
+		})
+	}
+	for _, v := range c.commentLikes[pat.Token] {
+		likes = append(likes, fonero.TimelineEvent{
+			Type:      fonero.TimelineEventLikeComment,
+			Timestamp: v.Timestamp,
+			PublicKey: v.PublicKey,
+			CommentID: v.CommentID,
+			Action:    v.Action,
+		})
+	}
+	for _, v := range c.authorizeVotes[pat.Token] {
+		avs = append(avs, fonero.TimelineEvent{
+			Type:      fonero.TimelineEventAuthorizeVote,
+			Timestamp: v.Timestamp,
+			PublicKey: v.PublicKey,
+			Action:    v.Action,
+		})
+	}
+	for _, v := range c.castVotes[pat.Token] {
+		cvs = append(cvs, fonero.TimelineEvent{
+			Type:      fonero.TimelineEventCastVote,
+			Timestamp: c.castVoteTimes[pat.Token][v.Ticket],
+			Ticket:    v.Ticket,
+			VoteBit:   v.VoteBit,
+		})
+	}
+	fonero.SortTimelineEvents(comments)
+	fonero.SortTimelineEvents(likes)
+	fonero.SortTimelineEvents(avs)
+	fonero.SortTimelineEvents(cvs)
+
+	events := make([]fonero.TimelineEvent, 0,
+		len(comments)+len(likes)+len(avs)+len(cvs)+1)
+	events = append(events, comments...)
+	events = append(events, likes...)
+	events = append(events, avs...)
+	if sv, ok := c.startVotes[pat.Token]; ok {
+		events = append(events, fonero.TimelineEvent{
+			Type:      fonero.TimelineEventStartVote,
+			Timestamp: c.startVoteTimes[pat.Token],
+			PublicKey: sv.PublicKey,
+		})
+	}
+	events = append(events, cvs...)
+	fonero.SortTimelineEvents(events)
+
+	reply, err := fonero.EncodeProposalActivityTimelineReply(
+		fonero.ProposalActivityTimelineReply{
+			Events: events,
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(reply), nil
+}
+
+func (c *testcache) recordStatusCounts(payload string) (string, error) {
+	_, err := fonero.DecodeRecordStatusCounts([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	c.RLock()
+	defer c.RUnlock()
+
+	counts := map[int]uint64{
+		int(cache.RecordStatusNotReviewed):       0,
+		int(cache.RecordStatusCensored):          0,
+		int(cache.RecordStatusPublic):            0,
+		int(cache.RecordStatusUnreviewedChanges): 0,
+		int(cache.RecordStatusArchived):          0,
+	}
+	for token := range c.records {
+		r, err := c.record(token)
+		if err != nil {
+			return "", err
+		}
+		counts[int(r.Status)]++
+	}
+
+	reply, err := fonero.EncodeRecordStatusCountsReply(
+		fonero.RecordStatusCountsReply{
+			Counts: counts,
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(reply), nil
+}
+
+func (c *testcache) archiveStats(payload string) (string, error) {
+	_, err := fonero.DecodeArchiveStats([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	c.RLock()
+	defer c.RUnlock()
+
+	var (
+		r   fonero.ArchiveStatsReply
+		age int64
+	)
+	for token, versions := range c.records {
+		latest, err := c.record(token)
+		if err != nil {
+			return "", err
+		}
+		if latest.Status != cache.RecordStatusArchived {
+			continue
+		}
+
+		created := latest.Timestamp
+		for _, v := range versions {
+			if v.Timestamp < created {
+				created = v.Timestamp
+			}
+		}
+
+		r.Abandoned++
+		r.Comments += uint64(len(c.comments[token]))
+		age += latest.Timestamp - created
+	}
+	if r.Abandoned > 0 {
+		r.AverageAge = age / int64(r.Abandoned)
+	}
+
+	reply, err := fonero.EncodeArchiveStatsReply(r)
+	if err != nil {
+		return "", err
+	}
+
+	return string(reply), nil
+}
+
+func (c *testcache) recordsByStatus(payload string) (string, error) {
+	rs, err := fonero.DecodeRecordsByStatus([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	status := cache.RecordStatusT(rs.Status)
+	switch status {
+	case cache.RecordStatusNotReviewed, cache.RecordStatusCensored,
+		cache.RecordStatusPublic, cache.RecordStatusUnreviewedChanges,
+		cache.RecordStatusArchived:
+	default:
+		return "", fmt.Errorf("invalid record status %v", status)
+	}
+
+	c.RLock()
+	defer c.RUnlock()
+
+	// Find the latest version of all records with the
+	// requested status.
+	records := make([]cache.Record, 0, len(c.records))
+	for token := range c.records {
+		r, err := c.record(token)
+		if err != nil {
+			return "", err
+		}
+		if r.Status != status {
+			continue
+		}
+		records = append(records, *r)
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].Timestamp != records[j].Timestamp {
+			return records[i].Timestamp < records[j].Timestamp
+		}
+		return records[i].CensorshipRecord.Token <
+			records[j].CensorshipRecord.Token
+	})
+
+	start := int(rs.Offset)
+	if start > len(records) {
+		start = len(records)
+	}
+	end := len(records)
+	if rs.Limit > 0 && start+int(rs.Limit) < end {
+		end = start + int(rs.Limit)
+	}
+
+	tokens := make([]string, 0, end-start)
+	for _, r := range records[start:end] {
+		tokens = append(tokens, r.CensorshipRecord.Token)
+	}
+
+	rsr, err := fonero.EncodeRecordsByStatusReply(
+		fonero.RecordsByStatusReply{
+			Tokens: tokens,
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(rsr), nil
+}
+
+func (c *testcache) recordHistory(payload string) (string, error) {
+	rh, err := fonero.DecodeRecordHistory([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	c.RLock()
+	defer c.RUnlock()
+
+	records, ok := c.records[rh.Token]
+	if !ok {
+		return "", cache.ErrRecordNotFound
+	}
+
+	versions := make([]fonero.RecordHistoryVersion, 0, len(records))
+	for _, r := range records {
+		v, err := strconv.ParseUint(r.Version, 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("parse version '%v' failed: %v",
+				r.Version, err)
+		}
+		versions = append(versions, fonero.RecordHistoryVersion{
+			Version:   v,
+			Status:    int(r.Status),
+			Timestamp: r.Timestamp,
+		})
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].Version < versions[j].Version
+	})
+
+	reply, err := fonero.EncodeRecordHistoryReply(
+		fonero.RecordHistoryReply{
+			Versions: versions,
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(reply), nil
+}
+
+func (c *testcache) getProposalMetadata(payload string) (string, error) {
+	g, err := fonero.DecodeGetProposalMetadata([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	c.RLock()
+	defer c.RUnlock()
+
+	var r *cache.Record
+	if g.Version != "" {
+		r, err = c.recordVersion(g.Token, g.Version)
+	} else {
+		r, err = c.record(g.Token)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	metadata := make([]fonero.MetadataStream, 0, len(r.Metadata))
+	for _, ms := range r.Metadata {
+		metadata = append(metadata, fonero.MetadataStream{
+			ID:      ms.ID,
+			Payload: ms.Payload,
+		})
+	}
+
+	reply, err := fonero.EncodeGetProposalMetadataReply(
+		fonero.GetProposalMetadataReply{
+			Version:  r.Version,
+			Metadata: metadata,
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(reply), nil
+}
+
+func (c *testcache) recordFilesManifest(payload string) (string, error) {
+	rfm, err := fonero.DecodeRecordFilesManifest([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	c.RLock()
+	defer c.RUnlock()
+
+	var r *cache.Record
+	if rfm.Version != "" {
+		r, err = c.recordVersion(rfm.Token, rfm.Version)
+	} else {
+		r, err = c.record(rfm.Token)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	files := make([]fonero.FileManifest, 0, len(r.Files))
+	for _, f := range r.Files {
+		b, err := base64.StdEncoding.DecodeString(f.Payload)
+		if err != nil {
+			return "", err
+		}
+		files = append(files, fonero.FileManifest{
+			Name:   f.Name,
+			MIME:   f.MIME,
+			Digest: f.Digest,
+			Size:   int64(len(b)),
+		})
+	}
+
+	reply, err := fonero.EncodeRecordFilesManifestReply(
+		fonero.RecordFilesManifestReply{
+			Version: r.Version,
+			Files:   files,
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(reply), nil
+}
+
+func (c *testcache) getLatestRecordVersion(payload string) (string, error) {
+	g, err := fonero.DecodeGetLatestRecordVersion([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	c.RLock()
+	defer c.RUnlock()
+
+	r, err := c.record(g.Token)
+	if err != nil {
+		return "", err
+	}
+
+	reply, err := fonero.EncodeGetLatestRecordVersionReply(
+		fonero.GetLatestRecordVersionReply{
+			Version: r.Version,
+			Status:  int(r.Status),
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(reply), nil
+}
+
+func (c *testcache) getRecordTimestampRange(payload string) (string, error) {
+	g, err := fonero.DecodeGetRecordTimestampRange([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	if g.Start > g.End {
+		return "", fmt.Errorf("invalid timestamp range: start %v > end %v",
+			g.Start, g.End)
+	}
+
+	c.RLock()
+	defer c.RUnlock()
+
+	// Find the latest version of all public records
+	// whose timestamp falls within the range.
+	records := make([]cache.Record, 0, len(c.records))
+	for token := range c.records {
+		r, err := c.record(token)
+		if err != nil {
+			return "", err
+		}
+		if r.Status != cache.RecordStatusPublic ||
+			r.Timestamp < g.Start || r.Timestamp > g.End {
+			continue
+		}
+		records = append(records, *r)
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Timestamp < records[j].Timestamp
+	})
+
+	tokens := make([]string, 0, len(records))
+	for _, r := range records {
+		tokens = append(tokens, r.CensorshipRecord.Token)
+	}
+
+	grb, err := fonero.EncodeGetRecordTimestampRangeReply(
+		fonero.GetRecordTimestampRangeReply{
+			Tokens: tokens,
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(grb), nil
+}
+
+// tally returns the vote option results of a proposal using the cast votes
+// that are currently in the cache.
+//
+// This function must be called with the lock held.
+func (c *testcache) tally(token string) []fonero.VoteOptionResult {
+	sv := c.startVotes[token]
+	tally := make(map[string]uint64, len(sv.Vote.Options))
+	for _, v := range c.castVotes[token] {
+		tally[v.VoteBit]++
+	}
+	results := make([]fonero.VoteOptionResult, 0, len(sv.Vote.Options))
+	for _, v := range sv.Vote.Options {
+		results = append(results, fonero.VoteOptionResult{
+			ID:          v.Id,
+			Description: v.Description,
+			Bits:        v.Bits,
+			Votes:       tally[strconv.FormatUint(v.Bits, 16)],
+		})
+	}
+	return results
+}
+
+// setVoteResults stores the passed in vote results of a proposal along with
+// the vote results digest.
+//
+// This function must be called with the lock held.
+func (c *testcache) setVoteResults(token string, results []fonero.VoteOptionResult) {
+	c.voteResults[token] = results
+	c.voteDigests[token] = fonero.ComputeVoteResultsDigest(
+		c.castVotes[token], results)
+}
+
+func (c *testcache) voteResultsByStatus(payload string) (string, error) {
+	vrbs, err := fonero.DecodeVoteResultsByStatus([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	c.RLock()
+	defer c.RUnlock()
+
+	tokens := make([]string, 0, len(c.voteResults))
+	for token, results := range c.voteResults {
+		if approved(c.startVotes[token], results) == vrbs.Approved {
+			tokens = append(tokens, token)
+		}
+	}
+	sort.Strings(tokens)
+
+	vrs := make([]fonero.TokenVoteResults, 0, len(tokens))
+	for _, v := range tokens {
+		vrs = append(vrs, fonero.TokenVoteResults{
+			Token:    v,
+			Approved: vrbs.Approved,
+			Results:  c.voteResults[v],
+		})
+	}
+
+	reply, err := fonero.EncodeVoteResultsByStatusReply(
+		fonero.VoteResultsByStatusReply{
+			VoteResults: vrs,
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(reply), nil
+}
+
+func (c *testcache) voteResultsDigest(payload string) (string, error) {
+	vrd, err := fonero.DecodeVoteResultsDigest([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	c.RLock()
+	defer c.RUnlock()
+
+	digest, ok := c.voteDigests[vrd.Token]
+	if !ok {
+		return "", cache.ErrRecordNotFound
+	}
+
+	reply, err := fonero.EncodeVoteResultsDigestReply(
+		fonero.VoteResultsDigestReply{
+			Digest: digest,
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(reply), nil
+}
+
+func (c *testcache) loadVoteResults(payload string) (string, error) {
+	lvr, err := fonero.DecodeLoadVoteResults([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	// Find proposals that have a finished voting period
+	// but have not yet had their vote results loaded.
+	tokens := make([]string, 0, len(c.startVoteReplies))
+	for token, svr := range c.startVoteReplies {
+		endHeight, err := strconv.ParseUint(svr.EndHeight, 10, 64)
+		if err != nil {
+			return "", err
+		}
+		if _, ok := c.voteResults[token]; ok || endHeight > lvr.BestBlock {
+			continue
+		}
+		tokens = append(tokens, token)
+	}
+	sort.Strings(tokens)
+
+	if !lvr.DryRun {
+		// Only load the requested tokens if a subset was specified
+		if len(lvr.Tokens) > 0 {
+			requested := make(map[string]bool, len(lvr.Tokens))
+			for _, v := range lvr.Tokens {
+				requested[v] = true
+			}
+			loaded := make([]string, 0, len(tokens))
+			for _, v := range tokens {
+				if requested[v] {
+					loaded = append(loaded, v)
+				}
+			}
+			tokens = loaded
+		}
+
+		for _, v := range tokens {
+			c.setVoteResults(v, c.tally(v))
+		}
+	}
+
+	lvrb, err := fonero.EncodeLoadVoteResultsReply(
+		fonero.LoadVoteResultsReply{
+			Tokens: tokens,
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(lvrb), nil
+}
+
+func (c *testcache) expireActiveVotes(payload string) (string, error) {
+	eav, err := fonero.DecodeExpireActiveVotes([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	// Find proposals that finished voting since the from height
+	tokens := make([]string, 0, len(c.startVoteReplies))
+	for token, svr := range c.startVoteReplies {
+		endHeight, err := strconv.ParseUint(svr.EndHeight, 10, 64)
+		if err != nil {
+			return "", err
+		}
+		if endHeight <= eav.FromHeight || endHeight > eav.BestBlock {
+			continue
+		}
+		tokens = append(tokens, token)
+	}
+	sort.Strings(tokens)
+
+	if !eav.DryRun {
+		for _, v := range tokens {
+			if _, ok := c.voteResults[v]; ok {
+				continue
+			}
+			c.setVoteResults(v, c.tally(v))
+		}
+	}
+
+	eavb, err := fonero.EncodeExpireActiveVotesReply(
+		fonero.ExpireActiveVotesReply{
+			BestBlock: eav.BestBlock,
+			Tokens:    tokens,
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(eavb), nil
+}
+
+func (c *testcache) recomputeVoteResults(payload string) (string, error) {
+	rvr, err := fonero.DecodeRecomputeVoteResults([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	svr, ok := c.startVoteReplies[rvr.Token]
+	if !ok {
+		return "", cache.ErrRecordNotFound
+	}
+	endHeight, err := strconv.ParseUint(svr.EndHeight, 10, 64)
+	if err != nil {
+		return "", err
+	}
+	if endHeight > rvr.BestBlock {
+		return "", fmt.Errorf("vote has not finished: token %v end height "+
+			"%v best block %v", rvr.Token, endHeight, rvr.BestBlock)
+	}
+
+	results := c.tally(rvr.Token)
+	c.setVoteResults(rvr.Token, results)
+
+	rvrb, err := fonero.EncodeRecomputeVoteResultsReply(
+		fonero.RecomputeVoteResultsReply{
+			Approved: approved(c.startVotes[rvr.Token], results),
+			Results:  results,
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(rvrb), nil
+}
+
+func (c *testcache) activityWindow(payload string) (string, error) {
+	aw, err := fonero.DecodeActivityWindow([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+	if aw.Start > aw.End {
+		return "", fmt.Errorf("invalid timestamp range: start %v > end %v",
+			aw.Start, aw.End)
+	}
+
+	c.RLock()
+	defer c.RUnlock()
+
+	inWindow := func(ts int64) bool {
+		return ts >= aw.Start && ts <= aw.End
+	}
+	activity := make(map[string]*fonero.ProposalActivity)
+	lookup := func(token string) *fonero.ProposalActivity {
+		pa, ok := activity[token]
+		if !ok {
+			pa = &fonero.ProposalActivity{Token: token}
+			activity[token] = pa
+		}
+		return pa
+	}
+	for token, comments := range c.comments {
+		for _, v := range comments {
+			if inWindow(v.Timestamp) {
+				lookup(token).Comments++
+			}
+		}
+	}
+	for token, times := range c.castVoteTimes {
+		for _, ts := range times {
+			if inWindow(ts) {
+				lookup(token).CastVotes++
+			}
+		}
+	}
+
+	pa := make([]fonero.ProposalActivity, 0, len(activity))
+	for _, v := range activity {
+		pa = append(pa, *v)
+	}
+	sort.Slice(pa, func(i, j int) bool {
+		return pa[i].Token < pa[j].Token
+	})
+
+	awrb, err := fonero.EncodeActivityWindowReply(
+		fonero.ActivityWindowReply{
+			Activity: pa,
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(awrb), nil
+}
+
+func (c *testcache) commentRate(payload string) (string, error) {
+	cr, err := fonero.DecodeCommentRate([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+	if cr.Window <= 0 {
+		return "", fmt.Errorf("invalid window: %v", cr.Window)
+	}
+
+	c.RLock()
+	defer c.RUnlock()
+
+	crr := fonero.CommentRateReply{
+		Start: time.Now().Unix() - cr.Window,
+	}
+	for _, v := range c.comments[cr.Token] {
+		if v.Timestamp >= crr.Start {
+			crr.Comments++
+		}
+		crr.Total++
+	}
+
+	crrb, err := fonero.EncodeCommentRateReply(crr)
+	if err != nil {
+		return "", err
+	}
+
+	return string(crrb), nil
+}
+
+func (c *testcache) eligibleTickets(payload string) (string, error) {
+	et, err := fonero.DecodeEligibleTickets([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	c.RLock()
+	defer c.RUnlock()
+
+	tickets := c.startVoteReplies[et.Token].EligibleTickets
+	start := int(et.Offset)
+	if start > len(tickets) {
+		start = len(tickets)
+	}
+	end := len(tickets)
+	if et.Limit > 0 && start+int(et.Limit) < end {
+		end = start + int(et.Limit)
+	}
+
+	page := make([]string, end-start)
+	copy(page, tickets[start:end])
+	etrb, err := fonero.EncodeEligibleTicketsReply(
+		fonero.EligibleTicketsReply{
+			Tickets: page,
+			Total:   uint32(len(tickets)),
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(etrb), nil
+}
+
+func (c *testcache) ballot(cmdPayload, replyPayload string) (string, error) {
+	b, err := fonero.DecodeBallot([]byte(cmdPayload))
+	if err != nil {
+		return "", err
+	}
+
+	// The receipts are only recorded when a ballot reply is provided
+	br := &fonero.BallotReply{}
+	if replyPayload != "" {
+		br, err = fonero.DecodeBallotReply([]byte(replyPayload))
+		if err != nil {
+			return "", err
+		}
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	ts := time.Now().Unix()
+	for i, v := range b.Votes {
+		c.castVotes[v.Token] = append(c.castVotes[v.Token], v)
+		if _, ok := c.castVoteTimes[v.Token]; !ok {
+			c.castVoteTimes[v.Token] = make(map[string]int64)
+		}
+		c.castVoteTimes[v.Token][v.Ticket] = ts
+
+		if i < len(br.Receipts) {
+			if _, ok := c.castVoteReceipts[v.Token]; !ok {
+				c.castVoteReceipts[v.Token] = make(map[string]string)
+			}
+			c.castVoteReceipts[v.Token][v.Ticket] = br.Receipts[i].Signature
+		}
+	}
+
+	return replyPayload, nil
+}
+
+func (c *testcache) countVotesByOption(payload string) (string, error) {
+	cvo, err := fonero.DecodeCountVotesByOption([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	c.RLock()
+	defer c.RUnlock()
+
+	sv, ok := c.startVotes[cvo.Token]
+	if !ok {
+		return "", cache.ErrRecordNotFound
+	}
+
+	counts := make(map[string]uint64, len(sv.Vote.Options))
+	for _, v := range c.tally(cvo.Token) {
+		counts[strconv.FormatUint(v.Bits, 16)] = v.Votes
+	}
+
+	reply, err := fonero.EncodeCountVotesByOptionReply(
+		fonero.CountVotesByOptionReply{
+			Options: sv.Vote.Options,
+			Counts:  counts,
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(reply), nil
+}
+
+func (c *testcache) getVoteStatus(payload string) (string, error) {
+	gvs, err := fonero.DecodeGetVoteStatus([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	c.RLock()
+	defer c.RUnlock()
+
+	r, err := c.record(gvs.Token)
+	if err != nil {
+		return "", err
+	}
+
+	var status fonero.VoteStatusT
+	av, authorized := c.authorizeVotes[gvs.Token][r.Version]
+	svr, started := c.startVoteReplies[gvs.Token]
+	switch {
+	case !started && authorized &&
+		av.Action == fonero.AuthVoteActionAuthorize:
+		status = fonero.VoteStatusAuthorized
+	case !started:
+		status = fonero.VoteStatusNotAuthorized
+	default:
+		endHeight, err := strconv.ParseUint(svr.EndHeight, 10, 64)
+		if err != nil {
+			return "", err
+		}
+		results, loaded := c.voteResults[gvs.Token]
+		switch {
+		case endHeight > gvs.BestBlock:
+			status = fonero.VoteStatusStarted
+		case !loaded:
+			status = fonero.VoteStatusFinished
+		case approved(c.startVotes[gvs.Token], results):
+			status = fonero.VoteStatusApproved
+		default:
+			status = fonero.VoteStatusRejected
+		}
+	}
+
+	reply, err := fonero.EncodeGetVoteStatusReply(
+		fonero.GetVoteStatusReply{
+			Status: status,
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(reply), nil
+}
+
+func (c *testcache) proposalSupportersCount(payload string) (string, error) {
+	psc, err := fonero.DecodeProposalSupportersCount([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	c.RLock()
+	defer c.RUnlock()
+
+	tickets := make(map[string]struct{}, len(c.castVotes[psc.Token]))
+	for _, v := range c.castVotes[psc.Token] {
+		tickets[v.Ticket] = struct{}{}
+	}
+
+	reply, err := fonero.EncodeProposalSupportersCountReply(
+		fonero.ProposalSupportersCountReply{
+			Supporters: uint64(len(tickets)),
+			Votes:      uint64(len(c.castVotes[psc.Token])),
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(reply), nil
+}
+
+func (c *testcache) votesByBlockWindow(payload string) (string, error) {
+	vbw, err := fonero.DecodeVotesByBlockWindow([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+	if vbw.StartHeight > vbw.EndHeight {
+		return "", fmt.Errorf("invalid block window: start height %v is "+
+			"greater than end height %v", vbw.StartHeight, vbw.EndHeight)
+	}
+
+	c.RLock()
+	defer c.RUnlock()
+
+	type vote struct {
+		token       string
+		startHeight uint64
+	}
+	votes := make([]vote, 0, len(c.startVoteReplies))
+	for token, svr := range c.startVoteReplies {
+		start, err := strconv.ParseUint(svr.StartBlockHeight, 10, 64)
+		if err != nil {
+			return "", err
+		}
+		end, err := strconv.ParseUint(svr.EndHeight, 10, 64)
+		if err != nil {
+			return "", err
+		}
+		if start > vbw.EndHeight || end < vbw.StartHeight {
+			continue
+		}
+		votes = append(votes, vote{token, start})
+	}
+
+	sort.Slice(votes, func(i, j int) bool {
+		if votes[i].startHeight != votes[j].startHeight {
+			return votes[i].startHeight < votes[j].startHeight
+		}
+		return votes[i].token < votes[j].token
+	})
+
+	tokens := make([]string, 0, len(votes))
+	for _, v := range votes {
+		tokens = append(tokens, v.token)
+	}
+
+	reply, err := fonero.EncodeVotesByBlockWindowReply(
+		fonero.VotesByBlockWindowReply{
+			Tokens: tokens,
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(reply), nil
+}
+
+func (c *testcache) proposalVotes(payload string) (string, error) {
+	vr, err := fonero.DecodeVoteResults([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	c.RLock()
+	defer c.RUnlock()
+
+	sv, started := c.startVotes[vr.Token]
+	reply := fonero.VoteResultsReply{
+		Started:   started,
+		StartVote: sv,
+	}
+	if vr.TallyOnly {
+		reply.Tally = c.tally(vr.Token)
+	} else {
+		// Page through the cast votes ordered by ticket
+		cv := make([]fonero.CastVote, 0, len(c.castVotes[vr.Token]))
+		for _, v := range c.castVotes[vr.Token] {
+			if vr.AfterTicket != "" && v.Ticket <= vr.AfterTicket {
+				continue
+			}
+			cv = append(cv, v)
+		}
+		sort.Slice(cv, func(i, j int) bool {
+			return cv[i].Ticket < cv[j].Ticket
+		})
+		start := int(vr.Offset)
+		if start > len(cv) {
+			start = len(cv)
+		}
+		end := len(cv)
+		if vr.Limit > 0 && start+int(vr.Limit) < end {
+			end = start + int(vr.Limit)
+		}
+		reply.CastVotes = cv[start:end]
+	}
+
+	vrrb, err := fonero.EncodeVoteResultsReply(reply)
+	if err != nil {
+		return "", err
+	}
+
+	return string(vrrb), nil
+}
+
+func (c *testcache) proposalVoteReceipts(payload string) (string, error) {
+	pvr, err := fonero.DecodeProposalVoteReceipts([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	c.RLock()
+	defer c.RUnlock()
+
+	receipts := make([]fonero.VoteReceipt, 0, len(c.castVotes[pvr.Token]))
+	for _, v := range c.castVotes[pvr.Token] {
+		if pvr.Ticket != "" && v.Ticket != pvr.Ticket {
+			continue
+		}
+		receipts = append(receipts, fonero.VoteReceipt{
+			Ticket:    v.Ticket,
+			VoteBit:   v.VoteBit,
+			Signature: v.Signature,
+			Receipt:   c.castVoteReceipts[pvr.Token][v.Ticket],
+		})
+	}
+	sort.Slice(receipts, func(i, j int) bool {
+		return receipts[i].Ticket < receipts[j].Ticket
+	})
+
+	reply, err := fonero.EncodeProposalVoteReceiptsReply(
+		fonero.ProposalVoteReceiptsReply{
+			Receipts: receipts,
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(reply), nil
+}
+
+// approved returns whether the yes option of the passed in vote option
+// results received at least the pass percentage of the votes.
+func approved(sv fonero.StartVote, results []fonero.VoteOptionResult) bool {
+	var total, yes uint64
+	for _, v := range results {
+		total += v.Votes
+		if v.ID == "yes" {
+			yes = v.Votes
+		}
+	}
+	return total > 0 && yes*100 >= total*uint64(sv.Vote.PassPercentage)
+}
+
+func (c *testcache) voteExport(payload string) (string, error) {
+	ve, err := fonero.DecodeVoteExport([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	vd, err := fonero.EncodeVoteDetails(
+		fonero.VoteDetails{
+			Token: ve.Token,
+		})
+	if err != nil {
+		return "", err
+	}
+	reply, err := c.voteDetails(string(vd))
+	if err != nil {
+		return "", err
+	}
+	vdr, err := fonero.DecodeVoteDetailsReply([]byte(reply))
+	if err != nil {
+		return "", err
+	}
+
+	c.RLock()
+	defer c.RUnlock()
+
+	// Use the loaded vote results once the vote is final and
+	// tally the cast votes otherwise.
+	cv := c.castVotes[ve.Token]
+	results, final := c.voteResults[ve.Token]
+	if !final {
+		results = c.tally(ve.Token)
+	}
+	verb, err := fonero.EncodeVoteExportReply(
+		fonero.VoteExportReply{
+			AuthorizeVote:  vdr.AuthorizeVote,
+			StartVote:      vdr.StartVote,
+			StartVoteReply: vdr.StartVoteReply,
+			CastVotes:      cv,
+			Results:        results,
+			Final:          final,
+			Approved:       final && approved(vdr.StartVote, results),
+		})
+	if err != nil {
+		return "", err
+	}
+
+	return string(verb), nil
+}
+
+func (c *testcache) bestBlock(replyPayload string) (string, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	if replyPayload != "" {
+		bb, err := strconv.ParseUint(replyPayload, 10, 64)
+		if err != nil {
+			return "", err
+		}
+		if bb > c.lastBestBlock {
+			c.lastBestBlock = bb
+		}
+	}
+	if c.lastBestBlock == 0 {
+		return "", fmt.Errorf("best block required")
+	}
+
+	bbrb, err := fonero.EncodeBestBlockReply(fonero.BestBlockReply{
+		Height: c.lastBestBlock,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return string(bbrb), nil
+}
+
+func (c *testcache) verifyCounts(payload string) (string, error) {
+	vc, err := fonero.DecodeVerifyCounts([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+
+	c.RLock()
+	defer c.RUnlock()
+
+	var comments, castVotes uint64
+	for _, v := range c.comments {
+		comments += uint64(len(v))
+	}
+	for _, v := range c.castVotes {
+		castVotes += uint64(len(v))
+	}
+
+	delta := func(table string, cacheCount, sourceCount uint64) fonero.CountDelta {
+		d := int64(cacheCount) - int64(sourceCount)
+		return fonero.CountDelta{
+			Table:    table,
+			Cache:    cacheCount,
+			Source:   sourceCount,
+			Delta:    d,
+			Diverged: d > int64(vc.Threshold) || -d > int64(vc.Threshold),
+		}
+	}
+	vcr := fonero.VerifyCountsReply{
+		Deltas: []fonero.CountDelta{
+			delta(fonero.VerifyCountsRecords, uint64(len(c.records)),
+				vc.Records),
+			delta(fonero.VerifyCountsComments, comments, vc.Comments),
+			delta(fonero.VerifyCountsCastVotes, castVotes, vc.CastVotes),
+		},
+	}
+	for _, v := range vcr.Deltas {
+		if v.Diverged {
+			vcr.Diverged = true
+		}
+	}
+
+	vcrb, err := fonero.EncodeVerifyCountsReply(vcr)
+	if err != nil {
+		return "", err
+	}
+
+	return string(vcrb), nil
 }
 
 func (c *testcache) foneroExec(cmd, cmdPayload, replyPayload string) (string, error) {
 	switch cmd {
 	case fonero.CmdGetComments:
 		return c.getComments(cmdPayload)
+	case fonero.CmdGetCommentsForProposals:
+		return c.getCommentsForProposals(cmdPayload)
+	case fonero.CmdInventoryDigest:
+		return c.inventoryDigest()
+	case fonero.CmdCensoredComments:
+		return c.censoredComments(cmdPayload)
+	case fonero.CmdCommentedProposals:
+		return c.commentedProposals(cmdPayload)
+	case fonero.CmdTopComments:
+		return c.topComments(cmdPayload)
+	case fonero.CmdGetCommentByReceipt:
+		return c.getCommentByReceipt(cmdPayload)
+	case fonero.CmdGetCommentAncestors:
+		return c.getCommentAncestors(cmdPayload)
+	case fonero.CmdGetCommentVersions:
+		return c.getCommentVersions(cmdPayload)
+	case fonero.CmdCommentThreadStats:
+		return c.commentThreadStats(cmdPayload)
+	case fonero.CmdNewComment:
+		return c.newComment(cmdPayload, replyPayload)
+	case fonero.CmdCensorComment:
+		return c.censorComment(cmdPayload, replyPayload)
+	case fonero.CmdSetCommentVisibility:
+		return c.setCommentVisibility(cmdPayload, replyPayload)
+	case fonero.CmdCommentsModifiedSince:
+		return c.commentsModifiedSince(cmdPayload)
+	case fonero.CmdGetCommentsSince:
+		return c.getCommentsSince(cmdPayload)
+	case fonero.CmdLikeComment:
+		return c.likeComment(cmdPayload, replyPayload)
+	case fonero.CmdLikeCommentUndo:
+		return c.likeCommentUndo(cmdPayload, replyPayload)
+	case fonero.CmdCommentLikes:
+		return c.getCommentLikes(cmdPayload)
+	case fonero.CmdProposalCommentsLikeCounts:
+		return c.proposalCommentsLikeCounts(cmdPayload)
+	case fonero.CmdCommentLikesNetScore:
+		return c.commentLikesNetScore(cmdPayload)
 	case fonero.CmdAuthorizeVote:
 		return c.authorizeVote(cmdPayload, replyPayload)
 	case fonero.CmdStartVote:
 		return c.startVote(cmdPayload, replyPayload)
 	case fonero.CmdVoteDetails:
 		return c.voteDetails(cmdPayload)
+	case fonero.CmdGetStartVoteReply:
+		return c.getStartVoteReply(cmdPayload)
+	case fonero.CmdGetVoteOptions:
+		return c.getVoteOptions(cmdPayload)
+	case fonero.CmdGetEligibleTicketCount:
+		return c.getEligibleTicketCount(cmdPayload)
+	case fonero.CmdProposalActivityTimeline:
+		return c.proposalActivityTimeline(cmdPayload)
+	case fonero.CmdVoteResultsDigest:
+		return c.voteResultsDigest(cmdPayload)
+	case fonero.CmdVoteResultsByStatus:
+		return c.voteResultsByStatus(cmdPayload)
+	case fonero.CmdRecordHistory:
+		return c.recordHistory(cmdPayload)
+	case fonero.CmdGetProposalMetadata:
+		return c.getProposalMetadata(cmdPayload)
+	case fonero.CmdRecordFilesManifest:
+		return c.recordFilesManifest(cmdPayload)
+	case fonero.CmdGetLatestRecordVersion:
+		return c.getLatestRecordVersion(cmdPayload)
+	case fonero.CmdRecordsByStatus:
+		return c.recordsByStatus(cmdPayload)
+	case fonero.CmdRecordStatusCounts:
+		return c.recordStatusCounts(cmdPayload)
+	case fonero.CmdArchiveStats:
+		return c.archiveStats(cmdPayload)
+	case fonero.CmdGetRecordTimestampRange:
+		return c.getRecordTimestampRange(cmdPayload)
+	case fonero.CmdActivityWindow:
+		return c.activityWindow(cmdPayload)
+	case fonero.CmdCommentRate:
+		return c.commentRate(cmdPayload)
+	case fonero.CmdVerifyCounts:
+		return c.verifyCounts(cmdPayload)
+	case fonero.CmdEligibleTickets:
+		return c.eligibleTickets(cmdPayload)
+	case fonero.CmdLoadVoteResults:
+		return c.loadVoteResults(cmdPayload)
+	case fonero.CmdRecomputeVoteResults:
+		return c.recomputeVoteResults(cmdPayload)
+	case fonero.CmdExpireActiveVotes:
+		return c.expireActiveVotes(cmdPayload)
+	case fonero.CmdBallot:
+		return c.ballot(cmdPayload, replyPayload)
+	case fonero.CmdBestBlock:
+		return c.bestBlock(replyPayload)
+	case fonero.CmdVoteExport:
+		return c.voteExport(cmdPayload)
+	case fonero.CmdProposalVotes:
+		return c.proposalVotes(cmdPayload)
+	case fonero.CmdProposalVoteReceipts:
+		return c.proposalVoteReceipts(cmdPayload)
+	case fonero.CmdCountVotesByOption:
+		return c.countVotesByOption(cmdPayload)
+	case fonero.CmdVotesByBlockWindow:
+		return c.votesByBlockWindow(cmdPayload)
+	case fonero.CmdProposalSupportersCount:
+		return c.proposalSupportersCount(cmdPayload)
+	case fonero.CmdGetVoteStatus:
+		return c.getVoteStatus(cmdPayload)
 	}
 
 	return "", cache.ErrInvalidPluginCmd
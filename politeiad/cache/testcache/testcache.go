@@ -5,6 +5,7 @@
 package testcache
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"sync"
@@ -20,10 +21,20 @@ type testcache struct {
 	records map[string]map[string]cache.Record // [token][version]Record
 
 	// Fonero plugin
-	comments         map[string][]fonero.Comment                // [token][]Comment
-	authorizeVotes   map[string]map[string]fonero.AuthorizeVote // [token][version]AuthorizeVote
-	startVotes       map[string]fonero.StartVote                // [token]StartVote
-	startVoteReplies map[string]fonero.StartVoteReply           // [token]StartVoteReply
+	comments         map[string][]fonero.Comment                   // [token][]Comment
+	censoredAt       map[string]map[string]int64                   // [token][commentID]Timestamp
+	commentVersions  map[string]map[string][]fonero.CommentVersion // [token][commentID][]CommentVersion
+	commentLikes     map[string][]fonero.LikeComment               // [token][]LikeComment
+	authorizeVotes   map[string]map[string]fonero.AuthorizeVote    // [token][version]AuthorizeVote
+	startVotes       map[string]fonero.StartVote                   // [token]StartVote
+	startVoteReplies map[string]fonero.StartVoteReply              // [token]StartVoteReply
+	startVoteTimes   map[string]int64                              // [token]Timestamp
+	castVotes        map[string][]fonero.CastVote                  // [token][]CastVote
+	castVoteTimes    map[string]map[string]int64                   // [token][ticket]Timestamp
+	castVoteReceipts map[string]map[string]string                  // [token][ticket]Receipt
+	voteResults      map[string][]fonero.VoteOptionResult          // [token]Loaded vote results
+	voteDigests      map[string]string                             // [token]Vote results digest
+	lastBestBlock    uint64                                        // Last best block
 }
 
 // NewRecords adds a record to the cache.
@@ -106,6 +117,36 @@ func (c *testcache) RecordVersion(token, version string) (*cache.Record, error)
 	return c.recordVersion(token, version)
 }
 
+// Records returns a batch of records.  The most recent version of each record
+// is returned unless a specific version is requested for its token.  Unknown
+// tokens are not included in the reply.
+func (c *testcache) Records(tokens []string, versions map[string]string) (map[string]cache.Record, error) {
+	c.RLock()
+	defer c.RUnlock()
+
+	records := make(map[string]cache.Record, len(tokens)) // [token]Record
+	for _, token := range tokens {
+		var (
+			r   *cache.Record
+			err error
+		)
+		version, ok := versions[token]
+		if ok && version != "" {
+			r, err = c.recordVersion(token, version)
+		} else {
+			r, err = c.record(token)
+		}
+		if err == cache.ErrRecordNotFound {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		records[token] = *r
+	}
+
+	return records, nil
+}
+
 // UpdateRecord is a stub to satisfy the cache interface.
 func (c *testcache) UpdateRecord(r cache.Record) error {
 	return nil
@@ -152,7 +193,7 @@ func (c *testcache) Setup() error {
 }
 
 // Build is a stub to satisfy the cache interface.
-func (c *testcache) Build(records []cache.Record) error {
+func (c *testcache) Build(ctx context.Context, records []cache.Record) error {
 	return nil
 }
 
@@ -166,7 +207,7 @@ func (c *testcache) PluginSetup(id string) error {
 }
 
 // PluginBuild is a stub to satisfy the cache interface.
-func (c *testcache) PluginBuild(id, payload string) error {
+func (c *testcache) PluginBuild(ctx context.Context, id, payload string) error {
 	return nil
 }
 
@@ -197,8 +238,17 @@ func New() *testcache {
 	return &testcache{
 		records:          make(map[string]map[string]cache.Record),
 		comments:         make(map[string][]fonero.Comment),
+		censoredAt:       make(map[string]map[string]int64),
+		commentVersions:  make(map[string]map[string][]fonero.CommentVersion),
+		commentLikes:     make(map[string][]fonero.LikeComment),
 		authorizeVotes:   make(map[string]map[string]fonero.AuthorizeVote),
 		startVotes:       make(map[string]fonero.StartVote),
 		startVoteReplies: make(map[string]fonero.StartVoteReply),
+		startVoteTimes:   make(map[string]int64),
+		castVotes:        make(map[string][]fonero.CastVote),
+		castVoteTimes:    make(map[string]map[string]int64),
+		castVoteReceipts: make(map[string]map[string]string),
+		voteResults:      make(map[string][]fonero.VoteOptionResult),
+		voteDigests:      make(map[string]string),
 	}
 }
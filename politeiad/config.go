@@ -20,6 +20,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/fonero-project/fnotime/api/v1"
 	"github.com/fonero-project/politeia/politeiad/sharedconfig"
@@ -58,33 +59,37 @@ var runServiceCommand func(string) error
 //
 // See loadConfig for details on the configuration load process.
 type config struct {
-	HomeDir       string   `short:"A" long:"appdata" description:"Path to application home directory"`
-	ShowVersion   bool     `short:"V" long:"version" description:"Display version information and exit"`
-	ConfigFile    string   `short:"C" long:"configfile" description:"Path to configuration file"`
-	DataDir       string   `short:"b" long:"datadir" description:"Directory to store data"`
-	LogDir        string   `long:"logdir" description:"Directory to log output."`
-	TestNet       bool     `long:"testnet" description:"Use the test network"`
-	SimNet        bool     `long:"simnet" description:"Use the simulation test network"`
-	Profile       string   `long:"profile" description:"Enable HTTP profiling on given port -- NOTE port must be between 1024 and 65536"`
-	CPUProfile    string   `long:"cpuprofile" description:"Write CPU profile to the specified file"`
-	MemProfile    string   `long:"memprofile" description:"Write mem profile to the specified file"`
-	DebugLevel    string   `short:"d" long:"debuglevel" description:"Logging level for all subsystems {trace, debug, info, warn, error, critical} -- You may also specify <subsystem>=<level>,<subsystem2>=<level>,... to set the log level for individual subsystems -- Use show to list available subsystems"`
-	Listeners     []string `long:"listen" description:"Add an interface/port to listen for connections (default all interfaces port: 49152, testnet: 59152)"`
-	Version       string
-	HTTPSCert     string `long:"httpscert" description:"File containing the https certificate file"`
-	HTTPSKey      string `long:"httpskey" description:"File containing the https certificate key"`
-	RPCUser       string `long:"rpcuser" description:"RPC user name for privileged commands"`
-	RPCPass       string `long:"rpcpass" description:"RPC password for privileged commands"`
-	FnotimeHost   string `long:"fnotimehost" description:"Fnotime ip:port"`
-	FnotimeCert   string `long:"fnotimecert" description:"File containing the https certificate file for fnotimehost"`
-	EnableCache   bool   `long:"enablecache" description:"Enable the external cache"`
-	CacheHost     string `long:"cachehost" description:"Cache ip:port"`
-	CacheRootCert string `long:"cacherootcert" description:"File containing the CA certificate for the cache"`
-	CacheCert     string `long:"cachecert" description:"File containing the politeiad client certificate for the cache"`
-	CacheKey      string `long:"cachekey" description:"File containing the politeiad client certificate key for the cache"`
-	BuildCache    bool   `long:"buildcache" description:"Build the cache from scratch"`
-	Identity      string `long:"identity" description:"File containing the politeiad identity file"`
-	GitTrace      bool   `long:"gittrace" description:"Enable git tracing in logs"`
+	HomeDir         string   `short:"A" long:"appdata" description:"Path to application home directory"`
+	ShowVersion     bool     `short:"V" long:"version" description:"Display version information and exit"`
+	ConfigFile      string   `short:"C" long:"configfile" description:"Path to configuration file"`
+	DataDir         string   `short:"b" long:"datadir" description:"Directory to store data"`
+	LogDir          string   `long:"logdir" description:"Directory to log output."`
+	TestNet         bool     `long:"testnet" description:"Use the test network"`
+	SimNet          bool     `long:"simnet" description:"Use the simulation test network"`
+	Profile         string   `long:"profile" description:"Enable HTTP profiling on given port -- NOTE port must be between 1024 and 65536"`
+	CPUProfile      string   `long:"cpuprofile" description:"Write CPU profile to the specified file"`
+	MemProfile      string   `long:"memprofile" description:"Write mem profile to the specified file"`
+	DebugLevel      string   `short:"d" long:"debuglevel" description:"Logging level for all subsystems {trace, debug, info, warn, error, critical} -- You may also specify <subsystem>=<level>,<subsystem2>=<level>,... to set the log level for individual subsystems -- Use show to list available subsystems"`
+	Listeners       []string `long:"listen" description:"Add an interface/port to listen for connections (default all interfaces port: 49152, testnet: 59152)"`
+	Version         string
+	HTTPSCert       string        `long:"httpscert" description:"File containing the https certificate file"`
+	HTTPSKey        string        `long:"httpskey" description:"File containing the https certificate key"`
+	RPCUser         string        `long:"rpcuser" description:"RPC user name for privileged commands"`
+	RPCPass         string        `long:"rpcpass" description:"RPC password for privileged commands"`
+	FnotimeHost     string        `long:"fnotimehost" description:"Fnotime ip:port"`
+	FnotimeCert     string        `long:"fnotimecert" description:"File containing the https certificate file for fnotimehost"`
+	EnableCache     bool          `long:"enablecache" description:"Enable the external cache"`
+	CacheHost       string        `long:"cachehost" description:"Cache ip:port"`
+	CacheRootCert   string        `long:"cacherootcert" description:"File containing the CA certificate for the cache"`
+	CacheCert       string        `long:"cachecert" description:"File containing the politeiad client certificate for the cache"`
+	CacheKey        string        `long:"cachekey" description:"File containing the politeiad client certificate key for the cache"`
+	BuildCache      bool          `long:"buildcache" description:"Build the cache from scratch"`
+	BuildVerifySigs bool          `long:"buildverifysigs" description:"Verify comment and cast vote signatures when building the cache"`
+	BuildMaxInvalid uint          `long:"buildmaxinvalid" description:"Maximum number of invalid signatures allowed when building the cache with buildverifysigs"`
+	BuildTimeout    time.Duration `long:"buildtimeout" description:"Abort the cache build if it has not completed within the timeout (default no timeout)"`
+	CacheSelfTest   bool          `long:"cacheselftest" description:"Verify that the cache plugin commands round-trip at startup"`
+	Identity        string        `long:"identity" description:"File containing the politeiad identity file"`
+	GitTrace        bool          `long:"gittrace" description:"Enable git tracing in logs"`
 }
 
 // serviceOptions defines the configuration options for the daemon as a service
@@ -504,6 +509,21 @@ func loadConfig() (*config, []string, error) {
 			"not be used without the enablecache param")
 	}
 
+	if cfg.BuildVerifySigs && !cfg.EnableCache {
+		return nil, nil, fmt.Errorf("the buildverifysigs param can " +
+			"not be used without the enablecache param")
+	}
+
+	if cfg.CacheSelfTest && !cfg.EnableCache {
+		return nil, nil, fmt.Errorf("the cacheselftest param can " +
+			"not be used without the enablecache param")
+	}
+
+	if cfg.BuildTimeout < 0 {
+		return nil, nil, fmt.Errorf("the buildtimeout param can " +
+			"not be negative")
+	}
+
 	// Initialize log rotation.  After log rotation has been initialized,
 	// the logger variables may be used.
 	initLogRotator(filepath.Join(cfg.LogDir, defaultLogFilename))
@@ -5,6 +5,7 @@
 package main
 
 import (
+	"context"
 	"crypto/elliptic"
 	"crypto/x509"
 	"encoding/hex"
@@ -1137,6 +1138,9 @@ func _main() error {
 		} else if err != nil {
 			return fmt.Errorf("cockroachdb new: %v", err)
 		}
+		db.SetBuildSignatureVerification(p.cfg.BuildVerifySigs,
+			int(p.cfg.BuildMaxInvalid))
+		db.SetBestBlockSource(b)
 		p.cache = db
 
 		// Setup the cache tables
@@ -1221,6 +1225,32 @@ func _main() error {
 
 	// Build the cache
 	if p.cfg.BuildCache {
+		// The build is aborted when the build timeout expires or
+		// when an interrupt is received.  An aborted build leaves
+		// the cache marked for a rebuild on the next start.
+		var (
+			ctx    context.Context
+			cancel context.CancelFunc
+		)
+		if p.cfg.BuildTimeout > 0 {
+			ctx, cancel = context.WithTimeout(context.Background(),
+				p.cfg.BuildTimeout)
+		} else {
+			ctx, cancel = context.WithCancel(context.Background())
+		}
+		defer cancel()
+
+		buildSigs := make(chan os.Signal, 1)
+		signal.Notify(buildSigs, syscall.SIGINT, syscall.SIGTERM)
+		go func() {
+			select {
+			case sig := <-buildSigs:
+				log.Infof("Aborting cache build with %v", sig)
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
 		// Fetch all versions of all records from the inventory and
 		// use them to build the cache.
 		vetted, unvetted, err := p.backend.Inventory(0, 0, true, true)
@@ -1237,7 +1267,7 @@ func _main() error {
 		}
 
 		// Build the cache
-		err = p.cache.Build(inv)
+		err = p.cache.Build(ctx, inv)
 		if err != nil {
 			return fmt.Errorf("build cache: %v", err)
 		}
@@ -1266,11 +1296,30 @@ func _main() error {
 			}
 
 			// Build plugin cache
-			err = p.cache.PluginBuild(v.ID, payload)
+			err = p.cache.PluginBuild(ctx, v.ID, payload)
 			if err != nil {
 				return fmt.Errorf("plugin '%v' build cache: %v", v.ID, err)
 			}
 		}
+
+		signal.Stop(buildSigs)
+		cancel()
+	}
+
+	// Verify the cache plugin command round-trips
+	if p.cfg.CacheSelfTest {
+		st, ok := p.cache.(cache.PluginSelfTester)
+		if !ok {
+			return fmt.Errorf("cache does not support self-tests")
+		}
+		for id := range p.plugins {
+			err := st.PluginSelfTest(id)
+			if err != nil {
+				return fmt.Errorf("plugin '%v' cache self-test: %v",
+					id, err)
+			}
+			log.Infof("Cache self-test passed: %v", id)
+		}
 	}
 
 	// Bind to a port and pass our router in
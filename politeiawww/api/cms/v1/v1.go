@@ -11,18 +11,19 @@ type LineItemTypeT int
 const (
 
 	// Contractor Management Routes
-	RouteInviteNewUser       = "/invite"
-	RouteRegisterUser        = "/register"
-	RouteNewInvoice          = "/invoices/new"
-	RouteEditInvoice         = "/invoices/edit"
-	RouteInvoiceDetails      = "/invoices/{token:[A-z0-9]{64}}"
-	RouteSetInvoiceStatus    = "/invoices/{token:[A-z0-9]{64}}/status"
-	RouteUserInvoices        = "/user/invoices"
-	RouteAdminInvoices       = "/admin/invoices"
-	RouteGeneratePayouts     = "/admin/generatepayouts"
-	RoutePayInvoices         = "/admin/payinvoices"
-	RouteInvoiceComments     = "/invoices/{token:[A-z0-9]{64}}/comments"
-	RouteInvoiceExchangeRate = "/invoices/exchangerate"
+	RouteInviteNewUser              = "/invite"
+	RouteRegisterUser               = "/register"
+	RouteNewInvoice                 = "/invoices/new"
+	RouteEditInvoice                = "/invoices/edit"
+	RouteInvoiceDetails             = "/invoices/{token:[A-z0-9]{64}}"
+	RouteSetInvoiceStatus           = "/invoices/{token:[A-z0-9]{64}}/status"
+	RouteUserInvoices               = "/user/invoices"
+	RouteAdminInvoices              = "/admin/invoices"
+	RouteGeneratePayouts            = "/admin/generatepayouts"
+	RoutePayInvoices                = "/admin/payinvoices"
+	RouteInvoiceComments            = "/invoices/{token:[A-z0-9]{64}}/comments"
+	RouteInvoiceExchangeRate        = "/invoices/exchangerate"
+	RouteInvoiceExchangeRateHistory = "/invoices/exchangeratehistory"
 
 	// Invoice status codes
 	InvoiceStatusInvalid  InvoiceStatusT = 0 // Invalid status
@@ -319,7 +320,31 @@ type InvoiceExchangeRate struct {
 
 // InvoiceExchangeRateReply returns the calculated monthly exchange rate
 type InvoiceExchangeRateReply struct {
-	ExchangeRate uint `json:"exchangerate"` // in USD cents
+	ExchangeRate uint   `json:"exchangerate"` // in cents of the fiat currency
+	Currency     string `json:"currency"`     // Fiat currency (e.g. USD)
+}
+
+// InvoiceExchangeRateHistory contains the request to receive the stored
+// monthly exchange rates of a range of years.  Both years are inclusive.
+type InvoiceExchangeRateHistory struct {
+	StartYear uint `json:"startyear"`
+	EndYear   uint `json:"endyear"`
+}
+
+// MonthlyExchangeRate is the stored exchange rate of a single month.  Missing
+// is set for months whose exchange rate has never been fetched.
+type MonthlyExchangeRate struct {
+	Month        uint `json:"month"`
+	Year         uint `json:"year"`
+	ExchangeRate uint `json:"exchangerate"`      // in cents of the fiat currency
+	Missing      bool `json:"missing,omitempty"` // Exchange rate not fetched
+}
+
+// InvoiceExchangeRateHistoryReply returns the monthly exchange rates of the
+// requested years ordered by month.
+type InvoiceExchangeRateHistoryReply struct {
+	Currency      string                `json:"currency"`      // Fiat currency (e.g. USD)
+	ExchangeRates []MonthlyExchangeRate `json:"exchangerates"` // Monthly exchange rates
 }
 
 // PayInvoices temporarily allows the administrator to set all approved invoices
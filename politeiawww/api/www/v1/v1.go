@@ -58,6 +58,8 @@ const (
 	RouteCommentsGet              = "/proposals/{token:[A-z0-9]{64}}/comments"
 	RouteVoteResults              = "/proposals/{token:[A-z0-9]{64}}/votes"
 	RouteVoteStatus               = "/proposals/{token:[A-z0-9]{64}}/votestatus"
+	RouteVoteExport               = "/proposals/{token:[A-z0-9]{64}}/voteexport"
+	RouteProposalVotesCSV         = "/proposals/{token:[A-z0-9]{64}}/votescsv"
 	RouteNewComment               = "/comments/new"
 	RouteLikeComment              = "/comments/like"
 	RouteCensorComment            = "/comments/censor"
@@ -955,6 +957,25 @@ type VoteResultsReply struct {
 	StartVoteReply StartVoteReply `json:"startvotereply"` // Eligible tickets and other details
 }
 
+// VoteExport retrieves the full vote lifecycle of a single proposal from the
+// server.
+type VoteExport struct{}
+
+// VoteExportReply returns the vote authorization, the original proposal vote,
+// the eligible tickets, all cast votes and the vote results.  OptionsResult
+// contains the final vote results when Final is set and the current vote
+// tally otherwise.
+type VoteExportReply struct {
+	AuthorizeVote      AuthorizeVote      `json:"authorizevote"`      // Vote authorization
+	AuthorizeVoteReply AuthorizeVoteReply `json:"authorizevotereply"` // Vote authorization receipt
+	StartVote          StartVote          `json:"startvote"`          // Original vote
+	StartVoteReply     StartVoteReply     `json:"startvotereply"`     // Eligible tickets and other details
+	CastVotes          []CastVote         `json:"castvotes"`          // All cast votes
+	OptionsResult      []VoteOptionResult `json:"optionsresult"`      // Votes received by each option
+	Final              bool               `json:"final"`              // Vote results are final
+	Approved           bool               `json:"approved"`           // Vote was approved
+}
+
 // Comment is the structure that describes the full server side content.  It
 // includes server side meta-data as well.
 type Comment struct {
@@ -972,6 +993,7 @@ type Comment struct {
 	TotalVotes  uint64 `json:"totalvotes"`  // Total number of up/down votes
 	ResultVotes int64  `json:"resultvotes"` // Vote score
 	Censored    bool   `json:"censored"`    // Has this comment been censored
+	Hidden      bool   `json:"hidden"`      // Has this comment been hidden
 
 	// Metadata generated by www
 	UserID   string `json:"userid"`   // User id
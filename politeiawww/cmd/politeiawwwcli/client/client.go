@@ -1079,6 +1079,11 @@ func (c *Client) StartVote(sv *v1.StartVote) (*v1.StartVoteReply, error) {
 	return &svr, nil
 }
 
+// ProposalVotesCSV retrieves the cast votes of the specified proposal as CSV.
+func (c *Client) ProposalVotesCSV(token string) ([]byte, error) {
+	return c.makeRequest("GET", "/proposals/"+token+"/votescsv", nil)
+}
+
 // VerifyUserPayment checks whether the logged in user has paid their user
 // registration fee.
 func (c *Client) VerifyUserPayment() (*v1.VerifyUserPaymentReply, error) {
@@ -1126,6 +1131,30 @@ func (c *Client) VoteResults(token string) (*v1.VoteResultsReply, error) {
 	return &vrr, nil
 }
 
+// VoteExport retrieves the full vote lifecycle of the specified proposal.
+func (c *Client) VoteExport(token string) (*v1.VoteExportReply, error) {
+	responseBody, err := c.makeRequest("GET", "/proposals/"+token+
+		"/voteexport", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var ver v1.VoteExportReply
+	err = json.Unmarshal(responseBody, &ver)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal VoteExportReply: %v", err)
+	}
+
+	if c.cfg.Verbose {
+		err := prettyPrintJSON(ver)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &ver, nil
+}
+
 // UserDetails retrieves the user details for the specified user.
 func (c *Client) UserDetails(userID string) (*v1.UserDetailsReply, error) {
 	responseBody, err := c.makeRequest("GET", "/user/"+userID, nil)
@@ -1597,6 +1626,31 @@ func (c *Client) InvoiceExchangeRate(ier *cms.InvoiceExchangeRate) (*cms.Invoice
 	return &ierr, nil
 }
 
+// InvoiceExchangeRateHistory retrieves the monthly exchange rates of a range
+// of years.
+func (c *Client) InvoiceExchangeRateHistory(ierh *cms.InvoiceExchangeRateHistory) (*cms.InvoiceExchangeRateHistoryReply, error) {
+	responseBody, err := c.makeRequest("POST",
+		cms.RouteInvoiceExchangeRateHistory, ierh)
+	if err != nil {
+		return nil, err
+	}
+
+	var ierhr cms.InvoiceExchangeRateHistoryReply
+	err = json.Unmarshal(responseBody, &ierhr)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal "+
+			"InvoiceExchangeRateHistoryReply: %v", err)
+	}
+
+	if c.cfg.Verbose {
+		err := prettyPrintJSON(ierhr)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &ierhr, nil
+}
+
 // Close all client connections.
 func (c *Client) Close() {
 	if c.conn != nil {
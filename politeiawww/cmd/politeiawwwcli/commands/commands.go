@@ -56,66 +56,70 @@ var (
 
 // Cmds is used to represent all of the politeiawwwcli commands.
 type Cmds struct {
-	AdminInvoices       AdminInvoicesCmd       `command:"admininvoices" description:"(admin) get all invoices (optional by month/year and/or status)"`
-	ActiveVotes         ActiveVotesCmd         `command:"activevotes" description:"(public) get the proposals that are being voted on"`
-	AuthorizeVote       AuthorizeVoteCmd       `command:"authorizevote" description:"(user)   authorize a proposal vote (must be proposal author)"`
-	CensorComment       CensorCommentCmd       `command:"censorcomment" description:"(admin)  censor a proposal comment"`
-	ChangePassword      ChangePasswordCmd      `command:"changepassword" description:"(user)   change the password for the logged in user"`
-	ChangeUsername      ChangeUsernameCmd      `command:"changeusername" description:"(user)   change the username for the logged in user"`
-	EditInvoice         EditInvoiceCmd         `command:"editinvoice" description:"(user)    edit a invoice"`
-	EditProposal        EditProposalCmd        `command:"editproposal" description:"(user)   edit a proposal"`
-	ManageUser          ManageUserCmd          `command:"manageuser" description:"(admin)  edit certain properties of the specified user"`
-	EditUser            EditUserCmd            `command:"edituser" description:"(user)   edit the  preferences of the logged in user"`
-	GeneratePayouts     GeneratePayoutsCmd     `command:"generatepayouts" description:"(admin) generate a list of payouts with addresses and amounts to pay"`
-	Help                HelpCmd                `command:"help" description:"         print a detailed help message for a specific command"`
-	InvoiceComments     InvoiceCommentsCmd     `command:"invoicecomments" description:"(user) get the comments for a invoice"`
-	InvoiceExchangeRate InvoiceExchangeRateCmd `command:"invoiceexchangerate" description:"(user) get exchange rate for a given month/year"`
-	Inventory           InventoryCmd           `command:"inventory" description:"(public) get the proposals that are being voted on"`
-	InviteNewUser       InviteNewUserCmd       `command:"invite" description:"(admin)  invite a new user"`
-	InvoiceDetails      InvoiceDetailsCmd      `command:"invoicedetails" description:"(public) get the details of a proposal"`
-	LikeComment         LikeCommentCmd         `command:"likecomment" description:"(user)   upvote/downvote a comment"`
-	Login               LoginCmd               `command:"login" description:"(public) login to Politeia"`
-	Logout              LogoutCmd              `command:"logout" description:"(public) logout of Politeia"`
-	Me                  MeCmd                  `command:"me" description:"(user)   get user details for the logged in user"`
-	NewInvoice          NewInvoiceCmd          `command:"newinvoice" description:"(user)   create a new invoice"`
-	NewProposal         NewProposalCmd         `command:"newproposal" description:"(user)   create a new proposal"`
-	NewComment          NewCommentCmd          `command:"newcomment" description:"(user)   create a new proposal comment"`
-	NewUser             NewUserCmd             `command:"newuser" description:"(public) create a new user"`
-	PayInvoices         PayInvoicesCmd         `command:"payinvoices" description:"(admin) set all approved invoices to paid"`
-	Policy              PolicyCmd              `command:"policy" description:"(public) get the server policy"`
-	ProposalComments    ProposalCommentsCmd    `command:"proposalcomments" description:"(public) get the comments for a proposal"`
-	ProposalDetails     ProposalDetailsCmd     `command:"proposaldetails" description:"(public) get the details of a proposal"`
-	ProposalPaywall     ProposalPaywallCmd     `command:"proposalpaywall" description:"(user)   get proposal paywall details for the logged in user"`
-	ProposalStats       ProposalStatsCmd       `command:"proposalstats" description:"(public) get statistics on the proposal inventory"`
-	UnvettedProposals   UnvettedProposalsCmd   `command:"unvettedproposals" description:"(admin)  get a page of unvetted proposals"`
-	VettedProposals     VettedProposalsCmd     `command:"vettedproposals" description:"(public) get a page of vetted proposals"`
-	RegisterUser        RegisterUserCmd        `command:"register" description:"(public) register an invited user to cms"`
-	RescanUserPayments  RescanUserPaymentsCmd  `command:"rescanuserpayments" description:"(admin)  rescan a user's payments to check for missed payments"`
-	ResendVerification  ResendVerificationCmd  `command:"resendverification" description:"(public) resend the user verification email"`
-	ResetPassword       ResetPasswordCmd       `command:"resetpassword" description:"(public) reset the password for a user that is not logged in"`
-	Secret              SecretCmd              `command:"secret" description:"(user)   ping politeiawww"`
-	SendFaucetTx        SendFaucetTxCmd        `command:"sendfaucettx" description:"         send a FNO transaction using the Fonero testnet faucet"`
-	SetInvoiceStatus    SetInvoiceStatusCmd    `command:"setinvoicestatus" description:"(admin)  set the status of an invoice"`
-	SetProposalStatus   SetProposalStatusCmd   `command:"setproposalstatus" description:"(admin)  set the status of a proposal"`
-	StartVote           StartVoteCmd           `command:"startvote" description:"(admin)  start the voting period on a proposal"`
-	Subscribe           SubscribeCmd           `command:"subscribe" description:"(public) subscribe to all websocket commands and do not exit tool"`
-	Tally               TallyCmd               `command:"tally" description:"(public) get the vote tally for a proposal"`
-	TestRun             TestRunCmd             `command:"testrun" description:"         run a series of tests on the politeiawww routes (dev use only)"`
-	TokenInventory      TokenInventoryCmd      `command:"tokeninventory" description:"(public) get the censorship record tokens of all proposals"`
-	UpdateUserKey       UpdateUserKeyCmd       `command:"updateuserkey" description:"(user)   generate a new identity for the logged in user"`
-	UserDetails         UserDetailsCmd         `command:"userdetails" description:"(public) get the details of a user profile"`
-	UserLikeComments    UserLikeCommentsCmd    `command:"userlikecomments" description:"(user)   get the logged in user's comment upvotes/downvotes for a proposal"`
-	UserPendingPayment  UserPendingPaymentCmd  `command:"userpendingpayment" description:"(user)   get details for a pending payment for the logged in user"`
-	UserInvoices        UserInvoicesCmd        `command:"userinvoices" description:"(user) get all invoices submitted by a specific user"`
-	UserProposals       UserProposalsCmd       `command:"userproposals" description:"(public) get all proposals submitted by a specific user"`
-	Users               UsersCmd               `command:"users" description:"(admin)  get a list of users"`
-	VerifyUserEmail     VerifyUserEmailCmd     `command:"verifyuseremail" description:"(public) verify a user's email address"`
-	VerifyUserPayment   VerifyUserPaymentCmd   `command:"verifyuserpayment" description:"(user)   check if the logged in user has paid their user registration fee"`
-	Version             VersionCmd             `command:"version" description:"(public) get server info and CSRF token"`
-	Vote                VoteCmd                `command:"vote" description:"(public) cast votes for a proposal"`
-	VoteResults         VoteResultsCmd         `command:"voteresults" description:"(public) get vote results for a proposal"`
-	VoteStatus          VoteStatusCmd          `command:"votestatus" description:"(public) get the vote status of a proposal"`
-	VoteStatuses        VoteStatusesCmd        `command:"votestatuses" description:"(public) get the vote status for all public proposals"`
+	AdminInvoices              AdminInvoicesCmd              `command:"admininvoices" description:"(admin) get all invoices (optional by month/year and/or status)"`
+	ActiveVotes                ActiveVotesCmd                `command:"activevotes" description:"(public) get the proposals that are being voted on"`
+	AuthorizeVote              AuthorizeVoteCmd              `command:"authorizevote" description:"(user)   authorize a proposal vote (must be proposal author)"`
+	CensorComment              CensorCommentCmd              `command:"censorcomment" description:"(admin)  censor a proposal comment"`
+	ChangePassword             ChangePasswordCmd             `command:"changepassword" description:"(user)   change the password for the logged in user"`
+	ChangeUsername             ChangeUsernameCmd             `command:"changeusername" description:"(user)   change the username for the logged in user"`
+	EditInvoice                EditInvoiceCmd                `command:"editinvoice" description:"(user)    edit a invoice"`
+	EditProposal               EditProposalCmd               `command:"editproposal" description:"(user)   edit a proposal"`
+	ManageUser                 ManageUserCmd                 `command:"manageuser" description:"(admin)  edit certain properties of the specified user"`
+	EditUser                   EditUserCmd                   `command:"edituser" description:"(user)   edit the  preferences of the logged in user"`
+	GeneratePayouts            GeneratePayoutsCmd            `command:"generatepayouts" description:"(admin) generate a list of payouts with addresses and amounts to pay"`
+	Help                       HelpCmd                       `command:"help" description:"         print a detailed help message for a specific command"`
+	InvoiceComments            InvoiceCommentsCmd            `command:"invoicecomments" description:"(user) get the comments for a invoice"`
+	InvoiceExchangeRate        InvoiceExchangeRateCmd        `command:"invoiceexchangerate" description:"(user) get exchange rate for a given month/year"`
+	InvoiceExchangeRateHistory InvoiceExchangeRateHistoryCmd `command:"invoiceexchangeratehistory" description:"(user) get the monthly exchange rates of a range of years"`
+	Inventory                  InventoryCmd                  `command:"inventory" description:"(public) get the proposals that are being voted on"`
+	InviteNewUser              InviteNewUserCmd              `command:"invite" description:"(admin)  invite a new user"`
+	InvoiceDetails             InvoiceDetailsCmd             `command:"invoicedetails" description:"(public) get the details of a proposal"`
+	LikeComment                LikeCommentCmd                `command:"likecomment" description:"(user)   upvote/downvote a comment"`
+	Login                      LoginCmd                      `command:"login" description:"(public) login to Politeia"`
+	Logout                     LogoutCmd                     `command:"logout" description:"(public) logout of Politeia"`
+	Me                         MeCmd                         `command:"me" description:"(user)   get user details for the logged in user"`
+	NewInvoice                 NewInvoiceCmd                 `command:"newinvoice" description:"(user)   create a new invoice"`
+	NewProposal                NewProposalCmd                `command:"newproposal" description:"(user)   create a new proposal"`
+	NewComment                 NewCommentCmd                 `command:"newcomment" description:"(user)   create a new proposal comment"`
+	NewUser                    NewUserCmd                    `command:"newuser" description:"(public) create a new user"`
+	PayInvoices                PayInvoicesCmd                `command:"payinvoices" description:"(admin) set all approved invoices to paid"`
+	Policy                     PolicyCmd                     `command:"policy" description:"(public) get the server policy"`
+	ProposalComments           ProposalCommentsCmd           `command:"proposalcomments" description:"(public) get the comments for a proposal"`
+	ProposalDetails            ProposalDetailsCmd            `command:"proposaldetails" description:"(public) get the details of a proposal"`
+	ProposalPaywall            ProposalPaywallCmd            `command:"proposalpaywall" description:"(user)   get proposal paywall details for the logged in user"`
+	ProposalStats              ProposalStatsCmd              `command:"proposalstats" description:"(public) get statistics on the proposal inventory"`
+	UnvettedProposals          UnvettedProposalsCmd          `command:"unvettedproposals" description:"(admin)  get a page of unvetted proposals"`
+	VettedProposals            VettedProposalsCmd            `command:"vettedproposals" description:"(public) get a page of vetted proposals"`
+	RegisterUser               RegisterUserCmd               `command:"register" description:"(public) register an invited user to cms"`
+	RescanUserPayments         RescanUserPaymentsCmd         `command:"rescanuserpayments" description:"(admin)  rescan a user's payments to check for missed payments"`
+	ResendVerification         ResendVerificationCmd         `command:"resendverification" description:"(public) resend the user verification email"`
+	ResetPassword              ResetPasswordCmd              `command:"resetpassword" description:"(public) reset the password for a user that is not logged in"`
+	Secret                     SecretCmd                     `command:"secret" description:"(user)   ping politeiawww"`
+	SendFaucetTx               SendFaucetTxCmd               `command:"sendfaucettx" description:"         send a FNO transaction using the Fonero testnet faucet"`
+	SetInvoiceStatus           SetInvoiceStatusCmd           `command:"setinvoicestatus" description:"(admin)  set the status of an invoice"`
+	SetProposalStatus          SetProposalStatusCmd          `command:"setproposalstatus" description:"(admin)  set the status of a proposal"`
+	StartVote                  StartVoteCmd                  `command:"startvote" description:"(admin)  start the voting period on a proposal"`
+	Subscribe                  SubscribeCmd                  `command:"subscribe" description:"(public) subscribe to all websocket commands and do not exit tool"`
+	Tally                      TallyCmd                      `command:"tally" description:"(public) get the vote tally for a proposal"`
+	TestRun                    TestRunCmd                    `command:"testrun" description:"         run a series of tests on the politeiawww routes (dev use only)"`
+	TokenInventory             TokenInventoryCmd             `command:"tokeninventory" description:"(public) get the censorship record tokens of all proposals"`
+	UpdateUserKey              UpdateUserKeyCmd              `command:"updateuserkey" description:"(user)   generate a new identity for the logged in user"`
+	UserDetails                UserDetailsCmd                `command:"userdetails" description:"(public) get the details of a user profile"`
+	UserLikeComments           UserLikeCommentsCmd           `command:"userlikecomments" description:"(user)   get the logged in user's comment upvotes/downvotes for a proposal"`
+	UserPendingPayment         UserPendingPaymentCmd         `command:"userpendingpayment" description:"(user)   get details for a pending payment for the logged in user"`
+	UserInvoices               UserInvoicesCmd               `command:"userinvoices" description:"(user) get all invoices submitted by a specific user"`
+	UserProposals              UserProposalsCmd              `command:"userproposals" description:"(public) get all proposals submitted by a specific user"`
+	Users                      UsersCmd                      `command:"users" description:"(admin)  get a list of users"`
+	VerifyUserEmail            VerifyUserEmailCmd            `command:"verifyuseremail" description:"(public) verify a user's email address"`
+	VerifyUserPayment          VerifyUserPaymentCmd          `command:"verifyuserpayment" description:"(user)   check if the logged in user has paid their user registration fee"`
+	Version                    VersionCmd                    `command:"version" description:"(public) get server info and CSRF token"`
+	Vote                       VoteCmd                       `command:"vote" description:"(public) cast votes for a proposal"`
+	VoteExport                 VoteExportCmd                 `command:"voteexport" description:"(public) export the full vote lifecycle of a proposal"`
+	VoteResults                VoteResultsCmd                `command:"voteresults" description:"(public) get vote results for a proposal"`
+	VotesCSV                   VotesCSVCmd                   `command:"votescsv" description:"(public) export the cast votes of a proposal as CSV"`
+	VoteStatus                 VoteStatusCmd                 `command:"votestatus" description:"(public) get the vote status of a proposal"`
+	VoteStatuses               VoteStatusesCmd               `command:"votestatuses" description:"(public) get the vote status for all public proposals"`
+	WatchVote                  WatchVoteCmd                  `command:"watchvote" description:"(public) poll the vote status of a proposal until the vote has ended"`
 }
 
 // SetConfig sets the global config variable.
@@ -92,6 +92,10 @@ func (cmd *HelpCmd) Execute(args []string) error {
 		fmt.Printf("%s\n", startVoteHelpMsg)
 	case "voteresults":
 		fmt.Printf("%s\n", voteResultsHelpMsg)
+	case "voteexport":
+		fmt.Printf("%s\n", voteExportHelpMsg)
+	case "votescsv":
+		fmt.Printf("%s\n", votesCSVHelpMsg)
 	case "inventory":
 		fmt.Printf("%s\n", inventoryHelpMsg)
 	case "tally":
@@ -104,6 +108,8 @@ func (cmd *HelpCmd) Execute(args []string) error {
 		fmt.Printf("%s\n", voteStatusHelpMsg)
 	case "votestatuses":
 		fmt.Printf("%s\n", voteStatusesHelpMsg)
+	case "watchvote":
+		fmt.Printf("%s\n", watchVoteHelpMsg)
 	case "proposalstats":
 		fmt.Printf("%s\n", proposalStatsHelpMsg)
 	case "vote":
@@ -122,6 +128,8 @@ func (cmd *HelpCmd) Execute(args []string) error {
 		fmt.Printf("%s\n", setInvoiceStatusHelpMsg)
 	case "invoicecomments":
 		fmt.Printf("%s\n", invoiceCommentsHelpMsg)
+	case "invoiceexchangeratehistory":
+		fmt.Printf("%s\n", invoiceExchangeRateHistoryHelpMsg)
 	default:
 		fmt.Printf("invalid command: use 'politeiawwwcli -h' " +
 			"to view a list of valid commands\n")
@@ -0,0 +1,71 @@
+// Copyright (c) 2017-2019 The Fonero developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package commands
+
+import (
+	"github.com/fonero-project/politeia/politeiawww/api/cms/v1"
+)
+
+// InvoiceExchangeRateHistoryCmd retrieves the monthly exchange rates of a
+// range of years.
+type InvoiceExchangeRateHistoryCmd struct {
+	Args struct {
+		StartYear uint `positional-arg-name:"startyear" required:"true"` // First year
+		EndYear   uint `positional-arg-name:"endyear"`                   // Last year
+	} `positional-args:"true"`
+}
+
+// Execute executes the invoice exchange rate history command.
+func (cmd *InvoiceExchangeRateHistoryCmd) Execute(args []string) error {
+	// Only retrieve the start year if no end year was given
+	endYear := cmd.Args.EndYear
+	if endYear == 0 {
+		endYear = cmd.Args.StartYear
+	}
+
+	ierh := &v1.InvoiceExchangeRateHistory{
+		StartYear: cmd.Args.StartYear,
+		EndYear:   endYear,
+	}
+
+	// Print request details
+	err := printJSON(ierh)
+	if err != nil {
+		return err
+	}
+
+	// Send request
+	ierhr, err := client.InvoiceExchangeRateHistory(ierh)
+	if err != nil {
+		return err
+	}
+
+	// Print response details
+	return printJSON(ierhr)
+}
+
+// invoiceExchangeRateHistoryHelpMsg is the output of the help command when
+// 'invoiceexchangeratehistory' is specified.
+const invoiceExchangeRateHistoryHelpMsg = `invoiceexchangeratehistory "startyear" "endyear"
+
+Request the stored monthly exchange rates of a range of years.  Months whose
+exchange rate has not been fetched are returned as missing.
+
+Arguments:
+1. startyear     (uint, required)   First year (YYYY)
+2. endyear       (uint, optional)   Last year (YYYY), defaults to startyear
+
+Result:
+{
+  "currency": (string) Fiat currency of the exchange rates
+  "exchangerates": [
+    {
+      "month": (uint) Month
+      "year": (uint) Year
+      "exchangerate": (uint) Exchange rate in cents
+      "missing": (bool) Exchange rate has not been fetched
+    }
+  ]
+}`
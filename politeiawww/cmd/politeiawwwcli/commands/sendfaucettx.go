@@ -18,6 +18,7 @@ type SendFaucetTxCmd struct {
 		Amount        uint64 `positional-arg-name:"amount" required:"true"`  // Amount in atoms
 		OverrideToken string `positional-arg-name:"overridetoken"`           // Faucet override token
 	} `positional-args:"true"`
+	Retries uint `long:"retries"` // Number of retries on transient errors
 }
 
 // Execute executes the send faucet tx command.
@@ -31,8 +32,8 @@ func (cmd *SendFaucetTxCmd) Execute(args []string) error {
 			fno, address)
 	}
 
-	txID, err := util.PayWithTestnetFaucet(cfg.FaucetHost, address, atoms,
-		cmd.Args.OverrideToken)
+	txID, err := util.PayWithTestnetFaucetRetry(cfg.FaucetHost, address,
+		atoms, cmd.Args.OverrideToken, int(cmd.Retries))
 	if err != nil {
 		return err
 	}
@@ -53,7 +54,7 @@ func (cmd *SendFaucetTxCmd) Execute(args []string) error {
 
 // sendFaucetTxHelpMsg is the output for the help command when 'sendfaucettx'
 // is specified.
-const sendFaucetTxHelpMsg = `sendfaucettx "address" "amount" "overridetoken"
+const sendFaucetTxHelpMsg = `sendfaucettx [flags] "address" "amount" "overridetoken"
 
 Use the Fonero testnet faucet to send FNO (in atoms) to an address. One atom is
 one hundred millionth of a single FNO (0.00000001 FNO).
@@ -63,5 +64,11 @@ Arguments:
 2. amount           (uint64, required)   Amount to send (atoms)
 3. overridetoken    (string, optional)   Override token for testnet faucet
 
+Flags:
+  --retries         (uint, optional)     Number of times to retry the faucet
+                                         request when the faucet is rate
+                                         limited or returns a server error
+                                         (default: 0)
+
 Result:
 Paid [amount] FNO to [address] with txID [transaction id]`
@@ -0,0 +1,125 @@
+// Copyright (c) 2017-2019 The Fonero developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/fonero-project/politeia/util"
+)
+
+// VoteExportCmd exports the full vote lifecycle of the specified proposal.
+type VoteExportCmd struct {
+	Args struct {
+		Token string `positional-arg-name:"token"` // Censorship token
+	} `positional-args:"true" required:"true"`
+	Output string `long:"output" short:"o"` // Output file
+}
+
+// Execute executes the vote export command.
+func (cmd *VoteExportCmd) Execute(args []string) error {
+	ver, err := client.VoteExport(cmd.Args.Token)
+	if err != nil {
+		return err
+	}
+
+	// Print the export to stdout if no output file was given
+	if cmd.Output == "" {
+		return printJSON(ver)
+	}
+
+	b, err := json.MarshalIndent(ver, "", "  ")
+	if err != nil {
+		return fmt.Errorf("MarshalIndent: %v", err)
+	}
+
+	fpath := util.CleanAndExpandPath(cmd.Output)
+	err = ioutil.WriteFile(fpath, b, 0600)
+	if err != nil {
+		return fmt.Errorf("WriteFile %v: %v", fpath, err)
+	}
+
+	if !cfg.Silent {
+		fmt.Printf("Vote export written to %v\n", fpath)
+	}
+
+	return nil
+}
+
+// voteExportHelpMsg is the output of the help command when 'voteexport' is
+// specified.
+const voteExportHelpMsg = `voteexport [flags] "token"
+
+Export the full vote lifecycle of a proposal as JSON. The export contains the
+vote authorization, the start vote, the eligible tickets, all cast votes and
+the vote results. The results are the final vote results once the vote has
+finished and the current vote tally otherwise.
+
+Arguments:
+1. token       (string, required)  Proposal censorship token
+
+Flags:
+  --output     (string, optional)  Write the export to this file instead of
+                                   stdout
+
+Response:
+{
+  "authorizevote": {
+    "action":              (string)  Authorize or revoke
+    "token":               (string)  Censorship token
+    "signature":           (string)  Signature of token+version+action
+    "publickey":           (string)  Public key used for signature
+  },
+  "authorizevotereply": {
+    "action":              (string)  Authorize or revoke
+    "receipt":             (string)  Server signature of client signature
+  },
+  "startvote": {
+    "publickey"            (string)  Public key of user that submitted proposal
+    "vote": {
+      "token":             (string)  Censorship token
+      "mask"               (uint64)  Valid votebits
+      "duration":          (uint32)  Duration of vote in blocks
+      "quorumpercentage"   (uint32)  Percent of votes required for quorum
+      "passpercentage":    (uint32)  Percent of votes required to pass
+      "options": [
+        {
+          "id"             (string)  Unique word identifying vote (e.g. yes)
+          "description"    (string)  Longer description of the vote
+          "bits":          (uint64)  Bits used for this option
+        },
+      ]
+    },
+    "signature"            (string)  Signature of Votehash
+  },
+  "startvotereply": {
+    "startblockheight":    (string)  Block height at start of vote
+    "startblockhash":      (string)  Hash of first block of vote interval
+    "endheight":           (string)  Block height at end of vote
+    "eligibletickets":     ([]string)  Valid voting tickets
+  },
+  "castvotes": [
+    {
+      "token":             (string)  Censorship token
+      "ticket":            (string)  Ticket hash
+      "votebit":           (string)  Selected vote bit, hex encoded
+      "signature":         (string)  Signature of token+ticket+votebit
+    }
+  ],
+  "optionsresult": [
+    {
+      "option": {
+        "id":              (string)  Unique word identifying vote (e.g. yes)
+        "description":     (string)  Longer description of the vote
+        "bits":            (uint64)  Bits used for this option
+      },
+      "votesreceived":     (uint64)  Number of votes received by the option
+    }
+  ],
+  "final":                 (bool)    Vote results are final
+  "approved":              (bool)    Vote was approved
+}`
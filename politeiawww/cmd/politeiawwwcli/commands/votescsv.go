@@ -0,0 +1,69 @@
+// Copyright (c) 2017-2019 The Fonero developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/fonero-project/politeia/util"
+)
+
+// VotesCSVCmd exports the cast votes of the specified proposal as CSV.
+type VotesCSVCmd struct {
+	Args struct {
+		Token string `positional-arg-name:"token"` // Censorship token
+	} `positional-args:"true" required:"true"`
+	Output string `long:"output" short:"o"` // Output file
+}
+
+// Execute executes the votes CSV command.
+func (cmd *VotesCSVCmd) Execute(args []string) error {
+	b, err := client.ProposalVotesCSV(cmd.Args.Token)
+	if err != nil {
+		return err
+	}
+
+	// Print the CSV to stdout if no output file was given
+	if cmd.Output == "" {
+		fmt.Printf("%s", b)
+		return nil
+	}
+
+	fpath := util.CleanAndExpandPath(cmd.Output)
+	err = ioutil.WriteFile(fpath, b, 0600)
+	if err != nil {
+		return fmt.Errorf("WriteFile %v: %v", fpath, err)
+	}
+
+	if !cfg.Silent {
+		fmt.Printf("Cast votes written to %v\n", fpath)
+	}
+
+	return nil
+}
+
+// votesCSVHelpMsg is the output of the help command when 'votescsv' is
+// specified.
+const votesCSVHelpMsg = `votescsv [flags] "token"
+
+Export the cast votes of a proposal as CSV. The first row is a header row
+followed by one row per cast vote, ordered by ticket.
+
+Arguments:
+1. token       (string, required)  Proposal censorship token
+
+Flags:
+  --output     (string, optional)  Write the CSV to this file instead of
+                                   stdout
+
+Response:
+ticket,votebit,option,signature
+(string),(string),(string),(string)
+
+  ticket       Ticket hash
+  votebit      Selected vote bit, hex encoded
+  option       Id of the vote option that matches the vote bit
+  signature    Signature of token+ticket+votebit`
@@ -0,0 +1,131 @@
+// Copyright (c) 2017-2019 The Fonero developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/fonero-project/politeia/politeiawww/api/www/v1"
+)
+
+// voteStatusClient is the subset of the politeiawww client that is used to
+// watch a proposal vote.
+type voteStatusClient interface {
+	VoteStatus(token string) (*v1.VoteStatusReply, error)
+}
+
+// WatchVoteCmd polls the vote status of the specified proposal and prints
+// the vote tally until the voting period has ended.
+type WatchVoteCmd struct {
+	Args struct {
+		Token    string `positional-arg-name:"token" required:"true"` // Censorship token
+		Interval string `positional-arg-name:"interval"`              // Polling interval in seconds
+	} `positional-args:"true"`
+}
+
+// Execute executes the watch vote command.
+func (cmd *WatchVoteCmd) Execute(args []string) error {
+	// Set polling interval default
+	if cmd.Args.Interval == "" {
+		cmd.Args.Interval = "60"
+	}
+
+	interval, err := strconv.ParseUint(cmd.Args.Interval, 10, 32)
+	if err != nil {
+		return fmt.Errorf("parsing Interval: %v", err)
+	}
+	if interval == 0 {
+		return fmt.Errorf("interval must be greater than zero")
+	}
+
+	return watchVote(client, cmd.Args.Token,
+		time.Duration(interval)*time.Second, printVoteStatus)
+}
+
+// watchVote polls the vote status of a proposal at the provided interval and
+// passes each vote status reply to the tick function.  It returns once the
+// voting period of the proposal has finished.  An error is returned if the
+// proposal vote has not been started.
+func watchVote(c voteStatusClient, token string, interval time.Duration, tick func(*v1.VoteStatusReply) error) error {
+	for {
+		vsr, err := c.VoteStatus(token)
+		if err != nil {
+			return fmt.Errorf("VoteStatus: %v", err)
+		}
+
+		switch vsr.Status {
+		case v1.PropVoteStatusStarted, v1.PropVoteStatusFinished:
+			// Vote status is valid; continue
+		default:
+			return fmt.Errorf("proposal vote has not been started: %v",
+				v1.PropVoteStatus[vsr.Status])
+		}
+
+		err = tick(vsr)
+		if err != nil {
+			return err
+		}
+
+		if vsr.Status == v1.PropVoteStatusFinished {
+			return nil
+		}
+
+		time.Sleep(interval)
+	}
+}
+
+// printVoteStatus prints the vote tally, quorum, and turnout of a vote status
+// reply using the style specified by the global config variable.
+func printVoteStatus(vsr *v1.VoteStatusReply) error {
+	if cfg.Silent || cfg.Verbose || cfg.RawJSON {
+		return printJSON(vsr)
+	}
+
+	var turnout float64
+	if vsr.NumOfEligibleVotes > 0 {
+		turnout = float64(vsr.TotalVotes) /
+			float64(vsr.NumOfEligibleVotes) * 100
+	}
+	quorum := uint64(float64(vsr.QuorumPercentage) / 100 *
+		float64(vsr.NumOfEligibleVotes))
+
+	fmt.Printf("%v\n", time.Now().Format(time.RFC3339))
+	fmt.Printf("  Status               : %v\n", v1.PropVoteStatus[vsr.Status])
+	fmt.Printf("  End height           : %v\n", vsr.EndHeight)
+	for _, v := range vsr.OptionsResult {
+		fmt.Printf("  %-20v : %v\n", v.Option.Id, v.VotesReceived)
+	}
+	fmt.Printf("  Total votes          : %v\n", vsr.TotalVotes)
+	fmt.Printf("  Quorum               : %v/%v\n", vsr.TotalVotes, quorum)
+	fmt.Printf("  Turnout              : %.2f%%\n", turnout)
+
+	return nil
+}
+
+// watchVoteHelpMsg is the output of the help command when 'watchvote' is
+// specified.
+const watchVoteHelpMsg = `watchvote "token" interval
+
+Poll the vote status of a proposal and print the vote tally every interval
+until the voting period has ended.  The command returns an error if the
+proposal vote has not been started.  When the --json flag is used, one JSON
+object is printed per poll.
+
+Arguments:
+1. token       (string, required)  Proposal censorship token
+2. interval    (uint32, optional)  Polling interval in seconds (default: 60)
+
+Response:
+
+2019-06-01T12:00:00Z
+  Status               : (string)  Vote status
+  End height           : (string)  Final block height of the vote
+  yes                  : (uint64)  Votes received by the option
+  no                   : (uint64)  Votes received by the option
+  Total votes          : (uint64)  Total number of votes cast
+  Quorum               : (string)  Total votes/votes required for quorum
+  Turnout              : (float64) Percentage of eligible tickets that voted`
@@ -0,0 +1,82 @@
+// Copyright (c) 2017-2019 The Fonero developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package commands
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/fonero-project/politeia/politeiawww/api/www/v1"
+)
+
+// testVoteStatusClient implements the voteStatusClient interface.  It returns
+// the vote status replies in order and repeats the last reply once they have
+// all been returned.
+type testVoteStatusClient struct {
+	replies []v1.VoteStatusReply
+	err     error
+	calls   int
+}
+
+// VoteStatus returns the next vote status reply of the test client.
+func (c *testVoteStatusClient) VoteStatus(token string) (*v1.VoteStatusReply, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	i := c.calls
+	if i >= len(c.replies) {
+		i = len(c.replies) - 1
+	}
+	c.calls++
+
+	vsr := c.replies[i]
+	return &vsr, nil
+}
+
+func TestWatchVote(t *testing.T) {
+	started := v1.VoteStatusReply{Status: v1.PropVoteStatusStarted}
+	finished := v1.VoteStatusReply{Status: v1.PropVoteStatusFinished}
+	authorized := v1.VoteStatusReply{Status: v1.PropVoteStatusAuthorized}
+
+	var tests = []struct {
+		name      string
+		client    *testVoteStatusClient
+		wantTicks int
+		wantErr   bool
+	}{
+		{"vote finished", &testVoteStatusClient{
+			replies: []v1.VoteStatusReply{finished},
+		}, 1, false},
+		{"vote finishes after polling", &testVoteStatusClient{
+			replies: []v1.VoteStatusReply{started, started, finished},
+		}, 3, false},
+		{"vote not started", &testVoteStatusClient{
+			replies: []v1.VoteStatusReply{authorized},
+		}, 0, true},
+		{"client error", &testVoteStatusClient{
+			err: errors.New("connection refused"),
+		}, 0, true},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			var ticks int
+			tick := func(vsr *v1.VoteStatusReply) error {
+				ticks++
+				return nil
+			}
+
+			err := watchVote(v.client, "token", 0, tick)
+			if (err != nil) != v.wantErr {
+				t.Fatalf("got error %v, want error %v",
+					err, v.wantErr)
+			}
+			if ticks != v.wantTicks {
+				t.Fatalf("got ticks %v, want %v", ticks, v.wantTicks)
+			}
+		})
+	}
+}
@@ -270,17 +270,19 @@ func (c *cockroachdb) InvoicesAll() ([]database.Invoice, error) {
 func (c *cockroachdb) NewExchangeRate(dbExchangeRate *database.ExchangeRate) error {
 	exchRate := encodeExchangeRate(dbExchangeRate)
 
-	log.Debugf("NewExchangeRate: %v %v", exchRate.Month, exchRate.Year)
+	log.Debugf("NewExchangeRate: %v %v %v", exchRate.Month, exchRate.Year,
+		exchRate.Currency)
 	return c.recordsdb.Create(exchRate).Error
 }
 
-// ExchangeRate returns exchange rate by month/year
-func (c *cockroachdb) ExchangeRate(month, year int) (*database.ExchangeRate, error) {
+// ExchangeRate returns exchange rate by month/year and fiat currency
+func (c *cockroachdb) ExchangeRate(month, year int, currency string) (*database.ExchangeRate, error) {
 	log.Tracef("ExchangeRate")
 
 	exchangeRate := ExchangeRate{}
 	err := c.recordsdb.
-		Where("month = ? AND year = ?", month, year).
+		Where("month = ? AND year = ? AND currency = ?", month, year,
+			currency).
 		Find(&exchangeRate).
 		Error
 	if err != nil {
@@ -293,6 +295,33 @@ func (c *cockroachdb) ExchangeRate(month, year int) (*database.ExchangeRate, err
 	return decodeExchangeRate(exchangeRate), nil
 }
 
+// ExchangeRatesByYears returns the exchange rates of the passed in fiat
+// currency for all months of the years within the passed in range, ordered by
+// month.  Both years are inclusive.
+//
+// ExchangeRatesByYears satisfies the database interface.
+func (c *cockroachdb) ExchangeRatesByYears(startYear, endYear int, currency string) ([]database.ExchangeRate, error) {
+	log.Tracef("ExchangeRatesByYears")
+
+	var exchangeRates []ExchangeRate
+	err := c.recordsdb.
+		Where("year >= ? AND year <= ? AND currency = ?", startYear,
+			endYear, currency).
+		Order("year asc, month asc").
+		Find(&exchangeRates).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	dbExchangeRates := make([]database.ExchangeRate, 0, len(exchangeRates))
+	for _, v := range exchangeRates {
+		dbExchangeRates = append(dbExchangeRates, *decodeExchangeRate(v))
+	}
+
+	return dbExchangeRates, nil
+}
+
 // Close satisfies the database interface.
 func (c *cockroachdb) Close() error {
 	return c.recordsdb.Close()
@@ -326,6 +355,14 @@ func createCmsTables(tx *gorm.DB) error {
 		if err != nil {
 			return err
 		}
+	} else if !tx.Dialect().HasColumn(tableNameExchangeRate, "currency") {
+		// Exchange rates that were stored before the fiat currency
+		// was configurable are USD rates.  The column default takes
+		// care of populating the existing rows.
+		err := tx.AutoMigrate(&ExchangeRate{}).Error
+		if err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -162,6 +162,7 @@ func encodeExchangeRate(dbExchangeRate *database.ExchangeRate) ExchangeRate {
 	exchangeRate := ExchangeRate{}
 	exchangeRate.Month = dbExchangeRate.Month
 	exchangeRate.Year = dbExchangeRate.Year
+	exchangeRate.Currency = dbExchangeRate.Currency
 	exchangeRate.ExchangeRate = dbExchangeRate.ExchangeRate
 	return exchangeRate
 }
@@ -170,6 +171,7 @@ func decodeExchangeRate(exchangeRate ExchangeRate) *database.ExchangeRate {
 	dbExchangeRate := &database.ExchangeRate{}
 	dbExchangeRate.Month = exchangeRate.Month
 	dbExchangeRate.Year = exchangeRate.Year
+	dbExchangeRate.Currency = exchangeRate.Currency
 	dbExchangeRate.ExchangeRate = exchangeRate.ExchangeRate
 	return dbExchangeRate
 }
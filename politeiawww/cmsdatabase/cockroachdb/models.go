@@ -75,9 +75,10 @@ func (InvoiceChange) TableName() string {
 
 // ExchangeRate contains cached calculated rates for a given month/year
 type ExchangeRate struct {
-	Month        uint `gorm:"not null"`
-	Year         uint `gorm:"not null"`
-	ExchangeRate uint `gorm:"not null"`
+	Month        uint   `gorm:"not null"`
+	Year         uint   `gorm:"not null"`
+	Currency     string `gorm:"not null;default:'USD'"`
+	ExchangeRate uint   `gorm:"not null"`
 }
 
 // TableName returns the table name of the line items table.
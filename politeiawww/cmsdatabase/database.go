@@ -41,7 +41,10 @@ type Database interface {
 	// ExchangeRate functions
 	NewExchangeRate(*ExchangeRate) error // Create new exchange rate
 
-	ExchangeRate(int, int) (*ExchangeRate, error) // Return an exchange rate based on month and year
+	ExchangeRate(int, int, string) (*ExchangeRate, error) // Return an exchange rate based on month, year and fiat currency
+
+	ExchangeRatesByYears(int, int, string) ([]ExchangeRate, error) // Return the exchange rates of a range of years and fiat currency ordered by month
+
 	// Setup the invoice tables
 	Setup() error
 
@@ -106,5 +109,6 @@ type InvoiceChange struct {
 type ExchangeRate struct {
 	Month        uint
 	Year         uint
-	ExchangeRate uint
+	Currency     string // Fiat currency of the rate
+	ExchangeRate uint   // Rate in cents of the fiat currency
 }
@@ -347,6 +347,32 @@ func (p *politeiawww) handleInvoiceExchangeRate(w http.ResponseWriter, r *http.R
 	util.RespondWithJSON(w, http.StatusOK, ierr)
 }
 
+// handleInvoiceExchangeRateHistory handles incoming requests for the monthly
+// exchange rates of a range of years.
+func (p *politeiawww) handleInvoiceExchangeRateHistory(w http.ResponseWriter, r *http.Request) {
+	log.Tracef("handleInvoiceExchangeRateHistory")
+
+	var ierh cms.InvoiceExchangeRateHistory
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&ierh); err != nil {
+		RespondWithError(w, r, 0,
+			"handleInvoiceExchangeRateHistory: unmarshal",
+			www.UserError{
+				ErrorCode: www.ErrorStatusInvalidInput,
+			})
+		return
+	}
+
+	ierhr, err := p.processInvoiceExchangeRateHistory(ierh)
+	if err != nil {
+		RespondWithError(w, r, 0, "handleInvoiceExchangeRateHistory: "+
+			"processInvoiceExchangeRateHistory: %v", err)
+		return
+	}
+
+	util.RespondWithJSON(w, http.StatusOK, ierhr)
+}
+
 func (p *politeiawww) handleCMSPolicy(w http.ResponseWriter, r *http.Request) {
 	// Get the policy command.
 	log.Tracef("handlePolicy")
@@ -432,6 +458,8 @@ func (p *politeiawww) setCMSWWWRoutes() {
 		p.handleInvoiceComments, permissionLogin)
 	p.addRoute(http.MethodPost, cms.RouteInvoiceExchangeRate,
 		p.handleInvoiceExchangeRate, permissionLogin)
+	p.addRoute(http.MethodPost, cms.RouteInvoiceExchangeRateHistory,
+		p.handleInvoiceExchangeRateHistory, permissionLogin)
 
 	// Unauthenticated websocket
 	p.addRoute("", www.RouteUnauthenticatedWebSocket,
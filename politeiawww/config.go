@@ -13,6 +13,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net"
+	"net/http"
 	"net/mail"
 	"net/url"
 	"os"
@@ -22,6 +23,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/fonero-project/fnod/hdkeychain"
 	"github.com/fonero-project/politeia/politeiad/api/v1/identity"
@@ -52,6 +54,12 @@ const (
 	defaultVoteDurationMin = uint32(2016)
 	defaultVoteDurationMax = uint32(4032)
 
+	// defaultLoadVoteResultsTimeout is the default timeout of the
+	// politeiad command that loads the vote results of finished
+	// proposal votes.  A first time load tallies every finished vote
+	// and takes much longer than a regular politeiad request.
+	defaultLoadVoteResultsTimeout = 10 * time.Minute
+
 	defaultMailAddress    = "Politeia <noreply@example.org>"
 	defaultCMSMailAddress = "Contractor Management System <noreply@example.org>"
 
@@ -112,31 +120,37 @@ type config struct {
 	RPCCert                  string `long:"rpccert" description:"File containing the https certificate file"`
 	RPCIdentityFile          string `long:"rpcidentityfile" description:"Path to file containing the politeiad identity"`
 	Identity                 *identity.PublicIdentity
-	RPCUser                  string `long:"rpcuser" description:"RPC user name for privileged commands"`
-	RPCPass                  string `long:"rpcpass" description:"RPC password for privileged commands"`
-	MailHost                 string `long:"mailhost" description:"Email server address in this format: <host>:<port>"`
-	MailUser                 string `long:"mailuser" description:"Email server username"`
-	MailPass                 string `long:"mailpass" description:"Email server password"`
-	MailAddress              string `long:"mailaddress" description:"Email address for outgoing email in the format: name <address>"`
-	DBHost                   string `long:"dbhost" description:"Database ip:port"`
-	DBRootCert               string `long:"dbrootcert" description:"File containing the CA certificate for the database"`
-	DBCert                   string `long:"dbcert" description:"File containing the politeiawww client certificate for the database"`
-	DBKey                    string `long:"dbkey" description:"File containing the politeiawww client certificate key for the database"`
-	UserDB                   string `long:"userdb" description:"Database choice for the user database"`
-	EncryptionKey            string `long:"encryptionkey" description:"File containing encryption key used for encrypting user data at rest"`
-	OldEncryptionKey         string `long:"oldencryptionkey" description:"File containing old encryption key (only set when rotating keys)"`
-	FetchIdentity            bool   `long:"fetchidentity" description:"Whether or not politeiawww fetches the identity from politeiad."`
-	WebServerAddress         string `long:"webserveraddress" description:"Address for the Politeia web server; it should have this format: <scheme>://<host>[:<port>]"`
-	Interactive              string `long:"interactive" description:"Set to i-know-this-is-a-bad-idea to turn off interactive mode during --fetchidentity."`
-	PaywallAmount            uint64 `long:"paywallamount" description:"Amount of FNO (in atoms) required for a user to register or submit a proposal."`
-	PaywallXpub              string `long:"paywallxpub" description:"Extended public key for deriving paywall addresses."`
-	MinConfirmationsRequired uint64 `long:"minconfirmations" description:"Minimum blocks confirmation for accepting paywall as paid. Only works in TestNet."`
-	VoteDurationMin          uint32 `long:"votedurationmin" description:"Minimum duration of a proposal vote in blocks"`
-	VoteDurationMax          uint32 `long:"votedurationmax" description:"Maximum duration of a proposal vote in blocks"`
-	AdminLogFile             string `long:"adminlogfile" description:"admin log filename (Default: admin.log)"`
-	Mode                     string `long:"mode" description:"Mode www runs as. Supported values: piwww, cmswww"`
-	SMTPSkipVerify           bool   `long:"smtpskipverify" description:"Skip SMTP TLS cert verification. Will only skip if SMTPCert is empty"`
-	SMTPCert                 string `long:"smtpcert" description:"File containing the smtp certificate file"`
+	RPCUser                  string        `long:"rpcuser" description:"RPC user name for privileged commands"`
+	RPCPass                  string        `long:"rpcpass" description:"RPC password for privileged commands"`
+	MailHost                 string        `long:"mailhost" description:"Email server address in this format: <host>:<port>"`
+	MailUser                 string        `long:"mailuser" description:"Email server username"`
+	MailPass                 string        `long:"mailpass" description:"Email server password"`
+	MailAddress              string        `long:"mailaddress" description:"Email address for outgoing email in the format: name <address>"`
+	DBHost                   string        `long:"dbhost" description:"Database ip:port"`
+	DBRootCert               string        `long:"dbrootcert" description:"File containing the CA certificate for the database"`
+	DBCert                   string        `long:"dbcert" description:"File containing the politeiawww client certificate for the database"`
+	DBKey                    string        `long:"dbkey" description:"File containing the politeiawww client certificate key for the database"`
+	UserDB                   string        `long:"userdb" description:"Database choice for the user database"`
+	EncryptionKey            string        `long:"encryptionkey" description:"File containing encryption key used for encrypting user data at rest"`
+	OldEncryptionKey         string        `long:"oldencryptionkey" description:"File containing old encryption key (only set when rotating keys)"`
+	FetchIdentity            bool          `long:"fetchidentity" description:"Whether or not politeiawww fetches the identity from politeiad."`
+	WebServerAddress         string        `long:"webserveraddress" description:"Address for the Politeia web server; it should have this format: <scheme>://<host>[:<port>]"`
+	Interactive              string        `long:"interactive" description:"Set to i-know-this-is-a-bad-idea to turn off interactive mode during --fetchidentity."`
+	PaywallAmount            uint64        `long:"paywallamount" description:"Amount of FNO (in atoms) required for a user to register or submit a proposal."`
+	PaywallXpub              string        `long:"paywallxpub" description:"Extended public key for deriving paywall addresses."`
+	MinConfirmationsRequired uint64        `long:"minconfirmations" description:"Minimum blocks confirmation for accepting paywall as paid. Only works in TestNet."`
+	VoteDurationMin          uint32        `long:"votedurationmin" description:"Minimum duration of a proposal vote in blocks"`
+	VoteDurationMax          uint32        `long:"votedurationmax" description:"Maximum duration of a proposal vote in blocks"`
+	LoadVoteResultsTimeout   time.Duration `long:"loadvoteresultstimeout" description:"Abort the politeiad command that loads the vote results of finished proposal votes if it has not completed within the timeout (0 means no timeout)"`
+	AdminLogFile             string        `long:"adminlogfile" description:"admin log filename (Default: admin.log)"`
+	Mode                     string        `long:"mode" description:"Mode www runs as. Supported values: piwww, cmswww"`
+	SMTPSkipVerify           bool          `long:"smtpskipverify" description:"Skip SMTP TLS cert verification. Will only skip if SMTPCert is empty"`
+	SMTPCert                 string        `long:"smtpcert" description:"File containing the smtp certificate file"`
+	FiatCurrency             string        `long:"fiatcurrency" description:"Fiat currency used for FNO exchange rates in cmswww mode. Supported values: USD, EUR, GBP"`
+	Exchange                 string        `long:"exchange" description:"Exchange that FNO prices are downloaded from in cmswww mode. Supported values: poloniex, binance"`
+	ExchangeRounding         string        `long:"exchangerounding" description:"Rounding mode used to convert FNO exchange rates to cents in cmswww mode. Supported values: round, floor, ceil, bankers"`
+	ExchangeHeaders          []string      `long:"exchangeheader" description:"Additional HTTP header sent with exchange price requests in the format <name>:<value> (e.g. an API key) -- May be specified multiple times"`
+	ExchangeHTTPHeaders      http.Header
 	SystemCerts              *x509.CertPool
 }
 
@@ -388,9 +402,13 @@ func loadConfig() (*config, []string, error) {
 		Version:                  version.String(),
 		VoteDurationMin:          defaultVoteDurationMin,
 		VoteDurationMax:          defaultVoteDurationMax,
+		LoadVoteResultsTimeout:   defaultLoadVoteResultsTimeout,
 		MailAddress:              defaultMailAddress,
 		Mode:                     defaultWWWMode,
 		UserDB:                   defaultUserDB,
+		FiatCurrency:             defaultFiatCurrency,
+		Exchange:                 defaultExchange,
+		ExchangeRounding:         defaultRoundingMode,
 	}
 
 	// Service options which are only added on Windows.
@@ -513,6 +531,48 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
+	// Verify exchange
+	cfg.Exchange = strings.ToLower(cfg.Exchange)
+	exchange, ok := priceExchanges[cfg.Exchange]
+	if !ok {
+		err := fmt.Errorf("invalid exchange: %v", cfg.Exchange)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	// Verify fiat currency
+	cfg.FiatCurrency = strings.ToUpper(cfg.FiatCurrency)
+	if _, ok := exchange.pairs[cfg.FiatCurrency]; !ok {
+		err := fmt.Errorf("invalid fiatcurrency: %v", cfg.FiatCurrency)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	// Verify exchange rate rounding mode
+	cfg.ExchangeRounding = strings.ToLower(cfg.ExchangeRounding)
+	if _, ok := centsRounders[cfg.ExchangeRounding]; !ok {
+		err := fmt.Errorf("invalid exchangerounding: %v",
+			cfg.ExchangeRounding)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	// Verify load vote results timeout
+	if cfg.LoadVoteResultsTimeout < 0 {
+		err := fmt.Errorf("invalid loadvoteresultstimeout: %v",
+			cfg.LoadVoteResultsTimeout)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	// Parse exchange headers
+	cfg.ExchangeHTTPHeaders, err = parseExchangeHeaders(cfg.ExchangeHeaders)
+	if err != nil {
+		err := fmt.Errorf("invalid exchangeheader: %v", err)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
 	// Verify mail address
 	if _, err := mail.ParseAddress(cfg.MailAddress); err != nil {
 		err := fmt.Errorf("invalid mailaddress: %v", err)
@@ -365,6 +365,7 @@ func convertCommentFromFonero(c foneroplugin.Comment) www.Comment {
 		UserID:      "",
 		Username:    "",
 		Censored:    c.Censored,
+		Hidden:      c.Hidden,
 	}
 }
 
@@ -464,6 +465,20 @@ func convertVoteResultsReplyFromFonero(vrr foneroplugin.VoteResultsReply) (www.S
 	return sv, cv
 }
 
+func convertVoteExportReplyFromFonero(ver foneroplugin.VoteExportReply) www.VoteExportReply {
+	av, avr := convertAuthVoteFromFonero(ver.AuthorizeVote)
+	return www.VoteExportReply{
+		AuthorizeVote:      av,
+		AuthorizeVoteReply: avr,
+		StartVote:          convertStartVoteFromFonero(ver.StartVote),
+		StartVoteReply:     convertStartVoteReplyFromFonero(ver.StartVoteReply),
+		CastVotes:          convertCastVotesFromFonero(ver.CastVotes),
+		OptionsResult:      convertVoteOptionResultsFromFonero(ver.Results),
+		Final:              ver.Final,
+		Approved:           ver.Approved,
+	}
+}
+
 func convertPluginSettingFromPD(ps pd.PluginSetting) PluginSetting {
 	return PluginSetting{
 		Key:   ps.Key,
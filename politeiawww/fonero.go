@@ -5,18 +5,35 @@
 package main
 
 import (
+	"context"
+	"encoding/csv"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"time"
 
-	"github.com/davecgh/go-spew/spew"
+	"github.com/fonero-project/fnod/chaincfg"
 	"github.com/fonero-project/politeia/foneroplugin"
 	pd "github.com/fonero-project/politeia/politeiad/api/v1"
 	"github.com/fonero-project/politeia/politeiad/cache"
 	"github.com/fonero-project/politeia/util"
 )
 
+// loadVoteResultsChunkSize is the maximum number of proposals whose vote
+// results are loaded by a single politeiad loadvoteresults command.
+const loadVoteResultsChunkSize = 20
+
+// streamCommentsPageSize is the number of comments that are requested from
+// the cache at a time when the comments of a proposal are streamed.
+const streamCommentsPageSize = 500
+
+// streamVotesPageSize is the number of cast votes that are requested from the
+// cache at a time when the cast votes of a proposal are streamed.
+const streamVotesPageSize = 1000
+
 // foneroGetComment sends the fonero plugin getcomment command to the cache and
 // returns the specified comment.
 func (p *politeiawww) foneroGetComment(token, commentID string) (*foneroplugin.Comment, error) {
@@ -51,210 +68,315 @@ func (p *politeiawww) foneroGetComment(token, commentID string) (*foneroplugin.C
 	return &gcr.Comment, nil
 }
 
-// foneroGetComments sends the fonero plugin getcomments command to the cache
-// and returns all of the comments for the passed in proposal token.
-func (p *politeiawww) foneroGetComments(token string) ([]foneroplugin.Comment, error) {
+// foneroGetCommentByReceipt sends the fonero plugin getcommentbyreceipt command
+// to the cache and returns the comment that has the specified server receipt.
+func (p *politeiawww) foneroGetCommentByReceipt(receipt string) (*foneroplugin.Comment, error) {
 	// Setup plugin command
-	gc := foneroplugin.GetComments{
-		Token: token,
+	gcbr := foneroplugin.GetCommentByReceipt{
+		Receipt: receipt,
 	}
 
-	payload, err := foneroplugin.EncodeGetComments(gc)
+	payload, err := foneroplugin.EncodeGetCommentByReceipt(gcbr)
 	if err != nil {
 		return nil, err
 	}
 
 	pc := cache.PluginCommand{
 		ID:             foneroplugin.ID,
-		Command:        foneroplugin.CmdGetComments,
+		Command:        foneroplugin.CmdGetCommentByReceipt,
 		CommandPayload: string(payload),
 	}
 
-	// Get comments from the cache
+	// Get comment from the cache
 	reply, err := p.cache.PluginExec(pc)
 	if err != nil {
-		return nil, fmt.Errorf("PluginExec: %v", err)
+		return nil, err
 	}
 
-	gcr, err := foneroplugin.DecodeGetCommentsReply([]byte(reply.Payload))
+	gcbrr, err := foneroplugin.DecodeGetCommentByReceiptReply(
+		[]byte(reply.Payload))
 	if err != nil {
 		return nil, err
 	}
 
-	return gcr.Comments, nil
+	return &gcbrr.Comment, nil
 }
 
-// foneroCommentLikes sends the fonero plugin commentlikes command to the cache
-// and returns all of the comment likes for the passed in comment.
-func (p *politeiawww) foneroCommentLikes(token, commentID string) ([]foneroplugin.LikeComment, error) {
+// foneroGetCommentAncestors sends the fonero plugin getcommentancestors
+// command to the cache and returns the specified comment along with its
+// ancestors.  The ancestors are ordered from the parent of the comment up to
+// the root comment.  A maxDepth of zero uses the plugin default.
+func (p *politeiawww) foneroGetCommentAncestors(token, commentID string, maxDepth uint32) (*foneroplugin.GetCommentAncestorsReply, error) {
 	// Setup plugin command
-	cl := foneroplugin.CommentLikes{
+	gca := foneroplugin.GetCommentAncestors{
 		Token:     token,
 		CommentID: commentID,
+		MaxDepth:  maxDepth,
 	}
 
-	payload, err := foneroplugin.EncodeCommentLikes(cl)
+	payload, err := foneroplugin.EncodeGetCommentAncestors(gca)
 	if err != nil {
 		return nil, err
 	}
 
 	pc := cache.PluginCommand{
 		ID:             foneroplugin.ID,
-		Command:        foneroplugin.CmdCommentLikes,
+		Command:        foneroplugin.CmdGetCommentAncestors,
 		CommandPayload: string(payload),
 	}
 
-	// Get comment likes from cache
+	// Get comment and ancestors from the cache
 	reply, err := p.cache.PluginExec(pc)
 	if err != nil {
 		return nil, err
 	}
 
-	clr, err := foneroplugin.DecodeCommentLikesReply([]byte(reply.Payload))
+	return foneroplugin.DecodeGetCommentAncestorsReply([]byte(reply.Payload))
+}
+
+// foneroCommentThreadStats sends the fonero plugin commentthreadstats command
+// to the cache and returns the number of uncensored and censored descendants
+// of each root comment of the passed in proposal.
+func (p *politeiawww) foneroCommentThreadStats(token string) ([]foneroplugin.CommentThread, error) {
+	// Setup plugin command
+	cts := foneroplugin.CommentThreadStats{
+		Token: token,
+	}
+
+	payload, err := foneroplugin.EncodeCommentThreadStats(cts)
 	if err != nil {
 		return nil, err
 	}
 
-	return clr.CommentLikes, nil
+	pc := cache.PluginCommand{
+		ID:             foneroplugin.ID,
+		Command:        foneroplugin.CmdCommentThreadStats,
+		CommandPayload: string(payload),
+	}
+
+	// Get comment thread stats from the cache
+	reply, err := p.cache.PluginExec(pc)
+	if err != nil {
+		return nil, err
+	}
+
+	ctsr, err := foneroplugin.DecodeCommentThreadStatsReply([]byte(reply.Payload))
+	if err != nil {
+		return nil, err
+	}
+
+	return ctsr.Threads, nil
 }
 
-// foneroPropCommentLikes sends the fonero plugin proposalcommentslikes command
-// to the cache and returns all of the comment likes for the passed in proposal
-// token.
-func (p *politeiawww) foneroPropCommentLikes(token string) ([]foneroplugin.LikeComment, error) {
+// foneroGetCommentVersions sends the fonero plugin getcommentversions command
+// to the cache and returns all stored versions of the passed in comment,
+// ordered from oldest to newest.
+func (p *politeiawww) foneroGetCommentVersions(token, commentID string) ([]foneroplugin.CommentVersion, error) {
 	// Setup plugin command
-	pcl := foneroplugin.GetProposalCommentsLikes{
-		Token: token,
+	gcv := foneroplugin.GetCommentVersions{
+		Token:     token,
+		CommentID: commentID,
 	}
 
-	payload, err := foneroplugin.EncodeGetProposalCommentsLikes(pcl)
+	payload, err := foneroplugin.EncodeGetCommentVersions(gcv)
 	if err != nil {
 		return nil, err
 	}
 
 	pc := cache.PluginCommand{
 		ID:             foneroplugin.ID,
-		Command:        foneroplugin.CmdProposalCommentsLikes,
+		Command:        foneroplugin.CmdGetCommentVersions,
 		CommandPayload: string(payload),
 	}
 
-	// Get proposal comment likes from cache
+	// Get comment versions from the cache
 	reply, err := p.cache.PluginExec(pc)
 	if err != nil {
 		return nil, err
 	}
 
-	rp := []byte(reply.Payload)
-	pclr, err := foneroplugin.DecodeGetProposalCommentsLikesReply(rp)
+	gcvr, err := foneroplugin.DecodeGetCommentVersionsReply([]byte(reply.Payload))
 	if err != nil {
 		return nil, err
 	}
 
-	return pclr.CommentsLikes, nil
+	return gcvr.Versions, nil
 }
 
-// foneroVoteDetails sends the fonero plugin votedetails command to the cache
-// and returns the vote details for the passed in proposal.
-func (p *politeiawww) foneroVoteDetails(token string) (*foneroplugin.VoteDetailsReply, error) {
+// foneroGetCommentsSince sends the fonero plugin getcommentssince command to
+// the cache and returns the comments of the passed in proposal that were
+// created or censored after the passed in timestamp.  If lastSeenID is not
+// empty, the timestamp of the last seen comment is used instead.
+func (p *politeiawww) foneroGetCommentsSince(token string, since int64, lastSeenID string) ([]foneroplugin.Comment, error) {
 	// Setup plugin command
-	vd := foneroplugin.VoteDetails{
-		Token: token,
+	gcs := foneroplugin.GetCommentsSince{
+		Token:     token,
+		Timestamp: since,
+		CommentID: lastSeenID,
 	}
 
-	payload, err := foneroplugin.EncodeVoteDetails(vd)
+	payload, err := foneroplugin.EncodeGetCommentsSince(gcs)
 	if err != nil {
 		return nil, err
 	}
 
 	pc := cache.PluginCommand{
 		ID:             foneroplugin.ID,
-		Command:        foneroplugin.CmdVoteDetails,
+		Command:        foneroplugin.CmdGetCommentsSince,
 		CommandPayload: string(payload),
 	}
 
-	// Get vote details from cache
+	// Get comments from the cache
 	reply, err := p.cache.PluginExec(pc)
 	if err != nil {
 		return nil, err
 	}
 
-	vdr, err := foneroplugin.DecodeVoteDetailsReply([]byte(reply.Payload))
+	gcsr, err := foneroplugin.DecodeGetCommentsSinceReply([]byte(reply.Payload))
 	if err != nil {
 		return nil, err
 	}
 
-	return vdr, nil
+	return gcsr.Comments, nil
 }
 
-// foneroProposalVotes sends the fonero plugin proposalvotes command to the
-// cache and returns the vote results for the passed in proposal.
-func (p *politeiawww) foneroProposalVotes(token string) (*foneroplugin.VoteResultsReply, error) {
+// foneroCommentsModifiedSince sends the fonero plugin commentsmodifiedsince
+// command to the cache and returns the IDs of the comments of the passed in
+// proposal that were created, edited or censored after the passed in
+// timestamp along with the comment likes that were added after the timestamp.
+func (p *politeiawww) foneroCommentsModifiedSince(token string, since int64) (*foneroplugin.CommentsModifiedSinceReply, error) {
+	payload, err := foneroplugin.EncodeCommentsModifiedSince(
+		foneroplugin.CommentsModifiedSince{
+			Token:     token,
+			Timestamp: since,
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	pc := cache.PluginCommand{
+		ID:             foneroplugin.ID,
+		Command:        foneroplugin.CmdCommentsModifiedSince,
+		CommandPayload: string(payload),
+	}
+
+	reply, err := p.cache.PluginExec(pc)
+	if err != nil {
+		return nil, err
+	}
+
+	return foneroplugin.DecodeCommentsModifiedSinceReply(
+		[]byte(reply.Payload))
+}
+
+// foneroGetComments sends the fonero plugin getcomments command to the cache
+// and returns all of the comments for the passed in proposal token.  Hidden
+// comments are not returned.
+func (p *politeiawww) foneroGetComments(token string) ([]foneroplugin.Comment, error) {
+	return p.foneroGetCommentsPage(token, 0, 0, false)
+}
+
+// foneroGetCommentsPage sends the fonero plugin getcomments command to the
+// cache and returns the requested page of comments of the passed in proposal.
+// A limit of zero returns all comments after the offset.  Hidden comments are
+// only returned when includeHidden is set.
+func (p *politeiawww) foneroGetCommentsPage(token string, offset, limit uint32, includeHidden bool) ([]foneroplugin.Comment, error) {
 	// Setup plugin command
-	vr := foneroplugin.VoteResults{
-		Token: token,
+	gc := foneroplugin.GetComments{
+		Token:         token,
+		Offset:        offset,
+		Limit:         limit,
+		IncludeHidden: includeHidden,
 	}
 
-	payload, err := foneroplugin.EncodeVoteResults(vr)
+	payload, err := foneroplugin.EncodeGetComments(gc)
 	if err != nil {
 		return nil, err
 	}
 
 	pc := cache.PluginCommand{
 		ID:             foneroplugin.ID,
-		Command:        foneroplugin.CmdProposalVotes,
+		Command:        foneroplugin.CmdGetComments,
 		CommandPayload: string(payload),
 	}
 
-	// Get proposal votes from cache
+	// Get comments from the cache
 	reply, err := p.cache.PluginExec(pc)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("PluginExec: %v", err)
 	}
 
-	vrr, err := foneroplugin.DecodeVoteResultsReply([]byte(reply.Payload))
+	gcr, err := foneroplugin.DecodeGetCommentsReply([]byte(reply.Payload))
 	if err != nil {
 		return nil, err
 	}
 
-	return vrr, nil
+	return gcr.Comments, nil
 }
 
-// foneroInventory sends the fonero plugin inventory command to the cache and
-// returns the fonero plugin inventory.
-func (p *politeiawww) foneroInventory() (*foneroplugin.InventoryReply, error) {
-	// Setup plugin command
-	i := foneroplugin.Inventory{}
-	payload, err := foneroplugin.EncodeInventory(i)
+// foneroGetCommentsForProposals sends the fonero plugin
+// getcommentsforproposals command to the cache and returns the most recent
+// comments of each of the passed in proposals, grouped by token.  A limit of
+// zero returns all comments of each proposal.  Hidden comments are not
+// returned and censored comments are only returned when excludeCensored is not
+// set.
+func (p *politeiawww) foneroGetCommentsForProposals(tokens []string, limit uint32, excludeCensored bool) (map[string][]foneroplugin.Comment, error) {
+	payload, err := foneroplugin.EncodeGetCommentsForProposals(
+		foneroplugin.GetCommentsForProposals{
+			Tokens:          tokens,
+			Limit:           limit,
+			ExcludeCensored: excludeCensored,
+		})
 	if err != nil {
 		return nil, err
 	}
 
 	pc := cache.PluginCommand{
 		ID:             foneroplugin.ID,
-		Command:        foneroplugin.CmdInventory,
+		Command:        foneroplugin.CmdGetCommentsForProposals,
 		CommandPayload: string(payload),
 	}
 
-	// Get cache inventory
 	reply, err := p.cache.PluginExec(pc)
+	if err != nil {
+		return nil, fmt.Errorf("PluginExec: %v", err)
+	}
+
+	gcfpr, err := foneroplugin.DecodeGetCommentsForProposalsReply(
+		[]byte(reply.Payload))
 	if err != nil {
 		return nil, err
 	}
 
-	ir, err := foneroplugin.DecodeInventoryReply([]byte(reply.Payload))
+	return gcfpr.Comments, nil
+}
+
+// foneroCommentCounts returns the number of comments of each of the passed in
+// proposals, keyed by token.  The comments are counted the same way as by
+// foneroGetComments, but the comments of all of the proposals are fetched
+// using a single getcommentsforproposals cache command.
+func (p *politeiawww) foneroCommentCounts(tokens []string) (map[string]uint, error) {
+	comments, err := p.foneroGetCommentsForProposals(tokens, 0, false)
 	if err != nil {
 		return nil, err
 	}
 
-	return ir, nil
+	counts := make(map[string]uint, len(comments)) // [token]numComments
+	for token, v := range comments {
+		counts[token] = uint(len(v))
+	}
+
+	return counts, nil
 }
 
-// foneroTokenInventory sends the fonero plugin tokeninventory command to the
-// cache.
-func (p *politeiawww) foneroTokenInventory(bestBlock uint64) (*foneroplugin.TokenInventoryReply, error) {
-	payload, err := foneroplugin.EncodeTokenInventory(
-		foneroplugin.TokenInventory{
-			BestBlock: bestBlock,
+// foneroCommentedProposals sends the fonero plugin commentedproposals command
+// to the cache and returns the proposals that the passed in public key has
+// commented on along with the number of comments the key made on each
+// proposal, sorted by token.
+func (p *politeiawww) foneroCommentedProposals(publicKey string) ([]foneroplugin.CommentedProposal, error) {
+	payload, err := foneroplugin.EncodeCommentedProposals(
+		foneroplugin.CommentedProposals{
+			PublicKey: publicKey,
 		})
 	if err != nil {
 		return nil, err
@@ -262,102 +384,1729 @@ func (p *politeiawww) foneroTokenInventory(bestBlock uint64) (*foneroplugin.Toke
 
 	pc := cache.PluginCommand{
 		ID:             foneroplugin.ID,
-		Command:        foneroplugin.CmdTokenInventory,
+		Command:        foneroplugin.CmdCommentedProposals,
 		CommandPayload: string(payload),
 	}
 
 	reply, err := p.cache.PluginExec(pc)
+	if err != nil {
+		return nil, fmt.Errorf("PluginExec: %v", err)
+	}
+
+	cpr, err := foneroplugin.DecodeCommentedProposalsReply(
+		[]byte(reply.Payload))
 	if err != nil {
 		return nil, err
 	}
 
-	tir, err := foneroplugin.DecodeTokenInventoryReply([]byte(reply.Payload))
+	return cpr.Proposals, nil
+}
+
+// foneroTopComments sends the fonero plugin topcomments command to the cache
+// and returns the uncensored comments of the passed in proposal with the
+// highest net like score.  A limit of zero returns all uncensored comments.
+func (p *politeiawww) foneroTopComments(token string, limit uint32) ([]foneroplugin.Comment, error) {
+	tc := foneroplugin.TopComments{
+		Token: token,
+		Limit: limit,
+	}
+	payload, err := foneroplugin.EncodeTopComments(tc)
 	if err != nil {
 		return nil, err
 	}
 
-	return tir, nil
+	pc := cache.PluginCommand{
+		ID:             foneroplugin.ID,
+		Command:        foneroplugin.CmdTopComments,
+		CommandPayload: string(payload),
+	}
+
+	resp, err := p.cache.PluginExec(pc)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := foneroplugin.DecodeTopCommentsReply([]byte(resp.Payload))
+	if err != nil {
+		return nil, err
+	}
+
+	return reply.Comments, nil
+}
+
+// foneroStreamComments writes all comments of the passed in proposal to the
+// passed in writer as newline delimited JSON, one comment per line.  The
+// comments are requested from the cache one page at a time so that they do not
+// have to be held in memory all at once.  Censored and hidden comments are
+// included so that the output is a complete dump of the comments of the
+// proposal.
+func (p *politeiawww) foneroStreamComments(w io.Writer, token string) error {
+	enc := json.NewEncoder(w)
+	for offset := uint32(0); ; offset += streamCommentsPageSize {
+		comments, err := p.foneroGetCommentsPage(token, offset,
+			streamCommentsPageSize, true)
+		if err != nil {
+			return err
+		}
+
+		for _, v := range comments {
+			err := enc.Encode(v)
+			if err != nil {
+				return fmt.Errorf("encode comment %v: %v",
+					v.CommentID, err)
+			}
+		}
+
+		if len(comments) < streamCommentsPageSize {
+			return nil
+		}
+	}
 }
 
-// foneroLoadVoteResults sends the loadvotesummaries command to politeiad.
-func (p *politeiawww) foneroLoadVoteResults(bestBlock uint64) (*foneroplugin.LoadVoteResultsReply, error) {
+// foneroCensoredComments sends the fonero plugin censoredcomments command to
+// the cache and returns the censored comments.  If token is an empty string,
+// the censored comments for all records are returned.
+func (p *politeiawww) foneroCensoredComments(token string) ([]foneroplugin.Comment, error) {
 	// Setup plugin command
-	challenge, err := util.Random(pd.ChallengeSize)
+	cc := foneroplugin.CensoredComments{
+		Token: token,
+	}
+
+	payload, err := foneroplugin.EncodeCensoredComments(cc)
 	if err != nil {
 		return nil, err
 	}
 
-	lvr := foneroplugin.LoadVoteResults{
-		BestBlock: bestBlock,
+	pc := cache.PluginCommand{
+		ID:             foneroplugin.ID,
+		Command:        foneroplugin.CmdCensoredComments,
+		CommandPayload: string(payload),
 	}
-	payload, err := foneroplugin.EncodeLoadVoteResults(lvr)
+
+	// Get censored comments from the cache
+	reply, err := p.cache.PluginExec(pc)
 	if err != nil {
 		return nil, err
 	}
 
-	pc := pd.PluginCommand{
-		Challenge: hex.EncodeToString(challenge),
-		ID:        foneroplugin.ID,
-		Command:   foneroplugin.CmdLoadVoteResults,
-		CommandID: foneroplugin.CmdLoadVoteResults,
-		Payload:   string(payload),
+	ccr, err := foneroplugin.DecodeCensoredCommentsReply([]byte(reply.Payload))
+	if err != nil {
+		return nil, err
 	}
 
-	// Send plugin command to politeiad
-	respBody, err := p.makeRequest(http.MethodPost,
-		pd.PluginCommandRoute, pc)
+	return ccr.Comments, nil
+}
+
+// foneroReparentComment sends the fonero plugin reparentcomment command to the
+// cache and returns the comment with its updated parent.
+func (p *politeiawww) foneroReparentComment(token, commentID, parentID string) (*foneroplugin.Comment, error) {
+	// Setup plugin command
+	rc := foneroplugin.ReparentComment{
+		Token:     token,
+		CommentID: commentID,
+		ParentID:  parentID,
+	}
+
+	payload, err := foneroplugin.EncodeReparentComment(rc)
 	if err != nil {
 		return nil, err
 	}
 
-	// Handle response
-	var pcr pd.PluginCommandReply
-	err = json.Unmarshal(respBody, &pcr)
+	pc := cache.PluginCommand{
+		ID:             foneroplugin.ID,
+		Command:        foneroplugin.CmdReparentComment,
+		CommandPayload: string(payload),
+	}
+
+	// Reparent comment in the cache
+	reply, err := p.cache.PluginExec(pc)
 	if err != nil {
 		return nil, err
 	}
 
-	err = util.VerifyChallenge(p.cfg.Identity, challenge, pcr.Response)
+	rcr, err := foneroplugin.DecodeReparentCommentReply([]byte(reply.Payload))
 	if err != nil {
 		return nil, err
 	}
 
-	b := []byte(pcr.Payload)
-	reply, err := foneroplugin.DecodeLoadVoteResultsReply(b)
+	return &rcr.Comment, nil
+}
+
+// foneroCommentLikes sends the fonero plugin commentlikes command to the cache
+// and returns all of the comment likes for the passed in comment.
+func (p *politeiawww) foneroCommentLikes(token, commentID string) ([]foneroplugin.LikeComment, error) {
+	// Setup plugin command
+	cl := foneroplugin.CommentLikes{
+		Token:     token,
+		CommentID: commentID,
+	}
+
+	payload, err := foneroplugin.EncodeCommentLikes(cl)
 	if err != nil {
-		spew.Dump("here")
 		return nil, err
 	}
 
-	return reply, nil
-}
+	pc := cache.PluginCommand{
+		ID:             foneroplugin.ID,
+		Command:        foneroplugin.CmdCommentLikes,
+		CommandPayload: string(payload),
+	}
 
-// foneroVoteSummary uses the fonero plugin vote summary command to request a
-// vote summary for a specific proposal from the cache.
-func (p *politeiawww) foneroVoteSummary(token string) (*foneroplugin.VoteSummaryReply, error) {
-	v := foneroplugin.VoteSummary{
-		Token: token,
+	// Get comment likes from cache
+	reply, err := p.cache.PluginExec(pc)
+	if err != nil {
+		return nil, err
 	}
-	payload, err := foneroplugin.EncodeVoteSummary(v)
+
+	clr, err := foneroplugin.DecodeCommentLikesReply([]byte(reply.Payload))
+	if err != nil {
+		return nil, err
+	}
+
+	return clr.CommentLikes, nil
+}
+
+// foneroCurrentCommentLikes sends the fonero plugin commentlikes command to the
+// cache and returns the current like of each public key on the passed in
+// comment.  Likes that have been undone are not returned.
+func (p *politeiawww) foneroCurrentCommentLikes(token, commentID string) ([]foneroplugin.LikeComment, error) {
+	payload, err := foneroplugin.EncodeCommentLikes(
+		foneroplugin.CommentLikes{
+			Token:     token,
+			CommentID: commentID,
+		})
 	if err != nil {
 		return nil, err
 	}
 
 	pc := cache.PluginCommand{
 		ID:             foneroplugin.ID,
-		Command:        foneroplugin.CmdVoteSummary,
+		Command:        foneroplugin.CmdCommentLikes,
 		CommandPayload: string(payload),
 	}
 
-	resp, err := p.cache.PluginExec(pc)
+	reply, err := p.cache.PluginExec(pc)
 	if err != nil {
 		return nil, err
 	}
 
-	reply, err := foneroplugin.DecodeVoteSummaryReply([]byte(resp.Payload))
+	clr, err := foneroplugin.DecodeCommentLikesReply([]byte(reply.Payload))
 	if err != nil {
 		return nil, err
 	}
 
-	return reply, nil
+	return clr.Current, nil
+}
+
+// foneroPropCommentLikes sends the fonero plugin proposalcommentslikes command
+// to the cache and returns all of the comment likes for the passed in proposal
+// token.
+func (p *politeiawww) foneroPropCommentLikes(token string) ([]foneroplugin.LikeComment, error) {
+	// Setup plugin command
+	pcl := foneroplugin.GetProposalCommentsLikes{
+		Token: token,
+	}
+
+	payload, err := foneroplugin.EncodeGetProposalCommentsLikes(pcl)
+	if err != nil {
+		return nil, err
+	}
+
+	pc := cache.PluginCommand{
+		ID:             foneroplugin.ID,
+		Command:        foneroplugin.CmdProposalCommentsLikes,
+		CommandPayload: string(payload),
+	}
+
+	// Get proposal comment likes from cache
+	reply, err := p.cache.PluginExec(pc)
+	if err != nil {
+		return nil, err
+	}
+
+	rp := []byte(reply.Payload)
+	pclr, err := foneroplugin.DecodeGetProposalCommentsLikesReply(rp)
+	if err != nil {
+		return nil, err
+	}
+
+	return pclr.CommentsLikes, nil
+}
+
+// foneroPropCommentLikeCounts sends the fonero plugin proposal comments like
+// counts command to the cache and returns the aggregated upvote and downvote
+// counts of each comment of the specified proposal.
+func (p *politeiawww) foneroPropCommentLikeCounts(token string) ([]foneroplugin.CommentLikeCounts, error) {
+	// Setup plugin command
+	g := foneroplugin.GetProposalCommentsLikeCounts{
+		Token: token,
+	}
+
+	payload, err := foneroplugin.EncodeGetProposalCommentsLikeCounts(g)
+	if err != nil {
+		return nil, err
+	}
+
+	pc := cache.PluginCommand{
+		ID:             foneroplugin.ID,
+		Command:        foneroplugin.CmdProposalCommentsLikeCounts,
+		CommandPayload: string(payload),
+	}
+
+	// Get proposal comment like counts from cache
+	reply, err := p.cache.PluginExec(pc)
+	if err != nil {
+		return nil, err
+	}
+
+	rp := []byte(reply.Payload)
+	gr, err := foneroplugin.DecodeGetProposalCommentsLikeCountsReply(rp)
+	if err != nil {
+		return nil, err
+	}
+
+	return gr.LikeCounts, nil
+}
+
+// foneroCommentLikesNetScore sends the fonero plugin commentlikesnetscore
+// command to the cache and returns the net like score of each of the passed in
+// comments of the specified proposal, keyed by comment ID.  Comments without
+// any likes have a score of zero.
+func (p *politeiawww) foneroCommentLikesNetScore(token string, commentIDs []string) (map[string]int64, error) {
+	payload, err := foneroplugin.EncodeCommentLikesNetScore(
+		foneroplugin.CommentLikesNetScore{
+			Token:      token,
+			CommentIDs: commentIDs,
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	pc := cache.PluginCommand{
+		ID:             foneroplugin.ID,
+		Command:        foneroplugin.CmdCommentLikesNetScore,
+		CommandPayload: string(payload),
+	}
+
+	reply, err := p.cache.PluginExec(pc)
+	if err != nil {
+		return nil, err
+	}
+
+	clr, err := foneroplugin.DecodeCommentLikesNetScoreReply(
+		[]byte(reply.Payload))
+	if err != nil {
+		return nil, err
+	}
+
+	return clr.Scores, nil
+}
+
+// foneroVoteDetails sends the fonero plugin votedetails command to the cache
+// and returns the vote details for the passed in proposal.
+func (p *politeiawww) foneroVoteDetails(token string) (*foneroplugin.VoteDetailsReply, error) {
+	// Setup plugin command
+	vd := foneroplugin.VoteDetails{
+		Token: token,
+	}
+
+	payload, err := foneroplugin.EncodeVoteDetails(vd)
+	if err != nil {
+		return nil, err
+	}
+
+	pc := cache.PluginCommand{
+		ID:             foneroplugin.ID,
+		Command:        foneroplugin.CmdVoteDetails,
+		CommandPayload: string(payload),
+	}
+
+	// Get vote details from cache
+	reply, err := p.cache.PluginExec(pc)
+	if err != nil {
+		return nil, err
+	}
+
+	vdr, err := foneroplugin.DecodeVoteDetailsReply([]byte(reply.Payload))
+	if err != nil {
+		return nil, err
+	}
+
+	return vdr, nil
+}
+
+// foneroGetStartVoteReply sends the fonero plugin getstartvotereply command to
+// the cache and returns the StartVoteReply of the passed in proposal.  A
+// cache.ErrRecordNotFound is returned if the vote of the proposal has not been
+// started.
+func (p *politeiawww) foneroGetStartVoteReply(token string) (*foneroplugin.StartVoteReply, error) {
+	payload, err := foneroplugin.EncodeGetStartVoteReply(
+		foneroplugin.GetStartVoteReply{
+			Token: token,
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	pc := cache.PluginCommand{
+		ID:             foneroplugin.ID,
+		Command:        foneroplugin.CmdGetStartVoteReply,
+		CommandPayload: string(payload),
+	}
+
+	reply, err := p.cache.PluginExec(pc)
+	if err != nil {
+		return nil, err
+	}
+
+	return foneroplugin.DecodeStartVoteReply([]byte(reply.Payload))
+}
+
+// foneroGetVoteOptions sends the fonero plugin getvoteoptions command to the
+// cache and returns the vote options and the vote mask of the passed in
+// proposal.  A cache.ErrRecordNotFound is returned if the vote of the proposal
+// has not been started.
+func (p *politeiawww) foneroGetVoteOptions(token string) (*foneroplugin.GetVoteOptionsReply, error) {
+	payload, err := foneroplugin.EncodeGetVoteOptions(
+		foneroplugin.GetVoteOptions{
+			Token: token,
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	pc := cache.PluginCommand{
+		ID:             foneroplugin.ID,
+		Command:        foneroplugin.CmdGetVoteOptions,
+		CommandPayload: string(payload),
+	}
+
+	reply, err := p.cache.PluginExec(pc)
+	if err != nil {
+		return nil, err
+	}
+
+	return foneroplugin.DecodeGetVoteOptionsReply([]byte(reply.Payload))
+}
+
+// foneroGetEligibleTicketCount sends the fonero plugin geteligibleticketcount
+// command to the cache and returns the number of tickets that are eligible to
+// vote on the passed in proposal.  A cache.ErrRecordNotFound is returned if the
+// vote of the proposal has not been started.
+func (p *politeiawww) foneroGetEligibleTicketCount(token string) (int, error) {
+	payload, err := foneroplugin.EncodeGetEligibleTicketCount(
+		foneroplugin.GetEligibleTicketCount{
+			Token: token,
+		})
+	if err != nil {
+		return 0, err
+	}
+
+	pc := cache.PluginCommand{
+		ID:             foneroplugin.ID,
+		Command:        foneroplugin.CmdGetEligibleTicketCount,
+		CommandPayload: string(payload),
+	}
+
+	reply, err := p.cache.PluginExec(pc)
+	if err != nil {
+		return 0, err
+	}
+
+	g, err := foneroplugin.DecodeGetEligibleTicketCountReply(
+		[]byte(reply.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	return g.EligibleTicketCount, nil
+}
+
+// foneroProposalActivityTimeline sends the fonero plugin
+// proposalactivitytimeline command to the cache and returns the comments,
+// comment likes, authorize votes, start vote, and cast votes of the passed in
+// proposal as a single stream of events that is ordered by timestamp.
+func (p *politeiawww) foneroProposalActivityTimeline(token string) ([]foneroplugin.TimelineEvent, error) {
+	payload, err := foneroplugin.EncodeProposalActivityTimeline(
+		foneroplugin.ProposalActivityTimeline{
+			Token: token,
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	pc := cache.PluginCommand{
+		ID:             foneroplugin.ID,
+		Command:        foneroplugin.CmdProposalActivityTimeline,
+		CommandPayload: string(payload),
+	}
+
+	reply, err := p.cache.PluginExec(pc)
+	if err != nil {
+		return nil, err
+	}
+
+	pat, err := foneroplugin.DecodeProposalActivityTimelineReply(
+		[]byte(reply.Payload))
+	if err != nil {
+		return nil, err
+	}
+
+	return pat.Events, nil
+}
+
+// foneroVoteResultsByStatus sends the fonero plugin voteresultsbystatus
+// command to the cache and returns the final vote results of all approved
+// proposals or, when approved is false, of all rejected proposals.
+func (p *politeiawww) foneroVoteResultsByStatus(approved bool) ([]foneroplugin.TokenVoteResults, error) {
+	payload, err := foneroplugin.EncodeVoteResultsByStatus(
+		foneroplugin.VoteResultsByStatus{
+			Approved: approved,
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	pc := cache.PluginCommand{
+		ID:             foneroplugin.ID,
+		Command:        foneroplugin.CmdVoteResultsByStatus,
+		CommandPayload: string(payload),
+	}
+
+	reply, err := p.cache.PluginExec(pc)
+	if err != nil {
+		return nil, err
+	}
+
+	vrbs, err := foneroplugin.DecodeVoteResultsByStatusReply(
+		[]byte(reply.Payload))
+	if err != nil {
+		return nil, err
+	}
+
+	return vrbs.VoteResults, nil
+}
+
+// foneroVoteResultsDigest sends the fonero plugin voteresultsdigest command to
+// the cache and returns the vote results digest of the passed in proposal.  A
+// cache.ErrRecordNotFound is returned if the vote results of the proposal have
+// not been loaded.
+func (p *politeiawww) foneroVoteResultsDigest(token string) (string, error) {
+	payload, err := foneroplugin.EncodeVoteResultsDigest(
+		foneroplugin.VoteResultsDigest{
+			Token: token,
+		})
+	if err != nil {
+		return "", err
+	}
+
+	pc := cache.PluginCommand{
+		ID:             foneroplugin.ID,
+		Command:        foneroplugin.CmdVoteResultsDigest,
+		CommandPayload: string(payload),
+	}
+
+	reply, err := p.cache.PluginExec(pc)
+	if err != nil {
+		return "", err
+	}
+
+	vrdr, err := foneroplugin.DecodeVoteResultsDigestReply(
+		[]byte(reply.Payload))
+	if err != nil {
+		return "", err
+	}
+
+	return vrdr.Digest, nil
+}
+
+// foneroProposalVotes sends the fonero plugin proposalvotes command to the
+// cache and returns the vote results for the passed in proposal.  If tallyOnly
+// is set, the number of votes cast for each vote option is returned instead of
+// the cast votes.  The cast votes are ordered by ticket and can be paged
+// through using offset and limit.  A limit of zero returns all cast votes
+// after the offset.  The started field of the reply distinguishes a vote that
+// has not been started from a started vote without any cast votes.
+func (p *politeiawww) foneroProposalVotes(token string, tallyOnly bool, offset, limit uint32) (*foneroplugin.VoteResultsReply, error) {
+	// Setup plugin command
+	vr := foneroplugin.VoteResults{
+		Token:     token,
+		TallyOnly: tallyOnly,
+		Offset:    offset,
+		Limit:     limit,
+	}
+
+	payload, err := foneroplugin.EncodeVoteResults(vr)
+	if err != nil {
+		return nil, err
+	}
+
+	pc := cache.PluginCommand{
+		ID:             foneroplugin.ID,
+		Command:        foneroplugin.CmdProposalVotes,
+		CommandPayload: string(payload),
+	}
+
+	// Get proposal votes from cache
+	reply, err := p.cache.PluginExec(pc)
+	if err != nil {
+		return nil, err
+	}
+
+	vrr, err := foneroplugin.DecodeVoteResultsReply([]byte(reply.Payload))
+	if err != nil {
+		return nil, err
+	}
+
+	return vrr, nil
+}
+
+// foneroProposalVoteReceipts sends the fonero plugin proposalvotereceipts
+// command to the cache and returns the cast votes of the passed in proposal
+// along with the receipts that the server returned for them, ordered by
+// ticket.  If a ticket is provided, only the vote cast by that ticket is
+// returned.
+func (p *politeiawww) foneroProposalVoteReceipts(token, ticket string) ([]foneroplugin.VoteReceipt, error) {
+	payload, err := foneroplugin.EncodeProposalVoteReceipts(
+		foneroplugin.ProposalVoteReceipts{
+			Token:  token,
+			Ticket: ticket,
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	pc := cache.PluginCommand{
+		ID:             foneroplugin.ID,
+		Command:        foneroplugin.CmdProposalVoteReceipts,
+		CommandPayload: string(payload),
+	}
+
+	reply, err := p.cache.PluginExec(pc)
+	if err != nil {
+		return nil, err
+	}
+
+	pvrr, err := foneroplugin.DecodeProposalVoteReceiptsReply(
+		[]byte(reply.Payload))
+	if err != nil {
+		return nil, err
+	}
+
+	return pvrr.Receipts, nil
+}
+
+// foneroProposalVotesAfter sends the fonero plugin proposalvotes command to the
+// cache and returns up to limit cast votes of the passed in proposal whose
+// tickets sort after the passed in ticket, ordered by ticket.
+func (p *politeiawww) foneroProposalVotesAfter(token, afterTicket string, limit uint32) (*foneroplugin.VoteResultsReply, error) {
+	payload, err := foneroplugin.EncodeVoteResults(
+		foneroplugin.VoteResults{
+			Token:       token,
+			Limit:       limit,
+			AfterTicket: afterTicket,
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	pc := cache.PluginCommand{
+		ID:             foneroplugin.ID,
+		Command:        foneroplugin.CmdProposalVotes,
+		CommandPayload: string(payload),
+	}
+
+	reply, err := p.cache.PluginExec(pc)
+	if err != nil {
+		return nil, err
+	}
+
+	vrr, err := foneroplugin.DecodeVoteResultsReply([]byte(reply.Payload))
+	if err != nil {
+		return nil, err
+	}
+
+	return vrr, nil
+}
+
+// foneroStreamProposalVotesCSV writes the cast votes of the passed in proposal
+// to the passed in writer as CSV.  The first row is a header row followed by
+// one row per cast vote, ordered by ticket.  The cast votes are requested from
+// the cache one page at a time, using the last ticket of each page as the
+// cursor of the next page, so that they do not have to be held in memory all
+// at once and so that votes cast during the stream do not shift the pages.
+// The option column is left empty for vote bits that do not match any of the
+// vote options.
+func (p *politeiawww) foneroStreamProposalVotesCSV(w io.Writer, token string) error {
+	cw := csv.NewWriter(w)
+	err := cw.Write([]string{"ticket", "votebit", "option", "signature"})
+	if err != nil {
+		return err
+	}
+
+	var (
+		options map[string]string // [voteBit]optionID
+		last    string            // Ticket of the last written vote
+	)
+	for {
+		vrr, err := p.foneroProposalVotesAfter(token, last,
+			streamVotesPageSize)
+		if err != nil {
+			return err
+		}
+
+		if options == nil {
+			options = make(map[string]string,
+				len(vrr.StartVote.Vote.Options))
+			for _, v := range vrr.StartVote.Vote.Options {
+				options[strconv.FormatUint(v.Bits, 16)] = v.Id
+			}
+		}
+
+		for _, v := range vrr.CastVotes {
+			err := cw.Write([]string{v.Ticket, v.VoteBit,
+				options[v.VoteBit], v.Signature})
+			if err != nil {
+				return fmt.Errorf("write vote %v: %v", v.Ticket, err)
+			}
+			last = v.Ticket
+		}
+
+		// Flush every page so that the rows are not buffered
+		// until the end of the stream.
+		cw.Flush()
+		err = cw.Error()
+		if err != nil {
+			return err
+		}
+
+		if len(vrr.CastVotes) < streamVotesPageSize {
+			return nil
+		}
+	}
+}
+
+// foneroVoteExport sends the fonero plugin voteexport command to the cache and
+// returns the full vote lifecycle of the specified proposal.
+func (p *politeiawww) foneroVoteExport(token string) (*foneroplugin.VoteExportReply, error) {
+	payload, err := foneroplugin.EncodeVoteExport(
+		foneroplugin.VoteExport{
+			Token: token,
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	pc := cache.PluginCommand{
+		ID:             foneroplugin.ID,
+		Command:        foneroplugin.CmdVoteExport,
+		CommandPayload: string(payload),
+	}
+
+	reply, err := p.cache.PluginExec(pc)
+	if err != nil {
+		return nil, err
+	}
+
+	ver, err := foneroplugin.DecodeVoteExportReply([]byte(reply.Payload))
+	if err != nil {
+		return nil, err
+	}
+
+	return ver, nil
+}
+
+// foneroVoteEligibility sends the fonero plugin voteeligibility command to the
+// cache and returns whether the passed in ticket is eligible to vote on the
+// passed in proposal and whether it has already voted.
+func (p *politeiawww) foneroVoteEligibility(token, ticket string) (*foneroplugin.VoteEligibilityReply, error) {
+	// Setup plugin command
+	ve := foneroplugin.VoteEligibility{
+		Token:  token,
+		Ticket: ticket,
+	}
+
+	payload, err := foneroplugin.EncodeVoteEligibility(ve)
+	if err != nil {
+		return nil, err
+	}
+
+	pc := cache.PluginCommand{
+		ID:             foneroplugin.ID,
+		Command:        foneroplugin.CmdVoteEligibility,
+		CommandPayload: string(payload),
+	}
+
+	// Get vote eligibility from cache
+	reply, err := p.cache.PluginExec(pc)
+	if err != nil {
+		return nil, err
+	}
+
+	ver, err := foneroplugin.DecodeVoteEligibilityReply([]byte(reply.Payload))
+	if err != nil {
+		return nil, err
+	}
+
+	return ver, nil
+}
+
+// foneroEligibleTickets sends the fonero plugin eligibletickets command to the
+// cache and returns the requested page of the tickets that are eligible to
+// vote on the passed in proposal.  A limit of zero returns all tickets after
+// the offset.
+func (p *politeiawww) foneroEligibleTickets(token string, offset, limit uint32) (*foneroplugin.EligibleTicketsReply, error) {
+	payload, err := foneroplugin.EncodeEligibleTickets(
+		foneroplugin.EligibleTickets{
+			Token:  token,
+			Offset: offset,
+			Limit:  limit,
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	pc := cache.PluginCommand{
+		ID:             foneroplugin.ID,
+		Command:        foneroplugin.CmdEligibleTickets,
+		CommandPayload: string(payload),
+	}
+
+	reply, err := p.cache.PluginExec(pc)
+	if err != nil {
+		return nil, err
+	}
+
+	return foneroplugin.DecodeEligibleTicketsReply([]byte(reply.Payload))
+}
+
+// foneroRecordTimestampRange sends the fonero plugin get record timestamp
+// range command to the cache and returns the tokens of all public records
+// whose most recent version falls within the provided timestamp range.
+func (p *politeiawww) foneroRecordTimestampRange(start, end int64) ([]string, error) {
+	// Setup plugin command
+	g := foneroplugin.GetRecordTimestampRange{
+		Start: start,
+		End:   end,
+	}
+
+	payload, err := foneroplugin.EncodeGetRecordTimestampRange(g)
+	if err != nil {
+		return nil, err
+	}
+
+	pc := cache.PluginCommand{
+		ID:             foneroplugin.ID,
+		Command:        foneroplugin.CmdGetRecordTimestampRange,
+		CommandPayload: string(payload),
+	}
+
+	// Get record tokens from cache
+	reply, err := p.cache.PluginExec(pc)
+	if err != nil {
+		return nil, err
+	}
+
+	gr, err := foneroplugin.DecodeGetRecordTimestampRangeReply(
+		[]byte(reply.Payload))
+	if err != nil {
+		return nil, err
+	}
+
+	return gr.Tokens, nil
+}
+
+// foneroRecordHistory sends the fonero plugin recordhistory command to the
+// cache and returns the version, status, and timestamp of every version of the
+// passed in record, ordered by version.
+func (p *politeiawww) foneroRecordHistory(token string) ([]foneroplugin.RecordHistoryVersion, error) {
+	rh := foneroplugin.RecordHistory{
+		Token: token,
+	}
+	payload, err := foneroplugin.EncodeRecordHistory(rh)
+	if err != nil {
+		return nil, err
+	}
+
+	pc := cache.PluginCommand{
+		ID:             foneroplugin.ID,
+		Command:        foneroplugin.CmdRecordHistory,
+		CommandPayload: string(payload),
+	}
+
+	resp, err := p.cache.PluginExec(pc)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := foneroplugin.DecodeRecordHistoryReply([]byte(resp.Payload))
+	if err != nil {
+		return nil, err
+	}
+
+	return reply.Versions, nil
+}
+
+// foneroGetProposalMetadata sends the fonero plugin getproposalmetadata
+// command to the cache and returns the metadata streams of the passed in
+// proposal version without its files.  The most recent version is used when
+// version is empty.
+func (p *politeiawww) foneroGetProposalMetadata(token, version string) (*foneroplugin.GetProposalMetadataReply, error) {
+	g := foneroplugin.GetProposalMetadata{
+		Token:   token,
+		Version: version,
+	}
+	payload, err := foneroplugin.EncodeGetProposalMetadata(g)
+	if err != nil {
+		return nil, err
+	}
+
+	pc := cache.PluginCommand{
+		ID:             foneroplugin.ID,
+		Command:        foneroplugin.CmdGetProposalMetadata,
+		CommandPayload: string(payload),
+	}
+
+	resp, err := p.cache.PluginExec(pc)
+	if err != nil {
+		return nil, err
+	}
+
+	return foneroplugin.DecodeGetProposalMetadataReply([]byte(resp.Payload))
+}
+
+// foneroRecordFilesManifest sends the fonero plugin recordfilesmanifest
+// command to the cache and returns the name, MIME type, digest and size of the
+// files of the passed in proposal version without the file payloads.  The most
+// recent version is used when version is empty.
+func (p *politeiawww) foneroRecordFilesManifest(token, version string) (*foneroplugin.RecordFilesManifestReply, error) {
+	rfm := foneroplugin.RecordFilesManifest{
+		Token:   token,
+		Version: version,
+	}
+	payload, err := foneroplugin.EncodeRecordFilesManifest(rfm)
+	if err != nil {
+		return nil, err
+	}
+
+	pc := cache.PluginCommand{
+		ID:             foneroplugin.ID,
+		Command:        foneroplugin.CmdRecordFilesManifest,
+		CommandPayload: string(payload),
+	}
+
+	resp, err := p.cache.PluginExec(pc)
+	if err != nil {
+		return nil, err
+	}
+
+	return foneroplugin.DecodeRecordFilesManifestReply([]byte(resp.Payload))
+}
+
+// foneroGetLatestRecordVersion sends the fonero plugin getlatestrecordversion
+// command to the cache and returns the version and status of the most recent
+// version of the passed in proposal.
+func (p *politeiawww) foneroGetLatestRecordVersion(token string) (*foneroplugin.GetLatestRecordVersionReply, error) {
+	payload, err := foneroplugin.EncodeGetLatestRecordVersion(
+		foneroplugin.GetLatestRecordVersion{
+			Token: token,
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	pc := cache.PluginCommand{
+		ID:             foneroplugin.ID,
+		Command:        foneroplugin.CmdGetLatestRecordVersion,
+		CommandPayload: string(payload),
+	}
+
+	resp, err := p.cache.PluginExec(pc)
+	if err != nil {
+		return nil, err
+	}
+
+	return foneroplugin.DecodeGetLatestRecordVersionReply([]byte(resp.Payload))
+}
+
+// foneroRecordsByStatus sends the fonero plugin recordsbystatus command to the
+// cache and returns the requested page of tokens of the records whose latest
+// version has the passed in status.  A limit of zero returns all tokens after
+// the offset.
+func (p *politeiawww) foneroRecordsByStatus(status pd.RecordStatusT, offset, limit uint32) ([]string, error) {
+	// Setup plugin command
+	rs := foneroplugin.RecordsByStatus{
+		Status: int(status),
+		Offset: offset,
+		Limit:  limit,
+	}
+
+	payload, err := foneroplugin.EncodeRecordsByStatus(rs)
+	if err != nil {
+		return nil, err
+	}
+
+	pc := cache.PluginCommand{
+		ID:             foneroplugin.ID,
+		Command:        foneroplugin.CmdRecordsByStatus,
+		CommandPayload: string(payload),
+	}
+
+	// Get record tokens from cache
+	reply, err := p.cache.PluginExec(pc)
+	if err != nil {
+		return nil, err
+	}
+
+	rsr, err := foneroplugin.DecodeRecordsByStatusReply([]byte(reply.Payload))
+	if err != nil {
+		return nil, err
+	}
+
+	return rsr.Tokens, nil
+}
+
+// foneroRecordStatusCounts sends the fonero plugin recordstatuscounts command
+// to the cache and returns the number of records whose latest version has each
+// record status.
+func (p *politeiawww) foneroRecordStatusCounts() (map[pd.RecordStatusT]uint64, error) {
+	payload, err := foneroplugin.EncodeRecordStatusCounts(
+		foneroplugin.RecordStatusCounts{})
+	if err != nil {
+		return nil, err
+	}
+
+	pc := cache.PluginCommand{
+		ID:             foneroplugin.ID,
+		Command:        foneroplugin.CmdRecordStatusCounts,
+		CommandPayload: string(payload),
+	}
+
+	reply, err := p.cache.PluginExec(pc)
+	if err != nil {
+		return nil, err
+	}
+
+	rsc, err := foneroplugin.DecodeRecordStatusCountsReply(
+		[]byte(reply.Payload))
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[pd.RecordStatusT]uint64, len(rsc.Counts))
+	for k, v := range rsc.Counts {
+		counts[pd.RecordStatusT(k)] = v
+	}
+
+	return counts, nil
+}
+
+// foneroArchiveStats sends the fonero plugin archivestats command to the cache
+// and returns the number of abandoned proposals, the total number of comments
+// on them, and their average age when they were abandoned.
+func (p *politeiawww) foneroArchiveStats() (*foneroplugin.ArchiveStatsReply, error) {
+	payload, err := foneroplugin.EncodeArchiveStats(
+		foneroplugin.ArchiveStats{})
+	if err != nil {
+		return nil, err
+	}
+
+	pc := cache.PluginCommand{
+		ID:             foneroplugin.ID,
+		Command:        foneroplugin.CmdArchiveStats,
+		CommandPayload: string(payload),
+	}
+
+	reply, err := p.cache.PluginExec(pc)
+	if err != nil {
+		return nil, err
+	}
+
+	return foneroplugin.DecodeArchiveStatsReply([]byte(reply.Payload))
+}
+
+// foneroActivityWindow sends the fonero plugin activitywindow command to the
+// cache and returns the number of comments and cast votes of every proposal
+// that had activity between the start and end timestamps, inclusive.
+func (p *politeiawww) foneroActivityWindow(start, end int64) ([]foneroplugin.ProposalActivity, error) {
+	payload, err := foneroplugin.EncodeActivityWindow(
+		foneroplugin.ActivityWindow{
+			Start: start,
+			End:   end,
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	pc := cache.PluginCommand{
+		ID:             foneroplugin.ID,
+		Command:        foneroplugin.CmdActivityWindow,
+		CommandPayload: string(payload),
+	}
+
+	reply, err := p.cache.PluginExec(pc)
+	if err != nil {
+		return nil, err
+	}
+
+	awr, err := foneroplugin.DecodeActivityWindowReply([]byte(reply.Payload))
+	if err != nil {
+		return nil, err
+	}
+
+	return awr.Activity, nil
+}
+
+// foneroCommentRate sends the fonero plugin commentrate command to the cache
+// and returns the number of comments of a proposal that were received within
+// the passed in window, in seconds, along with the total number of comments of
+// the proposal.
+func (p *politeiawww) foneroCommentRate(token string, window int64) (*foneroplugin.CommentRateReply, error) {
+	payload, err := foneroplugin.EncodeCommentRate(
+		foneroplugin.CommentRate{
+			Token:  token,
+			Window: window,
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	pc := cache.PluginCommand{
+		ID:             foneroplugin.ID,
+		Command:        foneroplugin.CmdCommentRate,
+		CommandPayload: string(payload),
+	}
+
+	reply, err := p.cache.PluginExec(pc)
+	if err != nil {
+		return nil, err
+	}
+
+	return foneroplugin.DecodeCommentRateReply([]byte(reply.Payload))
+}
+
+// foneroVerifyCounts sends the fonero plugin verifycounts command to the cache
+// and returns the difference between the cache counts and the passed in
+// politeiad counts of records, comments and cast votes.  A count diverges when
+// the absolute difference exceeds the threshold.
+func (p *politeiawww) foneroVerifyCounts(records, comments, castVotes, threshold uint64) (*foneroplugin.VerifyCountsReply, error) {
+	payload, err := foneroplugin.EncodeVerifyCounts(
+		foneroplugin.VerifyCounts{
+			Records:   records,
+			Comments:  comments,
+			CastVotes: castVotes,
+			Threshold: threshold,
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	pc := cache.PluginCommand{
+		ID:             foneroplugin.ID,
+		Command:        foneroplugin.CmdVerifyCounts,
+		CommandPayload: string(payload),
+	}
+
+	reply, err := p.cache.PluginExec(pc)
+	if err != nil {
+		return nil, err
+	}
+
+	return foneroplugin.DecodeVerifyCountsReply([]byte(reply.Payload))
+}
+
+// foneroInventory sends the fonero plugin inventory command to the cache and
+// returns the fonero plugin inventory.
+func (p *politeiawww) foneroInventory() (*foneroplugin.InventoryReply, error) {
+	// Setup plugin command
+	i := foneroplugin.Inventory{}
+	payload, err := foneroplugin.EncodeInventory(i)
+	if err != nil {
+		return nil, err
+	}
+
+	pc := cache.PluginCommand{
+		ID:             foneroplugin.ID,
+		Command:        foneroplugin.CmdInventory,
+		CommandPayload: string(payload),
+	}
+
+	// Get cache inventory
+	reply, err := p.cache.PluginExec(pc)
+	if err != nil {
+		return nil, err
+	}
+
+	ir, err := foneroplugin.DecodeInventoryReply([]byte(reply.Payload))
+	if err != nil {
+		return nil, err
+	}
+
+	return ir, nil
+}
+
+// foneroTokenInventory sends the fonero plugin tokeninventory command to the
+// cache.  If countsOnly is set, only the number of records in each stage of
+// the voting process is returned.
+func (p *politeiawww) foneroTokenInventory(bestBlock uint64, countsOnly bool) (*foneroplugin.TokenInventoryReply, error) {
+	payload, err := foneroplugin.EncodeTokenInventory(
+		foneroplugin.TokenInventory{
+			BestBlock:  bestBlock,
+			CountsOnly: countsOnly,
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	pc := cache.PluginCommand{
+		ID:             foneroplugin.ID,
+		Command:        foneroplugin.CmdTokenInventory,
+		CommandPayload: string(payload),
+	}
+
+	reply, err := p.cache.PluginExec(pc)
+	if err != nil {
+		return nil, err
+	}
+
+	tir, err := foneroplugin.DecodeTokenInventoryReply([]byte(reply.Payload))
+	if err != nil {
+		return nil, err
+	}
+
+	return tir, nil
+}
+
+// foneroPendingVoteResults sends a dry run of the fonero plugin
+// loadvoteresults command to the cache and returns the tokens of the proposals
+// that have finished voting but have not yet been added to the vote results
+// cache table.
+func (p *politeiawww) foneroPendingVoteResults(bestBlock uint64) ([]string, error) {
+	payload, err := foneroplugin.EncodeLoadVoteResults(
+		foneroplugin.LoadVoteResults{
+			BestBlock: bestBlock,
+			DryRun:    true,
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	pc := cache.PluginCommand{
+		ID:             foneroplugin.ID,
+		Command:        foneroplugin.CmdLoadVoteResults,
+		CommandPayload: string(payload),
+	}
+
+	reply, err := p.cache.PluginExec(pc)
+	if err != nil {
+		return nil, err
+	}
+
+	lvr, err := foneroplugin.DecodeLoadVoteResultsReply([]byte(reply.Payload))
+	if err != nil {
+		return nil, err
+	}
+
+	return lvr.Tokens, nil
+}
+
+// foneroLoadVoteResultsChunk sends the loadvoteresults command to politeiad
+// for the provided tokens.  All pending proposals are loaded when no tokens
+// are provided.  The command is aborted if it does not complete within the
+// configured load vote results timeout.
+func (p *politeiawww) foneroLoadVoteResultsChunk(ctx context.Context, bestBlock uint64, tokens []string) (*foneroplugin.LoadVoteResultsReply, error) {
+	// Setup plugin command
+	challenge, err := util.Random(pd.ChallengeSize)
+	if err != nil {
+		return nil, err
+	}
+
+	lvr := foneroplugin.LoadVoteResults{
+		BestBlock: bestBlock,
+		Tokens:    tokens,
+	}
+	payload, err := foneroplugin.EncodeLoadVoteResults(lvr)
+	if err != nil {
+		return nil, err
+	}
+
+	pc := pd.PluginCommand{
+		Challenge: hex.EncodeToString(challenge),
+		ID:        foneroplugin.ID,
+		Command:   foneroplugin.CmdLoadVoteResults,
+		CommandID: foneroplugin.CmdLoadVoteResults,
+		Payload:   string(payload),
+	}
+
+	// Send plugin command to politeiad. Loading the vote results
+	// can take much longer than a regular politeiad request so it
+	// uses its own timeout.
+	if p.cfg.LoadVoteResultsTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx,
+			p.cfg.LoadVoteResultsTimeout)
+		defer cancel()
+	}
+	respBody, err := p.makeRequestContext(ctx, http.MethodPost,
+		pd.PluginCommandRoute, pc)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("loadvoteresults did not complete "+
+				"within %v", p.cfg.LoadVoteResultsTimeout)
+		}
+		return nil, err
+	}
+
+	// Handle response
+	var pcr pd.PluginCommandReply
+	err = json.Unmarshal(respBody, &pcr)
+	if err != nil {
+		return nil, err
+	}
+
+	err = util.VerifyChallenge(p.cfg.Identity, challenge, pcr.Response)
+	if err != nil {
+		return nil, err
+	}
+
+	b := []byte(pcr.Payload)
+	reply, err := foneroplugin.DecodeLoadVoteResultsReply(b)
+	if err != nil {
+		return nil, err
+	}
+
+	return reply, nil
+}
+
+// foneroLoadVoteResults loads the vote results of all proposals that have
+// finished voting but have not yet been added to the vote results cache table.
+// Small sets of pending proposals are loaded using a single politeiad command.
+// Larger sets are loaded in chunks of loadVoteResultsChunkSize proposals so
+// that progress can be reported while the load is running.  The load is
+// aborted when the passed in context is cancelled.
+func (p *politeiawww) foneroLoadVoteResults(ctx context.Context, bestBlock uint64) error {
+	tokens, err := p.foneroPendingVoteResults(bestBlock)
+	if err != nil {
+		return fmt.Errorf("pending vote results: %v", err)
+	}
+
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	// Load small sets using a single command
+	if len(tokens) <= loadVoteResultsChunkSize {
+		_, err := p.foneroLoadVoteResultsChunk(ctx, bestBlock, nil)
+		return err
+	}
+
+	log.Infof("Loading vote results for %v proposals", len(tokens))
+
+	for i := 0; i < len(tokens); i += loadVoteResultsChunkSize {
+		end := i + loadVoteResultsChunkSize
+		if end > len(tokens) {
+			end = len(tokens)
+		}
+
+		_, err := p.foneroLoadVoteResultsChunk(ctx, bestBlock,
+			tokens[i:end])
+		if err != nil {
+			return fmt.Errorf("load vote results %v-%v: %v", i, end, err)
+		}
+
+		log.Infof("Loaded vote results %v/%v", end, len(tokens))
+	}
+
+	return nil
+}
+
+// foneroExpiredVotes sends a dry run of the fonero plugin expireactivevotes
+// command to the cache and returns the tokens of the proposal votes that
+// finished after fromHeight and on or before bestBlock.
+func (p *politeiawww) foneroExpiredVotes(fromHeight, bestBlock uint64) (*foneroplugin.ExpireActiveVotesReply, error) {
+	payload, err := foneroplugin.EncodeExpireActiveVotes(
+		foneroplugin.ExpireActiveVotes{
+			BestBlock:  bestBlock,
+			FromHeight: fromHeight,
+			DryRun:     true,
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	pc := cache.PluginCommand{
+		ID:             foneroplugin.ID,
+		Command:        foneroplugin.CmdExpireActiveVotes,
+		CommandPayload: string(payload),
+	}
+
+	reply, err := p.cache.PluginExec(pc)
+	if err != nil {
+		return nil, err
+	}
+
+	return foneroplugin.DecodeExpireActiveVotesReply([]byte(reply.Payload))
+}
+
+// foneroExpireActiveVotes returns the tokens of the proposal votes that
+// finished after fromHeight and on or before bestBlock so that the caller can
+// send notifications.  The expired votes are looked up in the cache and the
+// expireactivevotes command is then sent to politeiad so that their vote
+// results are loaded.  politeiad replies with the gitbe payload, which does
+// not contain the tokens, so the tokens are always taken from the cache.  The
+// best block of the reply should be used as the from height of the next call.
+func (p *politeiawww) foneroExpireActiveVotes(fromHeight, bestBlock uint64) (*foneroplugin.ExpireActiveVotesReply, error) {
+	eavr, err := p.foneroExpiredVotes(fromHeight, bestBlock)
+	if err != nil {
+		return nil, fmt.Errorf("expired votes: %v", err)
+	}
+	if len(eavr.Tokens) == 0 {
+		return eavr, nil
+	}
+
+	// Setup plugin command
+	challenge, err := util.Random(pd.ChallengeSize)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := foneroplugin.EncodeExpireActiveVotes(
+		foneroplugin.ExpireActiveVotes{
+			BestBlock:  eavr.BestBlock,
+			FromHeight: fromHeight,
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	pc := pd.PluginCommand{
+		Challenge: hex.EncodeToString(challenge),
+		ID:        foneroplugin.ID,
+		Command:   foneroplugin.CmdExpireActiveVotes,
+		CommandID: foneroplugin.CmdExpireActiveVotes,
+		Payload:   string(payload),
+	}
+
+	// Send plugin command to politeiad
+	respBody, err := p.makeRequest(http.MethodPost,
+		pd.PluginCommandRoute, pc)
+	if err != nil {
+		return nil, err
+	}
+
+	// Handle response
+	var pcr pd.PluginCommandReply
+	err = json.Unmarshal(respBody, &pcr)
+	if err != nil {
+		return nil, err
+	}
+
+	err = util.VerifyChallenge(p.cfg.Identity, challenge, pcr.Response)
+	if err != nil {
+		return nil, err
+	}
+
+	return eavr, nil
+}
+
+// foneroRecomputeVoteResults sends the recomputevoteresults command to
+// politeiad.  The cached vote results of the proposal are deleted and tallied
+// again from the cast votes.  An error is returned if the proposal vote has
+// not finished by the provided best block.
+func (p *politeiawww) foneroRecomputeVoteResults(bestBlock uint64, token string) error {
+	// Ensure the proposal vote has finished
+	vdr, err := p.foneroVoteDetails(token)
+	if err != nil {
+		return err
+	}
+	if vdr.StartVoteReply.EndHeight == "" {
+		return fmt.Errorf("vote has not started: %v", token)
+	}
+	endHeight, err := strconv.ParseUint(vdr.StartVoteReply.EndHeight, 10, 64)
+	if err != nil {
+		return fmt.Errorf("parse end height '%v': %v",
+			vdr.StartVoteReply.EndHeight, err)
+	}
+	if endHeight > bestBlock {
+		return fmt.Errorf("vote has not finished: token %v end height %v "+
+			"best block %v", token, endHeight, bestBlock)
+	}
+
+	// Setup plugin command
+	challenge, err := util.Random(pd.ChallengeSize)
+	if err != nil {
+		return err
+	}
+
+	payload, err := foneroplugin.EncodeRecomputeVoteResults(
+		foneroplugin.RecomputeVoteResults{
+			Token:     token,
+			BestBlock: bestBlock,
+		})
+	if err != nil {
+		return err
+	}
+
+	pc := pd.PluginCommand{
+		Challenge: hex.EncodeToString(challenge),
+		ID:        foneroplugin.ID,
+		Command:   foneroplugin.CmdRecomputeVoteResults,
+		CommandID: foneroplugin.CmdRecomputeVoteResults,
+		Payload:   string(payload),
+	}
+
+	// Send plugin command to politeiad
+	respBody, err := p.makeRequest(http.MethodPost,
+		pd.PluginCommandRoute, pc)
+	if err != nil {
+		return err
+	}
+
+	// Handle response
+	var pcr pd.PluginCommandReply
+	err = json.Unmarshal(respBody, &pcr)
+	if err != nil {
+		return err
+	}
+
+	return util.VerifyChallenge(p.cfg.Identity, challenge, pcr.Response)
+}
+
+// foneroCountVotesByOption sends the fonero plugin countvotesbyoption command
+// to the cache and returns the vote options of the passed in proposal along
+// with the number of votes cast for each option.
+func (p *politeiawww) foneroCountVotesByOption(token string) (*foneroplugin.CountVotesByOptionReply, error) {
+	c := foneroplugin.CountVotesByOption{
+		Token: token,
+	}
+	payload, err := foneroplugin.EncodeCountVotesByOption(c)
+	if err != nil {
+		return nil, err
+	}
+
+	pc := cache.PluginCommand{
+		ID:             foneroplugin.ID,
+		Command:        foneroplugin.CmdCountVotesByOption,
+		CommandPayload: string(payload),
+	}
+
+	resp, err := p.cache.PluginExec(pc)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := foneroplugin.DecodeCountVotesByOptionReply(
+		[]byte(resp.Payload))
+	if err != nil {
+		return nil, err
+	}
+
+	return reply, nil
+}
+
+// foneroGetVoteStatus sends the fonero plugin getvotestatus command to the
+// cache and returns the vote status of the passed in proposal.
+func (p *politeiawww) foneroGetVoteStatus(token string, bestBlock uint64) (foneroplugin.VoteStatusT, error) {
+	g := foneroplugin.GetVoteStatus{
+		Token:     token,
+		BestBlock: bestBlock,
+	}
+	payload, err := foneroplugin.EncodeGetVoteStatus(g)
+	if err != nil {
+		return foneroplugin.VoteStatusInvalid, err
+	}
+
+	pc := cache.PluginCommand{
+		ID:             foneroplugin.ID,
+		Command:        foneroplugin.CmdGetVoteStatus,
+		CommandPayload: string(payload),
+	}
+
+	resp, err := p.cache.PluginExec(pc)
+	if err != nil {
+		return foneroplugin.VoteStatusInvalid, err
+	}
+
+	reply, err := foneroplugin.DecodeGetVoteStatusReply([]byte(resp.Payload))
+	if err != nil {
+		return foneroplugin.VoteStatusInvalid, err
+	}
+
+	return reply.Status, nil
+}
+
+// foneroBestBlock sends the fonero plugin bestblock command to the cache and
+// returns the best block height that the cache is operating against.  This
+// may differ from the best block that is returned by politeiad when the cache
+// has not been told about the latest block yet.
+func (p *politeiawww) foneroBestBlock() (uint64, error) {
+	pc := cache.PluginCommand{
+		ID:      foneroplugin.ID,
+		Command: foneroplugin.CmdBestBlock,
+	}
+
+	resp, err := p.cache.PluginExec(pc)
+	if err != nil {
+		return 0, err
+	}
+
+	reply, err := foneroplugin.DecodeBestBlockReply([]byte(resp.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	return reply.Height, nil
+}
+
+// foneroInventoryDigest sends the fonero plugin inventorydigest command to the
+// cache and returns a digest of the fonero plugin cache contents.  The digest
+// can be compared against the digest of the politeiad fonero plugin inventory
+// to determine whether the cache needs to be rebuilt.
+func (p *politeiawww) foneroInventoryDigest() (*foneroplugin.InventoryDigestReply, error) {
+	pc := cache.PluginCommand{
+		ID:      foneroplugin.ID,
+		Command: foneroplugin.CmdInventoryDigest,
+	}
+
+	resp, err := p.cache.PluginExec(pc)
+	if err != nil {
+		return nil, err
+	}
+
+	return foneroplugin.DecodeInventoryDigestReply([]byte(resp.Payload))
+}
+
+// foneroProposalSupportersCount sends the fonero plugin
+// proposalsupporterscount command to the cache and returns the number of
+// distinct tickets that voted on the passed in proposal along with the total
+// number of votes that were cast.
+func (p *politeiawww) foneroProposalSupportersCount(token string) (*foneroplugin.ProposalSupportersCountReply, error) {
+	c := foneroplugin.ProposalSupportersCount{
+		Token: token,
+	}
+	payload, err := foneroplugin.EncodeProposalSupportersCount(c)
+	if err != nil {
+		return nil, err
+	}
+
+	pc := cache.PluginCommand{
+		ID:             foneroplugin.ID,
+		Command:        foneroplugin.CmdProposalSupportersCount,
+		CommandPayload: string(payload),
+	}
+
+	resp, err := p.cache.PluginExec(pc)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := foneroplugin.DecodeProposalSupportersCountReply(
+		[]byte(resp.Payload))
+	if err != nil {
+		return nil, err
+	}
+
+	return reply, nil
+}
+
+// foneroVotesByBlockWindow sends the fonero plugin votesbyblockwindow command
+// to the cache and returns the tokens of all proposals whose voting period
+// overlaps the passed in block window.  Both heights are inclusive.
+func (p *politeiawww) foneroVotesByBlockWindow(startHeight, endHeight uint64) ([]string, error) {
+	v := foneroplugin.VotesByBlockWindow{
+		StartHeight: startHeight,
+		EndHeight:   endHeight,
+	}
+	payload, err := foneroplugin.EncodeVotesByBlockWindow(v)
+	if err != nil {
+		return nil, err
+	}
+
+	pc := cache.PluginCommand{
+		ID:             foneroplugin.ID,
+		Command:        foneroplugin.CmdVotesByBlockWindow,
+		CommandPayload: string(payload),
+	}
+
+	resp, err := p.cache.PluginExec(pc)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := foneroplugin.DecodeVotesByBlockWindowReply(
+		[]byte(resp.Payload))
+	if err != nil {
+		return nil, err
+	}
+
+	return reply.Tokens, nil
+}
+
+// foneroVoteSummary uses the fonero plugin vote summary command to request a
+// vote summary for a specific proposal from the cache.
+func (p *politeiawww) foneroVoteSummary(token string) (*foneroplugin.VoteSummaryReply, error) {
+	v := foneroplugin.VoteSummary{
+		Token: token,
+	}
+	payload, err := foneroplugin.EncodeVoteSummary(v)
+	if err != nil {
+		return nil, err
+	}
+
+	pc := cache.PluginCommand{
+		ID:             foneroplugin.ID,
+		Command:        foneroplugin.CmdVoteSummary,
+		CommandPayload: string(payload),
+	}
+
+	resp, err := p.cache.PluginExec(pc)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := foneroplugin.DecodeVoteSummaryReply([]byte(resp.Payload))
+	if err != nil {
+		return nil, err
+	}
+
+	return reply, nil
+}
+
+// estimateVoteEndTimestamp converts the number of blocks between the best
+// block and the end height of a vote into wall clock time using the target
+// block spacing of the passed in network.  It returns the estimated UNIX
+// timestamp of the end of the vote, which is now when the vote has already
+// ended.
+func estimateVoteEndTimestamp(params *chaincfg.Params, endHeight, bestBlock uint64, now time.Time) int64 {
+	if endHeight <= bestBlock {
+		return now.Unix()
+	}
+	remaining := time.Duration(endHeight-bestBlock) * params.TargetTimePerBlock
+	return now.Add(remaining).Unix()
+}
+
+// foneroVoteEndTimestamp returns the estimated UNIX timestamp at which the
+// vote of the passed in proposal ends.  The estimate uses the vote summary end
+// height and the best block of the cache.
+func (p *politeiawww) foneroVoteEndTimestamp(token string) (int64, error) {
+	vs, err := p.foneroVoteSummary(token)
+	if err != nil {
+		return 0, err
+	}
+	if vs.EndHeight == "" {
+		return 0, fmt.Errorf("vote has not started")
+	}
+	endHeight, err := strconv.ParseUint(vs.EndHeight, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse end height '%v' failed: %v",
+			vs.EndHeight, err)
+	}
+
+	bestBlock, err := p.foneroBestBlock()
+	if err != nil {
+		return 0, err
+	}
+
+	return estimateVoteEndTimestamp(p.params, endHeight, bestBlock,
+		time.Now()), nil
 }
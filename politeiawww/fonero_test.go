@@ -0,0 +1,4567 @@
+// Copyright (c) 2017-2019 The Fonero developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/decred/slog"
+	"github.com/fonero-project/fnod/chaincfg"
+	"github.com/fonero-project/politeia/foneroplugin"
+	pd "github.com/fonero-project/politeia/politeiad/api/v1"
+	"github.com/fonero-project/politeia/politeiad/api/v1/identity"
+	"github.com/fonero-project/politeia/politeiad/cache"
+	www "github.com/fonero-project/politeia/politeiawww/api/www/v1"
+	"github.com/fonero-project/politeia/util"
+)
+
+func TestFoneroRecordTimestampRange(t *testing.T) {
+	p, cleanup := newTestPoliteiawww(t)
+	defer cleanup()
+
+	// newRecord adds a record to the cache.
+	newRecord := func(token, version string, s cache.RecordStatusT, ts int64) {
+		err := p.cache.NewRecord(cache.Record{
+			Version:   version,
+			Status:    s,
+			Timestamp: ts,
+			CensorshipRecord: cache.CensorshipRecord{
+				Token: token,
+			},
+		})
+		if err != nil {
+			t.Fatalf("new record: %v", err)
+		}
+	}
+
+	newRecord("a", "1", cache.RecordStatusPublic, 100)
+	newRecord("b", "1", cache.RecordStatusPublic, 200)
+	newRecord("c", "1", cache.RecordStatusPublic, 300)
+	newRecord("d", "1", cache.RecordStatusNotReviewed, 200)
+	newRecord("e", "1", cache.RecordStatusPublic, 150)
+	newRecord("e", "2", cache.RecordStatusPublic, 400)
+
+	var tests = []struct {
+		name    string
+		start   int64
+		end     int64
+		want    []string
+		wantErr bool
+	}{
+		{"all", 0, 1000, []string{"a", "b", "c", "e"}, false},
+		{"inclusive boundaries", 100, 300, []string{"a", "b", "c"}, false},
+		{"single timestamp", 200, 200, []string{"b"}, false},
+		{"latest version only", 150, 150, []string{}, false},
+		{"empty window", 301, 399, []string{}, false},
+		{"invalid range", 300, 100, nil, true},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			tokens, err := p.foneroRecordTimestampRange(v.start, v.end)
+			if (err != nil) != v.wantErr {
+				t.Fatalf("got error %v, want error %v",
+					err, v.wantErr)
+			}
+			if v.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(tokens, v.want) {
+				t.Fatalf("got tokens %v, want %v", tokens, v.want)
+			}
+		})
+	}
+}
+
+func TestFoneroRecordsByStatus(t *testing.T) {
+	p, cleanup := newTestPoliteiawww(t)
+	defer cleanup()
+
+	// newRecord adds a record to the cache.
+	newRecord := func(token, version string, s cache.RecordStatusT, ts int64) {
+		err := p.cache.NewRecord(cache.Record{
+			Version:   version,
+			Status:    s,
+			Timestamp: ts,
+			CensorshipRecord: cache.CensorshipRecord{
+				Token: token,
+			},
+		})
+		if err != nil {
+			t.Fatalf("new record: %v", err)
+		}
+	}
+
+	newRecord("a", "1", cache.RecordStatusPublic, 300)
+	newRecord("b", "1", cache.RecordStatusPublic, 100)
+	newRecord("c", "1", cache.RecordStatusCensored, 200)
+	newRecord("d", "1", cache.RecordStatusNotReviewed, 150)
+	newRecord("e", "1", cache.RecordStatusPublic, 200)
+	newRecord("f", "1", cache.RecordStatusNotReviewed, 50)
+	newRecord("f", "2", cache.RecordStatusCensored, 400)
+
+	var tests = []struct {
+		name    string
+		status  pd.RecordStatusT
+		offset  uint32
+		limit   uint32
+		want    []string
+		wantErr bool
+	}{
+		{"public", pd.RecordStatusPublic, 0, 0,
+			[]string{"b", "e", "a"}, false},
+		{"censored latest version only", pd.RecordStatusCensored, 0, 0,
+			[]string{"c", "f"}, false},
+		{"unreviewed", pd.RecordStatusNotReviewed, 0, 0,
+			[]string{"d"}, false},
+		{"no records", pd.RecordStatusArchived, 0, 0,
+			[]string{}, false},
+		{"first page", pd.RecordStatusPublic, 0, 2,
+			[]string{"b", "e"}, false},
+		{"last page", pd.RecordStatusPublic, 2, 2,
+			[]string{"a"}, false},
+		{"offset past end", pd.RecordStatusPublic, 5, 2,
+			[]string{}, false},
+		{"invalid status", pd.RecordStatusInvalid, 0, 0, nil, true},
+		{"not found status", pd.RecordStatusNotFound, 0, 0, nil, true},
+		{"unknown status", pd.RecordStatusT(99), 0, 0, nil, true},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			tokens, err := p.foneroRecordsByStatus(v.status,
+				v.offset, v.limit)
+			if (err != nil) != v.wantErr {
+				t.Fatalf("got error %v, want error %v",
+					err, v.wantErr)
+			}
+			if v.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(tokens, v.want) {
+				t.Fatalf("got tokens %v, want %v", tokens, v.want)
+			}
+		})
+	}
+}
+
+func TestFoneroRecordStatusCounts(t *testing.T) {
+	p, cleanup := newTestPoliteiawww(t)
+	defer cleanup()
+
+	// newRecord adds a record to the cache.
+	newRecord := func(token, version string, s cache.RecordStatusT) {
+		err := p.cache.NewRecord(cache.Record{
+			Version: version,
+			Status:  s,
+			CensorshipRecord: cache.CensorshipRecord{
+				Token: token,
+			},
+		})
+		if err != nil {
+			t.Fatalf("new record: %v", err)
+		}
+	}
+
+	// Only the latest version of record f is counted
+	newRecord("a", "1", cache.RecordStatusPublic)
+	newRecord("b", "1", cache.RecordStatusPublic)
+	newRecord("c", "1", cache.RecordStatusCensored)
+	newRecord("d", "1", cache.RecordStatusNotReviewed)
+	newRecord("e", "1", cache.RecordStatusArchived)
+	newRecord("f", "1", cache.RecordStatusNotReviewed)
+	newRecord("f", "2", cache.RecordStatusPublic)
+
+	counts, err := p.foneroRecordStatusCounts()
+	if err != nil {
+		t.Fatalf("foneroRecordStatusCounts: %v", err)
+	}
+	want := map[pd.RecordStatusT]uint64{
+		pd.RecordStatusNotReviewed:       1,
+		pd.RecordStatusCensored:          1,
+		pd.RecordStatusPublic:            3,
+		pd.RecordStatusUnreviewedChanges: 0,
+		pd.RecordStatusArchived:          1,
+	}
+	if !reflect.DeepEqual(counts, want) {
+		t.Fatalf("got counts %v, want %v", counts, want)
+	}
+}
+
+func TestFoneroArchiveStats(t *testing.T) {
+	p, cleanup := newTestPoliteiawww(t)
+	defer cleanup()
+
+	// newRecord adds a record version to the cache.
+	newRecord := func(token, version string, s cache.RecordStatusT, timestamp int64) {
+		err := p.cache.NewRecord(cache.Record{
+			Version:   version,
+			Status:    s,
+			Timestamp: timestamp,
+			CensorshipRecord: cache.CensorshipRecord{
+				Token: token,
+			},
+		})
+		if err != nil {
+			t.Fatalf("new record: %v", err)
+		}
+	}
+
+	// newComment adds a comment to the cache.
+	newComment := func(token, commentID string) {
+		nc, err := foneroplugin.EncodeNewComment(
+			foneroplugin.NewComment{
+				Token:    token,
+				ParentID: "0",
+				Comment:  "comment " + commentID,
+			})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ncr, err := foneroplugin.EncodeNewCommentReply(
+			foneroplugin.NewCommentReply{
+				CommentID: commentID,
+			})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = p.cache.PluginExec(cache.PluginCommand{
+			ID:             foneroplugin.ID,
+			Command:        foneroplugin.CmdNewComment,
+			CommandPayload: string(nc),
+			ReplyPayload:   string(ncr),
+		})
+		if err != nil {
+			t.Fatalf("new comment: %v", err)
+		}
+	}
+
+	// No proposals have been abandoned yet
+	stats, err := p.foneroArchiveStats()
+	if err != nil {
+		t.Fatalf("foneroArchiveStats: %v", err)
+	}
+	want := foneroplugin.ArchiveStatsReply{}
+	if *stats != want {
+		t.Fatalf("got %v, want %v", *stats, want)
+	}
+
+	// Proposal a is abandoned 4000 seconds after it was created and
+	// proposal b is abandoned 2000 seconds after it was created.
+	// Proposal c has not been abandoned.
+	newRecord("a", "1", cache.RecordStatusPublic, 1000)
+	newRecord("a", "2", cache.RecordStatusArchived, 5000)
+	newRecord("b", "1", cache.RecordStatusPublic, 2000)
+	newRecord("b", "2", cache.RecordStatusPublic, 3000)
+	newRecord("b", "3", cache.RecordStatusArchived, 4000)
+	newRecord("c", "1", cache.RecordStatusPublic, 1000)
+	newComment("a", "1")
+	newComment("a", "2")
+	newComment("b", "1")
+	newComment("c", "1")
+	newComment("c", "2")
+	newComment("c", "3")
+
+	stats, err = p.foneroArchiveStats()
+	if err != nil {
+		t.Fatalf("foneroArchiveStats: %v", err)
+	}
+	want = foneroplugin.ArchiveStatsReply{
+		Abandoned:  2,
+		Comments:   3,
+		AverageAge: 3000,
+	}
+	if *stats != want {
+		t.Fatalf("got %v, want %v", *stats, want)
+	}
+}
+
+func TestFoneroPropCommentLikeCounts(t *testing.T) {
+	p, cleanup := newTestPoliteiawww(t)
+	defer cleanup()
+
+	// likeComment adds a comment like of the passed in public key to
+	// the cache.
+	likeComment := func(token, commentID, action, publicKey string) {
+		payload, err := foneroplugin.EncodeLikeComment(
+			foneroplugin.LikeComment{
+				Token:     token,
+				CommentID: commentID,
+				Action:    action,
+				PublicKey: publicKey,
+			})
+		if err != nil {
+			t.Fatalf("encode like comment: %v", err)
+		}
+		_, err = p.cache.PluginExec(cache.PluginCommand{
+			ID:             foneroplugin.ID,
+			Command:        foneroplugin.CmdLikeComment,
+			CommandPayload: string(payload),
+		})
+		if err != nil {
+			t.Fatalf("like comment: %v", err)
+		}
+	}
+
+	// Only the current like of each public key is counted. The
+	// upvote of pk4 on comment 3 is replaced by a downvote.
+	likeComment("a", "1", "1", "pk1")
+	likeComment("a", "1", "1", "pk2")
+	likeComment("a", "1", "-1", "pk3")
+	likeComment("a", "2", "-1", "pk1")
+	likeComment("a", "2", "-1", "pk2")
+	likeComment("a", "3", "1", "pk1")
+	likeComment("a", "3", "-1", "pk2")
+	likeComment("a", "3", "1", "pk4")
+	likeComment("a", "3", "-1", "pk4")
+	likeComment("b", "1", "1", "pk1")
+
+	var tests = []struct {
+		name  string
+		token string
+		want  []foneroplugin.CommentLikeCounts
+	}{
+		{"mixed likes", "a", []foneroplugin.CommentLikeCounts{
+			{CommentID: "1", Upvotes: 2, Downvotes: 1, Score: 1},
+			{CommentID: "2", Upvotes: 0, Downvotes: 2, Score: -2},
+			{CommentID: "3", Upvotes: 1, Downvotes: 2, Score: -1},
+		}},
+		{"single like", "b", []foneroplugin.CommentLikeCounts{
+			{CommentID: "1", Upvotes: 1, Downvotes: 0, Score: 1},
+		}},
+		{"no likes", "c", []foneroplugin.CommentLikeCounts{}},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			counts, err := p.foneroPropCommentLikeCounts(v.token)
+			if err != nil {
+				t.Fatalf("foneroPropCommentLikeCounts: %v", err)
+			}
+			if !reflect.DeepEqual(counts, v.want) {
+				t.Fatalf("got like counts %v, want %v",
+					counts, v.want)
+			}
+		})
+	}
+}
+
+func TestFoneroCommentLikesNetScore(t *testing.T) {
+	p, cleanup := newTestPoliteiawww(t)
+	defer cleanup()
+
+	// likeComment adds a comment like of the passed in public key to
+	// the cache.
+	likeComment := func(token, commentID, action, publicKey string) {
+		payload, err := foneroplugin.EncodeLikeComment(
+			foneroplugin.LikeComment{
+				Token:     token,
+				CommentID: commentID,
+				Action:    action,
+				PublicKey: publicKey,
+			})
+		if err != nil {
+			t.Fatalf("encode like comment: %v", err)
+		}
+		_, err = p.cache.PluginExec(cache.PluginCommand{
+			ID:             foneroplugin.ID,
+			Command:        foneroplugin.CmdLikeComment,
+			CommandPayload: string(payload),
+		})
+		if err != nil {
+			t.Fatalf("like comment: %v", err)
+		}
+	}
+
+	likeComment("a", "1", "1", "pk1")
+	likeComment("a", "1", "1", "pk2")
+	likeComment("a", "1", "-1", "pk3")
+	likeComment("a", "2", "-1", "pk1")
+	likeComment("a", "2", "-1", "pk2")
+	likeComment("a", "3", "1", "pk1")
+	likeComment("a", "4", "1", "pk1")
+	likeComment("b", "1", "1", "pk1")
+
+	var tests = []struct {
+		name       string
+		token      string
+		commentIDs []string
+		want       map[string]int64
+	}{
+		{
+			"subset",
+			"a",
+			[]string{"1", "2"},
+			map[string]int64{"1": 1, "2": -2},
+		},
+		{
+			"comment without likes",
+			"a",
+			[]string{"3", "5"},
+			map[string]int64{"3": 1, "5": 0},
+		},
+		{
+			"no comments",
+			"a",
+			[]string{},
+			map[string]int64{},
+		},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			got, err := p.foneroCommentLikesNetScore(v.token,
+				v.commentIDs)
+			if err != nil {
+				t.Fatalf("foneroCommentLikesNetScore: %v", err)
+			}
+			if !reflect.DeepEqual(got, v.want) {
+				t.Fatalf("got scores %v, want %v", got, v.want)
+			}
+		})
+	}
+}
+
+func TestFoneroGetCommentByReceipt(t *testing.T) {
+	p, cleanup := newTestPoliteiawww(t)
+	defer cleanup()
+
+	// newComment adds a comment with the passed in receipt to the
+	// cache.
+	newComment := func(token, commentID, receipt string) {
+		nc, err := foneroplugin.EncodeNewComment(
+			foneroplugin.NewComment{
+				Token:    token,
+				ParentID: "0",
+				Comment:  "comment " + commentID,
+			})
+		if err != nil {
+			t.Fatalf("encode new comment: %v", err)
+		}
+		ncr, err := foneroplugin.EncodeNewCommentReply(
+			foneroplugin.NewCommentReply{
+				CommentID: commentID,
+				Receipt:   receipt,
+			})
+		if err != nil {
+			t.Fatalf("encode new comment reply: %v", err)
+		}
+		_, err = p.cache.PluginExec(cache.PluginCommand{
+			ID:             foneroplugin.ID,
+			Command:        foneroplugin.CmdNewComment,
+			CommandPayload: string(nc),
+			ReplyPayload:   string(ncr),
+		})
+		if err != nil {
+			t.Fatalf("new comment: %v", err)
+		}
+	}
+
+	newComment("a", "1", "receipta1")
+	newComment("a", "2", "receipta2")
+	newComment("b", "1", "receiptb1")
+
+	var tests = []struct {
+		name          string
+		receipt       string
+		wantToken     string
+		wantCommentID string
+		wantErr       error
+	}{
+		{"first comment", "receipta1", "a", "1", nil},
+		{"second comment", "receipta2", "a", "2", nil},
+		{"other proposal", "receiptb1", "b", "1", nil},
+		{"unknown receipt", "receiptc1", "", "", cache.ErrRecordNotFound},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			c, err := p.foneroGetCommentByReceipt(v.receipt)
+			if err != v.wantErr {
+				t.Fatalf("got error %v, want %v", err, v.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if c.Token != v.wantToken || c.CommentID != v.wantCommentID ||
+				c.Receipt != v.receipt {
+				t.Fatalf("got comment %v %v receipt %v, want %v %v "+
+					"receipt %v", c.Token, c.CommentID, c.Receipt,
+					v.wantToken, v.wantCommentID, v.receipt)
+			}
+		})
+	}
+}
+
+func TestFoneroGetCommentAncestors(t *testing.T) {
+	p, cleanup := newTestPoliteiawww(t)
+	defer cleanup()
+
+	// newComment adds a comment to the cache.
+	newComment := func(token, commentID, parentID string) {
+		nc, err := foneroplugin.EncodeNewComment(
+			foneroplugin.NewComment{
+				Token:    token,
+				ParentID: parentID,
+				Comment:  "comment " + commentID,
+			})
+		if err != nil {
+			t.Fatalf("encode new comment: %v", err)
+		}
+		ncr, err := foneroplugin.EncodeNewCommentReply(
+			foneroplugin.NewCommentReply{
+				CommentID: commentID,
+			})
+		if err != nil {
+			t.Fatalf("encode new comment reply: %v", err)
+		}
+		_, err = p.cache.PluginExec(cache.PluginCommand{
+			ID:             foneroplugin.ID,
+			Command:        foneroplugin.CmdNewComment,
+			CommandPayload: string(nc),
+			ReplyPayload:   string(ncr),
+		})
+		if err != nil {
+			t.Fatalf("new comment: %v", err)
+		}
+	}
+
+	// Comment 4 is 4 levels deep.  The parent of comment 6 is missing.
+	newComment("a", "1", "0")
+	newComment("a", "2", "1")
+	newComment("a", "3", "2")
+	newComment("a", "4", "3")
+	newComment("a", "6", "5")
+
+	var tests = []struct {
+		name      string
+		commentID string
+		maxDepth  uint32
+		want      []string // Ancestor comment IDs
+		wantErr   bool
+	}{
+		{"4 deep", "4", 0, []string{"3", "2", "1"}, false},
+		{"max depth", "4", 1, []string{"3"}, false},
+		{"root comment", "1", 0, []string{}, false},
+		{"missing ancestor", "6", 0, []string{}, false},
+		{"comment not found", "7", 0, nil, true},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			gcar, err := p.foneroGetCommentAncestors("a",
+				v.commentID, v.maxDepth)
+			if (err != nil) != v.wantErr {
+				t.Fatalf("got error %v, want error %v",
+					err, v.wantErr)
+			}
+			if v.wantErr {
+				return
+			}
+			if gcar.Comment.CommentID != v.commentID {
+				t.Fatalf("got comment %v, want %v",
+					gcar.Comment.CommentID, v.commentID)
+			}
+			got := make([]string, 0, len(gcar.Ancestors))
+			for _, a := range gcar.Ancestors {
+				got = append(got, a.CommentID)
+			}
+			if !reflect.DeepEqual(got, v.want) {
+				t.Fatalf("got ancestors %v, want %v", got, v.want)
+			}
+		})
+	}
+}
+
+func TestFoneroGetCommentsSince(t *testing.T) {
+	p, cleanup := newTestPoliteiawww(t)
+	defer cleanup()
+
+	// pluginExec executes a fonero plugin command in the cache.
+	pluginExec := func(cmd string, payload, reply []byte) {
+		t.Helper()
+
+		_, err := p.cache.PluginExec(cache.PluginCommand{
+			ID:             foneroplugin.ID,
+			Command:        cmd,
+			CommandPayload: string(payload),
+			ReplyPayload:   string(reply),
+		})
+		if err != nil {
+			t.Fatalf("%v: %v", cmd, err)
+		}
+	}
+
+	// newComment adds a comment to the cache.
+	newComment := func(commentID string, timestamp int64) {
+		t.Helper()
+
+		nc, err := foneroplugin.EncodeNewComment(
+			foneroplugin.NewComment{
+				Token:    "a",
+				ParentID: "0",
+				Comment:  "comment " + commentID,
+			})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ncr, err := foneroplugin.EncodeNewCommentReply(
+			foneroplugin.NewCommentReply{
+				CommentID: commentID,
+				Timestamp: timestamp,
+			})
+		if err != nil {
+			t.Fatal(err)
+		}
+		pluginExec(foneroplugin.CmdNewComment, nc, ncr)
+	}
+
+	newComment("1", 100)
+	newComment("2", 200)
+	newComment("3", 300)
+	newComment("4", 300)
+
+	// Censor the oldest comment after the second comment was made
+	cc, err := foneroplugin.EncodeCensorComment(
+		foneroplugin.CensorComment{
+			Token:     "a",
+			CommentID: "1",
+			Timestamp: 250,
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ccr, err := foneroplugin.EncodeCensorCommentReply(
+		foneroplugin.CensorCommentReply{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pluginExec(foneroplugin.CmdCensorComment, cc, ccr)
+
+	var tests = []struct {
+		name       string
+		since      int64
+		lastSeenID string
+		want       []string // Comment IDs
+		wantErr    bool
+	}{
+		{"all comments", 0, "", []string{"1", "2", "3", "4"}, false},
+		{"includes just censored comment", 150, "",
+			[]string{"1", "2", "3", "4"}, false},
+		{"newer comments only", 250, "", []string{"3", "4"}, false},
+		{"nothing new", 300, "", []string{}, false},
+		{"last seen comment", 0, "3", []string{"4"}, false},
+		{"last seen before censor", 0, "2",
+			[]string{"1", "3", "4"}, false},
+		{"unknown last seen comment", 0, "5", nil, true},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			comments, err := p.foneroGetCommentsSince("a", v.since,
+				v.lastSeenID)
+			if (err != nil) != v.wantErr {
+				t.Fatalf("got error %v, want error %v",
+					err, v.wantErr)
+			}
+			if v.wantErr {
+				return
+			}
+
+			got := make([]string, 0, len(comments))
+			for _, c := range comments {
+				got = append(got, c.CommentID)
+				if c.CommentID == "1" && (!c.Censored || c.Comment != "") {
+					t.Fatalf("comment 1 is not censored: %v", c)
+				}
+			}
+			if !reflect.DeepEqual(got, v.want) {
+				t.Fatalf("got comments %v, want %v", got, v.want)
+			}
+		})
+	}
+}
+
+func TestFoneroCommentsModifiedSince(t *testing.T) {
+	p, cleanup := newTestPoliteiawww(t)
+	defer cleanup()
+
+	// pluginExec executes a fonero plugin command in the cache.
+	pluginExec := func(cmd string, payload, reply []byte) {
+		t.Helper()
+
+		_, err := p.cache.PluginExec(cache.PluginCommand{
+			ID:             foneroplugin.ID,
+			Command:        cmd,
+			CommandPayload: string(payload),
+			ReplyPayload:   string(reply),
+		})
+		if err != nil {
+			t.Fatalf("%v: %v", cmd, err)
+		}
+	}
+
+	// Comments 1 and 2 are made before the sync timestamp and
+	// comment 3 is made after it.
+	for _, v := range []struct {
+		commentID string
+		timestamp int64
+	}{{"1", 100}, {"2", 100}, {"3", 300}} {
+		nc, err := foneroplugin.EncodeNewComment(
+			foneroplugin.NewComment{
+				Token:    "a",
+				ParentID: "0",
+				Comment:  "comment " + v.commentID,
+			})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ncr, err := foneroplugin.EncodeNewCommentReply(
+			foneroplugin.NewCommentReply{
+				CommentID: v.commentID,
+				Timestamp: v.timestamp,
+			})
+		if err != nil {
+			t.Fatal(err)
+		}
+		pluginExec(foneroplugin.CmdNewComment, nc, ncr)
+	}
+
+	// Comment 1 is censored and comment 2 is liked after the sync
+	// timestamp.  Comment 2 was also liked before it.
+	cc, err := foneroplugin.EncodeCensorComment(
+		foneroplugin.CensorComment{
+			Token:     "a",
+			CommentID: "1",
+			Timestamp: 250,
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pluginExec(foneroplugin.CmdCensorComment, cc, nil)
+
+	likes := []foneroplugin.LikeComment{
+		{Token: "a", CommentID: "2", Action: "1", PublicKey: "pk1",
+			Timestamp: 150},
+		{Token: "a", CommentID: "2", Action: "-1", PublicKey: "pk2",
+			Timestamp: 250},
+	}
+	for _, v := range likes {
+		lc, err := foneroplugin.EncodeLikeComment(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pluginExec(foneroplugin.CmdLikeComment, lc, nil)
+	}
+
+	var tests = []struct {
+		name      string
+		since     int64
+		wantIDs   []string
+		wantLikes []foneroplugin.LikeComment
+	}{
+		{"all changes", 0, []string{"1", "2", "3"}, likes},
+		{"censor event and new like", 200, []string{"1", "3"},
+			likes[1:]},
+		{"no changes", 300, []string{},
+			[]foneroplugin.LikeComment{}},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			got, err := p.foneroCommentsModifiedSince("a", v.since)
+			if err != nil {
+				t.Fatalf("foneroCommentsModifiedSince: %v", err)
+			}
+			if !reflect.DeepEqual(got.CommentIDs, v.wantIDs) {
+				t.Fatalf("got comment IDs %v, want %v",
+					got.CommentIDs, v.wantIDs)
+			}
+			if !reflect.DeepEqual(got.Likes, v.wantLikes) {
+				t.Fatalf("got likes %v, want %v", got.Likes,
+					v.wantLikes)
+			}
+		})
+	}
+}
+
+// testBackendReplies returns the replies of the gitbe backend to the plugin
+// commands that the stubbed politeiad supports, keyed by command.  These
+// commands do not require any work to be performed in gitbe, which returns an
+// empty reply.
+var testBackendReplies = map[string]func() ([]byte, error){
+	foneroplugin.CmdLoadVoteResults: func() ([]byte, error) {
+		return foneroplugin.EncodeLoadVoteResultsReply(
+			foneroplugin.LoadVoteResultsReply{})
+	},
+	foneroplugin.CmdRecomputeVoteResults: func() ([]byte, error) {
+		return foneroplugin.EncodeRecomputeVoteResultsReply(
+			foneroplugin.RecomputeVoteResultsReply{})
+	},
+	foneroplugin.CmdExpireActiveVotes: func() ([]byte, error) {
+		return foneroplugin.EncodeExpireActiveVotesReply(
+			foneroplugin.ExpireActiveVotesReply{})
+	},
+}
+
+// newTestPluginServer returns a stubbed politeiad that handles plugin
+// commands the way politeiad does.  The backend reply is passed to the cache
+// along with the command and is returned to the caller.  Cache errors are
+// only logged, since politeiad does not return them either.  Every command
+// that is received is recorded and returned by the calls closure.
+func newTestPluginServer(t *testing.T, p *politeiawww) (*httptest.Server, func() []pd.PluginCommand) {
+	t.Helper()
+
+	id, err := identity.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.cfg.Identity = &id.Public
+
+	var mtx sync.Mutex
+	var calls []pd.PluginCommand
+	s := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			var pc pd.PluginCommand
+			err := json.NewDecoder(r.Body).Decode(&pc)
+			if err != nil {
+				util.RespondWithJSON(w, http.StatusBadRequest, err)
+				return
+			}
+			challenge, err := hex.DecodeString(pc.Challenge)
+			if err != nil {
+				util.RespondWithJSON(w, http.StatusBadRequest, nil)
+				return
+			}
+
+			mtx.Lock()
+			calls = append(calls, pc)
+			mtx.Unlock()
+
+			backendReply, ok := testBackendReplies[pc.Command]
+			if !ok {
+				util.RespondWithJSON(w, http.StatusBadRequest, nil)
+				return
+			}
+			payload, err := backendReply()
+			if err != nil {
+				util.RespondWithJSON(w, http.StatusInternalServerError, nil)
+				return
+			}
+
+			_, err = p.cache.PluginExec(cache.PluginCommand{
+				ID:             pc.ID,
+				Command:        pc.Command,
+				CommandPayload: pc.Payload,
+				ReplyPayload:   string(payload),
+			})
+			if err != nil {
+				t.Logf("cache plugin exec %v: %v", pc.Command, err)
+			}
+
+			response := id.SignMessage(challenge)
+			util.RespondWithJSON(w, http.StatusOK, pd.PluginCommandReply{
+				Response:  hex.EncodeToString(response[:]),
+				ID:        pc.ID,
+				Command:   pc.Command,
+				CommandID: pc.CommandID,
+				Payload:   string(payload),
+			})
+		}))
+	p.cfg.RPCHost = s.URL
+
+	return s, func() []pd.PluginCommand {
+		mtx.Lock()
+		defer mtx.Unlock()
+		return calls
+	}
+}
+
+// newTestLoadVoteResultsServer returns a stubbed politeiad that passes
+// loadvoteresults plugin commands through to the cache.  The payload of every
+// command that is received is recorded and returned by the calls closure.
+func newTestLoadVoteResultsServer(t *testing.T, p *politeiawww) (*httptest.Server, func() []foneroplugin.LoadVoteResults) {
+	t.Helper()
+
+	s, calls := newTestPluginServer(t, p)
+	return s, func() []foneroplugin.LoadVoteResults {
+		lvrs := make([]foneroplugin.LoadVoteResults, 0, len(calls()))
+		for _, v := range calls() {
+			if v.Command != foneroplugin.CmdLoadVoteResults {
+				t.Fatalf("unexpected command %v", v.Command)
+			}
+			lvr, err := foneroplugin.DecodeLoadVoteResults([]byte(v.Payload))
+			if err != nil {
+				t.Fatal(err)
+			}
+			lvrs = append(lvrs, *lvr)
+		}
+		return lvrs
+	}
+}
+
+func TestFoneroLoadVoteResults(t *testing.T) {
+	var tests = []struct {
+		name       string
+		finished   int      // Proposals that have finished voting
+		wantChunks []int    // Number of tokens sent per command
+		wantLogs   []string // Expected progress log lines
+	}{
+		{"no pending", 0, nil, nil},
+		{"single shot", loadVoteResultsChunkSize, []int{0}, nil},
+		{"chunked", 2*loadVoteResultsChunkSize + 5,
+			[]int{loadVoteResultsChunkSize, loadVoteResultsChunkSize, 5},
+			[]string{
+				fmt.Sprintf("Loaded vote results %v/%v",
+					loadVoteResultsChunkSize,
+					2*loadVoteResultsChunkSize+5),
+				fmt.Sprintf("Loaded vote results %v/%v",
+					2*loadVoteResultsChunkSize,
+					2*loadVoteResultsChunkSize+5),
+				fmt.Sprintf("Loaded vote results %v/%v",
+					2*loadVoteResultsChunkSize+5,
+					2*loadVoteResultsChunkSize+5),
+			}},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			p, cleanup := newTestPoliteiawww(t)
+			defer cleanup()
+
+			td, calls := newTestLoadVoteResultsServer(t, p)
+			defer td.Close()
+
+			// Capture log output
+			var buf bytes.Buffer
+			logger := slog.NewBackend(&buf).Logger("PWWW")
+			logger.SetLevel(slog.LevelInfo)
+			oldLog := log
+			log = logger
+			defer func() {
+				log = oldLog
+			}()
+
+			// Start votes that have finished voting by block 100
+			// and a vote that is still in progress.
+			startVote := func(token string, endHeight uint64) {
+				sv, err := foneroplugin.EncodeStartVote(
+					foneroplugin.StartVote{
+						Vote: foneroplugin.Vote{
+							Token: token,
+						},
+					})
+				if err != nil {
+					t.Fatal(err)
+				}
+				svr, err := foneroplugin.EncodeStartVoteReply(
+					foneroplugin.StartVoteReply{
+						EndHeight: strconv.FormatUint(endHeight, 10),
+					})
+				if err != nil {
+					t.Fatal(err)
+				}
+				_, err = p.cache.PluginExec(cache.PluginCommand{
+					ID:             foneroplugin.ID,
+					Command:        foneroplugin.CmdStartVote,
+					CommandPayload: string(sv),
+					ReplyPayload:   string(svr),
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			for i := 0; i < v.finished; i++ {
+				startVote(fmt.Sprintf("%03d", i), 100)
+			}
+			startVote("inprogress", 200)
+
+			err := p.foneroLoadVoteResults(context.Background(), 100)
+			if err != nil {
+				t.Fatalf("foneroLoadVoteResults: %v", err)
+			}
+
+			// Verify chunking
+			got := calls()
+			chunks := make([]int, 0, len(got))
+			for _, c := range got {
+				if c.DryRun {
+					t.Fatalf("dry run sent to politeiad")
+				}
+				chunks = append(chunks, len(c.Tokens))
+			}
+			if len(chunks) == 0 {
+				chunks = nil
+			}
+			if !reflect.DeepEqual(chunks, v.wantChunks) {
+				t.Fatalf("got chunks %v, want %v", chunks, v.wantChunks)
+			}
+
+			// Verify everything was loaded
+			pending, err := p.foneroPendingVoteResults(100)
+			if err != nil {
+				t.Fatalf("foneroPendingVoteResults: %v", err)
+			}
+			if len(pending) != 0 {
+				t.Fatalf("got %v pending, want 0", len(pending))
+			}
+
+			// Verify progress logging
+			for _, l := range v.wantLogs {
+				if !strings.Contains(buf.String(), l) {
+					t.Fatalf("log %q not found in %q", l, buf.String())
+				}
+			}
+			if v.wantLogs == nil && buf.Len() != 0 {
+				t.Fatalf("unexpected log output %q", buf.String())
+			}
+		})
+	}
+}
+
+func TestFoneroLoadVoteResultsTimeout(t *testing.T) {
+	var tests = []struct {
+		name    string
+		timeout time.Duration // Load vote results timeout
+		delay   time.Duration // Delay of the politeiad reply
+		cancel  bool          // Cancel the load after 50ms
+		wantErr bool
+	}{
+		{"within timeout", 5 * time.Second, 0, false, false},
+		{"timeout exceeded", 50 * time.Millisecond, 5 * time.Second,
+			false, true},
+		{"cancelled", 0, 5 * time.Second, true, true},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			p, cleanup := newTestPoliteiawww(t)
+			defer cleanup()
+
+			// Delay the replies of the stubbed politeiad. The
+			// delay ends early when the request is aborted.
+			td, _ := newTestPluginServer(t, p)
+			defer td.Close()
+			delayed := httptest.NewServer(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					select {
+					case <-time.After(v.delay):
+					case <-r.Context().Done():
+						return
+					}
+					td.Config.Handler.ServeHTTP(w, r)
+				}))
+			defer delayed.Close()
+			p.cfg.RPCHost = delayed.URL
+			p.cfg.LoadVoteResultsTimeout = v.timeout
+
+			// Start a vote that has finished voting by block 100
+			sv, err := foneroplugin.EncodeStartVote(
+				foneroplugin.StartVote{
+					Vote: foneroplugin.Vote{
+						Token: "a",
+					},
+				})
+			if err != nil {
+				t.Fatal(err)
+			}
+			svr, err := foneroplugin.EncodeStartVoteReply(
+				foneroplugin.StartVoteReply{
+					EndHeight: "100",
+				})
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = p.cache.PluginExec(cache.PluginCommand{
+				ID:             foneroplugin.ID,
+				Command:        foneroplugin.CmdStartVote,
+				CommandPayload: string(sv),
+				ReplyPayload:   string(svr),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if v.cancel {
+				time.AfterFunc(50*time.Millisecond, cancel)
+			}
+
+			start := time.Now()
+			err = p.foneroLoadVoteResults(ctx, 100)
+			if (err != nil) != v.wantErr {
+				t.Fatalf("got error %v, want error %v", err, v.wantErr)
+			}
+			if v.delay > 0 && time.Since(start) >= v.delay {
+				t.Fatalf("load was not aborted")
+			}
+		})
+	}
+}
+
+func TestFoneroRecomputeVoteResults(t *testing.T) {
+	p, cleanup := newTestPoliteiawww(t)
+	defer cleanup()
+
+	td, calls := newTestPluginServer(t, p)
+	defer td.Close()
+
+	// exec executes a fonero plugin command against the cache.
+	exec := func(cmd string, payload, reply []byte) {
+		_, err := p.cache.PluginExec(cache.PluginCommand{
+			ID:             foneroplugin.ID,
+			Command:        cmd,
+			CommandPayload: string(payload),
+			ReplyPayload:   string(reply),
+		})
+		if err != nil {
+			t.Fatalf("%v: %v", cmd, err)
+		}
+	}
+
+	// newVote adds a proposal with a yes/no vote that ends at
+	// the passed in block height.
+	newVote := func(token string, endHeight uint64) {
+		err := p.cache.NewRecord(cache.Record{
+			Version: "1",
+			CensorshipRecord: cache.CensorshipRecord{
+				Token: token,
+			},
+		})
+		if err != nil {
+			t.Fatalf("new record: %v", err)
+		}
+		sv, err := foneroplugin.EncodeStartVote(foneroplugin.StartVote{
+			Vote: foneroplugin.Vote{
+				Token:          token,
+				PassPercentage: 60,
+				Options: []foneroplugin.VoteOption{
+					{Id: "no", Bits: 1},
+					{Id: "yes", Bits: 2},
+				},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		svr, err := foneroplugin.EncodeStartVoteReply(
+			foneroplugin.StartVoteReply{
+				EndHeight: strconv.FormatUint(endHeight, 10),
+			})
+		if err != nil {
+			t.Fatal(err)
+		}
+		exec(foneroplugin.CmdStartVote, sv, svr)
+	}
+
+	// castVotes casts the passed in number of yes votes.
+	castVotes := func(token string, count int) {
+		votes := make([]foneroplugin.CastVote, 0, count)
+		for i := 0; i < count; i++ {
+			votes = append(votes, foneroplugin.CastVote{
+				Token:   token,
+				Ticket:  fmt.Sprintf("%v%v", token, i),
+				VoteBit: "2",
+			})
+		}
+		b, err := foneroplugin.EncodeBallot(foneroplugin.Ballot{
+			Votes: votes,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		exec(foneroplugin.CmdBallot, b, nil)
+	}
+
+	// yesVotes returns the yes votes and approval of the
+	// final vote results of a proposal.
+	yesVotes := func(token string) (uint64, bool) {
+		ver, err := p.foneroVoteExport(token)
+		if err != nil {
+			t.Fatalf("foneroVoteExport: %v", err)
+		}
+		if !ver.Final {
+			t.Fatalf("vote results of %v are not final", token)
+		}
+		for _, v := range ver.Results {
+			if v.ID == "yes" {
+				return v.Votes, ver.Approved
+			}
+		}
+		t.Fatalf("yes option not found for %v", token)
+		return 0, false
+	}
+
+	newVote("a", 100)
+	newVote("b", 100)
+	newVote("inprogress", 200)
+
+	// Load the vote results before any votes are cached so
+	// that both proposals end up with a wrong tally.
+	lvr, err := foneroplugin.EncodeLoadVoteResults(
+		foneroplugin.LoadVoteResults{
+			BestBlock: 100,
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exec(foneroplugin.CmdLoadVoteResults, lvr, nil)
+	castVotes("a", 3)
+	castVotes("b", 2)
+
+	for _, token := range []string{"a", "b"} {
+		votes, approved := yesVotes(token)
+		if votes != 0 || approved {
+			t.Fatalf("%v: got seeded result %v %v, want 0 false",
+				token, votes, approved)
+		}
+	}
+
+	// Recompute a single proposal
+	err = p.foneroRecomputeVoteResults(100, "a")
+	if err != nil {
+		t.Fatalf("foneroRecomputeVoteResults: %v", err)
+	}
+	votes, approved := yesVotes("a")
+	if votes != 3 || !approved {
+		t.Fatalf("a: got %v %v, want 3 true", votes, approved)
+	}
+	votes, approved = yesVotes("b")
+	if votes != 0 || approved {
+		t.Fatalf("b: got %v %v, want 0 false", votes, approved)
+	}
+
+	// A vote that has not finished can not be recomputed
+	err = p.foneroRecomputeVoteResults(100, "inprogress")
+	if err == nil {
+		t.Fatalf("recompute unfinished vote: got nil error")
+	}
+
+	got := calls()
+	if len(got) != 1 || got[0].Command != foneroplugin.CmdRecomputeVoteResults {
+		t.Fatalf("got politeiad calls %v, want 1 %v", got,
+			foneroplugin.CmdRecomputeVoteResults)
+	}
+}
+
+func TestFoneroExpireActiveVotes(t *testing.T) {
+	p, cleanup := newTestPoliteiawww(t)
+	defer cleanup()
+
+	td, calls := newTestPluginServer(t, p)
+	defer td.Close()
+
+	// newVote adds a proposal vote that ends at the passed in
+	// block height.
+	newVote := func(token string, endHeight uint64) {
+		sv, err := foneroplugin.EncodeStartVote(foneroplugin.StartVote{
+			Vote: foneroplugin.Vote{
+				Token: token,
+				Options: []foneroplugin.VoteOption{
+					{Id: "no", Bits: 1},
+					{Id: "yes", Bits: 2},
+				},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		svr, err := foneroplugin.EncodeStartVoteReply(
+			foneroplugin.StartVoteReply{
+				EndHeight: strconv.FormatUint(endHeight, 10),
+			})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = p.cache.PluginExec(cache.PluginCommand{
+			ID:             foneroplugin.ID,
+			Command:        foneroplugin.CmdStartVote,
+			CommandPayload: string(sv),
+			ReplyPayload:   string(svr),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// final returns whether the vote results of a proposal have
+	// been loaded.
+	final := func(token string) bool {
+		ver, err := p.foneroVoteExport(token)
+		if err != nil {
+			t.Fatalf("foneroVoteExport: %v", err)
+		}
+		return ver.Final
+	}
+
+	newVote("reported", 90)
+	newVote("expired", 100)
+	newVote("inprogress", 200)
+
+	// Only the vote that finished since the previous run is
+	// returned and has its vote results loaded.
+	eavr, err := p.foneroExpireActiveVotes(95, 100)
+	if err != nil {
+		t.Fatalf("foneroExpireActiveVotes: %v", err)
+	}
+	if eavr.BestBlock != 100 {
+		t.Fatalf("got best block %v, want 100", eavr.BestBlock)
+	}
+	if !reflect.DeepEqual(eavr.Tokens, []string{"expired"}) {
+		t.Fatalf("got tokens %v, want [expired]", eavr.Tokens)
+	}
+	if !final("expired") {
+		t.Fatalf("vote results of expired were not loaded")
+	}
+	if final("reported") || final("inprogress") {
+		t.Fatalf("unexpected vote results loaded")
+	}
+
+	// A subsequent run from the previous best block does not
+	// report the vote again.
+	eavr, err = p.foneroExpireActiveVotes(eavr.BestBlock, 150)
+	if err != nil {
+		t.Fatalf("foneroExpireActiveVotes: %v", err)
+	}
+	if len(eavr.Tokens) != 0 {
+		t.Fatalf("got tokens %v, want none", eavr.Tokens)
+	}
+
+	// politeiad is only called when there are votes to expire
+	got := calls()
+	if len(got) != 1 || got[0].Command != foneroplugin.CmdExpireActiveVotes {
+		t.Fatalf("got politeiad calls %v, want 1 %v", got,
+			foneroplugin.CmdExpireActiveVotes)
+	}
+}
+
+func TestFoneroCountVotesByOption(t *testing.T) {
+	p, cleanup := newTestPoliteiawww(t)
+	defer cleanup()
+
+	// exec executes a fonero plugin command against the cache.
+	exec := func(cmd string, payload []byte) {
+		_, err := p.cache.PluginExec(cache.PluginCommand{
+			ID:             foneroplugin.ID,
+			Command:        cmd,
+			CommandPayload: string(payload),
+			ReplyPayload:   "{}",
+		})
+		if err != nil {
+			t.Fatalf("%v: %v", cmd, err)
+		}
+	}
+
+	options := []foneroplugin.VoteOption{
+		{
+			Id:          "no",
+			Description: "Don't approve proposal",
+			Bits:        0x01,
+		},
+		{
+			Id:          "yes",
+			Description: "Approve proposal",
+			Bits:        0x02,
+		},
+	}
+	for _, token := range []string{"a", "b"} {
+		sv, err := foneroplugin.EncodeStartVote(foneroplugin.StartVote{
+			Vote: foneroplugin.Vote{
+				Token:   token,
+				Options: options,
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		exec(foneroplugin.CmdStartVote, sv)
+	}
+
+	// Cast votes on proposal a only
+	votes := make([]foneroplugin.CastVote, 0, 4)
+	for i, bit := range []string{"2", "1", "2", "2"} {
+		votes = append(votes, foneroplugin.CastVote{
+			Token:   "a",
+			Ticket:  fmt.Sprintf("ticket%v", i),
+			VoteBit: bit,
+		})
+	}
+	b, err := foneroplugin.EncodeBallot(foneroplugin.Ballot{
+		Votes: votes,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exec(foneroplugin.CmdBallot, b)
+
+	var tests = []struct {
+		name    string
+		token   string
+		want    map[string]uint64
+		wantErr error
+	}{
+		{"votes cast", "a", map[string]uint64{"1": 1, "2": 3}, nil},
+		{"no votes", "b", map[string]uint64{"1": 0, "2": 0}, nil},
+		{"vote not started", "c", nil, cache.ErrRecordNotFound},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			reply, err := p.foneroCountVotesByOption(v.token)
+			if err != v.wantErr {
+				t.Fatalf("got error %v, want %v", err, v.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(reply.Counts, v.want) {
+				t.Fatalf("got counts %v, want %v", reply.Counts, v.want)
+			}
+			if !reflect.DeepEqual(reply.Options, options) {
+				t.Fatalf("got options %v, want %v",
+					reply.Options, options)
+			}
+		})
+	}
+}
+
+func TestFoneroRecordHistory(t *testing.T) {
+	p, cleanup := newTestPoliteiawww(t)
+	defer cleanup()
+
+	// The record is edited twice after being made public and
+	// the record versions are added out of order.
+	versions := []foneroplugin.RecordHistoryVersion{
+		{Version: 1, Status: int(cache.RecordStatusPublic), Timestamp: 100},
+		{Version: 3, Status: int(cache.RecordStatusPublic), Timestamp: 300},
+		{Version: 2, Status: int(cache.RecordStatusUnreviewedChanges),
+			Timestamp: 200},
+	}
+	for _, v := range versions {
+		err := p.cache.NewRecord(cache.Record{
+			Version:   strconv.FormatUint(v.Version, 10),
+			Status:    cache.RecordStatusT(v.Status),
+			Timestamp: v.Timestamp,
+			CensorshipRecord: cache.CensorshipRecord{
+				Token: "a",
+			},
+		})
+		if err != nil {
+			t.Fatalf("new record: %v", err)
+		}
+	}
+
+	var tests = []struct {
+		name    string
+		token   string
+		want    []foneroplugin.RecordHistoryVersion
+		wantErr error
+	}{
+		{"three versions", "a", []foneroplugin.RecordHistoryVersion{
+			versions[0], versions[2], versions[1],
+		}, nil},
+		{"record not found", "b", nil, cache.ErrRecordNotFound},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			got, err := p.foneroRecordHistory(v.token)
+			if err != v.wantErr {
+				t.Fatalf("got error %v, want %v", err, v.wantErr)
+			}
+			if !reflect.DeepEqual(got, v.want) {
+				t.Fatalf("got %v, want %v", got, v.want)
+			}
+		})
+	}
+}
+
+func TestFoneroGetProposalMetadata(t *testing.T) {
+	p, cleanup := newTestPoliteiawww(t)
+	defer cleanup()
+
+	// The metadata of the second version is edited and both
+	// versions carry a file whose payload must not be returned.
+	const filePayload = "ZmlsZXBheWxvYWQ="
+	metadata := map[string][]cache.MetadataStream{
+		"1": {{ID: 0, Payload: `{"version":1}`}},
+		"2": {{ID: 0, Payload: `{"version":2}`},
+			{ID: 2, Payload: `{"status":2}`}},
+	}
+	for version, ms := range metadata {
+		err := p.cache.NewRecord(cache.Record{
+			Version: version,
+			Status:  cache.RecordStatusPublic,
+			CensorshipRecord: cache.CensorshipRecord{
+				Token: "a",
+			},
+			Metadata: ms,
+			Files: []cache.File{{
+				Name:    "index.md",
+				MIME:    "text/plain; charset=utf-8",
+				Payload: filePayload,
+			}},
+		})
+		if err != nil {
+			t.Fatalf("new record: %v", err)
+		}
+	}
+
+	convert := func(ms []cache.MetadataStream) []foneroplugin.MetadataStream {
+		m := make([]foneroplugin.MetadataStream, 0, len(ms))
+		for _, v := range ms {
+			m = append(m, foneroplugin.MetadataStream{
+				ID:      v.ID,
+				Payload: v.Payload,
+			})
+		}
+		return m
+	}
+
+	var tests = []struct {
+		name        string
+		token       string
+		version     string
+		wantVersion string
+		wantErr     error
+	}{
+		{"latest version", "a", "", "2", nil},
+		{"requested version", "a", "1", "1", nil},
+		{"version not found", "a", "3", "", cache.ErrRecordNotFound},
+		{"record not found", "b", "", "", cache.ErrRecordNotFound},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			got, err := p.foneroGetProposalMetadata(v.token, v.version)
+			if err != v.wantErr {
+				t.Fatalf("got error %v, want %v", err, v.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.Version != v.wantVersion {
+				t.Fatalf("got version %v, want %v",
+					got.Version, v.wantVersion)
+			}
+			want := convert(metadata[v.wantVersion])
+			if !reflect.DeepEqual(got.Metadata, want) {
+				t.Fatalf("got metadata %v, want %v",
+					got.Metadata, want)
+			}
+		})
+	}
+
+	// The reply must not carry any of the record files
+	payload, err := foneroplugin.EncodeGetProposalMetadata(
+		foneroplugin.GetProposalMetadata{Token: "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reply, err := p.cache.PluginExec(cache.PluginCommand{
+		ID:             foneroplugin.ID,
+		Command:        foneroplugin.CmdGetProposalMetadata,
+		CommandPayload: string(payload),
+	})
+	if err != nil {
+		t.Fatalf("plugin exec: %v", err)
+	}
+	if strings.Contains(reply.Payload, filePayload) {
+		t.Fatalf("reply contains file payload: %v", reply.Payload)
+	}
+}
+
+func TestFoneroRecordFilesManifest(t *testing.T) {
+	p, cleanup := newTestPoliteiawww(t)
+	defer cleanup()
+
+	// The second version adds a file whose payload requires
+	// base64 padding.
+	files := map[string][]cache.File{
+		"1": {{
+			Name:    "index.md",
+			MIME:    "text/plain; charset=utf-8",
+			Digest:  "d1",
+			Payload: base64.StdEncoding.EncodeToString([]byte("abc")),
+		}},
+		"2": {{
+			Name:    "index.md",
+			MIME:    "text/plain; charset=utf-8",
+			Digest:  "d2",
+			Payload: base64.StdEncoding.EncodeToString([]byte("abcdef")),
+		}, {
+			Name:    "image.png",
+			MIME:    "image/png",
+			Digest:  "d3",
+			Payload: base64.StdEncoding.EncodeToString([]byte("pn")),
+		}},
+	}
+	for version, f := range files {
+		err := p.cache.NewRecord(cache.Record{
+			Version: version,
+			Status:  cache.RecordStatusPublic,
+			CensorshipRecord: cache.CensorshipRecord{
+				Token: "a",
+			},
+			Files: f,
+		})
+		if err != nil {
+			t.Fatalf("new record: %v", err)
+		}
+	}
+
+	var tests = []struct {
+		name        string
+		token       string
+		version     string
+		wantVersion string
+		wantFiles   []foneroplugin.FileManifest
+		wantErr     error
+	}{
+		{"latest version", "a", "", "2",
+			[]foneroplugin.FileManifest{
+				{Name: "index.md", MIME: "text/plain; charset=utf-8",
+					Digest: "d2", Size: 6},
+				{Name: "image.png", MIME: "image/png",
+					Digest: "d3", Size: 2},
+			}, nil},
+		{"requested version", "a", "1", "1",
+			[]foneroplugin.FileManifest{
+				{Name: "index.md", MIME: "text/plain; charset=utf-8",
+					Digest: "d1", Size: 3},
+			}, nil},
+		{"version not found", "a", "3", "", nil,
+			cache.ErrRecordNotFound},
+		{"record not found", "b", "", "", nil,
+			cache.ErrRecordNotFound},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			got, err := p.foneroRecordFilesManifest(v.token, v.version)
+			if err != v.wantErr {
+				t.Fatalf("got error %v, want %v", err, v.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.Version != v.wantVersion {
+				t.Fatalf("got version %v, want %v",
+					got.Version, v.wantVersion)
+			}
+			if !reflect.DeepEqual(got.Files, v.wantFiles) {
+				t.Fatalf("got files %v, want %v",
+					got.Files, v.wantFiles)
+			}
+		})
+	}
+
+	// The reply must not carry any of the file payloads
+	payload, err := foneroplugin.EncodeRecordFilesManifest(
+		foneroplugin.RecordFilesManifest{Token: "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reply, err := p.cache.PluginExec(cache.PluginCommand{
+		ID:             foneroplugin.ID,
+		Command:        foneroplugin.CmdRecordFilesManifest,
+		CommandPayload: string(payload),
+	})
+	if err != nil {
+		t.Fatalf("plugin exec: %v", err)
+	}
+	for _, f := range files["2"] {
+		if strings.Contains(reply.Payload, f.Payload) {
+			t.Fatalf("reply contains file payload: %v", reply.Payload)
+		}
+	}
+}
+
+func TestFoneroGetLatestRecordVersion(t *testing.T) {
+	p, cleanup := newTestPoliteiawww(t)
+	defer cleanup()
+
+	// Versions are compared numerically and the status of
+	// the latest version is returned.
+	versions := map[string]cache.RecordStatusT{
+		"1":  cache.RecordStatusNotReviewed,
+		"2":  cache.RecordStatusPublic,
+		"10": cache.RecordStatusArchived,
+	}
+	for version, status := range versions {
+		err := p.cache.NewRecord(cache.Record{
+			Version: version,
+			Status:  status,
+			CensorshipRecord: cache.CensorshipRecord{
+				Token: "a",
+			},
+		})
+		if err != nil {
+			t.Fatalf("new record: %v", err)
+		}
+	}
+
+	got, err := p.foneroGetLatestRecordVersion("a")
+	if err != nil {
+		t.Fatalf("foneroGetLatestRecordVersion: %v", err)
+	}
+	want := foneroplugin.GetLatestRecordVersionReply{
+		Version: "10",
+		Status:  int(cache.RecordStatusArchived),
+	}
+	if *got != want {
+		t.Fatalf("got %v, want %v", *got, want)
+	}
+
+	_, err = p.foneroGetLatestRecordVersion("b")
+	if err != cache.ErrRecordNotFound {
+		t.Fatalf("got error %v, want %v", err, cache.ErrRecordNotFound)
+	}
+}
+
+func TestFoneroBestBlock(t *testing.T) {
+	p, cleanup := newTestPoliteiawww(t)
+	defer cleanup()
+
+	// The cache has not been told about a best block yet
+	_, err := p.foneroBestBlock()
+	if err == nil {
+		t.Fatalf("got no error, want error")
+	}
+
+	// tell sends the best block that was returned by the backend
+	// to the cache the way that politeiad does.
+	tell := func(bestBlock string) {
+		_, err := p.cache.PluginExec(cache.PluginCommand{
+			ID:           foneroplugin.ID,
+			Command:      foneroplugin.CmdBestBlock,
+			ReplyPayload: bestBlock,
+		})
+		if err != nil {
+			t.Fatalf("best block %v: %v", bestBlock, err)
+		}
+	}
+
+	var tests = []struct {
+		name string
+		told string
+		want uint64
+	}{
+		{"first block", "500", 500},
+		{"new block", "501", 501},
+		{"stale block", "450", 501},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			tell(v.told)
+			got, err := p.foneroBestBlock()
+			if err != nil {
+				t.Fatalf("foneroBestBlock: %v", err)
+			}
+			if got != v.want {
+				t.Fatalf("got best block %v, want %v", got, v.want)
+			}
+		})
+	}
+}
+
+func TestEstimateVoteEndTimestamp(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+
+	var tests = []struct {
+		name      string
+		params    *chaincfg.Params
+		endHeight uint64
+		bestBlock uint64
+		remaining int64 // Blocks that remain until the vote ends
+	}{
+		{"mainnet", mainNetParams.Params, 2116, 100, 2016},
+		{"testnet", testNetParams.Params, 2116, 100, 2016},
+		{"simnet", simNetParams.Params, 2116, 100, 2016},
+		{"last block", mainNetParams.Params, 101, 100, 1},
+		{"vote ended", mainNetParams.Params, 100, 100, 0},
+		{"best block past end", testNetParams.Params, 100, 150, 0},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			if v.params.TargetTimePerBlock <= 0 {
+				t.Fatalf("invalid target time per block %v",
+					v.params.TargetTimePerBlock)
+			}
+			want := now.Add(time.Duration(v.remaining) *
+				v.params.TargetTimePerBlock).Unix()
+			got := estimateVoteEndTimestamp(v.params, v.endHeight,
+				v.bestBlock, now)
+			if got != want {
+				t.Fatalf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestFoneroGetVoteStatus(t *testing.T) {
+	p, cleanup := newTestPoliteiawww(t)
+	defer cleanup()
+
+	// exec executes a fonero plugin command against the cache.
+	exec := func(cmd string, payload, reply []byte) {
+		_, err := p.cache.PluginExec(cache.PluginCommand{
+			ID:             foneroplugin.ID,
+			Command:        cmd,
+			CommandPayload: string(payload),
+			ReplyPayload:   string(reply),
+		})
+		if err != nil {
+			t.Fatalf("%v: %v", cmd, err)
+		}
+	}
+
+	// authorizeVote authorizes or revokes the vote of a proposal.
+	authorizeVote := func(token, action string) {
+		av, err := foneroplugin.EncodeAuthorizeVote(
+			foneroplugin.AuthorizeVote{
+				Token:  token,
+				Action: action,
+			})
+		if err != nil {
+			t.Fatal(err)
+		}
+		avr, err := foneroplugin.EncodeAuthorizeVoteReply(
+			foneroplugin.AuthorizeVoteReply{
+				Action:        action,
+				RecordVersion: "1",
+			})
+		if err != nil {
+			t.Fatal(err)
+		}
+		exec(foneroplugin.CmdAuthorizeVote, av, avr)
+	}
+
+	// startVote starts the vote of a proposal with an end height of
+	// 200 and casts the passed in yes and no votes.
+	startVote := func(token string, yes, no int) {
+		sv, err := foneroplugin.EncodeStartVote(foneroplugin.StartVote{
+			Vote: foneroplugin.Vote{
+				Token:          token,
+				PassPercentage: 60,
+				Options: []foneroplugin.VoteOption{
+					{Id: "no", Bits: 0x01},
+					{Id: "yes", Bits: 0x02},
+				},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		svr, err := foneroplugin.EncodeStartVoteReply(
+			foneroplugin.StartVoteReply{
+				StartBlockHeight: "100",
+				EndHeight:        "200",
+			})
+		if err != nil {
+			t.Fatal(err)
+		}
+		exec(foneroplugin.CmdStartVote, sv, svr)
+
+		votes := make([]foneroplugin.CastVote, 0, yes+no)
+		for i := 0; i < yes+no; i++ {
+			bit := "2"
+			if i >= yes {
+				bit = "1"
+			}
+			votes = append(votes, foneroplugin.CastVote{
+				Token:   token,
+				Ticket:  fmt.Sprintf("ticket%v", i),
+				VoteBit: bit,
+			})
+		}
+		b, err := foneroplugin.EncodeBallot(foneroplugin.Ballot{
+			Votes: votes,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		exec(foneroplugin.CmdBallot, b, []byte("{}"))
+	}
+
+	// loadVoteResults loads the vote results of all finished votes.
+	loadVoteResults := func() {
+		lvr, err := foneroplugin.EncodeLoadVoteResults(
+			foneroplugin.LoadVoteResults{
+				BestBlock: 200,
+			})
+		if err != nil {
+			t.Fatal(err)
+		}
+		exec(foneroplugin.CmdLoadVoteResults, lvr, nil)
+	}
+
+	for _, token := range []string{"a", "b"} {
+		err := p.cache.NewRecord(cache.Record{
+			Version: "1",
+			Status:  cache.RecordStatusPublic,
+			CensorshipRecord: cache.CensorshipRecord{
+				Token: token,
+			},
+		})
+		if err != nil {
+			t.Fatalf("new record: %v", err)
+		}
+	}
+
+	// Each step moves the proposals through the vote lifecycle
+	// and is followed by a check of the resulting vote status.
+	var tests = []struct {
+		name      string
+		step      func()
+		token     string
+		bestBlock uint64
+		want      foneroplugin.VoteStatusT
+	}{
+		{"not authorized", func() {}, "a", 0,
+			foneroplugin.VoteStatusNotAuthorized},
+		{"authorized", func() {
+			authorizeVote("a", foneroplugin.AuthVoteActionAuthorize)
+		}, "a", 0, foneroplugin.VoteStatusAuthorized},
+		{"revoked", func() {
+			authorizeVote("a", foneroplugin.AuthVoteActionRevoke)
+		}, "a", 0, foneroplugin.VoteStatusNotAuthorized},
+		{"reauthorized", func() {
+			authorizeVote("a", foneroplugin.AuthVoteActionAuthorize)
+		}, "a", 0, foneroplugin.VoteStatusAuthorized},
+		{"started", func() {
+			startVote("a", 7, 3)
+		}, "a", 150, foneroplugin.VoteStatusStarted},
+		{"finished", func() {}, "a", 200,
+			foneroplugin.VoteStatusFinished},
+		{"approved", func() {
+			authorizeVote("b", foneroplugin.AuthVoteActionAuthorize)
+			startVote("b", 3, 7)
+			loadVoteResults()
+		}, "a", 200, foneroplugin.VoteStatusApproved},
+		{"rejected", func() {}, "b", 200,
+			foneroplugin.VoteStatusRejected},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			v.step()
+			got, err := p.foneroGetVoteStatus(v.token, v.bestBlock)
+			if err != nil {
+				t.Fatalf("foneroGetVoteStatus: %v", err)
+			}
+			if got != v.want {
+				t.Fatalf("got %v, want %v", got, v.want)
+			}
+		})
+	}
+
+	// A vote status can not be returned for a record that
+	// doesn't exist.
+	_, err := p.foneroGetVoteStatus("c", 0)
+	if err != cache.ErrRecordNotFound {
+		t.Fatalf("got error %v, want %v", err, cache.ErrRecordNotFound)
+	}
+}
+
+func TestFoneroVoteResultsByStatus(t *testing.T) {
+	p, cleanup := newTestPoliteiawww(t)
+	defer cleanup()
+
+	// exec executes a fonero plugin command against the cache.
+	exec := func(cmd string, payload, reply []byte) {
+		_, err := p.cache.PluginExec(cache.PluginCommand{
+			ID:             foneroplugin.ID,
+			Command:        cmd,
+			CommandPayload: string(payload),
+			ReplyPayload:   string(reply),
+		})
+		if err != nil {
+			t.Fatalf("%v: %v", cmd, err)
+		}
+	}
+
+	// startVote starts the vote of a proposal with the passed in end
+	// height and casts the passed in yes and no votes.
+	startVote := func(token, endHeight string, yes, no int) {
+		sv, err := foneroplugin.EncodeStartVote(foneroplugin.StartVote{
+			Vote: foneroplugin.Vote{
+				Token:          token,
+				PassPercentage: 60,
+				Options: []foneroplugin.VoteOption{
+					{Id: "no", Bits: 0x01},
+					{Id: "yes", Bits: 0x02},
+				},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		svr, err := foneroplugin.EncodeStartVoteReply(
+			foneroplugin.StartVoteReply{
+				StartBlockHeight: "100",
+				EndHeight:        endHeight,
+			})
+		if err != nil {
+			t.Fatal(err)
+		}
+		exec(foneroplugin.CmdStartVote, sv, svr)
+
+		votes := make([]foneroplugin.CastVote, 0, yes+no)
+		for i := 0; i < yes+no; i++ {
+			bit := "2"
+			if i >= yes {
+				bit = "1"
+			}
+			votes = append(votes, foneroplugin.CastVote{
+				Token:   token,
+				Ticket:  fmt.Sprintf("ticket%v", i),
+				VoteBit: bit,
+			})
+		}
+		b, err := foneroplugin.EncodeBallot(foneroplugin.Ballot{
+			Votes: votes,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		exec(foneroplugin.CmdBallot, b, []byte("{}"))
+	}
+
+	// The vote of proposal d is still in progress and must not be
+	// returned.
+	startVote("c", "200", 8, 2)
+	startVote("a", "200", 7, 3)
+	startVote("b", "200", 3, 7)
+	startVote("d", "300", 9, 1)
+
+	lvr, err := foneroplugin.EncodeLoadVoteResults(
+		foneroplugin.LoadVoteResults{
+			BestBlock: 200,
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exec(foneroplugin.CmdLoadVoteResults, lvr, nil)
+
+	// results returns the vote option results of a proposal.
+	results := func(yes, no uint64) []foneroplugin.VoteOptionResult {
+		return []foneroplugin.VoteOptionResult{
+			{ID: "no", Bits: 0x01, Votes: no},
+			{ID: "yes", Bits: 0x02, Votes: yes},
+		}
+	}
+
+	var tests = []struct {
+		name     string
+		approved bool
+		want     []foneroplugin.TokenVoteResults
+	}{
+		{"approved", true, []foneroplugin.TokenVoteResults{
+			{Token: "a", Approved: true, Results: results(7, 3)},
+			{Token: "c", Approved: true, Results: results(8, 2)},
+		}},
+		{"rejected", false, []foneroplugin.TokenVoteResults{
+			{Token: "b", Approved: false, Results: results(3, 7)},
+		}},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			got, err := p.foneroVoteResultsByStatus(v.approved)
+			if err != nil {
+				t.Fatalf("foneroVoteResultsByStatus: %v", err)
+			}
+			if !reflect.DeepEqual(got, v.want) {
+				t.Fatalf("got %v, want %v", got, v.want)
+			}
+		})
+	}
+}
+
+func TestFoneroProposalSupportersCount(t *testing.T) {
+	p, cleanup := newTestPoliteiawww(t)
+	defer cleanup()
+
+	// Cast votes on proposal a where ticket0 and ticket1
+	// have voted more than once.
+	votes := make([]foneroplugin.CastVote, 0, 6)
+	for _, ticket := range []string{"ticket0", "ticket1", "ticket0",
+		"ticket2", "ticket1", "ticket0"} {
+		votes = append(votes, foneroplugin.CastVote{
+			Token:   "a",
+			Ticket:  ticket,
+			VoteBit: "1",
+		})
+	}
+	votes = append(votes, foneroplugin.CastVote{
+		Token:   "b",
+		Ticket:  "ticket3",
+		VoteBit: "2",
+	})
+	b, err := foneroplugin.EncodeBallot(foneroplugin.Ballot{
+		Votes: votes,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = p.cache.PluginExec(cache.PluginCommand{
+		ID:             foneroplugin.ID,
+		Command:        foneroplugin.CmdBallot,
+		CommandPayload: string(b),
+		ReplyPayload:   "{}",
+	})
+	if err != nil {
+		t.Fatalf("%v: %v", foneroplugin.CmdBallot, err)
+	}
+
+	var tests = []struct {
+		name           string
+		token          string
+		wantSupporters uint64
+		wantVotes      uint64
+	}{
+		{"duplicate tickets", "a", 3, 6},
+		{"unique tickets", "b", 1, 1},
+		{"no votes", "c", 0, 0},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			reply, err := p.foneroProposalSupportersCount(v.token)
+			if err != nil {
+				t.Fatalf("foneroProposalSupportersCount: %v", err)
+			}
+			if reply.Supporters != v.wantSupporters {
+				t.Fatalf("got supporters %v, want %v",
+					reply.Supporters, v.wantSupporters)
+			}
+			if reply.Votes != v.wantVotes {
+				t.Fatalf("got votes %v, want %v",
+					reply.Votes, v.wantVotes)
+			}
+		})
+	}
+}
+
+func TestFoneroVotesByBlockWindow(t *testing.T) {
+	p, cleanup := newTestPoliteiawww(t)
+	defer cleanup()
+
+	// Start votes with the provided voting periods
+	votes := []struct {
+		token string
+		start string
+		end   string
+	}{
+		{"inside", "120", "140"},
+		{"overlapstart", "90", "110"},
+		{"overlapend", "140", "160"},
+		{"spans", "50", "200"},
+		{"before", "50", "99"},
+		{"after", "151", "170"},
+	}
+	for _, v := range votes {
+		sv, err := foneroplugin.EncodeStartVote(foneroplugin.StartVote{
+			Vote: foneroplugin.Vote{
+				Token: v.token,
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		svr, err := foneroplugin.EncodeStartVoteReply(
+			foneroplugin.StartVoteReply{
+				StartBlockHeight: v.start,
+				EndHeight:        v.end,
+			})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = p.cache.PluginExec(cache.PluginCommand{
+			ID:             foneroplugin.ID,
+			Command:        foneroplugin.CmdStartVote,
+			CommandPayload: string(sv),
+			ReplyPayload:   string(svr),
+		})
+		if err != nil {
+			t.Fatalf("%v: %v", foneroplugin.CmdStartVote, err)
+		}
+	}
+
+	var tests = []struct {
+		name    string
+		start   uint64
+		end     uint64
+		want    []string
+		wantErr bool
+	}{
+		{"window", 100, 150, []string{"spans", "overlapstart",
+			"inside", "overlapend"}, false},
+		{"single block", 99, 99, []string{"before", "spans",
+			"overlapstart"}, false},
+		{"no votes", 300, 400, []string{}, false},
+		{"invalid window", 150, 100, nil, true},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			got, err := p.foneroVotesByBlockWindow(v.start, v.end)
+			if (err != nil) != v.wantErr {
+				t.Fatalf("got error %v, want error %v",
+					err, v.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(got, v.want) {
+				t.Fatalf("got %v, want %v", got, v.want)
+			}
+		})
+	}
+}
+
+func TestFoneroProposalVotesPaging(t *testing.T) {
+	p, cleanup := newTestPoliteiawww(t)
+	defer cleanup()
+
+	// exec executes a fonero plugin command against the cache.
+	exec := func(cmd string, payload []byte) {
+		_, err := p.cache.PluginExec(cache.PluginCommand{
+			ID:             foneroplugin.ID,
+			Command:        cmd,
+			CommandPayload: string(payload),
+			ReplyPayload:   "{}",
+		})
+		if err != nil {
+			t.Fatalf("%v: %v", cmd, err)
+		}
+	}
+
+	// Seed a start vote and cast votes whose tickets are not
+	// in sorted order.
+	const token = "a"
+	sv, err := foneroplugin.EncodeStartVote(foneroplugin.StartVote{
+		Vote: foneroplugin.Vote{
+			Token: token,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exec(foneroplugin.CmdStartVote, sv)
+
+	const castVotes = 23
+	votes := make([]foneroplugin.CastVote, 0, castVotes)
+	for i := castVotes - 1; i >= 0; i-- {
+		votes = append(votes, foneroplugin.CastVote{
+			Token:  token,
+			Ticket: fmt.Sprintf("ticket%03d", i),
+		})
+	}
+	b, err := foneroplugin.EncodeBallot(foneroplugin.Ballot{
+		Votes: votes,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exec(foneroplugin.CmdBallot, b)
+
+	var tests = []struct {
+		name  string
+		limit uint32
+	}{
+		{"single vote pages", 1},
+		{"partial last page", 5},
+		{"exact pages", castVotes},
+		{"limit past end", castVotes + 10},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			seen := make(map[string]bool, castVotes)
+			var prev string
+			var offset uint32
+			for {
+				vrr, err := p.foneroProposalVotes(token, false,
+					offset, v.limit)
+				if err != nil {
+					t.Fatalf("foneroProposalVotes: %v", err)
+				}
+				if vrr.StartVote.Vote.Token != token {
+					t.Fatalf("got start vote %q, want %q",
+						vrr.StartVote.Vote.Token, token)
+				}
+				if uint32(len(vrr.CastVotes)) > v.limit {
+					t.Fatalf("got %v votes, want at most %v",
+						len(vrr.CastVotes), v.limit)
+				}
+				for _, cv := range vrr.CastVotes {
+					if seen[cv.Ticket] {
+						t.Fatalf("duplicate ticket %v", cv.Ticket)
+					}
+					if cv.Ticket <= prev {
+						t.Fatalf("ticket %v out of order after %v",
+							cv.Ticket, prev)
+					}
+					seen[cv.Ticket] = true
+					prev = cv.Ticket
+				}
+				if uint32(len(vrr.CastVotes)) < v.limit {
+					break
+				}
+				offset += v.limit
+			}
+			if len(seen) != castVotes {
+				t.Fatalf("got %v votes, want %v", len(seen), castVotes)
+			}
+		})
+	}
+
+	// An unset limit returns all cast votes
+	vrr, err := p.foneroProposalVotes(token, false, 0, 0)
+	if err != nil {
+		t.Fatalf("foneroProposalVotes: %v", err)
+	}
+	if len(vrr.CastVotes) != castVotes {
+		t.Fatalf("got %v votes, want %v", len(vrr.CastVotes), castVotes)
+	}
+}
+
+func TestFoneroProposalVotesStarted(t *testing.T) {
+	p, cleanup := newTestPoliteiawww(t)
+	defer cleanup()
+
+	// Start the vote of proposal a without casting any votes.
+	// The vote of proposal b is never started.
+	sv, err := foneroplugin.EncodeStartVote(foneroplugin.StartVote{
+		Vote: foneroplugin.Vote{
+			Token: "a",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = p.cache.PluginExec(cache.PluginCommand{
+		ID:             foneroplugin.ID,
+		Command:        foneroplugin.CmdStartVote,
+		CommandPayload: string(sv),
+		ReplyPayload:   "{}",
+	})
+	if err != nil {
+		t.Fatalf("%v: %v", foneroplugin.CmdStartVote, err)
+	}
+
+	var tests = []struct {
+		name        string
+		token       string
+		tallyOnly   bool
+		wantStarted bool
+	}{
+		{"started without votes", "a", false, true},
+		{"started without votes tally", "a", true, true},
+		{"not started", "b", false, false},
+		{"not started tally", "b", true, false},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			vrr, err := p.foneroProposalVotes(v.token, v.tallyOnly, 0, 0)
+			if err != nil {
+				t.Fatalf("foneroProposalVotes: %v", err)
+			}
+			if vrr.Started != v.wantStarted {
+				t.Fatalf("got started %v, want %v", vrr.Started,
+					v.wantStarted)
+			}
+			if len(vrr.CastVotes) != 0 {
+				t.Fatalf("got %v votes, want 0", len(vrr.CastVotes))
+			}
+		})
+	}
+}
+
+func TestFoneroGetStartVoteReply(t *testing.T) {
+	p, cleanup := newTestPoliteiawww(t)
+	defer cleanup()
+
+	// Start the vote of proposal a. The vote of proposal b is
+	// never started.
+	svr := foneroplugin.StartVoteReply{
+		Version:          1,
+		StartBlockHeight: "100",
+		StartBlockHash:   "hash",
+		EndHeight:        "2116",
+		EligibleTickets:  []string{"ticket1", "ticket2"},
+	}
+	sv, err := foneroplugin.EncodeStartVote(foneroplugin.StartVote{
+		Vote: foneroplugin.Vote{
+			Token: "a",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	svrb, err := foneroplugin.EncodeStartVoteReply(svr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = p.cache.PluginExec(cache.PluginCommand{
+		ID:             foneroplugin.ID,
+		Command:        foneroplugin.CmdStartVote,
+		CommandPayload: string(sv),
+		ReplyPayload:   string(svrb),
+	})
+	if err != nil {
+		t.Fatalf("start vote: %v", err)
+	}
+
+	var tests = []struct {
+		name    string
+		token   string
+		want    *foneroplugin.StartVoteReply
+		wantErr error
+	}{
+		{"started", "a", &svr, nil},
+		{"not started", "b", nil, cache.ErrRecordNotFound},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			got, err := p.foneroGetStartVoteReply(v.token)
+			if err != v.wantErr {
+				t.Fatalf("got error %v, want %v", err, v.wantErr)
+			}
+			if !reflect.DeepEqual(got, v.want) {
+				t.Fatalf("got %v, want %v", got, v.want)
+			}
+		})
+	}
+}
+
+func TestFoneroGetVoteOptions(t *testing.T) {
+	p, cleanup := newTestPoliteiawww(t)
+	defer cleanup()
+
+	// Start a multi-option vote for proposal a. The vote of
+	// proposal b is never started.
+	vote := foneroplugin.Vote{
+		Token: "a",
+		Mask:  0x7,
+		Options: []foneroplugin.VoteOption{
+			{Id: "no", Description: "Don't approve", Bits: 0x1},
+			{Id: "yes", Description: "Approve", Bits: 0x2},
+			{Id: "abstain", Description: "Abstain", Bits: 0x4},
+		},
+	}
+	sv, err := foneroplugin.EncodeStartVote(foneroplugin.StartVote{
+		Vote: vote,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	svr, err := foneroplugin.EncodeStartVoteReply(
+		foneroplugin.StartVoteReply{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = p.cache.PluginExec(cache.PluginCommand{
+		ID:             foneroplugin.ID,
+		Command:        foneroplugin.CmdStartVote,
+		CommandPayload: string(sv),
+		ReplyPayload:   string(svr),
+	})
+	if err != nil {
+		t.Fatalf("start vote: %v", err)
+	}
+
+	var tests = []struct {
+		name    string
+		token   string
+		want    *foneroplugin.GetVoteOptionsReply
+		wantErr error
+	}{
+		{
+			"started",
+			"a",
+			&foneroplugin.GetVoteOptionsReply{
+				Mask:    vote.Mask,
+				Options: vote.Options,
+			},
+			nil,
+		},
+		{"not started", "b", nil, cache.ErrRecordNotFound},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			got, err := p.foneroGetVoteOptions(v.token)
+			if err != v.wantErr {
+				t.Fatalf("got error %v, want %v", err, v.wantErr)
+			}
+			if !reflect.DeepEqual(got, v.want) {
+				t.Fatalf("got %v, want %v", got, v.want)
+			}
+		})
+	}
+}
+
+func TestFoneroGetEligibleTicketCount(t *testing.T) {
+	p, cleanup := newTestPoliteiawww(t)
+	defer cleanup()
+
+	// Start the vote of proposal a with three eligible tickets. The
+	// vote of proposal b is never started.
+	sv, err := foneroplugin.EncodeStartVote(foneroplugin.StartVote{
+		Vote: foneroplugin.Vote{
+			Token: "a",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	svr, err := foneroplugin.EncodeStartVoteReply(
+		foneroplugin.StartVoteReply{
+			EligibleTickets: []string{"t1", "t2", "t3"},
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = p.cache.PluginExec(cache.PluginCommand{
+		ID:             foneroplugin.ID,
+		Command:        foneroplugin.CmdStartVote,
+		CommandPayload: string(sv),
+		ReplyPayload:   string(svr),
+	})
+	if err != nil {
+		t.Fatalf("start vote: %v", err)
+	}
+
+	var tests = []struct {
+		name    string
+		token   string
+		want    int
+		wantErr error
+	}{
+		{"started", "a", 3, nil},
+		{"not started", "b", 0, cache.ErrRecordNotFound},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			got, err := p.foneroGetEligibleTicketCount(v.token)
+			if err != v.wantErr {
+				t.Fatalf("got error %v, want %v", err, v.wantErr)
+			}
+			if got != v.want {
+				t.Fatalf("got %v, want %v", got, v.want)
+			}
+		})
+	}
+}
+
+func TestFoneroProposalActivityTimeline(t *testing.T) {
+	p, cleanup := newTestPoliteiawww(t)
+	defer cleanup()
+
+	// exec executes a fonero plugin command against the cache.
+	exec := func(cmd string, payload, reply []byte) {
+		_, err := p.cache.PluginExec(cache.PluginCommand{
+			ID:             foneroplugin.ID,
+			Command:        cmd,
+			CommandPayload: string(payload),
+			ReplyPayload:   string(reply),
+		})
+		if err != nil {
+			t.Fatalf("%v: %v", cmd, err)
+		}
+	}
+
+	// newComment adds a comment to proposal a.
+	newComment := func(commentID, publicKey string, timestamp int64) {
+		nc, err := foneroplugin.EncodeNewComment(
+			foneroplugin.NewComment{
+				Token:     "a",
+				ParentID:  "0",
+				Comment:   "comment " + commentID,
+				PublicKey: publicKey,
+			})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ncr, err := foneroplugin.EncodeNewCommentReply(
+			foneroplugin.NewCommentReply{
+				CommentID: commentID,
+				Timestamp: timestamp,
+			})
+		if err != nil {
+			t.Fatal(err)
+		}
+		exec(foneroplugin.CmdNewComment, nc, ncr)
+	}
+
+	// The vote is started and the votes are cast at the current
+	// time, so comment 3 is made an hour in the future to be the
+	// last event. The like of comment 1 and comment 2 have the same
+	// timestamp.
+	av, err := foneroplugin.EncodeAuthorizeVote(foneroplugin.AuthorizeVote{
+		Token:     "a",
+		Action:    foneroplugin.AuthVoteActionAuthorize,
+		PublicKey: "author",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	avr, err := foneroplugin.EncodeAuthorizeVoteReply(
+		foneroplugin.AuthorizeVoteReply{
+			RecordVersion: "1",
+			Timestamp:     100,
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exec(foneroplugin.CmdAuthorizeVote, av, avr)
+
+	newComment("1", "alice", 150)
+	newComment("2", "bob", 200)
+	newComment("3", "carol", time.Now().Add(time.Hour).Unix())
+
+	lc, err := foneroplugin.EncodeLikeComment(foneroplugin.LikeComment{
+		Token:     "a",
+		CommentID: "1",
+		Action:    foneroplugin.LikeActionUpvote,
+		PublicKey: "bob",
+		Timestamp: 200,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exec(foneroplugin.CmdLikeComment, lc, []byte("{}"))
+
+	sv, err := foneroplugin.EncodeStartVote(foneroplugin.StartVote{
+		PublicKey: "admin",
+		Vote: foneroplugin.Vote{
+			Token: "a",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	svr, err := foneroplugin.EncodeStartVoteReply(
+		foneroplugin.StartVoteReply{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exec(foneroplugin.CmdStartVote, sv, svr)
+
+	b, err := foneroplugin.EncodeBallot(foneroplugin.Ballot{
+		Votes: []foneroplugin.CastVote{
+			{Token: "a", Ticket: "t1", VoteBit: "1"},
+			{Token: "a", Ticket: "t2", VoteBit: "2"},
+			{Token: "b", Ticket: "t3", VoteBit: "1"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exec(foneroplugin.CmdBallot, b, []byte("{}"))
+
+	got, err := p.foneroProposalActivityTimeline("a")
+	if err != nil {
+		t.Fatalf("foneroProposalActivityTimeline: %v", err)
+	}
+
+	// The timestamps of the start vote and the cast votes are
+	// only checked for their order.
+	for i := 1; i < len(got); i++ {
+		if got[i].Timestamp < got[i-1].Timestamp {
+			t.Fatalf("event %v is older than event %v: %v", i, i-1,
+				got)
+		}
+	}
+	for i, v := range got {
+		switch v.Type {
+		case foneroplugin.TimelineEventStartVote,
+			foneroplugin.TimelineEventCastVote:
+			got[i].Timestamp = 0
+		}
+	}
+
+	want := []foneroplugin.TimelineEvent{
+		{
+			Type:      foneroplugin.TimelineEventAuthorizeVote,
+			Timestamp: 100,
+			PublicKey: "author",
+			Action:    foneroplugin.AuthVoteActionAuthorize,
+		},
+		{
+			Type:      foneroplugin.TimelineEventComment,
+			Timestamp: 150,
+			PublicKey: "alice",
+			CommentID: "1",
+		},
+		{
+			Type:      foneroplugin.TimelineEventComment,
+			Timestamp: 200,
+			PublicKey: "bob",
+			CommentID: "2",
+		},
+		{
+			Type:      foneroplugin.TimelineEventLikeComment,
+			Timestamp: 200,
+			PublicKey: "bob",
+			CommentID: "1",
+			Action:    foneroplugin.LikeActionUpvote,
+		},
+		{
+			Type:      foneroplugin.TimelineEventStartVote,
+			PublicKey: "admin",
+		},
+		{
+			Type:    foneroplugin.TimelineEventCastVote,
+			Ticket:  "t1",
+			VoteBit: "1",
+		},
+		{
+			Type:    foneroplugin.TimelineEventCastVote,
+			Ticket:  "t2",
+			VoteBit: "2",
+		},
+		{
+			Type:      foneroplugin.TimelineEventComment,
+			Timestamp: got[len(got)-1].Timestamp,
+			PublicKey: "carol",
+			CommentID: "3",
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	// A proposal without activity has an empty timeline
+	got, err = p.foneroProposalActivityTimeline("c")
+	if err != nil {
+		t.Fatalf("foneroProposalActivityTimeline: %v", err)
+	}
+	if len(got) != 0 {
+		t.Fatalf("got %v events, want 0", len(got))
+	}
+}
+
+func TestFoneroVoteResultsDigest(t *testing.T) {
+	p, cleanup := newTestPoliteiawww(t)
+	defer cleanup()
+
+	// exec executes a fonero plugin command against the cache.
+	exec := func(cmd string, payload, reply []byte) {
+		_, err := p.cache.PluginExec(cache.PluginCommand{
+			ID:             foneroplugin.ID,
+			Command:        cmd,
+			CommandPayload: string(payload),
+			ReplyPayload:   string(reply),
+		})
+		if err != nil {
+			t.Fatalf("%v: %v", cmd, err)
+		}
+	}
+
+	// Seed a finished vote with a few cast votes
+	const token = "a"
+	options := []foneroplugin.VoteOption{
+		{Id: "no", Bits: 0x1},
+		{Id: "yes", Bits: 0x2},
+	}
+	sv, err := foneroplugin.EncodeStartVote(foneroplugin.StartVote{
+		Vote: foneroplugin.Vote{
+			Token:   token,
+			Options: options,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	svr, err := foneroplugin.EncodeStartVoteReply(
+		foneroplugin.StartVoteReply{
+			EndHeight: "100",
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exec(foneroplugin.CmdStartVote, sv, svr)
+
+	votes := []foneroplugin.CastVote{
+		{Token: token, Ticket: "ticket3", VoteBit: "2", Signature: "sig3"},
+		{Token: token, Ticket: "ticket1", VoteBit: "1", Signature: "sig1"},
+		{Token: token, Ticket: "ticket2", VoteBit: "2", Signature: "sig2"},
+	}
+	b, err := foneroplugin.EncodeBallot(foneroplugin.Ballot{
+		Votes: votes,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exec(foneroplugin.CmdBallot, b, nil)
+
+	// The digest is not available until the vote results have
+	// been loaded.
+	_, err = p.foneroVoteResultsDigest(token)
+	if err != cache.ErrRecordNotFound {
+		t.Fatalf("got error %v, want %v", err, cache.ErrRecordNotFound)
+	}
+
+	lvr, err := foneroplugin.EncodeLoadVoteResults(
+		foneroplugin.LoadVoteResults{
+			BestBlock: 100,
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exec(foneroplugin.CmdLoadVoteResults, lvr, nil)
+
+	// The digest must be stable across calls and must match an
+	// independently computed digest.
+	results := []foneroplugin.VoteOptionResult{
+		{ID: "no", Bits: 0x1, Votes: 1},
+		{ID: "yes", Bits: 0x2, Votes: 2},
+	}
+	want := foneroplugin.ComputeVoteResultsDigest(votes, results)
+	for i := 0; i < 2; i++ {
+		got, err := p.foneroVoteResultsDigest(token)
+		if err != nil {
+			t.Fatalf("foneroVoteResultsDigest: %v", err)
+		}
+		if got != want {
+			t.Fatalf("call %v: got digest %v, want %v", i, got, want)
+		}
+	}
+
+	// The digest does not depend on the order of the cast votes
+	// but changes when a cast vote is altered.
+	reordered := []foneroplugin.CastVote{votes[1], votes[2], votes[0]}
+	got := foneroplugin.ComputeVoteResultsDigest(reordered, results)
+	if got != want {
+		t.Fatalf("got reordered digest %v, want %v", got, want)
+	}
+	altered := make([]foneroplugin.CastVote, len(votes))
+	copy(altered, votes)
+	altered[1].VoteBit = "2"
+	got = foneroplugin.ComputeVoteResultsDigest(altered, results)
+	if got == want {
+		t.Fatalf("altered vote did not change the digest")
+	}
+}
+
+func TestFoneroStreamComments(t *testing.T) {
+	p, cleanup := newTestPoliteiawww(t)
+	defer cleanup()
+
+	// Add enough comments to require multiple pages and
+	// censor some of them.
+	total := 2*streamCommentsPageSize + 3
+	var censored int
+	for i := 1; i <= total; i++ {
+		commentID := strconv.Itoa(i)
+		nc, err := foneroplugin.EncodeNewComment(
+			foneroplugin.NewComment{
+				Token:    "a",
+				ParentID: "0",
+				Comment:  "comment " + commentID,
+			})
+		if err != nil {
+			t.Fatalf("encode new comment: %v", err)
+		}
+		ncr, err := foneroplugin.EncodeNewCommentReply(
+			foneroplugin.NewCommentReply{
+				CommentID: commentID,
+				Timestamp: int64(i),
+			})
+		if err != nil {
+			t.Fatalf("encode new comment reply: %v", err)
+		}
+		_, err = p.cache.PluginExec(cache.PluginCommand{
+			ID:             foneroplugin.ID,
+			Command:        foneroplugin.CmdNewComment,
+			CommandPayload: string(nc),
+			ReplyPayload:   string(ncr),
+		})
+		if err != nil {
+			t.Fatalf("new comment: %v", err)
+		}
+
+		if i%100 != 0 {
+			continue
+		}
+		cc, err := foneroplugin.EncodeCensorComment(
+			foneroplugin.CensorComment{
+				Token:     "a",
+				CommentID: commentID,
+			})
+		if err != nil {
+			t.Fatalf("encode censor comment: %v", err)
+		}
+		_, err = p.cache.PluginExec(cache.PluginCommand{
+			ID:             foneroplugin.ID,
+			Command:        foneroplugin.CmdCensorComment,
+			CommandPayload: string(cc),
+			ReplyPayload:   "{}",
+		})
+		if err != nil {
+			t.Fatalf("censor comment: %v", err)
+		}
+		censored++
+	}
+
+	var buf bytes.Buffer
+	err := p.foneroStreamComments(&buf, "a")
+	if err != nil {
+		t.Fatalf("foneroStreamComments: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != total {
+		t.Fatalf("got %v lines, want %v", len(lines), total)
+	}
+
+	// Every line must be a comment and every comment must be
+	// streamed exactly once.
+	seen := make(map[string]bool, total)
+	var gotCensored int
+	for i, v := range lines {
+		var c foneroplugin.Comment
+		err := json.Unmarshal([]byte(v), &c)
+		if err != nil {
+			t.Fatalf("line %v: %v", i, err)
+		}
+		if seen[c.CommentID] {
+			t.Fatalf("line %v: duplicate comment %v", i, c.CommentID)
+		}
+		seen[c.CommentID] = true
+		if c.Censored {
+			gotCensored++
+		}
+	}
+	if gotCensored != censored {
+		t.Fatalf("got %v censored comments, want %v",
+			gotCensored, censored)
+	}
+
+	// A proposal without comments produces no output
+	buf.Reset()
+	err = p.foneroStreamComments(&buf, "b")
+	if err != nil {
+		t.Fatalf("foneroStreamComments: %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("got output %q, want none", buf.String())
+	}
+}
+
+// testWriterFunc is an io.Writer that calls the underlying function on every
+// write.
+type testWriterFunc func([]byte) (int, error)
+
+// Write calls the underlying function.
+func (f testWriterFunc) Write(b []byte) (int, error) {
+	return f(b)
+}
+
+func TestFoneroStreamProposalVotesCSV(t *testing.T) {
+	p, cleanup := newTestPoliteiawww(t)
+	defer cleanup()
+
+	// exec executes a fonero plugin command against the cache.
+	exec := func(cmd string, payload []byte) {
+		_, err := p.cache.PluginExec(cache.PluginCommand{
+			ID:             foneroplugin.ID,
+			Command:        cmd,
+			CommandPayload: string(payload),
+			ReplyPayload:   "{}",
+		})
+		if err != nil {
+			t.Fatalf("%v: %v", cmd, err)
+		}
+	}
+
+	// Seed more cast votes than fit in a single page. Every
+	// tenth vote uses a vote bit that does not match any of the
+	// vote options.
+	const token = "a"
+	sv, err := foneroplugin.EncodeStartVote(foneroplugin.StartVote{
+		Vote: foneroplugin.Vote{
+			Token: token,
+			Options: []foneroplugin.VoteOption{
+				{Id: "no", Bits: 0x1},
+				{Id: "yes", Bits: 0x2},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exec(foneroplugin.CmdStartVote, sv)
+
+	const castVotes = streamVotesPageSize + 7
+	votes := make([]foneroplugin.CastVote, 0, castVotes)
+	want := make(map[string][]string, castVotes) // [ticket]row
+	for i := 0; i < castVotes; i++ {
+		ticket := fmt.Sprintf("ticket%04d", i)
+		voteBit, option := "1", "no"
+		switch {
+		case i%10 == 0:
+			voteBit, option = "4", ""
+		case i%2 == 0:
+			voteBit, option = "2", "yes"
+		}
+		votes = append(votes, foneroplugin.CastVote{
+			Token:     token,
+			Ticket:    ticket,
+			VoteBit:   voteBit,
+			Signature: "sig" + ticket,
+		})
+		want[ticket] = []string{ticket, voteBit, option, "sig" + ticket}
+	}
+	b, err := foneroplugin.EncodeBallot(foneroplugin.Ballot{
+		Votes: votes,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exec(foneroplugin.CmdBallot, b)
+
+	var buf bytes.Buffer
+	err = p.foneroStreamProposalVotesCSV(&buf, token)
+	if err != nil {
+		t.Fatalf("foneroStreamProposalVotesCSV: %v", err)
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("read csv: %v", err)
+	}
+	if len(rows) != castVotes+1 {
+		t.Fatalf("got %v rows, want %v", len(rows), castVotes+1)
+	}
+	header := []string{"ticket", "votebit", "option", "signature"}
+	if !reflect.DeepEqual(rows[0], header) {
+		t.Fatalf("got header %v, want %v", rows[0], header)
+	}
+
+	// Every cast vote must be written exactly once, ordered by
+	// ticket.
+	for i, v := range rows[1:] {
+		wantRow, ok := want[v[0]]
+		if !ok {
+			t.Fatalf("row %v: unexpected or duplicate ticket %v",
+				i, v[0])
+		}
+		if !reflect.DeepEqual(v, wantRow) {
+			t.Fatalf("row %v: got %v, want %v", i, v, wantRow)
+		}
+		if i > 0 && v[0] <= rows[i][0] {
+			t.Fatalf("row %v: ticket %v out of order after %v",
+				i, v[0], rows[i][0])
+		}
+		delete(want, v[0])
+	}
+
+	// A vote that is cast while the votes are being streamed
+	// must not shift the remaining pages.  The vote is cast once
+	// the first page has been written and sorts before every
+	// ticket that has already been written.
+	buf.Reset()
+	var cast bool
+	w := testWriterFunc(func(b []byte) (int, error) {
+		if !cast {
+			cast = true
+			ballot, err := foneroplugin.EncodeBallot(foneroplugin.Ballot{
+				Votes: []foneroplugin.CastVote{{
+					Token:     token,
+					Ticket:    "aticket",
+					VoteBit:   "1",
+					Signature: "sigaticket",
+				}},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			exec(foneroplugin.CmdBallot, ballot)
+		}
+		return buf.Write(b)
+	})
+	err = p.foneroStreamProposalVotesCSV(w, token)
+	if err != nil {
+		t.Fatalf("foneroStreamProposalVotesCSV: %v", err)
+	}
+	rows, err = csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("read csv: %v", err)
+	}
+	if len(rows) != castVotes+1 {
+		t.Fatalf("got %v rows, want %v", len(rows), castVotes+1)
+	}
+	seen := make(map[string]struct{}, castVotes)
+	for _, v := range rows[1:] {
+		if _, ok := seen[v[0]]; ok {
+			t.Fatalf("duplicate ticket %v", v[0])
+		}
+		seen[v[0]] = struct{}{}
+	}
+
+	// A proposal without cast votes only produces the header
+	buf.Reset()
+	err = p.foneroStreamProposalVotesCSV(&buf, "b")
+	if err != nil {
+		t.Fatalf("foneroStreamProposalVotesCSV: %v", err)
+	}
+	wantCSV := "ticket,votebit,option,signature\n"
+	if buf.String() != wantCSV {
+		t.Fatalf("got %q, want %q", buf.String(), wantCSV)
+	}
+}
+
+func TestFoneroGetCommentVersions(t *testing.T) {
+	p, cleanup := newTestPoliteiawww(t)
+	defer cleanup()
+
+	// newComment adds a comment to the cache. Adding a comment
+	// that already exists overwrites it with a new version.
+	newComment := func(comment, signature string, timestamp int64) {
+		nc, err := foneroplugin.EncodeNewComment(
+			foneroplugin.NewComment{
+				Token:     "a",
+				ParentID:  "0",
+				Comment:   comment,
+				Signature: signature,
+				PublicKey: "pk",
+			})
+		if err != nil {
+			t.Fatalf("encode new comment: %v", err)
+		}
+		ncr, err := foneroplugin.EncodeNewCommentReply(
+			foneroplugin.NewCommentReply{
+				CommentID: "1",
+				Receipt:   "receipt " + signature,
+				Timestamp: timestamp,
+			})
+		if err != nil {
+			t.Fatalf("encode new comment reply: %v", err)
+		}
+		_, err = p.cache.PluginExec(cache.PluginCommand{
+			ID:             foneroplugin.ID,
+			Command:        foneroplugin.CmdNewComment,
+			CommandPayload: string(nc),
+			ReplyPayload:   string(ncr),
+		})
+		if err != nil {
+			t.Fatalf("new comment: %v", err)
+		}
+	}
+
+	// The original comment is the only version until the
+	// comment is changed.
+	newComment("original", "sig1", 100)
+	got, err := p.foneroGetCommentVersions("a", "1")
+	if err != nil {
+		t.Fatalf("foneroGetCommentVersions: %v", err)
+	}
+	if len(got) != 1 || got[0].Version != 1 ||
+		got[0].Comment != "original" {
+		t.Fatalf("got %v, want the original version only", got)
+	}
+
+	// Overwrite the comment and then censor it
+	newComment("updated", "sig2", 200)
+	cc, err := foneroplugin.EncodeCensorComment(
+		foneroplugin.CensorComment{
+			Token:     "a",
+			CommentID: "1",
+			Reason:    "spam",
+			Signature: "adminsig",
+			PublicKey: "adminpk",
+			Receipt:   "receipt adminsig",
+			Timestamp: 300,
+		})
+	if err != nil {
+		t.Fatalf("encode censor comment: %v", err)
+	}
+	_, err = p.cache.PluginExec(cache.PluginCommand{
+		ID:             foneroplugin.ID,
+		Command:        foneroplugin.CmdCensorComment,
+		CommandPayload: string(cc),
+		ReplyPayload:   "{}",
+	})
+	if err != nil {
+		t.Fatalf("censor comment: %v", err)
+	}
+
+	want := []foneroplugin.CommentVersion{
+		{
+			Version:   1,
+			Comment:   "original",
+			Signature: "sig1",
+			PublicKey: "pk",
+			Receipt:   "receipt sig1",
+			Timestamp: 100,
+		},
+		{
+			Version:   2,
+			Comment:   "updated",
+			Signature: "sig2",
+			PublicKey: "pk",
+			Receipt:   "receipt sig2",
+			Timestamp: 200,
+		},
+		{
+			Version:   3,
+			Signature: "adminsig",
+			PublicKey: "adminpk",
+			Receipt:   "receipt adminsig",
+			Timestamp: 300,
+			Censored:  true,
+			Reason:    "spam",
+		},
+	}
+	got, err = p.foneroGetCommentVersions("a", "1")
+	if err != nil {
+		t.Fatalf("foneroGetCommentVersions: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	// Unknown comments are not found
+	_, err = p.foneroGetCommentVersions("a", "2")
+	if err != cache.ErrRecordNotFound {
+		t.Fatalf("got error %v, want %v", err, cache.ErrRecordNotFound)
+	}
+}
+
+func TestFoneroCommentThreadStats(t *testing.T) {
+	p, cleanup := newTestPoliteiawww(t)
+	defer cleanup()
+
+	// newComment adds a comment to the cache and censors it
+	// if requested.
+	newComment := func(token, commentID, parentID string, censored bool) {
+		nc, err := foneroplugin.EncodeNewComment(
+			foneroplugin.NewComment{
+				Token:    token,
+				ParentID: parentID,
+				Comment:  "comment " + commentID,
+			})
+		if err != nil {
+			t.Fatalf("encode new comment: %v", err)
+		}
+		ncr, err := foneroplugin.EncodeNewCommentReply(
+			foneroplugin.NewCommentReply{
+				CommentID: commentID,
+			})
+		if err != nil {
+			t.Fatalf("encode new comment reply: %v", err)
+		}
+		_, err = p.cache.PluginExec(cache.PluginCommand{
+			ID:             foneroplugin.ID,
+			Command:        foneroplugin.CmdNewComment,
+			CommandPayload: string(nc),
+			ReplyPayload:   string(ncr),
+		})
+		if err != nil {
+			t.Fatalf("new comment: %v", err)
+		}
+		if !censored {
+			return
+		}
+		cc, err := foneroplugin.EncodeCensorComment(
+			foneroplugin.CensorComment{
+				Token:     token,
+				CommentID: commentID,
+			})
+		if err != nil {
+			t.Fatalf("encode censor comment: %v", err)
+		}
+		_, err = p.cache.PluginExec(cache.PluginCommand{
+			ID:             foneroplugin.ID,
+			Command:        foneroplugin.CmdCensorComment,
+			CommandPayload: string(cc),
+			ReplyPayload:   "{}",
+		})
+		if err != nil {
+			t.Fatalf("censor comment: %v", err)
+		}
+	}
+
+	// Thread 1 is 3 levels deep with a censored reply in the
+	// middle.  Thread 5 has no replies.  Thread 6 has two
+	// direct replies.  The parent of comment 10 is missing.
+	newComment("a", "1", "0", false)
+	newComment("a", "2", "1", false)
+	newComment("a", "3", "2", true)
+	newComment("a", "4", "3", false)
+	newComment("a", "5", "0", false)
+	newComment("a", "6", "0", false)
+	newComment("a", "7", "6", false)
+	newComment("a", "8", "6", true)
+	newComment("a", "10", "9", false)
+	newComment("b", "1", "0", false)
+
+	var tests = []struct {
+		name  string
+		token string
+		want  []foneroplugin.CommentThread
+	}{
+		{"nested threads", "a", []foneroplugin.CommentThread{
+			{CommentID: "1", Replies: 2, Censored: 1},
+			{CommentID: "5"},
+			{CommentID: "6", Replies: 1, Censored: 1},
+		}},
+		{"root only", "b", []foneroplugin.CommentThread{
+			{CommentID: "1"},
+		}},
+		{"no comments", "c", []foneroplugin.CommentThread{}},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			got, err := p.foneroCommentThreadStats(v.token)
+			if err != nil {
+				t.Fatalf("foneroCommentThreadStats: %v", err)
+			}
+			if !reflect.DeepEqual(got, v.want) {
+				t.Fatalf("got threads %v, want %v", got, v.want)
+			}
+		})
+	}
+}
+
+func TestFoneroActivityWindow(t *testing.T) {
+	p, cleanup := newTestPoliteiawww(t)
+	defer cleanup()
+
+	// exec executes a fonero plugin command against the cache.
+	exec := func(cmd string, payload, reply []byte) {
+		_, err := p.cache.PluginExec(cache.PluginCommand{
+			ID:             foneroplugin.ID,
+			Command:        cmd,
+			CommandPayload: string(payload),
+			ReplyPayload:   string(reply),
+		})
+		if err != nil {
+			t.Fatalf("%v: %v", cmd, err)
+		}
+	}
+
+	// newComment adds a comment with the passed in timestamp.
+	newComment := func(token, commentID string, ts int64) {
+		nc, err := foneroplugin.EncodeNewComment(
+			foneroplugin.NewComment{
+				Token:    token,
+				ParentID: "0",
+			})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ncr, err := foneroplugin.EncodeNewCommentReply(
+			foneroplugin.NewCommentReply{
+				CommentID: commentID,
+				Timestamp: ts,
+			})
+		if err != nil {
+			t.Fatal(err)
+		}
+		exec(foneroplugin.CmdNewComment, nc, ncr)
+	}
+
+	// castVotes casts the passed in number of votes.  The votes
+	// are timestamped with the current time by the cache.
+	castVotes := func(token string, count int) {
+		votes := make([]foneroplugin.CastVote, 0, count)
+		for i := 0; i < count; i++ {
+			votes = append(votes, foneroplugin.CastVote{
+				Token:  token,
+				Ticket: fmt.Sprintf("%v%v", token, i),
+			})
+		}
+		b, err := foneroplugin.EncodeBallot(foneroplugin.Ballot{
+			Votes: votes,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		exec(foneroplugin.CmdBallot, b, nil)
+	}
+
+	newComment("a", "1", 100)
+	newComment("a", "2", 150)
+	newComment("a", "3", 300)
+	newComment("b", "1", 200)
+	newComment("c", "1", 50)
+	castVotes("a", 2)
+	castVotes("d", 3)
+	now := time.Now().Unix()
+
+	var tests = []struct {
+		name       string
+		start, end int64
+		want       []foneroplugin.ProposalActivity
+		wantErr    bool
+	}{
+		{"comments only", 100, 200, []foneroplugin.ProposalActivity{
+			{Token: "a", Comments: 2},
+			{Token: "b", Comments: 1},
+		}, false},
+		{"single timestamp", 300, 300, []foneroplugin.ProposalActivity{
+			{Token: "a", Comments: 1},
+		}, false},
+		{"votes only", now - 60, now + 60, []foneroplugin.ProposalActivity{
+			{Token: "a", CastVotes: 2},
+			{Token: "d", CastVotes: 3},
+		}, false},
+		{"comments and votes", 0, now + 60, []foneroplugin.ProposalActivity{
+			{Token: "a", Comments: 3, CastVotes: 2},
+			{Token: "b", Comments: 1},
+			{Token: "c", Comments: 1},
+			{Token: "d", CastVotes: 3},
+		}, false},
+		{"no activity", 400, 500, []foneroplugin.ProposalActivity{}, false},
+		{"invalid range", 200, 100, nil, true},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			got, err := p.foneroActivityWindow(v.start, v.end)
+			if (err != nil) != v.wantErr {
+				t.Fatalf("got error %v, want error %v", err, v.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(got, v.want) {
+				t.Fatalf("got activity %v, want %v", got, v.want)
+			}
+		})
+	}
+}
+
+func TestFoneroCurrentCommentLikes(t *testing.T) {
+	p, cleanup := newTestPoliteiawww(t)
+	defer cleanup()
+
+	// like executes a like or a like undo of the passed in public
+	// key against the cache.
+	like := func(publicKey, action string) {
+		cmd := foneroplugin.CmdLikeComment
+		payload, err := foneroplugin.EncodeLikeComment(
+			foneroplugin.LikeComment{
+				Token:     "a",
+				CommentID: "1",
+				Action:    action,
+				PublicKey: publicKey,
+			})
+		var reply []byte
+		if action == foneroplugin.LikeActionUndo {
+			cmd = foneroplugin.CmdLikeCommentUndo
+			payload, err = foneroplugin.EncodeLikeCommentUndo(
+				foneroplugin.LikeCommentUndo{
+					Token:     "a",
+					CommentID: "1",
+					PublicKey: publicKey,
+				})
+			if err != nil {
+				t.Fatal(err)
+			}
+			reply, err = foneroplugin.EncodeLikeCommentUndoReply(
+				foneroplugin.LikeCommentUndoReply{
+					Receipt:   "receipt",
+					Timestamp: 1,
+				})
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = p.cache.PluginExec(cache.PluginCommand{
+			ID:             foneroplugin.ID,
+			Command:        cmd,
+			CommandPayload: string(payload),
+			ReplyPayload:   string(reply),
+		})
+		if err != nil {
+			t.Fatalf("%v: %v", cmd, err)
+		}
+	}
+
+	const (
+		up   = foneroplugin.LikeActionUpvote
+		down = foneroplugin.LikeActionDownvote
+		undo = foneroplugin.LikeActionUndo
+	)
+
+	// Each step applies a like and asserts the resulting current
+	// likes of the comment.
+	var steps = []struct {
+		name      string
+		publicKey string
+		action    string
+		want      map[string]string // [publicKey]action
+	}{
+		{"pk1 up", "pk1", up, map[string]string{"pk1": up}},
+		{"pk1 undo", "pk1", undo, map[string]string{}},
+		{"pk1 down", "pk1", down, map[string]string{"pk1": down}},
+		{"pk2 up", "pk2", up, map[string]string{"pk1": down, "pk2": up}},
+		{"pk2 flip", "pk2", down, map[string]string{"pk1": down,
+			"pk2": down}},
+		{"pk1 repeat", "pk1", down, map[string]string{"pk2": down}},
+		{"pk2 undo", "pk2", undo, map[string]string{}},
+	}
+
+	for _, v := range steps {
+		like(v.publicKey, v.action)
+
+		current, err := p.foneroCurrentCommentLikes("a", "1")
+		if err != nil {
+			t.Fatalf("%v: foneroCurrentCommentLikes: %v", v.name, err)
+		}
+		got := make(map[string]string, len(current))
+		for _, lc := range current {
+			got[lc.PublicKey] = lc.Action
+		}
+		if !reflect.DeepEqual(got, v.want) {
+			t.Fatalf("%v: got current likes %v, want %v",
+				v.name, got, v.want)
+		}
+	}
+
+	// The like history is retained
+	history, err := p.foneroCommentLikes("a", "1")
+	if err != nil {
+		t.Fatalf("foneroCommentLikes: %v", err)
+	}
+	if len(history) != len(steps) {
+		t.Fatalf("got %v likes in history, want %v",
+			len(history), len(steps))
+	}
+}
+
+func TestFoneroTopComments(t *testing.T) {
+	p, cleanup := newTestPoliteiawww(t)
+	defer cleanup()
+
+	// exec executes a fonero plugin command against the cache.
+	exec := func(cmd string, payload []byte, err error) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = p.cache.PluginExec(cache.PluginCommand{
+			ID:             foneroplugin.ID,
+			Command:        cmd,
+			CommandPayload: string(payload),
+		})
+		if err != nil {
+			t.Fatalf("%v: %v", cmd, err)
+		}
+	}
+
+	// newComment adds a comment with the passed in timestamp.
+	newComment := func(token, commentID string, ts int64) {
+		nc, err := foneroplugin.EncodeNewComment(
+			foneroplugin.NewComment{
+				Token:    token,
+				ParentID: "0",
+			})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ncr, err := foneroplugin.EncodeNewCommentReply(
+			foneroplugin.NewCommentReply{
+				CommentID: commentID,
+				Timestamp: ts,
+			})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = p.cache.PluginExec(cache.PluginCommand{
+			ID:             foneroplugin.ID,
+			Command:        foneroplugin.CmdNewComment,
+			CommandPayload: string(nc),
+			ReplyPayload:   string(ncr),
+		})
+		if err != nil {
+			t.Fatalf("%v: %v", foneroplugin.CmdNewComment, err)
+		}
+	}
+
+	// like applies a like action of a public key to a comment.
+	like := func(token, commentID, publicKey, action string) {
+		lc, err := foneroplugin.EncodeLikeComment(
+			foneroplugin.LikeComment{
+				Token:     token,
+				CommentID: commentID,
+				Action:    action,
+				PublicKey: publicKey,
+			})
+		exec(foneroplugin.CmdLikeComment, lc, err)
+	}
+
+	const (
+		up   = foneroplugin.LikeActionUpvote
+		down = foneroplugin.LikeActionDownvote
+	)
+
+	// Comment 2 and 4 tie on score so the older comment 2 ranks
+	// first. The repeated upvote of pk3 on comment 2 undoes its
+	// previous upvote. Comment 5 has the highest score but has been
+	// censored.
+	for i := 1; i <= 5; i++ {
+		newComment("a", strconv.Itoa(i), int64(i*100))
+	}
+	newComment("b", "1", 100)
+	like("a", "1", "pk1", up)
+	like("a", "2", "pk1", up)
+	like("a", "2", "pk2", up)
+	like("a", "2", "pk3", up)
+	like("a", "2", "pk3", up)
+	like("a", "3", "pk1", down)
+	like("a", "4", "pk1", up)
+	like("a", "4", "pk2", up)
+	like("a", "5", "pk1", up)
+	like("a", "5", "pk2", up)
+	like("a", "5", "pk3", up)
+	like("b", "1", "pk1", up)
+	cc, err := foneroplugin.EncodeCensorComment(
+		foneroplugin.CensorComment{
+			Token:     "a",
+			CommentID: "5",
+		})
+	exec(foneroplugin.CmdCensorComment, cc, err)
+
+	var tests = []struct {
+		name       string
+		token      string
+		limit      uint32
+		wantIDs    []string
+		wantScores []int64
+	}{
+		{"all comments", "a", 0, []string{"2", "4", "1", "3"},
+			[]int64{2, 2, 1, -1}},
+		{"limit", "a", 2, []string{"2", "4"}, []int64{2, 2}},
+		{"limit exceeds comments", "a", 10, []string{"2", "4", "1", "3"},
+			[]int64{2, 2, 1, -1}},
+		{"other proposal", "b", 0, []string{"1"}, []int64{1}},
+		{"no comments", "c", 0, []string{}, []int64{}},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			comments, err := p.foneroTopComments(v.token, v.limit)
+			if err != nil {
+				t.Fatalf("foneroTopComments: %v", err)
+			}
+			ids := make([]string, 0, len(comments))
+			scores := make([]int64, 0, len(comments))
+			for _, c := range comments {
+				ids = append(ids, c.CommentID)
+				scores = append(scores, c.ResultVotes)
+			}
+			if !reflect.DeepEqual(ids, v.wantIDs) {
+				t.Fatalf("got comments %v, want %v", ids, v.wantIDs)
+			}
+			if !reflect.DeepEqual(scores, v.wantScores) {
+				t.Fatalf("got scores %v, want %v",
+					scores, v.wantScores)
+			}
+		})
+	}
+}
+
+func TestFoneroCommentVisibility(t *testing.T) {
+	p, cleanup := newTestPoliteiawww(t)
+	defer cleanup()
+
+	// newComment adds a comment with the passed in body.
+	newComment := func(commentID, body string, ts int64) {
+		nc, err := foneroplugin.EncodeNewComment(
+			foneroplugin.NewComment{
+				Token:    "a",
+				ParentID: "0",
+				Comment:  body,
+			})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ncr, err := foneroplugin.EncodeNewCommentReply(
+			foneroplugin.NewCommentReply{
+				CommentID: commentID,
+				Timestamp: ts,
+			})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = p.cache.PluginExec(cache.PluginCommand{
+			ID:             foneroplugin.ID,
+			Command:        foneroplugin.CmdNewComment,
+			CommandPayload: string(nc),
+			ReplyPayload:   string(ncr),
+		})
+		if err != nil {
+			t.Fatalf("%v: %v", foneroplugin.CmdNewComment, err)
+		}
+	}
+
+	// setVisibility hides or unhides a comment.
+	setVisibility := func(commentID string, hidden bool) error {
+		scv, err := foneroplugin.EncodeSetCommentVisibility(
+			foneroplugin.SetCommentVisibility{
+				Token:     "a",
+				CommentID: commentID,
+				Hidden:    hidden,
+			})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = p.cache.PluginExec(cache.PluginCommand{
+			ID:             foneroplugin.ID,
+			Command:        foneroplugin.CmdSetCommentVisibility,
+			CommandPayload: string(scv),
+		})
+		return err
+	}
+
+	newComment("1", "first", 100)
+	newComment("2", "second", 200)
+	newComment("3", "third", 300)
+
+	err := setVisibility("4", true)
+	if err != cache.ErrRecordNotFound {
+		t.Fatalf("got error %v, want %v", err, cache.ErrRecordNotFound)
+	}
+
+	// Each step changes the visibility of a comment and asserts
+	// the comments that are returned to normal clients and to
+	// admins along with the hidden comments.
+	var steps = []struct {
+		name       string
+		commentID  string
+		hidden     bool
+		wantPublic []string
+		wantAdmin  []string
+		wantHidden []string
+	}{
+		{"hide 2", "2", true, []string{"1", "3"},
+			[]string{"1", "2", "3"}, []string{"2"}},
+		{"hide 3", "3", true, []string{"1"},
+			[]string{"1", "2", "3"}, []string{"2", "3"}},
+		{"unhide 2", "2", false, []string{"1", "2"},
+			[]string{"1", "2", "3"}, []string{"3"}},
+		{"unhide 3", "3", false, []string{"1", "2", "3"},
+			[]string{"1", "2", "3"}, []string{}},
+	}
+
+	bodies := map[string]string{"1": "first", "2": "second", "3": "third"}
+	for _, v := range steps {
+		err := setVisibility(v.commentID, v.hidden)
+		if err != nil {
+			t.Fatalf("%v: set visibility: %v", v.name, err)
+		}
+
+		public, err := p.getPropComments("a", false)
+		if err != nil {
+			t.Fatalf("%v: getPropComments: %v", v.name, err)
+		}
+		admin, err := p.getPropComments("a", true)
+		if err != nil {
+			t.Fatalf("%v: getPropComments: %v", v.name, err)
+		}
+
+		ids := func(comments []www.Comment) []string {
+			s := make([]string, 0, len(comments))
+			for _, c := range comments {
+				s = append(s, c.CommentID)
+			}
+			return s
+		}
+		if got := ids(public); !reflect.DeepEqual(got, v.wantPublic) {
+			t.Fatalf("%v: got public comments %v, want %v",
+				v.name, got, v.wantPublic)
+		}
+		if got := ids(admin); !reflect.DeepEqual(got, v.wantAdmin) {
+			t.Fatalf("%v: got admin comments %v, want %v",
+				v.name, got, v.wantAdmin)
+		}
+
+		// Hidden comments retain their body
+		hidden := make([]string, 0, len(admin))
+		for _, c := range admin {
+			if c.Comment != bodies[c.CommentID] {
+				t.Fatalf("%v: got comment %v body %q, want %q",
+					v.name, c.CommentID, c.Comment,
+					bodies[c.CommentID])
+			}
+			if c.Hidden {
+				hidden = append(hidden, c.CommentID)
+			}
+		}
+		if !reflect.DeepEqual(hidden, v.wantHidden) {
+			t.Fatalf("%v: got hidden comments %v, want %v",
+				v.name, hidden, v.wantHidden)
+		}
+	}
+}
+
+func TestFoneroVerifyCounts(t *testing.T) {
+	p, cleanup := newTestPoliteiawww(t)
+	defer cleanup()
+
+	// exec executes a fonero plugin command against the cache.
+	exec := func(cmd string, payload, reply []byte) {
+		_, err := p.cache.PluginExec(cache.PluginCommand{
+			ID:             foneroplugin.ID,
+			Command:        cmd,
+			CommandPayload: string(payload),
+			ReplyPayload:   string(reply),
+		})
+		if err != nil {
+			t.Fatalf("%v: %v", cmd, err)
+		}
+	}
+
+	// Seed the cache with 2 records, 3 comments and 4 cast votes
+	for _, token := range []string{"a", "b"} {
+		err := p.cache.NewRecord(cache.Record{
+			Version: "1",
+			CensorshipRecord: cache.CensorshipRecord{
+				Token: token,
+			},
+		})
+		if err != nil {
+			t.Fatalf("NewRecord: %v", err)
+		}
+	}
+	for i := 1; i <= 3; i++ {
+		nc, err := foneroplugin.EncodeNewComment(
+			foneroplugin.NewComment{
+				Token:    "a",
+				ParentID: "0",
+			})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ncr, err := foneroplugin.EncodeNewCommentReply(
+			foneroplugin.NewCommentReply{
+				CommentID: strconv.Itoa(i),
+			})
+		if err != nil {
+			t.Fatal(err)
+		}
+		exec(foneroplugin.CmdNewComment, nc, ncr)
+	}
+	votes := make([]foneroplugin.CastVote, 0, 4)
+	for i := 0; i < 4; i++ {
+		votes = append(votes, foneroplugin.CastVote{
+			Token:  "b",
+			Ticket: fmt.Sprintf("t%v", i),
+		})
+	}
+	b, err := foneroplugin.EncodeBallot(foneroplugin.Ballot{
+		Votes: votes,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exec(foneroplugin.CmdBallot, b, nil)
+
+	var tests = []struct {
+		name         string
+		records      uint64
+		comments     uint64
+		castVotes    uint64
+		threshold    uint64
+		wantDeltas   []int64 // Records, comments, cast votes
+		wantDiverged []bool  // Records, comments, cast votes
+	}{
+		{"matching", 2, 3, 4, 0, []int64{0, 0, 0},
+			[]bool{false, false, false}},
+		{"cache behind", 3, 3, 10, 0, []int64{-1, 0, -6},
+			[]bool{true, false, true}},
+		{"cache ahead", 2, 1, 4, 0, []int64{0, 2, 0},
+			[]bool{false, true, false}},
+		{"within threshold", 3, 1, 10, 6, []int64{-1, 2, -6},
+			[]bool{false, false, false}},
+		{"beyond threshold", 3, 1, 10, 1, []int64{-1, 2, -6},
+			[]bool{false, true, true}},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			vcr, err := p.foneroVerifyCounts(v.records, v.comments,
+				v.castVotes, v.threshold)
+			if err != nil {
+				t.Fatalf("foneroVerifyCounts: %v", err)
+			}
+
+			tables := []string{foneroplugin.VerifyCountsRecords,
+				foneroplugin.VerifyCountsComments,
+				foneroplugin.VerifyCountsCastVotes}
+			if len(vcr.Deltas) != len(tables) {
+				t.Fatalf("got %v deltas, want %v",
+					len(vcr.Deltas), len(tables))
+			}
+			var wantDiverged bool
+			for i, d := range vcr.Deltas {
+				if d.Table != tables[i] {
+					t.Fatalf("got table %v, want %v", d.Table, tables[i])
+				}
+				if d.Delta != v.wantDeltas[i] {
+					t.Fatalf("%v: got delta %v, want %v",
+						d.Table, d.Delta, v.wantDeltas[i])
+				}
+				if d.Diverged != v.wantDiverged[i] {
+					t.Fatalf("%v: got diverged %v, want %v",
+						d.Table, d.Diverged, v.wantDiverged[i])
+				}
+				wantDiverged = wantDiverged || v.wantDiverged[i]
+			}
+			if vcr.Diverged != wantDiverged {
+				t.Fatalf("got diverged %v, want %v",
+					vcr.Diverged, wantDiverged)
+			}
+		})
+	}
+}
+
+func TestFoneroCommentRate(t *testing.T) {
+	p, cleanup := newTestPoliteiawww(t)
+	defer cleanup()
+
+	// newComment adds a comment with the passed in timestamp.
+	newComment := func(token, commentID string, ts int64) {
+		nc, err := foneroplugin.EncodeNewComment(
+			foneroplugin.NewComment{
+				Token:    token,
+				ParentID: "0",
+			})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ncr, err := foneroplugin.EncodeNewCommentReply(
+			foneroplugin.NewCommentReply{
+				CommentID: commentID,
+				Timestamp: ts,
+			})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = p.cache.PluginExec(cache.PluginCommand{
+			ID:             foneroplugin.ID,
+			Command:        foneroplugin.CmdNewComment,
+			CommandPayload: string(nc),
+			ReplyPayload:   string(ncr),
+		})
+		if err != nil {
+			t.Fatalf("%v: %v", foneroplugin.CmdNewComment, err)
+		}
+	}
+
+	const (
+		hour = int64(time.Hour / time.Second)
+		day  = 24 * hour
+	)
+	now := time.Now().Unix()
+	newComment("a", "1", now-60)
+	newComment("a", "2", now-hour/2)
+	newComment("a", "3", now-2*hour)
+	newComment("a", "4", now-2*day)
+	newComment("b", "1", now-60)
+
+	var tests = []struct {
+		name         string
+		token        string
+		window       int64
+		wantComments uint64
+		wantTotal    uint64
+		wantErr      bool
+	}{
+		{"last hour", "a", hour, 2, 4, false},
+		{"last day", "a", day, 3, 4, false},
+		{"last week", "a", 7 * day, 4, 4, false},
+		{"other proposal", "b", hour, 1, 1, false},
+		{"no comments", "c", day, 0, 0, false},
+		{"zero window", "a", 0, 0, 0, true},
+		{"negative window", "a", -hour, 0, 0, true},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			before := time.Now().Unix()
+			got, err := p.foneroCommentRate(v.token, v.window)
+			if (err != nil) != v.wantErr {
+				t.Fatalf("got error %v, want error %v", err, v.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.Comments != v.wantComments {
+				t.Fatalf("got comments %v, want %v",
+					got.Comments, v.wantComments)
+			}
+			if got.Total != v.wantTotal {
+				t.Fatalf("got total %v, want %v", got.Total, v.wantTotal)
+			}
+			if got.Start < before-v.window ||
+				got.Start > time.Now().Unix()-v.window {
+				t.Fatalf("got start %v, want now minus %v",
+					got.Start, v.window)
+			}
+		})
+	}
+}
+
+func TestFoneroEligibleTickets(t *testing.T) {
+	p, cleanup := newTestPoliteiawww(t)
+	defer cleanup()
+
+	// Start a vote with many eligible tickets
+	const ticketCount = 250
+	tickets := make([]string, 0, ticketCount)
+	for i := 0; i < ticketCount; i++ {
+		tickets = append(tickets, fmt.Sprintf("ticket%03d", i))
+	}
+	sv, err := foneroplugin.EncodeStartVote(foneroplugin.StartVote{
+		Vote: foneroplugin.Vote{
+			Token: "a",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	svr, err := foneroplugin.EncodeStartVoteReply(
+		foneroplugin.StartVoteReply{
+			EligibleTickets: tickets,
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = p.cache.PluginExec(cache.PluginCommand{
+		ID:             foneroplugin.ID,
+		Command:        foneroplugin.CmdStartVote,
+		CommandPayload: string(sv),
+		ReplyPayload:   string(svr),
+	})
+	if err != nil {
+		t.Fatalf("start vote: %v", err)
+	}
+
+	// Page through the tickets
+	const limit = 100
+	got := make([]string, 0, ticketCount)
+	for offset := uint32(0); ; offset += limit {
+		etr, err := p.foneroEligibleTickets("a", offset, limit)
+		if err != nil {
+			t.Fatalf("foneroEligibleTickets: %v", err)
+		}
+		if etr.Total != ticketCount {
+			t.Fatalf("got total %v, want %v", etr.Total, ticketCount)
+		}
+		if len(etr.Tickets) > limit {
+			t.Fatalf("got %v tickets, want at most %v",
+				len(etr.Tickets), limit)
+		}
+		got = append(got, etr.Tickets...)
+		if len(etr.Tickets) < limit {
+			break
+		}
+	}
+	if !reflect.DeepEqual(got, tickets) {
+		t.Fatalf("paged tickets do not match the eligible tickets")
+	}
+
+	var tests = []struct {
+		name          string
+		token         string
+		offset, limit uint32
+		wantTickets   []string
+		wantTotal     uint32
+	}{
+		{"all tickets", "a", 0, 0, tickets, ticketCount},
+		{"offset only", "a", 240, 0, tickets[240:], ticketCount},
+		{"offset past end", "a", 300, 10, []string{}, ticketCount},
+		{"vote not started", "b", 0, 0, []string{}, 0},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			etr, err := p.foneroEligibleTickets(v.token, v.offset, v.limit)
+			if err != nil {
+				t.Fatalf("foneroEligibleTickets: %v", err)
+			}
+			if etr.Total != v.wantTotal {
+				t.Fatalf("got total %v, want %v", etr.Total, v.wantTotal)
+			}
+			if !reflect.DeepEqual(etr.Tickets, v.wantTickets) {
+				t.Fatalf("got tickets %v, want %v",
+					etr.Tickets, v.wantTickets)
+			}
+		})
+	}
+}
+
+func TestFoneroProposalVoteReceipts(t *testing.T) {
+	p, cleanup := newTestPoliteiawww(t)
+	defer cleanup()
+
+	// Cast a ballot along with the receipts that were returned for it
+	b, err := foneroplugin.EncodeBallot(foneroplugin.Ballot{
+		Votes: []foneroplugin.CastVote{
+			{Token: "a", Ticket: "ticket1", VoteBit: "1", Signature: "sig1"},
+			{Token: "a", Ticket: "ticket0", VoteBit: "2", Signature: "sig0"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	br, err := foneroplugin.EncodeBallotReply(foneroplugin.BallotReply{
+		Receipts: []foneroplugin.CastVoteReply{
+			{ClientSignature: "sig1", Signature: "receipt1"},
+			{ClientSignature: "sig0", Signature: "receipt0"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = p.cache.PluginExec(cache.PluginCommand{
+		ID:             foneroplugin.ID,
+		Command:        foneroplugin.CmdBallot,
+		CommandPayload: string(b),
+		ReplyPayload:   string(br),
+	})
+	if err != nil {
+		t.Fatalf("%v: %v", foneroplugin.CmdBallot, err)
+	}
+
+	receipt0 := foneroplugin.VoteReceipt{
+		Ticket:    "ticket0",
+		VoteBit:   "2",
+		Signature: "sig0",
+		Receipt:   "receipt0",
+	}
+	receipt1 := foneroplugin.VoteReceipt{
+		Ticket:    "ticket1",
+		VoteBit:   "1",
+		Signature: "sig1",
+		Receipt:   "receipt1",
+	}
+
+	var tests = []struct {
+		name   string
+		token  string
+		ticket string
+		want   []foneroplugin.VoteReceipt
+	}{
+		{"by ticket", "a", "ticket1",
+			[]foneroplugin.VoteReceipt{receipt1}},
+		{"all tickets", "a", "",
+			[]foneroplugin.VoteReceipt{receipt0, receipt1}},
+		{"unknown ticket", "a", "ticket2",
+			[]foneroplugin.VoteReceipt{}},
+		{"no votes", "b", "",
+			[]foneroplugin.VoteReceipt{}},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			receipts, err := p.foneroProposalVoteReceipts(v.token, v.ticket)
+			if err != nil {
+				t.Fatalf("foneroProposalVoteReceipts: %v", err)
+			}
+			if !reflect.DeepEqual(receipts, v.want) {
+				t.Fatalf("got receipts %v, want %v", receipts, v.want)
+			}
+		})
+	}
+}
+
+func TestFoneroCensoredComments(t *testing.T) {
+	p, cleanup := newTestPoliteiawww(t)
+	defer cleanup()
+
+	// exec executes a fonero plugin command against the cache.
+	exec := func(cmd string, payload, reply []byte) {
+		_, err := p.cache.PluginExec(cache.PluginCommand{
+			ID:             foneroplugin.ID,
+			Command:        cmd,
+			CommandPayload: string(payload),
+			ReplyPayload:   string(reply),
+		})
+		if err != nil {
+			t.Fatalf("%v: %v", cmd, err)
+		}
+	}
+
+	// newComment adds a comment with the passed in timestamp.
+	newComment := func(token, commentID string, ts int64) {
+		nc, err := foneroplugin.EncodeNewComment(
+			foneroplugin.NewComment{
+				Token:    token,
+				ParentID: "0",
+				Comment:  "comment " + commentID,
+			})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ncr, err := foneroplugin.EncodeNewCommentReply(
+			foneroplugin.NewCommentReply{
+				CommentID: commentID,
+				Timestamp: ts,
+			})
+		if err != nil {
+			t.Fatal(err)
+		}
+		exec(foneroplugin.CmdNewComment, nc, ncr)
+	}
+
+	// censor censors a comment.
+	censor := func(token, commentID string) {
+		cc, err := foneroplugin.EncodeCensorComment(
+			foneroplugin.CensorComment{
+				Token:     token,
+				CommentID: commentID,
+			})
+		if err != nil {
+			t.Fatal(err)
+		}
+		exec(foneroplugin.CmdCensorComment, cc, nil)
+	}
+
+	// Proposal a has a censored and an uncensored comment, proposal
+	// b has two censored comments and proposal c only has an
+	// uncensored comment.
+	newComment("a", "1", 100)
+	newComment("a", "2", 400)
+	newComment("b", "1", 200)
+	newComment("b", "2", 300)
+	newComment("c", "1", 500)
+	censor("a", "2")
+	censor("b", "1")
+	censor("b", "2")
+
+	var tests = []struct {
+		name  string
+		token string
+		want  []string // token/commentID
+	}{
+		{"all records", "", []string{"b/1", "b/2", "a/2"}},
+		{"single record", "b", []string{"b/1", "b/2"}},
+		{"mixed record", "a", []string{"a/2"}},
+		{"no censored comments", "c", []string{}},
+		{"unknown record", "d", []string{}},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			comments, err := p.foneroCensoredComments(v.token)
+			if err != nil {
+				t.Fatalf("foneroCensoredComments: %v", err)
+			}
+			got := make([]string, 0, len(comments))
+			for _, c := range comments {
+				if !c.Censored || c.Comment != "" {
+					t.Fatalf("comment %v/%v is not censored",
+						c.Token, c.CommentID)
+				}
+				got = append(got, c.Token+"/"+c.CommentID)
+			}
+			if !reflect.DeepEqual(got, v.want) {
+				t.Fatalf("got %v, want %v", got, v.want)
+			}
+		})
+	}
+}
+
+func TestFoneroGetCommentsForProposals(t *testing.T) {
+	p, cleanup := newTestPoliteiawww(t)
+	defer cleanup()
+
+	// newComment adds a comment with the passed in timestamp.
+	newComment := func(token, commentID string, ts int64) {
+		nc, err := foneroplugin.EncodeNewComment(
+			foneroplugin.NewComment{
+				Token:    token,
+				ParentID: "0",
+			})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ncr, err := foneroplugin.EncodeNewCommentReply(
+			foneroplugin.NewCommentReply{
+				CommentID: commentID,
+				Timestamp: ts,
+			})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = p.cache.PluginExec(cache.PluginCommand{
+			ID:             foneroplugin.ID,
+			Command:        foneroplugin.CmdNewComment,
+			CommandPayload: string(nc),
+			ReplyPayload:   string(ncr),
+		})
+		if err != nil {
+			t.Fatalf("%v: %v", foneroplugin.CmdNewComment, err)
+		}
+	}
+
+	// Proposal a has three comments, proposal b has two comments of
+	// which the most recent one is censored and proposal c has a
+	// single comment. Proposal d does not have any comments.
+	for i := 1; i <= 3; i++ {
+		newComment("a", strconv.Itoa(i), int64(i*100))
+	}
+	newComment("b", "1", 100)
+	newComment("b", "2", 200)
+	newComment("c", "1", 100)
+	cc, err := foneroplugin.EncodeCensorComment(
+		foneroplugin.CensorComment{
+			Token:     "b",
+			CommentID: "2",
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = p.cache.PluginExec(cache.PluginCommand{
+		ID:             foneroplugin.ID,
+		Command:        foneroplugin.CmdCensorComment,
+		CommandPayload: string(cc),
+	})
+	if err != nil {
+		t.Fatalf("%v: %v", foneroplugin.CmdCensorComment, err)
+	}
+
+	var tests = []struct {
+		name            string
+		tokens          []string
+		limit           uint32
+		excludeCensored bool
+		want            map[string][]string // [token][]commentID
+	}{
+		{"all comments", []string{"a", "b", "c"}, 0, false,
+			map[string][]string{
+				"a": {"3", "2", "1"},
+				"b": {"2", "1"},
+				"c": {"1"},
+			}},
+		{"per token limit", []string{"a", "b", "c"}, 2, false,
+			map[string][]string{
+				"a": {"3", "2"},
+				"b": {"2", "1"},
+				"c": {"1"},
+			}},
+		{"limit of one", []string{"a", "b", "c"}, 1, false,
+			map[string][]string{
+				"a": {"3"},
+				"b": {"2"},
+				"c": {"1"},
+			}},
+		{"exclude censored", []string{"a", "b", "c"}, 1, true,
+			map[string][]string{
+				"a": {"3"},
+				"b": {"1"},
+				"c": {"1"},
+			}},
+		{"no comments", []string{"a", "d"}, 0, false,
+			map[string][]string{
+				"a": {"3", "2", "1"},
+				"d": {},
+			}},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			comments, err := p.foneroGetCommentsForProposals(v.tokens,
+				v.limit, v.excludeCensored)
+			if err != nil {
+				t.Fatalf("foneroGetCommentsForProposals: %v", err)
+			}
+			got := make(map[string][]string, len(comments))
+			for token, c := range comments {
+				ids := make([]string, 0, len(c))
+				for _, v := range c {
+					if v.Token != token {
+						t.Fatalf("comment %v of %v grouped under %v",
+							v.CommentID, v.Token, token)
+					}
+					ids = append(ids, v.CommentID)
+				}
+				got[token] = ids
+			}
+			if !reflect.DeepEqual(got, v.want) {
+				t.Fatalf("got comments %v, want %v", got, v.want)
+			}
+		})
+	}
+}
+
+func TestFoneroInventoryDigest(t *testing.T) {
+	p, cleanup := newTestPoliteiawww(t)
+	defer cleanup()
+
+	// newComment adds a comment to a proposal.
+	newComment := func(token, commentID string) {
+		nc, err := foneroplugin.EncodeNewComment(
+			foneroplugin.NewComment{
+				Token:    token,
+				ParentID: "0",
+			})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ncr, err := foneroplugin.EncodeNewCommentReply(
+			foneroplugin.NewCommentReply{
+				CommentID: commentID,
+				Timestamp: 100,
+			})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = p.cache.PluginExec(cache.PluginCommand{
+			ID:             foneroplugin.ID,
+			Command:        foneroplugin.CmdNewComment,
+			CommandPayload: string(nc),
+			ReplyPayload:   string(ncr),
+		})
+		if err != nil {
+			t.Fatalf("%v: %v", foneroplugin.CmdNewComment, err)
+		}
+	}
+
+	// commentCount returns the comment count of the digest.
+	commentCount := func(idr *foneroplugin.InventoryDigestReply) int {
+		for _, v := range idr.Tables {
+			if v.Table == "comments" {
+				return v.Count
+			}
+		}
+		t.Fatalf("comments table digest not found")
+		return 0
+	}
+
+	newComment("a", "1")
+	newComment("b", "1")
+
+	// The digest is stable when the cache does not change
+	first, err := p.foneroInventoryDigest()
+	if err != nil {
+		t.Fatalf("foneroInventoryDigest: %v", err)
+	}
+	second, err := p.foneroInventoryDigest()
+	if err != nil {
+		t.Fatalf("foneroInventoryDigest: %v", err)
+	}
+	if !reflect.DeepEqual(first, second) {
+		t.Fatalf("got digest %v, want %v", second, first)
+	}
+	if commentCount(first) != 2 {
+		t.Fatalf("got %v comments, want 2", commentCount(first))
+	}
+
+	// The digest changes when a row is added
+	newComment("a", "2")
+	third, err := p.foneroInventoryDigest()
+	if err != nil {
+		t.Fatalf("foneroInventoryDigest: %v", err)
+	}
+	if third.Digest == first.Digest {
+		t.Fatalf("digest did not change")
+	}
+	if commentCount(third) != 3 {
+		t.Fatalf("got %v comments, want 3", commentCount(third))
+	}
+}
+
+func TestFoneroCommentedProposals(t *testing.T) {
+	p, cleanup := newTestPoliteiawww(t)
+	defer cleanup()
+
+	// newComment adds a comment that was made by the passed in
+	// public key.
+	newComment := func(token, commentID, publicKey string) {
+		nc, err := foneroplugin.EncodeNewComment(
+			foneroplugin.NewComment{
+				Token:     token,
+				ParentID:  "0",
+				PublicKey: publicKey,
+			})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ncr, err := foneroplugin.EncodeNewCommentReply(
+			foneroplugin.NewCommentReply{
+				CommentID: commentID,
+				Timestamp: 100,
+			})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = p.cache.PluginExec(cache.PluginCommand{
+			ID:             foneroplugin.ID,
+			Command:        foneroplugin.CmdNewComment,
+			CommandPayload: string(nc),
+			ReplyPayload:   string(ncr),
+		})
+		if err != nil {
+			t.Fatalf("%v: %v", foneroplugin.CmdNewComment, err)
+		}
+	}
+
+	// pk1 commented twice on proposal b and once on proposal a.
+	// pk2 commented on proposals a and c.
+	newComment("b", "1", "pk1")
+	newComment("a", "1", "pk1")
+	newComment("a", "2", "pk2")
+	newComment("b", "2", "pk1")
+	newComment("c", "1", "pk2")
+
+	var tests = []struct {
+		name      string
+		publicKey string
+		want      []foneroplugin.CommentedProposal
+	}{
+		{"two proposals", "pk1", []foneroplugin.CommentedProposal{
+			{Token: "a", Comments: 1},
+			{Token: "b", Comments: 2},
+		}},
+		{"other key", "pk2", []foneroplugin.CommentedProposal{
+			{Token: "a", Comments: 1},
+			{Token: "c", Comments: 1},
+		}},
+		{"no comments", "pk3", []foneroplugin.CommentedProposal{}},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			got, err := p.foneroCommentedProposals(v.publicKey)
+			if err != nil {
+				t.Fatalf("foneroCommentedProposals: %v", err)
+			}
+			if !reflect.DeepEqual(got, v.want) {
+				t.Fatalf("got %v, want %v", got, v.want)
+			}
+		})
+	}
+}
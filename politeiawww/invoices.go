@@ -551,7 +551,7 @@ func (p *politeiawww) validateInvoice(ni cms.NewInvoice, u *user.User) error {
 			// Verify that the submitted monthly average matches the value
 			// was calculated server side.
 			monthAvg, err := p.cmsDB.ExchangeRate(int(invInput.Month),
-				int(invInput.Year))
+				int(invInput.Year), p.cfg.FiatCurrency)
 			if err != nil {
 				return www.UserError{
 					ErrorCode: www.ErrorStatusInvalidExchangeRate,
@@ -424,6 +424,40 @@ func (p *politeiawww) handleVoteResults(w http.ResponseWriter, r *http.Request)
 	util.RespondWithJSON(w, http.StatusOK, vrr)
 }
 
+// handleVoteExport returns the full vote lifecycle of a proposal.
+func (p *politeiawww) handleVoteExport(w http.ResponseWriter, r *http.Request) {
+	log.Tracef("handleVoteExport")
+
+	pathParams := mux.Vars(r)
+	token := pathParams["token"]
+
+	ver, err := p.processVoteExport(token)
+	if err != nil {
+		RespondWithError(w, r, 0,
+			"handleVoteExport: processVoteExport %v",
+			err)
+		return
+	}
+
+	util.RespondWithJSON(w, http.StatusOK, ver)
+}
+
+// handleProposalVotesCSV streams the cast votes of a proposal as CSV.
+func (p *politeiawww) handleProposalVotesCSV(w http.ResponseWriter, r *http.Request) {
+	log.Tracef("handleProposalVotesCSV")
+
+	pathParams := mux.Vars(r)
+	token := pathParams["token"]
+
+	err := p.processProposalVotesCSV(w, token)
+	if err != nil {
+		RespondWithError(w, r, 0,
+			"handleProposalVotesCSV: processProposalVotesCSV %v",
+			err)
+		return
+	}
+}
+
 // handleGetAllVoteStatus returns the voting status of all public proposals.
 func (p *politeiawww) handleGetAllVoteStatus(w http.ResponseWriter, r *http.Request) {
 	gasvr, err := p.processGetAllVoteStatus()
@@ -1075,6 +1109,10 @@ func (p *politeiawww) setPoliteiaWWWRoutes() {
 		p.handleGetAllVoteStatus, permissionPublic)
 	p.addRoute(http.MethodGet, www.RouteVoteStatus,
 		p.handleVoteStatus, permissionPublic)
+	p.addRoute(http.MethodGet, www.RouteVoteExport,
+		p.handleVoteExport, permissionPublic)
+	p.addRoute(http.MethodGet, www.RouteProposalVotesCSV,
+		p.handleProposalVotesCSV, permissionPublic)
 	p.addRoute(http.MethodGet, www.RoutePropsStats,
 		p.handleProposalsStats, permissionPublic)
 	p.addRoute(http.MethodGet, www.RouteTokenInventory,
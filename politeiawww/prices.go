@@ -2,82 +2,438 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"math"
+	"mime"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	cms "github.com/fonero-project/politeia/politeiawww/api/cms/v1"
 	www "github.com/fonero-project/politeia/politeiawww/api/www/v1"
 	database "github.com/fonero-project/politeia/politeiawww/cmsdatabase"
+	"github.com/fonero-project/politeia/util/version"
 )
 
 const poloURL = "https://poloniex.com/public"
+const binanceURL = "https://api.binance.com/api/v3/klines"
 const httpTimeout = time.Second * 3
 const pricePeriod = 900
 
+// exchangeRateHistoryMaxYears is the maximum number of years that can be
+// requested in a single exchange rate history request.
+const exchangeRateHistoryMaxYears = 10
+
+const (
+	// Supported fiat currencies
+	fiatUSD = "USD"
+	fiatEUR = "EUR"
+	fiatGBP = "GBP"
+
+	defaultFiatCurrency = fiatUSD
+)
+
+const (
+	// Supported exchanges that prices are downloaded from
+	exchangePoloniex = "poloniex"
+	exchangeBinance  = "binance"
+
+	defaultExchange = exchangePoloniex
+)
+
+// Supported rounding modes of the conversion of the average fiat/FNO price to
+// cents.  The rounding mode determines the stored exchange rate and therefore
+// the amount of FNO that is paid out for an invoice.  Rounding down (floor)
+// undervalues FNO and pays out slightly more FNO, rounding up (ceil) pays out
+// slightly less FNO, and rounding half away from zero (round) biases every
+// rate that falls exactly on a half cent upwards.  Bankers' rounding rounds
+// half cents to the nearest even cent, which does not bias the rates over
+// many months.
+const (
+	roundingModeRound   = "round"   // Round half away from zero
+	roundingModeFloor   = "floor"   // Round down
+	roundingModeCeil    = "ceil"    // Round up
+	roundingModeBankers = "bankers" // Round half to even
+
+	defaultRoundingMode = roundingModeRound
+)
+
+var (
+	// errExchangeNonJSON is emitted when the exchange API returns a
+	// response that is not JSON, e.g. an HTML rate limit or challenge
+	// page.
+	errExchangeNonJSON = errors.New("exchange returned non-JSON response")
+
+	// errNoPriceData is emitted when the exchange price charts do not
+	// contain any overlapping data points for the requested month.
+	errNoPriceData = errors.New("no price data")
+
+	// centsRounders contains the rounding function of each supported
+	// rounding mode.
+	centsRounders = map[string]func(float64) float64{
+		roundingModeRound:   math.Round,
+		roundingModeFloor:   math.Floor,
+		roundingModeCeil:    math.Ceil,
+		roundingModeBankers: math.RoundToEven,
+	}
+
+	// poloniexPricePairs contains the Poloniex currency pairs that
+	// are chained together to calculate the FNO price for each
+	// supported fiat currency.  The first pair is always BTC/FNO.
+	// USD uses the USDT/BTC pair as the fiat peg while the other
+	// currencies further convert the USDT price using a fiat backed
+	// token pair.
+	poloniexPricePairs = map[string][]pricePair{
+		fiatUSD: {
+			{pairing: "BTC_FNO"},
+			{pairing: "USDT_BTC"},
+		},
+		fiatEUR: {
+			{pairing: "BTC_FNO"},
+			{pairing: "USDT_BTC"},
+			{pairing: "USDT_EURT", invert: true},
+		},
+		fiatGBP: {
+			{pairing: "BTC_FNO"},
+			{pairing: "USDT_BTC"},
+			{pairing: "USDT_GBPT", invert: true},
+		},
+	}
+
+	// binancePricePairs contains the Binance symbols that are chained
+	// together to calculate the FNO price for each supported fiat
+	// currency.  They follow the same conversions as the Poloniex
+	// pairs.
+	binancePricePairs = map[string][]pricePair{
+		fiatUSD: {
+			{pairing: "FNOBTC"},
+			{pairing: "BTCUSDT"},
+		},
+		fiatEUR: {
+			{pairing: "FNOBTC"},
+			{pairing: "BTCUSDT"},
+			{pairing: "EURUSDT", invert: true},
+		},
+		fiatGBP: {
+			{pairing: "FNOBTC"},
+			{pairing: "BTCUSDT"},
+			{pairing: "GBPUSDT", invert: true},
+		},
+	}
+
+	// priceExchanges contains the exchanges that prices can be
+	// downloaded from.
+	priceExchanges = map[string]priceExchange{
+		exchangePoloniex: {
+			url:    poloURL,
+			pairs:  poloniexPricePairs,
+			query:  poloniexPricesQuery,
+			decode: decodePoloniexPrices,
+		},
+		exchangeBinance: {
+			url:    binanceURL,
+			pairs:  binancePricePairs,
+			query:  klinePricesQuery,
+			decode: decodeKlinePrices,
+		},
+	}
+)
+
+// pricePair is an exchange currency pair that is used to convert a price from
+// one currency into another.  Pairs that quote the rate in the opposite
+// direction of the conversion are inverted.
+type pricePair struct {
+	pairing string // Exchange currency pair
+	invert  bool   // Divide by the pair price instead of multiplying
+}
+
+// priceExchange describes how the price charts of an exchange are downloaded.
+// Every exchange has its own currency pairs, request parameters and response
+// format, so the price calculations do not depend on the exchange.
+type priceExchange struct {
+	url    string                 // Price chart API URL
+	pairs  map[string][]pricePair // Currency pairs of each fiat currency
+	query  pricesQuery            // Price chart request parameters
+	decode priceDecoder           // Price chart response decoder
+}
+
+// pricesQuery returns the query parameters of an exchange request for the
+// price chart of the passed in currency pair between the passed in unix
+// timestamps.  The chart contains a candle every price period.
+type pricesQuery func(pairing string, startDate, endDate int64) url.Values
+
+// poloniexPricesQuery returns the query parameters of a Poloniex
+// returnChartData request.
+func poloniexPricesQuery(pairing string, startDate, endDate int64) url.Values {
+	return url.Values{
+		"command":      {"returnChartData"},
+		"currencyPair": {pairing},
+		"start":        {strconv.FormatInt(startDate, 10)},
+		"end":          {strconv.FormatInt(endDate, 10)},
+		"period":       {strconv.Itoa(pricePeriod)},
+	}
+}
+
+// klinePricesQuery returns the query parameters of a kline request.  The
+// start and end times of a kline request are in milliseconds.
+func klinePricesQuery(pairing string, startDate, endDate int64) url.Values {
+	return url.Values{
+		"symbol":    {pairing},
+		"interval":  {strconv.Itoa(pricePeriod/60) + "m"},
+		"startTime": {strconv.FormatInt(startDate*1000, 10)},
+		"endTime":   {strconv.FormatInt(endDate*1000, 10)},
+	}
+}
+
 type poloChartData struct {
 	Date            uint64  `json:"date"`
 	WeightedAverage float64 `json:"weightedAverage"`
 }
 
-// GetMonthAverage returns the average USD/FNO price for a given month
-func (p *politeiawww) GetMonthAverage(month time.Month, year int) (uint, error) {
-	startTime := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
-	endTime := startTime.AddDate(0, 1, 0)
-
-	unixStart := startTime.Unix()
-	unixEnd := endTime.Unix()
+// priceDecoder decodes the price chart response of an exchange into a map of
+// unix timestamp => average price.
+type priceDecoder func(r io.Reader) (map[uint64]float64, error)
 
-	// Download BTC/FNO and USDT/BTC prices from Poloniex
-	fnoPrices, err := getPrices("BTC_FNO", unixStart, unixEnd)
+// decodePoloniexPrices decodes a Poloniex returnChartData response, which is
+// an array of candle objects that contain the weighted average price.
+func decodePoloniexPrices(r io.Reader) (map[uint64]float64, error) {
+	var chartData []poloChartData
+	err := json.NewDecoder(r).Decode(&chartData)
 	if err != nil {
-		return 0, err
+		return nil, err
+	}
+
+	prices := make(map[uint64]float64, len(chartData))
+	for _, v := range chartData {
+		prices[v.Date] = v.WeightedAverage
 	}
-	btcPrices, err := getPrices("USDT_BTC", unixStart, unixEnd)
+
+	return prices, nil
+}
+
+// decodeKlinePrices decodes a kline response, which is an array of candle
+// arrays in the format [open time in milliseconds, open, high, low, close,
+// volume, close time, quote volume, ...] with the prices and volumes encoded
+// as strings.  The average price of a candle is its volume weighted average
+// price, which is the quote volume divided by the volume.  The close price is
+// used for candles without any volume.
+func decodeKlinePrices(r io.Reader) (map[uint64]float64, error) {
+	var klines [][]json.RawMessage
+	err := json.NewDecoder(r).Decode(&klines)
 	if err != nil {
-		return 0, err
+		return nil, err
+	}
+
+	prices := make(map[uint64]float64, len(klines))
+	for i, v := range klines {
+		if len(v) < 8 {
+			return nil, fmt.Errorf("kline %v: got %v fields, want at "+
+				"least 8", i, len(v))
+		}
+
+		var openTime uint64
+		err := json.Unmarshal(v[0], &openTime)
+		if err != nil {
+			return nil, fmt.Errorf("kline %v: open time: %v", i, err)
+		}
+
+		// The close price, volume and quote volume are
+		// strings that contain a decimal number.
+		var fields [3]float64
+		for j, idx := range []int{4, 5, 7} {
+			var s string
+			err := json.Unmarshal(v[idx], &s)
+			if err != nil {
+				return nil, fmt.Errorf("kline %v: field %v: %v",
+					i, idx, err)
+			}
+			fields[j], err = strconv.ParseFloat(s, 64)
+			if err != nil {
+				return nil, fmt.Errorf("kline %v: field %v: %v",
+					i, idx, err)
+			}
+		}
+		closePrice, volume, quoteVolume := fields[0], fields[1], fields[2]
+
+		price := closePrice
+		if volume > 0 {
+			price = quoteVolume / volume
+		}
+		prices[openTime/1000] = price
 	}
 
-	// Create a map of unix timestamps => average price
-	usdtFnoPrices := make(map[uint64]float64)
+	return prices, nil
+}
+
+// exchangeUserAgent returns the User-Agent that is sent with every exchange
+// price request.
+func exchangeUserAgent() string {
+	return "politeiawww/" + version.String()
+}
 
-	// Select only timestamps which appear in both charts to
-	// populate the result set. Multiply BTC/FNO rate by
-	// USDT/BTC rate to get USDT/FNO rate.
-	for timestamp, fno := range fnoPrices {
-		if btc, ok := btcPrices[timestamp]; ok {
-			usdtFnoPrices[timestamp] = fno * btc
+// parseExchangeHeaders parses headers in the format <name>:<value> into the
+// additional HTTP headers that are sent with every exchange price request.
+func parseExchangeHeaders(headers []string) (http.Header, error) {
+	h := make(http.Header, len(headers))
+	for _, v := range headers {
+		s := strings.SplitN(v, ":", 2)
+		if len(s) != 2 || strings.TrimSpace(s[0]) == "" {
+			return nil, fmt.Errorf("invalid header '%v': must be in "+
+				"the format <name>:<value>", v)
 		}
+		h.Add(strings.TrimSpace(s[0]), strings.TrimSpace(s[1]))
 	}
+	return h, nil
+}
 
-	// Calculate and return the average of all USDT/FNO prices
+// toCents converts the passed in price to cents using the passed in rounding
+// mode.
+func toCents(price float64, rounding string) (uint, error) {
+	round, ok := centsRounders[rounding]
+	if !ok {
+		return 0, fmt.Errorf("unsupported rounding mode: %v", rounding)
+	}
+	return uint(round(price * 100)), nil
+}
+
+// GetMonthAverage returns the average fiat/FNO price for a given month in
+// cents of the passed in fiat currency.  The price is converted to cents using
+// the passed in rounding mode.
+func (p *politeiawww) GetMonthAverage(currency, rounding string, month time.Month, year int) (uint, error) {
+	return getMonthAverage(priceExchanges[p.cfg.Exchange],
+		p.cfg.ExchangeHTTPHeaders, currency, rounding, month, year)
+}
+
+// getMonthAverage downloads the price charts of the currency pairs of the
+// passed in fiat currency from the passed in exchange and returns the average
+// fiat/FNO price for a given month in cents, rounded using the passed in
+// rounding mode.  The passed in headers are sent with every request and may be
+// nil.
+func getMonthAverage(exchange priceExchange, headers http.Header, currency, rounding string, month time.Month, year int) (uint, error) {
+	pairs, ok := exchange.pairs[currency]
+	if !ok {
+		return 0, fmt.Errorf("unsupported fiat currency: %v", currency)
+	}
+	if _, ok := centsRounders[rounding]; !ok {
+		return 0, fmt.Errorf("unsupported rounding mode: %v", rounding)
+	}
+
+	startTime := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	endTime := startTime.AddDate(0, 1, 0)
+
+	unixStart := startTime.Unix()
+	unixEnd := endTime.Unix()
+
+	// Create a map of unix timestamps => average price.  Start with
+	// the BTC/FNO prices and convert them using each subsequent pair.
+	// Only timestamps which appear in all charts are kept.
+	var fiatFnoPrices map[uint64]float64
+	for _, pair := range pairs {
+		prices, err := getPrices(exchange, headers, pair.pairing,
+			unixStart, unixEnd)
+		if err != nil {
+			return 0, err
+		}
+		if fiatFnoPrices == nil {
+			fiatFnoPrices = prices
+			continue
+		}
+		for timestamp, price := range fiatFnoPrices {
+			rate, ok := prices[timestamp]
+			if !ok || (pair.invert && rate == 0) {
+				delete(fiatFnoPrices, timestamp)
+				continue
+			}
+			if pair.invert {
+				fiatFnoPrices[timestamp] = price / rate
+			} else {
+				fiatFnoPrices[timestamp] = price * rate
+			}
+		}
+	}
+	if len(fiatFnoPrices) == 0 {
+		return 0, errNoPriceData
+	}
+
+	// Calculate and return the average of all fiat/FNO prices
 	var average float64
-	for _, price := range usdtFnoPrices {
+	for _, price := range fiatFnoPrices {
 		average += price
 	}
-	average = average / float64(len(usdtFnoPrices))
+	average = average / float64(len(fiatFnoPrices))
 
-	return uint(math.Round(average * 100)), nil
+	return toCents(average, rounding)
 }
 
-// GetPrices contacts the Poloniex API to download
+// getPrices contacts the exchange API to download
 // price data for a given CC pairing. Returns a map
-// of unix timestamp => average price
-func getPrices(pairing string, startDate int64, endDate int64) (map[uint64]float64, error) {
+// of unix timestamp => average price.  The passed
+// in headers are added to the request and may
+// replace the default User-Agent.
+//
+// The exchange caps the number of candles returned
+// by a single request, so a response that ends
+// before endDate is followed up by requests that
+// start after its last candle until the full range
+// is covered.
+func getPrices(exchange priceExchange, headers http.Header, pairing string, startDate int64, endDate int64) (map[uint64]float64, error) {
+	prices := make(map[uint64]float64)
+	for start := startDate; start <= endDate; {
+		page, err := getPricesPage(exchange, headers, pairing,
+			start, endDate)
+		if err != nil {
+			return nil, err
+		}
+		if len(page) == 0 {
+			break
+		}
+
+		// Merge the page into the map of unix timestamps =>
+		// average price
+		var last int64
+		for timestamp, price := range page {
+			prices[timestamp] = price
+			if int64(timestamp) > last {
+				last = int64(timestamp)
+			}
+		}
+
+		// Stop once the last candle covers the end of the range
+		// or when the exchange did not move past the requested
+		// start, which would otherwise loop forever.
+		if last+pricePeriod > endDate || last < start {
+			break
+		}
+		log.Debugf("getPrices %v: response truncated at %v, "+
+			"requesting remaining range", pairing, last)
+		start = last + pricePeriod
+	}
+
+	return prices, nil
+}
+
+// getPricesPage performs a single price chart request
+// to the exchange for the given range and returns the
+// decoded prices.
+func getPricesPage(exchange priceExchange, headers http.Header, pairing string, startDate int64, endDate int64) (map[uint64]float64, error) {
 	// Construct HTTP request and set parameters
-	req, err := http.NewRequest(http.MethodGet, poloURL, nil)
+	req, err := http.NewRequest(http.MethodGet, exchange.url, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	q := req.URL.Query()
-	q.Set("command", "returnChartData")
-	q.Set("currencyPair", pairing)
-	q.Set("start", strconv.FormatInt(startDate, 10))
-	q.Set("end", strconv.FormatInt(endDate, 10))
-	q.Set("period", strconv.Itoa(pricePeriod))
-	req.URL.RawQuery = q.Encode()
+	// Identify ourselves since some exchanges and proxies
+	// reject or rate limit anonymous requests.
+	req.Header.Set("User-Agent", exchangeUserAgent())
+	for k, v := range headers {
+		req.Header[k] = v
+	}
+
+	req.URL.RawQuery = exchange.query(pairing, startDate, endDate).Encode()
 
 	// Create HTTP client,
 	httpClient := http.Client{
@@ -92,32 +448,43 @@ func getPrices(pairing string, startDate int64, endDate int64) (map[uint64]float
 
 	defer resp.Body.Close()
 
-	// Read response and deserialise JSON
-	decoder := json.NewDecoder(resp.Body)
-	var chartData []poloChartData
-	err = decoder.Decode(&chartData)
-	if err != nil {
-		return nil, err
+	// Make sure the exchange actually returned JSON. When the
+	// exchange is rate limiting us or is behind a challenge page
+	// it returns HTML, which would otherwise surface as an opaque
+	// JSON decoding error.
+	ct, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil || ct != "application/json" {
+		log.Debugf("getPrices %v: status %v, content type '%v'",
+			pairing, resp.StatusCode, resp.Header.Get("Content-Type"))
+		return nil, errExchangeNonJSON
 	}
-
-	// Create a map of unix timestamps => average price
-	prices := make(map[uint64]float64, len(chartData))
-	for _, data := range chartData {
-		prices[data.Date] = data.WeightedAverage
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("exchange returned status %v",
+			resp.StatusCode)
 	}
 
-	return prices, nil
+	// Read response and decode the exchange specific JSON
+	return exchange.decode(resp.Body)
 }
 
-// GetMonthAverage returns the average USD/FNO price for a given month
+// processInvoiceExchangeRate returns the average fiat/FNO price for the
+// requested month in the fiat currency that politeiawww is configured with.
+// The rate is calculated and stored the first time that it is requested and
+// is converted to cents using the configured rounding mode.
 func (p *politeiawww) processInvoiceExchangeRate(ier cms.InvoiceExchangeRate) (cms.InvoiceExchangeRateReply, error) {
 	reply := cms.InvoiceExchangeRateReply{}
+	currency := p.cfg.FiatCurrency
+	rounding := p.cfg.ExchangeRounding
 
-	monthAvg, err := p.cmsDB.ExchangeRate(int(ier.Month), int(ier.Year))
+	monthAvg, err := p.cmsDB.ExchangeRate(int(ier.Month), int(ier.Year),
+		currency)
 	if err != nil {
 		if err == database.ErrExchangeRateNotFound {
-			monthAvgRaw, err := p.GetMonthAverage(time.Month(ier.Month), int(ier.Year))
+			monthAvgRaw, err := p.GetMonthAverage(currency, rounding,
+				time.Month(ier.Month), int(ier.Year))
 			if err != nil {
+				log.Debugf("processInvoiceExchangeRate: "+
+					"GetMonthAverage %v: %v", currency, err)
 				return reply, www.UserError{
 					ErrorCode: www.ErrorStatusInvalidExchangeRate,
 				}
@@ -125,6 +492,7 @@ func (p *politeiawww) processInvoiceExchangeRate(ier cms.InvoiceExchangeRate) (c
 			monthAvg = &database.ExchangeRate{
 				Month:        ier.Month,
 				Year:         ier.Year,
+				Currency:     currency,
 				ExchangeRate: monthAvgRaw,
 			}
 			err = p.cmsDB.NewExchangeRate(monthAvg)
@@ -137,5 +505,68 @@ func (p *politeiawww) processInvoiceExchangeRate(ier cms.InvoiceExchangeRate) (c
 		}
 	}
 	reply.ExchangeRate = monthAvg.ExchangeRate
+	reply.Currency = monthAvg.Currency
 	return reply, nil
 }
+
+// exchangeRateSeries returns the monthly exchange rates of every month of the
+// passed in years ordered by month.  Months that do not have a stored exchange
+// rate are included as missing.
+func exchangeRateSeries(startYear, endYear uint, rates []database.ExchangeRate) []cms.MonthlyExchangeRate {
+	stored := make(map[[2]uint]uint, len(rates)) // [year, month]exchangeRate
+	for _, v := range rates {
+		stored[[2]uint{v.Year, v.Month}] = v.ExchangeRate
+	}
+
+	series := make([]cms.MonthlyExchangeRate, 0, (endYear-startYear+1)*12)
+	for year := startYear; year <= endYear; year++ {
+		for month := uint(1); month <= 12; month++ {
+			rate, ok := stored[[2]uint{year, month}]
+			series = append(series, cms.MonthlyExchangeRate{
+				Month:        month,
+				Year:         year,
+				ExchangeRate: rate,
+				Missing:      !ok,
+			})
+		}
+	}
+
+	return series
+}
+
+// validateExchangeRateHistory returns an error if the passed in exchange rate
+// history request does not contain a valid range of years.
+func validateExchangeRateHistory(ierh cms.InvoiceExchangeRateHistory) error {
+	if ierh.StartYear == 0 || ierh.StartYear > ierh.EndYear ||
+		ierh.EndYear-ierh.StartYear >= exchangeRateHistoryMaxYears {
+		return www.UserError{
+			ErrorCode: www.ErrorStatusInvalidInput,
+		}
+	}
+	return nil
+}
+
+// processInvoiceExchangeRateHistory returns the stored monthly exchange rates
+// of the requested years in the fiat currency that politeiawww is configured
+// with.  Exchange rates that have not been fetched yet are returned as missing
+// and are not fetched by this call.
+func (p *politeiawww) processInvoiceExchangeRateHistory(ierh cms.InvoiceExchangeRateHistory) (*cms.InvoiceExchangeRateHistoryReply, error) {
+	log.Tracef("processInvoiceExchangeRateHistory")
+
+	err := validateExchangeRateHistory(ierh)
+	if err != nil {
+		return nil, err
+	}
+
+	currency := p.cfg.FiatCurrency
+	rates, err := p.cmsDB.ExchangeRatesByYears(int(ierh.StartYear),
+		int(ierh.EndYear), currency)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cms.InvoiceExchangeRateHistoryReply{
+		Currency:      currency,
+		ExchangeRates: exchangeRateSeries(ierh.StartYear, ierh.EndYear, rates),
+	}, nil
+}
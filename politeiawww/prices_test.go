@@ -0,0 +1,502 @@
+// Copyright (c) 2017-2019 The Fonero developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	cms "github.com/fonero-project/politeia/politeiawww/api/cms/v1"
+	database "github.com/fonero-project/politeia/politeiawww/cmsdatabase"
+)
+
+// testExchange returns the passed in price exchange with its API URL replaced
+// by the passed in test server URL.
+func testExchange(name, url string) priceExchange {
+	e := priceExchanges[name]
+	e.url = url
+	return e
+}
+
+func TestGetPrices(t *testing.T) {
+	// Exchange that is behind a challenge page
+	html := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/html; charset=UTF-8")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("<!DOCTYPE html><html><body>Checking your " +
+				"browser before accessing poloniex.com</body></html>"))
+		}))
+	defer html.Close()
+
+	// Exchange that returns valid chart data
+	valid := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`[{"date":1,"weightedAverage":0.5},` +
+				`{"date":2,"weightedAverage":1.5}]`))
+		}))
+	defer valid.Close()
+
+	// Exchange that returns an empty dataset
+	empty := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`[]`))
+		}))
+	defer empty.Close()
+
+	var tests = []struct {
+		name      string
+		url       string
+		wantCount int
+		wantErr   error
+	}{
+		{"html response", html.URL, 0, errExchangeNonJSON},
+		{"valid response", valid.URL, 2, nil},
+		{"empty response", empty.URL, 0, nil},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			prices, err := getPrices(testExchange(exchangePoloniex,
+				v.url), nil, "BTC_FNO", 0, 1)
+			if err != v.wantErr {
+				t.Fatalf("got error %v, want %v",
+					errToStr(err), errToStr(v.wantErr))
+			}
+			if len(prices) != v.wantCount {
+				t.Fatalf("got %v prices, want %v",
+					len(prices), v.wantCount)
+			}
+		})
+	}
+}
+
+func TestGetPricesHeaders(t *testing.T) {
+	// Exchange that records the headers of the last request
+	var got http.Header
+	exchange := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			got = r.Header
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`[]`))
+		}))
+	defer exchange.Close()
+
+	var tests = []struct {
+		name      string
+		headers   http.Header
+		userAgent string
+		apiKey    string
+	}{
+		{"no headers", nil, exchangeUserAgent(), ""},
+		{"api key", http.Header{"X-Api-Key": {"secret"}},
+			exchangeUserAgent(), "secret"},
+		{"user agent override", http.Header{"User-Agent": {"custom"}},
+			"custom", ""},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			_, err := getPrices(testExchange(exchangePoloniex,
+				exchange.URL), v.headers, "BTC_FNO", 0, 1)
+			if err != nil {
+				t.Fatalf("getPrices: %v", err)
+			}
+			if ua := got.Get("User-Agent"); ua != v.userAgent {
+				t.Fatalf("got User-Agent %q, want %q", ua, v.userAgent)
+			}
+			if key := got.Get("X-Api-Key"); key != v.apiKey {
+				t.Fatalf("got X-Api-Key %q, want %q", key, v.apiKey)
+			}
+		})
+	}
+}
+
+func TestGetPricesTruncated(t *testing.T) {
+	// Exchange that holds one candle per period starting at the
+	// unix epoch and caps every response to maxCandles candles
+	const maxCandles = 3
+	var requests int
+	exchange := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			q := r.URL.Query()
+			start, _ := strconv.ParseInt(q.Get("start"), 10, 64)
+			end, _ := strconv.ParseInt(q.Get("end"), 10, 64)
+			var chartData []poloChartData
+			for d := start - start%pricePeriod; d <= end; d += pricePeriod {
+				if d < start {
+					continue
+				}
+				if len(chartData) == maxCandles {
+					break
+				}
+				chartData = append(chartData, poloChartData{
+					Date:            uint64(d),
+					WeightedAverage: float64(d),
+				})
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(chartData)
+		}))
+	defer exchange.Close()
+
+	var tests = []struct {
+		name         string
+		end          int64
+		wantCount    int
+		wantRequests int
+	}{
+		{"under cap", pricePeriod, 2, 1},
+		{"exactly cap", 2 * pricePeriod, 3, 1},
+		{"several pages", 9 * pricePeriod, 10, 4},
+		{"partial candle", 10*pricePeriod - 1, 10, 4},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			requests = 0
+			prices, err := getPrices(testExchange(exchangePoloniex,
+				exchange.URL), nil, "BTC_FNO", 0, v.end)
+			if err != nil {
+				t.Fatalf("getPrices: %v", err)
+			}
+			if len(prices) != v.wantCount {
+				t.Fatalf("got %v prices, want %v",
+					len(prices), v.wantCount)
+			}
+			for i := 0; i < v.wantCount; i++ {
+				d := uint64(i * pricePeriod)
+				if p, ok := prices[d]; !ok || p != float64(d) {
+					t.Fatalf("missing price at %v", d)
+				}
+			}
+			if requests != v.wantRequests {
+				t.Fatalf("got %v requests, want %v",
+					requests, v.wantRequests)
+			}
+		})
+	}
+}
+
+func TestPriceDecoders(t *testing.T) {
+	// Both fixtures contain the same candles. The kline volume
+	// weighted average is the quote volume divided by the volume
+	// and the close price is used for a candle without volume.
+	want := map[uint64]float64{
+		1546300800: 0.5,
+		1546301700: 1.5,
+		1546302600: 2,
+	}
+
+	var tests = []struct {
+		name     string
+		exchange string
+		fixture  string
+		wantErr  bool
+	}{
+		{"poloniex", exchangePoloniex,
+			`[{"date":1546300800,"weightedAverage":0.5},` +
+				`{"date":1546301700,"weightedAverage":1.5},` +
+				`{"date":1546302600,"weightedAverage":2}]`, false},
+		{"kline", exchangeBinance,
+			`[[1546300800000,"0.4","0.6","0.4","0.6","10",` +
+				`1546301699999,"5",3,"0","0","0"],` +
+				`[1546301700000,"1.4","1.6","1.4","1.6","4",` +
+				`1546302599999,"6",2,"0","0","0"],` +
+				`[1546302600000,"2","2","2","2","0",` +
+				`1546303499999,"0",0,"0","0","0"]]`, false},
+		{"kline missing fields", exchangeBinance,
+			`[[1546300800000,"0.4","0.6","0.4","0.6"]]`, true},
+		{"kline invalid price", exchangeBinance,
+			`[[1546300800000,"0.4","0.6","0.4","x","10",` +
+				`1546301699999,"5",3,"0","0","0"]]`, true},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			decode := priceExchanges[v.exchange].decode
+			got, err := decode(strings.NewReader(v.fixture))
+			if (err != nil) != v.wantErr {
+				t.Fatalf("got error %v, want error %v",
+					err, v.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestPricesQuery(t *testing.T) {
+	var tests = []struct {
+		name     string
+		exchange string
+		want     url.Values
+	}{
+		{"poloniex", exchangePoloniex, url.Values{
+			"command":      {"returnChartData"},
+			"currencyPair": {"BTC_FNO"},
+			"start":        {"1546300800"},
+			"end":          {"1548979200"},
+			"period":       {"900"},
+		}},
+		{"binance", exchangeBinance, url.Values{
+			"symbol":    {"BTC_FNO"},
+			"interval":  {"15m"},
+			"startTime": {"1546300800000"},
+			"endTime":   {"1548979200000"},
+		}},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			query := priceExchanges[v.exchange].query
+			got := query("BTC_FNO", 1546300800, 1548979200)
+			if !reflect.DeepEqual(got, v.want) {
+				t.Fatalf("got query %v, want %v", got, v.want)
+			}
+		})
+	}
+}
+
+func TestParseExchangeHeaders(t *testing.T) {
+	var tests = []struct {
+		name    string
+		headers []string
+		want    http.Header
+		wantErr bool
+	}{
+		{"none", nil, http.Header{}, false},
+		{"single", []string{"x-api-key: secret"},
+			http.Header{"X-Api-Key": {"secret"}}, false},
+		{"value with colon", []string{"Authorization:Basic a:b"},
+			http.Header{"Authorization": {"Basic a:b"}}, false},
+		{"repeated", []string{"Accept:a", "Accept:b"},
+			http.Header{"Accept": {"a", "b"}}, false},
+		{"missing separator", []string{"x-api-key"}, nil, true},
+		{"missing name", []string{":secret"}, nil, true},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			h, err := parseExchangeHeaders(v.headers)
+			if (err != nil) != v.wantErr {
+				t.Fatalf("got error %v, want error %v",
+					err, v.wantErr)
+			}
+			if !reflect.DeepEqual(h, v.want) {
+				t.Fatalf("got headers %v, want %v", h, v.want)
+			}
+		})
+	}
+}
+
+func TestGetMonthAverage(t *testing.T) {
+	// kline returns a kline fixture of a single candle without volume
+	// so that the close price is used.
+	kline := func(openTime int64, price string) string {
+		return fmt.Sprintf(`[%v,"0","0","0","%v","0",0,"0"]`,
+			openTime*1000, price)
+	}
+
+	// Chart data of each currency pair of each exchange.  The GBP
+	// token pair is missing the second data point so only the first
+	// is used.
+	charts := map[string]string{
+		"BTC_FNO": `[{"date":1,"weightedAverage":0.001},` +
+			`{"date":2,"weightedAverage":0.002}]`,
+		"USDT_BTC": `[{"date":1,"weightedAverage":10000},` +
+			`{"date":2,"weightedAverage":5000}]`,
+		"USDT_EURT": `[{"date":1,"weightedAverage":1.25},` +
+			`{"date":2,"weightedAverage":1.25}]`,
+		"USDT_GBPT": `[{"date":1,"weightedAverage":1.6}]`,
+		"FNOBTC": "[" + kline(1, "0.001") + "," +
+			kline(2, "0.002") + "]",
+		"BTCUSDT": "[" + kline(1, "10000") + "," +
+			kline(2, "5000") + "]",
+		"EURUSDT": "[" + kline(1, "1.25") + "," +
+			kline(2, "1.25") + "]",
+		"GBPUSDT": "[" + kline(1, "1.6") + "]",
+	}
+	exchange := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			q := r.URL.Query()
+			pairing := q.Get("currencyPair")
+			if pairing == "" {
+				pairing = q.Get("symbol")
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(charts[pairing]))
+		}))
+	defer exchange.Close()
+
+	// Exchange that returns an empty dataset
+	empty := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`[]`))
+		}))
+	defer empty.Close()
+
+	var tests = []struct {
+		name     string
+		url      string
+		currency string
+		want     uint
+		wantErr  bool
+	}{
+		{"usd", exchange.URL, fiatUSD, 1000, false},
+		{"eur", exchange.URL, fiatEUR, 800, false},
+		{"gbp partial data", exchange.URL, fiatGBP, 625, false},
+		{"unsupported currency", exchange.URL, "JPY", 0, true},
+		{"no price data", empty.URL, fiatEUR, 0, true},
+	}
+
+	for _, name := range []string{exchangePoloniex, exchangeBinance} {
+		for _, v := range tests {
+			t.Run(name+" "+v.name, func(t *testing.T) {
+				avg, err := getMonthAverage(testExchange(name, v.url),
+					nil, v.currency, roundingModeRound, time.January,
+					2019)
+				if (err != nil) != v.wantErr {
+					t.Fatalf("got error %v, want error %v",
+						err, v.wantErr)
+				}
+				if avg != v.want {
+					t.Fatalf("got average %v, want %v", avg, v.want)
+				}
+			})
+		}
+	}
+}
+
+func TestToCents(t *testing.T) {
+	// The prices fall exactly on a half cent
+	var tests = []struct {
+		name     string
+		price    float64
+		rounding string
+		want     uint
+		wantErr  bool
+	}{
+		{"round", 0.125, roundingModeRound, 13, false},
+		{"floor", 0.125, roundingModeFloor, 12, false},
+		{"ceil", 0.125, roundingModeCeil, 13, false},
+		{"bankers even", 0.125, roundingModeBankers, 12, false},
+		{"bankers odd", 0.135, roundingModeBankers, 14, false},
+		{"unsupported", 0.125, "truncate", 0, true},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			cents, err := toCents(v.price, v.rounding)
+			if (err != nil) != v.wantErr {
+				t.Fatalf("got error %v, want error %v",
+					err, v.wantErr)
+			}
+			if cents != v.want {
+				t.Fatalf("got %v cents, want %v", cents, v.want)
+			}
+		})
+	}
+}
+
+func TestExchangeRateSeries(t *testing.T) {
+	// Stored exchange rates are not ordered and skip months
+	rates := []database.ExchangeRate{
+		{Month: 3, Year: 2019, ExchangeRate: 1900},
+		{Month: 1, Year: 2018, ExchangeRate: 1500},
+		{Month: 12, Year: 2018, ExchangeRate: 1700},
+		{Month: 2, Year: 2018, ExchangeRate: 1600},
+	}
+
+	series := exchangeRateSeries(2018, 2019, rates)
+	if len(series) != 24 {
+		t.Fatalf("got %v months, want 24", len(series))
+	}
+
+	// Every month must be present in order
+	for i, v := range series {
+		wantYear := uint(2018 + i/12)
+		wantMonth := uint(i%12 + 1)
+		if v.Year != wantYear || v.Month != wantMonth {
+			t.Fatalf("got %v/%v at index %v, want %v/%v",
+				v.Month, v.Year, i, wantMonth, wantYear)
+		}
+	}
+
+	var tests = []struct {
+		name  string
+		index int
+		want  cms.MonthlyExchangeRate
+	}{
+		{"first stored", 0, cms.MonthlyExchangeRate{
+			Month: 1, Year: 2018, ExchangeRate: 1500}},
+		{"second stored", 1, cms.MonthlyExchangeRate{
+			Month: 2, Year: 2018, ExchangeRate: 1600}},
+		{"gap", 2, cms.MonthlyExchangeRate{
+			Month: 3, Year: 2018, Missing: true}},
+		{"end of year", 11, cms.MonthlyExchangeRate{
+			Month: 12, Year: 2018, ExchangeRate: 1700}},
+		{"gap across years", 12, cms.MonthlyExchangeRate{
+			Month: 1, Year: 2019, Missing: true}},
+		{"second year", 14, cms.MonthlyExchangeRate{
+			Month: 3, Year: 2019, ExchangeRate: 1900}},
+		{"never fetched", 23, cms.MonthlyExchangeRate{
+			Month: 12, Year: 2019, Missing: true}},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			got := series[v.index]
+			if got != v.want {
+				t.Fatalf("got %v, want %v", got, v.want)
+			}
+		})
+	}
+}
+
+func TestValidateExchangeRateHistory(t *testing.T) {
+	var tests = []struct {
+		name      string
+		startYear uint
+		endYear   uint
+		wantErr   bool
+	}{
+		{"single year", 2019, 2019, false},
+		{"range", 2010, 2019, false},
+		{"missing start year", 0, 2019, true},
+		{"reversed", 2019, 2018, true},
+		{"too many years", 2009, 2019, true},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			err := validateExchangeRateHistory(
+				cms.InvoiceExchangeRateHistory{
+					StartYear: v.startYear,
+					EndYear:   v.endYear,
+				})
+			if (err != nil) != v.wantErr {
+				t.Fatalf("got error %v, want error %v",
+					err, v.wantErr)
+			}
+		})
+	}
+}
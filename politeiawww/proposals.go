@@ -5,6 +5,7 @@
 package main
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/hex"
@@ -471,17 +472,18 @@ func (p *politeiawww) getAllProps() ([]www.ProposalRecord, error) {
 	}
 
 	// Fill in the number of comments for each proposal
+	tokens := make([]string, 0, len(records))
+	for _, v := range records {
+		tokens = append(tokens, v.CensorshipRecord.Token)
+	}
+	counts, err := p.foneroCommentCounts(tokens)
+	if err != nil {
+		log.Errorf("getAllProps: foneroCommentCounts failed: %v", err)
+	}
 	props := make([]www.ProposalRecord, 0, len(records))
 	for _, v := range records {
 		pr := convertPropFromCache(v)
-
-		dc, err := p.foneroGetComments(pr.CensorshipRecord.Token)
-		if err != nil {
-			log.Errorf("getAllProps: foneroGetComments failed "+
-				"for token %v", pr.CensorshipRecord.Token)
-		}
-		pr.NumComments = uint(len(dc))
-
+		pr.NumComments = counts[pr.CensorshipRecord.Token]
 		props = append(props, pr)
 	}
 
@@ -513,6 +515,63 @@ func (p *politeiawww) getAllProps() ([]www.ProposalRecord, error) {
 	return props, nil
 }
 
+// getProps gets a batch of proposals from the cache using a single query then
+// fills in any missing fields before returning the proposals.  The latest
+// version of each proposal is returned unless a specific version is requested
+// for its token.  The returned map is keyed by token and does not contain
+// entries for proposals that were not found.
+func (p *politeiawww) getProps(tokens []string, versions map[string]string) (map[string]www.ProposalRecord, error) {
+	log.Tracef("getProps: %v", tokens)
+
+	// Get proposals from cache
+	records, err := p.cache.Records(tokens, versions)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fill in the number of comments for each proposal
+	found := make([]string, 0, len(records))
+	for token := range records {
+		found = append(found, token)
+	}
+	counts, err := p.foneroCommentCounts(found)
+	if err != nil {
+		log.Errorf("getProps: foneroCommentCounts failed: %v", err)
+	}
+	props := make(map[string]www.ProposalRecord, len(records)) // [token]Proposal
+	for token, v := range records {
+		pr := convertPropFromCache(v)
+		pr.NumComments = counts[token]
+		props[token] = pr
+	}
+
+	p.RLock()
+	defer p.RUnlock()
+
+	// Fill in author info for each proposal. Cache usernames to
+	// prevent duplicate database lookups.
+	usernames := make(map[string]string, len(props)) // [userID]username
+	for token, pr := range props {
+		userID, ok := p.userPubkeys[pr.PublicKey]
+		if !ok {
+			log.Errorf("getProps: userID lookup failed for "+
+				"token:%v pubkey:%v", token, pr.PublicKey)
+		}
+		pr.UserId = userID
+
+		u, ok := usernames[userID]
+		if !ok {
+			u = p.getUsernameById(userID)
+			usernames[userID] = u
+		}
+		pr.Username = u
+
+		props[token] = pr
+	}
+
+	return props, nil
+}
+
 // filterProps filters the given proposals according to the filtering
 // parameters specified by the passed in proposalsFilter.  filterProps will
 // only return a single page of proposals regardless of how many proposals are
@@ -652,12 +711,12 @@ func (p *politeiawww) getUserProps(filter proposalsFilter) ([]www.ProposalRecord
 	return filtered, &ps, nil
 }
 
-func (p *politeiawww) getPropComments(token string) ([]www.Comment, error) {
-	log.Tracef("getPropComments: %v", token)
+func (p *politeiawww) getPropComments(token string, includeHidden bool) ([]www.Comment, error) {
+	log.Tracef("getPropComments: %v %v", token, includeHidden)
 
-	dc, err := p.foneroGetComments(token)
+	dc, err := p.foneroGetCommentsPage(token, 0, 0, includeHidden)
 	if err != nil {
-		return nil, fmt.Errorf("foneroGetComments: %v", err)
+		return nil, fmt.Errorf("foneroGetCommentsPage: %v", err)
 	}
 
 	p.RLock()
@@ -1318,12 +1377,14 @@ func (p *politeiawww) processProposalsStats() (*www.ProposalsStatsReply, error)
 
 // processCommentsGet returns all comments for a given proposal. If the user is
 // logged in the user's last access time for the given comments will also be
-// returned.
+// returned. Hidden comments are only returned when the user is an admin.
 func (p *politeiawww) processCommentsGet(token string, u *user.User) (*www.GetCommentsReply, error) {
 	log.Tracef("ProcessCommentGet: %v", token)
 
-	// Fetch proposal comments from cache
-	c, err := p.getPropComments(token)
+	// Fetch proposal comments from cache. Hidden comments are
+	// only returned to admins.
+	includeHidden := u != nil && u.Admin
+	c, err := p.getPropComments(token, includeHidden)
 	if err != nil {
 		return nil, err
 	}
@@ -1509,20 +1570,31 @@ func (p *politeiawww) processActiveVote() (*www.ActiveVoteReply, error) {
 		return nil, err
 	}
 
-	// Get all proposals from cache
-	all, err := p.getAllProps()
+	// Get the proposals with an active voting period from the
+	// cache using a single batch fetch
+	ti, err := p.processTokenInventory()
 	if err != nil {
-		return nil, fmt.Errorf("getAllProps: %v", err)
+		return nil, fmt.Errorf("processTokenInventory: %v", err)
+	}
+	props, err := p.getProps(ti.Active, nil)
+	if err != nil {
+		return nil, fmt.Errorf("getProps: %v", err)
 	}
 
 	// Compile proposal vote tuples
-	pvt := make([]www.ProposalVoteTuple, 0, len(all))
-	for _, v := range all {
+	pvt := make([]www.ProposalVoteTuple, 0, len(ti.Active))
+	for _, token := range ti.Active {
+		v, ok := props[token]
+		if !ok {
+			log.Errorf("processActiveVote: proposal not found %v", token)
+			continue
+		}
+
 		// Get vote details from cache
-		vdr, err := p.foneroVoteDetails(v.CensorshipRecord.Token)
+		vdr, err := p.foneroVoteDetails(token)
 		if err != nil {
 			log.Errorf("processActiveVote: foneroVoteDetails failed %v: %v",
-				v.CensorshipRecord.Token, err)
+				token, err)
 			continue
 		}
 		vd := convertVoteDetailsReplyFromFonero(*vdr)
@@ -1574,7 +1646,7 @@ func (p *politeiawww) processVoteResults(token string) (*www.VoteResultsReply, e
 	}
 
 	// Get cast votes from cache
-	vrr, err := p.foneroProposalVotes(token)
+	vrr, err := p.foneroProposalVotes(token, false, 0, 0)
 	if err != nil {
 		return nil, fmt.Errorf("foneroVoteDetails: %v", err)
 	}
@@ -1586,6 +1658,74 @@ func (p *politeiawww) processVoteResults(token string) (*www.VoteResultsReply, e
 	}, nil
 }
 
+// processVoteExport returns the full vote lifecycle of a specific proposal.
+func (p *politeiawww) processVoteExport(token string) (*www.VoteExportReply, error) {
+	log.Tracef("processVoteExport: %v", token)
+
+	// Ensure proposal is vetted
+	pr, err := p.getProp(token)
+	if err != nil {
+		if err == cache.ErrRecordNotFound {
+			err = www.UserError{
+				ErrorCode: www.ErrorStatusProposalNotFound,
+			}
+		}
+		return nil, err
+	}
+
+	if pr.State != www.PropStateVetted {
+		return nil, www.UserError{
+			ErrorCode: www.ErrorStatusWrongStatus,
+		}
+	}
+
+	// Get vote export from cache
+	ver, err := p.foneroVoteExport(token)
+	if err != nil {
+		return nil, fmt.Errorf("foneroVoteExport: %v", err)
+	}
+
+	r := convertVoteExportReplyFromFonero(*ver)
+	return &r, nil
+}
+
+// processProposalVotesCSV writes the cast votes of a specific proposal to the
+// passed in response writer as CSV.  Errors that occur once the CSV is being
+// streamed are only logged since the reply status has already been sent.
+func (p *politeiawww) processProposalVotesCSV(w http.ResponseWriter, token string) error {
+	log.Tracef("processProposalVotesCSV: %v", token)
+
+	// Ensure proposal is vetted
+	pr, err := p.getProp(token)
+	if err != nil {
+		if err == cache.ErrRecordNotFound {
+			err = www.UserError{
+				ErrorCode: www.ErrorStatusProposalNotFound,
+			}
+		}
+		return err
+	}
+
+	if pr.State != www.PropStateVetted {
+		return www.UserError{
+			ErrorCode: www.ErrorStatusWrongStatus,
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition",
+		fmt.Sprintf("attachment; filename=%v.csv", token))
+	err = p.foneroStreamProposalVotesCSV(w, token)
+	if err != nil {
+		// The CSV header has already been written to the client
+		// at this point so the error can only be logged.
+		log.Errorf("processProposalVotesCSV: foneroStreamProposalVotesCSV "+
+			"%v: %v", token, err)
+	}
+
+	return nil
+}
+
 // processCastVotes handles the www.Ballot call
 func (p *politeiawww) processCastVotes(ballot *www.Ballot) (*www.BallotReply, error) {
 	log.Tracef("processCastVotes")
@@ -1985,12 +2125,12 @@ func (p *politeiawww) processTokenInventory() (*www.TokenInventoryReply, error)
 	var done bool
 	var r www.TokenInventoryReply
 	for retries := 0; !done && retries <= 1; retries++ {
-		ti, err := p.foneroTokenInventory(bb)
+		ti, err := p.foneroTokenInventory(bb, false)
 		if err != nil {
 			if err == cache.ErrRecordNotFound {
 				// There are missing entries in the vote
 				// results cache table. Load them.
-				_, err := p.foneroLoadVoteResults(bb)
+				err := p.foneroLoadVoteResults(context.Background(), bb)
 				if err != nil {
 					return nil, err
 				}
@@ -14,6 +14,7 @@ import (
 	"image/color"
 	"image/png"
 	"math/rand"
+	"reflect"
 	"strconv"
 	"testing"
 	"time"
@@ -23,6 +24,7 @@ import (
 	pd "github.com/fonero-project/politeia/politeiad/api/v1"
 	"github.com/fonero-project/politeia/politeiad/api/v1/identity"
 	"github.com/fonero-project/politeia/politeiad/api/v1/mime"
+	"github.com/fonero-project/politeia/politeiad/cache"
 	"github.com/fonero-project/politeia/politeiad/testpoliteiad"
 	www "github.com/fonero-project/politeia/politeiawww/api/www/v1"
 	"github.com/fonero-project/politeia/politeiawww/user"
@@ -1107,3 +1109,244 @@ func TestProcessSetProposalStatus(t *testing.T) {
 		})
 	}
 }
+
+func TestProcessVoteExport(t *testing.T) {
+	// Setup test environment
+	p, cleanup := newTestPoliteiawww(t)
+	defer cleanup()
+
+	d := newTestPoliteiad(t, p)
+	defer d.Close()
+
+	// pluginExec executes a fonero plugin command in the cache
+	pluginExec := func(cmd, payload, reply string) {
+		t.Helper()
+
+		_, err := p.cache.PluginExec(cache.PluginCommand{
+			ID:             foneroplugin.ID,
+			Command:        cmd,
+			CommandPayload: payload,
+			ReplyPayload:   reply,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Create a proposal that has finished voting
+	admin, id := newUser(t, p, true, true)
+	prop := newProposalRecord(t, admin, id, www.PropStatusPublic)
+	token := prop.CensorshipRecord.Token
+	d.AddRecord(t, convertPropToPD(t, prop))
+
+	cmd := newAuthorizeVoteCmd(t, token, prop.Version,
+		www.AuthVoteActionAuthorize, id)
+	d.Plugin(t, cmd)
+
+	tickets := []string{"ticket1", "ticket2", "ticket3", "ticket4"}
+	sv, err := foneroplugin.EncodeStartVote(
+		convertStartVoteFromWWW(newStartVote(t, token, id)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	svr, err := foneroplugin.EncodeStartVoteReply(
+		foneroplugin.StartVoteReply{
+			StartBlockHeight: "100",
+			EndHeight:        "200",
+			EligibleTickets:  tickets,
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pluginExec(foneroplugin.CmdStartVote, string(sv), string(svr))
+
+	votes := []foneroplugin.CastVote{
+		{Token: token, Ticket: tickets[0], VoteBit: "2"},
+		{Token: token, Ticket: tickets[1], VoteBit: "2"},
+		{Token: token, Ticket: tickets[2], VoteBit: "2"},
+		{Token: token, Ticket: tickets[3], VoteBit: "1"},
+	}
+	b, err := foneroplugin.EncodeBallot(foneroplugin.Ballot{
+		Votes: votes,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pluginExec(foneroplugin.CmdBallot, string(b), "")
+
+	lvr, err := foneroplugin.EncodeLoadVoteResults(
+		foneroplugin.LoadVoteResults{
+			BestBlock: 200,
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pluginExec(foneroplugin.CmdLoadVoteResults, string(lvr), "")
+
+	// Create a proposal that has not been made public
+	propUnvetted := newProposalRecord(t, admin, id,
+		www.PropStatusNotReviewed)
+	d.AddRecord(t, convertPropToPD(t, propUnvetted))
+
+	// Check the errors
+	var tests = []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"proposal not found", "abc",
+			www.UserError{
+				ErrorCode: www.ErrorStatusProposalNotFound,
+			}},
+		{"proposal not vetted", propUnvetted.CensorshipRecord.Token,
+			www.UserError{
+				ErrorCode: www.ErrorStatusWrongStatus,
+			}},
+		{"success", token, nil},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			_, err := p.processVoteExport(v.token)
+			got := errToStr(err)
+			want := errToStr(v.want)
+			if got != want {
+				t.Errorf("got error %v, want %v", got, want)
+			}
+		})
+	}
+
+	// Check that the export contains all sections
+	ver, err := p.processVoteExport(token)
+	if err != nil {
+		t.Fatalf("processVoteExport: %v", err)
+	}
+	if ver.AuthorizeVote.Action != www.AuthVoteActionAuthorize ||
+		ver.AuthorizeVote.Token != token {
+		t.Errorf("got authorize vote %v, want authorized %v",
+			ver.AuthorizeVote, token)
+	}
+	if ver.AuthorizeVoteReply.Receipt == "" {
+		t.Errorf("authorize vote receipt missing")
+	}
+	if ver.StartVote.Vote.Token != token {
+		t.Errorf("got start vote token %v, want %v",
+			ver.StartVote.Vote.Token, token)
+	}
+	if ver.StartVoteReply.EndHeight != "200" ||
+		len(ver.StartVoteReply.EligibleTickets) != len(tickets) {
+		t.Errorf("got start vote reply %v, want end height 200 and "+
+			"%v eligible tickets", ver.StartVoteReply, len(tickets))
+	}
+	if len(ver.CastVotes) != len(votes) {
+		t.Errorf("got %v cast votes, want %v",
+			len(ver.CastVotes), len(votes))
+	}
+	if !ver.Final || !ver.Approved {
+		t.Errorf("got final %v approved %v, want final and approved",
+			ver.Final, ver.Approved)
+	}
+	received := make(map[string]uint64, len(ver.OptionsResult))
+	for _, v := range ver.OptionsResult {
+		received[v.Option.Id] = v.VotesReceived
+	}
+	if received["yes"] != 3 || received["no"] != 1 {
+		t.Errorf("got options result %v, want yes 3 no 1", received)
+	}
+}
+
+func TestGetProps(t *testing.T) {
+	p, cleanup := newTestPoliteiawww(t)
+	defer cleanup()
+
+	// newRecord adds a record to the cache.
+	newRecord := func(token, version string) {
+		err := p.cache.NewRecord(cache.Record{
+			Version: version,
+			Status:  cache.RecordStatusPublic,
+			CensorshipRecord: cache.CensorshipRecord{
+				Token: token,
+			},
+		})
+		if err != nil {
+			t.Fatalf("new record: %v", err)
+		}
+	}
+
+	newRecord("a", "1")
+	newRecord("b", "1")
+	newRecord("b", "2")
+	newRecord("b", "3")
+	newRecord("c", "1")
+
+	// Proposal b has two comments
+	for _, commentID := range []string{"1", "2"} {
+		nc, err := foneroplugin.EncodeNewComment(foneroplugin.NewComment{
+			Token:    "b",
+			ParentID: "0",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ncr, err := foneroplugin.EncodeNewCommentReply(
+			foneroplugin.NewCommentReply{
+				CommentID: commentID,
+			})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = p.cache.PluginExec(cache.PluginCommand{
+			ID:             foneroplugin.ID,
+			Command:        foneroplugin.CmdNewComment,
+			CommandPayload: string(nc),
+			ReplyPayload:   string(ncr),
+		})
+		if err != nil {
+			t.Fatalf("%v: %v", foneroplugin.CmdNewComment, err)
+		}
+	}
+	wantComments := map[string]uint{"b": 2}
+
+	var tests = []struct {
+		name     string
+		tokens   []string
+		versions map[string]string
+		want     map[string]string // [token]version
+	}{
+		{"latest versions", []string{"a", "b", "c"}, nil,
+			map[string]string{"a": "1", "b": "3", "c": "1"}},
+		{"unknown tokens", []string{"a", "x", "b", "y"}, nil,
+			map[string]string{"a": "1", "b": "3"}},
+		{"specific version", []string{"a", "b"},
+			map[string]string{"b": "2"},
+			map[string]string{"a": "1", "b": "2"}},
+		{"unknown version", []string{"a", "b"},
+			map[string]string{"b": "4"},
+			map[string]string{"a": "1"}},
+		{"no tokens", []string{}, nil, map[string]string{}},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			props, err := p.getProps(v.tokens, v.versions)
+			if err != nil {
+				t.Fatalf("getProps: %v", err)
+			}
+			got := make(map[string]string, len(props))
+			for token, pr := range props {
+				if pr.CensorshipRecord.Token != token {
+					t.Fatalf("got token %v, want %v",
+						pr.CensorshipRecord.Token, token)
+				}
+				if pr.NumComments != wantComments[token] {
+					t.Fatalf("%v: got %v comments, want %v", token,
+						pr.NumComments, wantComments[token])
+				}
+				got[token] = pr.Version
+			}
+			if !reflect.DeepEqual(got, v.want) {
+				t.Fatalf("got versions %v, want %v", got, v.want)
+			}
+		})
+	}
+}
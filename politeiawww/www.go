@@ -7,6 +7,7 @@ package main
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"crypto/elliptic"
 	"crypto/tls"
 	_ "encoding/gob"
@@ -199,6 +200,12 @@ func (p *politeiawww) addRoute(method string, route string, handler http.Handler
 //
 // XXX doesn't belong in this file but stuff it here for now.
 func (p *politeiawww) makeRequest(method string, route string, v interface{}) ([]byte, error) {
+	return p.makeRequestContext(context.Background(), method, route, v)
+}
+
+// makeRequestContext makes a politeiad request that is aborted when the passed
+// in context is cancelled or its deadline expires.
+func (p *politeiawww) makeRequestContext(ctx context.Context, method string, route string, v interface{}) ([]byte, error) {
 	var (
 		requestBody []byte
 		err         error
@@ -224,6 +231,7 @@ func (p *politeiawww) makeRequest(method string, route string, v interface{}) ([
 	if err != nil {
 		return nil, err
 	}
+	req = req.WithContext(ctx)
 	req.SetBasicAuth(p.cfg.RPCUser, p.cfg.RPCPass)
 	r, err := p.client.Do(req)
 	if err != nil {
@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
+	"math/rand"
 	"net/http"
 	"net/url"
 	"regexp"
@@ -35,6 +36,13 @@ const (
 	faucetTimeout  = 5 * time.Second // Testnet faucet request timeout
 )
 
+var (
+	// faucetRetryDelay is the delay before the first testnet faucet retry.
+	// The delay is doubled after each retry up to faucetRetryMaxDelay.
+	faucetRetryDelay    = time.Second
+	faucetRetryMaxDelay = 30 * time.Second
+)
+
 // FaucetResponse represents the expected JSON response from the testnet faucet.
 type FaucetResponse struct {
 	Txid  string
@@ -294,13 +302,56 @@ func DerivePaywallAddress(params *chaincfg.Params, xpub string, index uint32) (s
 
 // PayWithTestnetFaucet makes a request to the testnet faucet.
 func PayWithTestnetFaucet(faucetURL string, address string, amount uint64, overridetoken string) (string, error) {
+	txID, _, err := payWithTestnetFaucet(faucetURL, address, amount,
+		overridetoken)
+	return txID, err
+}
+
+// PayWithTestnetFaucetRetry makes a request to the testnet faucet and retries
+// the request up to the provided number of times when the faucet returns a
+// transient error, such as a rate limit or server error.  The delay between
+// retries is increased exponentially and is jittered.
+func PayWithTestnetFaucetRetry(faucetURL string, address string, amount uint64, overridetoken string, retries int) (string, error) {
+	delay := faucetRetryDelay
+	for attempt := 0; ; attempt++ {
+		txID, transient, err := payWithTestnetFaucet(faucetURL, address,
+			amount, overridetoken)
+		if err == nil {
+			return txID, nil
+		}
+		if !transient {
+			return "", err
+		}
+		if attempt >= retries {
+			return "", fmt.Errorf("testnet faucet failed after %v "+
+				"attempts: %v", attempt+1, err)
+		}
+
+		// Wait a random duration between half of the delay and
+		// the full delay before retrying.
+		wait := delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+		log.Printf("testnet faucet request failed, retrying in %v: %v",
+			wait, err)
+		time.Sleep(wait)
+
+		delay *= 2
+		if delay > faucetRetryMaxDelay {
+			delay = faucetRetryMaxDelay
+		}
+	}
+}
+
+// payWithTestnetFaucet makes a single request to the testnet faucet.  The
+// returned bool indicates whether the returned error is transient and the
+// request may succeed if it is retried.
+func payWithTestnetFaucet(faucetURL string, address string, amount uint64, overridetoken string) (string, bool, error) {
 	fnoaddress, err := fnoutil.DecodeAddress(address)
 	if err != nil {
-		return "", fmt.Errorf("address is invalid: %v", err)
+		return "", false, fmt.Errorf("address is invalid: %v", err)
 	}
 
 	if !fnoaddress.IsForNet(&chaincfg.TestNetParams) {
-		return "", fmt.Errorf("faucet only supports testnet")
+		return "", false, fmt.Errorf("faucet only supports testnet")
 	}
 
 	fnoamount := strconv.FormatFloat(fnoutil.Amount(amount).ToCoin(),
@@ -314,7 +365,7 @@ func PayWithTestnetFaucet(faucetURL string, address string, amount uint64, overr
 
 	req, err := http.NewRequest("POST", faucetURL, strings.NewReader(form.Encode()))
 	if err != nil {
-		return "", err
+		return "", false, err
 	}
 	req.PostForm = form
 	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
@@ -329,40 +380,42 @@ func PayWithTestnetFaucet(faucetURL string, address string, amount uint64, overr
 	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
-		return "", err
+		// Network errors and timeouts are transient
+		return "", true, err
 	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
+		// Rate limit and server errors are transient
+		transient := resp.StatusCode == http.StatusTooManyRequests ||
+			resp.StatusCode >= http.StatusInternalServerError
+
 		body, err := ioutil.ReadAll(resp.Body)
 		if err != nil {
-			return "", fmt.Errorf("testnet faucet error: %v %v %v",
-				resp.StatusCode, faucetURL, err)
+			return "", transient, fmt.Errorf("testnet faucet error: "+
+				"%v %v %v", resp.StatusCode, faucetURL, err)
 		}
-		return "", fmt.Errorf("testnet faucet error: %v %v %s",
+		return "", transient, fmt.Errorf("testnet faucet error: %v %v %s",
 			resp.StatusCode, faucetURL, body)
 	}
 
-	if resp == nil {
-		return "", errors.New("unknown error")
-	}
-
 	jsonReply := resp.Header.Get("X-Json-Reply")
 	if jsonReply == "" {
-		return "", fmt.Errorf("bad reply from %v", faucetURL)
+		return "", false, fmt.Errorf("bad reply from %v", faucetURL)
 	}
 
 	fr := &FaucetResponse{}
 	err = json.Unmarshal([]byte(jsonReply), fr)
 	if err != nil {
-		return "", fmt.Errorf("unable to process reply: '%v': %v", jsonReply,
-			err)
+		return "", false, fmt.Errorf("unable to process reply: '%v': %v",
+			jsonReply, err)
 	}
 
 	if fr.Error != "" {
-		return "", errors.New(fr.Error)
+		return "", false, errors.New(fr.Error)
 	}
 
-	return fr.Txid, nil
+	return fr.Txid, false, nil
 }
 
 // FetchTxWithBlockExplorers uses public block explorers to look for a
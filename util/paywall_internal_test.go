@@ -0,0 +1,87 @@
+// Copyright (c) 2017-2019 The Fonero developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fonero-project/fnod/chaincfg"
+)
+
+const testXpub = "tpubVobLtToNtTq6TZNw4raWQok35PRPZou53vegZqNubtBTJMMFmuMpWybFCfweJ52N8uZJPZZdHE5SRnBBuuRPfC5jdNstfKjiAs8JtbYG9jx"
+
+// newTestFaucet returns a test faucet that replies to each request with the
+// next status code in the provided list.  Requests beyond the end of the list
+// are successful.  The returned counter is incremented on every request.
+func newTestFaucet(statuses []int) (*httptest.Server, *int) {
+	var requests int
+	s := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			i := requests
+			requests++
+			if i < len(statuses) {
+				w.WriteHeader(statuses[i])
+				w.Write([]byte("faucet error"))
+				return
+			}
+			w.Header().Set("X-Json-Reply", `{"Txid":"txid"}`)
+			w.WriteHeader(http.StatusOK)
+		}))
+	return s, &requests
+}
+
+func TestPayWithTestnetFaucetRetry(t *testing.T) {
+	// Don't wait between retries
+	delay := faucetRetryDelay
+	faucetRetryDelay = 0
+	defer func() {
+		faucetRetryDelay = delay
+	}()
+
+	address, err := DerivePaywallAddress(&chaincfg.TestNetParams, testXpub, 0)
+	if err != nil {
+		t.Fatalf("DerivePaywallAddress: %v", err)
+	}
+
+	var tests = []struct {
+		name         string
+		statuses     []int
+		retries      int
+		wantRequests int
+		wantErr      bool
+	}{
+		{"success", nil, 0, 1, false},
+		{"fails twice then succeeds",
+			[]int{http.StatusServiceUnavailable, http.StatusTooManyRequests},
+			3, 3, false},
+		{"retries exhausted",
+			[]int{http.StatusServiceUnavailable, http.StatusBadGateway},
+			1, 2, true},
+		{"non transient error", []int{http.StatusBadRequest}, 3, 1, true},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			faucet, requests := newTestFaucet(v.statuses)
+			defer faucet.Close()
+
+			txID, err := PayWithTestnetFaucetRetry(faucet.URL, address,
+				1e8, "", v.retries)
+			if (err != nil) != v.wantErr {
+				t.Fatalf("got error %v, want error %v",
+					err, v.wantErr)
+			}
+			if *requests != v.wantRequests {
+				t.Fatalf("got %v requests, want %v",
+					*requests, v.wantRequests)
+			}
+			if !v.wantErr && txID != "txid" {
+				t.Fatalf("got txid %v, want txid", txID)
+			}
+		})
+	}
+}